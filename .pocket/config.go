@@ -4,36 +4,84 @@ import (
 	"context"
 
 	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tasks/git"
 	"github.com/fredrikaverpil/pocket/tasks/github"
 	"github.com/fredrikaverpil/pocket/tasks/golang"
+	"github.com/fredrikaverpil/pocket/tasks/license"
 	"github.com/fredrikaverpil/pocket/tasks/markdown"
+	"github.com/fredrikaverpil/pocket/tasks/sarif"
+	"github.com/fredrikaverpil/pocket/tasks/toolversions"
+	"github.com/fredrikaverpil/pocket/tools/golangcilint"
+	"github.com/fredrikaverpil/pocket/tools/mdformat"
 )
 
 // autoRun defines the tasks that run on ./pok with no arguments.
 var autoRun = pocket.Parallel(
-	pocket.RunIn(golang.Tasks(), pocket.Detect(golang.Detect())),
+	// Tool installs are tested per tool by toolTests.
+	pocket.RunIn(golang.Tasks(golang.WithTest(golang.TestOptions{Short: true})), pocket.Detect(golang.Detect())),
 	pocket.RunIn(markdown.Tasks(), pocket.Detect(markdown.Detect())),
-	pocket.WithOpts(github.Workflows, github.WorkflowsOptions{SkipPocket: true, IncludePocketMatrix: true}),
+	license.Header,
+	pocket.WithOpts(github.Workflows, github.WorkflowsOptions{SkipPocket: true}),
 )
 
+// toolTests install and run each managed tool, as separate CI jobs per
+// platform. They run TestTools in ./tools, which go-test skips with -short.
+var toolTests = pocket.Parallel(
+	toolTest("buf"),
+	toolTest("bun"),
+	toolTest("gazelle"),
+	toolTest("golangci-lint"),
+	toolTest("govulncheck"),
+	toolTest("grype"),
+	toolTest("ko"),
+	toolTest("mdformat"),
+	toolTest("nvim"),
+	toolTest("prettier"),
+	toolTest("protoc-gen-connect-go"),
+	toolTest("protoc-gen-go"),
+	toolTest("protoc-gen-go-grpc"),
+	toolTest("stylua"),
+	toolTest("trivy"),
+	toolTest("ts_query_ls"),
+	toolTest("uv"),
+)
+
+// toolTest returns a task that runs the TestTools subtest of a tool.
+func toolTest(name string) *pocket.TaskDef {
+	return pocket.Task("tool-test:"+name, "install and run "+name,
+		pocket.Run("go", "test", "-count=1", "-run", "^TestTools$/^"+name+"$", "./tools"),
+	)
+}
+
 // matrixConfig configures GitHub Actions matrix generation.
 var matrixConfig = github.MatrixConfig{
 	DefaultPlatforms: []string{"ubuntu-latest", "macos-latest", "windows-latest"},
 	TaskOverrides: map[string]github.TaskOverride{
-		"go-lint":      {Platforms: []string{"ubuntu-latest"}},
-		"go-vulncheck": {Platforms: []string{"ubuntu-latest"}},
-		"md-format":    {Platforms: []string{"ubuntu-latest"}},
+		"go-lint":        {Platforms: []string{"ubuntu-latest"}},
+		"go-vulncheck":   {Platforms: []string{"ubuntu-latest"}},
+		"license-header": {Platforms: []string{"ubuntu-latest"}},
+		"md-format":      {Platforms: []string{"ubuntu-latest"}},
 	},
 	ExcludeTasks: []string{"github-workflows"},
+	UploadSARIF:  true,
 }
 
 // Config is the pocket configuration for this project.
 var Config = pocket.Config{
 	AutoRun: autoRun,
+	Generate: []pocket.Runnable{
+		github.WorkflowTask(pocket.Parallel(autoRun, toolTests), matrixConfig),
+		github.DepsConfigTask(github.DepsConfig{Renovate: true}),
+	},
 	ManualRun: []pocket.Runnable{
 		Greet,
-		github.MatrixTask(autoRun, matrixConfig),
+		github.MatrixTask(pocket.Parallel(autoRun, toolTests), matrixConfig),
+		toolTests,
+		sarif.Report,
+		git.HooksTask(git.DefaultHooksConfig()),
+		toolversions.Task,
 	},
+	Internal: []*pocket.TaskDef{golangcilint.Install, mdformat.Install},
 	Shim: &pocket.ShimConfig{
 		Posix:      true,
 		Windows:    true,
@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// WithInputs declares the files a task reads, as globs relative to each
+// path the task runs in. A "**" segment matches any number of directories,
+// other segments follow path.Match. Inputs are shown by ./pok plan -json and
+// used by ./pok affected to map changed files to tasks.
+//
+// Example:
+//
+//	pocket.Clone(golang.Test, pocket.WithInputs("**/*.go", "go.mod", "go.sum"))
+func WithInputs(globs ...string) TaskOpt {
+	return func(td *TaskDef) {
+		td.inputs = globs
+	}
+}
+
+// WithOutputs declares the files a task writes, as globs relative to each
+// path the task runs in (see WithInputs). Outputs are shown by ./pok plan
+// -json. With WithUpToDate, the task is skipped as "(up to date)" while its
+// outputs are newer than its inputs.
+//
+// Example:
+//
+//	pocket.Clone(protoGenerate, pocket.WithInputs("**/*.proto"), pocket.WithOutputs("gen/**"))
+func WithOutputs(globs ...string) TaskOpt {
+	return func(td *TaskDef) {
+		td.outputs = globs
+	}
+}
+
+// AffectedTask is a task affected by changed files.
+type AffectedTask struct {
+	Name  string   `json:"name"`  // CLI command name
+	Paths []string `json:"paths"` // Paths the task runs in that contain changed inputs
+	Files []string `json:"files"` // Changed files the task reads, relative to the git root
+
+	// Owners are the CODEOWNERS owners of Files, set by ./pok affected -owners.
+	Owners []string `json:"owners,omitempty"`
+}
+
+// Affected returns the visible tasks that read any of files, which are
+// relative to the git root. A task reads the files matching its inputs (see
+// WithInputs) in the paths it runs in; a task without inputs is assumed to
+// read every file in its paths. Tasks are returned in plan order.
+func (p *ConfigPlan) Affected(files []string) []AffectedTask {
+	var result []AffectedTask
+	seen := make(map[string]bool)
+	for _, f := range p.Tasks {
+		if f.hidden || seen[f.name] {
+			continue
+		}
+		seen[f.name] = true
+		paths := taskPaths(p, f.name, ".")
+		if len(paths) == 0 {
+			paths = []string{"."}
+		}
+		task := AffectedTask{Name: f.name}
+		for _, dir := range paths {
+			matched := false
+			for _, file := range files {
+				if f.readsFile(dir, file) {
+					matched = true
+					if !slices.Contains(task.Files, file) {
+						task.Files = append(task.Files, file)
+					}
+				}
+			}
+			if matched {
+				task.Paths = append(task.Paths, dir)
+			}
+		}
+		if len(task.Files) > 0 {
+			slices.Sort(task.Files)
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+// readsFile reports whether the task, run in dir, reads file. Both are
+// relative to the git root.
+func (f *TaskDef) readsFile(dir, file string) bool {
+	rel := file
+	if dir != "." {
+		var ok bool
+		if rel, ok = strings.CutPrefix(file, dir+"/"); !ok {
+			return false
+		}
+	}
+	return len(f.inputs) == 0 || matchesAnyGlob(rel, f.inputs)
+}
+
+// MatchGlob reports whether name matches pattern, both slash-separated.
+// A "**" segment matches any number of segments (including none); other
+// segments are matched with path.Match.
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(name); i >= 0; i-- {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// affectedOptions configures the affected builtin task.
+type affectedOptions struct {
+	JSON   bool   `arg:"json" usage:"output as JSON"`
+	Owners bool   `arg:"owners" usage:"show the CODEOWNERS owners of the changed files"`
+	Owner  string `arg:"owner" usage:"only consider changed files owned by this CODEOWNERS owner, e.g. @org/team"`
+}
+
+// affected prints the tasks affected by the files given as arguments.
+func affected(ctx context.Context) error {
+	opts := Options[affectedOptions](ctx)
+	args := positionalArgs(ctx)
+	if len(args) == 0 {
+		return fmt.Errorf("affected: no files given")
+	}
+	files, err := gitRootRelative(args)
+	if err != nil {
+		return fmt.Errorf("affected: %w", err)
+	}
+	var owners codeowners
+	if opts.Owners || opts.Owner != "" {
+		if owners, err = loadCodeowners(); err != nil {
+			return fmt.Errorf("affected: read CODEOWNERS: %w", err)
+		}
+		if owners == nil {
+			return fmt.Errorf("affected: no CODEOWNERS file in %s", strings.Join(codeownersPaths, ", "))
+		}
+	}
+	if opts.Owner != "" {
+		files = owners.ownedBy(files, opts.Owner)
+	}
+	tasks := GetConfigPlan(ctx).Affected(files)
+	if opts.Owners {
+		for i := range tasks {
+			tasks[i].Owners = owners.ownersOf(tasks[i].Files)
+		}
+	}
+
+	if opts.JSON {
+		if tasks == nil {
+			tasks = []AffectedTask{}
+		}
+		data, err := json.MarshalIndent(tasks, "", "  ")
+		if err != nil {
+			return fmt.Errorf("affected: marshal: %w", err)
+		}
+		Printf(ctx, "%s\n", data)
+		return nil
+	}
+	for _, task := range tasks {
+		line := task.Name
+		if Verbose(ctx) {
+			line = fmt.Sprintf("%s (%s): %s", task.Name, strings.Join(task.Paths, ", "), strings.Join(task.Files, ", "))
+		}
+		if opts.Owners {
+			line += " [" + strings.Join(task.Owners, " ") + "]"
+		}
+		Println(ctx, line)
+	}
+	return nil
+}
+
+// gitRootRelative converts files, relative to the working directory or
+// absolute, to slash-separated paths relative to the git root.
+func gitRootRelative(files []string) ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	root := GitRoot()
+	result := make([]string, 0, len(files))
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(wd, file)
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is outside the git root", file)
+		}
+		result = append(result, filepath.ToSlash(rel))
+	}
+	return result, nil
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/app/main.go", true},
+		{"docs/**", "docs", true},
+		{"docs/**", "docs/a/b.md", true},
+		{"docs/**", "docsite/a.md", false},
+		{"proto/**/*.proto", "proto/v1/api.proto", true},
+		{"proto/**/*.proto", "api/v1/api.proto", false},
+		{"go.mod", "go.mod", true},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConfigPlanAffected(t *testing.T) {
+	noop := func(_ context.Context) error { return nil }
+	plan := BuildConfigPlan(Config{
+		AutoRun: Serial(
+			Task("md-format", "format markdown", noop, WithInputs("**/*.md")),
+			RunIn(Task("go-test", "test", noop, WithInputs("**/*.go", "go.mod"), WithOutputs("coverage.out")),
+				Include("services/api", "services/web")),
+			Task("docs", "build docs", noop).InDir("docs"),
+			Task("install:x", "install x", noop, AsHidden()),
+		),
+	})
+
+	got := plan.Affected([]string{"README.md", "services/api/main.go", "services/web/go.mod", "docs/index.md"})
+	want := []AffectedTask{
+		{Name: "md-format", Paths: []string{"."}, Files: []string{"README.md", "docs/index.md"}},
+		{Name: "go-test", Paths: []string{"services/api", "services/web"}, Files: []string{"services/api/main.go", "services/web/go.mod"}},
+		{Name: "docs", Paths: []string{"docs"}, Files: []string{"docs/index.md"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Affected() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := plan.Affected([]string{"services/api/README.txt"}); len(got) != 0 {
+		t.Errorf("Affected() = %+v, want no tasks", got)
+	}
+}
+
+func TestWithInputs_Plan(t *testing.T) {
+	task := Task("go-test", "test", func(_ context.Context) error { return nil },
+		WithInputs("**/*.go"), WithOutputs("coverage.out"))
+	tasks, err := CollectTasks(Clone(task, Named("go-test-short")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || !reflect.DeepEqual(tasks[0].Inputs, []string{"**/*.go"}) ||
+		!reflect.DeepEqual(tasks[0].Outputs, []string{"coverage.out"}) {
+		t.Errorf("expected inputs and outputs to be collected, got %+v", tasks)
+	}
+}
+
+func TestAffectedTask(t *testing.T) {
+	noop := func(_ context.Context) error { return nil }
+	plan := BuildConfigPlan(Config{
+		AutoRun: Task("go-test", "test", noop, WithInputs("**/*.go")),
+	})
+	var stdout strings.Builder
+	ctx := NewRunContext(context.Background(), RunContextOptions{
+		Output:     &Output{Stdout: &stdout, Stderr: io.Discard},
+		ConfigPlan: plan,
+	})
+	ctx = withPositionalArgs(ctx, []string{FromGitRoot("main.go"), FromGitRoot("README.md")})
+	if err := affected(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := stdout.String(); got != "go-test\n" {
+		t.Errorf("affected output = %q, want %q", got, "go-test\n")
+	}
+}
+
+func TestCodeowners(t *testing.T) {
+	owners := parseCodeowners(`# comment
+*                 @org/platform
+*.go              @org/go # trailing comment
+/docs/            @org/docs
+services/api      @org/api
+build/            @org/build
+/services/api/gen
+`)
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"README.md", []string{"@org/platform"}},
+		{"main.go", []string{"@org/go"}},
+		{"docs/guide.md", []string{"@org/docs"}},
+		{"docs/sub/x.go", []string{"@org/docs"}},
+		{"pkg/docs/x.md", []string{"@org/platform"}},
+		{"services/api/main.go", []string{"@org/api"}},
+		{"services/api/gen/x.go", nil},
+		{"tools/build/x.sh", []string{"@org/build"}},
+	}
+	for _, tt := range tests {
+		if got := owners.owners(tt.file); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("owners(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+
+	files := []string{"README.md", "main.go", "services/api/main.go", "services/api/handler.go"}
+	if got, want := owners.ownedBy(files, "@ORG/api"), []string{"services/api/main.go", "services/api/handler.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ownedBy() = %q, want %q", got, want)
+	}
+	if got, want := owners.ownersOf(files), []string{"@org/api", "@org/go", "@org/platform"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ownersOf() = %q, want %q", got, want)
+	}
+}
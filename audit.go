@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// AuditFileName is the name of the audit log in the .pocket directory.
+const AuditFileName = "audit.jsonl"
+
+// AuditConfig controls the audit log of external commands, see Config.Audit.
+type AuditConfig struct {
+	// MaxAge removes records older than this duration at the end of each
+	// run. Zero keeps all records.
+	MaxAge time.Duration
+}
+
+// auditRecord is a line of the audit log: an external command run by a
+// task. The arguments are only recorded as a hash, so secrets passed as
+// arguments don't end up in the log.
+type auditRecord struct {
+	Time       time.Time `json:"time"`
+	Run        time.Time `json:"run"`  // start of the pocket run
+	Task       string    `json:"task"` // task that ran the command
+	Path       string    `json:"path"` // path the task ran in
+	Tool       string    `json:"tool"`
+	Version    string    `json:"version,omitempty"` // for tools installed by pocket
+	ArgsHash   string    `json:"args_hash"`         // sha256 of the arguments
+	Dir        string    `json:"dir,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	ExitCode   int       `json:"exit_code"`
+}
+
+// FromAuditLog returns the path of the audit log.
+func FromAuditLog() string {
+	return FromPocketDir(AuditFileName)
+}
+
+// openAuditLog opens the audit log for appending records.
+func openAuditLog() (*os.File, error) {
+	if err := os.MkdirAll(FromPocketDir(), 0o755); err != nil {
+		return nil, fmt.Errorf("create pocket dir: %w", err)
+	}
+	f, err := os.OpenFile(FromAuditLog(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	return f, nil
+}
+
+// newAuditRecord returns the record of cmd, run by a task of ec.
+func newAuditRecord(ec *execContext, cmd *exec.Cmd, start time.Time, d time.Duration, err error) auditRecord {
+	tool := filepath.Base(cmd.Path)
+	if len(cmd.Args) > 0 {
+		tool = filepath.Base(cmd.Args[0])
+	}
+	tool = strings.TrimSuffix(tool, ".exe")
+	var args []string
+	if len(cmd.Args) > 1 {
+		args = cmd.Args[1:]
+	}
+	sum := sha256.Sum256([]byte(strings.Join(args, "\x00")))
+	return auditRecord{
+		Time:       start.UTC(),
+		Task:       ec.task,
+		Path:       cmp.Or(ec.path, "."),
+		Tool:       tool,
+		Version:    ec.dedup.toolVersion(tool),
+		ArgsHash:   hex.EncodeToString(sum[:]),
+		Dir:        cmd.Dir,
+		DurationMS: d.Milliseconds(),
+		ExitCode:   exitCode(err),
+	}
+}
+
+// recordAudit appends r to the audit log, if enabled.
+func (l *runLog) recordAudit(r auditRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.audit == nil {
+		return
+	}
+	r.Run = l.start.UTC()
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	_, _ = l.audit.Write(append(data, '\n'))
+}
+
+// readAuditLog reads the records of the audit log at path. Lines that are
+// not valid records, e.g. from an interrupted write, are skipped.
+func readAuditLog(path string) ([]auditRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var records []auditRecord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err == nil {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// pruneAuditLog removes the records older than maxAge from the audit log.
+func pruneAuditLog(path string, maxAge time.Duration, now time.Time) error {
+	if maxAge <= 0 {
+		return nil
+	}
+	records, err := readAuditLog(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	pruned := false
+	for _, r := range records {
+		if now.Sub(r.Time) > maxAge {
+			pruned = true
+			continue
+		}
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	if !pruned {
+		return nil
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// auditOptions configures the audit builtin task.
+type auditOptions struct {
+	Tool   string `arg:"tool"   usage:"only show commands of this tool, e.g. golangci-lint"`
+	Task   string `arg:"task"   usage:"only show commands run by this task"`
+	Since  string `arg:"since"  usage:"only show commands run within this duration, e.g. 24h"`
+	Failed bool   `arg:"failed" usage:"only show failed commands"`
+	JSON   bool   `arg:"json"   usage:"output the records as JSON lines"`
+}
+
+// audit reports the commands recorded in the audit log.
+func audit(ctx context.Context) error {
+	opts := Options[auditOptions](ctx)
+	var since time.Time
+	if opts.Since != "" {
+		d, err := time.ParseDuration(opts.Since)
+		if err != nil {
+			return fmt.Errorf("audit: invalid -since: %w", err)
+		}
+		since = time.Now().Add(-d)
+	}
+	records, err := readAuditLog(FromAuditLog())
+	if errors.Is(err, fs.ErrNotExist) {
+		Println(ctx, "No audit log; enable it with Config.Audit.")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	var matched []auditRecord
+	for _, r := range records {
+		if opts.Tool != "" && r.Tool != opts.Tool ||
+			opts.Task != "" && r.Task != opts.Task ||
+			opts.Failed && r.ExitCode == 0 ||
+			r.Time.Before(since) {
+			continue
+		}
+		matched = append(matched, r)
+	}
+	w := GetOutput(ctx).Stdout
+	if opts.JSON {
+		enc := json.NewEncoder(w)
+		for _, r := range matched {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return writeAuditReport(w, matched)
+}
+
+// writeAuditReport writes records as a table, oldest first.
+func writeAuditReport(w io.Writer, records []auditRecord) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(w, "No matching commands.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tTASK\tPATH\tTOOL\tVERSION\tARGS\tDURATION\tEXIT")
+	for _, r := range records {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			r.Time.Local().Format(time.DateTime), r.Task, r.Path, r.Tool, cmp.Or(r.Version, "-"),
+			r.ArgsHash[:min(12, len(r.ArgsHash))], formatDuration(time.Duration(r.DurationMS)*time.Millisecond), r.ExitCode)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestAuditRecords(t *testing.T) {
+	install := Task("install:linter", "install linter", func(_ context.Context) error { return nil },
+		AsHidden(), AsTool("linter", "v1.2.3"))
+	lint := Task("lint", "lint", Serial(install, func(ctx context.Context) error {
+		if err := Exec(ctx, "linter", "run", "--token=secret"); err != nil {
+			return err
+		}
+		return Exec(ctx, "git", "status")
+	}))
+
+	var audit strings.Builder
+	rl := &runLog{start: time.Now(), audit: &audit}
+	ctx := NewRunContext(context.Background(), RunContextOptions{
+		Output: discardOutput(),
+		Runner: func(cmd *exec.Cmd) error {
+			if cmd.Args[0] == "git" {
+				return errors.New("git failed")
+			}
+			return nil
+		},
+	})
+	getExecContext(ctx).runLog = rl
+	_ = lint.run(ctx)
+
+	var records []auditRecord
+	for line := range strings.Lines(audit.String()) {
+		var r auditRecord
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2:\n%s", len(records), audit.String())
+	}
+	linter, git := records[0], records[1]
+	if linter.Task != "lint" || linter.Path != "." || linter.Tool != "linter" || linter.Version != "v1.2.3" || linter.ExitCode != 0 {
+		t.Errorf("linter record = %+v", linter)
+	}
+	if len(linter.ArgsHash) != 64 || strings.Contains(audit.String(), "secret") {
+		t.Errorf("expected the arguments to be recorded as a sha256 hash only:\n%s", audit.String())
+	}
+	if git.Tool != "git" || git.Version != "" || git.ExitCode != 1 || git.ArgsHash == linter.ArgsHash {
+		t.Errorf("git record = %+v", git)
+	}
+	if !linter.Run.Equal(rl.start.UTC().Round(0)) {
+		t.Errorf("run = %v, want the run start %v", linter.Run, rl.start)
+	}
+}
+
+func TestAuditReport(t *testing.T) {
+	// Not parallel due to shared git root.
+	tmpDir := t.TempDir()
+	defer gitroot.Override(tmpDir)()
+
+	now := time.Now().UTC()
+	records := []auditRecord{
+		{Time: now.Add(-48 * time.Hour), Task: "go-lint", Path: ".", Tool: "golangci-lint", Version: "v2.0.2", ArgsHash: strings.Repeat("a", 64)},
+		{Time: now.Add(-time.Hour), Task: "go-lint", Path: ".", Tool: "golangci-lint", Version: "v2.0.2", ArgsHash: strings.Repeat("b", 64), ExitCode: 1},
+		{Time: now.Add(-time.Minute), Task: "go-test", Path: "services/api", Tool: "go", ArgsHash: strings.Repeat("c", 64)},
+	}
+	f, err := openAuditLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := json.NewEncoder(f)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.WriteString("{truncated\n")
+	f.Close()
+
+	report := func(opts auditOptions) string {
+		t.Helper()
+		var stdout strings.Builder
+		ctx := withOptions(TestContext(&Output{Stdout: &stdout, Stderr: &stdout}), opts)
+		if err := audit(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return stdout.String()
+	}
+	if got := report(auditOptions{}); strings.Count(got, "\n") != 4 || !strings.Contains(got, "services/api  go") {
+		t.Errorf("report of all records:\n%s", got)
+	}
+	if got := report(auditOptions{Tool: "golangci-lint", Since: "24h"}); strings.Count(got, "\n") != 2 || !strings.Contains(got, "bbbbbbbbbbbb") {
+		t.Errorf("report of recent golangci-lint runs:\n%s", got)
+	}
+	if got := report(auditOptions{Failed: true, JSON: true}); strings.Count(got, "\n") != 1 || !strings.Contains(got, `"exit_code":1`) {
+		t.Errorf("JSON report of failed commands:\n%s", got)
+	}
+
+	if err := pruneAuditLog(FromAuditLog(), 24*time.Hour, now); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := readAuditLog(FromAuditLog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 2 {
+		t.Errorf("kept %d records after pruning, want 2", len(kept))
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".pocket", AuditFileName)); err != nil {
+		t.Errorf("expected the audit log in .pocket: %v", err)
+	}
+}
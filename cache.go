@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// noCacheEnv disables task result caching when set to a non-empty value.
+// The -no-cache flag sets it, so nested pocket runs don't use the cache
+// either.
+const noCacheEnv = "POK_NO_CACHE"
+
+// WithCache skips the task when it last succeeded in the same path with the
+// same inputs (see WithInputs), options, positional arguments, tool
+// versions, platform, Go toolchain and environment variables declared with
+// WithCacheEnv. The state is kept in .pocket/cache and ignored with -no-cache.
+// Tasks without inputs are never skipped. Outputs are not restored, so only
+// cache tasks whose results are checks or files that are kept around.
+//
+// Example:
+//
+//	pocket.Clone(golang.Lint, pocket.WithCache())
+func WithCache() TaskOpt {
+	return func(td *TaskDef) {
+		td.cache = true
+	}
+}
+
+// WithCacheEnv adds the values of the named environment variables to the
+// cache key of a task with WithCache, e.g. CGO_ENABLED or GOFLAGS, so that a
+// change reruns the task. Variables set with Env take precedence over the
+// process environment. Other environment variables are ignored.
+//
+// Example:
+//
+//	pocket.Clone(golang.Test, pocket.WithCache(), pocket.WithCacheEnv("CGO_ENABLED", "GOFLAGS"))
+func WithCacheEnv(names ...string) TaskOpt {
+	return func(td *TaskDef) {
+		td.cacheEnv = append(slices.Clone(td.cacheEnv), names...)
+	}
+}
+
+// cachePlatform is the platform in cache keys. A variable so tests can
+// simulate other platforms.
+var cachePlatform = runtime.GOOS + "/" + runtime.GOARCH
+
+// goVersions caches the Go toolchain version per directory.
+var goVersions sync.Map
+
+// goVersion returns the version of the Go toolchain that go commands use in
+// dir, which can differ per module through GOTOOLCHAIN and the toolchain
+// line of go.mod. It falls back to the version pocket was built with.
+func goVersion(dir string) string {
+	if v, ok := goVersions.Load(dir); ok {
+		return v.(string)
+	}
+	version := runtime.Version()
+	cmd := exec.Command("go", "env", "GOVERSION")
+	cmd.Dir = dir
+	if out, err := cmd.Output(); err == nil && len(strings.TrimSpace(string(out))) > 0 {
+		version = strings.TrimSpace(string(out))
+	}
+	goVersions.Store(dir, version)
+	return version
+}
+
+// FromCacheDir returns a path relative to the .pocket/cache directory.
+func FromCacheDir(elem ...string) string {
+	return FromPocketDir(append([]string{"cache"}, elem...)...)
+}
+
+// cacheEnabled reports whether the task's results are cached.
+func (f *TaskDef) cacheEnabled() bool {
+	return f.cache && len(f.inputs) > 0 && os.Getenv(noCacheEnv) == ""
+}
+
+// cacheKey returns a hash of everything the result of the task run in ctx
+// depends on: the task, path, options, positional arguments, platform, Go
+// toolchain, declared environment variables, the versions of the tools in
+// the task's tree and the content of its input files.
+func (f *TaskDef) cacheKey(ctx context.Context) (string, error) {
+	h := sha256.New()
+	root := FromGitRoot(Path(ctx))
+	fmt.Fprintf(h, "task %s\npath %s\n", f.name, Path(ctx))
+	fmt.Fprintf(h, "platform %s\ngo %s\n", cachePlatform, goVersion(root))
+	env := getExecContext(ctx).env
+	for _, name := range slices.Sorted(slices.Values(f.cacheEnv)) {
+		value, ok := env[name]
+		if !ok {
+			value, ok = os.LookupEnv(name)
+		}
+		if ok {
+			fmt.Fprintf(h, "env %s=%s\n", name, value)
+		} else {
+			fmt.Fprintf(h, "env %s unset\n", name)
+		}
+	}
+	if f.opts != nil {
+		opts, err := json.Marshal(f.opts)
+		if err != nil {
+			return "", fmt.Errorf("hash options: %w", err)
+		}
+		fmt.Fprintf(h, "opts %s\n", opts)
+	}
+	fmt.Fprintf(h, "args %q\n", positionalArgs(ctx))
+	tasks, err := CollectTasks(f.body)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range tasks {
+		if t.Tool != nil {
+			fmt.Fprintf(h, "tool %s %s\n", t.Tool.Name, t.Tool.Version)
+		}
+	}
+
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !matchesAnyGlob(rel, f.inputs) {
+			return nil
+		}
+		sum, err := FileSHA256(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "file %s %s\n", rel, sum)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("hash inputs: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheFile returns the file holding the cache key of the last successful
+// run of the task in dir.
+func cacheFile(name, dir string) string {
+	sum := sha256.Sum256([]byte(name + "\x00" + dir))
+	return FromCacheDir("tasks", hex.EncodeToString(sum[:16]))
+}
+
+// cacheHit reports whether key is the cache key of the last successful run
+// of the task in dir.
+func cacheHit(name, dir, key string) bool {
+	data, err := os.ReadFile(cacheFile(name, dir))
+	return err == nil && strings.TrimSpace(string(data)) == key
+}
+
+// storeCache records key as the cache key of a successful run of the task
+// in dir.
+func storeCache(name, dir, key string) error {
+	path := cacheFile(name, dir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(key+"\n"), 0o644)
+}
+
+// FileSHA256 returns the hex-encoded SHA-256 checksum of the file at path.
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
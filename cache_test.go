@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestWithCache(t *testing.T) {
+	// Not parallel due to shared git root.
+	tmpDir := t.TempDir()
+	defer gitroot.Override(tmpDir)()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.txt", "a")
+	write("notes.md", "notes")
+
+	type lintOptions struct {
+		Strict bool `arg:"strict" usage:"strict mode"`
+	}
+	runs := 0
+	fail := false
+	lint := Task("lint", "lint text", func(_ context.Context) error {
+		runs++
+		if fail {
+			return errors.New("lint failed")
+		}
+		return nil
+	}, Opts(lintOptions{}), WithInputs("*.txt"), WithCache())
+
+	run := func(r Runnable, want int) {
+		t.Helper()
+		_ = runWithContext(context.Background(), r, discardOutput(), ".", false, nil, nil)
+		if runs != want {
+			t.Errorf("runs = %d, want %d", runs, want)
+		}
+	}
+	run(lint, 1)
+	run(lint, 1) // unchanged
+	write("notes.md", "changed")
+	run(lint, 1) // not an input
+	write("a.txt", "changed")
+	run(lint, 2)
+	run(WithOpts(lint, lintOptions{Strict: true}), 3)
+	run(lint, 4) // options differ from the last successful run
+	run(lint, 4)
+
+	t.Setenv(noCacheEnv, "1")
+	run(lint, 5)
+	t.Setenv(noCacheEnv, "")
+
+	fail = true
+	write("b.txt", "b")
+	run(lint, 6)
+	run(lint, 7) // failures are not cached
+}
+
+func TestUpToDate(t *testing.T) {
+	tmpDir := t.TempDir()
+	defer gitroot.Override(tmpDir)()
+	now := time.Now()
+	write := func(name string, age time.Duration) {
+		t.Helper()
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("api.proto", time.Hour)
+
+	runs := 0
+	generate := Task("generate", "generate code", func(_ context.Context) error {
+		runs++
+		write("gen/api.pb.go", 0)
+		write("docs/api.md", 0)
+		return nil
+	}, WithInputs("*.proto"), WithOutputs("gen/**", "docs/api.md"), WithUpToDate())
+
+	run := func(want int, wantHeader string) {
+		t.Helper()
+		var buf bytes.Buffer
+		out := &Output{Stdout: &buf, Stderr: &buf}
+		_ = runWithContext(context.Background(), generate, out, ".", false, nil, nil)
+		if runs != want {
+			t.Errorf("runs = %d, want %d", runs, want)
+		}
+		if !strings.Contains(buf.String(), wantHeader) {
+			t.Errorf("output = %q, want %q", buf.String(), wantHeader)
+		}
+	}
+	run(1, "generate") // no outputs yet
+	run(1, "generate (up to date)")
+	write("api.proto", 0)
+	write("gen/api.pb.go", time.Minute)
+	run(2, "generate") // input newer than output
+	run(2, "generate (up to date)")
+	if err := os.Remove(filepath.Join(tmpDir, "docs", "api.md")); err != nil {
+		t.Fatal(err)
+	}
+	run(3, "generate") // an output is missing
+	run(3, "generate (up to date)")
+
+	t.Setenv(noCacheEnv, "1")
+	run(4, "generate")
+
+	// Without WithUpToDate, the outputs don't skip the task.
+	t.Setenv(noCacheEnv, "")
+	generate = Task("generate", "generate code", func(_ context.Context) error {
+		runs++
+		return nil
+	}, WithInputs("*.proto"), WithOutputs("gen/**", "docs/api.md"))
+	run(5, "generate")
+}
+
+func TestCacheKey_PlatformAndEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	defer gitroot.Override(tmpDir)()
+	noop := func(_ context.Context) error { return nil }
+	task := Task("test", "test", noop, WithInputs("*.txt"), WithCache(), WithCacheEnv("CGO_ENABLED"))
+	key := func(ctx context.Context) string {
+		t.Helper()
+		k, err := task.cacheKey(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	ctx := TestContext(discardOutput())
+
+	t.Setenv("CGO_ENABLED", "1")
+	base := key(ctx)
+	t.Setenv("CGO_ENABLED", "0")
+	if key(ctx) == base {
+		t.Error("expected the cache key to change with a declared environment variable")
+	}
+	if key(withEnv(ctx, map[string]string{"CGO_ENABLED": "1"})) != base {
+		t.Error("expected variables set with Env to take precedence")
+	}
+	t.Setenv("UNDECLARED", "x")
+	if key(withEnv(ctx, map[string]string{"CGO_ENABLED": "1"})) != base {
+		t.Error("expected undeclared environment variables to be ignored")
+	}
+
+	orig := cachePlatform
+	cachePlatform = "plan9/386"
+	t.Cleanup(func() { cachePlatform = orig })
+	if key(withEnv(ctx, map[string]string{"CGO_ENABLED": "1"})) == base {
+		t.Error("expected the cache key to change with the platform")
+	}
+}
+
+func TestCacheKey_ToolVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	defer gitroot.Override(tmpDir)()
+	noop := func(_ context.Context) error { return nil }
+	key := func(version string) string {
+		t.Helper()
+		install := Task("install:tool", "install tool", noop, AsHidden(), AsTool("tool", version))
+		task := Task("lint", "lint", Serial(install, noop), WithInputs("*.txt"), WithCache())
+		k, err := task.cacheKey(TestContext(discardOutput()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	if key("v1.0.0") == key("v1.1.0") {
+		t.Error("expected the cache key to change with the tool version")
+	}
+	if key("v1.0.0") != key("v1.0.0") {
+		t.Error("expected a stable cache key")
+	}
+}
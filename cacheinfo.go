@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// cacheLocation is a directory shown by cache-info.
+type cacheLocation struct {
+	name string
+	path string // empty if unknown, e.g. without go
+}
+
+// cacheInfo prints the cache directories used by pocket and its tools with
+// their sizes, to help debug slow or uncached CI runs.
+func cacheInfo(ctx context.Context) error {
+	locations := []cacheLocation{
+		{"pocket tools", FromToolsDir()},
+		{"pocket bin", FromBinDir()},
+		{"pocket venvs", FromPocketDir("venvs")},
+		{"task results", FromCacheDir()},
+	}
+	goCache, goModCache := goCacheDirs(ctx)
+	locations = append(locations,
+		cacheLocation{"GOCACHE", goCache},
+		cacheLocation{"GOMODCACHE", goModCache},
+		cacheLocation{"UV_CACHE_DIR", uvCacheDir()},
+		cacheLocation{"BUN_INSTALL_CACHE_DIR", bunCacheDir()},
+	)
+	return writeCacheInfo(GetOutput(ctx).Stdout, locations)
+}
+
+// writeCacheInfo writes each location with its size.
+func writeCacheInfo(w io.Writer, locations []cacheLocation) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, l := range locations {
+		if l.path == "" {
+			fmt.Fprintf(tw, "  %s\t(unknown)\t\n", l.name)
+			continue
+		}
+		size, err := dirSize(l.path)
+		switch {
+		case os.IsNotExist(err):
+			fmt.Fprintf(tw, "  %s\t%s\t(missing)\n", l.name, l.path)
+		case err != nil:
+			fmt.Fprintf(tw, "  %s\t%s\t(error: %v)\n", l.name, l.path, err)
+		default:
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", l.name, l.path, formatBytes(size))
+		}
+	}
+	return tw.Flush()
+}
+
+// dirSize returns the total size of the regular files below dir.
+func dirSize(dir string) (int64, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return 0, err
+	}
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries, e.g. read-only module cache dirs
+			// on some file systems.
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size, err
+}
+
+// goCacheDirs returns GOCACHE and GOMODCACHE as reported by go env, or
+// empty strings if go is not available.
+func goCacheDirs(ctx context.Context) (goCache, goModCache string) {
+	var out bytes.Buffer
+	cmd := Command(ctx, "go", "env", "GOCACHE", "GOMODCACHE")
+	cmd.Stdout = &out
+	if err := RunCommand(ctx, cmd); err != nil {
+		return "", ""
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		return "", ""
+	}
+	return strings.TrimSpace(lines[0]), strings.TrimSpace(lines[1])
+}
+
+// uvCacheDir returns the cache directory of uv, see
+// https://docs.astral.sh/uv/concepts/cache/#cache-directory.
+func uvCacheDir() string {
+	if dir := os.Getenv("UV_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "uv", "cache")
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "uv")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cache", "uv")
+}
+
+// bunCacheDir returns the package cache directory of bun, see
+// https://bun.sh/docs/install/cache.
+func bunCacheDir() string {
+	if dir := os.Getenv("BUN_INSTALL_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("BUN_INSTALL"); dir != "" {
+		return filepath.Join(dir, "install", "cache")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".bun", "install", "cache")
+}
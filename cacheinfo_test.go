@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteCacheInfo(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "tools", "lint"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tools", "lint", "bin"), make([]byte, 1536), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tools", "version"), []byte("v1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	err := writeCacheInfo(&out, []cacheLocation{
+		{"pocket tools", filepath.Join(dir, "tools")},
+		{"pocket venvs", filepath.Join(dir, "venvs")},
+		{"GOCACHE", ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"pocket tools  " + filepath.Join(dir, "tools") + "  1.5KiB",
+		"pocket venvs  " + filepath.Join(dir, "venvs") + "  (missing)",
+		"GOCACHE       (unknown)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestUVAndBunCacheDir(t *testing.T) {
+	t.Setenv("UV_CACHE_DIR", "/tmp/uv")
+	t.Setenv("BUN_INSTALL_CACHE_DIR", "")
+	t.Setenv("BUN_INSTALL", "/opt/bun")
+	if got := uvCacheDir(); got != "/tmp/uv" {
+		t.Errorf("uvCacheDir() = %q, want /tmp/uv", got)
+	}
+	if got, want := bunCacheDir(), filepath.Join("/opt/bun", "install", "cache"); got != want {
+		t.Errorf("bunCacheDir() = %q, want %q", got, want)
+	}
+}
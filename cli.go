@@ -1,15 +1,27 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"text/tabwriter"
+	"time"
+
+	"github.com/fredrikaverpil/pocket/internal/prcomment"
 )
 
 // detectCwd returns the current working directory relative to git root.
@@ -40,6 +52,16 @@ func detectCwd() string {
 	return rel
 }
 
+// resolveContext validates a -context directory. It must be one of the
+// module directories pocket generates shims in.
+func resolveContext(dir string, moduleDirs []string) (string, error) {
+	dir = cleanDir(dir)
+	if !slices.Contains(moduleDirs, dir) {
+		return "", fmt.Errorf("no shim in %q (shims are generated in: %s)", dir, strings.Join(moduleDirs, ", "))
+	}
+	return dir, nil
+}
+
 // cliMain is the entry point for the CLI.
 // It parses flags, handles -h/--help, and runs the specified function(s).
 // If no function is specified, runs all autorun functions.
@@ -50,18 +72,77 @@ func cliMain(plan *ConfigPlan) {
 // cliRun parses flags and runs functions, returning the exit code.
 func cliRun(plan *ConfigPlan) int {
 	verbose := flag.Bool("v", false, "verbose output")
-	help := flag.Bool("h", false, "show help")
+	help := flag.Bool("h", false, "show help (use -h <task> for task help)")
+	color := flag.String("color", string(ColorAuto), "colored output: auto, always or never")
+	output := flag.String("output", outputText, "output format: text, tap or json events")
+	contextDir := flag.String("context", "", "run as the shim in this directory, e.g. services/api")
+	skipFlag := flag.String("skip", "", "comma-separated tasks to skip for this run, e.g. go-vulncheck")
+	tagsFlag := flag.String("tags", "", "comma-separated tags; run only the tasks with one of them, e.g. slow")
+	skipTagsFlag := flag.String("skip-tags", "", "comma-separated tags; skip the tasks with one of them, e.g. integration")
+	trace := flag.Bool("trace", false, "print every command (binary, arguments, directory, env) before running it")
+	jobs := flag.Int("j", 0, "run at most this many tasks in parallel (default: Config.MaxParallel, 0 = no limit)")
+	keepGoing := flag.Bool("keep-going", false, "run the other tasks when a task fails, and report all failures at the end")
+	reinstallTools := flag.Bool("reinstall-tools", false, "download and link the tools of the run again, e.g. after a corrupted install")
+	fixFlag := flag.Bool("fix", false, "make linters and formatters fix what they can; -fix=false only checks, e.g. in CI")
+	noCache := flag.Bool("no-cache", false, "run tasks with WithCache or WithUpToDate even if their inputs are unchanged")
+	dryRunFlag := flag.Bool("dry-run", false, "print the tasks that would run, in order and with their paths, without running them")
+	graphFlag := flag.String("graph", "", "print the task graph as dot (Graphviz) or mermaid instead of running it")
+	watchFlag := flag.Bool("watch", false, "re-run the task whenever files in its paths change")
+	profileFlag := flag.Bool("profile", false, "write a timeline of the run to .pocket/profile.json, for ui.perfetto.dev or chrome://tracing")
+	summaryFlag := flag.String("summary", summaryAuto, "per-task timing summary: auto (when several tasks ran), text, json or none")
+	flat := flag.Bool("flat", false, "list tasks as auto-run and manual instead of by group")
+	printExitCodes := flag.Bool("print-exit-codes", false, "print the exit codes and their meaning, e.g. for CI scripts")
 
 	// Detect current working directory relative to git root.
 	cwd := detectCwd()
-
-	// Filter functions based on cwd.
-	visibleFuncs := filterFuncsByCwd(plan.Tasks, cwd, plan.PathMappings)
-
+	var visibleFuncs []*TaskDef
 	flag.Usage = func() {
-		printHelp(visibleFuncs, plan.AutoRunNames, plan.BuiltinTasks)
+		printHelp(plan, visibleFuncs, cwd, *flat)
 	}
 	flag.Parse()
+	if *printExitCodes {
+		writeExitCodes(os.Stdout)
+		return 0
+	}
+	if *contextDir != "" {
+		dir, err := resolveContext(*contextDir, plan.ModuleDirectories)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return ExitUsage
+		}
+		// Commands and nested pocket runs read the context like from a shim.
+		cwd = dir
+		os.Setenv("POK_CONTEXT", dir)
+	}
+	if *trace {
+		// Nested pocket runs inherit the setting, like the context.
+		os.Setenv(traceEnv, "1")
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "fix" {
+			os.Setenv(fixEnv, strconv.FormatBool(*fixFlag))
+		}
+	})
+	if _, err := fixOverride(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return ExitUsage
+	}
+	if *noCache {
+		os.Setenv(noCacheEnv, "1")
+	}
+	if *reinstallTools {
+		os.Setenv(reinstallToolsEnv, "1")
+	}
+	if *jobs < 0 {
+		fmt.Fprintf(os.Stderr, "error: invalid -j %d (want a positive number of tasks, or 0 for no limit)\n", *jobs)
+		return ExitUsage
+	}
+	if *jobs > 0 {
+		os.Setenv(jobsEnv, strconv.Itoa(*jobs))
+	}
+
+	// Filter functions based on cwd.
+	visibleFuncs = filterFuncsByCwd(plan.Tasks, cwd, plan.PathMappings)
 
 	// Build function map for lookup (visible functions + built-in functions).
 	funcMap := make(map[string]*TaskDef, len(visibleFuncs)+len(plan.BuiltinTasks))
@@ -71,9 +152,49 @@ func cliRun(plan *ConfigPlan) int {
 	for _, f := range plan.BuiltinTasks {
 		funcMap[f.name] = f
 	}
+	// ./pok all is the same as ./pok, e.g. to pass -skip after the name.
+	if _, ok := funcMap["all"]; !ok && plan.AllTask != nil {
+		funcMap["all"] = plan.AllTask
+	}
+	if plan.isCustomDefault() {
+		funcMap[plan.DefaultTask.name] = plan.DefaultTask
+	}
 
 	args := flag.Args()
 
+	mode, err := parseColorMode(*color)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return ExitUsage
+	}
+	setColorMode(mode)
+	switch *output {
+	case outputText, outputTAP, outputJSON:
+	default:
+		fmt.Fprintf(os.Stderr, "error: invalid output format %q (want text, tap or json)\n", *output)
+		return ExitUsage
+	}
+	switch *summaryFlag {
+	case summaryAuto, summaryText, summaryJSON, summaryNone:
+	default:
+		fmt.Fprintf(os.Stderr, "error: invalid summary format %q (want auto, text, json or none)\n", *summaryFlag)
+		return ExitUsage
+	}
+	if *summaryFlag == summaryJSON && *output != outputText {
+		fmt.Fprintf(os.Stderr, "error: -summary=json and -output=%s both write to stdout\n", *output)
+		return ExitUsage
+	}
+	switch *graphFlag {
+	case "", graphDOT, graphMermaid:
+	default:
+		fmt.Fprintf(os.Stderr, "error: invalid graph format %q (want dot or mermaid)\n", *graphFlag)
+		return ExitUsage
+	}
+	if *watchFlag && *output != outputText {
+		fmt.Fprintf(os.Stderr, "error: -watch does not support -output=%s\n", *output)
+		return ExitUsage
+	}
+
 	// Handle help: ./pok -h or ./pok -h funcname
 	if *help {
 		if len(args) > 0 {
@@ -82,9 +203,9 @@ func cliRun(plan *ConfigPlan) int {
 				return 0
 			}
 			fmt.Fprintf(os.Stderr, "unknown function: %s\n", args[0])
-			return 1
+			return ExitUsage
 		}
-		printHelp(visibleFuncs, plan.AutoRunNames, plan.BuiltinTasks)
+		printHelp(plan, visibleFuncs, cwd, *flat)
 		return 0
 	}
 
@@ -94,53 +215,321 @@ func cliRun(plan *ConfigPlan) int {
 
 	// Determine what to run.
 	var funcToRun *TaskDef
+	var toRun Runnable
+	var positional, paths []string
+	skip := splitList(*skipFlag)
 
 	if len(args) == 0 {
-		// No arguments: run all autorun functions.
-		if plan.AllTask != nil {
+		// No arguments: run the default task if available here,
+		// otherwise all autorun functions.
+		if f, ok := defaultTask(plan, funcMap); ok {
+			funcToRun = f
+			if dir, ok := plan.TaskDirs[f.name]; ok {
+				funcToRun = f.InDir(dir)
+			}
+		} else if plan.AllTask != nil {
 			funcToRun = plan.AllTask
 		} else {
 			fmt.Fprintln(os.Stderr, "no function specified and no default function")
-			return 1
+			return ExitUsage
 		}
 	} else {
 		name := args[0]
 		// Check if it's a function.
 		if f, ok := funcMap[name]; ok {
 			funcToRun = f
+			if dir, ok := plan.TaskDirs[name]; ok {
+				funcToRun = f.InDir(dir)
+			}
+			// Restrict the run to the paths given with path=<dir>.
+			var taskArgs []string
+			taskArgs, paths, err = extractListArg(args[1:], "path")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return ExitUsage
+			}
+			taskArgs, taskSkip, err := extractListArg(taskArgs, "skip")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return ExitUsage
+			}
+			skip = append(skip, taskSkip...)
+			// fix=<bool> is the same as -fix, for any task.
+			taskArgs, taskFix, err := extractBoolArg(taskArgs, "fix")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return ExitUsage
+			}
+			if taskFix != nil {
+				os.Setenv(fixEnv, strconv.FormatBool(*taskFix))
+			}
+			if len(paths) > 0 {
+				if paths, err = validateTaskPaths(plan, name, paths); err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					return ExitUsage
+				}
+			}
+			// Split off positional arguments, for tasks that accept them.
+			if f.args != "" {
+				info, _ := inspectArgs(f.opts)
+				taskArgs, positional = splitPositionalArgs(taskArgs, info)
+			}
 			// Parse function-specific arguments.
-			if len(args) > 1 && f.opts != nil {
-				funcArgs, wantHelp, err := parseTaskArgs(args[1:])
+			if len(taskArgs) > 0 {
+				funcArgs, wantHelp, err := parseTaskArgs(taskArgs)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "error parsing arguments: %v\n", err)
-					return 1
+					return ExitUsage
 				}
 				if wantHelp {
 					printFuncHelp(f)
 					return 0
 				}
+				if f.opts == nil {
+					fmt.Fprintf(os.Stderr, "error: %s takes no options, got %s\n", name, strings.Join(taskArgs, " "))
+					return ExitUsage
+				}
 				// Parse options and store in function.
 				parsedOpts, err := parseOptionsFromCLI(f.opts, funcArgs)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "error parsing options: %v\n", err)
-					return 1
+					return ExitUsage
 				}
 				if parsedOpts != nil {
-					funcToRun = WithOpts(f, parsedOpts)
+					funcToRun = WithOpts(funcToRun, parsedOpts)
 				}
 			}
+			if len(paths) > 0 {
+				toRun = &pathsRunnable{paths: paths, inner: funcToRun}
+			}
 		} else {
 			fmt.Fprintf(os.Stderr, "unknown function: %s\n", name)
-			return 1
+			return ExitUsage
+		}
+	}
+
+	if len(skip) > 0 {
+		if err := validateSkip(plan, skip); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return ExitUsage
+		}
+		if toRun == nil {
+			toRun = funcToRun
+		}
+		toRun = &skipRunnable{names: skip, inner: toRun}
+	}
+
+	var tagSelection *tagFilter
+	tags, skipTags := splitList(*tagsFlag), splitList(*skipTagsFlag)
+	if len(tags) > 0 || len(skipTags) > 0 {
+		if err := validateTags(plan, "-tags", tags); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return ExitUsage
+		}
+		if err := validateTags(plan, "-skip-tags", skipTags); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return ExitUsage
+		}
+		if toRun == nil {
+			toRun = funcToRun
+		}
+		tagSelection = &tagFilter{only: tags, skip: skipTags}
+		toRun = &tagRunnable{filter: *tagSelection, inner: toRun}
+	}
+
+	if positional != nil {
+		ctx = withPositionalArgs(ctx, positional)
+	}
+
+	if *dryRunFlag {
+		d := &dryRun{plan: plan, task: funcToRun, cwd: cmp.Or(cwd, "."), paths: paths, skip: skip, tags: tagSelection, verbose: *verbose}
+		if err := d.write(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return ExitFailure
+		}
+		return 0
+	}
+
+	if *graphFlag != "" {
+		d := &dryRun{plan: plan, task: funcToRun, cwd: cmp.Or(cwd, "."), paths: paths, skip: skip, tags: tagSelection}
+		steps, err := d.steps()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return ExitFailure
+		}
+		if funcToRun == plan.AllTask {
+			// The all task runs its steps in order from a plain function.
+			steps = []*PlanStep{{Type: "func", Name: funcToRun.name, Children: []*PlanStep{{Type: "serial", Children: steps}}}}
+		}
+		newTaskGraph(steps, d.stepPaths).write(os.Stdout, *graphFlag)
+		return 0
+	}
+
+	if *watchFlag {
+		if toRun == nil {
+			toRun = funcToRun
+		}
+		w := &watcher{
+			paths:   watchPaths(plan, funcToRun.name, cwd, paths),
+			inputs:  funcToRun.inputs,
+			outputs: funcToRun.outputs,
+		}
+		err := watchRun(ctx, toRun, funcToRun.name, w, StdOutput(), cwd, *verbose, plan)
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "error: watch: %v\n", err)
+			return ExitFailure
+		}
+		return ExitInterrupted
+	}
+
+	// With TAP or JSON output or a JSON summary, stdout is reserved for the
+	// report, so task output goes to stderr.
+	out := StdOutput()
+	if *output != outputText || *summaryFlag == summaryJSON {
+		out = &Output{Stdout: os.Stderr, Stderr: os.Stderr}
+	}
+
+	// Persist the run's output and metadata if enabled.
+	var rl *runLog
+	if plan.Config != nil && plan.Config.RunLog != nil {
+		if rl, err = openRunLog(time.Now(), os.Args, cwd); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		} else {
+			out = rl.wrap(out)
+		}
+	}
+	var prComment *PRCommentConfig
+	if plan.Config != nil && plan.Config.PRComment != nil {
+		if _, ok := prcomment.Current(os.Getenv); ok {
+			prComment = plan.Config.PRComment
+		}
+	}
+	notify := plan.Config != nil && len(plan.Config.Notify) > 0
+	// Without a run log file, an in-memory log still records the tasks and
+	// the failed command for reports and reproduction scripts.
+	if rl == nil {
+		rl = &runLog{start: time.Now()}
+	}
+	if *output == outputJSON {
+		rl.events = os.Stdout
+	}
+	var auditLog *os.File
+	if plan.Config != nil && plan.Config.Audit != nil {
+		if auditLog, err = openAuditLog(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		} else {
+			rl.audit = auditLog
 		}
 	}
 
 	// Run the function.
-	if err := runWithContext(ctx, funcToRun, StdOutput(), cwd, *verbose, plan); err != nil {
-		fmt.Fprintf(os.Stderr, "function %s failed: %v\n", funcToRun.name, err)
-		return 1
+	code := 0
+	start := time.Now()
+	if toRun == nil {
+		toRun = funcToRun
+	}
+	var failures *failureReport
+	if *keepGoing {
+		failures = &failureReport{}
+		toRun = &keepGoingRunnable{report: failures, inner: toRun}
+	}
+	runErr := runWithContext(ctx, toRun, out, cwd, *verbose, plan, rl)
+	if runErr == nil && failures != nil {
+		runErr = failures.err()
+	}
+	if runErr != nil {
+		fmt.Fprintf(out.Stderr, "function %s failed: %v\n", funcToRun.name, runErr)
+		var kgErr *keepGoingError
+		if errors.As(runErr, &kgErr) {
+			kgErr.writeReport(out.Stderr)
+		}
+		if errors.Is(runErr, ErrToolNotFound) || errors.Is(runErr, ErrToolNotExecutable) {
+			fmt.Fprintln(out.Stderr, "hint: run './pok doctor' to check the tool setup")
+		}
+		if rl.failure != nil {
+			if script, err := writeRepro(rl.failure); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: write reproduction script: %v\n", err)
+			} else {
+				fmt.Fprintf(out.Stderr, "hint: re-run the failed command outside pocket with %s\n", script)
+			}
+		}
+		code = cliExitCode(runErr)
+		if ctx.Err() != nil {
+			code = ExitInterrupted
+		}
 	}
-	return 0
+	elapsed := time.Since(start)
+	if skipped := rl.skippedTasks(); len(skipped) > 0 {
+		fmt.Fprintf(out.Stderr, "skipped: %s\n", strings.Join(skipped, ", "))
+	}
+	switch *output {
+	case outputTAP:
+		writeTAP(os.Stdout, tapEntries(rl.visibleTasks(), funcToRun.name, elapsed, runErr))
+	case outputJSON:
+		rl.recordDone(funcToRun.name, cwd, elapsed, code)
+	}
+	timings := newTimingSummary(rl.visibleTasks(), funcToRun.name, elapsed, runErr)
+	switch {
+	case *summaryFlag == summaryJSON:
+		if err := timings.writeJSON(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: write summary: %v\n", err)
+		}
+	case *summaryFlag == summaryText, *summaryFlag == summaryAuto && len(timings.Tasks) > 1:
+		_ = timings.writeText(out.Stdout)
+	}
+	if *profileFlag {
+		if err := rl.writeProfile(FromPocketDir(profileFile)); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		} else {
+			fmt.Fprintf(out.Stderr, "profile written to %s/%s (open it in ui.perfetto.dev or chrome://tracing)\n", DirName, profileFile)
+		}
+	}
+	if prComment != nil {
+		summary := runSummary{
+			Task:  funcToRun.name,
+			Path:  cwd,
+			Tasks: tapEntries(rl.visibleTasks(), funcToRun.name, elapsed, runErr),
+			Err:   runErr,
+		}
+		if err := postRunComment(ctx, *prComment, summary); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: post pull request comment: %v\n", err)
+		}
+	}
+	if notify {
+		run := newNotifyRun(funcToRun.name, cwd, tapEntries(rl.visibleTasks(), funcToRun.name, elapsed, runErr), elapsed, runErr)
+		if err := sendNotifications(ctx, plan.Config.Notify, run); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	if auditLog != nil {
+		if err := auditLog.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: close audit log: %v\n", err)
+		}
+		if err := pruneAuditLog(FromAuditLog(), plan.Config.Audit.MaxAge, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: prune audit log: %v\n", err)
+		}
+	}
+	if rl != nil {
+		if err := rl.close(code); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: close run log: %v\n", err)
+		}
+		if plan.Config != nil && plan.Config.RunLog != nil {
+			if err := pruneRunLogs(FromLogsDir(), *plan.Config.RunLog, time.Now()); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: prune run logs: %v\n", err)
+			}
+		}
+	}
+	return code
+}
+
+// defaultTask returns the configured default task if it is in funcMap,
+// i.e. available in the current directory.
+func defaultTask(plan *ConfigPlan, funcMap map[string]*TaskDef) (*TaskDef, bool) {
+	if plan.DefaultTask == nil {
+		return nil, false
+	}
+	f, ok := funcMap[plan.DefaultTask.name]
+	return f, ok
 }
 
 // filterFuncsByCwd returns functions visible in the given directory.
@@ -165,14 +554,47 @@ func isFuncVisibleIn(funcName, cwd string, pathMappings map[string]*PathFilter)
 	return cwd == "."
 }
 
-// printHelp prints the help message with available functions.
-func printHelp(funcs []*TaskDef, autoRunNames map[string]bool, builtinFuncs []*TaskDef) {
-	fmt.Println("Usage: pok [flags] <task> [args...]")
-	fmt.Println()
-	fmt.Println("Flags:")
-	fmt.Println("  -h         show help (use -h <task> for task help)")
-	fmt.Println("  -v         verbose output")
-	fmt.Println()
+// printHelp prints the help message with the functions visible in cwd.
+func printHelp(plan *ConfigPlan, funcs []*TaskDef, cwd string, flat bool) {
+	writeHelp(os.Stdout, plan, funcs, cwd, flat)
+}
+
+// writeFlags writes a line per flag of fs, with the default value of flags
+// whose default isn't the zero value.
+func writeFlags(out io.Writer, fs *flag.FlagSet) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fs.VisitAll(func(f *flag.Flag) {
+		usage := f.Usage
+		switch f.DefValue {
+		case "", "false", "0":
+		default:
+			usage += fmt.Sprintf(" (default: %s)", f.DefValue)
+		}
+		fmt.Fprintf(w, "  -%s\t%s\n", f.Name, usage)
+	})
+	w.Flush()
+}
+
+// taskGroup returns the group the task is listed under in help.
+func taskGroup(f *TaskDef) string {
+	return cmp.Or(f.group, "custom")
+}
+
+// writeHelp writes the help message. Outside the git root, it names the
+// context, and each task is annotated with the paths it runs in. Tasks are
+// listed by group, with auto-run tasks marked, or as auto-run and manual
+// tasks if flat is set.
+func writeHelp(out io.Writer, plan *ConfigPlan, funcs []*TaskDef, cwd string, flat bool) {
+	fmt.Fprintln(out, "Usage: pok [flags] <task> [args...] [path=<dir>...]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	writeFlags(out, flag.CommandLine)
+	fmt.Fprintln(out)
+
+	if cwd != "" && cwd != "." {
+		fmt.Fprintf(out, "Context: %s (root-only tasks are hidden; run ./pok -h from the git root)\n", cwd)
+		fmt.Fprintln(out)
+	}
 
 	// Separate visible tasks into auto-run and manual.
 	var autorun, other []*TaskDef
@@ -180,12 +602,18 @@ func printHelp(funcs []*TaskDef, autoRunNames map[string]bool, builtinFuncs []*T
 		if f.hidden {
 			continue
 		}
-		if autoRunNames[f.name] {
+		if plan.AutoRunNames[f.name] {
 			autorun = append(autorun, f)
 		} else {
 			other = append(other, f)
 		}
 	}
+	if plan.isCustomDefault() {
+		other = append(other, plan.DefaultTask)
+	}
+	isDefault := func(f *TaskDef) bool {
+		return plan.DefaultTask != nil && f.name == plan.DefaultTask.name
+	}
 	sort.Slice(autorun, func(i, j int) bool {
 		return autorun[i].name < autorun[j].name
 	})
@@ -193,75 +621,232 @@ func printHelp(funcs []*TaskDef, autoRunNames map[string]bool, builtinFuncs []*T
 		return other[i].name < other[j].name
 	})
 
-	if len(autorun) > 0 {
-		fmt.Println("Tasks (auto-run):")
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		for _, f := range autorun {
-			fmt.Fprintf(w, "  %s\t%s\n", f.name, f.usage)
+	writeTasks := func(tasks []*TaskDef, markAutoRun bool) {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, f := range tasks {
+			fmt.Fprintf(w, "  %s\t%s", f.name, f.usage)
+			if isDefault(f) {
+				fmt.Fprint(w, " (default)")
+			}
+			if markAutoRun && plan.AutoRunNames[f.name] {
+				fmt.Fprint(w, " (auto-run)")
+			}
+			if paths := taskPaths(plan, f.name, cwd); len(paths) > 0 {
+				fmt.Fprintf(w, " [%s]", strings.Join(paths, ", "))
+			}
+			fmt.Fprintln(w)
 		}
 		w.Flush()
 	}
 
-	if len(other) > 0 {
+	if flat {
 		if len(autorun) > 0 {
-			fmt.Println()
+			fmt.Fprintln(out, "Tasks (auto-run):")
+			writeTasks(autorun, false)
 		}
-		fmt.Println("Tasks (manual):")
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		for _, f := range other {
-			fmt.Fprintf(w, "  %s\t%s\n", f.name, f.usage)
+		if len(other) > 0 {
+			if len(autorun) > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintln(out, "Tasks (manual):")
+			writeTasks(other, false)
+		}
+	} else {
+		groups := make(map[string][]*TaskDef)
+		for _, f := range append(slices.Clone(autorun), other...) {
+			groups[taskGroup(f)] = append(groups[taskGroup(f)], f)
+		}
+		// Groups are sorted by name, with the config's own tasks last.
+		order := slices.Sorted(maps.Keys(groups))
+		order = append(slices.DeleteFunc(order, func(g string) bool { return g == "custom" }), "custom")
+		first := true
+		for _, group := range order {
+			tasks := groups[group]
+			if len(tasks) == 0 {
+				continue
+			}
+			if !first {
+				fmt.Fprintln(out)
+			}
+			first = false
+			sort.Slice(tasks, func(i, j int) bool {
+				return tasks[i].name < tasks[j].name
+			})
+			fmt.Fprintf(out, "Tasks (%s):\n", group)
+			writeTasks(tasks, true)
 		}
-		w.Flush()
 	}
 
 	// Sort and display built-in tasks.
+	builtinFuncs := plan.BuiltinTasks
 	if len(builtinFuncs) > 0 {
 		if len(autorun) > 0 || len(other) > 0 {
-			fmt.Println()
+			fmt.Fprintln(out)
 		}
-		fmt.Println("Tasks (built-in):")
+		fmt.Fprintln(out, "Tasks (built-in):")
 		sort.Slice(builtinFuncs, func(i, j int) bool {
 			return builtinFuncs[i].name < builtinFuncs[j].name
 		})
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
 		for _, f := range builtinFuncs {
-			fmt.Fprintf(w, "  %s\t%s\n", f.name, f.usage)
+			fmt.Fprintf(w, "  %s\t%s", f.name, f.usage)
+			if isDefault(f) {
+				fmt.Fprint(w, " (default)")
+			}
+			fmt.Fprintln(w)
 		}
 		w.Flush()
 	}
 
 	if len(autorun) == 0 && len(other) == 0 && len(builtinFuncs) == 0 {
-		fmt.Println("No tasks available.")
+		fmt.Fprintln(out, "No tasks available.")
+	}
+}
+
+// taskPaths returns the paths a task runs in when invoked from cwd, or nil
+// for tasks that only run at the git root.
+func taskPaths(plan *ConfigPlan, name, cwd string) []string {
+	if dir, ok := plan.TaskDirs[name]; ok {
+		return []string{dir}
+	}
+	if pf, ok := plan.PathMappings[name]; ok {
+		return pf.ResolveFor(cmp.Or(cwd, "."))
 	}
+	return nil
+}
+
+// extractListArg removes the arguments for key from the arguments of a
+// task and returns the remaining arguments and the values. A value is given
+// as key=<value>, -key=<value> or -key <value>, can be a comma-separated
+// list and can be repeated. Arguments after "--" are left as is.
+func extractListArg(args []string, key string) (rest, values []string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(rest, args[i:]...), values, nil
+		}
+		k, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if k != key || (!strings.HasPrefix(arg, "-") && !hasValue) {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return nil, nil, fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			value = args[i]
+		}
+		values = append(values, splitList(value)...)
+	}
+	return rest, values, nil
+}
+
+// extractBoolArg removes the boolean argument key from args, given as -key
+// (true) or key=<bool>, and returns its last value, or nil if there is none.
+// Arguments after a bare "--" are left as they are.
+func extractBoolArg(args []string, key string) (rest []string, value *bool, err error) {
+	for i, arg := range args {
+		if arg == "--" {
+			return append(rest, args[i:]...), value, nil
+		}
+		k, v, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if k != key || (!strings.HasPrefix(arg, "-") && !hasValue) {
+			rest = append(rest, arg)
+			continue
+		}
+		b := true
+		if hasValue {
+			if b, err = strconv.ParseBool(v); err != nil {
+				return nil, nil, fmt.Errorf("invalid %s (want true or false)", arg)
+			}
+		}
+		value = &b
+	}
+	return rest, value, nil
+}
+
+// validateSkip checks that each task to skip is a task of the config.
+func validateSkip(plan *ConfigPlan, names []string) error {
+	for _, name := range names {
+		if !slices.ContainsFunc(plan.Tasks, func(f *TaskDef) bool { return f.name == name }) {
+			return fmt.Errorf("-skip: unknown task %s", name)
+		}
+	}
+	return nil
+}
+
+// validateTaskPaths checks that the task runs in each of paths and returns
+// them cleaned and deduplicated. Paths are relative to the git root.
+func validateTaskPaths(plan *ConfigPlan, name string, paths []string) ([]string, error) {
+	allowed := taskPaths(plan, name, ".")
+	if allowed == nil {
+		allowed = []string{"."}
+	}
+	var result []string
+	for _, p := range paths {
+		p = cleanDir(p)
+		if !slices.Contains(allowed, p) {
+			return nil, fmt.Errorf("task %s does not run in %s (runs in: %s)", name, p, strings.Join(allowed, ", "))
+		}
+		if !slices.Contains(result, p) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
 }
 
 // printFuncHelp prints help for a specific function.
 func printFuncHelp(f *TaskDef) {
-	fmt.Printf("%s - %s\n", f.name, f.usage)
+	writeFuncHelp(os.Stdout, f)
+}
 
-	// Check if function has options attached.
-	if f.opts == nil {
-		fmt.Println("\nThis function accepts no options.")
-		return
+// writeFuncHelp writes the help for a function: usage, long usage,
+// options and examples.
+func writeFuncHelp(out io.Writer, f *TaskDef) {
+	fmt.Fprintf(out, "%s - %s\n", f.name, f.usage)
+	if f.args != "" {
+		fmt.Fprintf(out, "\nUsage: %s [options] %s\n", f.name, f.args)
+	}
+	if f.longUsage != "" {
+		fmt.Fprintf(out, "\n%s\n", strings.TrimRight(f.longUsage, "\n"))
+	}
+	if len(f.tags) > 0 {
+		fmt.Fprintf(out, "\nTags: %s\n", strings.Join(f.tags, ", "))
 	}
 
-	info, err := inspectArgs(f.opts)
-	if err != nil || info == nil || len(info.Fields) == 0 {
-		fmt.Println("\nThis function accepts no options.")
-		return
+	// Check if function has options attached.
+	var info *argsInfo
+	if f.opts != nil {
+		if i, err := inspectArgs(f.opts); err == nil {
+			info = i
+		}
+	}
+	if info == nil || len(info.Fields) == 0 {
+		fmt.Fprintln(out, "\nThis function accepts no options.")
+	} else {
+		fmt.Fprintln(out, "\nOptions:")
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, field := range info.Fields {
+			defaultStr := formatArgDefault(field.Default)
+			if field.Usage != "" {
+				fmt.Fprintf(w, "  -%s\t%s (default: %s)\n", field.Name, field.Usage, defaultStr)
+			} else {
+				fmt.Fprintf(w, "  -%s\t(default: %s)\n", field.Name, defaultStr)
+			}
+		}
+		w.Flush()
 	}
 
-	fmt.Println("\nOptions:")
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	for _, field := range info.Fields {
-		defaultStr := formatArgDefault(field.Default)
-		if field.Usage != "" {
-			fmt.Fprintf(w, "  -%s\t%s (default: %s)\n", field.Name, field.Usage, defaultStr)
-		} else {
-			fmt.Fprintf(w, "  -%s\t(default: %s)\n", field.Name, defaultStr)
+	if len(f.examples) > 0 {
+		fmt.Fprintln(out, "\nExamples:")
+		for _, ex := range f.examples {
+			if ex.Description != "" {
+				fmt.Fprintf(out, "  # %s\n", ex.Description)
+			}
+			fmt.Fprintf(out, "  %s\n", ex.Command)
 		}
 	}
-	w.Flush()
 }
 
 // parseTaskArgs and other option parsing functions are in options.go,
@@ -1,8 +1,12 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"context"
+	"flag"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -51,6 +55,67 @@ func TestPrintFuncHelp_WithArgs(t *testing.T) {
 	}
 }
 
+func TestWriteFuncHelp_LongUsageAndExamples(t *testing.T) {
+	fn := Task("greet", "print a greeting", func(_ context.Context) error { return nil },
+		Opts(CLITestOptions{Name: "world", Count: 5}),
+		WithLongUsage("Prints a greeting.\n\nRepeats it count times.\n"),
+		WithExample("./pok greet -name=pocket", "greet pocket"),
+		WithExample("./pok greet -count=2", ""),
+	)
+
+	var buf strings.Builder
+	writeFuncHelp(&buf, fn)
+
+	want := `greet - print a greeting
+
+Prints a greeting.
+
+Repeats it count times.
+
+Options:
+  -name   who to greet (default: "world")
+  -count  how many times (default: 5)
+
+Examples:
+  # greet pocket
+  ./pok greet -name=pocket
+  ./pok greet -count=2
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected help:\n got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteFuncHelp_ExamplesWithoutOptions(t *testing.T) {
+	fn := Task("hello", "say hello", func(_ context.Context) error { return nil },
+		WithExample("./pok hello", ""))
+
+	var buf strings.Builder
+	writeFuncHelp(&buf, fn)
+
+	want := "hello - say hello\n\nThis function accepts no options.\n\nExamples:\n  ./pok hello\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected help:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestTaskOpts_ExamplesNotShared(t *testing.T) {
+	base := Task("hello", "say hello", func(_ context.Context) error { return nil },
+		WithExample("./pok hello", ""))
+	a := Clone(base, WithExample("./pok hello -a", ""))
+	b := Clone(base, WithExample("./pok hello -b", ""))
+
+	if len(base.Examples()) != 1 {
+		t.Errorf("expected base to keep 1 example, got %d", len(base.Examples()))
+	}
+	if got := a.Examples()[1].Command; got != "./pok hello -a" {
+		t.Errorf("unexpected example for a: %q", got)
+	}
+	if got := b.Examples()[1].Command; got != "./pok hello -b" {
+		t.Errorf("unexpected example for b: %q", got)
+	}
+}
+
 func TestParseTaskArgs(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -195,6 +260,100 @@ func TestParseTaskArgs(t *testing.T) {
 	}
 }
 
+func TestSplitPositionalArgs(t *testing.T) {
+	info, err := inspectArgs(affectedOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withName, err := inspectArgs(CLITestOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name           string
+		args           []string
+		info           *argsInfo
+		wantOpts       []string
+		wantPositional []string
+	}{
+		{"only positional", []string{"a.go", "b.go"}, info, []string{}, []string{"a.go", "b.go"}},
+		{"bool option", []string{"-json", "a.go"}, info, []string{"-json"}, []string{"a.go"}},
+		{"option value", []string{"-name", "x", "a.go"}, withName, []string{"-name", "x"}, []string{"a.go"}},
+		{"option with equals", []string{"-name=x", "a.go"}, withName, []string{"-name=x"}, []string{"a.go"}},
+		{"separator", []string{"-json", "--", "-a.go"}, info, []string{"-json"}, []string{"-a.go"}},
+		{"no positional", []string{"-json"}, info, []string{"-json"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, positional := splitPositionalArgs(tt.args, tt.info)
+			if strings.Join(opts, " ") != strings.Join(tt.wantOpts, " ") {
+				t.Errorf("opts = %q, want %q", opts, tt.wantOpts)
+			}
+			if strings.Join(positional, " ") != strings.Join(tt.wantPositional, " ") {
+				t.Errorf("positional = %q, want %q", positional, tt.wantPositional)
+			}
+		})
+	}
+}
+
+func TestExtractListArg(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantRest  []string
+		wantPaths []string
+		wantErr   bool
+	}{
+		{args: []string{"path=services/api", "-short"}, wantRest: []string{"-short"}, wantPaths: []string{"services/api"}},
+		{args: []string{"-path", "a", "--path=b", "-path=c"}, wantPaths: []string{"a", "b", "c"}},
+		{args: []string{"-name", "path"}, wantRest: []string{"-name", "path"}},
+		{args: []string{"--", "path=a"}, wantRest: []string{"--", "path=a"}},
+		{args: []string{"-path"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		rest, paths, err := extractListArg(tt.args, "path")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("extractListArg(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if strings.Join(rest, " ") != strings.Join(tt.wantRest, " ") || strings.Join(paths, " ") != strings.Join(tt.wantPaths, " ") {
+			t.Errorf("extractListArg(%q) = %q, %q, want %q, %q", tt.args, rest, paths, tt.wantRest, tt.wantPaths)
+		}
+	}
+}
+
+func TestExtractBoolArg(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantRest []string
+		wantFix  string
+		wantErr  bool
+	}{
+		{args: []string{"fix=true", "-short"}, wantRest: []string{"-short"}, wantFix: "true"},
+		{args: []string{"-fix"}, wantFix: "true"},
+		{args: []string{"-fix", "--fix=false"}, wantFix: "false"},
+		{args: []string{"-skip-fix", "fix"}, wantRest: []string{"-skip-fix", "fix"}, wantFix: "unset"},
+		{args: []string{"--", "fix=true"}, wantRest: []string{"--", "fix=true"}, wantFix: "unset"},
+		{args: []string{"fix=maybe"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		rest, fix, err := extractBoolArg(tt.args, "fix")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("extractBoolArg(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		got := "unset"
+		if fix != nil {
+			got = strconv.FormatBool(*fix)
+		}
+		if strings.Join(rest, " ") != strings.Join(tt.wantRest, " ") || got != tt.wantFix {
+			t.Errorf("extractBoolArg(%q) = %q, %s, want %q, %s", tt.args, rest, got, tt.wantRest, tt.wantFix)
+		}
+	}
+}
+
 func TestDetectCwd_WithEnvVar(t *testing.T) {
 	// Set the environment variable.
 	os.Setenv("POK_CONTEXT", "proj1")
@@ -218,6 +377,29 @@ func TestDetectCwd_WithoutEnvVar(t *testing.T) {
 	}
 }
 
+func TestResolveContext(t *testing.T) {
+	moduleDirs := []string{".", "services/api"}
+	tests := []struct {
+		dir     string
+		want    string
+		wantErr bool
+	}{
+		{dir: "services/api", want: "services/api"},
+		{dir: "./services/api/", want: "services/api"},
+		{dir: ".", want: "."},
+		{dir: "services", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := resolveContext(tt.dir, moduleDirs)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("resolveContext(%q) error = %v, wantErr %v", tt.dir, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("resolveContext(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
 func TestFilterFuncsByCwd(t *testing.T) {
 	fn1 := Task("fn1", "func 1", func(_ context.Context) error { return nil })
 	fn2 := Task("fn2", "func 2", func(_ context.Context) error { return nil })
@@ -347,3 +529,150 @@ func TestFilterFuncsByCwd_ManualRunPaths(t *testing.T) {
 		t.Errorf("root: expected 0 visible funcs, got %d: %v", len(rootVisible), rootVisible)
 	}
 }
+
+func TestWriteHelp_Context(t *testing.T) {
+	lint := Task("lint", "lint code", func(_ context.Context) error { return nil })
+	release := Task("release", "cut a release", func(_ context.Context) error { return nil })
+	plan := BuildConfigPlan(Config{
+		AutoRun:   RunIn(lint, Include(".", "services/api", "services/web")),
+		ManualRun: []Runnable{release},
+	})
+
+	tests := []struct {
+		cwd     string
+		want    []string
+		notWant []string
+	}{
+		{
+			cwd:     ".",
+			want:    []string{"lint code (auto-run) [., services/api, services/web]", "release  cut a release\n"},
+			notWant: []string{"Context:"},
+		},
+		{
+			cwd:     "services/api",
+			want:    []string{"Context: services/api", "lint code (auto-run) [services/api]\n"},
+			notWant: []string{"release", "services/web"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cwd, func(t *testing.T) {
+			var b strings.Builder
+			writeHelp(&b, plan, filterFuncsByCwd(plan.Tasks, tt.cwd, plan.PathMappings), tt.cwd, false)
+			got := b.String()
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("help missing %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("help contains %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteHelp_DefaultTask(t *testing.T) {
+	lint := Task("lint", "lint code", func(_ context.Context) error { return nil })
+	quick := Task("quick", "run quick checks", func(_ context.Context) error { return nil })
+
+	plan := BuildConfigPlan(Config{AutoRun: lint, ManualRun: []Runnable{quick}, DefaultTask: "quick"})
+	var b strings.Builder
+	writeHelp(&b, plan, filterFuncsByCwd(plan.Tasks, ".", plan.PathMappings), ".", false)
+	if got := b.String(); !strings.Contains(got, "quick  run quick checks (default)\n") || strings.Contains(got, "lint code (default)") {
+		t.Errorf("expected only quick to be marked as default:\n%s", got)
+	}
+
+	plan = BuildConfigPlan(Config{AutoRun: lint, DefaultTask: Serial(lint)})
+	b.Reset()
+	writeHelp(&b, plan, filterFuncsByCwd(plan.Tasks, ".", plan.PathMappings), ".", false)
+	if got := b.String(); !strings.Contains(got, "default  run the default tasks (default)\n") {
+		t.Errorf("expected the default pipeline to be listed:\n%s", got)
+	}
+}
+
+func TestWriteFlags(t *testing.T) {
+	fs := flag.NewFlagSet("pok", flag.ContinueOnError)
+	fs.Bool("v", false, "verbose output")
+	fs.String("color", "auto", "colored output")
+	fs.Bool("keep-going", false, "run the other tasks when a task fails")
+
+	var b strings.Builder
+	writeFlags(&b, fs)
+	want := "" +
+		"  -color       colored output (default: auto)\n" +
+		"  -keep-going  run the other tasks when a task fails\n" +
+		"  -v           verbose output\n"
+	if got := b.String(); got != want {
+		t.Errorf("writeFlags() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteHelp_Groups(t *testing.T) {
+	noop := func(_ context.Context) error { return nil }
+	goLint := Task("go-lint", "lint Go code", noop, WithGroup("golang"))
+	goTest := Task("go-test", "test Go code", noop, WithGroup("golang"))
+	pyLint := Task("py-lint", "lint Python code", noop, WithGroup("python"))
+	mdFormat := Task("md-format", "format Markdown", noop, WithGroup("markdown"))
+	release := Task("release", "cut a release", noop)
+	plan := BuildConfigPlan(Config{
+		AutoRun:   Serial(RunIn(goLint, Include(".", "services/api")), pyLint, mdFormat),
+		ManualRun: []Runnable{goTest, release},
+	})
+	funcs := filterFuncsByCwd(plan.Tasks, ".", plan.PathMappings)
+
+	var b strings.Builder
+	writeHelp(&b, plan, funcs, ".", false)
+	got := b.String()
+	wantOrder := []string{
+		"Tasks (golang):\n  go-lint  lint Go code (auto-run) [., services/api]\n  go-test  test Go code\n",
+		"Tasks (markdown):\n  md-format  format Markdown (auto-run)\n",
+		"Tasks (python):\n  py-lint  lint Python code (auto-run)\n",
+		"Tasks (custom):\n  release  cut a release\n",
+		"Tasks (built-in):",
+	}
+	last := -1
+	for _, s := range wantOrder {
+		i := strings.Index(got, s)
+		if i < 0 || i < last {
+			t.Fatalf("help missing %q or out of order:\n%s", s, got)
+		}
+		last = i
+	}
+
+	b.Reset()
+	writeHelp(&b, plan, funcs, ".", true)
+	got = b.String()
+	for _, s := range []string{
+		"Tasks (auto-run):\n  go-lint    lint Go code [., services/api]\n  md-format  format Markdown\n  py-lint    lint Python code\n",
+		"Tasks (manual):\n  go-test  test Go code\n  release  cut a release\n",
+	} {
+		if !strings.Contains(got, s) {
+			t.Errorf("flat help missing %q:\n%s", s, got)
+		}
+	}
+	if strings.Contains(got, "Tasks (golang):") || strings.Contains(got, "(auto-run) ") {
+		t.Errorf("flat help should not group tasks:\n%s", got)
+	}
+}
+
+func TestTaskGroup(t *testing.T) {
+	noop := func(_ context.Context) error { return nil }
+	if got := taskGroup(Task("release", "cut a release", noop)); got != "custom" {
+		t.Errorf("taskGroup() of a task created by pocket = %q, want custom", got)
+	}
+	if got := taskGroup(Clone(Task("lint", "lint", noop), WithGroup("lint"))); got != "lint" {
+		t.Errorf("taskGroup() with WithGroup = %q, want lint", got)
+	}
+	for fn, want := range map[string]string{
+		"github.com/fredrikaverpil/pocket/tasks/golang.init":         "github.com/fredrikaverpil/pocket/tasks/golang",
+		"github.com/fredrikaverpil/pocket/tasks/git.HooksTask.func1": "github.com/fredrikaverpil/pocket/tasks/git",
+		"example.com/m/v2/lint.(*T).Task":                            "example.com/m/v2/lint",
+		"main.init":                                                  "main",
+	} {
+		if got := funcPackage(fn); got != want {
+			t.Errorf("funcPackage(%q) = %q, want %q", fn, got, want)
+		}
+	}
+}
@@ -1,14 +1,23 @@
+// SPDX-License-Identifier: MIT
+
 package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 
 	pocket "github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
 	"github.com/fredrikaverpil/pocket/internal/scaffold"
+	"github.com/fredrikaverpil/pocket/internal/testenv"
+	"github.com/fredrikaverpil/pocket/internal/uninstall"
 )
 
 func main() {
@@ -19,7 +28,21 @@ func main() {
 
 	switch os.Args[1] {
 	case "init":
-		if err := runInit(); err != nil {
+		fs := flag.NewFlagSet("init", flag.ExitOnError)
+		repair := fs.Bool("repair", false, "recreate missing files of an existing .pocket/")
+		var modules stringList
+		fs.Var(&modules, "module", "Go module `dir` to set up with a shim of its own (repeatable)")
+		scanModules := fs.Bool("scan-modules", false, "set up every Go module (directory with a go.mod)")
+		_ = fs.Parse(os.Args[2:])
+		if err := runInit(*repair, modules, *scanModules); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	case "uninstall":
+		fs := flag.NewFlagSet("uninstall", flag.ExitOnError)
+		dryRun := fs.Bool("dry-run", false, "only list what would be removed")
+		_ = fs.Parse(os.Args[2:])
+		if err := runUninstall(*dryRun); err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
 		}
@@ -36,19 +59,39 @@ func printUsage() {
 	fmt.Println(`pocket - bootstrap pocket in your project
 
 Usage:
-  pocket init      Initialize .pocket/ in current directory
+  pocket init            Initialize .pocket/ in current directory
+  pocket init -repair    Recreate missing files of an existing .pocket/,
+                         keeping config.go and other existing files
+  pocket init -module <dir>
+                         Also set up the Go module in <dir> with a shim of
+                         its own (repeatable)
+  pocket init -scan-modules
+                         Set up every Go module found in the repository
+  pocket uninstall       Remove .pocket/, shims, generated files and git hooks
+  pocket uninstall -dry-run
+                         List what uninstall would remove
 
 Examples:
-  go run github.com/fredrikaverpil/pocket/cmd/pocket@latest init`)
+  go run github.com/fredrikaverpil/pocket/cmd/pocket@latest init
+  go run github.com/fredrikaverpil/pocket/cmd/pocket@latest init -module services/api -module services/web`)
 }
 
-func runInit() error {
-	// Check .pocket doesn't already exist
-	if _, err := os.Stat(".pocket"); err == nil {
-		return fmt.Errorf(".pocket/ already exists")
+func runInit(repair bool, modules []string, scanModules bool) error {
+	// Only repair an existing .pocket
+	if _, err := os.Stat(".pocket"); err == nil && !repair {
+		return fmt.Errorf(".pocket/ already exists (use -repair to recreate missing files)")
+	}
+
+	modules, err := moduleDirs(modules, scanModules)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("Initializing pocket...")
+	if repair {
+		fmt.Println("Repairing pocket...")
+	} else {
+		fmt.Println("Initializing pocket...")
+	}
 
 	// Create .pocket directory
 	if err := os.MkdirAll(".pocket", 0o755); err != nil {
@@ -56,20 +99,56 @@ func runInit() error {
 	}
 
 	// Create go.mod
-	fmt.Println("  Creating .pocket/go.mod")
-	if err := runCommand(".pocket", "go", "mod", "init", "pocket"); err != nil {
-		return fmt.Errorf("go mod init: %w", err)
+	goModPath := filepath.Join(".pocket", "go.mod")
+	if _, err := os.Stat(goModPath); err == nil {
+		fmt.Println("  Keeping .pocket/go.mod")
+	} else {
+		fmt.Println("  Creating .pocket/go.mod")
+		if err := runCommand(".pocket", "go", "mod", "init", "pocket"); err != nil {
+			return fmt.Errorf("go mod init: %w", err)
+		}
 	}
 
 	// Get dependencies
-	fmt.Println("  Adding github.com/fredrikaverpil/pocket@latest")
-	if err := runCommand(".pocket", "go", "get", "github.com/fredrikaverpil/pocket@latest"); err != nil {
-		return fmt.Errorf("go get: %w", err)
+	goMod, err := os.ReadFile(goModPath)
+	if err != nil {
+		return fmt.Errorf("reading .pocket/go.mod: %w", err)
+	}
+	switch {
+	case requiresPocket(goMod):
+		// Keep the pocket version (and any replace directive) of the repo.
+	case testenv.Getenv(testenv.ReplaceEnv) != "":
+		dir := testenv.Getenv(testenv.ReplaceEnv)
+		fmt.Printf("  Using github.com/fredrikaverpil/pocket from %s\n", dir)
+		if err := runCommand(".pocket", "go", "mod", "edit",
+			"-require=github.com/fredrikaverpil/pocket@v0.0.0",
+			"-replace=github.com/fredrikaverpil/pocket="+dir,
+		); err != nil {
+			return fmt.Errorf("go mod edit: %w", err)
+		}
+	default:
+		fmt.Println("  Adding github.com/fredrikaverpil/pocket@latest")
+		if err := runCommand(".pocket", "go", "get", "github.com/fredrikaverpil/pocket@latest"); err != nil {
+			return fmt.Errorf("go get: %w", err)
+		}
+	}
+
+	// config.go and .gitignore are only created if missing.
+	for _, name := range []string{"config.go", ".gitignore"} {
+		if _, err := os.Stat(filepath.Join(".pocket", name)); err == nil {
+			fmt.Printf("  Keeping .pocket/%s\n", name)
+		}
 	}
 
 	// Generate all scaffold files (config.go, .gitignore, main.go, shim)
 	// Detect platform and generate appropriate shim(s)
 	fmt.Println("  Generating scaffold files")
+	for _, dir := range modules {
+		fmt.Printf("  Adding module %s\n", dir)
+	}
+	if err := scaffold.GenerateConfig(modules); err != nil {
+		return fmt.Errorf("generating scaffold: %w", err)
+	}
 	cfg := &pocket.Config{
 		Shim: &pocket.ShimConfig{
 			Posix:      runtime.GOOS != "windows",
@@ -77,11 +156,17 @@ func runInit() error {
 			PowerShell: runtime.GOOS == "windows",
 		},
 	}
-	// Create minimal ConfigPlan for initial scaffold (just root directory)
+	// Create minimal ConfigPlan for initial scaffold (root directory and
+	// the modules pre-populated in config.go)
 	plan := &pocket.ConfigPlan{
 		Config:            cfg,
 		ModuleDirectories: []string{"."},
 	}
+	for _, dir := range modules {
+		if !slices.Contains(plan.ModuleDirectories, dir) {
+			plan.ModuleDirectories = append(plan.ModuleDirectories, dir)
+		}
+	}
 	if _, err := scaffold.GenerateAll(plan); err != nil {
 		return fmt.Errorf("generating scaffold: %w", err)
 	}
@@ -93,6 +178,14 @@ func runInit() error {
 	}
 
 	fmt.Println()
+	if repair {
+		shim := "./pok"
+		if runtime.GOOS == "windows" {
+			shim = ".\\pok"
+		}
+		fmt.Printf("Done! Run %s generate to regenerate the shims configured in config.go.\n", shim)
+		return nil
+	}
 	fmt.Println("Done! You can now run:")
 	if runtime.GOOS == "windows" {
 		fmt.Println("  .\\pok -h          # list available tasks")
@@ -107,6 +200,82 @@ func runInit() error {
 	return nil
 }
 
+// moduleDirs returns the Go module directories to set up, relative to the
+// git root: the given dirs and, with scan, every directory with a go.mod.
+func moduleDirs(dirs []string, scan bool) ([]string, error) {
+	var modules []string
+	for _, dir := range dirs {
+		dir = filepath.ToSlash(filepath.Clean(dir))
+		if filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+			return nil, fmt.Errorf("module %s: must be a directory inside the repository", dir)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+			return nil, fmt.Errorf("module %s: no go.mod found", dir)
+		}
+		modules = append(modules, dir)
+	}
+	if scan {
+		modules = append(modules, pocket.DetectByFile("go.mod")...)
+	}
+	slices.Sort(modules)
+	return slices.Compact(modules), nil
+}
+
+// stringList is a flag.Value collecting the values of a repeated flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func runUninstall(dryRun bool) error {
+	root, err := gitroot.Dir()
+	if err != nil {
+		return fmt.Errorf("finding git root: %w", err)
+	}
+	plan, err := uninstall.Find(root)
+	if err != nil {
+		return err
+	}
+	if plan.Empty() {
+		fmt.Println("Nothing to remove.")
+		return nil
+	}
+	if dryRun {
+		fmt.Println("Would remove:")
+		plan.Print(os.Stdout)
+		return nil
+	}
+	fmt.Println("Removing:")
+	plan.Print(os.Stdout)
+	return plan.Apply(root)
+}
+
+// requiresPocket reports whether the go.mod content requires the pocket module.
+func requiresPocket(goMod []byte) bool {
+	inRequire := false
+	for _, line := range strings.Split(string(goMod), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == ")":
+			inRequire = false
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == "require":
+			if len(fields) > 1 && fields[1] == "github.com/fredrikaverpil/pocket" {
+				return true
+			}
+		case inRequire && fields[0] == "github.com/fredrikaverpil/pocket":
+			return true
+		}
+	}
+	return false
+}
+
 func runCommand(dir, name string, args ...string) error {
 	cmd := exec.CommandContext(context.Background(), name, args...)
 	cmd.Dir = dir
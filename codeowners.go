@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// codeownersPaths are the locations GitHub reads CODEOWNERS from, in order.
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// codeownersRule is a single CODEOWNERS line.
+type codeownersRule struct {
+	glob   string // pattern translated to a MatchGlob pattern
+	owners []string
+}
+
+// codeowners holds the rules of a CODEOWNERS file.
+type codeowners []codeownersRule
+
+// loadCodeowners reads the CODEOWNERS file of the repository. It returns nil
+// if there is none.
+func loadCodeowners() (codeowners, error) {
+	for _, p := range codeownersPaths {
+		data, err := os.ReadFile(FromGitRoot(p))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return parseCodeowners(string(data)), nil
+	}
+	return nil, nil
+}
+
+// parseCodeowners parses the rules of a CODEOWNERS file. Patterns follow
+// GitHub's gitignore-like syntax: a pattern with a leading or inner "/" is
+// anchored to the root, other patterns match at any depth, and a pattern
+// matching a directory matches everything below it.
+func parseCodeowners(data string) codeowners {
+	var rules codeowners
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var owners []string
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			owners = append(owners, owner)
+		}
+		pattern := fields[0]
+		anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+		glob := strings.TrimPrefix(pattern, "/")
+		if strings.HasSuffix(glob, "/") {
+			glob += "**"
+		}
+		if !anchored {
+			glob = "**/" + glob
+		}
+		rules = append(rules, codeownersRule{glob: glob, owners: owners})
+	}
+	return rules
+}
+
+// owners returns the owners of file, relative to the git root. As on GitHub,
+// the last matching rule wins.
+func (c codeowners) owners(file string) []string {
+	for _, rule := range slices.Backward(c) {
+		if MatchGlob(rule.glob, file) || MatchGlob(rule.glob+"/**", file) {
+			return rule.owners
+		}
+	}
+	return nil
+}
+
+// ownersOf returns the sorted owners of files.
+func (c codeowners) ownersOf(files []string) []string {
+	var result []string
+	for _, file := range files {
+		result = append(result, c.owners(file)...)
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+// ownedBy returns the files owned by owner. Owners are compared
+// case-insensitively, like GitHub does.
+func (c codeowners) ownedBy(files []string, owner string) []string {
+	var result []string
+	for _, file := range files {
+		if slices.ContainsFunc(c.owners(file), func(o string) bool { return strings.EqualFold(o, owner) }) {
+			result = append(result, file)
+		}
+	}
+	return result
+}
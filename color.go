@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// ColorMode controls whether output is colored.
+type ColorMode string
+
+const (
+	// ColorAuto colors output when stdout is a terminal, honoring
+	// NO_COLOR, CLICOLOR_FORCE and FORCE_COLOR.
+	ColorAuto ColorMode = "auto"
+	// ColorAlways forces colored output, even when piped.
+	ColorAlways ColorMode = "always"
+	// ColorNever disables colored output, including for child tools.
+	ColorNever ColorMode = "never"
+)
+
+var (
+	colorMu   sync.Mutex
+	colorMode = ColorAuto
+)
+
+// parseColorMode validates a --color flag value.
+func parseColorMode(s string) (ColorMode, error) {
+	switch m := ColorMode(s); m {
+	case ColorAuto, ColorAlways, ColorNever:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid color mode %q (want auto, always or never)", s)
+	}
+}
+
+// setColorMode sets the color mode. It must be called before the first
+// command is created, as the child color environment is computed once.
+func setColorMode(m ColorMode) {
+	colorMu.Lock()
+	defer colorMu.Unlock()
+	colorMode = m
+}
+
+// ColorEnabled reports whether colored output is enabled for this run,
+// according to --color, NO_COLOR, CLICOLOR_FORCE/FORCE_COLOR and whether
+// stdout is a terminal. Tasks can use it to pass color flags to tools.
+func ColorEnabled() bool {
+	colorMu.Lock()
+	m := colorMode
+	colorMu.Unlock()
+	return colorEnabled(m, term.IsTerminal(int(os.Stdout.Fd())), os.LookupEnv)
+}
+
+// colorEnabled resolves the color policy.
+// Precedence: explicit mode > NO_COLOR > CLICOLOR_FORCE/FORCE_COLOR > TTY detection.
+func colorEnabled(m ColorMode, isTTY bool, lookupEnv func(string) (string, bool)) bool {
+	switch m {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if v, ok := lookupEnv("NO_COLOR"); ok && v != "" {
+		return false
+	}
+	for _, key := range []string{"CLICOLOR_FORCE", "FORCE_COLOR"} {
+		if v, ok := lookupEnv(key); ok && v != "" && v != "0" {
+			return true
+		}
+	}
+	return isTTY
+}
+
+// colorEnvFor returns the env vars that make child tools follow the color policy.
+// Colors are forced when enabled (output is often buffered, so tools would
+// otherwise see a pipe). With ColorNever, NO_COLOR=1 is propagated.
+func colorEnvFor(m ColorMode, isTTY bool, lookupEnv func(string) (string, bool)) []string {
+	if m == ColorNever {
+		return []string{"NO_COLOR=1"}
+	}
+	// colorEnabled already applied NO_COLOR, so only its result matters here.
+	return computeColorEnv(colorEnabled(m, isTTY, lookupEnv), false)
+}
+
+// ansiRe matches ANSI escape sequences (CSI and OSC).
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// stripANSI removes ANSI escape sequences from b.
+func stripANSI(b []byte) []byte {
+	if bytes.IndexByte(b, 0x1b) < 0 {
+		return b
+	}
+	return ansiRe.ReplaceAll(b, nil)
+}
+
+// ansiStripWriter removes ANSI escape sequences before writing to w.
+// Used for log files and machine-readable output. An escape sequence split
+// across writes is held back until it is complete.
+type ansiStripWriter struct {
+	w       io.Writer
+	pending []byte
+}
+
+func newANSIStripWriter(w io.Writer) *ansiStripWriter {
+	return &ansiStripWriter{w: w}
+}
+
+func (a *ansiStripWriter) Write(p []byte) (int, error) {
+	buf := append(a.pending, p...)
+	a.pending = nil
+	// Hold back a trailing, possibly incomplete escape sequence.
+	if i := bytes.LastIndexByte(buf, 0x1b); i >= 0 && !ansiRe.Match(buf[i:]) && len(buf)-i < 64 {
+		a.pending = append([]byte(nil), buf[i:]...)
+		buf = buf[:i]
+	}
+	if _, err := a.w.Write(stripANSI(buf)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes bytes held back by Write. An escape sequence that never
+// completed is written without its ESC byte. It does not close w.
+func (a *ansiStripWriter) Close() error {
+	if len(a.pending) == 0 {
+		return nil
+	}
+	pending := bytes.ReplaceAll(a.pending, []byte{0x1b}, nil)
+	a.pending = nil
+	_, err := a.w.Write(pending)
+	return err
+}
+
+// stripPlanANSI removes ANSI escape sequences from the text fields of an
+// introspection plan, so that JSON output is plain text. Example slices
+// are copied since they are shared with the task definitions.
+func stripPlanANSI(plan *IntrospectPlan) {
+	var stripSteps func(steps []*PlanStep)
+	stripSteps = func(steps []*PlanStep) {
+		for _, s := range steps {
+			s.Usage = stripANSIString(s.Usage)
+			s.LongUsage = stripANSIString(s.LongUsage)
+			s.Examples = stripExamplesANSI(s.Examples)
+			stripSteps(s.Children)
+		}
+	}
+	stripSteps(plan.AutoRun)
+	for i := range plan.ManualRun {
+		t := &plan.ManualRun[i]
+		t.Usage = stripANSIString(t.Usage)
+		t.LongUsage = stripANSIString(t.LongUsage)
+		t.Examples = stripExamplesANSI(t.Examples)
+	}
+}
+
+func stripExamplesANSI(examples []Example) []Example {
+	if examples == nil {
+		return nil
+	}
+	result := make([]Example, len(examples))
+	for i, e := range examples {
+		result[i] = Example{Command: stripANSIString(e.Command), Description: stripANSIString(e.Description)}
+	}
+	return result
+}
+
+func stripANSIString(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	return string(stripANSI([]byte(s)))
+}
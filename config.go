@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 // Config defines the configuration for a project using pocket.
@@ -26,6 +28,72 @@ type Config struct {
 	//	},
 	ManualRun []Runnable
 
+	// Internal exposes hidden tasks, such as tool installers, under
+	// ./pok internal <name> instead of fully hiding them. With -reinstall,
+	// tool installers install their tool again even if it is installed.
+	// The tasks stay hidden in ./pok -h.
+	//
+	// Example:
+	//
+	//	Internal: []*pocket.TaskDef{golangcilint.Install, uv.Install},
+	//
+	//	./pok internal                                   # list the tasks
+	//	./pok internal -reinstall install:golangci-lint  # reinstall a tool
+	Internal []*TaskDef
+
+	// DefaultTask is what ./pok runs without arguments instead of "all":
+	// the name of a task (string) or a Runnable, such as a quicker pipeline
+	// for local development. ./pok all still runs the full AutoRun tree.
+	// In directories where a named default task isn't available, ./pok
+	// runs "all". ./pok -h marks the default task with "(default)".
+	//
+	// Example:
+	//
+	//	DefaultTask: "quick",
+	//	DefaultTask: pocket.Serial(golang.Lint, golang.Test),
+	DefaultTask any
+
+	// Generate registers runnables that write generated files (workflows,
+	// editor configs, etc.). They run as part of the built-in "generate" task
+	// and at the start of "all", so the git-diff check catches any drift.
+	//
+	// Example:
+	//
+	//	Generate: []pocket.Runnable{
+	//	    github.WorkflowTask(autoRun, matrixConfig),
+	//	},
+	Generate []Runnable
+
+	// MaxParallel bounds how many items Parallel runs at once, across all
+	// Parallel groups of a run, so large monorepos don't start dozens of
+	// linters simultaneously. Zero runs all items at once. The -j flag
+	// overrides it.
+	//
+	// Example:
+	//
+	//	MaxParallel: 4,
+	MaxParallel int
+
+	// PythonVersion is the Python version of the Python tasks and the
+	// virtual environments created with uv, e.g. "3.12". The PythonVersion
+	// options of the tasks take precedence. Empty uses uv.DefaultPythonVersion.
+	// The py-interp task installs it and pins it in .python-version.
+	//
+	// Example:
+	//
+	//	PythonVersion: "3.12",
+	PythonVersion string
+
+	// ParallelOutput controls how the output of tasks run by Parallel is
+	// shown: ParallelOutputBuffered (the default) prints the output of each
+	// item when it completes, ParallelOutputPrefixed streams it line by line
+	// with the name of the task, e.g. "[go-test] ok ...".
+	//
+	// Example:
+	//
+	//	ParallelOutput: pocket.ParallelOutputPrefixed,
+	ParallelOutput ParallelOutput
+
 	// Shim controls shim script generation.
 	// By default, only Posix (./pok) is generated with name "pok".
 	Shim *ShimConfig
@@ -39,6 +107,127 @@ type Config struct {
 	// By default, "all" fails if there are uncommitted changes after running all tasks.
 	// Set to true to disable this check.
 	SkipGitDiff bool
+
+	// GitDiff configures the git diff check in "all" and the defaults of the
+	// built-in "git-diff" task (untracked files, excluded paths, patch output).
+	//
+	// Example:
+	//
+	//	GitDiff: pocket.GitDiffOptions{
+	//	    Untracked: true,
+	//	    Exclude:   "CHANGELOG.md,docs/**",
+	//	},
+	GitDiff GitDiffOptions
+
+	// RunLog persists each run's combined output and metadata (tasks,
+	// durations, exit codes, command lines) to .pocket/logs/<timestamp>.log.
+	// Commands are recorded when run with Exec, ExecIn or RunCommand;
+	// a Command started with cmd.Run bypasses the log.
+	// Disabled when nil.
+	//
+	// Example:
+	//
+	//	RunLog: &pocket.RunLogConfig{Keep: 50, MaxAge: 14 * 24 * time.Hour},
+	RunLog *RunLogConfig
+
+	// Audit appends a record of every external command run with Exec,
+	// ExecIn or RunCommand to .pocket/audit.jsonl: the task, the tool and
+	// its version (for tools installed by pocket), a hash of the arguments,
+	// the duration and the exit code. ./pok audit reports on it, e.g. to
+	// answer what exactly ran in a release build. Disabled when nil.
+	//
+	// Example:
+	//
+	//	Audit: &pocket.AuditConfig{MaxAge: 90 * 24 * time.Hour},
+	Audit *AuditConfig
+
+	// Policy declares the files the "repo-policy" task (package
+	// tasks/policy) requires. Share rules across repositories by exporting
+	// them from a common Go package. Nil uses policy.DefaultRules().
+	//
+	// Example:
+	//
+	//	Policy: append(policy.DefaultRules(), pocket.PolicyRule{
+	//	    Paths:    []string{"CONTRIBUTING.md"},
+	//	    Contains: []string{`(?i)pull request`},
+	//	}),
+	Policy []PolicyRule
+
+	// GoDeps declares the Go module dependency rules the "go-deps-policy"
+	// task (package tasks/golang) enforces. Share rules across repositories
+	// by exporting them from a common Go package.
+	//
+	// Example:
+	//
+	//	GoDeps: pocket.GoDepsPolicy{
+	//	    Deny:        []string{"github.com/pkg/errors", "github.com/golang/protobuf/..."},
+	//	    MaxMajor:    map[string]int{"github.com/jackc/pgx/...": 5},
+	//	    DenyReplace: true,
+	//	},
+	GoDeps GoDepsPolicy
+
+	// Schemas declares the YAML and JSON files the "schema-validate" task
+	// (package tasks/schema) validates, and the JSON Schema or CUE schema
+	// they are validated against.
+	//
+	// Example:
+	//
+	//	Schemas: []pocket.SchemaRule{
+	//	    {Files: []string{"deploy/**/*.yaml"}, Schema: "schemas/k8s.cue", Definition: "#Manifest"},
+	//	    {Files: []string{"config/*.json"}, Schema: "schemas/config.schema.json"},
+	//	},
+	Schemas []SchemaRule
+
+	// PRComment posts a summary of each run as a pull request comment when
+	// running in GitHub Actions for a pull request with GITHUB_TOKEN set:
+	// failed tasks, Go coverage and SARIF finding counts. Later runs update
+	// the comment. Disabled when nil.
+	//
+	// Example:
+	//
+	//	PRComment: &pocket.PRCommentConfig{BaseCoverage: "base/coverage.out"},
+	PRComment *PRCommentConfig
+
+	// Notify lists webhooks called after a run completes, by default only
+	// when it failed. Slack and Teams incoming webhooks are supported, as
+	// well as generic JSON endpoints.
+	//
+	// Example:
+	//
+	//	Notify: []pocket.Notification{{
+	//	    URL:    `{{env "SLACK_WEBHOOK_URL"}}`,
+	//	    Format: pocket.NotifySlack,
+	//	    When:   func(r pocket.NotifyRun) bool { return r.Failed && r.Event == "schedule" },
+	//	}},
+	Notify []Notification
+
+	// Cache configures a remote cache for the tasks with WithCache, shared
+	// e.g. by CI machines. Disabled when nil.
+	//
+	// Example:
+	//
+	//	Cache: &pocket.CacheConfig{URL: "s3://my-bucket/pocket-cache"},
+	Cache *CacheConfig
+
+	// DefaultConfigs replaces the bundled default configs of tools, by tool
+	// name as passed to ConfigPath (e.g. "golangci-lint", "ruff",
+	// "prettier"), with configs fetched from a URL or a Go module. Configs
+	// in the repository still take precedence. ./pok update fetches them
+	// again.
+	//
+	// Example:
+	//
+	//	DefaultConfigs: map[string]pocket.ConfigSource{
+	//	    "golangci-lint": {Module: "github.com/acme/lint-configs@v1.4.0", File: "golangci.yml"},
+	//	    "ruff":          {URL: "https://config.acme.dev/ruff.toml", SHA256: "9f2c..."},
+	//	},
+	DefaultConfigs map[string]ConfigSource
+
+	// ClearQuarantine removes the macOS quarantine attribute from tools
+	// installed with Download or FromLocal, and ad-hoc signs the installed
+	// binary if its code signature doesn't verify, so that Gatekeeper
+	// doesn't block it. It has no effect on other platforms.
+	ClearQuarantine bool
 }
 
 // ShimConfig controls shim script generation.
@@ -58,6 +247,79 @@ type ShimConfig struct {
 	// PowerShell generates a PowerShell script (pok.ps1).
 	// The PowerShell script can auto-download Go if not found.
 	PowerShell bool
+
+	// Prebuilt makes the Posix and PowerShell shims download a prebuilt
+	// task runner for the host platform instead of building .pocket with
+	// Go, so contributors don't need a Go toolchain. Platforms without a
+	// prebuilt runner, and runs with POK_BUILD=1 set, build with Go as
+	// usual. Nil always builds with Go.
+	//
+	// Example:
+	//
+	//	Prebuilt: &pocket.PrebuiltRunner{
+	//	    URL: "https://github.com/org/repo/releases/download/pocket-runner-v1",
+	//	},
+	Prebuilt *PrebuiltRunner
+}
+
+// PrebuiltRunner configures where shims download prebuilt task runners.
+// Build them with the runner-build task (package tasks/prebuilt) and upload
+// the files it writes as release assets.
+type PrebuiltRunner struct {
+	// URL is the base URL of the runner downloads, e.g. a GitHub release
+	// download URL. Runners are downloaded from <URL>/pocket-runner-<os>-<arch>
+	// (with .exe on Windows).
+	URL string
+
+	// Checksums are the SHA256 checksums of the runners keyed by "os-arch",
+	// which the shims verify downloads against. When empty, ./pok generate
+	// reads them from <URL>/SHA256SUMS.
+	Checksums map[string]string
+}
+
+// PolicyRule declares a file that must exist in the repository.
+type PolicyRule struct {
+	// Paths are the accepted locations, relative to the git root.
+	// The first existing path is checked.
+	Paths []string
+
+	// Contains lists regular expressions that must all match the file content.
+	Contains []string
+
+	// Validate is an optional custom check of the file content.
+	Validate func(data []byte) error
+}
+
+// SchemaRule declares files that must validate against a schema.
+type SchemaRule struct {
+	// Files are globs of YAML or JSON files, relative to the git root.
+	// A "**" segment matches any number of directories.
+	Files []string
+
+	// Schema is the schema file, relative to the git root. Files ending in
+	// .cue are CUE; others (.json, .yaml) are JSON Schema.
+	Schema string
+
+	// Definition selects a CUE definition in Schema, e.g. "#Deployment".
+	// Empty validates against the whole schema. Ignored for JSON Schema.
+	Definition string
+}
+
+// GoDepsPolicy declares rules for the modules in a Go module graph.
+// Patterns are module paths, path.Match globs, or a path ending in "/..."
+// that matches the path and everything below it.
+type GoDepsPolicy struct {
+	// Deny lists modules that must not be in the module graph.
+	Deny []string
+
+	// Allow lists modules exempt from all rules.
+	Allow []string
+
+	// MaxMajor limits the major version of the matching modules.
+	MaxMajor map[string]int
+
+	// DenyReplace fails on replace directives in go.mod.
+	DenyReplace bool
 }
 
 // WithDefaults returns a copy of the config with default values applied.
@@ -1,12 +1,17 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
 	"regexp"
 	"sync"
+	"time"
 )
 
 // execContext holds runtime state for function execution.
@@ -21,25 +26,50 @@ type execContext struct {
 	verbose    bool                // verbose mode enabled
 	dedup      *dedupState         // shared deduplication state (thread-safe)
 	skipRules  map[string][]string // task name -> paths to skip in (empty = skip everywhere)
+	skipFlag   map[string]bool     // task names skipped with the -skip flag
+	tags       *tagFilter          // tasks selected with -tags and -skip-tags (nil = all)
+	runLog     *runLog             // run log recorder (nil when disabled)
+	runner     CommandRunner       // runs Exec/ExecIn commands (nil = cmd.Run)
+	installer  Installer           // replaces InstallGo/Download (nil = install for real)
+	reinstall  bool                // install tools even if installed (see Reinstall)
+	limits     Limits              // resource limits of the current task's commands
+	sandbox    *Sandbox            // sandbox of the current task's commands (nil = none)
+	env        map[string]string   // extra environment of the current task's commands, see Env
+	keepGoing  *failureReport      // failed tasks with -keep-going (nil = stop at the first failure)
+	failScope  *failScope          // scope of the running task with -keep-going
+	slots      chan struct{}       // bounds items run at once by Parallel (nil = no limit)
+	prefixer   *linePrefixer       // streams prefixed lines in Parallel groups (nil = buffered output)
+	task       string              // name of the running task, for the audit log
+	lane       int                 // timeline lane of the running tasks, see -profile
 }
 
 // dedupState tracks executed runnables for deduplication.
 // Shared across parallel executions with thread-safe access.
 type dedupState struct {
 	mu       sync.Mutex
-	executed map[uintptr]bool
+	executed map[dedupKey]bool
+	installs map[string]*installRun // tool name@version -> first install
+	tools    map[string]string      // tool name -> version installed in this run
+}
+
+// dedupKey identifies a runnable run in a path.
+type dedupKey struct {
+	ptr  uintptr
+	path string
 }
 
 // newDedupState creates a new deduplication state.
 func newDedupState() *dedupState {
 	return &dedupState{
-		executed: make(map[uintptr]bool),
+		executed: make(map[dedupKey]bool),
+		installs: make(map[string]*installRun),
+		tools:    make(map[string]string),
 	}
 }
 
 // shouldRun checks if a runnable should run (not already executed).
 // Marks it as executed if it should run. Thread-safe.
-func (d *dedupState) shouldRun(key uintptr) bool {
+func (d *dedupState) shouldRun(key dedupKey) bool {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	if d.executed[key] {
@@ -49,6 +79,35 @@ func (d *dedupState) shouldRun(key uintptr) bool {
 	return true
 }
 
+// startInstall returns the install of the tool with key, and whether the
+// caller is the first to install it and must run it. Thread-safe.
+func (d *dedupState) startInstall(key string) (*installRun, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if run, ok := d.installs[key]; ok {
+		return run, false
+	}
+	run := &installRun{done: make(chan struct{})}
+	d.installs[key] = run
+	return run, true
+}
+
+// recordTool records the version of a tool installed in this run, for the
+// audit log. Thread-safe.
+func (d *dedupState) recordTool(name, version string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tools[name] = version
+}
+
+// toolVersion returns the version of the tool installed in this run, or ""
+// for tools not installed by pocket. Thread-safe.
+func (d *dedupState) toolVersion(name string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.tools[name]
+}
+
 // contextKey is the type for context keys to avoid collisions.
 type contextKey int
 
@@ -92,6 +151,13 @@ func withPath(ctx context.Context, path string) context.Context {
 	return withExecContext(ctx, &newEC)
 }
 
+// withTask returns a context with the name of the running task set.
+func withTask(ctx context.Context, name string) context.Context {
+	newEC := *getExecContext(ctx)
+	newEC.task = name
+	return withExecContext(ctx, &newEC)
+}
+
 // withOptions stores options for a function in the context.
 // It normalizes to the struct type if a pointer is provided.
 // Panics if the same options type is already in the context (nested functions
@@ -158,7 +224,7 @@ func Exec(ctx context.Context, name string, args ...string) error {
 	} else {
 		cmd.Dir = GitRoot()
 	}
-	return cmd.Run()
+	return runCommand(ec, cmd)
 }
 
 // ExecIn runs an external command in a specific directory.
@@ -171,7 +237,62 @@ func ExecIn(ctx context.Context, dir, name string, args ...string) error {
 	cmd.Stdout = ec.out.Stdout
 	cmd.Stderr = ec.out.Stderr
 	cmd.Dir = dir
-	return cmd.Run()
+	return runCommand(ec, cmd)
+}
+
+// RunCommand runs a command created with Command the way Exec does: it is
+// recorded in the run log, commands that cannot be started return a
+// *ToolError, and a CommandRunner set for the context (see pockettest)
+// intercepts it. Use it instead of cmd.Run when a task needs to capture
+// output or set extra environment variables.
+// Stdout and Stderr left at os.Stdout and os.Stderr (as set by Command)
+// are redirected to the task output.
+//
+// Example:
+//
+//	var out bytes.Buffer
+//	cmd := pocket.Command(ctx, "git", "rev-parse", "HEAD")
+//	cmd.Stdout = &out
+//	if err := pocket.RunCommand(ctx, cmd); err != nil {
+//	    return err
+//	}
+func RunCommand(ctx context.Context, cmd *exec.Cmd) error {
+	ec := getExecContext(ctx)
+	if ec.mode == modeCollect {
+		return nil
+	}
+	if cmd.Stdout == nil || cmd.Stdout == os.Stdout {
+		cmd.Stdout = ec.out.Stdout
+	}
+	if cmd.Stderr == nil || cmd.Stderr == os.Stderr {
+		cmd.Stderr = ec.out.Stderr
+	}
+	return runCommand(ec, cmd)
+}
+
+// runCommand runs cmd and records it in the run log, if enabled.
+// With -trace, the command is printed before it runs.
+// Commands that cannot be started return a *ToolError.
+func runCommand(ec *execContext, cmd *exec.Cmd) error {
+	if traceEnabled() {
+		traceCommand(ec.out.Stderr, cmd)
+	}
+	run := cmd.Run
+	switch {
+	case ec.runner != nil:
+		run = func() error { return ec.runner(cmd) }
+	case ec.sandbox != nil || !ec.limits.IsZero():
+		run = func() error { return runRestricted(cmd, ec.sandbox, ec.limits) }
+	}
+	if ec.runLog == nil {
+		return classifyExecError(cmd, run())
+	}
+	start := time.Now()
+	err := classifyExecError(cmd, run())
+	d := time.Since(start)
+	ec.runLog.recordCommand(cmd, ec.lane, d, err)
+	ec.runLog.recordAudit(newAuditRecord(ec, cmd, start, d, err))
+	return err
 }
 
 // Printf writes formatted output to stdout.
@@ -216,6 +337,14 @@ func Verbose(ctx context.Context) bool {
 	return getExecContext(ctx).verbose
 }
 
+// Reinstall returns whether tools should be installed even if they are
+// already installed, as requested with -reinstall-tools, ./pok tools
+// reinstall or ./pok internal -reinstall. Tool installers that skip
+// existing installs should check it.
+func Reinstall(ctx context.Context) bool {
+	return getExecContext(ctx).reinstall || os.Getenv(reinstallToolsEnv) != ""
+}
+
 // CWD returns where the CLI was invoked (relative to git root).
 func CWD(ctx context.Context) string {
 	return getExecContext(ctx).cwd
@@ -267,6 +396,23 @@ func (ec *execContext) shouldSkipTask(taskName string) bool {
 	return false
 }
 
+// skipRunnable runs a Runnable with the named tasks skipped. See the -skip
+// flag.
+type skipRunnable struct {
+	names []string
+	inner Runnable
+}
+
+func (s *skipRunnable) run(ctx context.Context) error {
+	ec := getExecContext(ctx)
+	newEC := *ec
+	newEC.skipFlag = make(map[string]bool, len(s.names))
+	for _, name := range s.names {
+		newEC.skipFlag[name] = true
+	}
+	return s.inner.run(withExecContext(ctx, &newEC))
+}
+
 // matchSkipPath checks if the current path matches the skip pattern.
 // Supports exact matches and regex patterns.
 func matchSkipPath(currentPath, pattern string) bool {
@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket/internal/testenv"
+)
+
+// maxDefaultConfigSize limits the size of a fetched default config.
+const maxDefaultConfigSize = 1 << 20
+
+// ConfigSource locates a tool's default config outside the repository, so
+// that an organization can manage the defaults of all its repositories in
+// one place. Set either URL, or Module and File. See Config.DefaultConfigs.
+type ConfigSource struct {
+	// URL is an https:// URL of the config file.
+	URL string
+
+	// Module is a Go module with the config file, as path@version, e.g.
+	// "github.com/acme/lint-configs@v1.4.0". The module is fetched with
+	// "go mod download", which verifies it against the checksum database.
+	// A version of "latest" follows the newest release on ./pok update.
+	Module string
+
+	// File is the path of the config file in Module.
+	File string
+
+	// SHA256 is the expected hex-encoded SHA-256 of the config file.
+	// Optional, but recommended for URL.
+	SHA256 string
+}
+
+// validate reports whether the source is set up correctly.
+func (s ConfigSource) validate() error {
+	switch {
+	case s.URL != "" && s.Module != "":
+		return fmt.Errorf("set either URL or Module, not both")
+	case s.URL != "":
+		if !strings.HasPrefix(s.URL, "https://") {
+			return fmt.Errorf("URL %q is not https://", s.URL)
+		}
+	case s.Module != "":
+		if !strings.Contains(s.Module, "@") {
+			return fmt.Errorf("module %q has no @version", s.Module)
+		}
+		if s.File == "" {
+			return fmt.Errorf("module %s: File is empty", s.Module)
+		}
+	default:
+		return fmt.Errorf("URL and Module are empty")
+	}
+	return nil
+}
+
+// name returns the file name of the config.
+func (s ConfigSource) name() string {
+	if s.Module != "" {
+		return path.Base(s.File)
+	}
+	name := path.Base(strings.SplitN(s.URL, "?", 2)[0])
+	if name == "." || name == "/" {
+		return "config"
+	}
+	return name
+}
+
+// remoteConfigDir returns the directory of the fetched default configs of
+// a tool.
+func remoteConfigDir(toolName string) string {
+	return FromToolsDir(toolName, "remote")
+}
+
+// remoteConfigPath returns the path of a tool's default config fetched from
+// source, fetching it if it hasn't been yet. The path depends on the source,
+// so changing it in the config fetches the new one.
+func remoteConfigPath(ctx context.Context, toolName string, source ConfigSource) (string, error) {
+	key := sha256.Sum256([]byte(source.URL + "\x00" + source.Module + "\x00" + source.File + "\x00" + source.SHA256))
+	configPath := filepath.Join(remoteConfigDir(toolName), hex.EncodeToString(key[:6]), source.name())
+	if _, err := os.Stat(configPath); err == nil {
+		return configPath, nil
+	}
+
+	var data []byte
+	var err error
+	if source.Module != "" {
+		data, err = fetchModuleConfig(ctx, source)
+	} else {
+		data, err = fetchURLConfig(ctx, source.URL)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s default config: %w", toolName, err)
+	}
+	if source.SHA256 != "" {
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != strings.ToLower(source.SHA256) {
+			return "", fmt.Errorf("%s default config: checksum mismatch: got %x, want %s", toolName, sum, source.SHA256)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		return "", fmt.Errorf("create config dir: %w", err)
+	}
+	tmp := configPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", fmt.Errorf("write default config: %w", err)
+	}
+	if err := os.Rename(tmp, configPath); err != nil {
+		return "", fmt.Errorf("write default config: %w", err)
+	}
+	return configPath, nil
+}
+
+// fetchURLConfig downloads a config file.
+func fetchURLConfig(ctx context.Context, url string) ([]byte, error) {
+	if Verbose(ctx) {
+		Printf(ctx, "  Downloading %s\n", url)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, testenv.MirrorURL(url), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: HTTP %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDefaultConfigSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("download: %w", err)
+	}
+	if len(data) > maxDefaultConfigSize {
+		return nil, fmt.Errorf("download %s: larger than %d bytes", url, maxDefaultConfigSize)
+	}
+	return data, nil
+}
+
+// fetchModuleConfig reads a config file from a Go module, downloading the
+// module to the module cache.
+func fetchModuleConfig(ctx context.Context, source ConfigSource) ([]byte, error) {
+	if Verbose(ctx) {
+		Printf(ctx, "  Downloading %s\n", source.Module)
+	}
+	var stderr bytes.Buffer
+	cmd := Command(ctx, "go", "mod", "download", "-json", source.Module)
+	cmd.Dir = FromPocketDir()
+	cmd.Stdout = nil
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var mod struct {
+		Dir   string
+		Error string
+	}
+	if jsonErr := json.Unmarshal(out, &mod); jsonErr == nil && mod.Error != "" {
+		return nil, fmt.Errorf("go mod download %s: %s", source.Module, mod.Error)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("go mod download %s: %w: %s", source.Module, err, strings.TrimSpace(stderr.String()))
+	}
+	if mod.Dir == "" {
+		return nil, fmt.Errorf("go mod download %s: no module directory", source.Module)
+	}
+	data, err := os.ReadFile(filepath.Join(mod.Dir, filepath.FromSlash(source.File)))
+	if err != nil {
+		return nil, fmt.Errorf("module %s: %w", source.Module, err)
+	}
+	return data, nil
+}
+
+// defaultConfigSource returns the source of a tool's default config from
+// Config.DefaultConfigs, if any.
+func defaultConfigSource(ctx context.Context, toolName string) (ConfigSource, bool) {
+	plan := GetConfigPlan(ctx)
+	if plan == nil || plan.Config == nil {
+		return ConfigSource{}, false
+	}
+	source, ok := plan.Config.DefaultConfigs[toolName]
+	return source, ok
+}
+
+// refreshDefaultConfigs removes the fetched default configs, so that the
+// next runs fetch them again. Run by ./pok update, so that sources that
+// aren't pinned pick up their latest version.
+func refreshDefaultConfigs(cfg *Config) error {
+	if cfg == nil {
+		return nil
+	}
+	for toolName := range cfg.DefaultConfigs {
+		if err := os.RemoveAll(remoteConfigDir(toolName)); err != nil {
+			return fmt.Errorf("refresh %s default config: %w", toolName, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestConfigPath_DefaultConfigs(t *testing.T) {
+	tmpDir := t.TempDir()
+	defer gitroot.Override(tmpDir)()
+	const remote = "version: \"2\"\n"
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches++
+		_, _ = w.Write([]byte(remote))
+	}))
+	defer srv.Close()
+
+	sum := sha256.Sum256([]byte(remote))
+	cfg := &Config{DefaultConfigs: map[string]ConfigSource{
+		"lint": {URL: srv.URL + "/org/golangci.yml", SHA256: hex.EncodeToString(sum[:])},
+		"fmt":  {URL: srv.URL + "/org/fmt.toml", SHA256: strings.Repeat("0", 64)},
+	}}
+	ctx := NewRunContext(context.Background(), RunContextOptions{ConfigPlan: &ConfigPlan{Config: cfg}})
+	toolConfig := ToolConfig{UserFiles: []string{".golangci.yml"}, DefaultFile: "golangci.yml", DefaultData: []byte("bundled")}
+
+	for range 2 {
+		path, err := ConfigPath(ctx, "lint", toolConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != remote || filepath.Base(path) != "golangci.yml" {
+			t.Errorf("ConfigPath() = %s with %q, want the remote config", path, data)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d times, want once", fetches)
+	}
+
+	if _, err := ConfigPath(ctx, "fmt", ToolConfig{}); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("ConfigPath() with wrong checksum = %v, want checksum mismatch", err)
+	}
+
+	// Configs in the repository take precedence.
+	userConfig := filepath.Join(tmpDir, ".golangci.yml")
+	if err := os.WriteFile(userConfig, []byte("user"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := ConfigPath(ctx, "lint", toolConfig); path != userConfig {
+		t.Errorf("ConfigPath() = %s, want %s", path, userConfig)
+	}
+	if err := os.Remove(userConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	// ./pok update fetches them again.
+	if err := refreshDefaultConfigs(cfg); err != nil {
+		t.Fatal(err)
+	}
+	before := fetches
+	if _, err := ConfigPath(ctx, "lint", toolConfig); err != nil || fetches != before+1 {
+		t.Errorf("ConfigPath() after refresh = %v, fetched %d times, want %d", err, fetches, before+1)
+	}
+}
+
+func TestValidate_DefaultConfigs(t *testing.T) {
+	tests := []struct {
+		source ConfigSource
+		valid  bool
+	}{
+		{ConfigSource{URL: "https://config.acme.dev/ruff.toml"}, true},
+		{ConfigSource{Module: "github.com/acme/configs@v1.0.0", File: "golangci.yml"}, true},
+		{ConfigSource{URL: "http://config.acme.dev/ruff.toml"}, false},
+		{ConfigSource{Module: "github.com/acme/configs", File: "golangci.yml"}, false},
+		{ConfigSource{Module: "github.com/acme/configs@v1.0.0"}, false},
+		{ConfigSource{URL: "https://config.acme.dev/ruff.toml", Module: "github.com/acme/configs@v1.0.0"}, false},
+		{ConfigSource{}, false},
+	}
+	for _, tt := range tests {
+		plan := BuildConfigPlan(Config{DefaultConfigs: map[string]ConfigSource{"ruff": tt.source}})
+		if err := plan.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, want valid %v", tt.source, err, tt.valid)
+		}
+	}
+}
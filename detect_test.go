@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
@@ -5,10 +7,12 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
 )
 
 func TestDetectByFile(t *testing.T) {
-	// Not parallel due to shared gitRoot variable.
+	// Not parallel due to shared git root.
 
 	tests := []struct {
 		name      string
@@ -106,9 +110,7 @@ func TestDetectByFile(t *testing.T) {
 			}
 
 			// Override git root for this test.
-			origRoot := gitRoot
-			gitRoot = tmpDir
-			defer func() { gitRoot = origRoot }()
+			defer gitroot.Override(tmpDir)()
 
 			got := DetectByFile(tt.markers...)
 
@@ -120,7 +122,7 @@ func TestDetectByFile(t *testing.T) {
 }
 
 func TestDetectByExtension(t *testing.T) {
-	// Not parallel due to shared gitRoot variable.
+	// Not parallel due to shared git root.
 
 	tests := []struct {
 		name       string
@@ -179,9 +181,7 @@ func TestDetectByExtension(t *testing.T) {
 				}
 			}
 
-			origRoot := gitRoot
-			gitRoot = tmpDir
-			defer func() { gitRoot = origRoot }()
+			defer gitroot.Override(tmpDir)()
 
 			got := DetectByExtension(tt.extensions...)
 
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// doctor checks the environment pocket runs tools in and reports problems.
+func doctor(ctx context.Context) error {
+	problems := 0
+	report := func(ok bool, format string, args ...any) {
+		status := "ok"
+		if !ok {
+			status = "FAIL"
+			problems++
+		}
+		Printf(ctx, "  [%s] %s\n", status, fmt.Sprintf(format, args...))
+	}
+
+	for _, name := range []string{"go", "git"} {
+		p, err := exec.LookPath(name)
+		report(err == nil, "%s on PATH: %s", name, cmpOrErr(p, err))
+	}
+
+	binDir := FromBinDir()
+	entries, err := os.ReadDir(binDir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		Printf(ctx, "  [ok] %s does not exist yet (no tools installed)\n", binDir)
+	case err != nil:
+		report(false, "read %s: %v", binDir, err)
+	}
+	for _, e := range entries {
+		p := filepath.Join(binDir, e.Name())
+		fi, err := os.Stat(p) // follows symlinks into .pocket/tools
+		if err != nil {
+			target, _ := os.Readlink(p)
+			report(false, "%s: broken link to %s (reinstall with ./pok tools reinstall)", e.Name(), target)
+			continue
+		}
+		if runtime.GOOS != Windows && fi.Mode().Perm()&0o111 == 0 {
+			report(false, "%s: not executable", e.Name())
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("doctor: %d problem(s) found", problems)
+	}
+	Printf(ctx, "No problems found.\n")
+	return nil
+}
+
+// cmpOrErr returns s, or the error text if err is set.
+func cmpOrErr(s string, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// dryRun describes a run for -dry-run.
+type dryRun struct {
+	plan    *ConfigPlan
+	task    *TaskDef
+	cwd     string     // directory pocket was invoked from, relative to the git root
+	paths   []string   // paths given with path=<dir>
+	skip    []string   // tasks skipped with -skip
+	tags    *tagFilter // tasks selected with -tags and -skip-tags, or nil
+	verbose bool       // also show hidden tasks, e.g. tool installs
+}
+
+// write prints the tasks the run would execute, in order, with their
+// Serial/Parallel nesting and the paths each task runs in. The tree is
+// walked in collect mode, so no commands are run.
+func (d *dryRun) write(w io.Writer) error {
+	steps, err := d.steps()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Dry run of %s from %s (no commands are run):\n", d.task.name, d.cwd)
+	d.writeSteps(w, steps, "  ")
+	return nil
+}
+
+// steps returns the plan steps of the task. Tasks not selected by -tags or
+// -skip-tags are left out.
+func (d *dryRun) steps() ([]*PlanStep, error) {
+	if d.task != d.plan.AllTask {
+		plan, err := NewEngine(d.selected(d.task)).Plan(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("collect plan: %w", err)
+		}
+		return plan.Steps(), nil
+	}
+
+	// The all task runs generate, AutoRun and git-diff from a plain
+	// function, so its steps are assembled here.
+	var steps []*PlanStep
+	cfg := d.plan.Config
+	if !cfg.SkipGenerate {
+		steps = append(steps, d.builtinStep("generate"))
+	}
+	autoRun, err := NewEngine(d.selected(cfg.AutoRun)).Plan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("collect plan: %w", err)
+	}
+	steps = append(steps, autoRun.Steps()...)
+	if !cfg.SkipGitDiff {
+		steps = append(steps, d.builtinStep("git-diff"))
+	}
+	return steps, nil
+}
+
+// selected applies the tag selection of the run to r, if any.
+func (d *dryRun) selected(r Runnable) Runnable {
+	if d.tags == nil || r == nil {
+		return r
+	}
+	return &tagRunnable{filter: *d.tags, inner: r}
+}
+
+// builtinStep returns a plan step for the named builtin task.
+func (d *dryRun) builtinStep(name string) *PlanStep {
+	step := &PlanStep{Type: "func", Name: name}
+	for _, f := range d.plan.BuiltinTasks {
+		if f.name == name {
+			step.Usage = f.usage
+		}
+	}
+	return step
+}
+
+// writeSteps prints steps as a tree, like the plan task.
+func (d *dryRun) writeSteps(w io.Writer, steps []*PlanStep, indent string) {
+	visible := filterPlanSteps(steps, d.verbose, false)
+	for i, step := range visible {
+		connector, childIndent := "├── ", indent+"│   "
+		if i == len(visible)-1 {
+			connector, childIndent = "└── ", indent+"    "
+		}
+
+		switch step.Type {
+		case "func":
+			label := step.Name
+			var paths []string
+			if slices.Contains(d.skip, step.Name) {
+				label += " (skipped by -skip)"
+			} else if paths = d.stepPaths(step); len(paths) == 0 {
+				label += " (not run from " + d.cwd + ")"
+			} else {
+				label += " [" + strings.Join(paths, ", ") + "]"
+			}
+			if step.Usage != "" && !step.Hidden {
+				label += " - " + step.Usage
+			}
+			fmt.Fprintf(w, "%s%s%s\n", indent, connector, label)
+			if len(paths) > 0 {
+				d.writeSteps(w, step.Children, childIndent)
+			}
+
+		case "serial", "parallel":
+			if len(filterPlanSteps(step.Children, d.verbose, false)) == 0 {
+				continue
+			}
+			kind := "Serial"
+			if step.Type == "parallel" {
+				kind = "Parallel"
+			}
+			fmt.Fprintf(w, "%s%s%s:\n", indent, connector, kind)
+			d.writeSteps(w, step.Children, childIndent)
+		}
+	}
+}
+
+// stepPaths returns the paths the task of step runs in, the way the task
+// and its enclosing RunIn or InDir resolve them when run.
+func (d *dryRun) stepPaths(step *PlanStep) []string {
+	cwds := d.paths
+	if len(cwds) == 0 {
+		cwds = []string{d.cwd}
+	}
+	var result []string
+	for _, cwd := range cwds {
+		switch {
+		case step.dir != "":
+			if dirWithin(step.dir, cwd) {
+				result = append(result, step.dir)
+			}
+		case step.paths != nil:
+			// Skip rules without paths skip the task everywhere.
+			rules, hasRules := step.paths.skipTasks[step.Name]
+			for _, path := range step.paths.ResolveFor(cwd) {
+				skipped := hasRules && (len(rules) == 0 || slices.ContainsFunc(rules, func(pattern string) bool {
+					return matchSkipPath(path, pattern)
+				}))
+				if !skipped {
+					result = append(result, path)
+				}
+			}
+		case len(d.paths) > 0:
+			result = append(result, cwd)
+		default:
+			// Tasks outside RunIn run at the git root.
+			result = append(result, ".")
+		}
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
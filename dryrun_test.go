@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	ran := false
+	noop := func(_ context.Context) error {
+		ran = true
+		return nil
+	}
+	install := Task("install:tool", "install tool", noop, AsHidden())
+	lint := Task("lint", "lint code", Serial(install, Do(noop)))
+	test := Task("test", "run tests", noop).WithTags("slow")
+	docs := Task("docs", "build docs", noop)
+	cfg := Config{
+		AutoRun: Serial(
+			RunIn(Parallel(lint, test), Include("api", "web"), Skip(test, "web")),
+			InDir("site", docs),
+		),
+		SkipGenerate: true,
+	}
+	plan := BuildConfigPlan(cfg)
+
+	tests := []struct {
+		name    string
+		dryRun  dryRun
+		want    []string
+		notWant []string
+	}{
+		{
+			name:   "all from root",
+			dryRun: dryRun{task: plan.AllTask, cwd: "."},
+			want: []string{
+				"Dry run of all from .",
+				"  ├── Serial:\n  │   ├── Parallel:\n",
+				"lint [api, web] - lint code",
+				"test [api] - run tests",
+				"docs [site] - build docs",
+				"└── git-diff",
+			},
+			notWant: []string{"generate", "install:tool"},
+		},
+		{
+			name:   "all from shim",
+			dryRun: dryRun{task: plan.AllTask, cwd: "web", verbose: true},
+			want:   []string{"lint [web]", "install:tool [web]", "test (not run from web)", "docs (not run from web)"},
+		},
+		{
+			name:   "task with paths and skip",
+			dryRun: dryRun{task: lint, cwd: ".", paths: []string{"api"}, skip: []string{"test"}},
+			want:   []string{"Dry run of lint from .", "  └── lint [api] - lint code\n"},
+		},
+		{
+			name:   "skipped task",
+			dryRun: dryRun{task: plan.AllTask, cwd: ".", skip: []string{"lint"}},
+			want:   []string{"lint (skipped by -skip)", "test [api]"},
+		},
+		{
+			name:    "selected by tag",
+			dryRun:  dryRun{task: plan.AllTask, cwd: ".", tags: &tagFilter{only: []string{"slow"}}},
+			want:    []string{"test [api] - run tests"},
+			notWant: []string{"lint", "docs"},
+		},
+		{
+			name:    "skipped by tag",
+			dryRun:  dryRun{task: plan.AllTask, cwd: ".", tags: &tagFilter{skip: []string{"slow"}}},
+			want:    []string{"lint [api, web]", "docs [site]"},
+			notWant: []string{"test"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.dryRun.plan = plan
+			var out strings.Builder
+			if err := tt.dryRun.write(&out); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, out.String())
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out.String(), notWant) {
+					t.Errorf("output contains %q:\n%s", notWant, out.String())
+				}
+			}
+		})
+	}
+	if ran {
+		t.Error("dry run ran a task")
+	}
+}
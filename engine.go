@@ -1,6 +1,9 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
+	"cmp"
 	"context"
 	"io"
 	"slices"
@@ -20,12 +23,25 @@ const (
 
 // PlanStep represents a single step in the execution plan.
 type PlanStep struct {
-	Type     string      `json:"type"`               // "serial", "parallel", "func"
-	Name     string      `json:"name,omitempty"`     // Function name
-	Usage    string      `json:"usage,omitempty"`    // Function usage/description
-	Hidden   bool        `json:"hidden,omitempty"`   // Whether this is a hidden function
-	Deduped  bool        `json:"deduped,omitempty"`  // Would be skipped due to deduplication
-	Children []*PlanStep `json:"children,omitempty"` // Nested steps (for serial/parallel groups)
+	Type      string      `json:"type"`                // "serial", "parallel", "func"
+	Name      string      `json:"name,omitempty"`      // Function name
+	Usage     string      `json:"usage,omitempty"`     // Function usage/description
+	LongUsage string      `json:"longUsage,omitempty"` // Detailed help text
+	Examples  []Example   `json:"examples,omitempty"`  // Example invocations
+	Hidden    bool        `json:"hidden,omitempty"`    // Whether this is a hidden function
+	Tool      *ToolInfo   `json:"tool,omitempty"`      // Managed tool installed by this function
+	Limits    *Limits     `json:"limits,omitempty"`    // Resource limits set by WithLimits
+	Inputs    []string    `json:"inputs,omitempty"`    // Globs of files read, set by WithInputs
+	Outputs   []string    `json:"outputs,omitempty"`   // Globs of files written, set by WithOutputs
+	Sandbox   *Sandbox    `json:"sandbox,omitempty"`   // Sandbox set by WithSandbox
+	Timeout   string      `json:"timeout,omitempty"`   // Run time limit set by WithTimeout, e.g. "10m0s"
+	Tags      []string    `json:"tags,omitempty"`      // Tags set by WithTags
+	Deduped   bool        `json:"deduped,omitempty"`   // Would be skipped due to deduplication
+	Children  []*PlanStep `json:"children,omitempty"`  // Nested steps (for serial/parallel groups)
+
+	paths *PathFilter // enclosing PathFilter of a func, if any
+	dir   string      // fixed directory of a func (see InDir), if any
+	task  *TaskDef    // task of a func
 }
 
 // ExecutionPlan holds the complete plan collected during modeCollect.
@@ -35,6 +51,8 @@ type ExecutionPlan struct {
 	stack        []*PlanStep            // Current nesting stack during collection
 	pathMappings map[string]*PathFilter // Task name -> PathFilter (collected during walk)
 	currentPaths *PathFilter            // Current PathFilter context during collection
+	currentDir   string                 // Current InDir directory during collection
+	taskDirs     map[string]string      // Task name -> InDir directory (collected during walk)
 	taskDefs     []*TaskDef             // Collected TaskDefs (visible ones only)
 	seenDefs     map[string]bool        // Track seen task names for deduplication
 	skipRules    map[string][]string    // Current skip rules from PathFilter (task name -> paths)
@@ -46,6 +64,7 @@ func newExecutionPlan() *ExecutionPlan {
 		steps:        make([]*PlanStep, 0),
 		stack:        make([]*PlanStep, 0),
 		pathMappings: make(map[string]*PathFilter),
+		taskDirs:     make(map[string]string),
 		taskDefs:     make([]*TaskDef, 0),
 		seenDefs:     make(map[string]bool),
 	}
@@ -56,12 +75,29 @@ func (p *ExecutionPlan) addFunc(td *TaskDef, deduped bool) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	step := &PlanStep{
-		Type:    "func",
-		Name:    td.name,
-		Usage:   td.usage,
-		Hidden:  td.hidden,
-		Deduped: deduped,
+		Type:      "func",
+		Name:      td.name,
+		Usage:     td.usage,
+		LongUsage: td.longUsage,
+		Examples:  td.examples,
+		Hidden:    td.hidden,
+		Tool:      td.tool,
+		Inputs:    td.inputs,
+		Outputs:   td.outputs,
+		Tags:      td.tags,
+		Deduped:   deduped,
+	}
+	if !td.limits.IsZero() {
+		limits := td.limits
+		step.Limits = &limits
+	}
+	step.Sandbox = td.sandbox
+	if td.timeout > 0 {
+		step.Timeout = td.timeout.String()
 	}
+	step.paths = p.currentPaths
+	step.dir = cmp.Or(td.dir, p.currentDir)
+	step.task = td
 	p.appendStep(step)
 	// Push onto stack so nested deps become children
 	p.stack = append(p.stack, step)
@@ -71,6 +107,11 @@ func (p *ExecutionPlan) addFunc(td *TaskDef, deduped bool) {
 		p.pathMappings[td.name] = p.currentPaths
 	}
 
+	// Record the fixed directory if the task or an enclosing InDir sets one
+	if dir := cmp.Or(td.dir, p.currentDir); dir != "" {
+		p.taskDirs[td.name] = dir
+	}
+
 	// Collect TaskDef (deduplicated by name, only non-hidden visible tasks)
 	// Also filter out tasks that are skipped everywhere (empty paths = skip everywhere)
 	if !p.seenDefs[td.name] && !td.hidden && !p.isSkippedEverywhere(td.name) {
@@ -121,6 +162,24 @@ func (p *ExecutionPlan) restorePathContext(prev pathContext) {
 	p.skipRules = prev.skipRules
 }
 
+// setDirContext sets the InDir directory for subsequent addFunc calls.
+// Returns the previous directory for restoration.
+func (p *ExecutionPlan) setDirContext(dir string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prev := p.currentDir
+	p.currentDir = dir
+	return prev
+}
+
+// TaskDirs returns the directories of tasks that run in a fixed directory
+// (see InDir).
+func (p *ExecutionPlan) TaskDirs() map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.taskDirs
+}
+
 // PathMappings returns the collected path mappings.
 func (p *ExecutionPlan) PathMappings() map[string]*PathFilter {
 	p.mu.Lock()
@@ -246,9 +305,18 @@ func collectTasksFromSteps(
 			seen[step.Name] = true
 
 			info := TaskInfo{
-				Name:   step.Name,
-				Usage:  step.Usage,
-				Hidden: step.Hidden,
+				Name:      step.Name,
+				Usage:     step.Usage,
+				LongUsage: step.LongUsage,
+				Examples:  step.Examples,
+				Hidden:    step.Hidden,
+				Tool:      step.Tool,
+				Limits:    step.Limits,
+				Sandbox:   step.Sandbox,
+				Timeout:   step.Timeout,
+				Tags:      step.Tags,
+				Inputs:    step.Inputs,
+				Outputs:   step.Outputs,
 			}
 
 			// Get paths from mapping, default to ["."] for root-only tasks
@@ -349,6 +417,18 @@ func printPlanSteps(ctx context.Context, steps []*PlanStep, indent string, showH
 			if step.Deduped {
 				annotations = append(annotations, "skipped")
 			}
+			if step.Limits != nil {
+				annotations = append(annotations, "limits: "+step.Limits.String())
+			}
+			if step.Sandbox != nil {
+				annotations = append(annotations, step.Sandbox.String())
+			}
+			if step.Timeout != "" {
+				annotations = append(annotations, "timeout: "+step.Timeout)
+			}
+			if len(step.Tags) > 0 {
+				annotations = append(annotations, "tags: "+strings.Join(step.Tags, ", "))
+			}
 			if len(annotations) > 0 {
 				label += " (" + strings.Join(annotations, ", ") + ")"
 			}
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Events written with -output=json, one JSON object per line.
+const (
+	eventStart  = "start"  // a task started
+	eventFinish = "finish" // a task succeeded, was cached or was skipped
+	eventError  = "error"  // a task failed
+	eventDone   = "done"   // the run ended; always the last event
+)
+
+// taskEvent is a line of the -output=json event stream, e.g.
+//
+//	{"event":"start","task":"go-test","path":"services/api","time":"..."}
+//	{"event":"error","task":"go-test","path":"services/api","time":"...","duration_ms":1250,"status":"failed","exit_code":1,"error":"exit status 1"}
+type taskEvent struct {
+	Event      string    `json:"event"`
+	Task       string    `json:"task"`
+	Path       string    `json:"path"`
+	Time       time.Time `json:"time"`
+	DurationMS *int64    `json:"duration_ms,omitempty"` // not set for start
+	Status     string    `json:"status,omitempty"`      // ok, failed, cached or skipped
+	ExitCode   *int      `json:"exit_code,omitempty"`   // not set for start
+	Error      string    `json:"error,omitempty"`
+}
+
+// writeEvent writes e to the event stream of the log, if enabled. Events of
+// hidden tasks are not written, like in TAP output. The caller must hold
+// l.mu, so events from parallel tasks are written whole.
+func (l *runLog) writeEvent(e taskEvent, hidden bool) {
+	if l.events == nil || hidden {
+		return
+	}
+	e.Time = time.Now().UTC()
+	if e.Path == "" {
+		e.Path = "."
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	_, _ = l.events.Write(append(data, '\n'))
+}
+
+// recordStart records a started task. Only the event stream shows it.
+func (l *runLog) recordStart(name, path string, hidden bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writeEvent(taskEvent{Event: eventStart, Task: name, Path: path}, hidden)
+}
+
+// finishEvent returns the event for a finished task run.
+func finishEvent(t runLogEntry) taskEvent {
+	ms, code := t.duration.Milliseconds(), t.exitCode
+	e := taskEvent{
+		Event:      eventFinish,
+		Task:       t.name,
+		Path:       t.path,
+		DurationMS: &ms,
+		Status:     "ok",
+		ExitCode:   &code,
+	}
+	switch {
+	case t.skipped:
+		e.Status = "skipped"
+	case t.cached:
+		e.Status = "cached"
+	case t.err != nil:
+		e.Event, e.Status = eventError, "failed"
+		e.Error = strings.TrimSpace(t.err.Error())
+	}
+	return e
+}
+
+// recordDone writes the last event of the stream for the run of the task
+// name, with the exit code of pocket.
+func (l *runLog) recordDone(name, path string, d time.Duration, code int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ms := d.Milliseconds()
+	e := taskEvent{Event: eventDone, Task: name, Path: path, DurationMS: &ms, Status: "ok", ExitCode: &code}
+	if code != 0 {
+		e.Status = "failed"
+	}
+	l.writeEvent(e, false)
+}
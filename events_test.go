@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunLogEvents(t *testing.T) {
+	noop := func(_ context.Context) error { return nil }
+	install := Task("install:tool", "install tool", noop, AsHidden())
+	lint := Task("lint", "lint code", noop)
+	test := Task("test", "run tests", func(_ context.Context) error { return errors.New("exit status 1") })
+
+	var events strings.Builder
+	rl := &runLog{start: time.Now(), events: &events}
+	err := runWithContext(context.Background(), Serial(install, lint, test), discardOutput(), ".", false, nil, rl)
+	if err == nil {
+		t.Fatal("expected the run to fail")
+	}
+	rl.recordDone("all", ".", time.Second, 1)
+
+	type event struct {
+		Event      string `json:"event"`
+		Task       string `json:"task"`
+		Path       string `json:"path"`
+		DurationMS *int64 `json:"duration_ms"`
+		Status     string `json:"status"`
+		ExitCode   *int   `json:"exit_code"`
+		Error      string `json:"error"`
+	}
+	var got []event
+	for line := range strings.Lines(events.String()) {
+		var e event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		got = append(got, e)
+	}
+
+	want := []struct{ event, task, status string }{
+		{"start", "lint", ""},
+		{"finish", "lint", "ok"},
+		{"start", "test", ""},
+		{"error", "test", "failed"},
+		{"done", "all", "failed"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d:\n%s", len(got), len(want), events.String())
+	}
+	for i, w := range want {
+		e := got[i]
+		if e.Event != w.event || e.Task != w.task || e.Status != w.status || e.Path != "." {
+			t.Errorf("event %d = %+v, want %s of %s with status %q", i, e, w.event, w.task, w.status)
+		}
+		if (e.Event == "start") != (e.DurationMS == nil && e.ExitCode == nil) {
+			t.Errorf("event %d: only start events should lack duration and exit code: %+v", i, e)
+		}
+	}
+	if got[3].Error != "exit status 1" || *got[3].ExitCode != 1 {
+		t.Errorf("error event = %+v, want error and exit code of the task", got[3])
+	}
+}
+
+func TestFinishEvent(t *testing.T) {
+	tests := []struct {
+		entry  runLogEntry
+		event  string
+		status string
+	}{
+		{runLogEntry{name: "lint"}, "finish", "ok"},
+		{runLogEntry{name: "lint", cached: true}, "finish", "cached"},
+		{runLogEntry{name: "lint", skipped: true}, "finish", "skipped"},
+		{runLogEntry{name: "lint", exitCode: 2, err: errors.New("boom")}, "error", "failed"},
+	}
+	for _, tt := range tests {
+		e := finishEvent(tt.entry)
+		if e.Event != tt.event || e.Status != tt.status {
+			t.Errorf("finishEvent(%+v) = %s/%s, want %s/%s", tt.entry, e.Event, e.Status, tt.event, tt.status)
+		}
+	}
+}
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
@@ -19,7 +21,7 @@ const WaitDelay = 5 * time.Second
 
 var (
 	colorEnvOnce sync.Once
-	colorEnvVars []string // extra env vars to force colors
+	colorEnvVars []string // extra env vars to force (or disable) colors
 )
 
 // colorForceEnvVars are the environment variables set to force color output.
@@ -44,12 +46,15 @@ func computeColorEnv(isTTY, noColorSet bool) []string {
 	return colorForceEnvVars
 }
 
-// initColorEnv detects if stdout is a TTY and prepares env vars to force colors.
+// initColorEnv detects if stdout is a TTY and prepares the color env vars
+// for child tools according to the color mode (see color.go).
 // This is called once on first Command() call.
 func initColorEnv() {
-	_, noColor := os.LookupEnv("NO_COLOR")
+	colorMu.Lock()
+	m := colorMode
+	colorMu.Unlock()
 	isTTY := term.IsTerminal(int(os.Stdout.Fd()))
-	colorEnvVars = computeColorEnv(isTTY, noColor)
+	colorEnvVars = colorEnvFor(m, isTTY, os.LookupEnv)
 }
 
 // newCommand creates an exec.Cmd with common setup but no output configuration.
@@ -60,6 +65,7 @@ func newCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
 	binDir := FromBinDir()
 	env := PrependPath(os.Environ(), binDir)
 	env = append(env, colorEnvVars...)
+	env = append(env, commandEnv(ctx)...)
 
 	// If name is not a path and exists in .pocket/bin/, use the full path.
 	// This is needed because exec.Command resolves the binary using os.Getenv("PATH")
@@ -110,11 +116,13 @@ func fileExists(path string) bool {
 // If stdout is a TTY, color-forcing environment variables are added so that
 // tools output ANSI colors even when their output is buffered (for parallel execution).
 //
-// Note: For commands run from task actions, prefer TaskContext.Command() which
-// automatically wires output to the task's output writers for proper parallel buffering.
+// Run the command with RunCommand rather than cmd.Run: RunCommand wires
+// os.Stdout and os.Stderr to the task output (buffered in parallel
+// execution), and records the command and its output in the run log.
+// Output of a command started with cmd.Run goes straight to the terminal
+// and is missing from the run log.
 //
-// To redirect output (e.g., for buffering in parallel execution),
-// set cmd.Stdout and cmd.Stderr after creating the command.
+// To capture output, set cmd.Stdout or cmd.Stderr after creating the command.
 func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
 	cmd := newCommand(ctx, name, args...)
 	cmd.Stdout = os.Stdout
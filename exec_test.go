@@ -1,9 +1,12 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 )
 
@@ -141,3 +144,110 @@ func TestPrependPath(t *testing.T) {
 		})
 	}
 }
+
+func TestColorEnvFor(t *testing.T) {
+	env := func(vars map[string]string) func(string) (string, bool) {
+		return func(key string) (string, bool) {
+			v, ok := vars[key]
+			return v, ok
+		}
+	}
+
+	tests := []struct {
+		name  string
+		mode  ColorMode
+		isTTY bool
+		vars  map[string]string
+		want  string // expected first env var, "" for none
+	}{
+		{"auto TTY", ColorAuto, true, nil, "FORCE_COLOR=1"},
+		{"auto pipe", ColorAuto, false, nil, ""},
+		{"auto NO_COLOR wins over TTY", ColorAuto, true, map[string]string{"NO_COLOR": "1"}, ""},
+		{"auto empty NO_COLOR is ignored", ColorAuto, true, map[string]string{"NO_COLOR": ""}, "FORCE_COLOR=1"},
+		{"auto CLICOLOR_FORCE on pipe", ColorAuto, false, map[string]string{"CLICOLOR_FORCE": "1"}, "FORCE_COLOR=1"},
+		{"auto FORCE_COLOR=0 on pipe", ColorAuto, false, map[string]string{"FORCE_COLOR": "0"}, ""},
+		{"always overrides NO_COLOR", ColorAlways, false, map[string]string{"NO_COLOR": "1"}, "FORCE_COLOR=1"},
+		{"never propagates NO_COLOR", ColorNever, true, nil, "NO_COLOR=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := colorEnvFor(tt.mode, tt.isTTY, env(tt.vars))
+			if tt.want == "" {
+				if len(got) != 0 {
+					t.Errorf("expected no env vars, got %v", got)
+				}
+				return
+			}
+			if !slices.Contains(got, tt.want) {
+				t.Errorf("expected %q in %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestANSIStripWriter(t *testing.T) {
+	var buf strings.Builder
+	w := newANSIStripWriter(&buf)
+
+	// The second escape sequence is split across writes.
+	for _, chunk := range []string{"\x1b[31mred\x1b[0m ok \x1b[1", ";32mgreen\x1b[0m\n"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := buf.String(); got != "red ok green\n" {
+		t.Errorf("got %q, want %q", got, "red ok green\n")
+	}
+}
+
+func TestANSIStripWriter_Close(t *testing.T) {
+	var buf strings.Builder
+	w := newANSIStripWriter(&buf)
+	if _, err := w.Write([]byte("done \x1b[3")); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "done " {
+		t.Fatalf("expected incomplete sequence to be held back, got %q", got)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "done [3" {
+		t.Errorf("got %q, want %q", got, "done [3")
+	}
+}
+
+func TestStripPlanANSI(t *testing.T) {
+	examples := []Example{{Command: "./pok x", Description: "\x1b[1mbold\x1b[0m"}}
+	plan := IntrospectPlan{
+		AutoRun: []*PlanStep{{
+			Type:     "serial",
+			Children: []*PlanStep{{Type: "func", Name: "x", Usage: "\x1b[32mgreen\x1b[0m", Examples: examples}},
+		}},
+		ManualRun: []TaskInfo{{Name: "y", LongUsage: "\x1b[31mred\x1b[0m"}},
+	}
+	stripPlanANSI(&plan)
+
+	step := plan.AutoRun[0].Children[0]
+	if step.Usage != "green" || step.Examples[0].Description != "bold" {
+		t.Errorf("expected plain AutoRun text, got %q / %q", step.Usage, step.Examples[0].Description)
+	}
+	if plan.ManualRun[0].LongUsage != "red" {
+		t.Errorf("expected plain ManualRun text, got %q", plan.ManualRun[0].LongUsage)
+	}
+	if examples[0].Description != "\x1b[1mbold\x1b[0m" {
+		t.Error("expected shared examples to be left untouched")
+	}
+}
+
+func TestParseColorMode(t *testing.T) {
+	for _, valid := range []string{"auto", "always", "never"} {
+		if _, err := parseColorMode(valid); err != nil {
+			t.Errorf("parseColorMode(%q) failed: %v", valid, err)
+		}
+	}
+	if _, err := parseColorMode("sometimes"); err == nil {
+		t.Error("expected error for invalid color mode")
+	}
+}
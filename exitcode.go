@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Exit codes used by the CLI, following shell conventions for usage errors,
+// commands that could not be run and interrupts. ./pok -print-exit-codes
+// lists them.
+const (
+	ExitFailure           = 1   // a task failed
+	ExitUsage             = 2   // unknown task, invalid flag or argument
+	ExitConfig            = 3   // the configuration is invalid
+	ExitToolInstall       = 4   // a tool could not be installed
+	ExitToolNotExecutable = 126 // a tool exists but could not be executed
+	ExitToolNotFound      = 127 // a tool is not installed or not on PATH
+	ExitInterrupted       = 130 // the run was interrupted (SIGINT or SIGTERM)
+)
+
+// exitCodes describes the exit codes for ./pok -print-exit-codes.
+var exitCodes = []struct {
+	code int
+	desc string
+}{
+	{0, "success"},
+	{ExitFailure, "a task failed"},
+	{ExitUsage, "unknown task, invalid flag or argument"},
+	{ExitConfig, "the configuration is invalid"},
+	{ExitToolInstall, "a tool could not be installed"},
+	{ExitToolNotExecutable, "a tool exists but could not be executed"},
+	{ExitToolNotFound, "a tool is not installed or not on PATH"},
+	{ExitInterrupted, "the run was interrupted (SIGINT or SIGTERM)"},
+}
+
+// writeExitCodes writes the exit codes and their meaning, one per line.
+func writeExitCodes(w io.Writer) {
+	for _, c := range exitCodes {
+		fmt.Fprintf(w, "%3d  %s\n", c.code, c.desc)
+	}
+}
+
+var (
+	// ErrToolNotFound is matched by errors from commands whose binary
+	// could not be found.
+	ErrToolNotFound = errors.New("tool not found")
+	// ErrToolNotExecutable is matched by errors from commands whose binary
+	// exists but could not be executed (e.g. missing permissions).
+	ErrToolNotExecutable = errors.New("tool not executable")
+)
+
+// ToolError reports that a command could not be started, as opposed to a
+// command that ran and failed. Match it with errors.Is(err, ErrToolNotFound)
+// or errors.Is(err, ErrToolNotExecutable).
+type ToolError struct {
+	Name string // command name as passed to Exec
+	Kind error  // ErrToolNotFound or ErrToolNotExecutable
+	Err  error  // underlying error from os/exec
+}
+
+func (e *ToolError) Error() string {
+	return fmt.Sprintf("%s: %v: %v", e.Name, e.Kind, e.Err)
+}
+
+func (e *ToolError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// ExitCode returns the CLI exit code for the error.
+func (e *ToolError) ExitCode() int {
+	if e.Kind == ErrToolNotExecutable {
+		return ExitToolNotExecutable
+	}
+	return ExitToolNotFound
+}
+
+// InstallError reports that the installer task of a tool (see AsTool)
+// failed, e.g. because a download or go install failed.
+type InstallError struct {
+	Tool string // tool name as passed to AsTool
+	Err  error  // error of the installer task
+}
+
+func (e *InstallError) Error() string {
+	return fmt.Sprintf("install %s: %v", e.Tool, e.Err)
+}
+
+func (e *InstallError) Unwrap() error {
+	return e.Err
+}
+
+// classifyExecError wraps errors from starting cmd in a ToolError.
+// Errors from commands that ran (including non-zero exits) are returned as is.
+func classifyExecError(cmd *exec.Cmd, err error) error {
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return err
+	}
+	// A missing working directory is reported like a missing binary.
+	if cmd.Dir != "" {
+		if _, statErr := os.Stat(cmd.Dir); statErr != nil {
+			return err
+		}
+	}
+	name := filepath.Base(cmd.Path)
+	if len(cmd.Args) > 0 {
+		name = cmd.Args[0]
+	}
+	switch {
+	case errors.Is(err, exec.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return &ToolError{Name: name, Kind: ErrToolNotFound, Err: err}
+	case errors.Is(err, fs.ErrPermission):
+		return &ToolError{Name: name, Kind: ErrToolNotExecutable, Err: err}
+	}
+	return err
+}
+
+// cliExitCode returns the process exit code for a failed run.
+func cliExitCode(err error) int {
+	var installErr *InstallError
+	if errors.As(err, &installErr) {
+		return ExitToolInstall
+	}
+	var toolErr *ToolError
+	if errors.As(err, &toolErr) {
+		return toolErr.ExitCode()
+	}
+	return ExitFailure
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExec_ClassifiesToolErrors(t *testing.T) {
+	if runtime.GOOS == Windows {
+		t.Skip("permission bits are not used on windows")
+	}
+	dir := t.TempDir()
+	notExec := filepath.Join(dir, "not-executable")
+	if err := os.WriteFile(notExec, []byte("#!/bin/sh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	failing := filepath.Join(dir, "failing")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\nexit 3\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		cmd      string
+		wantKind error
+		wantCode int
+	}{
+		{name: "missing from PATH", cmd: "pocket-no-such-tool", wantKind: ErrToolNotFound, wantCode: ExitToolNotFound},
+		{name: "missing path", cmd: filepath.Join(dir, "missing"), wantKind: ErrToolNotFound, wantCode: ExitToolNotFound},
+		{name: "not executable", cmd: notExec, wantKind: ErrToolNotExecutable, wantCode: ExitToolNotExecutable},
+		{name: "tool failure", cmd: failing, wantCode: ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Do(func(ctx context.Context) error {
+				return ExecIn(ctx, dir, tt.cmd)
+			})
+			err := runWithContext(context.Background(), r, discardOutput(), ".", false, nil, nil)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			var toolErr *ToolError
+			isToolErr := errors.As(err, &toolErr)
+			if tt.wantKind == nil {
+				if isToolErr {
+					t.Errorf("got ToolError %v for a command that ran", err)
+				}
+			} else if !errors.Is(err, tt.wantKind) {
+				t.Errorf("error = %v, want %v", err, tt.wantKind)
+			}
+			if got := cliExitCode(err); got != tt.wantCode {
+				t.Errorf("cliExitCode() = %d, want %d", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCliExitCode_InstallError(t *testing.T) {
+	install := Task("install:tool", "install tool", func(_ context.Context) error {
+		return errors.New("download failed")
+	}, AsHidden(), AsTool("tool", "v1.0.0"))
+	lint := Task("lint", "lint code", Serial(install, Do(func(_ context.Context) error { return nil })))
+
+	err := runWithContext(context.Background(), lint, discardOutput(), ".", false, nil, nil)
+	var installErr *InstallError
+	if !errors.As(err, &installErr) || installErr.Tool != "tool" {
+		t.Fatalf("error = %v, want an InstallError for tool", err)
+	}
+	if got := cliExitCode(err); got != ExitToolInstall {
+		t.Errorf("cliExitCode() = %d, want %d", got, ExitToolInstall)
+	}
+}
+
+func TestWriteExitCodes(t *testing.T) {
+	var b strings.Builder
+	writeExitCodes(&b)
+	for _, want := range []string{"  0  success\n", "  2  unknown task", "130  the run was interrupted"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("writeExitCodes() missing %q:\n%s", want, b.String())
+		}
+	}
+}
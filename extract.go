@@ -1,14 +1,22 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"time"
 )
 
 // ExtractOpt configures extraction behavior.
@@ -18,8 +26,12 @@ type extractConfig struct {
 	// renameMap maps source paths (or base names) to destination names.
 	// If a file matches a key, it's extracted with the corresponding value as name.
 	renameMap map[string]string
+	// renameOrder holds the renameMap keys in the order they were added.
+	renameOrder []string
 	// flatten extracts all files to the root of destDir, ignoring directory structure.
 	flatten bool
+	// warnings receives problems that don't fail the extraction.
+	warnings io.Writer
 }
 
 func newExtractConfig(opts []ExtractOpt) *extractConfig {
@@ -47,7 +59,7 @@ func WithRenameFile(srcPath, destName string) ExtractOpt {
 		if destName == "" {
 			destName = filepath.Base(srcPath)
 		}
-		cfg.renameMap[srcPath] = destName
+		cfg.addRename(srcPath, destName)
 	}
 }
 
@@ -56,10 +68,21 @@ func WithRenameFile(srcPath, destName string) ExtractOpt {
 // Multiple calls accumulate files to extract.
 func WithExtractFile(name string) ExtractOpt {
 	return func(cfg *extractConfig) {
-		cfg.renameMap[name] = name
+		cfg.addRename(name, name)
 	}
 }
 
+// addRename adds or replaces a rename mapping, keeping insertion order.
+// Archive paths use forward slashes, so src is matched with forward
+// slashes too, also when it was built with filepath.Join on Windows.
+func (cfg *extractConfig) addRename(src, dest string) {
+	src = filepath.ToSlash(src)
+	if _, ok := cfg.renameMap[src]; !ok {
+		cfg.renameOrder = append(cfg.renameOrder, src)
+	}
+	cfg.renameMap[src] = dest
+}
+
 // WithFlatten flattens directory structure, extracting all files to destDir root.
 // File names are preserved but directory paths are discarded.
 func WithFlatten() ExtractOpt {
@@ -68,6 +91,15 @@ func WithFlatten() ExtractOpt {
 	}
 }
 
+// WithWarnings writes problems that don't fail the extraction, such as
+// symlinks that could be neither created nor copied, to w. Without it,
+// they are dropped. Download and FromLocal pass the task's stderr.
+func WithWarnings(w io.Writer) ExtractOpt {
+	return func(cfg *extractConfig) {
+		cfg.warnings = w
+	}
+}
+
 // ExtractTarGz extracts a .tar.gz archive to destDir.
 // If no options are provided, all files are extracted preserving directory structure.
 // Use WithRenameFile or WithExtractFile to limit extraction to specific files.
@@ -110,66 +142,63 @@ func ExtractZip(src, destDir string, opts ...ExtractOpt) error {
 	}
 	defer r.Close()
 
-	cfg := newExtractConfig(opts)
-
+	x := newExtractor(destDir, newExtractConfig(opts))
 	for _, f := range r.File {
-		name := f.Name
-		baseName := filepath.Base(name)
-
-		// Determine output name based on config.
-		outputName, shouldExtract := resolveOutputName(name, baseName, cfg)
-		if !shouldExtract {
-			continue
+		target, ok, err := x.target(f.Name)
+		if err != nil {
+			return err
 		}
-
-		target := filepath.Join(destDir, outputName)
-
-		// Security check: ensure we don't escape destDir.
-		if !strings.HasPrefix(filepath.Clean(target), filepath.Clean(destDir)) {
-			return fmt.Errorf("invalid file path: %s", name)
+		if !ok {
+			continue
 		}
 
-		if f.FileInfo().IsDir() {
-			if cfg.flatten || len(cfg.renameMap) > 0 {
-				continue
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := x.dir(target, mode, f.Modified); err != nil {
+				return err
 			}
-			if err := os.MkdirAll(target, f.Mode()); err != nil {
-				return fmt.Errorf("create directory: %w", err)
+		case mode&os.ModeSymlink != 0:
+			linkname, err := readZipSymlink(f)
+			if err != nil {
+				return err
+			}
+			if err := x.symlink(target, linkname); err != nil {
+				return err
+			}
+		default:
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("open file in archive: %w", err)
+			}
+			err = x.file(f.Name, target, rc, mode, f.Modified)
+			rc.Close()
+			if err != nil {
+				return err
 			}
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-			return fmt.Errorf("create parent directory: %w", err)
-		}
-
-		if err := extractZipFile(f, target); err != nil {
-			return err
 		}
 	}
-	return nil
+	return x.finish()
 }
 
-func extractZipFile(f *zip.File, target string) error {
+// readZipSymlink returns the link target stored as the content of a zip entry.
+func readZipSymlink(f *zip.File) (string, error) {
 	rc, err := f.Open()
 	if err != nil {
-		return fmt.Errorf("open file in archive: %w", err)
+		return "", fmt.Errorf("open file in archive: %w", err)
 	}
 	defer rc.Close()
 
-	outFile, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode())
+	// Link targets are paths; anything larger is not a sane symlink.
+	b, err := io.ReadAll(io.LimitReader(rc, 4096))
 	if err != nil {
-		return fmt.Errorf("create output file: %w", err)
-	}
-	defer outFile.Close()
-
-	if _, err := io.Copy(outFile, rc); err != nil {
-		return fmt.Errorf("write file: %w", err)
+		return "", fmt.Errorf("read symlink %s: %w", f.Name, err)
 	}
-	return nil
+	return string(b), nil
 }
 
 func extractTarReader(tr *tar.Reader, destDir string, cfg *extractConfig) error {
+	x := newExtractor(destDir, cfg)
 	for {
 		header, err := tr.Next()
 		if err == io.EOF {
@@ -179,55 +208,289 @@ func extractTarReader(tr *tar.Reader, destDir string, cfg *extractConfig) error
 			return fmt.Errorf("read tar header: %w", err)
 		}
 
-		name := header.Name
-		baseName := filepath.Base(name)
-
-		// Determine output name based on config.
-		outputName, shouldExtract := resolveOutputName(name, baseName, cfg)
-		if !shouldExtract {
-			continue
+		target, ok, err := x.target(header.Name)
+		if err != nil {
+			return err
 		}
-
-		target := filepath.Join(destDir, outputName)
-
-		// Security check: ensure we don't escape destDir.
-		if !strings.HasPrefix(filepath.Clean(target), filepath.Clean(destDir)) {
-			return fmt.Errorf("invalid file path: %s", name)
+		if !ok {
+			continue
 		}
 
+		mode := header.FileInfo().Mode()
 		switch header.Typeflag {
 		case tar.TypeDir:
-			if cfg.flatten || len(cfg.renameMap) > 0 {
-				continue
-			}
-			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
-				return fmt.Errorf("create directory: %w", err)
+			if err := x.dir(target, mode, header.ModTime); err != nil {
+				return err
 			}
 		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-				return fmt.Errorf("create parent directory: %w", err)
+			if err := x.file(header.Name, target, tr, mode, header.ModTime); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := x.symlink(target, header.Linkname); err != nil {
+				return err
 			}
-			if err := extractTarFile(tr, target, os.FileMode(header.Mode)); err != nil {
+		case tar.TypeLink:
+			if err := x.hardlink(header.Name, target, header.Linkname); err != nil {
 				return err
 			}
 		}
 	}
+	return x.finish()
+}
+
+// extractor writes archive entries below destDir.
+// Every write is checked to stay inside destDir, both lexically and after
+// resolving symlinks created by earlier entries.
+type extractor struct {
+	destDir string
+	cfg     *extractConfig
+
+	// extracted maps archive paths to the files written for them,
+	// so later hardlink entries can refer to them.
+	extracted map[string]string
+	// dirTimes are applied last, since writing files updates directory mtimes.
+	dirTimes []dirTime
+	// copyLinks are symlinks that could not be created; their targets are
+	// copied once all entries are extracted.
+	copyLinks []copyLink
+}
+
+type copyLink struct {
+	target string // where the symlink should have been created
+	src    string // the file it points to
+}
+
+// createSymlink creates a symlink. A variable so tests can simulate
+// systems without symlink support.
+var createSymlink = os.Symlink
+
+// symlinkUnsupported reports whether a failed symlink should fall back to a
+// copy. Creating symlinks on Windows requires a privilege (or developer
+// mode) that many users don't have.
+func symlinkUnsupported(err error) bool {
+	return runtime.GOOS == Windows || errors.Is(err, fs.ErrPermission)
+}
+
+type dirTime struct {
+	path    string
+	modTime time.Time
+}
+
+func newExtractor(destDir string, cfg *extractConfig) *extractor {
+	return &extractor{
+		destDir:   filepath.Clean(destDir),
+		cfg:       cfg,
+		extracted: make(map[string]string),
+	}
+}
+
+// selective reports whether only some entries are extracted, in which case
+// directory entries are skipped.
+func (x *extractor) selective() bool {
+	return x.cfg.flatten || len(x.cfg.renameMap) > 0
+}
+
+// target returns the destination path for an archive entry, or false if the
+// entry is not selected for extraction.
+func (x *extractor) target(name string) (string, bool, error) {
+	// Archive paths use forward slashes; reject absolute paths in any form.
+	if path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", false, fmt.Errorf("invalid file path: %s", name)
+	}
+	outputName, ok := resolveOutputName(name, filepath.Base(name), x.cfg)
+	if !ok {
+		return "", false, nil
+	}
+	target := filepath.Join(x.destDir, outputName)
+	if !isWithinDir(x.destDir, target) {
+		return "", false, fmt.Errorf("invalid file path: %s", name)
+	}
+	return target, true, nil
+}
+
+func (x *extractor) dir(target string, mode os.FileMode, modTime time.Time) error {
+	if x.selective() {
+		return nil
+	}
+	if err := x.mkdirParent(target); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(target, mode.Perm()|0o700); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	x.dirTimes = append(x.dirTimes, dirTime{path: target, modTime: modTime})
 	return nil
 }
 
-func extractTarFile(tr *tar.Reader, target string, mode os.FileMode) error {
-	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+func (x *extractor) file(name, target string, r io.Reader, mode os.FileMode, modTime time.Time) error {
+	if err := x.prepare(target); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
 	if err != nil {
 		return fmt.Errorf("create output file: %w", err)
 	}
-	defer f.Close()
-
-	if _, err := io.Copy(f, tr); err != nil {
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := f.Close(); err != nil {
 		return fmt.Errorf("write file: %w", err)
 	}
+	// OpenFile applies the umask and leaves modes of existing files alone.
+	if err := os.Chmod(target, mode.Perm()); err != nil {
+		return fmt.Errorf("set file mode: %w", err)
+	}
+	if !modTime.IsZero() {
+		if err := os.Chtimes(target, modTime, modTime); err != nil {
+			return fmt.Errorf("set file times: %w", err)
+		}
+	}
+	x.extracted[path.Clean(name)] = target
+	return nil
+}
+
+// symlink creates a symlink at target. The link must point inside destDir.
+func (x *extractor) symlink(target, linkname string) error {
+	if linkname == "" || path.IsAbs(linkname) || filepath.IsAbs(linkname) || filepath.VolumeName(linkname) != "" {
+		return fmt.Errorf("invalid symlink %s -> %s", target, linkname)
+	}
+	if !isWithinDir(x.destDir, filepath.Join(filepath.Dir(target), filepath.FromSlash(linkname))) {
+		return fmt.Errorf("invalid symlink %s -> %s: target escapes %s", target, linkname, x.destDir)
+	}
+	if err := x.prepare(target); err != nil {
+		return err
+	}
+	// Check again from the resolved parent, which may itself be a symlink.
+	root, parent, err := x.resolve(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	if !isWithinDir(root, filepath.Join(parent, filepath.FromSlash(linkname))) {
+		return fmt.Errorf("invalid symlink %s -> %s: target escapes %s", target, linkname, x.destDir)
+	}
+	if err := createSymlink(filepath.FromSlash(linkname), target); err != nil {
+		if !symlinkUnsupported(err) {
+			return fmt.Errorf("create symlink: %w", err)
+		}
+		// The link target may come later in the archive.
+		x.copyLinks = append(x.copyLinks, copyLink{
+			target: target,
+			src:    filepath.Join(filepath.Dir(target), filepath.FromSlash(linkname)),
+		})
+	}
 	return nil
 }
 
+// hardlink links target to a previously extracted entry, copying the file
+// if the filesystem does not support hardlinks. When extracting selected
+// files only, a link to an entry that was not selected is skipped.
+func (x *extractor) hardlink(name, target, linkname string) error {
+	src, ok := x.extracted[path.Clean(linkname)]
+	if !ok {
+		if x.selective() {
+			return nil
+		}
+		return fmt.Errorf("hardlink %s: target %s was not extracted", name, linkname)
+	}
+	if err := x.prepare(target); err != nil {
+		return err
+	}
+	if err := os.Link(src, target); err != nil {
+		if err := CopyFile(src, target); err != nil {
+			return fmt.Errorf("create hardlink: %w", err)
+		}
+	}
+	x.extracted[path.Clean(name)] = target
+	return nil
+}
+
+// prepare creates the parent directory of target and removes any existing
+// entry at target, so a previous symlink is replaced rather than followed.
+func (x *extractor) prepare(target string) error {
+	if err := x.mkdirParent(target); err != nil {
+		return err
+	}
+	if fi, err := os.Lstat(target); err == nil && !fi.IsDir() {
+		if err := os.Remove(target); err != nil {
+			return fmt.Errorf("replace %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// mkdirParent creates the parent directory of target and verifies that it
+// still resolves inside destDir once symlinks are followed.
+func (x *extractor) mkdirParent(target string) error {
+	parent := filepath.Dir(target)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return fmt.Errorf("create parent directory: %w", err)
+	}
+	root, resolved, err := x.resolve(parent)
+	if err != nil {
+		return err
+	}
+	if !isWithinDir(root, resolved) {
+		return fmt.Errorf("invalid file path: %s resolves outside %s", target, x.destDir)
+	}
+	return nil
+}
+
+// resolve returns destDir and dir with all symlinks evaluated.
+func (x *extractor) resolve(dir string) (root, resolved string, err error) {
+	root, err = filepath.EvalSymlinks(x.destDir)
+	if err != nil {
+		return "", "", fmt.Errorf("resolve %s: %w", x.destDir, err)
+	}
+	resolved, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("resolve %s: %w", dir, err)
+	}
+	return root, resolved, nil
+}
+
+// finish copies the targets of symlinks that could not be created, then
+// applies directory mtimes in reverse order, so children are done before
+// the parents whose mtimes they would otherwise change.
+func (x *extractor) finish() error {
+	for _, l := range x.copyLinks {
+		fi, err := os.Stat(l.src)
+		if err != nil || !fi.Mode().IsRegular() {
+			x.warnf("skipped symlink %s: cannot create symlinks and %s is not a file", l.target, l.src)
+			continue
+		}
+		if err := CopyFile(l.src, l.target); err != nil {
+			return fmt.Errorf("copy symlink target: %w", err)
+		}
+	}
+	for _, d := range slices.Backward(x.dirTimes) {
+		if d.modTime.IsZero() {
+			continue
+		}
+		if err := os.Chtimes(d.path, d.modTime, d.modTime); err != nil {
+			return fmt.Errorf("set directory times: %w", err)
+		}
+	}
+	return nil
+}
+
+// warnf writes a warning to the configured writer, if any.
+func (x *extractor) warnf(format string, args ...any) {
+	if x.cfg.warnings != nil {
+		fmt.Fprintf(x.cfg.warnings, "warning: "+format+"\n", args...)
+	}
+}
+
+// isWithinDir reports whether p is dir or a path below it.
+func isWithinDir(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
 // resolveOutputName determines the output file name based on extraction config.
 // Returns the output name and whether the file should be extracted.
 func resolveOutputName(fullPath, baseName string, cfg *extractConfig) (string, bool) {
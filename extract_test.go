@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	body     string
+	linkname string
+	mode     int64
+}
+
+func writeTestTar(t *testing.T, entries []tarEntry) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "test.tar")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	for _, e := range entries {
+		mode := e.mode
+		if mode == 0 {
+			mode = 0o644
+		}
+		hdr := &tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Linkname: e.linkname,
+			Mode:     mode,
+			Size:     int64(len(e.body)),
+			ModTime:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestExtractTar_LinksModesAndTimes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+	src := writeTestTar(t, []tarEntry{
+		{name: "tool/", typeflag: tar.TypeDir, mode: 0o755},
+		{name: "tool/bin/tool-1.0", typeflag: tar.TypeReg, body: "binary", mode: 0o755},
+		{name: "tool/bin/tool", typeflag: tar.TypeSymlink, linkname: "tool-1.0"},
+		{name: "tool/bin/tool-hard", typeflag: tar.TypeLink, linkname: "tool/bin/tool-1.0"},
+	})
+	dest := t.TempDir()
+	if err := ExtractTar(src, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(filepath.Join(dest, "tool", "bin", "tool-1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o755 {
+		t.Errorf("mode = %v, want 0755", fi.Mode().Perm())
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !fi.ModTime().Equal(want) {
+		t.Errorf("mtime = %v, want %v", fi.ModTime(), want)
+	}
+
+	link, err := os.Readlink(filepath.Join(dest, "tool", "bin", "tool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "tool-1.0" {
+		t.Errorf("symlink target = %q, want %q", link, "tool-1.0")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, "tool", "bin", "tool-hard"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "binary" {
+		t.Errorf("hardlink content = %q, want %q", data, "binary")
+	}
+}
+
+func TestExtractTar_RejectsEscapes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+	tests := []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{
+			name:    "dot-dot path",
+			entries: []tarEntry{{name: "../evil", typeflag: tar.TypeReg, body: "x"}},
+		},
+		{
+			name:    "absolute path",
+			entries: []tarEntry{{name: "/tmp/evil", typeflag: tar.TypeReg, body: "x"}},
+		},
+		{
+			name:    "absolute symlink",
+			entries: []tarEntry{{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc"}},
+		},
+		{
+			name:    "escaping symlink",
+			entries: []tarEntry{{name: "a/link", typeflag: tar.TypeSymlink, linkname: "../../outside"}},
+		},
+		{
+			name: "write through symlink",
+			entries: []tarEntry{
+				{name: "a/", typeflag: tar.TypeDir, mode: 0o755},
+				{name: "a/link", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "a/link/up", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "a/link/up/evil", typeflag: tar.TypeReg, body: "x"},
+			},
+		},
+		{
+			name:    "hardlink to unknown file",
+			entries: []tarEntry{{name: "hard", typeflag: tar.TypeLink, linkname: "../../etc/passwd"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dest := filepath.Join(root, "dest")
+			if err := os.Mkdir(dest, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ExtractTar(writeTestTar(t, tt.entries), dest); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if _, err := os.Stat(filepath.Join(root, "evil")); err == nil {
+				t.Error("file was written outside destination")
+			}
+		})
+	}
+}
+
+func TestExtractZip_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+	src := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, e := range []struct {
+		name, body string
+		mode       os.FileMode
+	}{
+		{"bin/tool-1.0", "binary", 0o755},
+		{"bin/tool", "tool-1.0", os.ModeSymlink | 0o777},
+		{"bin/escape", "../../outside", os.ModeSymlink | 0o777},
+	} {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Store}
+		hdr.SetMode(e.mode)
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	dest := t.TempDir()
+	if err := ExtractZip(src, dest); err == nil {
+		t.Fatal("expected error for escaping symlink")
+	}
+	link, err := os.Readlink(filepath.Join(dest, "bin", "tool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "tool-1.0" {
+		t.Errorf("symlink target = %q, want %q", link, "tool-1.0")
+	}
+	fi, err := os.Stat(filepath.Join(dest, "bin", "tool-1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o755 {
+		t.Errorf("mode = %v, want 0755", fi.Mode().Perm())
+	}
+}
+
+func TestFindFirstExtractedFile_UsesOptionOrder(t *testing.T) {
+	opts := []ExtractOpt{
+		WithRenameFile("tool-1.0/tool", "tool"),
+		WithExtractFile("LICENSE"),
+		WithExtractFile("README.md"),
+	}
+	for range 50 {
+		if got, want := findFirstExtractedFile("bin", opts), filepath.Join("bin", "tool"); got != want {
+			t.Fatalf("findFirstExtractedFile() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestExtractTar_SymlinkFallbackCopiesTarget(t *testing.T) {
+	orig := createSymlink
+	createSymlink = func(_, _ string) error {
+		return &os.LinkError{Op: "symlink", Err: fs.ErrPermission}
+	}
+	t.Cleanup(func() { createSymlink = orig })
+
+	src := writeTestTar(t, []tarEntry{
+		// The link comes before its target, as in many release archives.
+		{name: "bin/tool", typeflag: tar.TypeSymlink, linkname: "tool-1.0"},
+		{name: "bin/tool-1.0", typeflag: tar.TypeReg, body: "binary", mode: 0o755},
+		{name: "bin/dangling", typeflag: tar.TypeSymlink, linkname: "missing"},
+	})
+	dest := t.TempDir()
+	var warnings strings.Builder
+	if err := ExtractTar(src, dest, WithWarnings(&warnings)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, "bin", "tool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "binary" {
+		t.Errorf("copied link content = %q, want %q", data, "binary")
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "bin", "dangling")); !os.IsNotExist(err) {
+		t.Errorf("expected dangling link to be skipped, got %v", err)
+	}
+	if !strings.Contains(warnings.String(), "warning: skipped symlink") || !strings.Contains(warnings.String(), "dangling") {
+		t.Errorf("expected a warning for the dangling link, got %q", warnings.String())
+	}
+}
+
+func TestExtractTar_SelectiveSkipsHardlinkToUnselectedEntry(t *testing.T) {
+	src := writeTestTar(t, []tarEntry{
+		{name: "tool-1.0/other", typeflag: tar.TypeReg, body: "other"},
+		{name: "tool-1.0/tool", typeflag: tar.TypeLink, linkname: "tool-1.0/other"},
+		{name: "tool-1.0/LICENSE", typeflag: tar.TypeReg, body: "MIT"},
+	})
+	dest := t.TempDir()
+	if err := ExtractTar(src, dest, WithExtractFile("tool"), WithExtractFile("LICENSE")); err != nil {
+		t.Fatalf("ExtractTar() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "LICENSE")); err != nil {
+		t.Errorf("expected LICENSE to be extracted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "tool")); !os.IsNotExist(err) {
+		t.Errorf("expected hardlink to unselected entry to be skipped, got %v", err)
+	}
+}
@@ -1,12 +1,23 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
+	"archive/tar"
+	"cmp"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket/internal/testenv"
 )
 
 // DownloadOpt configures download and extraction behavior.
@@ -19,6 +30,8 @@ type downloadConfig struct {
 	symlink      bool
 	skipIfExists string
 	httpHeaders  map[string]string
+	checksum     string // expected SHA-256, hex encoded
+	maxSize      int64  // maximum download size in bytes, 0 for no limit
 }
 
 func newDownloadConfig(opts []DownloadOpt) *downloadConfig {
@@ -69,7 +82,8 @@ func WithSymlink() DownloadOpt {
 	}
 }
 
-// WithSkipIfExists skips the download if the specified file exists.
+// WithSkipIfExists skips the download if the specified file exists, unless
+// tools are reinstalled (see Reinstall).
 func WithSkipIfExists(path string) DownloadOpt {
 	return func(cfg *downloadConfig) {
 		cfg.skipIfExists = path
@@ -84,6 +98,22 @@ func WithHTTPHeader(key, value string) DownloadOpt {
 	}
 }
 
+// WithChecksum verifies the download against the given hex-encoded SHA-256
+// digest. Archives are extracted to a staging directory and only moved into
+// place once the checksum matches.
+func WithChecksum(sha256 string) DownloadOpt {
+	return func(cfg *downloadConfig) {
+		cfg.checksum = strings.ToLower(sha256)
+	}
+}
+
+// WithMaxSize fails the download if it exceeds n bytes.
+func WithMaxSize(n int64) DownloadOpt {
+	return func(cfg *downloadConfig) {
+		cfg.maxSize = n
+	}
+}
+
 // Download creates a Runnable that fetches a URL and optionally extracts it.
 // Progress and status messages are written to the context's output.
 //
@@ -105,10 +135,15 @@ func Download(url string, opts ...DownloadOpt) Runnable {
 
 // download is the internal implementation of Download.
 func download(ctx context.Context, url string, opts ...DownloadOpt) error {
+	if ec := getExecContext(ctx); ec.installer != nil {
+		return ec.installer(ctx, url)
+	}
+
 	cfg := newDownloadConfig(opts)
+	cfg.extractOpts = append(cfg.extractOpts, WithWarnings(getExecContext(ctx).out.Stderr))
 
 	// Check if we can skip.
-	if cfg.skipIfExists != "" {
+	if cfg.skipIfExists != "" && !Reinstall(ctx) {
 		if _, err := os.Stat(cfg.skipIfExists); err == nil {
 			// Already exists, just ensure symlink if requested.
 			if cfg.symlink {
@@ -129,8 +164,7 @@ func download(ctx context.Context, url string, opts ...DownloadOpt) error {
 
 	Printf(ctx, "  Downloading %s\n", url)
 
-	// Download to temp file.
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, testenv.MirrorURL(url), nil)
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -147,26 +181,31 @@ func download(ctx context.Context, url string, opts ...DownloadOpt) error {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("download: HTTP %d", resp.StatusCode)
 	}
-
-	tmpFile, err := os.CreateTemp("", "pocket-download-*")
-	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
+	if cfg.maxSize > 0 && resp.ContentLength > cfg.maxSize {
+		return fmt.Errorf("download: size %d exceeds limit of %d bytes", resp.ContentLength, cfg.maxSize)
 	}
-	tmpPath := tmpFile.Name()
-	defer os.Remove(tmpPath)
 
-	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
-		tmpFile.Close()
-		return fmt.Errorf("download: %w", err)
+	body := newVerifyingReader(resp.Body, cfg)
+	var binaryPath string
+	switch cfg.format {
+	case "tar.gz", "tar":
+		// Tar archives are read sequentially, so extract straight from the
+		// response body instead of buffering to a temp file.
+		binaryPath, err = streamExtract(body, cfg)
+	default:
+		// Zip needs random access and raw files need a name on disk.
+		binaryPath, err = downloadToTemp(body, cfg)
 	}
-	tmpFile.Close()
-
-	// Process the downloaded file.
-	binaryPath, err := processFile(tmpPath, cfg)
 	if err != nil {
 		return err
 	}
 
+	if clearQuarantineEnabled(ctx) {
+		if err := clearQuarantine(ctx, cmp.Or(cfg.destDir, "."), binaryPath); err != nil {
+			return err
+		}
+	}
+
 	// Create symlink if requested.
 	if cfg.symlink && binaryPath != "" {
 		if _, err := CreateSymlink(binaryPath); err != nil {
@@ -177,6 +216,112 @@ func download(ctx context.Context, url string, opts ...DownloadOpt) error {
 	return nil
 }
 
+// verifyingReader hashes everything read through it and enforces the
+// configured size limit.
+type verifyingReader struct {
+	r       io.Reader
+	hash    hash.Hash
+	n       int64
+	maxSize int64
+	want    string
+}
+
+func newVerifyingReader(r io.Reader, cfg *downloadConfig) *verifyingReader {
+	return &verifyingReader{r: r, hash: sha256.New(), maxSize: cfg.maxSize, want: cfg.checksum}
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.hash.Write(p[:n])
+	v.n += int64(n)
+	if v.maxSize > 0 && v.n > v.maxSize {
+		return n, fmt.Errorf("download exceeds limit of %d bytes", v.maxSize)
+	}
+	return n, err
+}
+
+// verify drains the rest of the stream and checks the checksum.
+// Archive readers stop at the end-of-archive marker, which may be
+// followed by padding that is part of the checksummed file.
+func (v *verifyingReader) verify() error {
+	if _, err := io.Copy(io.Discard, v); err != nil {
+		return fmt.Errorf("download: %w", err)
+	}
+	if v.want == "" {
+		return nil
+	}
+	if got := hex.EncodeToString(v.hash.Sum(nil)); got != v.want {
+		return fmt.Errorf("checksum mismatch: got sha256 %s, want %s", got, v.want)
+	}
+	return nil
+}
+
+// streamExtract extracts a tar or tar.gz stream into a staging directory
+// inside destDir, verifies the stream and then moves the entries into place.
+func streamExtract(body *verifyingReader, cfg *downloadConfig) (string, error) {
+	destDir := cfg.destDir
+	if destDir == "" {
+		destDir = "."
+	}
+	staging, err := os.MkdirTemp(destDir, ".pocket-extract-*")
+	if err != nil {
+		return "", fmt.Errorf("create staging dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	var r io.Reader = body
+	if cfg.format == "tar.gz" {
+		gzr, err := gzip.NewReader(body)
+		if err != nil {
+			return "", fmt.Errorf("create gzip reader: %w", err)
+		}
+		defer gzr.Close()
+		r = gzr
+	}
+	if err := extractTarReader(tar.NewReader(r), staging, newExtractConfig(cfg.extractOpts)); err != nil {
+		return "", fmt.Errorf("extract %s: %w", cfg.format, err)
+	}
+	if err := body.verify(); err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(staging)
+	if err != nil {
+		return "", fmt.Errorf("read staging dir: %w", err)
+	}
+	for _, e := range entries {
+		dst := filepath.Join(destDir, e.Name())
+		if err := os.RemoveAll(dst); err != nil {
+			return "", fmt.Errorf("replace %s: %w", dst, err)
+		}
+		if err := os.Rename(filepath.Join(staging, e.Name()), dst); err != nil {
+			return "", fmt.Errorf("move extracted %s: %w", e.Name(), err)
+		}
+	}
+	return findFirstExtractedFile(destDir, cfg.extractOpts), nil
+}
+
+// downloadToTemp writes the stream to a temp file, verifies it and
+// processes it like a local file.
+func downloadToTemp(body *verifyingReader, cfg *downloadConfig) (string, error) {
+	tmpFile, err := os.CreateTemp("", "pocket-download-*")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := io.Copy(tmpFile, body); err != nil {
+		tmpFile.Close()
+		return "", fmt.Errorf("download: %w", err)
+	}
+	tmpFile.Close()
+	if err := body.verify(); err != nil {
+		return "", err
+	}
+	return processFile(tmpPath, cfg)
+}
+
 // FromLocal creates a Runnable that processes a local file (extract/copy).
 // Useful for processing pre-downloaded or bundled archives.
 func FromLocal(path string, opts ...DownloadOpt) Runnable {
@@ -188,9 +333,10 @@ func FromLocal(path string, opts ...DownloadOpt) Runnable {
 // fromLocal is the internal implementation of FromLocal.
 func fromLocal(ctx context.Context, path string, opts ...DownloadOpt) error {
 	cfg := newDownloadConfig(opts)
+	cfg.extractOpts = append(cfg.extractOpts, WithWarnings(getExecContext(ctx).out.Stderr))
 
 	// Check if we can skip.
-	if cfg.skipIfExists != "" {
+	if cfg.skipIfExists != "" && !Reinstall(ctx) {
 		if _, err := os.Stat(cfg.skipIfExists); err == nil {
 			if cfg.symlink {
 				if _, err := CreateSymlink(cfg.skipIfExists); err != nil {
@@ -216,6 +362,12 @@ func fromLocal(ctx context.Context, path string, opts ...DownloadOpt) error {
 		return err
 	}
 
+	if clearQuarantineEnabled(ctx) {
+		if err := clearQuarantine(ctx, cmp.Or(cfg.destDir, "."), binaryPath); err != nil {
+			return err
+		}
+	}
+
 	// Create symlink if requested.
 	if cfg.symlink && binaryPath != "" {
 		if _, err := CreateSymlink(binaryPath); err != nil {
@@ -271,8 +423,8 @@ func findFirstExtractedFile(destDir string, opts []ExtractOpt) string {
 	cfg := newExtractConfig(opts)
 
 	// If we have rename mappings, use the first destination name.
-	for _, destName := range cfg.renameMap {
-		return filepath.Join(destDir, destName)
+	if len(cfg.renameOrder) > 0 {
+		return filepath.Join(destDir, cfg.renameMap[cfg.renameOrder[0]])
 	}
 
 	// Otherwise, we can't determine which file was extracted.
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for name, body := range files {
+		hdr := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o755, Size: int64(len(body))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownload_StreamedTarGz(t *testing.T) {
+	archive := testTarGz(t, map[string]string{"tool-1.0/tool": "binary"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(archive)
+	}))
+	defer srv.Close()
+
+	sum := sha256.Sum256(archive)
+	valid := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name    string
+		opts    []DownloadOpt
+		wantErr string
+	}{
+		{name: "valid checksum", opts: []DownloadOpt{WithChecksum(valid)}},
+		{name: "checksum mismatch", opts: []DownloadOpt{WithChecksum(strings.Repeat("0", 64))}, wantErr: "checksum mismatch"},
+		{name: "size limit", opts: []DownloadOpt{WithMaxSize(10)}, wantErr: "exceeds limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			opts := append([]DownloadOpt{
+				WithDestDir(dest),
+				WithFormat("tar.gz"),
+				WithExtract(WithRenameFile("tool-1.0/tool", "tool")),
+			}, tt.opts...)
+			err := runWithContext(context.Background(), Download(srv.URL, opts...), discardOutput(), ".", false, nil, nil)
+
+			_, statErr := os.Stat(filepath.Join(dest, "tool"))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if statErr == nil {
+					t.Error("tool was installed despite failed verification")
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if statErr != nil {
+					t.Errorf("tool not installed: %v", statErr)
+				}
+			}
+
+			// The staging directory is always removed.
+			entries, _ := os.ReadDir(dest)
+			for _, e := range entries {
+				if strings.HasPrefix(e.Name(), ".pocket-extract-") {
+					t.Errorf("staging dir %s left behind", e.Name())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// fixEnv overrides whether linters and formatters fix what they find, see
+// Fix. The -fix flag sets it, so nested pocket runs use the same mode.
+const fixEnv = "POK_FIX"
+
+// Fix returns whether a linter or formatter task fixes the issues it finds
+// (writes formatted files, applies lint fixes) rather than only reporting
+// them. fix is the task's own setting, e.g. !opts.SkipFix or !opts.Check.
+//
+// ./pok -fix (or POK_FIX=true) makes every task fix what it can, and
+// ./pok -fix=false (or POK_FIX=false) makes them check only, e.g. in CI.
+// Without either, the task's setting applies.
+//
+// Example:
+//
+//	if pocket.Fix(ctx, !opts.SkipFix) {
+//	    args = append(args, "--fix")
+//	}
+func Fix(_ context.Context, fix bool) bool {
+	if v, err := fixOverride(); err == nil && v != nil {
+		return *v
+	}
+	return fix
+}
+
+// fixOverride returns the fix mode set with -fix or POK_FIX, or nil if
+// there is none.
+func fixOverride() (*bool, error) {
+	s := os.Getenv(fixEnv)
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s=%q (want true or false)", fixEnv, s)
+	}
+	return &v, nil
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFix(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		env  string
+		fix  bool
+		want bool
+	}{
+		{"", true, true},
+		{"", false, false},
+		{"true", false, true},
+		{"1", false, true},
+		{"false", true, false},
+		{"invalid", true, true},
+	}
+	for _, tt := range tests {
+		t.Setenv(fixEnv, tt.env)
+		if got := Fix(ctx, tt.fix); got != tt.want {
+			t.Errorf("Fix(%v) with %s=%q = %v, want %v", tt.fix, fixEnv, tt.env, got, tt.want)
+		}
+	}
+
+	t.Setenv(fixEnv, "maybe")
+	if _, err := fixOverride(); err == nil {
+		t.Errorf("fixOverride() with %s=maybe succeeded", fixEnv)
+	}
+}
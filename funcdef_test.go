@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
@@ -120,3 +122,27 @@ func TestRun_ComposesWithFuncDef(t *testing.T) {
 		t.Errorf("expected name 'test-task', got %q", funcs[0].Name())
 	}
 }
+
+func TestTask_FuncNotCalledInCollectMode(t *testing.T) {
+	executed := false
+	task := Task("test-task", "test", func(_ context.Context) error {
+		executed = true
+		return nil
+	})
+
+	// Collecting the plan must not run the task, like Do.
+	if _, err := NewEngine(task).Plan(context.Background()); err != nil {
+		t.Fatalf("Engine.Plan() failed: %v", err)
+	}
+	if executed {
+		t.Error("task function was executed in collect mode, should be skipped")
+	}
+
+	ec := newExecContext(StdOutput(), ".", false, nil)
+	if err := task.run(withExecContext(context.Background(), ec)); err != nil {
+		t.Fatalf("task.run() = %v, want nil", err)
+	}
+	if !executed {
+		t.Error("task function was not executed in execute mode")
+	}
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GitDiffOptions configures the git-diff check run by the "git-diff" task
+// and at the end of "all".
+type GitDiffOptions struct {
+	// Untracked also fails on untracked (new, not ignored) files.
+	Untracked bool `arg:"untracked" usage:"also fail on untracked files"`
+
+	// Exclude lists path globs to ignore, relative to the git root
+	// (comma-separated on the command line). A trailing "/**" matches
+	// everything below a directory, e.g. "docs/**" or "CHANGELOG.md".
+	Exclude string `arg:"exclude" usage:"comma-separated path globs to ignore"`
+
+	// SkipPatch doesn't print the diff of the changed files on failure.
+	SkipPatch bool `arg:"skip-patch" usage:"don't print the patch of changed files on failure"`
+}
+
+// gitDiffCheck fails if there are uncommitted changes in the working tree.
+// The changed files are listed with their patch, and when running in GitHub
+// Actions each file also gets an error annotation.
+func gitDiffCheck(ctx context.Context, opts GitDiffOptions) error {
+	ec := getExecContext(ctx)
+	if ec.mode == modeCollect {
+		return nil
+	}
+
+	files, err := gitLines(ctx, "diff", "--name-only")
+	if err != nil {
+		return err
+	}
+	if opts.Untracked {
+		untracked, err := gitLines(ctx, "ls-files", "--others", "--exclude-standard")
+		if err != nil {
+			return err
+		}
+		files = append(files, untracked...)
+	}
+	files = excludePaths(files, splitList(opts.Exclude))
+	if len(files) == 0 {
+		return nil
+	}
+
+	Printf(ctx, "Uncommitted changes:\n")
+	for _, f := range files {
+		Printf(ctx, "  %s\n", f)
+	}
+	for _, f := range files {
+		AnnotateError(ctx, f, 0, "Uncommitted changes",
+			f+" changed after running pocket; run ./pok locally and commit the result")
+	}
+	if !opts.SkipPatch {
+		// Show the patch; the exit code is irrelevant here.
+		_ = Exec(ctx, "git", append([]string{"--no-pager", "diff", "--no-ext-diff", "--"}, files...)...)
+	}
+	return fmt.Errorf("uncommitted changes detected in %d file(s); please commit or stage your changes", len(files))
+}
+
+// AnnotateError prints a GitHub Actions error annotation for file, which is
+// relative to the git root, when running in GitHub Actions. A line of 0
+// annotates the file as a whole.
+func AnnotateError(ctx context.Context, file string, line int, title, msg string) {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return
+	}
+	props := "file=" + escapeProperty(file)
+	if line > 0 {
+		props += ",line=" + strconv.Itoa(line)
+	}
+	Printf(ctx, "::error %s,title=%s::%s\n", props, escapeProperty(title), escapeData(msg))
+}
+
+// escapeData escapes the message of a GitHub Actions workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a GitHub Actions workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// gitLines runs a git command from the git root and returns its non-empty output lines.
+func gitLines(ctx context.Context, args ...string) ([]string, error) {
+	var stdout bytes.Buffer
+	cmd := newCommand(ctx, "git", args...)
+	cmd.Dir = GitRoot()
+	cmd.Stdout = &stdout
+	ec := getExecContext(ctx)
+	cmd.Stderr = ec.out.Stderr
+	if err := runCommand(ec, cmd); err != nil {
+		return nil, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return splitLines(stdout.String()), nil
+}
+
+// excludePaths removes paths matching any of the globs.
+func excludePaths(paths, globs []string) []string {
+	if len(globs) == 0 {
+		return paths
+	}
+	result := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if !matchesAnyGlob(p, globs) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// matchesAnyGlob reports whether p matches any of the globs, see MatchGlob.
+func matchesAnyGlob(p string, globs []string) bool {
+	return slices.ContainsFunc(globs, func(g string) bool { return MatchGlob(g, p) })
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// splitLines splits output into trimmed, non-empty lines.
+func splitLines(s string) []string {
+	var result []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExcludePaths(t *testing.T) {
+	files := []string{"CHANGELOG.md", "docs/api/index.md", "docs.go", "main.go", "sub/CHANGELOG.md"}
+
+	got := excludePaths(files, splitList("CHANGELOG.md, docs/**"))
+	want := []string{"docs.go", "main.go", "sub/CHANGELOG.md"}
+	if !slices.Equal(got, want) {
+		t.Errorf("excludePaths() = %v, want %v", got, want)
+	}
+
+	got = excludePaths(files, splitList("*/CHANGELOG.md"))
+	if slices.Contains(got, "sub/CHANGELOG.md") || !slices.Contains(got, "CHANGELOG.md") {
+		t.Errorf("expected only sub/CHANGELOG.md to be excluded, got %v", got)
+	}
+
+	if got := excludePaths(files, nil); !slices.Equal(got, files) {
+		t.Errorf("expected no exclusions, got %v", got)
+	}
+}
+
+func TestEscapeWorkflowCommand(t *testing.T) {
+	tests := []struct {
+		in       string
+		data     string
+		property string
+	}{
+		{"plain.go", "plain.go", "plain.go"},
+		{"100%\r\nnext", "100%25%0D%0Anext", "100%25%0D%0Anext"},
+		{"a:b,c", "a:b,c", "a%3Ab%2Cc"},
+	}
+	for _, tt := range tests {
+		if got := escapeData(tt.in); got != tt.data {
+			t.Errorf("escapeData(%q) = %q, want %q", tt.in, got, tt.data)
+		}
+		if got := escapeProperty(tt.in); got != tt.property {
+			t.Errorf("escapeProperty(%q) = %q, want %q", tt.in, got, tt.property)
+		}
+	}
+}
+
+func TestAnnotateError(t *testing.T) {
+	var stdout strings.Builder
+	ctx := TestContext(&Output{Stdout: &stdout, Stderr: io.Discard})
+
+	t.Setenv("GITHUB_ACTIONS", "")
+	AnnotateError(ctx, "a.yaml", 3, "Title", "msg")
+	if stdout.Len() != 0 {
+		t.Fatalf("expected no annotation outside GitHub Actions, got %q", stdout.String())
+	}
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	AnnotateError(ctx, "deploy/a.yaml", 3, "Schema validation", "bad: value")
+	AnnotateError(ctx, "b.json", 0, "Title", "whole file")
+	want := "::error file=deploy/a.yaml,line=3,title=Schema validation::bad: value\n" +
+		"::error file=b.json,title=Title::whole file\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("annotations = %q, want %q", got, want)
+	}
+}
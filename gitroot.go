@@ -1,10 +1,12 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
-	"os"
 	"path/filepath"
 	"runtime"
-	"sync"
+
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
 )
 
 const (
@@ -16,38 +18,13 @@ const (
 	BinDirName = "bin"
 )
 
-var (
-	gitRootOnce sync.Once
-	gitRoot     string
-)
-
 // GitRoot returns the root directory of the git repository.
 func GitRoot() string {
-	gitRootOnce.Do(func() {
-		var err error
-		gitRoot, err = findGitRoot()
-		if err != nil {
-			panic("pocket: unable to find git root: " + err.Error())
-		}
-	})
-	return gitRoot
-}
-
-func findGitRoot() (string, error) {
-	dir, err := os.Getwd()
+	dir, err := gitroot.Dir()
 	if err != nil {
-		return "", err
-	}
-	for {
-		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
-			return dir, nil
-		}
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			return "", os.ErrNotExist
-		}
-		dir = parent
+		panic("pocket: unable to find git root: " + err.Error())
 	}
+	return dir
 }
 
 // FromGitRoot returns a path relative to the git root.
@@ -3,8 +3,10 @@ module github.com/fredrikaverpil/pocket
 go 1.25.5
 
 require (
+	github.com/vektah/gqlparser/v2 v2.5.58
 	golang.org/x/sync v0.19.0
+	golang.org/x/sys v0.40.0
 	golang.org/x/term v0.39.0
 )
 
-require golang.org/x/sys v0.40.0 // indirect
+require github.com/agnivade/levenshtein v1.2.1 // indirect
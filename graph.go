@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Graph formats for -graph.
+const (
+	graphDOT     = "dot"
+	graphMermaid = "mermaid"
+)
+
+// graphNode is a task or a Serial/Parallel group in a task graph.
+type graphNode struct {
+	id     string
+	label  string
+	group  bool // Serial or Parallel
+	hidden bool // hidden task, e.g. a tool installer
+}
+
+// graphEdge connects a node to a step it runs. Children of a Serial group
+// are labeled with their position.
+type graphEdge struct {
+	from, to string
+	label    string
+}
+
+// taskGraph is the Runnable tree of a run, as rendered by -graph. A task
+// reached from several places, such as a shared tool installer, is a single
+// node with several incoming edges.
+type taskGraph struct {
+	nodes []graphNode
+	edges []graphEdge
+	tasks map[string]string // task label -> node id
+	seen  map[graphEdge]bool
+}
+
+// newTaskGraph builds the graph of steps. paths returns the paths a task
+// step runs in, shown in its node.
+func newTaskGraph(steps []*PlanStep, paths func(*PlanStep) []string) *taskGraph {
+	g := &taskGraph{tasks: make(map[string]string), seen: make(map[graphEdge]bool)}
+	g.addSteps("", steps, false, paths)
+	return g
+}
+
+// addSteps adds steps as children of the parent node, or as roots if
+// parent is "".
+func (g *taskGraph) addSteps(parent string, steps []*PlanStep, serial bool, paths func(*PlanStep) []string) {
+	for i, step := range steps {
+		label := ""
+		if serial {
+			label = strconv.Itoa(i + 1)
+		}
+		switch step.Type {
+		case "serial", "parallel":
+			kind := "Serial"
+			if step.Type == "parallel" {
+				kind = "Parallel"
+			}
+			id := g.addNode(graphNode{label: kind, group: true})
+			g.addEdge(parent, id, label)
+			g.addSteps(id, step.Children, step.Type == "serial", paths)
+		case "func":
+			name := step.Name
+			if p := paths(step); len(p) > 1 || (len(p) == 1 && p[0] != ".") {
+				name += "\n[" + strings.Join(p, ", ") + "]"
+			}
+			id, added := g.addTask(name, step.Hidden)
+			g.addEdge(parent, id, label)
+			if added {
+				g.addSteps(id, step.Children, false, paths)
+			}
+		}
+	}
+}
+
+// addEdge adds an edge unless it exists or from is "".
+func (g *taskGraph) addEdge(from, to, label string) {
+	e := graphEdge{from: from, to: to, label: label}
+	if from == "" || g.seen[e] {
+		return
+	}
+	g.seen[e] = true
+	g.edges = append(g.edges, e)
+}
+
+// addTask returns the node of the task with the given label, adding it if
+// it is new.
+func (g *taskGraph) addTask(label string, hidden bool) (id string, added bool) {
+	if id, ok := g.tasks[label]; ok {
+		return id, false
+	}
+	id = g.addNode(graphNode{label: label, hidden: hidden})
+	g.tasks[label] = id
+	return id, true
+}
+
+// addNode adds a node with a new id and returns the id.
+func (g *taskGraph) addNode(n graphNode) string {
+	n.id = "n" + strconv.Itoa(len(g.nodes))
+	g.nodes = append(g.nodes, n)
+	return n.id
+}
+
+// write renders the graph in the given format, graphDOT or graphMermaid.
+func (g *taskGraph) write(w io.Writer, format string) {
+	switch format {
+	case graphDOT:
+		g.writeDOT(w)
+	case graphMermaid:
+		g.writeMermaid(w)
+	}
+}
+
+// writeDOT renders the graph in the Graphviz DOT language.
+func (g *taskGraph) writeDOT(w io.Writer) {
+	fmt.Fprintln(w, "digraph pocket {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, `  node [shape=box, style=rounded, fontname="sans-serif"];`)
+	for _, n := range g.nodes {
+		attrs := "label=" + dotQuote(n.label)
+		switch {
+		case n.group:
+			attrs += ", shape=ellipse, style=dashed"
+		case n.hidden:
+			attrs += `, style="rounded,dashed", fontcolor=gray40`
+		}
+		fmt.Fprintf(w, "  %s [%s];\n", n.id, attrs)
+	}
+	for _, e := range g.edges {
+		if e.label != "" {
+			fmt.Fprintf(w, "  %s -> %s [label=%s];\n", e.from, e.to, dotQuote(e.label))
+		} else {
+			fmt.Fprintf(w, "  %s -> %s;\n", e.from, e.to)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// writeMermaid renders the graph as a Mermaid flowchart.
+func (g *taskGraph) writeMermaid(w io.Writer) {
+	fmt.Fprintln(w, "flowchart LR")
+	for _, n := range g.nodes {
+		label := mermaidLabel(n.label)
+		switch {
+		case n.group:
+			fmt.Fprintf(w, "  %s([%s])\n", n.id, label)
+		case n.hidden:
+			fmt.Fprintf(w, "  %s[%s]:::hidden\n", n.id, label)
+		default:
+			fmt.Fprintf(w, "  %s[%s]\n", n.id, label)
+		}
+	}
+	for _, e := range g.edges {
+		if e.label != "" {
+			fmt.Fprintf(w, "  %s -->|%s| %s\n", e.from, e.label, e.to)
+		} else {
+			fmt.Fprintf(w, "  %s --> %s\n", e.from, e.to)
+		}
+	}
+	fmt.Fprintln(w, "  classDef hidden stroke-dasharray: 5 5,color:#777")
+}
+
+// dotQuote quotes a string for DOT, with newlines as line breaks.
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// mermaidLabel quotes a node label for Mermaid.
+func mermaidLabel(s string) string {
+	s = strings.NewReplacer(`"`, "#quot;", "\n", "<br>").Replace(s)
+	return `"` + s + `"`
+}
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// graphSteps returns the plan steps of a tree with a tool installer shared
+// by two tasks.
+func graphSteps(t *testing.T) []*PlanStep {
+	t.Helper()
+	noop := func(_ context.Context) error { return nil }
+	install := Task("install:tool", "install tool", noop, AsHidden())
+	lint := Task("lint", "lint", Serial(install, noop))
+	format := Task("format", "format", Serial(install, noop))
+	test := Task("test", "test", noop)
+
+	plan, err := NewEngine(Serial(format, Parallel(lint, test))).Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() failed: %v", err)
+	}
+	return plan.Steps()
+}
+
+func TestTaskGraph_Mermaid(t *testing.T) {
+	paths := func(step *PlanStep) []string {
+		if step.Name == "test" {
+			return []string{"a", "b"}
+		}
+		return []string{"."}
+	}
+	var out strings.Builder
+	newTaskGraph(graphSteps(t), paths).write(&out, graphMermaid)
+
+	want := `flowchart LR
+  n0(["Serial"])
+  n1["format"]
+  n2(["Serial"])
+  n3["install:tool"]:::hidden
+  n4(["Parallel"])
+  n5["lint"]
+  n6(["Serial"])
+  n7["test<br>[a, b]"]
+  n0 -->|1| n1
+  n1 --> n2
+  n2 -->|1| n3
+  n0 -->|2| n4
+  n4 --> n5
+  n5 --> n6
+  n6 -->|1| n3
+  n4 --> n7
+  classDef hidden stroke-dasharray: 5 5,color:#777
+`
+	if got := out.String(); got != want {
+		t.Errorf("mermaid graph:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTaskGraph_DOT(t *testing.T) {
+	paths := func(*PlanStep) []string { return nil }
+	var out strings.Builder
+	newTaskGraph(graphSteps(t), paths).write(&out, graphDOT)
+
+	got := out.String()
+	for _, want := range []string{
+		"digraph pocket {\n",
+		`  n0 [label="Serial", shape=ellipse, style=dashed];`,
+		`  n3 [label="install:tool", style="rounded,dashed", fontcolor=gray40];`,
+		`  n0 -> n1 [label="1"];`,
+		`  n6 -> n3 [label="1"];`,
+		`  n4 -> n7;`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("DOT graph does not contain %q:\n%s", want, got)
+		}
+	}
+	// The shared installer is a single node.
+	if n := strings.Count(got, `label="install:tool"`); n != 1 {
+		t.Errorf("installer node defined %d times, want 1", n)
+	}
+}
+
+func TestGraphQuoting(t *testing.T) {
+	if got, want := dotQuote("a \"b\"\n[c\\d]"), `"a \"b\"\n[c\\d]"`; got != want {
+		t.Errorf("dotQuote() = %s, want %s", got, want)
+	}
+	if got, want := mermaidLabel("a \"b\"\n[c]"), `"a #quot;b#quot;<br>[c]"`; got != want {
+		t.Errorf("mermaidLabel() = %s, want %s", got, want)
+	}
+}
@@ -1,8 +1,12 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"context"
+	"os"
 	"reflect"
+	"strconv"
 	"sync"
 
 	"golang.org/x/sync/errgroup"
@@ -72,6 +76,8 @@ type parallel struct {
 //   - Compose in Config: Parallel(task1, task2)
 //
 // Items can be *TaskDef, Runnable, or func(context.Context) error.
+// Config.MaxParallel and the -j flag bound how many items run at once,
+// across all Parallel groups of a run.
 //
 // Example:
 //
@@ -117,26 +123,71 @@ func (p *parallel) run(ctx context.Context) error {
 		return toRun[0].run(ctx)
 	}
 
-	buffers := make([]*bufferedOutput, len(toRun))
-	for i := range buffers {
-		buffers[i] = newBufferedOutput(ec.out)
+	// Buffer the output of each item, or stream it line by line. Nested
+	// groups stream to the output of the outermost group.
+	prefixer := ec.prefixer
+	if prefixer == nil && parallelOutput(ec.configPlan) == ParallelOutputPrefixed {
+		prefixer = &linePrefixer{out: ec.out}
+	}
+	var buffers []*bufferedOutput
+	if prefixer == nil {
+		buffers = make([]*bufferedOutput, len(toRun))
+		for i := range buffers {
+			buffers[i] = newBufferedOutput(ec.out)
+		}
 	}
 
 	var flushMu sync.Mutex
 
+	// With a parallelism limit, each item needs a slot to run. The goroutine
+	// running this group holds a slot, which its items take turns to use,
+	// while the others wait for a shared slot. Nested groups thus never wait
+	// for the slots of their waiting parents.
+	inherited := make(chan struct{}, 1)
+	inherited <- struct{}{}
+	acquire := func(ctx context.Context) (release func(), err error) {
+		if ec.slots == nil {
+			return func() {}, nil
+		}
+		select {
+		case <-inherited:
+			return func() { inherited <- struct{}{} }, nil
+		case ec.slots <- struct{}{}:
+			return func() { <-ec.slots }, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
 	g, gCtx := errgroup.WithContext(ctx)
 	for i, r := range toRun {
 		g.Go(func() error {
-			newEC := *ec
-			newEC.out = buffers[i].Output()
-			newCtx := withExecContext(gCtx, &newEC)
-			err := r.run(newCtx)
-
-			flushMu.Lock()
-			buffers[i].Flush()
-			flushMu.Unlock()
+			release, err := acquire(gCtx)
+			if err != nil {
+				return err
+			}
+			defer release()
 
-			return err
+			newEC := *ec
+			if i > 0 && ec.runLog != nil {
+				// The first item continues the lane of the group.
+				newEC.lane = ec.runLog.acquireLane()
+				defer ec.runLog.releaseLane(newEC.lane)
+			}
+			if prefixer != nil {
+				var flush func()
+				newEC.out, flush = prefixer.output("")
+				newEC.prefixer = prefixer
+				defer flush()
+			} else {
+				newEC.out = buffers[i].Output()
+				defer func() {
+					flushMu.Lock()
+					buffers[i].Flush()
+					flushMu.Unlock()
+				}()
+			}
+			return r.run(withExecContext(gCtx, &newEC))
 		})
 	}
 
@@ -151,12 +202,25 @@ func (p *parallel) run(ctx context.Context) error {
 // always run because they represent the actual work that their parent task performs.
 // Without this, Clone(task, Opts(...)) variants would incorrectly skip work because
 // they share the same inner runnable pointers.
+//
+// A task runs once per path (its InDir directory, or the current path).
+// Hidden tasks, such as tool installers, run once per invocation.
 func shouldRun(ec *execContext, r Runnable) bool {
 	// Only deduplicate TaskDef - inner runnables always run
-	if _, ok := r.(*TaskDef); !ok {
+	td, ok := r.(*TaskDef)
+	if !ok {
 		return true
 	}
-	key := runnableKey(r)
+	key := dedupKey{ptr: runnableKey(r)}
+	switch {
+	case td.hidden:
+	case td.dir != "":
+		key.path = td.dir
+	case ec.path != "":
+		key.path = ec.path
+	default:
+		key.path = "."
+	}
 	return ec.dedup.shouldRun(key)
 }
 
@@ -166,8 +230,67 @@ func runnableKey(r Runnable) uintptr {
 }
 
 // runWithContext executes a Runnable with fresh execution context.
-func runWithContext(ctx context.Context, r Runnable, out *Output, cwd string, verbose bool, configPlan *ConfigPlan) error {
+func runWithContext(
+	ctx context.Context,
+	r Runnable,
+	out *Output,
+	cwd string,
+	verbose bool,
+	configPlan *ConfigPlan,
+	rl *runLog,
+) error {
 	ec := newExecContext(out, cwd, verbose, configPlan)
+	ec.runLog = rl
+	ec.slots = newParallelSlots(maxParallel(configPlan))
 	ctx = withExecContext(ctx, ec)
 	return r.run(ctx)
 }
+
+// ParallelOutput controls how the output of tasks run by Parallel is shown,
+// see Config.ParallelOutput.
+type ParallelOutput string
+
+const (
+	// ParallelOutputBuffered prints the output of each item of a Parallel
+	// group when the item completes, so the output of tasks doesn't mix.
+	ParallelOutputBuffered ParallelOutput = "buffered"
+	// ParallelOutputPrefixed streams the output of the items of a Parallel
+	// group as it is written, one whole line at a time, each line prefixed
+	// with the name of the task that wrote it.
+	ParallelOutputPrefixed ParallelOutput = "prefixed"
+)
+
+// parallelOutput returns how Parallel shows output in a run of plan.
+func parallelOutput(plan *ConfigPlan) ParallelOutput {
+	if plan != nil && plan.Config != nil && plan.Config.ParallelOutput != "" {
+		return plan.Config.ParallelOutput
+	}
+	return ParallelOutputBuffered
+}
+
+// jobsEnv bounds how many items Parallel runs at once, overriding
+// Config.MaxParallel. The -j flag sets it, so nested pocket runs inherit the
+// limit.
+const jobsEnv = "POK_JOBS"
+
+// maxParallel returns how many items Parallel runs at once in a run of
+// plan: POK_JOBS if set, else Config.MaxParallel. Zero means no limit.
+func maxParallel(plan *ConfigPlan) int {
+	if n, err := strconv.Atoi(os.Getenv(jobsEnv)); err == nil && n > 0 {
+		return n
+	}
+	if plan != nil && plan.Config != nil {
+		return max(plan.Config.MaxParallel, 0)
+	}
+	return 0
+}
+
+// newParallelSlots returns the slots shared by the Parallel groups of a run
+// limited to n items at once, or nil for no limit. The goroutine running
+// the tree holds one slot, so the channel holds the other n-1.
+func newParallelSlots(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n-1)
+}
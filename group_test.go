@@ -1,8 +1,18 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestSerial_Composition(t *testing.T) {
@@ -208,3 +218,211 @@ func TestParallel_ClonedTasksWithDifferentOptions(t *testing.T) {
 		}
 	}
 }
+
+func TestParallel_RunInteractive(t *testing.T) {
+	var mu sync.Mutex
+	attached := make(map[string]bool)
+	ctx := NewRunContext(context.Background(), RunContextOptions{
+		Output: &Output{Stdout: io.Discard, Stderr: io.Discard},
+		Runner: func(cmd *exec.Cmd) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attached[cmd.Args[0]] = cmd.Stdin == os.Stdin && cmd.Stdout == os.Stdout && cmd.Stderr == os.Stderr
+			return nil
+		},
+	})
+	r := Parallel(Run("lint"), RunInteractive("repl"))
+	if err := r.run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !attached["repl"] {
+		t.Error("expected the interactive command to be attached to the terminal")
+	}
+	if attached["lint"] {
+		t.Error("expected the regular command to write to the buffered task output")
+	}
+}
+
+func TestParallel_ToolInstalledOnce(t *testing.T) {
+	var mu sync.Mutex
+	installs := 0
+	installed := false
+	install := func(ctx context.Context) error {
+		Printf(ctx, "  Downloading tool\n")
+		mu.Lock()
+		installs++
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		installed = true
+		mu.Unlock()
+		return nil
+	}
+	// Different installer tasks for the same tool version, e.g. from two
+	// task packages.
+	installA := Task("install:tool", "install tool", install, AsHidden(), AsTool("tool", "v1.0.0"))
+	installB := Task("install:tool", "install tool", install, AsHidden(), AsTool("tool", "v1.0.0"))
+	use := func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !installed {
+			t.Error("task ran before the tool was installed")
+		}
+		Printf(ctx, "  using tool\n")
+		return nil
+	}
+	lint := Task("lint", "lint", Serial(installA, use))
+	format := Task("format", "format", Serial(installB, use))
+
+	var stdout strings.Builder
+	out := &Output{Stdout: &lockedWriter{mu: &sync.Mutex{}, w: &stdout}, Stderr: io.Discard}
+	if err := runWithContext(context.Background(), Parallel(lint, format), out, ".", false, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if installs != 1 {
+		t.Errorf("tool installed %d times, want once", installs)
+	}
+	if got := strings.Count(stdout.String(), "Downloading tool"); got != 1 {
+		t.Errorf("install output printed %d times, want once:\n%s", got, stdout.String())
+	}
+}
+
+func TestParallel_ToolInstallError(t *testing.T) {
+	failing := func(_ context.Context) error { return errors.New("download failed") }
+	installA := Task("install:tool", "install tool", failing, AsHidden(), AsTool("tool", "v1.0.0"))
+	installB := Task("install:tool", "install tool", failing, AsHidden(), AsTool("tool", "v1.0.0"))
+	ctx := withExecContext(context.Background(), newExecContext(discardOutput(), ".", false, nil))
+
+	// Tasks waiting for a failed install get its error.
+	for _, install := range []*TaskDef{installA, installB} {
+		var installErr *InstallError
+		if err := install.run(ctx); !errors.As(err, &installErr) || installErr.Tool != "tool" {
+			t.Errorf("%s: err = %v, want an InstallError for tool", install.name, err)
+		}
+	}
+}
+
+func TestParallel_MaxParallel(t *testing.T) {
+	for _, limit := range []int{1, 2, 3} {
+		var mu sync.Mutex
+		running, peak := 0, 0
+		task := func(name string) *TaskDef {
+			return Task(name, name, func(_ context.Context) error {
+				mu.Lock()
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+		}
+		// Nested groups share the limit and must not deadlock on it.
+		tree := Parallel(
+			task("a"),
+			Parallel(task("b"), task("c"), Serial(task("d"), Parallel(task("e"), task("f")))),
+			task("g"),
+			task("h"),
+		)
+		plan := BuildConfigPlan(Config{MaxParallel: limit})
+		done := make(chan error, 1)
+		go func() { done <- runWithContext(context.Background(), tree, discardOutput(), ".", false, plan, nil) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("limit %d: %v", limit, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("limit %d: run deadlocked", limit)
+		}
+		if peak > limit || peak == 0 {
+			t.Errorf("limit %d: ran %d tasks at once", limit, peak)
+		}
+		if limit > 1 && peak < 2 {
+			t.Errorf("limit %d: expected tasks to run in parallel, peak %d", limit, peak)
+		}
+	}
+}
+
+func TestParallel_PrefixedOutput(t *testing.T) {
+	// Each task writes a partial line and waits for the other before
+	// completing it, so the output interleaves.
+	turn := make(chan struct{})
+	task := func(name string, first bool) *TaskDef {
+		return Task(name, name, func(ctx context.Context) error {
+			if !first {
+				<-turn
+			}
+			Printf(ctx, "%s: start", name)
+			if first {
+				turn <- struct{}{}
+				<-turn
+			} else {
+				turn <- struct{}{}
+			}
+			Printf(ctx, " done\n%s: no newline", name)
+			return nil
+		})
+	}
+	tree := Parallel(task("go-test", true), Serial(task("py-lint", false)))
+
+	var out strings.Builder
+	plan := BuildConfigPlan(Config{ParallelOutput: ParallelOutputPrefixed})
+	output := &Output{Stdout: &out, Stderr: &out}
+	if err := runWithContext(context.Background(), tree, output, ".", false, plan, nil); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	slices.Sort(lines)
+	want := []string{
+		":: go-test",
+		":: py-lint",
+		"[go-test] go-test: no newline",
+		"[go-test] go-test: start done",
+		"[py-lint] py-lint: no newline",
+		"[py-lint] py-lint: start done",
+	}
+	if !slices.Equal(lines, want) {
+		t.Errorf("output lines =\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMaxParallel(t *testing.T) {
+	plan := BuildConfigPlan(Config{MaxParallel: 4})
+	t.Setenv(jobsEnv, "")
+	if got := maxParallel(plan); got != 4 {
+		t.Errorf("maxParallel() = %d, want Config.MaxParallel 4", got)
+	}
+	if got := maxParallel(nil); got != 0 {
+		t.Errorf("maxParallel(nil) = %d, want 0", got)
+	}
+	t.Setenv(jobsEnv, "2")
+	if got := maxParallel(plan); got != 2 {
+		t.Errorf("maxParallel() = %d, want POK_JOBS 2", got)
+	}
+}
+
+func TestShouldRun_OncePerPath(t *testing.T) {
+	var ran []string
+	lint := Task("lint", "lint", func(ctx context.Context) error {
+		ran = append(ran, Path(ctx))
+		return nil
+	})
+	// lint is reached twice in each path, and runs once in each.
+	root := Serial(RunIn(Serial(lint, lint), Include("proj1", "proj2")), lint, lint)
+	if err := runWithContext(context.Background(), root, discardOutput(), ".", false, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"proj1", "proj2", "."}
+	if len(ran) != len(want) {
+		t.Fatalf("ran in %v, want %v", ran, want)
+	}
+	for i := range want {
+		if ran[i] != want[i] {
+			t.Errorf("ran in %v, want %v", ran, want)
+			break
+		}
+	}
+}
@@ -1,7 +1,10 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -26,6 +29,10 @@ func InstallGo(pkg, version string) Runnable {
 
 // installGo is the internal implementation of InstallGo.
 func installGo(ctx context.Context, pkg, version string) error {
+	if ec := getExecContext(ctx); ec.installer != nil {
+		return ec.installer(ctx, pkg+"@"+version)
+	}
+
 	// Determine binary name from package path.
 	binaryName := goBinaryName(pkg)
 	if runtime.GOOS == Windows {
@@ -37,7 +44,7 @@ func installGo(ctx context.Context, pkg, version string) error {
 	binaryPath := filepath.Join(toolDir, binaryName)
 
 	// Check if already installed.
-	if _, err := os.Stat(binaryPath); err == nil {
+	if _, err := os.Stat(binaryPath); err == nil && !Reinstall(ctx) {
 		// Already installed, ensure symlink exists.
 		if _, err := CreateSymlink(binaryPath); err != nil {
 			return err
@@ -60,7 +67,7 @@ func installGo(ctx context.Context, pkg, version string) error {
 	cmd.Stderr = ec.out.Stderr
 	cmd.Env = append(cmd.Environ(), "GOBIN="+toolDir)
 
-	if err := cmd.Run(); err != nil {
+	if err := runCommand(ec, cmd); err != nil {
 		return fmt.Errorf("go install %s: %w", pkgWithVersion, err)
 	}
 
@@ -72,6 +79,47 @@ func installGo(ctx context.Context, pkg, version string) error {
 	return nil
 }
 
+// installRun is the install of a tool by the first task installing it.
+type installRun struct {
+	done chan struct{} // closed when the install is done
+	err  error
+}
+
+// runInstaller runs the body of the tool installer f and reports whether it
+// ran. A tool version is installed once per invocation, even by different
+// installer tasks: when several tasks need it concurrently, the first one
+// installs it and the others wait for it, return its error and print
+// nothing. The installer's output is buffered and written as one block, like
+// the output of a task in Parallel, so it isn't interleaved with the output
+// of the tasks running alongside it.
+func runInstaller(ctx context.Context, f *TaskDef) (ran bool, err error) {
+	ec := getExecContext(ctx)
+	run, first := ec.dedup.startInstall(f.tool.Name + "@" + f.tool.Version)
+	if !first {
+		select {
+		case <-run.done:
+			return false, run.err
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	defer close(run.done)
+	ec.dedup.recordTool(f.tool.Name, f.tool.Version)
+
+	buf := newBufferedOutput(ec.out)
+	newEC := *ec
+	newEC.out = buf.Output()
+	newEC.env = nil // tools are shared between paths, see Env
+	err = f.runBody(withExecContext(ctx, &newEC))
+	buf.Flush()
+	var installErr *InstallError
+	if err != nil && !errors.As(err, &installErr) {
+		err = &InstallError{Tool: f.tool.Name, Err: err}
+	}
+	run.err = err
+	return true, err
+}
+
 // ToolConfig describes how to find or create a tool's configuration file.
 type ToolConfig struct {
 	// UserFiles are filenames to search for in the repo root.
@@ -92,8 +140,11 @@ type ToolConfig struct {
 //   - Absolute paths are checked as-is (use FromGitRoot() for repo-root configs)
 //   - Relative paths are checked in the task's current directory (from Path(ctx))
 //
-// If no user config is found, writes DefaultData to .pocket/tools/<name>/<DefaultFile>.
-// Returns empty string and no error if cfg is empty.
+// If no user config is found, returns the tool's default config from
+// Config.DefaultConfigs if set (see ConfigSource), fetching it to
+// .pocket/tools/<name>/remote/. Otherwise writes DefaultData to
+// .pocket/tools/<name>/<DefaultFile>. Returns empty string and no error if
+// there is no default config.
 //
 // Example:
 //
@@ -111,10 +162,6 @@ type ToolConfig struct {
 //	    return pocket.Exec(ctx, "golangci-lint", "run", "-c", configPath)
 //	}
 func ConfigPath(ctx context.Context, toolName string, cfg ToolConfig) (string, error) {
-	if len(cfg.UserFiles) == 0 && cfg.DefaultFile == "" {
-		return "", nil
-	}
-
 	// Check for user config files.
 	// Absolute paths are used as-is, relative paths are resolved from task CWD.
 	cwd := FromGitRoot(Path(ctx))
@@ -130,6 +177,11 @@ func ConfigPath(ctx context.Context, toolName string, cfg ToolConfig) (string, e
 		}
 	}
 
+	// The organization's default config replaces the bundled one.
+	if source, ok := defaultConfigSource(ctx, toolName); ok {
+		return remoteConfigPath(ctx, toolName, source)
+	}
+
 	// No default config provided, return empty.
 	if cfg.DefaultFile == "" || len(cfg.DefaultData) == 0 {
 		return "", nil
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// internalOptions configures the internal builtin task.
+type internalOptions struct {
+	Reinstall bool `arg:"reinstall" usage:"install tools again even if they are installed"`
+}
+
+// runInternal runs the hidden task named by the first argument, one of the
+// tasks exposed with Config.Internal, or lists them without arguments.
+func runInternal(ctx context.Context) error {
+	opts := Options[internalOptions](ctx)
+	var tasks []*TaskDef
+	if plan := GetConfigPlan(ctx); plan != nil && plan.Config != nil {
+		tasks = plan.Config.Internal
+	}
+	args := positionalArgs(ctx)
+	if len(args) == 0 {
+		if len(tasks) == 0 {
+			Println(ctx, "No internal tasks; expose hidden tasks with Config.Internal.")
+			return nil
+		}
+		w := tabwriter.NewWriter(GetOutput(ctx).Stdout, 0, 0, 2, ' ', 0)
+		sorted := slices.SortedFunc(slices.Values(tasks), func(a, b *TaskDef) int {
+			return strings.Compare(a.name, b.name)
+		})
+		for _, f := range sorted {
+			fmt.Fprintf(w, "  %s\t%s\n", f.name, f.usage)
+		}
+		return w.Flush()
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("internal: want one task, got %s", strings.Join(args, " "))
+	}
+	i := slices.IndexFunc(tasks, func(f *TaskDef) bool { return f.name == args[0] })
+	if i < 0 {
+		return fmt.Errorf("internal: unknown task %q (expose it with Config.Internal)", args[0])
+	}
+	if opts.Reinstall {
+		ec := *getExecContext(ctx)
+		ec.reinstall = true
+		ctx = withExecContext(ctx, &ec)
+	}
+	return tasks[i].run(ctx)
+}
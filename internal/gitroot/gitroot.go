@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: MIT
+
+// Package gitroot holds the git root pocket resolves paths against.
+// It lives outside package pocket so pockettest can redirect it.
+package gitroot
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	mu       sync.Mutex
+	dir      string
+	err      error
+	resolved bool
+)
+
+// Dir returns the nearest parent of the working directory that contains
+// .git. It is looked up once per process.
+func Dir() (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !resolved {
+		dir, err = find()
+		resolved = true
+	}
+	return dir, err
+}
+
+// Override makes Dir return d until restore is called.
+func Override(d string) (restore func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	prevDir, prevErr, prevResolved := dir, err, resolved
+	dir, err, resolved = d, nil, true
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		dir, err, resolved = prevDir, prevErr, prevResolved
+	}
+}
+
+func find() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(wd, ".git")); err == nil {
+			return wd, nil
+		}
+		parent := filepath.Dir(wd)
+		if parent == wd {
+			return "", os.ErrNotExist
+		}
+		wd = parent
+	}
+}
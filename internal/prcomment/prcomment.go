@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: MIT
+
+// Package prcomment posts pull request comments from GitHub Actions runs.
+// It lives outside package pocket so both the run reporter and
+// tasks/github can use it.
+package prcomment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PullRequest identifies the pull request of the current GitHub Actions run.
+type PullRequest struct {
+	apiURL string
+	repo   string
+	token  string
+	number int
+}
+
+// Current returns the pull request that triggered the current
+// GitHub Actions run. It reports false outside of GitHub Actions, for other
+// events, or without GITHUB_TOKEN.
+func Current(getenv func(string) string) (PullRequest, bool) {
+	if getenv("GITHUB_ACTIONS") != "true" || getenv("GITHUB_TOKEN") == "" || getenv("GITHUB_REPOSITORY") == "" {
+		return PullRequest{}, false
+	}
+	data, err := os.ReadFile(getenv("GITHUB_EVENT_PATH"))
+	if err != nil {
+		return PullRequest{}, false
+	}
+	var event struct {
+		PullRequest struct {
+			Number int `json:"number"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil || event.PullRequest.Number == 0 {
+		return PullRequest{}, false
+	}
+	apiURL := getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	return PullRequest{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		repo:   getenv("GITHUB_REPOSITORY"),
+		token:  getenv("GITHUB_TOKEN"),
+		number: event.PullRequest.Number,
+	}, true
+}
+
+// Upsert posts body as a comment on the pull request, or updates the
+// comment posted earlier with the same key, so that repeated runs keep a
+// single comment up to date.
+func (pr PullRequest) Upsert(ctx context.Context, key, body string) error {
+	marker := "<!-- pocket:" + key + " -->"
+	id, _, err := pr.find(ctx, marker)
+	if err != nil {
+		return err
+	}
+	return pr.write(ctx, id, marker+"\n"+body)
+}
+
+// verifyDelay is how long UpsertSection waits before checking that its
+// section was not overwritten by a concurrent run. A variable for tests.
+var verifyDelay = time.Second
+
+// UpsertSection is like Upsert, but only replaces the named section of the
+// comment, keeping the sections written by other runs, e.g. the jobs of an
+// OS matrix that report to the same comment. Sections are sorted by name.
+// Runs that update the comment at the same time can overwrite each other's
+// section, so the section is checked after writing and written again if it
+// was lost.
+func (pr PullRequest) UpsertSection(ctx context.Context, key, section, body string) error {
+	marker := "<!-- pocket:" + key + " -->"
+	want := sectionMarker(section) + "\n" + body
+	for range 3 {
+		id, existing, err := pr.find(ctx, marker)
+		if err != nil {
+			return err
+		}
+		sections := parseSections(strings.TrimPrefix(existing, marker))
+		sections[section] = body
+		if err := pr.write(ctx, id, marker+"\n"+formatSections(sections)); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(verifyDelay):
+		}
+		_, current, err := pr.find(ctx, marker)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(current, want) {
+			return nil
+		}
+	}
+	return fmt.Errorf("section %q of comment %q was overwritten by concurrent runs", section, key)
+}
+
+// sectionMarker starts a section of a comment written by UpsertSection.
+func sectionMarker(name string) string {
+	return "<!-- pocket-section:" + name + " -->"
+}
+
+// parseSections splits a comment body into its sections by name.
+// Text before the first section is dropped.
+func parseSections(body string) map[string]string {
+	sections := map[string]string{}
+	const prefix = "<!-- pocket-section:"
+	name, inSection := "", false
+	var b strings.Builder
+	flush := func() {
+		if inSection {
+			sections[name] = strings.TrimSuffix(b.String(), "\n")
+		}
+		b.Reset()
+	}
+	for line := range strings.Lines(body) {
+		if rest, ok := strings.CutPrefix(line, prefix); ok {
+			flush()
+			name, inSection = strings.TrimSuffix(strings.TrimSpace(rest), " -->"), true
+			continue
+		}
+		b.WriteString(line)
+	}
+	flush()
+	return sections
+}
+
+// formatSections renders sections sorted by name, separated by blank lines.
+func formatSections(sections map[string]string) string {
+	var b strings.Builder
+	for i, name := range slices.Sorted(maps.Keys(sections)) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(sectionMarker(name) + "\n" + sections[name])
+		if !strings.HasSuffix(sections[name], "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// find returns the ID and body of the comment starting with marker, or 0
+// if there is none.
+func (pr PullRequest) find(ctx context.Context, marker string) (int64, string, error) {
+	for page := 1; ; page++ {
+		var comments []struct {
+			ID   int64  `json:"id"`
+			Body string `json:"body"`
+		}
+		url := fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=100&page=%d", pr.apiURL, pr.repo, pr.number, page)
+		if err := pr.do(ctx, http.MethodGet, url, nil, &comments); err != nil {
+			return 0, "", err
+		}
+		for _, c := range comments {
+			if strings.HasPrefix(c.Body, marker) {
+				return c.ID, c.Body, nil
+			}
+		}
+		if len(comments) < 100 {
+			return 0, "", nil
+		}
+	}
+}
+
+// write updates the comment with the given ID, or posts a new one if id is 0.
+func (pr PullRequest) write(ctx context.Context, id int64, body string) error {
+	payload := map[string]string{"body": body}
+	if id != 0 {
+		url := fmt.Sprintf("%s/repos/%s/issues/comments/%d", pr.apiURL, pr.repo, id)
+		return pr.do(ctx, http.MethodPatch, url, payload, nil)
+	}
+	url := fmt.Sprintf("%s/repos/%s/issues/%d/comments", pr.apiURL, pr.repo, pr.number)
+	return pr.do(ctx, http.MethodPost, url, payload, nil)
+}
+
+// do sends a GitHub API request with an optional JSON body and decodes the
+// JSON response into out, if not nil.
+func (pr PullRequest) do(ctx context.Context, method, url string, in, out any) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+pr.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s", method, url, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
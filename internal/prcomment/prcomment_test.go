@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: MIT
+
+package prcomment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCurrent(t *testing.T) {
+	eventPath := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(eventPath, []byte(`{"pull_request":{"number":42}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_TOKEN":      "token",
+		"GITHUB_REPOSITORY": "org/repo",
+		"GITHUB_EVENT_PATH": eventPath,
+	}
+	pr, ok := Current(func(k string) string { return env[k] })
+	if !ok {
+		t.Fatal("Current() = false, want true")
+	}
+	want := PullRequest{apiURL: "https://api.github.com", repo: "org/repo", token: "token", number: 42}
+	if pr != want {
+		t.Errorf("Current() = %+v, want %+v", pr, want)
+	}
+
+	delete(env, "GITHUB_TOKEN")
+	if _, ok := Current(func(k string) string { return env[k] }); ok {
+		t.Error("Current() without token = true, want false")
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	type comment struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+	}
+	comments := []comment{{ID: 1, Body: "unrelated"}}
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var in comment
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(comments)
+		case http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			comments = append(comments, comment{ID: int64(len(comments) + 1), Body: in.Body})
+		case http.MethodPatch:
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			comments[1].Body = in.Body
+		}
+	}))
+	defer srv.Close()
+
+	pr := PullRequest{apiURL: srv.URL, repo: "org/repo", token: "token", number: 7}
+	for _, body := range []string{"first", "second"} {
+		if err := pr.Upsert(context.Background(), "bench", body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantRequests := []string{
+		"GET /repos/org/repo/issues/7/comments",
+		"POST /repos/org/repo/issues/7/comments",
+		"GET /repos/org/repo/issues/7/comments",
+		"PATCH /repos/org/repo/issues/comments/2",
+	}
+	if strings.Join(requests, "\n") != strings.Join(wantRequests, "\n") {
+		t.Errorf("requests =\n%s\nwant\n%s", strings.Join(requests, "\n"), strings.Join(wantRequests, "\n"))
+	}
+	if len(comments) != 2 || comments[1].Body != "<!-- pocket:bench -->\nsecond" {
+		t.Errorf("comments = %+v, want a single updated pocket comment", comments)
+	}
+}
+
+func TestUpsertSection(t *testing.T) {
+	orig := verifyDelay
+	verifyDelay = 0
+	t.Cleanup(func() { verifyDelay = orig })
+
+	type comment struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+	}
+	var comments []comment
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in comment
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(comments)
+		case http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			comments = append(comments, comment{ID: int64(len(comments) + 1), Body: in.Body})
+		case http.MethodPatch:
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			comments[0].Body = in.Body
+		}
+	}))
+	defer srv.Close()
+
+	pr := PullRequest{apiURL: srv.URL, repo: "org/repo", token: "token", number: 7}
+	for _, s := range []struct{ section, body string }{
+		{"macOS", "macOS passed\n"},
+		{"Linux", "Linux failed\n"},
+		{"Linux", "Linux passed\n"},
+	} {
+		if err := pr.UpsertSection(context.Background(), "run:go-test:.", s.section, s.body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "<!-- pocket:run:go-test:. -->\n" +
+		"<!-- pocket-section:Linux -->\nLinux passed\n\n" +
+		"<!-- pocket-section:macOS -->\nmacOS passed\n"
+	if len(comments) != 1 || comments[0].Body != want {
+		t.Errorf("comments = %+v, want a single comment with body:\n%s", comments, want)
+	}
+}
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package scaffold provides generation of .pocket/ scaffold files.
 package scaffold
 
@@ -17,7 +19,7 @@ import (
 var MainTemplate []byte
 
 //go:embed config.go.tmpl
-var ConfigTemplate []byte
+var configTemplate string
 
 //go:embed gitignore.tmpl
 var GitignoreTemplate []byte
@@ -40,11 +42,8 @@ func GenerateAll(plan *pocket.ConfigPlan) ([]string, error) {
 	}
 
 	// Create config.go if not exists (user-editable, never overwritten)
-	configPath := filepath.Join(pocketDir, "config.go")
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		if err := os.WriteFile(configPath, ConfigTemplate, 0o644); err != nil {
-			return nil, fmt.Errorf("writing config.go: %w", err)
-		}
+	if err := GenerateConfig(nil); err != nil {
+		return nil, err
 	}
 
 	// Create .gitignore if not exists
@@ -81,6 +80,31 @@ func GenerateAll(plan *pocket.ConfigPlan) ([]string, error) {
 	return shimPaths, nil
 }
 
+// GenerateConfig creates .pocket/config.go from the template if it doesn't
+// exist. Go modules in modules (relative to the git root) are pre-populated
+// in AutoRun, so that they get shims of their own.
+func GenerateConfig(modules []string) error {
+	configPath := filepath.Join(pocket.FromGitRoot(), pocket.DirName, "config.go")
+	if _, err := os.Stat(configPath); err == nil {
+		return nil // Already exists
+	}
+
+	tmpl, err := template.New("config.go").Parse(configTemplate)
+	if err != nil {
+		return fmt.Errorf("parsing config.go template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string][]string{"Modules": modules}); err != nil {
+		return fmt.Errorf("executing config.go template: %w", err)
+	}
+
+	if err := os.WriteFile(configPath, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing config.go: %w", err)
+	}
+	return nil
+}
+
 // GenerateMain creates or updates .pocket/main.go from the template.
 func GenerateMain() error {
 	mainPath := filepath.Join(pocket.FromGitRoot(), pocket.DirName, "main.go")
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package shim
 
 import (
@@ -5,8 +7,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
+
+	"github.com/fredrikaverpil/pocket/internal/testenv"
 )
 
+// GoDownloadsURL lists Go releases and their checksums.
+const GoDownloadsURL = "https://go.dev/dl/?mode=json&include=all"
+
 // GoChecksums holds SHA256 checksums for Go downloads, keyed by "os-arch".
 type GoChecksums map[string]string
 
@@ -25,10 +33,40 @@ type goFile struct {
 	Kind     string `json:"kind"`
 }
 
+var (
+	checksumsMu       sync.Mutex
+	checksumsOverride GoChecksums
+)
+
+// OverrideGoChecksums makes FetchGoChecksums return checksums, without
+// network access, until restore is called. pockettest uses it to render
+// shims offline.
+func OverrideGoChecksums(checksums GoChecksums) (restore func()) {
+	checksumsMu.Lock()
+	defer checksumsMu.Unlock()
+	prev := checksumsOverride
+	if checksums == nil {
+		checksums = GoChecksums{}
+	}
+	checksumsOverride = checksums
+	return func() {
+		checksumsMu.Lock()
+		defer checksumsMu.Unlock()
+		checksumsOverride = prev
+	}
+}
+
 // FetchGoChecksums fetches SHA256 checksums for the given Go version
 // from the official Go download API.
 func FetchGoChecksums(ctx context.Context, version string) (GoChecksums, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://go.dev/dl/?mode=json&include=all", nil)
+	checksumsMu.Lock()
+	override := checksumsOverride
+	checksumsMu.Unlock()
+	if override != nil {
+		return override, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, testenv.MirrorURL(GoDownloadsURL), nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: MIT
+
+package shim
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket/internal/testenv"
+)
+
+// ChecksumsFile is the name of the file listing the SHA256 checksums of the
+// prebuilt runners, in the format of sha256sum.
+const ChecksumsFile = "SHA256SUMS"
+
+// AssetName returns the file name of the prebuilt runner for a platform,
+// e.g. "pocket-runner-linux-amd64" or "pocket-runner-windows-amd64.exe".
+func AssetName(goos, goarch string) string {
+	name := RunnerName + "-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// FetchRunnerChecksums fetches <baseURL>/SHA256SUMS and returns the
+// checksums of the prebuilt runners keyed by "os-arch".
+func FetchRunnerChecksums(ctx context.Context, baseURL string) (map[string]string, error) {
+	url := strings.TrimSuffix(baseURL, "/") + "/" + ChecksumsFile
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, testenv.MirrorURL(url), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching runner checksums: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: HTTP %d", url, resp.StatusCode)
+	}
+	checksums, err := parseRunnerChecksums(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", url, err)
+	}
+	if len(checksums) == 0 {
+		return nil, fmt.Errorf("%s lists no %s files", url, RunnerName)
+	}
+	return checksums, nil
+}
+
+// parseRunnerChecksums reads "<sha256>  <file>" lines and returns the
+// checksums of the runner files keyed by "os-arch". Other files are
+// ignored.
+func parseRunnerChecksums(r io.Reader) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks binary mode with a "*" before the name.
+		name := strings.TrimSuffix(strings.TrimPrefix(fields[1], "*"), ".exe")
+		platform, ok := strings.CutPrefix(name, RunnerName+"-")
+		if !ok || strings.Count(platform, "-") != 1 {
+			continue
+		}
+		checksums[platform] = strings.ToLower(fields[0])
+	}
+	return checksums, scanner.Err()
+}
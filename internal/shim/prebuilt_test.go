@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: MIT
+
+package shim
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+func TestAssetName(t *testing.T) {
+	t.Parallel()
+
+	if got, want := AssetName("linux", "arm64"), "pocket-runner-linux-arm64"; got != want {
+		t.Errorf("AssetName() = %q, want %q", got, want)
+	}
+	if got, want := AssetName("windows", "amd64"), "pocket-runner-windows-amd64.exe"; got != want {
+		t.Errorf("AssetName() = %q, want %q", got, want)
+	}
+}
+
+func TestParseRunnerChecksums(t *testing.T) {
+	t.Parallel()
+
+	sums := "AAA  pocket-runner-darwin-arm64\n" +
+		"bbb *pocket-runner-windows-amd64.exe\n" +
+		"ccc  notes.txt\n" +
+		"ddd  pocket-runner-linux\n"
+	got, err := parseRunnerChecksums(strings.NewReader(sums))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"darwin-arm64": "aaa", "windows-amd64": "bbb"}
+	if !maps.Equal(got, want) {
+		t.Errorf("parseRunnerChecksums() = %v, want %v", got, want)
+	}
+}
+
+func TestRender_Prebuilt(t *testing.T) {
+	t.Parallel()
+
+	cfg := pocket.Config{
+		Shim: &pocket.ShimConfig{
+			Name:       "pok",
+			Posix:      true,
+			PowerShell: true,
+			Prebuilt: &pocket.PrebuiltRunner{
+				URL:       "https://example.com/releases/v1",
+				Checksums: map[string]string{"linux-amd64": "0123456789abcdef", "windows-arm64": "fedcba9876543210"},
+			},
+		},
+	}
+	files, err := Render(cfg, "1.25.0", GoChecksums{"linux-amd64": "abc123"}, []string{"."})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	posix, ps1 := string(files[0].Content), string(files[1].Content)
+
+	for _, want := range []string{
+		`"linux-amd64") RUNNER_SHA256="0123456789abcdef" ;;`,
+		`https://example.com/releases/v1/pocket-runner-${RUNNER_OS}-${RUNNER_ARCH}`,
+		`exec "$RUNNER" "$@"`,
+	} {
+		if !strings.Contains(posix, want) {
+			t.Errorf("posix shim missing %q", want)
+		}
+	}
+	for _, want := range []string{
+		`"windows-arm64" = "fedcba9876543210"`,
+		`https://example.com/releases/v1/pocket-runner-windows-$RunnerArch.exe`,
+	} {
+		if !strings.Contains(ps1, want) {
+			t.Errorf("powershell shim missing %q", want)
+		}
+	}
+
+	// Without Prebuilt, shims always build with Go.
+	cfg.Shim.Prebuilt = nil
+	files, err = Render(cfg, "1.25.0", GoChecksums{"linux-amd64": "abc123"}, []string{"."})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if strings.Contains(string(files[0].Content), "RUNNER_SHA256") {
+		t.Error("posix shim downloads a runner without Prebuilt")
+	}
+}
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package shim provides generation of the ./pok wrapper scripts.
 package shim
 
@@ -7,6 +9,7 @@ import (
 	_ "embed"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -24,12 +27,20 @@ var windowsTemplate string
 //go:embed pok.ps1.tmpl
 var powershellTemplate string
 
+// RunnerName is the name of the task runner binary that shims build from
+// .pocket into .pocket/bin and execute.
+const RunnerName = "pocket-runner"
+
 // shimData holds the template data for generating a shim.
 type shimData struct {
 	GoVersion   string
 	PocketDir   string
 	Context     string
+	Runner      string      // task runner binary name, without .exe
 	GoChecksums GoChecksums // SHA256 checksums keyed by "os-arch"
+
+	// Prebuilt configures downloading a prebuilt runner (nil = build with Go).
+	Prebuilt *pocket.PrebuiltRunner
 }
 
 // shimType represents a type of shim to generate.
@@ -58,10 +69,15 @@ func GenerateWithDirs(cfg pocket.Config, moduleDirs []string) ([]string, error)
 // using the specified root directory. This is useful for testing.
 // Returns the list of generated shim paths relative to the root directory.
 func GenerateWithRoot(cfg pocket.Config, rootDir string) ([]string, error) {
+	return generateWithRootAndDirs(cfg, rootDir, ModuleDirs(cfg))
+}
+
+// ModuleDirs returns the sorted module directories of the config (AutoRun
+// and ManualRun), always including the root. A shim is generated in each.
+// Uses Engine.Plan() to derive directories from path mappings (single walk).
+func ModuleDirs(cfg pocket.Config) []string {
 	cfg = cfg.WithDefaults()
 
-	// Collect all module directories from the config (AutoRun + ManualRun).
-	// Uses Engine.Plan() to derive directories from path mappings (single walk).
 	moduleDirSet := make(map[string]bool)
 	moduleDirSet["."] = true // Always include root.
 
@@ -87,8 +103,7 @@ func GenerateWithRoot(cfg pocket.Config, rootDir string) ([]string, error) {
 		moduleDirs = append(moduleDirs, dir)
 	}
 	slices.Sort(moduleDirs)
-
-	return generateWithRootAndDirs(cfg, rootDir, moduleDirs)
+	return moduleDirs
 }
 
 // generateWithRootAndDirs generates shims using pre-computed module directories.
@@ -106,6 +121,54 @@ func generateWithRootAndDirs(cfg pocket.Config, rootDir string, moduleDirs []str
 		return nil, fmt.Errorf("fetching Go checksums: %w", err)
 	}
 
+	// Fetch checksums for prebuilt runners, unless pinned in the config.
+	if prebuilt := cfg.Shim.Prebuilt; prebuilt != nil && len(prebuilt.Checksums) == 0 {
+		runnerChecksums, err := FetchRunnerChecksums(context.Background(), prebuilt.URL)
+		if err != nil {
+			return nil, err
+		}
+		shimCfg := *cfg.Shim
+		shimCfg.Prebuilt = &pocket.PrebuiltRunner{URL: prebuilt.URL, Checksums: runnerChecksums}
+		cfg.Shim = &shimCfg
+	}
+
+	return writeShims(rootDir, cfg, goVersion, checksums, moduleDirs)
+}
+
+// writeShims renders the shims and writes them below rootDir.
+// Returns the list of generated shim paths relative to rootDir.
+func writeShims(rootDir string, cfg pocket.Config, goVersion string, checksums GoChecksums, moduleDirs []string) ([]string, error) {
+	files, err := Render(cfg, goVersion, checksums, moduleDirs)
+	if err != nil {
+		return nil, err
+	}
+	generatedPaths := make([]string, 0, len(files))
+	for _, f := range files {
+		shimPath := filepath.Join(rootDir, filepath.FromSlash(f.Path))
+
+		// Ensure the directory exists.
+		if err := os.MkdirAll(filepath.Dir(shimPath), 0o755); err != nil {
+			return nil, fmt.Errorf("creating directory: %w", err)
+		}
+		if err := os.WriteFile(shimPath, f.Content, 0o755); err != nil {
+			return nil, fmt.Errorf("writing shim: %w", err)
+		}
+		generatedPaths = append(generatedPaths, filepath.FromSlash(f.Path))
+	}
+	return generatedPaths, nil
+}
+
+// File is a rendered shim.
+type File struct {
+	Path    string // relative to the git root, using forward slashes
+	Content []byte
+}
+
+// Render renders the shims for each module directory without writing them.
+// Files are ordered by shim type, then module directory.
+func Render(cfg pocket.Config, goVersion string, checksums GoChecksums, moduleDirs []string) ([]File, error) {
+	cfg = cfg.WithDefaults()
+
 	// Determine which shim types to generate.
 	var types []shimType
 	if cfg.Shim.Posix {
@@ -130,8 +193,8 @@ func generateWithRootAndDirs(cfg pocket.Config, rootDir string, moduleDirs []str
 		})
 	}
 
-	// Generate each shim type at each module directory.
-	var generatedPaths []string
+	// Render each shim type at each module directory.
+	var files []File
 	for _, st := range types {
 		tmpl, err := template.New(st.name).Parse(st.template)
 		if err != nil {
@@ -139,26 +202,22 @@ func generateWithRootAndDirs(cfg pocket.Config, rootDir string, moduleDirs []str
 		}
 
 		for _, moduleDir := range moduleDirs {
-			shimPath, err := generateShimAt(tmpl, cfg.Shim.Name, st.extension, goVersion, checksums, rootDir, moduleDir)
+			content, err := renderShim(tmpl, goVersion, checksums, cfg.Shim.Prebuilt, moduleDir)
 			if err != nil {
 				return nil, fmt.Errorf("generating %s shim at %s: %w", st.name, moduleDir, err)
 			}
-			generatedPaths = append(generatedPaths, shimPath)
+			files = append(files, File{
+				Path:    path.Join(moduleDir, cfg.Shim.Name+st.extension),
+				Content: content,
+			})
 		}
 	}
-
-	return generatedPaths, nil
+	return files, nil
 }
 
-// generateShimAt creates a single shim at the specified module directory.
-// moduleDir is relative to rootDir (e.g., ".", "proj1", "services/api").
-// Returns the generated shim path relative to rootDir.
-func generateShimAt(
-	tmpl *template.Template,
-	shimName, extension, goVersion string,
-	checksums GoChecksums,
-	rootDir, moduleDir string,
-) (string, error) {
+// renderShim renders a single shim for the specified module directory.
+// moduleDir is relative to the git root (e.g., ".", "proj1", "services/api").
+func renderShim(tmpl *template.Template, goVersion string, checksums GoChecksums, prebuilt *pocket.PrebuiltRunner, moduleDir string) ([]byte, error) {
 	// Calculate relative path from moduleDir back to .pocket.
 	// For ".", pocketDir is ".pocket".
 	// For "proj1", pocketDir is "../.pocket".
@@ -174,27 +233,14 @@ func generateShimAt(
 		GoVersion:   goVersion,
 		PocketDir:   pocketDir,
 		Context:     moduleDir,
+		Runner:      RunnerName,
 		GoChecksums: checksums,
+		Prebuilt:    prebuilt,
 	}
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("executing shim template: %w", err)
-	}
-
-	// Create the shim at moduleDir within rootDir.
-	shimPath := filepath.Join(rootDir, moduleDir, shimName+extension)
-
-	// Ensure the directory exists.
-	if err := os.MkdirAll(filepath.Dir(shimPath), 0o755); err != nil {
-		return "", fmt.Errorf("creating directory: %w", err)
-	}
-
-	if err := os.WriteFile(shimPath, buf.Bytes(), 0o755); err != nil {
-		return "", fmt.Errorf("writing shim: %w", err)
+		return nil, fmt.Errorf("executing shim template: %w", err)
 	}
-
-	// Return path relative to rootDir.
-	relPath := filepath.Join(moduleDir, shimName+extension)
-	return relPath, nil
+	return buf.Bytes(), nil
 }
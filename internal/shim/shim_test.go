@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package shim
 
 import (
@@ -326,6 +328,10 @@ func TestGenerateWithRoot_ShimContent(t *testing.T) {
 		if !strings.Contains(contentStr, `GO_VERSION="1.24.4"`) {
 			t.Error("posix shim missing GO_VERSION")
 		}
+		// The runner is built and exec'd so tool exit codes pass through.
+		if !strings.Contains(contentStr, `exec "$POK_DIR/bin/`+RunnerName+`"`) {
+			t.Error("posix shim does not exec the built runner")
+		}
 	})
 
 	// Verify Windows CMD shim content.
@@ -648,3 +654,73 @@ func TestGenerateWithRoot_ManualRunOnlyPaths(t *testing.T) {
 		}
 	}
 }
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	cfg := pocket.Config{
+		Shim: &pocket.ShimConfig{Name: "pok", Posix: true, PowerShell: true},
+	}
+	files, err := Render(cfg, "1.25.0", GoChecksums{"linux-amd64": "abc123"}, []string{".", "services/api"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	want := []string{"pok", "services/api/pok", "pok.ps1", "services/api/pok.ps1"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if !strings.Contains(string(files[1].Content), "../../.pocket") {
+		t.Error("nested shim missing relative .pocket path")
+	}
+	if !strings.Contains(string(files[0].Content), "abc123") {
+		t.Error("shim missing Go checksum")
+	}
+}
+
+func TestRender_BuildsRunner(t *testing.T) {
+	t.Parallel()
+
+	cfg := pocket.Config{
+		Shim: &pocket.ShimConfig{Name: "pok", Posix: true, Windows: true, PowerShell: true},
+	}
+	files, err := Render(cfg, "1.25.0", nil, []string{"."})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	// go run reports every failure as exit status 1, so the shims build the
+	// task runner and run it, passing its exit code through.
+	want := map[string][]string{
+		"pok": {
+			`build -C "$POK_DIR" -o "bin/` + RunnerName + `" .`,
+			`exec "$POK_DIR/bin/` + RunnerName + `" "$@"`,
+		},
+		"pok.cmd": {
+			`build -C "%POK_DIR%" -o "bin\` + RunnerName + `.exe" . || exit /b 1`,
+			`exit /b %ERRORLEVEL%`,
+		},
+		"pok.ps1": {
+			`build -C $PocketDir -o "bin/$Runner" .`,
+			`& "$PocketDir/bin/$Runner" @args`,
+		},
+	}
+	for _, f := range files {
+		content := string(f.Content)
+		if strings.Contains(content, " run -C ") {
+			t.Errorf("%s uses go run", f.Path)
+		}
+		for _, s := range want[f.Path] {
+			if !strings.Contains(content, s) {
+				t.Errorf("%s missing %q", f.Path, s)
+			}
+		}
+	}
+	if len(files) != len(want) {
+		t.Errorf("got %d shims, want %d", len(files), len(want))
+	}
+}
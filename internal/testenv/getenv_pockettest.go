@@ -0,0 +1,12 @@
+// SPDX-License-Identifier: MIT
+
+//go:build pockettest
+
+package testenv
+
+import "os"
+
+// Getenv returns the value of the hook variable key.
+func Getenv(key string) string {
+	return os.Getenv(key)
+}
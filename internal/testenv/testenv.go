@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: MIT
+
+// Package testenv holds the environment hooks pockettest.Repo uses to run
+// pocket offline.
+//
+// The hooks are only read by binaries built with the "pockettest" build tag,
+// which Repo sets for the commands it runs. Regular builds ignore them.
+package testenv
+
+import (
+	"net/url"
+	"strings"
+)
+
+// BuildTag enables the hooks.
+const BuildTag = "pockettest"
+
+const (
+	// MirrorEnv holds a base URL that downloads are fetched from instead of
+	// their origin. See MirrorURL.
+	MirrorEnv = "POK_DOWNLOAD_MIRROR"
+
+	// ReplaceEnv holds a local pocket checkout that "pocket init" requires
+	// instead of the latest release.
+	ReplaceEnv = "POK_INIT_REPLACE"
+)
+
+// MirrorURL returns the URL to fetch rawURL from. If MirrorEnv is set,
+// "https://host/path?query" becomes "<mirror>/host/path?query";
+// otherwise rawURL is returned unchanged.
+func MirrorURL(rawURL string) string {
+	mirror := Getenv(MirrorEnv)
+	if mirror == "" {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	mirrored := strings.TrimSuffix(mirror, "/") + "/" + u.Host + u.EscapedPath()
+	if u.RawQuery != "" {
+		mirrored += "?" + u.RawQuery
+	}
+	return mirrored
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: MIT
+
+// Package uninstall finds and removes what pocket added to a repository:
+// the .pocket directory, the shims, generated files such as workflows, and
+// the git hooks set up by hooks-install.
+// It is shared by "pocket uninstall" and the "uninstall" builtin task.
+package uninstall
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// HooksPath is the core.hooksPath set by the hooks-install task, relative to
+// the git root.
+const HooksPath = ".pocket/hooks"
+
+// HooksPathBackupKey is the git config key in which hooks-install keeps a
+// previous core.hooksPath.
+const HooksPathBackupKey = "pocket.hooksPathBackup"
+
+// generatedHeader starts the header comment of files generated by pocket,
+// e.g. "# Code generated by pocket. DO NOT EDIT.".
+const generatedHeader = "Code generated by pocket"
+
+// shimPrefixes start a line near the top of each shim.
+var shimPrefixes = []string{
+	`POK_DIR="`,      // posix shim
+	`set "POK_DIR=`,  // cmd shim
+	`$PocketDir = "`, // PowerShell shim
+}
+
+// headerLines is how many lines at the start of a file are searched for the
+// generated header or a shim line.
+const headerLines = 10
+
+// Plan is what uninstalling pocket removes from a repository.
+type Plan struct {
+	// PocketDir is true if the .pocket directory exists.
+	PocketDir bool
+
+	// Files are the shims and generated files, relative to the git root.
+	Files []string
+
+	// Hooks is true if core.hooksPath points at the hooks written by
+	// hooks-install. It is restored to HooksBackup, or unset if empty.
+	Hooks       bool
+	HooksBackup string
+}
+
+// Find returns what uninstalling pocket from the repository at root removes.
+func Find(root string) (*Plan, error) {
+	p := &Plan{}
+	if info, err := os.Stat(filepath.Join(root, ".pocket")); err == nil && info.IsDir() {
+		p.PocketDir = true
+	}
+
+	out, err := git(root, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("list files: %w", err)
+	}
+	for _, file := range strings.Split(out, "\x00") {
+		if file == "" || strings.HasPrefix(file, ".pocket/") {
+			continue
+		}
+		if generated(filepath.Join(root, filepath.FromSlash(file))) {
+			p.Files = append(p.Files, file)
+		}
+	}
+	slices.Sort(p.Files)
+	p.Files = slices.Compact(p.Files)
+
+	// git config --get fails when the key is unset.
+	if current, _ := git(root, "config", "--get", "core.hooksPath"); strings.TrimSpace(current) == HooksPath {
+		p.Hooks = true
+		backup, _ := git(root, "config", "--get", HooksPathBackupKey)
+		p.HooksBackup = strings.TrimSpace(backup)
+	}
+	return p, nil
+}
+
+// Empty reports whether there is nothing to remove.
+func (p *Plan) Empty() bool {
+	return !p.PocketDir && len(p.Files) == 0 && !p.Hooks
+}
+
+// Print lists what the plan removes.
+func (p *Plan) Print(w io.Writer) {
+	if p.PocketDir {
+		fmt.Fprintln(w, "  .pocket/")
+	}
+	for _, file := range p.Files {
+		fmt.Fprintf(w, "  %s\n", file)
+	}
+	if p.Hooks {
+		if p.HooksBackup != "" {
+			fmt.Fprintf(w, "  git config core.hooksPath (restored to %s)\n", p.HooksBackup)
+		} else {
+			fmt.Fprintln(w, "  git config core.hooksPath")
+		}
+	}
+}
+
+// Apply removes what the plan lists from the repository at root.
+func (p *Plan) Apply(root string) error {
+	if p.Hooks {
+		if p.HooksBackup != "" {
+			if _, err := git(root, "config", "core.hooksPath", p.HooksBackup); err != nil {
+				return fmt.Errorf("restore core.hooksPath: %w", err)
+			}
+			if _, err := git(root, "config", "--unset", HooksPathBackupKey); err != nil {
+				return fmt.Errorf("unset %s: %w", HooksPathBackupKey, err)
+			}
+		} else if _, err := git(root, "config", "--unset", "core.hooksPath"); err != nil {
+			return fmt.Errorf("unset core.hooksPath: %w", err)
+		}
+	}
+	for _, file := range p.Files {
+		if err := os.Remove(filepath.Join(root, filepath.FromSlash(file))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if p.PocketDir {
+		if err := os.RemoveAll(filepath.Join(root, ".pocket")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generated reports whether the file at path was written by pocket.
+// Templates that pocket renders such files from are not.
+func generated(path string) bool {
+	if filepath.Ext(path) == ".tmpl" {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < headerLines && scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(strings.TrimLeft(line, "#/ "), generatedHeader) {
+			return true
+		}
+		if slices.ContainsFunc(shimPrefixes, func(prefix string) bool { return strings.HasPrefix(line, prefix) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// git runs git in root and returns its output.
+func git(root string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	return out.String(), err
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: MIT
+
+package uninstall
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindAndApply(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	root := t.TempDir()
+	gitConfig := filepath.Join(root, ".git", "test-gitconfig")
+	t.Setenv("GIT_CONFIG_GLOBAL", gitConfig)
+	mustGit(t, root, "init", "-q")
+
+	files := map[string]string{
+		".pocket/config.go":            "package main\n",
+		"pok":                          "#!/bin/bash\nset -e\n\nPOK_DIR=\".pocket\"\n",
+		"services/api/pok.cmd":         "@echo off\r\nset \"POK_DIR=..\\..\\.pocket\"\r\n",
+		".github/workflows/pr.yml":     "# Code generated by pocket. DO NOT EDIT.\nname: pr\n",
+		".github/workflows/custom.yml": "name: custom\n",
+		"main.go":                      "package main\n",
+		"templates/pr.yml.tmpl":        "# Code generated by pocket. DO NOT EDIT.\n",
+		"hooks_test.go":                "package git\n\nconst want = \"# Code generated by pocket. DO NOT EDIT.\\n\"\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustGit(t, root, "config", "core.hooksPath", HooksPath)
+	mustGit(t, root, "config", HooksPathBackupKey, ".husky")
+
+	plan, err := Find(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFiles := []string{".github/workflows/pr.yml", "pok", "services/api/pok.cmd"}
+	if !plan.PocketDir || !slices.Equal(plan.Files, wantFiles) || !plan.Hooks || plan.HooksBackup != ".husky" {
+		t.Fatalf("Find() = %+v, want .pocket, %q and hooks restored to .husky", plan, wantFiles)
+	}
+	var out strings.Builder
+	plan.Print(&out)
+	if !strings.Contains(out.String(), "core.hooksPath (restored to .husky)") {
+		t.Errorf("Print() = %q", out.String())
+	}
+
+	if err := plan.Apply(root); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range append(wantFiles, ".pocket") {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", name)
+		}
+	}
+	for _, name := range []string{".github/workflows/custom.yml", "main.go", "templates/pr.yml.tmpl", "hooks_test.go"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("expected %s to be kept: %v", name, err)
+		}
+	}
+	if got := strings.TrimSpace(mustGit(t, root, "config", "--get", "core.hooksPath")); got != ".husky" {
+		t.Errorf("core.hooksPath = %q, want .husky", got)
+	}
+
+	plan, err = Find(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() {
+		t.Errorf("expected nothing left to remove, got %+v", plan)
+	}
+}
+
+func mustGit(t *testing.T, root string, args ...string) string {
+	t.Helper()
+	out, err := git(root, args...)
+	if err != nil {
+		t.Fatalf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunInternal(t *testing.T) {
+	var reinstall []bool
+	install := Task("install:tool", "install tool", func(ctx context.Context) error {
+		reinstall = append(reinstall, Reinstall(ctx))
+		return nil
+	}, AsHidden())
+	lint := Task("lint", "lint code", func(_ context.Context) error { return nil })
+	plan := BuildConfigPlan(Config{
+		AutoRun:  lint,
+		Internal: []*TaskDef{install},
+	})
+
+	var stdout strings.Builder
+	newCtx := func(opts internalOptions, args ...string) context.Context {
+		ctx := NewRunContext(context.Background(), RunContextOptions{
+			Output:     &Output{Stdout: &stdout, Stderr: io.Discard},
+			ConfigPlan: plan,
+		})
+		return withPositionalArgs(withOptions(ctx, opts), args)
+	}
+
+	if err := runInternal(newCtx(internalOptions{})); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "  install:tool  install tool\n"; got != want {
+		t.Errorf("listing = %q, want %q", got, want)
+	}
+
+	if err := runInternal(newCtx(internalOptions{}, "install:tool")); err != nil {
+		t.Fatal(err)
+	}
+	if err := runInternal(newCtx(internalOptions{Reinstall: true}, "install:tool")); err != nil {
+		t.Fatal(err)
+	}
+	if len(reinstall) != 2 || reinstall[0] || !reinstall[1] {
+		t.Errorf("Reinstall(ctx) in runs = %v, want [false true]", reinstall)
+	}
+
+	err := runInternal(newCtx(internalOptions{}, "lint"))
+	if err == nil || !strings.Contains(err.Error(), `unknown task "lint"`) {
+		t.Errorf("expected unknown task error for a task not in Internal, got %v", err)
+	}
+}
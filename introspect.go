@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
@@ -7,10 +9,19 @@ import (
 // TaskInfo represents a task for introspection.
 // This is the public type used by the introspection API for CI/CD integration.
 type TaskInfo struct {
-	Name   string   `json:"name"`             // CLI command name
-	Usage  string   `json:"usage"`            // Description/help text
-	Paths  []string `json:"paths,omitempty"`  // Directories this task runs in
-	Hidden bool     `json:"hidden,omitempty"` // Whether task is hidden from help
+	Name      string    `json:"name"`                // CLI command name
+	Usage     string    `json:"usage"`               // Description/help text
+	LongUsage string    `json:"longUsage,omitempty"` // Detailed help text
+	Examples  []Example `json:"examples,omitempty"`  // Example invocations
+	Paths     []string  `json:"paths,omitempty"`     // Directories this task runs in
+	Hidden    bool      `json:"hidden,omitempty"`    // Whether task is hidden from help
+	Tool      *ToolInfo `json:"tool,omitempty"`      // Managed tool installed by this task
+	Limits    *Limits   `json:"limits,omitempty"`    // Resource limits set by WithLimits
+	Sandbox   *Sandbox  `json:"sandbox,omitempty"`   // Sandbox set by WithSandbox
+	Timeout   string    `json:"timeout,omitempty"`   // Run time limit set by WithTimeout
+	Tags      []string  `json:"tags,omitempty"`      // Tags set by WithTags
+	Inputs    []string  `json:"inputs,omitempty"`    // Globs of files read, set by WithInputs
+	Outputs   []string  `json:"outputs,omitempty"`   // Globs of files written, set by WithOutputs
 }
 
 // IntrospectPlan represents the full introspection structure.
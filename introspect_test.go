@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
@@ -56,6 +58,62 @@ func TestCollectTasks_Hidden(t *testing.T) {
 	}
 }
 
+func TestCollectTasks_LongUsageAndExamples(t *testing.T) {
+	fn := Task("deploy", "deploy service", func(_ context.Context) error {
+		return nil
+	},
+		WithLongUsage("Deploys the service."),
+		WithExample("./pok deploy -env=prod", "deploy to production"),
+		WithExample("./pok deploy -env=dev", ""),
+	)
+
+	tasks, err := CollectTasks(fn)
+	if err != nil {
+		t.Fatalf("CollectTasks() failed: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(tasks))
+	}
+	if tasks[0].LongUsage != "Deploys the service." {
+		t.Errorf("expected long usage, got %q", tasks[0].LongUsage)
+	}
+
+	data, err := json.Marshal(tasks[0])
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	want := `{"name":"deploy","usage":"deploy service","longUsage":"Deploys the service.",` +
+		`"examples":[{"command":"./pok deploy -env=prod","description":"deploy to production"},` +
+		`{"command":"./pok deploy -env=dev"}],"paths":["."]}`
+	if string(data) != want {
+		t.Errorf("unexpected JSON:\n got: %s\nwant: %s", data, want)
+	}
+}
+
+func TestCollectTasks_Tool(t *testing.T) {
+	install := Task("install:lint", "install lint", func(_ context.Context) error {
+		return nil
+	}, AsHidden(), AsTool("lint", "v1.2.3"))
+	fn := Task("lint", "run lint", Serial(install, func(_ context.Context) error {
+		return nil
+	}))
+
+	tasks, err := CollectTasks(fn)
+	if err != nil {
+		t.Fatalf("CollectTasks() failed: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(tasks))
+	}
+	if tasks[0].Tool != nil {
+		t.Errorf("expected no tool for %q, got %+v", tasks[0].Name, tasks[0].Tool)
+	}
+	want := ToolInfo{Name: "lint", Version: "v1.2.3"}
+	if tasks[1].Tool == nil || *tasks[1].Tool != want {
+		t.Errorf("expected tool %+v for %q, got %+v", want, tasks[1].Name, tasks[1].Tool)
+	}
+}
+
 func TestCollectTasks_Serial(t *testing.T) {
 	fn1 := Task("format", "format code", func(_ context.Context) error { return nil })
 	fn2 := Task("lint", "lint code", func(_ context.Context) error { return nil })
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// errFailedInside is recorded for a task when tasks inside it failed with
+// -keep-going, so that it isn't reported or cached as successful.
+var errFailedInside = errors.New("tasks inside failed")
+
+// taskFailure is a task that failed in a run with -keep-going.
+type taskFailure struct {
+	name string
+	path string
+	err  error
+}
+
+// failureReport collects the failed tasks of a run with -keep-going.
+type failureReport struct {
+	mu       sync.Mutex
+	failures []taskFailure
+}
+
+func (r *failureReport) add(name, path string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, taskFailure{name: name, path: path, err: err})
+}
+
+// err returns a *keepGoingError with the failures, or nil if no task
+// failed.
+func (r *failureReport) err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.failures) == 0 {
+		return nil
+	}
+	return &keepGoingError{failures: slices.Clone(r.failures)}
+}
+
+// keepGoingError is the error of a run with -keep-going in which tasks
+// failed. It wraps the errors of the failed tasks.
+type keepGoingError struct {
+	failures []taskFailure
+}
+
+func (e *keepGoingError) Error() string {
+	return fmt.Sprintf("%d task(s) failed", len(e.failures))
+}
+
+func (e *keepGoingError) Unwrap() []error {
+	errs := make([]error, len(e.failures))
+	for i, f := range e.failures {
+		errs[i] = f.err
+	}
+	return errs
+}
+
+// writeReport writes the failed tasks grouped by task, in the order the
+// tasks first failed.
+func (e *keepGoingError) writeReport(w io.Writer) {
+	failures := slices.Clone(e.failures)
+	first := make(map[string]int)
+	for i, f := range failures {
+		if _, ok := first[f.name]; !ok {
+			first[f.name] = i
+		}
+	}
+	slices.SortStableFunc(failures, func(a, b taskFailure) int {
+		return cmp.Compare(first[a.name], first[b.name])
+	})
+	fmt.Fprintln(w, "failed tasks:")
+	for _, f := range failures {
+		name := f.name
+		if f.path != "." {
+			name += " [" + f.path + "]"
+		}
+		msg := strings.ReplaceAll(strings.TrimRight(f.err.Error(), "\n"), "\n", "\n    ")
+		fmt.Fprintf(w, "  %s: %s\n", name, msg)
+	}
+}
+
+// failScope tracks whether a task inside a running task failed with
+// -keep-going. Scopes of nested tasks point to the scope of the task
+// they run in.
+type failScope struct {
+	parent *failScope
+	failed atomic.Bool
+}
+
+// markFailed marks the scope and the scopes it is nested in as failed.
+func (s *failScope) markFailed() {
+	for ; s != nil; s = s.parent {
+		s.failed.Store(true)
+	}
+}
+
+// withFailScope returns a context with a new scope for the task about to
+// run, nested in the scope of ctx.
+func withFailScope(ctx context.Context) (context.Context, *failScope) {
+	ec := getExecContext(ctx)
+	newEC := *ec
+	newEC.failScope = &failScope{parent: ec.failScope}
+	return withExecContext(ctx, &newEC), newEC.failScope
+}
+
+// keepGoingRunnable runs a Runnable so that failed tasks are collected in
+// report instead of stopping the run. See the -keep-going flag.
+type keepGoingRunnable struct {
+	report *failureReport
+	inner  Runnable
+}
+
+func (k *keepGoingRunnable) run(ctx context.Context) error {
+	ec := getExecContext(ctx)
+	newEC := *ec
+	newEC.keepGoing = k.report
+	return k.inner.run(withExecContext(ctx, &newEC))
+}
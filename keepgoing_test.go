@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeepGoing(t *testing.T) {
+	var ran []string
+	record := func(name string, err error) *TaskDef {
+		return Task(name, name, Do(func(_ context.Context) error {
+			ran = append(ran, name)
+			return err
+		}))
+	}
+	errLint := errors.New("lint failed")
+	errTest := errors.New("test failed")
+	install := Task("install:tool", "install tool", Do(func(_ context.Context) error {
+		return errors.New("download failed")
+	}), AsHidden())
+	root := Serial(
+		record("format", nil),
+		Task("go", "go tasks", Serial(record("go-lint", errLint), record("go-vet", nil))),
+		record("go-test", errTest),
+		Task("py-lint", "lint python", Serial(install, record("ruff", nil))),
+		record("md-format", nil),
+	)
+
+	report := &failureReport{}
+	rl := &runLog{start: time.Now()}
+	err := runWithContext(context.Background(), &keepGoingRunnable{report: report, inner: root}, discardOutput(), ".", false, nil, rl)
+	if err != nil {
+		t.Fatalf("run returned %v, want failures in the report", err)
+	}
+	if want := []string{"format", "go-lint", "go-vet", "go-test", "md-format"}; !slices.Equal(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+
+	err = report.err()
+	if err == nil || err.Error() != "3 task(s) failed" {
+		t.Fatalf("report.err() = %v, want 3 failures", err)
+	}
+	if !errors.Is(err, errLint) || !errors.Is(err, errTest) {
+		t.Errorf("report error does not wrap the task errors: %v", err)
+	}
+
+	// The composite task is failed in the run log, but not reported.
+	failed := map[string]bool{}
+	for _, task := range rl.visibleTasks() {
+		failed[task.name] = task.err != nil
+	}
+	if !failed["go"] || !failed["py-lint"] || failed["format"] {
+		t.Errorf("run log failures = %v", failed)
+	}
+	var kgErr *keepGoingError
+	if !errors.As(err, &kgErr) || slices.ContainsFunc(kgErr.failures, func(f taskFailure) bool { return f.name == "go" }) {
+		t.Errorf("composite task reported: %+v", kgErr)
+	}
+}
+
+func TestKeepGoing_Parallel(t *testing.T) {
+	slow := Task("slow", "slow", Do(func(ctx context.Context) error {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}))
+	fail := Task("fail", "fail", Do(func(_ context.Context) error { return errors.New("boom") }))
+
+	report := &failureReport{}
+	root := &keepGoingRunnable{report: report, inner: Parallel(slow, fail)}
+	if err := runWithContext(context.Background(), root, discardOutput(), ".", false, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	var kgErr *keepGoingError
+	if err := report.err(); !errors.As(err, &kgErr) || len(kgErr.failures) != 1 || kgErr.failures[0].name != "fail" {
+		t.Errorf("report.err() = %v, want only fail (slow must not be cancelled)", err)
+	}
+}
+
+func TestKeepGoingError_WriteReport(t *testing.T) {
+	e := &keepGoingError{failures: []taskFailure{
+		{name: "go-test", path: "services/api", err: errors.New("exit status 1")},
+		{name: "md-format", path: ".", err: errors.New("2 files\nnot formatted")},
+		{name: "go-test", path: "services/worker", err: errors.New("exit status 2")},
+	}}
+	var b strings.Builder
+	e.writeReport(&b)
+	want := `failed tasks:
+  go-test [services/api]: exit status 1
+  go-test [services/worker]: exit status 2
+  md-format: 2 files
+    not formatted
+`
+	if b.String() != want {
+		t.Errorf("writeReport() =\n%s\nwant:\n%s", b.String(), want)
+	}
+}
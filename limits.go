@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits caps the resources of the commands a task runs, so that a runaway
+// test or linter can't take down a shared CI runner. Zero fields are not
+// limited.
+//
+// On Linux, the limits are set with ulimit in a /bin/sh wrapper around each
+// command (Memory is the virtual memory limit). On Windows, each command is
+// assigned to a Job Object. On other platforms the limits are not enforced.
+type Limits struct {
+	// Memory is the maximum memory per command, in bytes.
+	Memory int64 `json:"memory,omitempty"`
+
+	// CPUTime is the maximum CPU time per command. A command using several
+	// cores consumes CPU time faster than wall time.
+	CPUTime time.Duration `json:"cpuTime,omitempty"`
+}
+
+// IsZero reports whether no limit is set.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// String formats the limits for plan output, e.g. "memory 2GiB, cpu 10m0s".
+func (l Limits) String() string {
+	var parts []string
+	if l.Memory > 0 {
+		parts = append(parts, "memory "+formatBytes(l.Memory))
+	}
+	if l.CPUTime > 0 {
+		parts = append(parts, "cpu "+l.CPUTime.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatBytes formats n with a binary unit, e.g. 1536 MiB as "1.5GiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	s := fmt.Sprintf("%.1f", float64(n)/float64(div))
+	return strings.TrimSuffix(s, ".0") + string("KMGTP"[exp]) + "iB"
+}
+
+// WithLimits caps the memory and CPU time of each command the task runs,
+// including commands of nested tasks without limits of their own.
+// The limits are shown by ./pok plan.
+//
+// Example:
+//
+//	pocket.Clone(golang.Test, pocket.WithLimits(pocket.Limits{
+//	    Memory:  4 << 30, // 4 GiB
+//	    CPUTime: 30 * time.Minute,
+//	}))
+func WithLimits(limits Limits) TaskOpt {
+	return func(td *TaskDef) {
+		td.limits = limits
+	}
+}
+
+// withLimits sets the limits for commands run with ctx.
+func withLimits(ctx context.Context, limits Limits) context.Context {
+	ec := getExecContext(ctx)
+	newEC := *ec
+	newEC.limits = limits
+	return withExecContext(ctx, &newEC)
+}
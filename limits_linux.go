@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runWithLimits runs cmd under a /bin/sh wrapper that sets the limits with
+// ulimit before exec'ing the command.
+func runWithLimits(cmd *exec.Cmd, limits Limits) error {
+	if cmd.Err != nil {
+		// The command was not found; let Run report it.
+		return cmd.Run()
+	}
+	var script []string
+	if limits.Memory > 0 {
+		// ulimit -v takes KiB.
+		script = append(script, fmt.Sprintf("ulimit -v %d", max(limits.Memory/1024, 1)))
+	}
+	if limits.CPUTime > 0 {
+		script = append(script, fmt.Sprintf("ulimit -t %d", max(int64(limits.CPUTime.Seconds()), 1)))
+	}
+	script = append(script, `exec "$0" "$@"`)
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return err
+	}
+	// Restore the command afterwards, so that errors and the run log show it
+	// rather than the wrapper.
+	path, args := cmd.Path, cmd.Args
+	defer func() { cmd.Path, cmd.Args = path, args }()
+	cmd.Args = append([]string{"sh", "-c", strings.Join(script, " && "), path}, args[1:]...)
+	cmd.Path = sh
+	return cmd.Run()
+}
@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: MIT
+
+//go:build !linux && !windows
+
+package pocket
+
+import "os/exec"
+
+// runWithLimits runs cmd without limits: they are not enforced on this
+// platform.
+func runWithLimits(cmd *exec.Cmd, _ Limits) error {
+	return cmd.Run()
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimits_String(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		want   string
+	}{
+		{Limits{}, ""},
+		{Limits{Memory: 512}, "memory 512B"},
+		{Limits{Memory: 2 << 30}, "memory 2GiB"},
+		{Limits{Memory: 1536 << 20, CPUTime: 10 * time.Minute}, "memory 1.5GiB, cpu 10m0s"},
+		{Limits{CPUTime: 90 * time.Second}, "cpu 1m30s"},
+	}
+	for _, tt := range tests {
+		if got := tt.limits.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.limits, got, tt.want)
+		}
+	}
+}
+
+func TestWithLimits_Plan(t *testing.T) {
+	limits := Limits{Memory: 4 << 30, CPUTime: 30 * time.Minute}
+	task := Clone(Task("test", "run tests", func(_ context.Context) error { return nil }), WithLimits(limits))
+
+	plan, err := NewEngine(task).Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() failed: %v", err)
+	}
+	steps := plan.Steps()
+	if len(steps) != 1 || steps[0].Limits == nil || *steps[0].Limits != limits {
+		t.Fatalf("expected step with limits %+v, got %+v", limits, steps)
+	}
+	if tasks := plan.Tasks(); len(tasks) != 1 || tasks[0].Limits == nil {
+		t.Errorf("expected task info with limits, got %+v", tasks)
+	}
+
+	var stdout strings.Builder
+	ctx := TestContext(&Output{Stdout: &stdout, Stderr: io.Discard})
+	printPlanSteps(ctx, steps, "", false, false)
+	if want := "test (limits: memory 4GiB, cpu 30m0s) - run tests"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("plan output %q does not contain %q", stdout.String(), want)
+	}
+}
+
+func TestWithLimits_Run(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ulimit wrapper is only used on Linux")
+	}
+	var stdout strings.Builder
+	task := Task("limited", "print limits", Do(func(ctx context.Context) error {
+		cmd := Command(ctx, "sh", "-c", "ulimit -v; ulimit -t")
+		cmd.Stdout = &stdout
+		return RunCommand(ctx, cmd)
+	}), WithLimits(Limits{Memory: 1 << 30, CPUTime: 2 * time.Minute}))
+
+	ctx := TestContext(&Output{Stdout: io.Discard, Stderr: io.Discard})
+	if err := task.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "1048576\n120\n"; got != want {
+		t.Errorf("limits in command = %q, want %q", got, want)
+	}
+}
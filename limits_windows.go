@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"fmt"
+	"os/exec"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// runWithLimits starts cmd and assigns it to a Job Object with the limits.
+// Processes the command starts before it is assigned are not limited.
+func runWithLimits(cmd *exec.Cmd, limits Limits) error {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return fmt.Errorf("create job object: %w", err)
+	}
+	defer windows.CloseHandle(job)
+
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	if limits.Memory > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_PROCESS_MEMORY
+		info.ProcessMemoryLimit = uintptr(limits.Memory)
+	}
+	if limits.CPUTime > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_PROCESS_TIME
+		// In 100-nanosecond ticks.
+		info.BasicLimitInformation.PerProcessUserTimeLimit = limits.CPUTime.Nanoseconds() / 100
+	}
+	// Kill the command's processes when the job handle is closed.
+	info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		return fmt.Errorf("set job object limits: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err == nil {
+		err = windows.AssignProcessToJobObject(job, process)
+		windows.CloseHandle(process)
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("assign job object: %w", err)
+	}
+	return cmd.Wait()
+}
@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Notification formats for Notification.Format.
+const (
+	NotifySlack = "slack"
+	NotifyTeams = "teams"
+	NotifyJSON  = "json"
+)
+
+// defaultNotifyMessage is the message template used when
+// Notification.Message is empty.
+const defaultNotifyMessage = `pocket {{.Task}} {{if .Failed}}failed{{else}}passed{{end}}` +
+	`{{with .Repository}} in {{.}}{{end}}{{with .Ref}} ({{.}}){{end}}` +
+	`{{with .FailedTasks}}: {{join . ", "}}{{end}}{{with .LogURL}}` + "\n" + `{{.}}{{end}}`
+
+// Notification is a webhook called after a run completes, e.g. to ping the
+// owning channel when a scheduled workflow fails overnight.
+type Notification struct {
+	// URL is a text/template for the webhook URL. Use {{env "NAME"}} to
+	// keep secrets out of the config; the notification is skipped when the
+	// URL renders empty, e.g. locally where the variable is unset.
+	URL string
+
+	// Format is the payload format: NotifySlack or NotifyTeams send
+	// {"text": message}, NotifyJSON (default) sends the NotifyRun fields
+	// and the message.
+	Format string
+
+	// Message is a text/template for the message, executed with NotifyRun.
+	// Default: the task, result, repository, failed tasks and log link.
+	Message string
+
+	// When decides whether to notify. Default: when the run failed.
+	When func(NotifyRun) bool
+}
+
+// NotifyRun describes a completed run for Notification templates and
+// conditions.
+type NotifyRun struct {
+	Task        string        `json:"task"`
+	Path        string        `json:"path"`
+	Failed      bool          `json:"failed"`
+	FailedTasks []string      `json:"failedTasks"`
+	Duration    time.Duration `json:"durationNs"`
+
+	// GitHub Actions context, empty outside of GitHub Actions.
+	Repository string `json:"repository"`
+	Ref        string `json:"ref"`
+	Event      string `json:"event"`
+	LogURL     string `json:"logUrl"`
+}
+
+// newNotifyRun builds the NotifyRun for a run from its task entries.
+func newNotifyRun(task, path string, tasks []runLogEntry, d time.Duration, err error) NotifyRun {
+	run := NotifyRun{
+		Task:        task,
+		Path:        path,
+		Failed:      err != nil,
+		FailedTasks: []string{},
+		Duration:    d,
+		Repository:  os.Getenv("GITHUB_REPOSITORY"),
+		Ref:         os.Getenv("GITHUB_REF_NAME"),
+		Event:       os.Getenv("GITHUB_EVENT_NAME"),
+		LogURL:      githubRunURL(),
+	}
+	for _, t := range tasks {
+		if t.err == nil {
+			continue
+		}
+		name := t.name
+		if t.path != "" && t.path != "." {
+			name += " [" + t.path + "]"
+		}
+		run.FailedTasks = append(run.FailedTasks, name)
+	}
+	return run
+}
+
+// notifyFuncs are the functions available in Notification templates.
+var notifyFuncs = template.FuncMap{
+	"env":  os.Getenv,
+	"join": strings.Join,
+}
+
+// sendNotifications calls the webhooks whose condition holds for run.
+func sendNotifications(ctx context.Context, notifications []Notification, run NotifyRun) error {
+	var errs []string
+	for i, n := range notifications {
+		if err := n.send(ctx, run); err != nil {
+			errs = append(errs, fmt.Sprintf("notification %d: %v", i+1, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// send calls the webhook if its condition holds for run.
+func (n Notification) send(ctx context.Context, run NotifyRun) error {
+	when := n.When
+	if when == nil {
+		when = func(r NotifyRun) bool { return r.Failed }
+	}
+	if !when(run) {
+		return nil
+	}
+	url, err := executeNotifyTemplate("url", n.URL, run)
+	if err != nil {
+		return err
+	}
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return nil
+	}
+	payload, err := n.payload(run)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		// The URL may contain a secret; don't echo it.
+		return fmt.Errorf("invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("post webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// payload renders the message and encodes the request body for n.Format.
+func (n Notification) payload(run NotifyRun) ([]byte, error) {
+	tmpl := n.Message
+	if tmpl == "" {
+		tmpl = defaultNotifyMessage
+	}
+	msg, err := executeNotifyTemplate("message", tmpl, run)
+	if err != nil {
+		return nil, err
+	}
+	switch n.Format {
+	case NotifySlack, NotifyTeams:
+		return json.Marshal(map[string]string{"text": msg})
+	case "", NotifyJSON:
+		return json.Marshal(struct {
+			NotifyRun
+			Message string `json:"message"`
+		}{run, msg})
+	default:
+		return nil, fmt.Errorf("unknown format %q", n.Format)
+	}
+}
+
+func executeNotifyTemplate(name, text string, run NotifyRun) (string, error) {
+	tmpl, err := template.New(name).Funcs(notifyFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse %s template: %w", name, err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, run); err != nil {
+		return "", fmt.Errorf("execute %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// githubRunURL returns the URL of the current GitHub Actions run, or "".
+func githubRunURL() string {
+	server, repo, id := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if server == "" || repo == "" || id == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, id)
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewNotifyRun(t *testing.T) {
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_REPOSITORY", "org/repo")
+	t.Setenv("GITHUB_RUN_ID", "7")
+	tasks := []runLogEntry{
+		{name: "go-lint", path: "."},
+		{name: "go-test", path: "services/api", err: errors.New("exit status 1")},
+	}
+	run := newNotifyRun("all", ".", tasks, time.Second, errors.New("failed"))
+	if !run.Failed || len(run.FailedTasks) != 1 || run.FailedTasks[0] != "go-test [services/api]" {
+		t.Errorf("newNotifyRun() = %+v, want go-test [services/api] failed", run)
+	}
+	if want := "https://github.com/org/repo/actions/runs/7"; run.LogURL != want {
+		t.Errorf("LogURL = %q, want %q", run.LogURL, want)
+	}
+}
+
+func TestNotificationSend(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+	}))
+	defer srv.Close()
+	t.Setenv("POCKET_TEST_WEBHOOK", srv.URL)
+
+	failed := NotifyRun{
+		Task:        "all",
+		Failed:      true,
+		FailedTasks: []string{"go-test"},
+		Repository:  "org/repo",
+		Ref:         "main",
+		Event:       "schedule",
+		LogURL:      "https://github.com/org/repo/actions/runs/7",
+	}
+	passed := NotifyRun{Task: "all", FailedTasks: []string{}}
+	notifications := []Notification{
+		{URL: `{{env "POCKET_TEST_WEBHOOK"}}`, Format: NotifySlack},
+		{URL: `{{env "POCKET_TEST_WEBHOOK"}}/hook`, Message: "{{.Task}}: {{len .FailedTasks}} failed", When: func(NotifyRun) bool { return true }},
+		{URL: `{{env "POCKET_TEST_UNSET"}}`, Format: NotifyTeams},
+	}
+
+	if err := sendNotifications(context.Background(), notifications, failed); err != nil {
+		t.Fatal(err)
+	}
+	if err := sendNotifications(context.Background(), notifications, passed); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 3 {
+		t.Fatalf("got %d requests, want 3: %q", len(bodies), bodies)
+	}
+
+	var slack struct{ Text string }
+	if err := json.Unmarshal([]byte(bodies[0]), &slack); err != nil {
+		t.Fatal(err)
+	}
+	if want := "pocket all failed in org/repo (main): go-test\nhttps://github.com/org/repo/actions/runs/7"; slack.Text != want {
+		t.Errorf("slack text = %q, want %q", slack.Text, want)
+	}
+	var generic struct {
+		Task    string `json:"task"`
+		Failed  bool   `json:"failed"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(bodies[2]), &generic); err != nil {
+		t.Fatal(err)
+	}
+	if generic.Task != "all" || generic.Failed || generic.Message != "all: 0 failed" {
+		t.Errorf("generic payload = %+v", generic)
+	}
+
+	bad := []Notification{{URL: srv.URL, Format: "irc", When: func(NotifyRun) bool { return true }}}
+	if err := sendNotifications(context.Background(), bad, passed); err == nil {
+		t.Error("sendNotifications() with unknown format: expected error")
+	}
+}
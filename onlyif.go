@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+)
+
+// OnlyIf wraps a Runnable so that it only runs if cond returns true. The
+// condition is checked at run time, once for each path the Runnable runs in
+// (see Path), e.g. to skip docker tasks when no docker daemon is running, or
+// vulncheck when offline. Skipped tasks are reported as
+// "skipped: condition false" and recorded in the run log like tasks skipped
+// with -skip, so they show up in the summary and -output=json events.
+// ./pok plan lists the tasks inside as usual.
+//
+// Example:
+//
+//	AutoRun: pocket.Serial(
+//	    golang.Tasks(),
+//	    pocket.OnlyIf(dockerRunning, dockerBuild),
+//	)
+func OnlyIf(cond func(context.Context) bool, r Runnable) Runnable {
+	return &onlyIfRunnable{cond: cond, inner: r}
+}
+
+// onlyIfRunnable runs a Runnable if a condition holds.
+type onlyIfRunnable struct {
+	cond  func(context.Context) bool
+	inner Runnable
+}
+
+func (o *onlyIfRunnable) run(ctx context.Context) error {
+	if getExecContext(ctx).mode == modeCollect || o.cond(ctx) {
+		return o.inner.run(ctx)
+	}
+	tasks, err := CollectTasks(o.inner)
+	if err != nil {
+		return err
+	}
+	ec := getExecContext(ctx)
+	for _, t := range tasks {
+		if !t.Hidden {
+			printTaskHeader(ctx, t.Name+" (skipped: condition false)")
+		}
+		if ec.runLog != nil {
+			ec.runLog.recordSkip(t.Name, Path(ctx), t.Hidden)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOnlyIf(t *testing.T) {
+	for _, cond := range []bool{true, false} {
+		var ran []string
+		record := func(name string) *TaskDef {
+			return Task(name, name, Do(func(_ context.Context) error {
+				ran = append(ran, name)
+				return nil
+			}))
+		}
+		install := Task("install:tool", "install tool", func(_ context.Context) error { return nil }, AsHidden())
+		root := Serial(
+			record("lint"),
+			OnlyIf(func(_ context.Context) bool { return cond }, Serial(install, record("docker-build"))),
+		)
+
+		var stdout strings.Builder
+		out := &Output{Stdout: &stdout, Stderr: discardOutput().Stderr}
+		rl := &runLog{start: time.Now()}
+		if err := runWithContext(context.Background(), root, out, ".", false, nil, rl); err != nil {
+			t.Fatal(err)
+		}
+		skipped := strings.Contains(stdout.String(), ":: docker-build (skipped: condition false)")
+		wantRan := 1
+		if cond {
+			wantRan = 2
+		}
+		if len(ran) != wantRan {
+			t.Errorf("cond %v: ran %v", cond, ran)
+		}
+		if skipped == cond {
+			t.Errorf("cond %v: unexpected output:\n%s", cond, stdout.String())
+		}
+		if strings.Contains(stdout.String(), "install:tool (skipped") {
+			t.Errorf("cond %v: hidden task reported as skipped:\n%s", cond, stdout.String())
+		}
+		if got := rl.skippedTasks(); (len(got) == 1 && got[0] == "docker-build") == cond {
+			t.Errorf("cond %v: skippedTasks() = %v", cond, got)
+		}
+	}
+}
+
+func TestOnlyIf_Plan(t *testing.T) {
+	called := false
+	task := Task("docker-build", "build image", func(_ context.Context) error { return nil })
+	r := OnlyIf(func(_ context.Context) bool { called = true; return false }, task)
+
+	tasks, err := CollectTasks(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "docker-build" {
+		t.Errorf("CollectTasks() = %+v, want docker-build", tasks)
+	}
+	if called {
+		t.Error("condition was checked while collecting the plan")
+	}
+}
@@ -1,6 +1,9 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -185,6 +188,61 @@ func parseTaskArgs(args []string) (map[string]string, bool, error) {
 	return result, false, nil
 }
 
+// splitPositionalArgs splits the arguments of a task that accepts positional
+// arguments into its options and the positional arguments. Options come
+// first; a bare "--", or the first argument that is neither an option nor
+// the value of one, starts the positional arguments.
+func splitPositionalArgs(args []string, info *argsInfo) (opts, positional []string) {
+	isBool := func(name string) bool {
+		if info == nil {
+			return false
+		}
+		for _, field := range info.Fields {
+			if field.Name == name {
+				return field.Type == reflect.Bool
+			}
+		}
+		return false
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+		if len(arg) == 0 || arg[0] != '-' {
+			return args[:i], args[i:]
+		}
+		key := strings.TrimLeft(arg, "-")
+		if !strings.Contains(key, "=") && key != "h" && key != "help" && !isBool(key) {
+			i++ // skip the value
+		}
+	}
+	return args, nil
+}
+
+// positionalArgsKey is the context key for the positional arguments of a task.
+type positionalArgsKey struct{}
+
+// withPositionalArgs stores the positional arguments of a task in the context.
+func withPositionalArgs(ctx context.Context, args []string) context.Context {
+	return context.WithValue(ctx, positionalArgsKey{}, args)
+}
+
+// positionalArgs returns the positional arguments given to the task on the
+// command line, see acceptArgs.
+func positionalArgs(ctx context.Context) []string {
+	args, _ := ctx.Value(positionalArgsKey{}).([]string)
+	return args
+}
+
+// acceptArgs lets a task take positional arguments after its options,
+// described by label in help, e.g. "<files...>".
+func acceptArgs(label string) TaskOpt {
+	return func(td *TaskDef) {
+		td.args = label
+	}
+}
+
 // formatArgDefault formats a default value for display.
 func formatArgDefault(v any) string {
 	switch val := v.(type) {
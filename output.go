@@ -1,7 +1,10 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -81,3 +84,80 @@ func (l *lockedWriter) Write(p []byte) (n int, err error) {
 	defer l.mu.Unlock()
 	return l.w.Write(p)
 }
+
+// linePrefixer streams the output of the items of Parallel groups line by
+// line to out, with Config.ParallelOutput set to ParallelOutputPrefixed.
+// Lines are written whole, so the output of concurrent tasks interleaves by
+// line rather than by write.
+type linePrefixer struct {
+	mu  sync.Mutex
+	out *Output
+}
+
+// output returns an Output whose lines are written to p.out with prefix,
+// and a function that writes the last line if it has no newline.
+func (p *linePrefixer) output(prefix string) (*Output, func()) {
+	stdout := &prefixWriter{mu: &p.mu, w: p.out.Stdout, prefix: prefix}
+	stderr := &prefixWriter{mu: &p.mu, w: p.out.Stderr, prefix: prefix}
+	return &Output{Stdout: stdout, Stderr: stderr}, func() {
+		stdout.flush()
+		stderr.flush()
+	}
+}
+
+// prefixWriter writes complete lines to w, each starting with prefix.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte // the incomplete last line
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.buf = append(p.buf, b...)
+	i := bytes.LastIndexByte(p.buf, '\n')
+	if i < 0 {
+		return len(b), nil
+	}
+	lines := p.buf[:i+1]
+	if p.prefix != "" {
+		var out bytes.Buffer
+		for line := range bytes.Lines(lines) {
+			out.WriteString(p.prefix)
+			out.Write(line)
+		}
+		lines = out.Bytes()
+	}
+	if _, err := p.w.Write(lines); err != nil {
+		return 0, err
+	}
+	p.buf = append(p.buf[:0], p.buf[i+1:]...)
+	return len(b), nil
+}
+
+// flush writes the incomplete last line, if any, with a newline.
+func (p *prefixWriter) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.buf) > 0 {
+		_, _ = fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.buf)
+		p.buf = nil
+	}
+}
+
+// withLinePrefix returns a context in which output is streamed with the
+// name of a task in a Parallel group with prefixed output, and a function
+// that writes the last line of the task.
+func withLinePrefix(ctx context.Context, name string) (context.Context, func()) {
+	ec := getExecContext(ctx)
+	prefix := "[" + name + "] "
+	if ec.path != "" && ec.path != "." {
+		prefix = "[" + name + " " + ec.path + "] "
+	}
+	newEC := *ec
+	var flush func()
+	newEC.out, flush = ec.prefixer.output(prefix)
+	return withExecContext(ctx, &newEC), flush
+}
@@ -1,7 +1,11 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"context"
+	"path"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strings"
@@ -246,3 +250,71 @@ func containsRegexMeta(s string) bool {
 	}
 	return strings.ContainsAny(s, `.+*?[](){}|^$\`)
 }
+
+// InDir wraps a Runnable to run in dir, relative to the git root, instead
+// of the current path. Unlike RunIn, dir does not have to be a detected
+// module and no shim is generated in it: tasks inside are run from the root
+// shim (or a shim in a parent of dir), with dir shown in their headers.
+//
+// Example:
+//
+//	pocket.InDir("docs", markdown.Tasks())
+//
+// To run a single task in a directory, use TaskDef.InDir.
+func InDir(dir string, r Runnable) Runnable {
+	return &dirRunnable{dir: cleanDir(dir), inner: r}
+}
+
+// dirRunnable runs a Runnable in a fixed directory.
+type dirRunnable struct {
+	dir   string
+	inner Runnable
+}
+
+func (d *dirRunnable) run(ctx context.Context) error {
+	ec := getExecContext(ctx)
+
+	// In collect mode, record the directory for the tasks inside.
+	if ec.mode == modeCollect {
+		prev := ec.plan.setDirContext(d.dir)
+		err := d.inner.run(ctx)
+		ec.plan.setDirContext(prev)
+		return err
+	}
+
+	if !dirWithin(d.dir, ec.cwd) {
+		return nil
+	}
+	return d.inner.run(withPath(ctx, d.dir))
+}
+
+// pathsRunnable runs a Runnable once in each of its paths, as if invoked
+// from the shim in that path. See the path=<dir> task argument.
+type pathsRunnable struct {
+	paths []string
+	inner Runnable
+}
+
+func (p *pathsRunnable) run(ctx context.Context) error {
+	for _, path := range p.paths {
+		ec := getExecContext(ctx)
+		newEC := *ec
+		newEC.cwd = path
+		newEC.path = path
+		if err := p.inner.run(withExecContext(ctx, &newEC)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanDir normalizes a directory relative to the git root.
+func cleanDir(dir string) string {
+	return path.Clean(filepath.ToSlash(dir))
+}
+
+// dirWithin reports whether dir is cwd or below it.
+// Both are relative to the git root.
+func dirWithin(dir, cwd string) bool {
+	return cwd == "." || dir == cwd || strings.HasPrefix(dir, cwd+"/")
+}
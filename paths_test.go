@@ -1,7 +1,13 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"context"
+	"io"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -214,3 +220,88 @@ func TestModuleDirectoriesFromPlan(t *testing.T) {
 		}
 	}
 }
+
+func TestInDir(t *testing.T) {
+	var mu sync.Mutex
+	var ran []string
+	record := func(name string) *TaskDef {
+		return Task(name, name, func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, name+"@"+Path(ctx))
+			return nil
+		})
+	}
+	docs, tool, mod := record("docs"), record("tool"), record("mod")
+	root := Serial(
+		docs.InDir("docs"),
+		InDir("tools/gen/", tool),
+		RunIn(mod, Include("proj1", "proj2")),
+	)
+
+	tests := []struct {
+		cwd  string
+		want []string
+	}{
+		{cwd: ".", want: []string{"docs@docs", "tool@tools/gen", "mod@proj1", "mod@proj2"}},
+		{cwd: "tools", want: []string{"tool@tools/gen"}},
+		{cwd: "proj1", want: []string{"mod@proj1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cwd, func(t *testing.T) {
+			ran = nil
+			var stdout strings.Builder
+			out := &Output{Stdout: &stdout, Stderr: io.Discard}
+			if err := runWithContext(context.Background(), root, out, tt.cwd, false, nil, nil); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ran, tt.want) {
+				t.Errorf("ran %v, want %v", ran, tt.want)
+			}
+			if tt.cwd == "." && !strings.Contains(stdout.String(), ":: docs [docs]") {
+				t.Errorf("header does not show the directory:\n%s", stdout.String())
+			}
+		})
+	}
+
+	plan := BuildConfigPlan(Config{AutoRun: root})
+	if got := plan.TaskDirs; got["docs"] != "docs" || got["tool"] != "tools/gen" || len(got) != 2 {
+		t.Errorf("TaskDirs = %v", got)
+	}
+	if !slices.Equal(plan.ModuleDirectories, []string{".", "proj1", "proj2"}) {
+		t.Errorf("InDir directories must not get shims, got %v", plan.ModuleDirectories)
+	}
+}
+
+func TestPathsRunnable(t *testing.T) {
+	var ran []string
+	test := Task("test", "test", Do(func(ctx context.Context) error {
+		ran = append(ran, Path(ctx))
+		return nil
+	}))
+	plan := BuildConfigPlan(Config{AutoRun: RunIn(test, Include("proj1", "proj2", "proj3"))})
+
+	paths, err := validateTaskPaths(plan, "test", []string{"proj3/", "proj1", "proj3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout strings.Builder
+	out := &Output{Stdout: &stdout, Stderr: io.Discard}
+	if err := runWithContext(context.Background(), &pathsRunnable{paths: paths, inner: test}, out, ".", false, plan, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"proj3", "proj1"}; !slices.Equal(ran, want) {
+		t.Errorf("ran in %v, want %v", ran, want)
+	}
+	if !strings.Contains(stdout.String(), ":: test [proj3]") {
+		t.Errorf("header does not show the path:\n%s", stdout.String())
+	}
+
+	_, err = validateTaskPaths(plan, "test", []string{"proj4"})
+	if err == nil || !strings.Contains(err.Error(), "runs in: proj1, proj2, proj3") {
+		t.Errorf("validateTaskPaths(proj4) error = %v, want error listing the task paths", err)
+	}
+	if _, err := validateTaskPaths(plan, "plan", []string{"."}); err != nil {
+		t.Errorf("root-only tasks run in .: %v", err)
+	}
+}
@@ -1,8 +1,15 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
+	"fmt"
+	"maps"
+	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 )
 
 // OS name constants matching runtime.GOOS values.
@@ -17,6 +24,7 @@ const (
 	// Go-style architecture names (matching runtime.GOARCH).
 	AMD64 = "amd64"
 	ARM64 = "arm64"
+	ARM   = "arm" // 32-bit ARM; release artifacts are usually armv7
 
 	// Alternative naming conventions used by various tools.
 	X8664   = "x86_64"
@@ -118,3 +126,76 @@ func DefaultArchiveFormatFor(os string) string {
 	}
 	return "tar.gz"
 }
+
+// HostMusl reports whether the host is Linux with musl libc (e.g. Alpine),
+// where binaries linked against glibc don't run.
+func HostMusl() bool {
+	hostMuslOnce.Do(func() {
+		if runtime.GOOS != Linux {
+			return
+		}
+		matches, _ := filepath.Glob("/lib/ld-musl-*.so.1")
+		hostMusl = len(matches) > 0
+	})
+	return hostMusl
+}
+
+var (
+	hostMuslOnce sync.Once
+	hostMusl     bool
+)
+
+// UnsupportedPlatformError is returned when a tool has no release artifact
+// for the host platform.
+type UnsupportedPlatformError struct {
+	Tool      string   // tool name
+	Platform  string   // host platform, e.g. "linux/arm-musl"
+	Supported []string // platforms the tool has artifacts for
+}
+
+func (e *UnsupportedPlatformError) Error() string {
+	return fmt.Sprintf("%s has no release for %s (available: %s)",
+		e.Tool, e.Platform, strings.Join(e.Supported, ", "))
+}
+
+// PlatformAsset looks up the host platform in assets, a tool's release
+// artifacts (or the platform part of their names) keyed by "os/arch" with
+// Go names, e.g. "linux/arm64". For Linux, a key may end in "-gnu" or
+// "-musl" for artifacts that only run with that libc; a key without suffix
+// is used with either, e.g. for statically linked binaries. It returns an
+// *UnsupportedPlatformError, instead of a URL that 404s, when the host is
+// not in assets.
+//
+// Example:
+//
+//	target, err := pocket.PlatformAsset(Name, map[string]string{
+//	    "darwin/arm64":     "aarch64-apple-darwin",
+//	    "linux/amd64-gnu":  "x86_64-unknown-linux-gnu",
+//	    "linux/amd64-musl": "x86_64-unknown-linux-musl",
+//	})
+func PlatformAsset(tool string, assets map[string]string) (string, error) {
+	return platformAsset(tool, assets, runtime.GOOS, runtime.GOARCH, HostMusl())
+}
+
+func platformAsset(tool string, assets map[string]string, goos, goarch string, musl bool) (string, error) {
+	key := goos + "/" + goarch
+	platform := key
+	if goos == Linux {
+		libc := "-gnu"
+		if musl {
+			libc = "-musl"
+		}
+		platform += libc
+		if asset, ok := assets[key+libc]; ok {
+			return asset, nil
+		}
+	}
+	if asset, ok := assets[key]; ok {
+		return asset, nil
+	}
+	return "", &UnsupportedPlatformError{
+		Tool:      tool,
+		Platform:  platform,
+		Supported: slices.Sorted(maps.Keys(assets)),
+	}
+}
@@ -1,6 +1,12 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+)
 
 func TestArchToX8664(t *testing.T) {
 	t.Parallel()
@@ -106,3 +112,43 @@ func TestDefaultArchiveFormatFor(t *testing.T) {
 		}
 	}
 }
+
+func TestPlatformAsset(t *testing.T) {
+	t.Parallel()
+	assets := map[string]string{
+		"darwin/arm64":     "macos-arm64",
+		"linux/amd64-gnu":  "linux-x64",
+		"linux/amd64-musl": "linux-x64-musl",
+		"linux/arm64":      "linux-arm64-static",
+	}
+	tests := []struct {
+		goos, goarch string
+		musl         bool
+		want         string
+		wantErr      string
+	}{
+		{goos: Darwin, goarch: ARM64, want: "macos-arm64"},
+		{goos: Linux, goarch: AMD64, want: "linux-x64"},
+		{goos: Linux, goarch: AMD64, musl: true, want: "linux-x64-musl"},
+		{goos: Linux, goarch: ARM64, want: "linux-arm64-static"},
+		{goos: Linux, goarch: ARM64, musl: true, want: "linux-arm64-static"},
+		{
+			goos: Linux, goarch: ARM, musl: true,
+			wantErr: "tool has no release for linux/arm-musl (available: darwin/arm64, linux/amd64-gnu, linux/amd64-musl, linux/arm64)",
+		},
+		{goos: Windows, goarch: AMD64, wantErr: "tool has no release for windows/amd64"},
+	}
+	for _, tt := range tests {
+		got, err := platformAsset("tool", assets, tt.goos, tt.goarch, tt.musl)
+		if tt.wantErr != "" {
+			var unsupported *UnsupportedPlatformError
+			if !errors.As(err, &unsupported) || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("platformAsset(%s/%s, musl=%v) error = %v, want %q", tt.goos, tt.goarch, tt.musl, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("platformAsset(%s/%s, musl=%v) = %q, %v, want %q", tt.goos, tt.goarch, tt.musl, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: MIT
+
+package pockettest
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Capture records task output in memory. Safe for concurrent use.
+type Capture struct {
+	mu     sync.Mutex
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+}
+
+// NewCapture creates an empty Capture.
+func NewCapture() *Capture {
+	return &Capture{}
+}
+
+// Output returns a pocket.Output writing into the capture.
+func (c *Capture) Output() *pocket.Output {
+	return &pocket.Output{
+		Stdout: &captureWriter{mu: &c.mu, buf: &c.stdout},
+		Stderr: &captureWriter{mu: &c.mu, buf: &c.stderr},
+	}
+}
+
+// Stdout returns everything written to stdout so far.
+func (c *Capture) Stdout() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stdout.String()
+}
+
+// Stderr returns everything written to stderr so far.
+func (c *Capture) Stderr() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stderr.String()
+}
+
+type captureWriter struct {
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: MIT
+
+package pockettest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden and
+// AssertGoldenFile rewrite golden files instead of comparing against them.
+//
+//	POCKET_UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "POCKET_UPDATE_GOLDEN"
+
+func updateGolden() bool {
+	return os.Getenv(UpdateGoldenEnv) != ""
+}
+
+// AssertGolden compares every file in fsys with the file of the same path
+// below dir, and reports golden files that fsys no longer contains.
+// With UpdateGoldenEnv set, dir is rewritten to match fsys instead.
+//
+// Example:
+//
+//	fsys, err := pockettest.Render(Config, pockettest.RenderOptions{GoVersion: "1.25.0"})
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	pockettest.AssertGolden(t, fsys, "testdata/generated")
+func AssertGolden(t testing.TB, fsys fs.FS, dir string) {
+	t.Helper()
+
+	var got []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		got = append(got, p)
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		AssertGoldenFile(t, data, filepath.Join(dir, filepath.FromSlash(p)))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk rendered files: %v", err)
+	}
+
+	// Golden files without a rendered counterpart are stale.
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(got, filepath.ToSlash(rel)) {
+			return nil
+		}
+		if updateGolden() {
+			return os.Remove(p)
+		}
+		t.Errorf("golden file %s is no longer generated (run with %s=1 to update)", p, UpdateGoldenEnv)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk golden files: %v", err)
+	}
+}
+
+// AssertGoldenFile compares got with the contents of the golden file at path.
+// With UpdateGoldenEnv set, the golden file is written instead.
+func AssertGoldenFile(t testing.TB, got []byte, path string) {
+	t.Helper()
+
+	if updateGolden() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("read golden file: %v (run with %s=1 to create it)", err, UpdateGoldenEnv)
+		return
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from generated content (run with %s=1 to update)\n%s",
+			path, UpdateGoldenEnv, lineDiff(string(want), string(got)))
+	}
+}
+
+// lineDiff describes the first differing line between want and got.
+func lineDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := range max(len(wantLines), len(gotLines)) {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			return fmt.Sprintf("first difference at line %d:\n- %s\n+ %s", i+1, w, g)
+		}
+	}
+	return ""
+}
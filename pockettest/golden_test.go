@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: MIT
+
+package pockettest_test
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/pockettest"
+	"github.com/fredrikaverpil/pocket/tasks/github"
+)
+
+// recordingTB captures test failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRender(t *testing.T) {
+	autoRun := pocket.Serial(pocket.Task("lint", "lint code", func(ctx context.Context) error { return nil }))
+	cfg := pocket.Config{
+		AutoRun:  autoRun,
+		Generate: []pocket.Runnable{github.WorkflowTask(autoRun, github.MatrixConfig{})},
+		Shim:     &pocket.ShimConfig{Name: "pok", Posix: true, Windows: true},
+	}
+	fsys, err := pockettest.Render(cfg, pockettest.RenderOptions{GoVersion: "1.25.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"pok", "pok.cmd", ".pocket/main.go", ".github/workflows/pr.yml"} {
+		if _, err := fs.Stat(fsys, p); err != nil {
+			t.Errorf("missing %s: %v", p, err)
+		}
+	}
+	// User-owned files are inputs to generate, not outputs.
+	if _, err := fs.Stat(fsys, ".pocket/config.go"); err == nil {
+		t.Error("unexpected .pocket/config.go in rendered files")
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	cfg := pocket.Config{Shim: &pocket.ShimConfig{Name: "pok", Posix: true}}
+	fsys, err := pockettest.Render(cfg, pockettest.RenderOptions{GoVersion: "1.25.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	// Create the golden files.
+	t.Setenv(pockettest.UpdateGoldenEnv, "1")
+	pockettest.AssertGolden(t, fsys, dir)
+	t.Setenv(pockettest.UpdateGoldenEnv, "")
+
+	// Unchanged output matches.
+	pockettest.AssertGolden(t, fsys, dir)
+
+	// Changed and stale golden files are reported.
+	if err := os.WriteFile(filepath.Join(dir, "pok"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stale"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rec := &recordingTB{TB: t}
+	pockettest.AssertGolden(rec, fsys, dir)
+	if len(rec.errors) != 2 {
+		t.Errorf("expected 2 failures, got %d: %q", len(rec.errors), rec.errors)
+	}
+}
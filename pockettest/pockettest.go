@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: MIT
+
+// Package pockettest provides helpers for unit-testing pocket tasks without
+// executing real tools.
+//
+// A Harness runs tasks with a fake command runner that records every command
+// started via pocket.Exec, pocket.ExecIn or pocket.RunCommand and replies
+// with scripted output and exit codes. Tool installs by pocket.InstallGo and
+// pocket.Download are recorded instead of fetched. Task output is captured
+// in memory.
+//
+// Example:
+//
+//	func TestLint(t *testing.T) {
+//	    h := pockettest.New(t, pockettest.WithPath("services/api"))
+//	    h.Runner.On("golangci-lint", "run").Return(pockettest.Response{
+//	        Stdout:   "main.go:1: issue",
+//	        ExitCode: 1,
+//	    })
+//
+//	    if err := h.Run(golang.Lint); err == nil {
+//	        t.Fatal("expected lint failure")
+//	    }
+//	    if got := h.Runner.Installs(); len(got) != 1 {
+//	        t.Errorf("expected golangci-lint to be installed, got %q", got)
+//	    }
+//	    if got := h.Runner.Calls()[0].Name; got != "golangci-lint" {
+//	        t.Errorf("unexpected command: %s", got)
+//	    }
+//	}
+//
+// Commands that tasks build with pocket.Command and start with cmd.Run
+// are not intercepted.
+package pockettest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Harness holds a run context wired to a fake Runner and captured Output.
+type Harness struct {
+	Runner *Runner
+	Output *Capture
+
+	ctx context.Context
+}
+
+// Option configures a Harness.
+type Option func(*harnessConfig)
+
+type harnessConfig struct {
+	path       string
+	verbose    bool
+	configPlan *pocket.ConfigPlan
+}
+
+// WithPath sets the path returned by pocket.Path, relative to git root.
+func WithPath(path string) Option {
+	return func(cfg *harnessConfig) {
+		cfg.path = path
+	}
+}
+
+// WithVerbose enables verbose mode.
+func WithVerbose() Option {
+	return func(cfg *harnessConfig) {
+		cfg.verbose = true
+	}
+}
+
+// WithConfigPlan sets the plan returned by pocket.GetConfigPlan.
+func WithConfigPlan(plan *pocket.ConfigPlan) Option {
+	return func(cfg *harnessConfig) {
+		cfg.configPlan = plan
+	}
+}
+
+// New creates a Harness. Its context is cancelled when the test ends.
+func New(t testing.TB, opts ...Option) *Harness {
+	t.Helper()
+	h := &Harness{
+		Runner: NewRunner(),
+		Output: NewCapture(),
+	}
+	h.ctx = Context(t.Context(), h.Runner, h.Output, opts...)
+	return h
+}
+
+// Context returns a pocket run context using runner and output.
+// Use it to call task functions directly, or use a Harness.
+func Context(parent context.Context, runner *Runner, output *Capture, opts ...Option) context.Context {
+	var cfg harnessConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return pocket.NewRunContext(parent, pocket.RunContextOptions{
+		Output:     output.Output(),
+		Path:       cfg.path,
+		Verbose:    cfg.verbose,
+		Runner:     runner.Run,
+		Installer:  runner.Install,
+		ConfigPlan: cfg.configPlan,
+	})
+}
+
+// Context returns the harness's run context.
+func (h *Harness) Context() context.Context {
+	return h.ctx
+}
+
+// Run runs the task in the harness's context.
+func (h *Harness) Run(task *pocket.TaskDef) error {
+	return task.Run(h.ctx)
+}
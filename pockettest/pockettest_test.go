@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: MIT
+
+package pockettest_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/pockettest"
+	"github.com/fredrikaverpil/pocket/tasks/golang"
+	"github.com/fredrikaverpil/pocket/tools/golangcilint"
+)
+
+var lint = pocket.Task("lint", "run linter", func(ctx context.Context) error {
+	if pocket.Verbose(ctx) {
+		pocket.Printf(ctx, "linting %s\n", pocket.Path(ctx))
+	}
+	if err := pocket.Exec(ctx, "golangci-lint", "run", "./..."); err != nil {
+		return err
+	}
+	return pocket.Exec(ctx, "go", "vet", "./...")
+}, pocket.AsSilent())
+
+func TestHarness_RecordsCommands(t *testing.T) {
+	h := pockettest.New(t, pockettest.WithPath("services/api"), pockettest.WithVerbose())
+
+	if err := h.Run(lint); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"golangci-lint run ./...", "go vet ./..."}
+	if got := h.Runner.Commands(); !slices.Equal(got, want) {
+		t.Errorf("Commands() = %q, want %q", got, want)
+	}
+	if dir := h.Runner.Calls()[0].Dir; !strings.HasSuffix(dir, "services/api") {
+		t.Errorf("Dir = %q, want suffix services/api", dir)
+	}
+	if got := h.Output.Stdout(); got != "linting services/api\n" {
+		t.Errorf("Stdout() = %q", got)
+	}
+}
+
+func TestHarness_ScriptedFailure(t *testing.T) {
+	h := pockettest.New(t)
+	h.Runner.On("golangci-lint", "run").Return(pockettest.Response{
+		Stdout:   "main.go:1: issue\n",
+		ExitCode: 1,
+	})
+
+	err := h.Run(lint)
+	var exitErr *pockettest.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("err = %v, want exit status 1", err)
+	}
+	if got := h.Runner.Commands(); len(got) != 1 {
+		t.Errorf("expected go vet not to run, got %q", got)
+	}
+	if got := h.Output.Stdout(); got != "main.go:1: issue\n" {
+		t.Errorf("Stdout() = %q", got)
+	}
+}
+
+func TestHarness_ToolNotFound(t *testing.T) {
+	h := pockettest.New(t)
+	h.Runner.On("golangci-lint").Return(pockettest.Response{Err: exec.ErrNotFound})
+
+	if err := h.Run(lint); !errors.Is(err, pocket.ErrToolNotFound) {
+		t.Fatalf("err = %v, want ErrToolNotFound", err)
+	}
+}
+
+func TestHarness_BuiltinTaskWithInstall(t *testing.T) {
+	h := pockettest.New(t)
+	h.Runner.On("golangci-lint", "run").Return(pockettest.Response{
+		Stdout:   "main.go:1: issue\n",
+		ExitCode: 1,
+	})
+
+	if err := h.Run(golang.Lint); err == nil {
+		t.Fatal("expected lint failure")
+	}
+	wantInstall := "github.com/golangci/golangci-lint/v2/cmd/golangci-lint@" + golangcilint.Version
+	if got := h.Runner.Installs(); !slices.Equal(got, []string{wantInstall}) {
+		t.Errorf("Installs() = %q, want %q", got, wantInstall)
+	}
+	calls := h.Runner.Calls()
+	if len(calls) != 1 || calls[0].Name != "golangci-lint" || !slices.Contains(calls[0].Args, "./...") {
+		t.Errorf("unexpected commands: %q", h.Runner.Commands())
+	}
+}
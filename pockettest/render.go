@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: MIT
+
+package pockettest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing/fstest"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+	"github.com/fredrikaverpil/pocket/internal/scaffold"
+	"github.com/fredrikaverpil/pocket/internal/shim"
+)
+
+// RenderOptions configures Render.
+type RenderOptions struct {
+	// GoVersion is written into the shims.
+	// Default: the version in .pocket/go.mod.
+	GoVersion string
+
+	// GoChecksums are written into the shims, keyed by "os-arch".
+	// Default: none. Pocket normally fetches these from go.dev; leaving them
+	// empty keeps snapshots stable and offline.
+	GoChecksums map[string]string
+}
+
+// userFiles are read by ./pok generate but never overwritten by it.
+// Render copies them from the real .pocket directory and leaves them out
+// of the result.
+var userFiles = []string{
+	".pocket/go.mod",
+	".pocket/config.go",
+	".pocket/.gitignore",
+}
+
+// Render runs ./pok generate for cfg and returns the files it writes as an
+// in-memory FS, without touching the working tree. Paths are relative to
+// the git root: ".pocket/main.go", the shims in each module directory, and
+// whatever cfg.Generate writes (e.g. ".github/workflows/pr.yml").
+//
+// Module directories are detected in the real git root. Generation itself
+// runs against a temporary git root, with commands and tool installs
+// recorded by a fake Runner. Render redirects pocket's git root while it
+// runs, so it must not run in parallel with other tests that use pocket.
+//
+// Compare the result against checked-in files with AssertGolden.
+func Render(cfg pocket.Config, opts RenderOptions) (fs.FS, error) {
+	pocket.RegisterGenerateAll(scaffold.GenerateAll)
+	cfg = cfg.WithDefaults()
+	plan := pocket.BuildConfigPlan(cfg)
+	var generate *pocket.TaskDef
+	for _, task := range plan.BuiltinTasks {
+		if task.Name() == "generate" {
+			generate = task
+		}
+	}
+	if generate == nil {
+		return nil, errors.New("generate task not found")
+	}
+
+	root, err := os.MkdirTemp("", "pockettest-render-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(root)
+	if err := seedUserFiles(root, opts.GoVersion); err != nil {
+		return nil, err
+	}
+
+	restoreRoot := gitroot.Override(root)
+	defer restoreRoot()
+	restoreChecksums := shim.OverrideGoChecksums(opts.GoChecksums)
+	defer restoreChecksums()
+
+	output := NewCapture()
+	ctx := Context(context.Background(), NewRunner(), output, WithConfigPlan(plan))
+	if err := generate.Run(ctx); err != nil {
+		return nil, fmt.Errorf("generate: %w\n%s", err, output.Stderr())
+	}
+	return collect(root)
+}
+
+// seedUserFiles copies userFiles from the real .pocket directory into root.
+// A non-empty goVersion replaces .pocket/go.mod.
+func seedUserFiles(root, goVersion string) error {
+	if err := os.MkdirAll(filepath.Join(root, pocket.DirName), 0o755); err != nil {
+		return err
+	}
+	for _, name := range userFiles {
+		data, err := os.ReadFile(pocket.FromGitRoot(filepath.FromSlash(name)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), data, 0o644); err != nil {
+			return err
+		}
+	}
+	if goVersion != "" {
+		gomod := "module pocket\n\ngo " + goVersion + "\n"
+		return os.WriteFile(filepath.Join(root, pocket.DirName, "go.mod"), []byte(gomod), 0o644)
+	}
+	return nil
+}
+
+// collect reads the files below root, except userFiles, into a MapFS.
+func collect(root string) (fs.FS, error) {
+	skip := make(map[string]bool, len(userFiles))
+	for _, name := range userFiles {
+		skip[name] = true
+	}
+	fsys := fstest.MapFS{}
+	err := fs.WalkDir(os.DirFS(root), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || skip[path] {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+		if err != nil {
+			return err
+		}
+		fsys[path] = &fstest.MapFile{Data: data, Mode: info.Mode().Perm()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fsys, nil
+}
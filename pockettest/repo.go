@@ -0,0 +1,261 @@
+// SPDX-License-Identifier: MIT
+
+package pockettest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/shim"
+	"github.com/fredrikaverpil/pocket/internal/testenv"
+)
+
+// Repo is a temporary git repository for end-to-end tests of pocket itself
+// or of a project's pocket config. It runs the real pocket CLI and shims as
+// subprocesses, offline:
+//
+//   - pocket init uses the pocket source this package was built from
+//   - Go modules resolve from the local module cache (GOPROXY=off)
+//   - tool downloads are served by Repo.Mirror instead of the network
+//
+// The pocket CLI and the shims' runner are built with the "pockettest"
+// build tag, which makes them honour these redirects. Regular pocket builds
+// ignore them.
+//
+// Example:
+//
+//	func TestInit(t *testing.T) {
+//	    repo := pockettest.NewRepo(t)
+//	    repo.Init()
+//	    repo.Pok("generate")
+//	    if !repo.Exists(".pocket/main.go") {
+//	        t.Fatal("main.go not generated")
+//	    }
+//	}
+type Repo struct {
+	// Dir is the repository root.
+	Dir string
+	// Mirror serves tool downloads for the repo's commands.
+	Mirror *Mirror
+
+	t   testing.TB
+	env []string
+}
+
+// NewRepo creates an empty git repository in a temp directory.
+// It skips the test in -short mode, since commands build and run Go code.
+func NewRepo(t testing.TB) *Repo {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping end-to-end test in short mode")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	dir := t.TempDir()
+	r := &Repo{Dir: dir, t: t}
+	r.Mirror = newMirror(t, r)
+	r.env = append(os.Environ(),
+		"GOPROXY=off",
+		"GOSUMDB=off",
+		"GOFLAGS=-mod=mod -tags="+testenv.BuildTag,
+		"GOTOOLCHAIN=local",
+		"GIT_CONFIG_GLOBAL="+filepath.Join(dir, ".git", "test-gitconfig"),
+		testenv.MirrorEnv+"="+r.Mirror.URL(),
+		testenv.ReplaceEnv+"="+sourceDir(),
+	)
+	r.Run("git", "init", "-q")
+	r.Run("git", "config", "user.email", "pockettest@example.com")
+	r.Run("git", "config", "user.name", "pockettest")
+	return r
+}
+
+// Init runs "pocket init" with args, e.g. "-repair", in the repository,
+// using the bootstrap CLI in cmd/pocket built from the pocket source.
+func (r *Repo) Init(args ...string) {
+	r.t.Helper()
+	r.Run(pocketCLI(r.t), append([]string{"init"}, args...)...)
+}
+
+// Pok runs the repository's root shim with args and returns its combined
+// output. The test fails if the shim fails.
+func (r *Repo) Pok(args ...string) string {
+	r.t.Helper()
+	return r.PokIn(".", args...)
+}
+
+// PokIn runs the shim in dir, relative to the repository root.
+// The test fails if the shim fails.
+func (r *Repo) PokIn(dir string, args ...string) string {
+	r.t.Helper()
+	out, err := r.PokErr(dir, args...)
+	if err != nil {
+		r.t.Fatalf("%s/pok %s: %v\n%s", dir, strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// PokErr is like PokIn but returns the error instead of failing the test.
+func (r *Repo) PokErr(dir string, args ...string) (string, error) {
+	shim := filepath.Join(r.Dir, filepath.FromSlash(dir), "pok")
+	if runtime.GOOS == pocket.Windows {
+		shim += ".cmd"
+	}
+	return r.runIn(dir, shim, args...)
+}
+
+// Run runs a command in the repository and returns its combined output.
+// The test fails if the command fails.
+func (r *Repo) Run(name string, args ...string) string {
+	r.t.Helper()
+	out, err := r.RunErr(name, args...)
+	if err != nil {
+		r.t.Fatalf("%s %s: %v\n%s", name, strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// RunErr is like Run but returns the error instead of failing the test.
+func (r *Repo) RunErr(name string, args ...string) (string, error) {
+	return r.runIn(".", name, args...)
+}
+
+func (r *Repo) runIn(dir, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(r.t.Context(), name, args...)
+	cmd.Dir = filepath.Join(r.Dir, filepath.FromSlash(dir))
+	cmd.Env = r.env
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// WriteFile writes a file relative to the repository root.
+func (r *Repo) WriteFile(path, content string) {
+	r.t.Helper()
+	p := filepath.Join(r.Dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// ReadFile reads a file relative to the repository root.
+func (r *Repo) ReadFile(path string) string {
+	r.t.Helper()
+	data, err := os.ReadFile(filepath.Join(r.Dir, filepath.FromSlash(path)))
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return string(data)
+}
+
+// Exists reports whether a path exists relative to the repository root.
+func (r *Repo) Exists(path string) bool {
+	_, err := os.Stat(filepath.Join(r.Dir, filepath.FromSlash(path)))
+	return err == nil
+}
+
+var (
+	cliOnce sync.Once
+	cliPath string
+	cliErr  error
+	cliOut  []byte
+)
+
+// pocketCLI builds cmd/pocket from the pocket source once per test binary.
+func pocketCLI(t testing.TB) string {
+	t.Helper()
+	cliOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "pockettest-cli-*")
+		if err != nil {
+			cliErr = err
+			return
+		}
+		cliPath = filepath.Join(dir, pocket.BinaryName("pocket"))
+		cmd := exec.Command("go", "build", "-tags", testenv.BuildTag, "-o", cliPath, "./cmd/pocket")
+		cmd.Dir = sourceDir()
+		cliOut, cliErr = cmd.CombinedOutput()
+	})
+	if cliErr != nil {
+		t.Fatalf("build pocket CLI: %v\n%s", cliErr, cliOut)
+	}
+	return cliPath
+}
+
+// sourceDir returns the root of the pocket module this package belongs to.
+func sourceDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(filepath.Dir(file))
+}
+
+// Mirror is a fake download server. Commands run by a Repo fetch every
+// download from it. Requests for URLs that
+// were not registered fail the test, so nothing reaches the network.
+type Mirror struct {
+	srv   *httptest.Server
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newMirror(t testing.TB, repo *Repo) *Mirror {
+	m := &Mirror{files: make(map[string][]byte)}
+	m.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key := strings.TrimPrefix(req.URL.RequestURI(), "/")
+		m.mu.Lock()
+		body, ok := m.files[key]
+		m.mu.Unlock()
+		switch {
+		case ok:
+			w.Write(body)
+		case key == mirrorPath(shim.GoDownloadsURL):
+			w.Write(goReleases(repo.Dir))
+		default:
+			t.Errorf("pockettest: unexpected download of https://%s", key)
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+// URL returns the mirror's base URL.
+func (m *Mirror) URL() string {
+	return m.srv.URL
+}
+
+// Serve registers the response body for downloads of rawURL.
+func (m *Mirror) Serve(rawURL string, body []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[mirrorPath(rawURL)] = body
+}
+
+// mirrorPath returns the path and query below the mirror root under which
+// rawURL is requested.
+func mirrorPath(rawURL string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(rawURL, "https://"), "http://")
+}
+
+// goReleases answers the Go downloads API with the repo's Go version and no
+// checksums, so shims can be generated offline.
+func goReleases(dir string) []byte {
+	version, err := pocket.GoVersionFromDir(filepath.Join(dir, pocket.DirName))
+	if err != nil {
+		version = strings.TrimPrefix(runtime.Version(), "go")
+	}
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode([]map[string]any{{"version": "go" + version, "files": []any{}}})
+	return buf.Bytes()
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: MIT
+
+package pockettest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket/pockettest"
+)
+
+func TestRepo_InitAndGenerate(t *testing.T) {
+	repo := pockettest.NewRepo(t)
+	repo.Init()
+
+	for _, p := range []string{".pocket/go.mod", ".pocket/main.go", ".pocket/config.go", ".pocket/.gitignore", "pok"} {
+		if !repo.Exists(p) {
+			t.Errorf("init did not create %s", p)
+		}
+	}
+	if out := repo.Pok("-h"); !strings.Contains(out, "generate") {
+		t.Errorf("help does not list built-in tasks:\n%s", out)
+	}
+
+	// Add a task and a module directory, then regenerate shims.
+	repo.WriteFile(".pocket/config.go", `package main
+
+import (
+	"context"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+var Config = pocket.Config{
+	ManualRun: []pocket.Runnable{
+		pocket.RunIn(pocket.Task("hello", "say hello", func(ctx context.Context) error {
+			pocket.Printf(ctx, "hello from %s\n", pocket.Path(ctx))
+			return nil
+		}), pocket.Include("services/api")),
+		pocket.Task("fetch", "download a tool", pocket.Download("https://example.com/tool.txt",
+			pocket.WithDestDir(pocket.FromToolsDir("fake")),
+		)),
+	},
+}
+`)
+	repo.Pok("generate")
+	if !repo.Exists("services/api/pok") {
+		t.Error("generate did not create services/api/pok")
+	}
+	if out := repo.PokIn("services/api", "hello"); !strings.Contains(out, "hello from") {
+		t.Errorf("unexpected hello output:\n%s", out)
+	}
+
+	// Tool downloads are served by the mirror.
+	repo.Mirror.Serve("https://example.com/tool.txt", []byte("fake tool"))
+	repo.Pok("fetch")
+	if !repo.Exists(".pocket/tools/fake") {
+		t.Error("fetch did not download into .pocket/tools/fake")
+	}
+}
+
+func TestRepo_InitRepair(t *testing.T) {
+	repo := pockettest.NewRepo(t)
+	repo.Init()
+
+	const config = "package main\n\n// Edited by the user.\n\nimport \"github.com/fredrikaverpil/pocket\"\n\nvar Config = pocket.Config{}\n"
+	repo.WriteFile(".pocket/config.go", config)
+	for _, p := range []string{"pok", ".pocket/.gitignore", ".pocket/main.go"} {
+		if err := os.Remove(filepath.Join(repo.Dir, p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo.Init("-repair")
+	for _, p := range []string{"pok", ".pocket/.gitignore", ".pocket/main.go"} {
+		if !repo.Exists(p) {
+			t.Errorf("init -repair did not recreate %s", p)
+		}
+	}
+	if got := repo.ReadFile(".pocket/config.go"); got != config {
+		t.Errorf("init -repair changed config.go:\n%s", got)
+	}
+	if out := repo.Pok("-h"); !strings.Contains(out, "generate") {
+		t.Errorf("repaired shim does not run:\n%s", out)
+	}
+}
+
+func TestRepo_InitModules(t *testing.T) {
+	repo := pockettest.NewRepo(t)
+	repo.WriteFile("services/api/go.mod", "module example.com/api\n\ngo 1.21\n")
+	repo.WriteFile("services/web/go.mod", "module example.com/web\n\ngo 1.21\n")
+	repo.Init("-module", "services/api", "-module", "services/web")
+
+	for _, p := range []string{"pok", "services/api/pok", "services/web/pok"} {
+		if !repo.Exists(p) {
+			t.Errorf("init -module did not create %s", p)
+		}
+	}
+	if got := repo.ReadFile(".pocket/config.go"); !strings.Contains(got, `"services/api",`) || !strings.Contains(got, `"services/web",`) {
+		t.Errorf("config.go does not include the modules:\n%s", got)
+	}
+
+	// The shims are kept when regenerated from config.go.
+	if err := os.Remove(filepath.Join(repo.Dir, "services/web/pok")); err != nil {
+		t.Fatal(err)
+	}
+	repo.Pok("generate")
+	if !repo.Exists("services/web/pok") {
+		t.Error("generate did not recreate services/web/pok")
+	}
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: MIT
+
+package pockettest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Call is a recorded command invocation.
+type Call struct {
+	Name string   // command name, without directory or .exe suffix
+	Args []string // arguments, excluding the command name
+	Dir  string   // working directory
+	Env  []string // environment
+}
+
+// String returns the command line, e.g. "go test ./...".
+func (c Call) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Response is the scripted result of a command.
+type Response struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int   // non-zero returns an *ExitError
+	Err      error // returned as is, e.g. exec.ErrNotFound; overrides ExitCode
+}
+
+// ExitError is returned for responses with a non-zero ExitCode.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Runner is a fake pocket.CommandRunner. It records every command and
+// replies with the response of the first matching stub, or succeeds
+// silently if no stub matches. It is also a fake pocket.Installer that
+// records tool installs without fetching anything. Safe for concurrent use.
+type Runner struct {
+	mu       sync.Mutex
+	calls    []Call
+	stubs    []*Stub
+	installs []string
+}
+
+// Stub matches commands by name and argument prefix.
+type Stub struct {
+	name string
+	args []string
+	resp Response
+}
+
+// NewRunner creates a Runner with no stubs.
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+// On adds a stub for commands named name whose arguments start with args.
+// Stubs are matched in the order they were added.
+func (r *Runner) On(name string, args ...string) *Stub {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := &Stub{name: name, args: args}
+	r.stubs = append(r.stubs, s)
+	return s
+}
+
+// Return sets the stub's response.
+func (s *Stub) Return(resp Response) {
+	s.resp = resp
+}
+
+func (s *Stub) matches(c Call) bool {
+	return s.name == c.Name && len(c.Args) >= len(s.args) && slices.Equal(c.Args[:len(s.args)], s.args)
+}
+
+// Run records cmd and writes the scripted response to its output.
+// It satisfies pocket.CommandRunner.
+func (r *Runner) Run(cmd *exec.Cmd) error {
+	call := Call{
+		Name: commandName(cmd),
+		Args: slices.Clone(cmd.Args[1:]),
+		Dir:  cmd.Dir,
+		Env:  slices.Clone(cmd.Env),
+	}
+
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	var resp Response
+	for _, s := range r.stubs {
+		if s.matches(call) {
+			resp = s.resp
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	if resp.Stdout != "" && cmd.Stdout != nil {
+		io.WriteString(cmd.Stdout, resp.Stdout)
+	}
+	if resp.Stderr != "" && cmd.Stderr != nil {
+		io.WriteString(cmd.Stderr, resp.Stderr)
+	}
+	switch {
+	case resp.Err != nil:
+		return resp.Err
+	case resp.ExitCode != 0:
+		return &ExitError{Code: resp.ExitCode}
+	}
+	return nil
+}
+
+// Install records a tool install by pocket.InstallGo or pocket.Download
+// and succeeds. It satisfies pocket.Installer.
+func (r *Runner) Install(_ context.Context, source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.installs = append(r.installs, source)
+	return nil
+}
+
+// Installs returns the recorded install sources in invocation order:
+// "<pkg>@<version>" for pocket.InstallGo and the URL for pocket.Download.
+func (r *Runner) Installs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.installs)
+}
+
+// Calls returns the recorded commands in invocation order.
+func (r *Runner) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// Commands returns the recorded command lines, see Call.String.
+func (r *Runner) Commands() []string {
+	calls := r.Calls()
+	lines := make([]string, len(calls))
+	for i, c := range calls {
+		lines[i] = c.String()
+	}
+	return lines
+}
+
+// commandName returns the command name as the task passed it, even if
+// pocket resolved it to a binary in .pocket/bin.
+func commandName(cmd *exec.Cmd) string {
+	name := cmd.Path
+	if len(cmd.Args) > 0 {
+		name = cmd.Args[0]
+	}
+	return strings.TrimSuffix(filepath.Base(name), ".exe")
+}
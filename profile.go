@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// profileFile is where -profile writes the timeline of the run, relative to
+// the .pocket directory.
+const profileFile = "profile.json"
+
+// traceEvent is a complete event ("ph":"X") of the Chrome trace event
+// format, which chrome://tracing and ui.perfetto.dev open. Times are in
+// microseconds since the start of the run.
+type traceEvent struct {
+	Name     string         `json:"name"`
+	Category string         `json:"cat"`
+	Phase    string         `json:"ph"`
+	Start    int64          `json:"ts"`
+	Duration int64          `json:"dur"`
+	PID      int            `json:"pid"`
+	TID      int            `json:"tid"`
+	Args     map[string]any `json:"args,omitempty"`
+}
+
+// profile is the trace file written by -profile.
+type profile struct {
+	TraceEvents     []traceEvent `json:"traceEvents"`
+	DisplayTimeUnit string       `json:"displayTimeUnit"`
+}
+
+// writeProfile writes the tasks and commands of the run to path as a Chrome
+// trace, one slice per task run and per command. The items of Parallel
+// groups are on lanes (threads) of their own.
+func (l *runLog) writeProfile(path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p := profile{TraceEvents: []traceEvent{}, DisplayTimeUnit: "ms"}
+	add := func(e runLogEntry, category, dir string) {
+		if e.end.IsZero() {
+			return
+		}
+		args := map[string]any{"path": cmp.Or(dir, "."), "exit_code": e.exitCode}
+		if e.err != nil {
+			args["error"] = e.err.Error()
+		}
+		p.TraceEvents = append(p.TraceEvents, traceEvent{
+			Name:     e.name,
+			Category: category,
+			Phase:    "X",
+			Start:    e.end.Add(-e.duration).Sub(l.start).Microseconds(),
+			Duration: e.duration.Microseconds(),
+			PID:      1,
+			TID:      e.lane + 1,
+			Args:     args,
+		})
+	}
+	for _, t := range l.tasks {
+		category := "task"
+		if t.hidden {
+			category = "hidden"
+		}
+		add(t, category, t.path)
+	}
+	root := GitRoot()
+	for _, c := range l.commands {
+		dir := c.path
+		if rel, err := filepath.Rel(root, c.path); err == nil {
+			dir = filepath.ToSlash(rel)
+		}
+		add(c, "command", dir)
+	}
+	slices.SortStableFunc(p.TraceEvents, func(a, b traceEvent) int {
+		// Enclosing events first, so that trace viewers nest the others.
+		return cmp.Or(cmp.Compare(a.Start, b.Start), cmp.Compare(b.Duration, a.Duration))
+	})
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("write profile: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write profile: %w", err)
+	}
+	return nil
+}
+
+// acquireLane returns a timeline lane for an item of a Parallel group that
+// runs alongside the others. Tasks and commands on a lane run one after
+// another or inside each other, as trace viewers require; lane 0 is the
+// lane of the run.
+func (l *runLog) acquireLane() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n := len(l.free); n > 0 {
+		lane := l.free[n-1]
+		l.free = l.free[:n-1]
+		return lane
+	}
+	l.lanes++
+	return l.lanes
+}
+
+// releaseLane frees a lane returned by acquireLane for reuse.
+func (l *runLog) releaseLane(lane int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.free = append(l.free, lane)
+}
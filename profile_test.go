@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestWriteProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	defer gitroot.Override(tmpDir)()
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	l := &runLog{start: start}
+	// all runs go-test in two paths in parallel; one of them runs a command.
+	l.tasks = []runLogEntry{
+		{name: "go-test", path: "a", duration: 60 * time.Millisecond, end: at(70)},
+		{name: "go-test", path: "b", lane: 1, duration: 80 * time.Millisecond, end: at(90), exitCode: 1, err: errors.New("exit status 1")},
+		{name: "all", path: ".", duration: 100 * time.Millisecond, end: at(100)},
+		{name: "go-lint", path: ".", cached: true},
+	}
+	l.commands = []runLogEntry{
+		{name: "go test ./...", path: filepath.Join(tmpDir, "a"), duration: 50 * time.Millisecond, end: at(65)},
+	}
+
+	path := filepath.Join(tmpDir, ".pocket", profileFile)
+	if err := l.writeProfile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatalf("invalid profile: %v\n%s", err, data)
+	}
+
+	type slice struct {
+		name, path string
+		start, dur int64
+		tid        int
+	}
+	want := []slice{
+		{"all", ".", 0, 100000, 1},
+		{"go-test", "b", 10000, 80000, 2},
+		{"go-test", "a", 10000, 60000, 1},
+		{"go test ./...", "a", 15000, 50000, 1},
+	}
+	if len(p.TraceEvents) != len(want) {
+		t.Fatalf("got %d events, want %d:\n%s", len(p.TraceEvents), len(want), data)
+	}
+	for i, e := range p.TraceEvents {
+		got := slice{e.Name, e.Args["path"].(string), e.Start, e.Duration, e.TID}
+		if got != want[i] || e.Phase != "X" {
+			t.Errorf("event %d = %+v (%s), want %+v", i, got, e.Phase, want[i])
+		}
+	}
+	if p.TraceEvents[1].Args["error"] != "exit status 1" {
+		t.Errorf("failed task args = %v", p.TraceEvents[1].Args)
+	}
+}
+
+func TestRunLogLanes(t *testing.T) {
+	// The tasks wait for each other, so that they all run at once.
+	var started sync.WaitGroup
+	started.Add(3)
+	body := func(_ context.Context) error {
+		started.Done()
+		started.Wait()
+		return nil
+	}
+	rl := &runLog{start: time.Now()}
+	group := Parallel(Task("a", "a", body), Task("b", "b", body), Task("c", "c", body))
+	if err := runWithContext(context.Background(), group, discardOutput(), ".", false, nil, rl); err != nil {
+		t.Fatal(err)
+	}
+	lanes := make(map[string]int)
+	for _, e := range rl.tasks {
+		lanes[e.name] = e.lane
+	}
+	if lanes["a"] != 0 || lanes["b"] == 0 || lanes["c"] == 0 || lanes["b"] == lanes["c"] {
+		t.Errorf("lanes = %v, want a on the run's lane and b and c on lanes of their own", lanes)
+	}
+}
@@ -0,0 +1,257 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fredrikaverpil/pocket/internal/prcomment"
+)
+
+// PRCommentConfig controls the pull request comment that summarizes a run
+// in GitHub Actions: failed tasks, Go coverage and SARIF finding counts.
+// The comment is posted once per task and path, with a section per runner
+// OS, and updated by later runs instead of duplicated. It requires GITHUB_TOKEN with
+// pull-requests: write permission in the environment of the run.
+type PRCommentConfig struct {
+	// Coverage is the Go coverage profile to summarize, relative to the git
+	// root. Default: "coverage.out" (written by go-test).
+	Coverage string
+
+	// BaseCoverage is a coverage profile of the base branch, relative to
+	// the git root, e.g. restored from a cache or artifact. When it exists,
+	// the comment shows the coverage delta.
+	BaseCoverage string
+
+	// SkipCoverage leaves coverage out of the comment.
+	SkipCoverage bool
+
+	// SkipFindings leaves SARIF finding counts out of the comment. Findings
+	// are counted from the reports in $POK_SARIF_DIR.
+	SkipFindings bool
+}
+
+// runSummary is the data of a pull request run comment.
+type runSummary struct {
+	Task     string
+	Path     string
+	OS       string
+	RunURL   string
+	Tasks    []runLogEntry
+	Err      error
+	Coverage *coverageSummary
+	Findings []findingCount
+}
+
+// coverageSummary is the statement coverage of a run, in percent.
+type coverageSummary struct {
+	Percent float64
+	Base    float64
+	HasBase bool
+}
+
+// findingCount is the number of SARIF results reported by a tool.
+type findingCount struct {
+	Tool  string
+	Count int
+}
+
+// postRunComment posts or updates the pull request comment for the run,
+// if running in GitHub Actions for a pull request with a token.
+func postRunComment(ctx context.Context, cfg PRCommentConfig, s runSummary) error {
+	pr, ok := prcomment.Current(os.Getenv)
+	if !ok {
+		return nil
+	}
+	s.OS = os.Getenv("RUNNER_OS")
+	s.RunURL = githubRunURL()
+	if !cfg.SkipCoverage {
+		s.Coverage = runCoverage(cfg)
+	}
+	if !cfg.SkipFindings {
+		if dir := os.Getenv("POK_SARIF_DIR"); dir != "" {
+			if !filepath.IsAbs(dir) {
+				dir = FromGitRoot(dir)
+			}
+			s.Findings = sarifFindingCounts(dir)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	key := "run:" + s.Task + ":" + s.Path
+	return pr.UpsertSection(ctx, key, s.OS, s.markdown())
+}
+
+// runCoverage returns the coverage of the profiles in cfg, or nil if
+// there is no coverage profile.
+func runCoverage(cfg PRCommentConfig) *coverageSummary {
+	profile := cfg.Coverage
+	if profile == "" {
+		profile = "coverage.out"
+	}
+	percent, ok := coverageTotal(FromGitRoot(profile))
+	if !ok {
+		return nil
+	}
+	c := &coverageSummary{Percent: percent}
+	if cfg.BaseCoverage != "" {
+		c.Base, c.HasBase = coverageTotal(FromGitRoot(cfg.BaseCoverage))
+	}
+	return c
+}
+
+// coverageTotal returns the percentage of statements covered in a Go
+// coverage profile. Blocks listed more than once, as in profiles merged
+// from several packages, count once.
+func coverageTotal(path string) (float64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	type block struct{ stmts, count int }
+	blocks := map[string]block{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		stmts, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		b := blocks[fields[0]]
+		blocks[fields[0]] = block{stmts: stmts, count: max(b.count, count)}
+	}
+	var total, covered int
+	for _, b := range blocks {
+		total += b.stmts
+		if b.count > 0 {
+			covered += b.stmts
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return float64(covered) / float64(total) * 100, true
+}
+
+// sarifFindingCounts counts the results per tool in the SARIF files in dir,
+// sorted by tool name.
+func sarifFindingCounts(dir string) []findingCount {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.sarif"))
+	counts := map[string]int{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var log struct {
+			Runs []struct {
+				Tool struct {
+					Driver struct {
+						Name string `json:"name"`
+					} `json:"driver"`
+				} `json:"tool"`
+				Results []json.RawMessage `json:"results"`
+			} `json:"runs"`
+		}
+		if err := json.Unmarshal(data, &log); err != nil {
+			continue
+		}
+		for _, run := range log.Runs {
+			name := run.Tool.Driver.Name
+			if name == "" {
+				name = strings.TrimSuffix(filepath.Base(file), ".sarif")
+			}
+			counts[name] += len(run.Results)
+		}
+	}
+	findings := make([]findingCount, 0, len(counts))
+	for tool, n := range counts {
+		findings = append(findings, findingCount{Tool: tool, Count: n})
+	}
+	slices.SortFunc(findings, func(a, b findingCount) int { return strings.Compare(a.Tool, b.Tool) })
+	return findings
+}
+
+// markdown formats the summary as a pull request comment.
+func (s runSummary) markdown() string {
+	var b strings.Builder
+	var failed []runLogEntry
+	var skipped []string
+	run := 0
+	for _, t := range s.Tasks {
+		switch {
+		case t.skipped:
+			if name := "`" + t.name + "`"; !slices.Contains(skipped, name) {
+				skipped = append(skipped, name)
+			}
+			continue
+		case t.err != nil:
+			failed = append(failed, t)
+		}
+		run++
+	}
+	if s.Err == nil {
+		fmt.Fprintf(&b, "### :white_check_mark: pocket %s passed\n\n", s.Task)
+	} else {
+		fmt.Fprintf(&b, "### :x: pocket %s failed\n\n", s.Task)
+	}
+	var where []string
+	if s.Path != "" && s.Path != "." {
+		where = append(where, "in `"+s.Path+"`")
+	}
+	if s.OS != "" {
+		where = append(where, "on "+s.OS)
+	}
+	fmt.Fprintf(&b, "%d task(s) run", run)
+	if len(where) > 0 {
+		b.WriteString(" " + strings.Join(where, " "))
+	}
+	if s.RunURL != "" {
+		fmt.Fprintf(&b, " ([logs](%s))", s.RunURL)
+	}
+	b.WriteString(".\n")
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "\nSkipped: %s\n", strings.Join(skipped, ", "))
+	}
+
+	if len(failed) > 0 {
+		b.WriteString("\n| Failed task | Path | Exit code | Duration |\n|---|---|---:|---:|\n")
+		for _, t := range failed {
+			fmt.Fprintf(&b, "| `%s` | `%s` | %d | %s |\n", t.name, t.path, t.exitCode, t.duration.Round(time.Millisecond))
+		}
+	}
+	if c := s.Coverage; c != nil {
+		fmt.Fprintf(&b, "\n**Coverage:** %.1f%%", c.Percent)
+		if c.HasBase {
+			fmt.Fprintf(&b, " (%+.1f%% compared to base)", c.Percent-c.Base)
+		}
+		b.WriteString("\n")
+	}
+	if len(s.Findings) > 0 {
+		parts := make([]string, len(s.Findings))
+		for i, f := range s.Findings {
+			parts[i] = fmt.Sprintf("%s: %d", f.Tool, f.Count)
+		}
+		fmt.Fprintf(&b, "\n**Findings:** %s\n", strings.Join(parts, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoverageTotal(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "coverage.out")
+	content := `mode: atomic
+example.com/app/a.go:3.14,5.2 2 1
+example.com/app/a.go:7.14,9.2 3 0
+example.com/app/b.go:3.14,5.2 5 0
+example.com/app/b.go:3.14,5.2 5 4
+`
+	if err := os.WriteFile(profile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := coverageTotal(profile)
+	if !ok || got != 70 {
+		t.Errorf("coverageTotal() = %v, %v, want 70, true", got, ok)
+	}
+	if _, ok := coverageTotal(filepath.Join(t.TempDir(), "missing.out")); ok {
+		t.Error("coverageTotal() of missing profile = true, want false")
+	}
+}
+
+func TestSARIFFindingCounts(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go-vulncheck.sarif": `{"runs":[{"tool":{"driver":{"name":"govulncheck"}},"results":[{},{}]}]}`,
+		"image-scan.sarif":   `{"runs":[{"tool":{"driver":{"name":"Grype"}},"results":[]}]}`,
+		"custom.sarif":       `{"runs":[{"results":[{}]}]}`,
+		"broken.sarif":       `{`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := sarifFindingCounts(dir)
+	want := []findingCount{{"Grype", 0}, {"custom", 1}, {"govulncheck", 2}}
+	if len(got) != len(want) {
+		t.Fatalf("sarifFindingCounts() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sarifFindingCounts()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRunSummaryMarkdown(t *testing.T) {
+	s := runSummary{
+		Task:   "all",
+		Path:   "services/api",
+		OS:     "Linux",
+		RunURL: "https://github.com/org/repo/actions/runs/1",
+		Tasks: []runLogEntry{
+			{name: "go-lint", path: "services/api", duration: 1500 * time.Millisecond},
+			{name: "go-test", path: "services/api", duration: 2 * time.Second, exitCode: 1, err: errors.New("exit status 1")},
+		},
+		Err:      errors.New("exit status 1"),
+		Coverage: &coverageSummary{Percent: 81.25, Base: 80, HasBase: true},
+		Findings: []findingCount{{"govulncheck", 2}},
+	}
+	want := "### :x: pocket all failed\n\n" +
+		"2 task(s) run in `services/api` on Linux ([logs](https://github.com/org/repo/actions/runs/1)).\n\n" +
+		"| Failed task | Path | Exit code | Duration |\n|---|---|---:|---:|\n" +
+		"| `go-test` | `services/api` | 1 | 2s |\n\n" +
+		"**Coverage:** 81.2% (+1.2% compared to base)\n\n" +
+		"**Findings:** govulncheck: 2\n"
+	if got := s.markdown(); got != want {
+		t.Errorf("markdown() =\n%s\nwant\n%s", got, want)
+	}
+
+	s = runSummary{Task: "go-test", Path: ".", Tasks: s.Tasks[:1]}
+	if got := s.markdown(); !strings.HasPrefix(got, "### :white_check_mark: pocket go-test passed\n\n1 task(s) run.\n") {
+		t.Errorf("markdown() = %q", got)
+	}
+
+	s.Tasks = append(s.Tasks,
+		runLogEntry{name: "go-vulncheck", path: "a", skipped: true},
+		runLogEntry{name: "go-vulncheck", path: "b", skipped: true})
+	if got := s.markdown(); !strings.Contains(got, "1 task(s) run.\n\nSkipped: `go-vulncheck`\n") {
+		t.Errorf("markdown() = %q", got)
+	}
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// quarantineAttr is the extended attribute macOS Gatekeeper checks before
+// running a downloaded file for the first time.
+const quarantineAttr = "com.apple.quarantine"
+
+// clearQuarantineEnabled reports whether Config.ClearQuarantine is set for
+// the run.
+func clearQuarantineEnabled(ctx context.Context) bool {
+	plan := GetConfigPlan(ctx)
+	return runtime.GOOS == Darwin && plan != nil && plan.Config != nil && plan.Config.ClearQuarantine
+}
+
+// clearQuarantine removes the quarantine attribute from the files below dir
+// and, if binary is not empty, ad-hoc signs it when its code signature
+// doesn't verify. See Config.ClearQuarantine.
+func clearQuarantine(ctx context.Context, dir, binary string) error {
+	ec := getExecContext(ctx)
+
+	// xattr fails for files without the attribute, which is the common
+	// case, so its exit status is ignored.
+	_ = runCommand(ec, newCommand(ctx, "xattr", "-r", "-d", quarantineAttr, dir))
+
+	if binary == "" {
+		return nil
+	}
+	if err := runCommand(ec, newCommand(ctx, "codesign", "--verify", binary)); err == nil {
+		return nil
+	}
+	if Verbose(ctx) {
+		Printf(ctx, "  Ad-hoc signing %s\n", binary)
+	}
+	if err := runCommand(ec, newCommand(ctx, "codesign", "--force", "--sign", "-", binary)); err != nil {
+		return fmt.Errorf("ad-hoc sign %s: %w", binary, err)
+	}
+	return nil
+}
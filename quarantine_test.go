@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestClearQuarantine(t *testing.T) {
+	tests := []struct {
+		name     string
+		binary   string
+		verifyOK bool
+		want     []string
+	}{
+		{
+			name: "no binary",
+			want: []string{"xattr -r -d com.apple.quarantine /tools/x"},
+		},
+		{
+			name:     "valid signature",
+			binary:   "/tools/x/bin/x",
+			verifyOK: true,
+			want: []string{
+				"xattr -r -d com.apple.quarantine /tools/x",
+				"codesign --verify /tools/x/bin/x",
+			},
+		},
+		{
+			name:   "ad-hoc sign",
+			binary: "/tools/x/bin/x",
+			want: []string{
+				"xattr -r -d com.apple.quarantine /tools/x",
+				"codesign --verify /tools/x/bin/x",
+				"codesign --force --sign - /tools/x/bin/x",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			ctx := NewRunContext(context.Background(), RunContextOptions{
+				Output: &Output{Stdout: io.Discard, Stderr: io.Discard},
+				Runner: func(cmd *exec.Cmd) error {
+					line := strings.Join(cmd.Args, " ")
+					got = append(got, line)
+					switch {
+					case cmd.Args[0] == "xattr":
+						return errors.New("no such xattr")
+					case slices.Contains(cmd.Args, "--verify") && !tt.verifyOK:
+						return errors.New("code object is not signed")
+					}
+					return nil
+				},
+			})
+			if err := clearQuarantine(ctx, "/tools/x", tt.binary); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("commands =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
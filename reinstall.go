@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// reinstallToolsEnv makes tool installers install their tool even if it is
+// installed, see Reinstall. The -reinstall-tools flag sets it, so nested
+// pocket runs reinstall their tools too.
+const reinstallToolsEnv = "POK_REINSTALL_TOOLS"
+
+// runTools lists the managed tools of the config, or reinstalls the tools
+// named after "reinstall" (all tools without names).
+func runTools(ctx context.Context) error {
+	installers := toolInstallers(GetConfigPlan(ctx))
+	args := positionalArgs(ctx)
+	if len(args) == 0 {
+		if len(installers) == 0 {
+			Println(ctx, "No managed tools.")
+			return nil
+		}
+		w := tabwriter.NewWriter(GetOutput(ctx).Stdout, 0, 0, 2, ' ', 0)
+		for _, f := range installers {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", f.tool.Name, f.tool.Version, f.name)
+		}
+		return w.Flush()
+	}
+	if args[0] != "reinstall" {
+		return fmt.Errorf("tools: unknown command %q (want reinstall)", args[0])
+	}
+
+	toReinstall := installers
+	if names := args[1:]; len(names) > 0 {
+		toReinstall = nil
+		for _, name := range names {
+			i := slices.IndexFunc(installers, func(f *TaskDef) bool {
+				return f.tool.Name == name || f.name == name
+			})
+			if i < 0 {
+				return fmt.Errorf("tools: unknown tool %q (run ./pok tools to list them)", name)
+			}
+			toReinstall = append(toReinstall, installers[i])
+		}
+	}
+
+	ec := *getExecContext(ctx)
+	ec.reinstall = true
+	ctx = withExecContext(ctx, &ec)
+	for _, f := range toReinstall {
+		Printf(ctx, "Reinstalling %s %s\n", f.tool.Name, f.tool.Version)
+		if err := f.run(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toolInstallers returns the tasks that install the managed tools of the
+// config's AutoRun, ManualRun, Generate and Internal tasks, one per tool
+// version, sorted by tool name.
+func toolInstallers(plan *ConfigPlan) []*TaskDef {
+	if plan == nil || plan.Config == nil {
+		return nil
+	}
+	cfg := plan.Config
+	var roots []Runnable
+	if cfg.AutoRun != nil {
+		roots = append(roots, cfg.AutoRun)
+	}
+	roots = append(roots, cfg.ManualRun...)
+	roots = append(roots, cfg.Generate...)
+	for _, f := range cfg.Internal {
+		roots = append(roots, f)
+	}
+
+	seen := make(map[string]bool)
+	var installers []*TaskDef
+	var walk func(steps []*PlanStep)
+	walk = func(steps []*PlanStep) {
+		for _, step := range steps {
+			if f := step.task; f != nil && f.tool != nil && !seen[f.tool.Name+"@"+f.tool.Version] {
+				seen[f.tool.Name+"@"+f.tool.Version] = true
+				installers = append(installers, f)
+			}
+			walk(step.Children)
+		}
+	}
+	for _, r := range roots {
+		if execPlan, err := NewEngine(r).Plan(context.Background()); err == nil {
+			walk(execPlan.Steps())
+		}
+	}
+	slices.SortStableFunc(installers, func(a, b *TaskDef) int {
+		return strings.Compare(a.tool.Name, b.tool.Name)
+	})
+	return installers
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunTools(t *testing.T) {
+	var installed []string
+	installer := func(name, version string) *TaskDef {
+		return Task("install:"+name, "install "+name, func(ctx context.Context) error {
+			if Reinstall(ctx) {
+				installed = append(installed, name)
+			}
+			return nil
+		}, AsHidden(), AsTool(name, version))
+	}
+	linter := installer("linter", "v1.0.0")
+	formatter := installer("formatter", "v2.0.0")
+	noop := func(_ context.Context) error { return nil }
+	plan := BuildConfigPlan(Config{
+		AutoRun: Parallel(
+			Task("lint", "lint", Serial(linter, noop)),
+			Task("lint-again", "lint again", Serial(linter, noop)),
+		),
+		ManualRun: []Runnable{Task("format", "format", Serial(formatter, noop))},
+	})
+
+	var stdout strings.Builder
+	newCtx := func(args ...string) context.Context {
+		ctx := NewRunContext(context.Background(), RunContextOptions{
+			Output:     &Output{Stdout: &stdout, Stderr: io.Discard},
+			ConfigPlan: plan,
+		})
+		return withPositionalArgs(ctx, args)
+	}
+
+	if err := runTools(newCtx()); err != nil {
+		t.Fatal(err)
+	}
+	want := "  formatter  v2.0.0  install:formatter\n  linter     v1.0.0  install:linter\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("tools listing =\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := runTools(newCtx("reinstall", "linter")); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(installed, ",") != "linter" {
+		t.Errorf("reinstalled %v, want [linter]", installed)
+	}
+	installed = nil
+	if err := runTools(newCtx("reinstall")); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(installed, ",") != "formatter,linter" {
+		t.Errorf("reinstalled %v, want all tools", installed)
+	}
+
+	if err := runTools(newCtx("reinstall", "nope")); err == nil || !strings.Contains(err.Error(), `unknown tool "nope"`) {
+		t.Errorf("expected unknown tool error, got %v", err)
+	}
+	if err := runTools(newCtx("upgrade")); err == nil {
+		t.Error("expected an error for an unknown command")
+	}
+}
+
+func TestReinstallEnv(t *testing.T) {
+	ctx := TestContext(discardOutput())
+	t.Setenv(reinstallToolsEnv, "")
+	if Reinstall(ctx) {
+		t.Error("Reinstall() = true without -reinstall-tools")
+	}
+	t.Setenv(reinstallToolsEnv, "1")
+	if !Reinstall(ctx) {
+		t.Error("Reinstall() = false with POK_REINSTALL_TOOLS set")
+	}
+}
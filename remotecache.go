@@ -0,0 +1,312 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CacheConfig configures a remote cache shared by the tasks with WithCache,
+// e.g. between CI machines. Entries are looked up when a task's local cache
+// misses and stored after it succeeds. Entries are keyed by the task's cache
+// key, which includes the platform and Go toolchain, so machines only share
+// entries with machines of the same OS and architecture. Remote errors are
+// reported as warnings and don't fail the run.
+type CacheConfig struct {
+	// URL locates the remote cache:
+	//   - https://host/path: GET and PUT <URL>/<key>, with the bearer token
+	//     from TokenEnv if set
+	//   - s3://bucket/prefix: Amazon S3 (or a compatible store with
+	//     AWS_ENDPOINT_URL_S3), using AWS_ACCESS_KEY_ID,
+	//     AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_REGION. Grant
+	//     s3:ListBucket, without which S3 reports missing entries as 403.
+	//   - gs://bucket/prefix: Google Cloud Storage, with the OAuth access
+	//     token from TokenEnv or GOOGLE_OAUTH_ACCESS_TOKEN
+	// The remote cache is disabled when URL is empty and Backend is nil.
+	URL string
+
+	// TokenEnv names the environment variable holding the bearer token for
+	// https:// and gs:// URLs. Default: POK_CACHE_TOKEN.
+	TokenEnv string
+
+	// ReadOnly only looks up entries, e.g. to let local runs use the
+	// entries CI stores without write credentials.
+	ReadOnly bool
+
+	// Backend is a custom remote cache, used instead of URL.
+	Backend CacheBackend
+}
+
+// CacheBackend stores remote cache entries by cache key. An entry records
+// a successful task run; its data is informational.
+type CacheBackend interface {
+	// Get returns the entry for key, and false if there is none.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Put stores the entry for key.
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// defaultCacheTokenEnv holds the bearer token of https:// remote caches.
+const defaultCacheTokenEnv = "POK_CACHE_TOKEN"
+
+// cacheEntry is the data stored for a remote cache entry.
+type cacheEntry struct {
+	Task string    `json:"task"`
+	Path string    `json:"path"`
+	Time time.Time `json:"time"`
+}
+
+// remoteCache returns the remote cache of the running config, or nil.
+func remoteCache(ctx context.Context) (CacheBackend, *CacheConfig, error) {
+	plan := GetConfigPlan(ctx)
+	if plan == nil || plan.Config == nil || plan.Config.Cache == nil {
+		return nil, nil, nil
+	}
+	cfg := plan.Config.Cache
+	if cfg.Backend != nil {
+		return cfg.Backend, cfg, nil
+	}
+	if cfg.URL == "" {
+		return nil, nil, nil
+	}
+	backend, err := newCacheBackend(*cfg)
+	return backend, cfg, err
+}
+
+// newCacheBackend returns the backend for cfg.URL.
+func newCacheBackend(cfg CacheConfig) (CacheBackend, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("cache URL: %w", err)
+	}
+	tokenEnv := cfg.TokenEnv
+	if tokenEnv == "" {
+		tokenEnv = defaultCacheTokenEnv
+	}
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "http", "https":
+		return &httpCache{base: strings.TrimSuffix(cfg.URL, "/"), token: os.Getenv(tokenEnv)}, nil
+	case "gs":
+		token := os.Getenv(tokenEnv)
+		if token == "" {
+			token = os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+		}
+		base := "https://storage.googleapis.com/" + u.Host
+		if prefix != "" {
+			base += "/" + prefix
+		}
+		return &httpCache{base: base, token: token}, nil
+	case "s3":
+		return newS3Cache(u.Host, prefix), nil
+	}
+	return nil, fmt.Errorf("cache URL %q: unsupported scheme (want https, s3 or gs)", cfg.URL)
+}
+
+// cacheClient is the HTTP client of remote caches.
+var cacheClient = &http.Client{Timeout: 30 * time.Second}
+
+// httpCache is a remote cache storing entries at <base>/<key>.
+type httpCache struct {
+	base  string
+	token string
+}
+
+func (c *httpCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return c.do(ctx, http.MethodGet, key, nil)
+}
+
+func (c *httpCache) Put(ctx context.Context, key string, data []byte) error {
+	_, _, err := c.do(ctx, http.MethodPut, key, data)
+	return err
+}
+
+func (c *httpCache) do(ctx context.Context, method, key string, body []byte) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.base+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return doCacheRequest(req)
+}
+
+// doCacheRequest sends a remote cache request. A 404 response is a miss.
+func doCacheRequest(req *http.Request) ([]byte, bool, error) {
+	resp, err := cacheClient.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, nil
+	case resp.StatusCode >= 300:
+		return nil, false, fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), resp.Status)
+	}
+	return data, true, err
+}
+
+// s3Cache is a remote cache storing entries as S3 objects <prefix>/<key>,
+// signed with AWS Signature Version 4.
+type s3Cache struct {
+	endpoint string // scheme and host, e.g. https://bucket.s3.us-east-1.amazonaws.com
+	path     string // object path prefix, e.g. /prefix (or /bucket/prefix for custom endpoints)
+	region   string
+	now      func() time.Time
+}
+
+func newS3Cache(bucket, prefix string) *s3Cache {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	c := &s3Cache{region: region, now: time.Now}
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	if endpoint != "" {
+		// Custom endpoints (e.g. MinIO) use path-style addressing.
+		c.endpoint = strings.TrimSuffix(endpoint, "/")
+		c.path = "/" + bucket
+	} else {
+		c.endpoint = "https://" + bucket + ".s3." + region + ".amazonaws.com"
+	}
+	if prefix != "" {
+		c.path += "/" + prefix
+	}
+	return c
+}
+
+func (c *s3Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return c.do(ctx, http.MethodGet, key, nil)
+}
+
+func (c *s3Cache) Put(ctx context.Context, key string, data []byte) error {
+	_, _, err := c.do(ctx, http.MethodPut, key, data)
+	return err
+}
+
+func (c *s3Cache) do(ctx context.Context, method, key string, body []byte) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+c.path+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, false, fmt.Errorf("s3: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+	}
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signV4(req, body, accessKey, secretKey, c.region, "s3", c.now())
+	return doCacheRequest(req)
+}
+
+// signV4 signs req with AWS Signature Version 4, signing the host and all
+// headers set on req.
+func signV4(req *http.Request, body []byte, accessKey, secretKey, region, service string, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// remoteCacheHit reports whether the remote cache has an entry for key.
+// Errors are printed as warnings.
+func remoteCacheHit(ctx context.Context, name, key string) bool {
+	backend, _, err := remoteCache(ctx)
+	if err != nil {
+		Printf(ctx, "warning: %s: remote cache: %v\n", name, err)
+		return false
+	}
+	if backend == nil {
+		return false
+	}
+	_, ok, err := backend.Get(ctx, key)
+	if err != nil {
+		Printf(ctx, "warning: %s: remote cache: %v\n", name, err)
+	}
+	return ok
+}
+
+// storeRemoteCache stores an entry for key in the remote cache, unless it
+// is read-only. Errors are printed as warnings.
+func storeRemoteCache(ctx context.Context, name, key string) {
+	backend, cfg, err := remoteCache(ctx)
+	if err == nil && (backend == nil || cfg.ReadOnly) {
+		return
+	}
+	if err == nil {
+		data, _ := json.Marshal(cacheEntry{Task: name, Path: Path(ctx), Time: time.Now().UTC()})
+		err = backend.Put(ctx, key, data)
+	}
+	if err != nil {
+		Printf(ctx, "warning: %s: remote cache: %v\n", name, err)
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Names of the reproduction scripts written to .pocket when a run fails.
+const (
+	reproShellScript = "repro.sh"
+	reproCmdScript   = "repro.cmd"
+)
+
+// reproCommand is a snapshot of a failed command, enough to re-run it
+// outside pocket.
+type reproCommand struct {
+	args []string
+	dir  string
+	path string   // PATH the command ran with
+	env  []string // KEY=value entries pocket added or changed, except PATH
+}
+
+// newReproCommand captures cmd and the environment changes pocket made
+// compared to its own environment.
+func newReproCommand(cmd *exec.Cmd) *reproCommand {
+	args := append([]string{cmd.Path}, cmd.Args[1:]...)
+	if cmd.Path == "" {
+		args[0] = cmd.Args[0]
+	}
+	c := &reproCommand{args: args, dir: cmd.Dir}
+	if c.dir == "" {
+		c.dir, _ = os.Getwd()
+	}
+	if cmd.Env == nil {
+		return c
+	}
+	base := make(map[string]string)
+	for _, e := range os.Environ() {
+		key, value, _ := strings.Cut(e, "=")
+		base[key] = value
+	}
+	for _, e := range cmd.Env {
+		key, value, _ := strings.Cut(e, "=")
+		switch {
+		case strings.EqualFold(key, "PATH"):
+			c.path = value
+		case base[key] != value:
+			c.env = append(c.env, e)
+		}
+	}
+	return c
+}
+
+// shellScript returns a POSIX shell script that re-runs the command.
+func (c *reproCommand) shellScript() string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# Re-runs the command that failed in the last pocket run.\n")
+	fmt.Fprintf(&b, "cd %s || exit 1\n", shellQuote(c.dir))
+	if c.path != "" {
+		fmt.Fprintf(&b, "export PATH=%s\n", shellQuote(c.path))
+	}
+	for _, e := range c.env {
+		key, value, _ := strings.Cut(e, "=")
+		fmt.Fprintf(&b, "export %s=%s\n", key, shellQuote(value))
+	}
+	quoted := make([]string, len(c.args))
+	for i, arg := range c.args {
+		quoted[i] = shellQuote(arg)
+	}
+	fmt.Fprintf(&b, "exec %s\n", strings.Join(quoted, " "))
+	return b.String()
+}
+
+// cmdScript returns a Windows batch script that re-runs the command.
+func (c *reproCommand) cmdScript() string {
+	var b strings.Builder
+	b.WriteString("@echo off\r\n")
+	b.WriteString("rem Re-runs the command that failed in the last pocket run.\r\n")
+	b.WriteString("setlocal\r\n")
+	fmt.Fprintf(&b, "cd /d %s || exit /b 1\r\n", cmdQuote(c.dir))
+	if c.path != "" {
+		fmt.Fprintf(&b, "set \"PATH=%s\"\r\n", cmdEscape(c.path))
+	}
+	for _, e := range c.env {
+		fmt.Fprintf(&b, "set \"%s\"\r\n", cmdEscape(e))
+	}
+	quoted := make([]string, len(c.args))
+	for i, arg := range c.args {
+		quoted[i] = cmdQuote(arg)
+	}
+	fmt.Fprintf(&b, "%s\r\n", strings.Join(quoted, " "))
+	return b.String()
+}
+
+// writeRepro writes the reproduction scripts for c to .pocket and returns
+// the path of the script for the current platform.
+func writeRepro(c *reproCommand) (string, error) {
+	shPath := FromPocketDir(reproShellScript)
+	if err := os.WriteFile(shPath, []byte(c.shellScript()), 0o755); err != nil {
+		return "", err
+	}
+	cmdPath := FromPocketDir(reproCmdScript)
+	if err := os.WriteFile(cmdPath, []byte(c.cmdScript()), 0o644); err != nil {
+		return "", err
+	}
+	if runtime.GOOS == Windows {
+		return cmdPath, nil
+	}
+	return shPath, nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,@+", r))
+	}) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// cmdQuote quotes s for cmd.exe.
+func cmdQuote(s string) string {
+	return `"` + strings.ReplaceAll(cmdEscape(s), `"`, `""`) + `"`
+}
+
+// cmdEscape escapes the variable expansion of cmd.exe in s.
+func cmdEscape(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
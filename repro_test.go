@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewReproCommand(t *testing.T) {
+	t.Setenv("POCKET_REPRO_KEEP", "same")
+	cmd := exec.Command("/bin/echo", "hello world", "it's")
+	cmd.Dir = "/repo/services/api"
+	cmd.Env = append(PrependPath(os.Environ(), "/repo/.pocket/bin"), "FORCE_COLOR=1")
+
+	c := newReproCommand(cmd)
+	if want := []string{"/bin/echo", "hello world", "it's"}; !slices.Equal(c.args, want) {
+		t.Errorf("args = %q, want %q", c.args, want)
+	}
+	if !strings.HasPrefix(c.path, "/repo/.pocket/bin") {
+		t.Errorf("path = %q, want .pocket/bin first", c.path)
+	}
+	if want := []string{"FORCE_COLOR=1"}; !slices.Equal(c.env, want) {
+		t.Errorf("env = %q, want only the variables pocket changed %q", c.env, want)
+	}
+
+	sh := c.shellScript()
+	for _, want := range []string{
+		"#!/bin/sh\n",
+		"cd /repo/services/api || exit 1\n",
+		"export PATH=" + shellQuote(c.path) + "\n",
+		"export FORCE_COLOR=1\n",
+		`exec /bin/echo 'hello world' 'it'\''s'` + "\n",
+	} {
+		if !strings.Contains(sh, want) {
+			t.Errorf("shell script does not contain %q:\n%s", want, sh)
+		}
+	}
+	bat := c.cmdScript()
+	for _, want := range []string{
+		"cd /d \"/repo/services/api\" || exit /b 1\r\n",
+		"set \"FORCE_COLOR=1\"\r\n",
+		`"/bin/echo" "hello world" "it's"` + "\r\n",
+	} {
+		if !strings.Contains(bat, want) {
+			t.Errorf("cmd script does not contain %q:\n%s", want, bat)
+		}
+	}
+}
+
+func TestRunLog_RecordsFirstFailure(t *testing.T) {
+	rl := &runLog{}
+	rl.recordCommand(exec.Command("true"), 0, 0, nil)
+	if rl.failure != nil {
+		t.Fatalf("expected no failure after a successful command, got %+v", rl.failure)
+	}
+	rl.recordCommand(exec.Command("go", "test", "./..."), 0, 0, errors.New("exit status 1"))
+	rl.recordCommand(exec.Command("golangci-lint", "run"), 0, 0, errors.New("signal: interrupt"))
+	if rl.failure == nil || rl.failure.args[len(rl.failure.args)-1] != "./..." {
+		t.Errorf("expected the first failed command to be kept, got %+v", rl.failure)
+	}
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"maps"
+	"os/exec"
+)
+
+// CommandRunner runs an external command prepared by Exec, ExecIn,
+// RunCommand or InstallGo.
+// The command's Stdout and Stderr are already wired to the task output.
+// Replace it to record or script commands instead of running real tools
+// (see package pockettest).
+type CommandRunner func(cmd *exec.Cmd) error
+
+// Installer replaces the work of InstallGo and Download: fetching a tool
+// into .pocket/tools and linking it into .pocket/bin. source is
+// "<pkg>@<version>" for InstallGo and the URL for Download.
+// Replace it so tasks with install steps can run in tests without network
+// access (see package pockettest).
+type Installer func(ctx context.Context, source string) error
+
+// RunContextOptions configures NewRunContext.
+type RunContextOptions struct {
+	// Output receives task output. Default: StdOutput()
+	Output *Output
+
+	// Path is the current path relative to git root, as returned by Path.
+	// Default: "."
+	Path string
+
+	// Verbose enables verbose mode, as returned by Verbose.
+	Verbose bool
+
+	// Runner runs commands from Exec, ExecIn, RunCommand and InstallGo.
+	// Default: cmd.Run
+	Runner CommandRunner
+
+	// Installer replaces InstallGo and Download. Default: install for real.
+	Installer Installer
+
+	// ConfigPlan is returned by GetConfigPlan. Optional.
+	ConfigPlan *ConfigPlan
+
+	// Env holds extra environment variables for commands, as set by Env.
+	Env map[string]string
+}
+
+// NewRunContext returns a context for running tasks outside the CLI,
+// e.g. calling TaskDef.Run from a unit test.
+//
+// Example:
+//
+//	ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{
+//	    Path: "services/api",
+//	})
+//	err := MyTask.Run(ctx)
+func NewRunContext(parent context.Context, opts RunContextOptions) context.Context {
+	out := opts.Output
+	if out == nil {
+		out = StdOutput()
+	}
+	ec := newExecContext(out, ".", opts.Verbose, opts.ConfigPlan)
+	ec.runner = opts.Runner
+	ec.installer = opts.Installer
+	ec.env = maps.Clone(opts.Env)
+	if opts.Path != "" && opts.Path != "." {
+		ec.path = opts.Path
+	}
+	return withExecContext(parent, ec)
+}
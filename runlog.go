@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogsDirName is the name of the run log subdirectory.
+const LogsDirName = "logs"
+
+// runLogTimeFormat is used for log file names; it sorts chronologically.
+const runLogTimeFormat = "20060102-150405.000"
+
+// RunLogConfig controls persistence of run logs to .pocket/logs/.
+type RunLogConfig struct {
+	// Keep is the number of most recent logs to retain.
+	// Default: 20
+	Keep int
+
+	// MaxAge removes logs older than this duration. Zero keeps logs
+	// regardless of age (Keep still applies).
+	MaxAge time.Duration
+}
+
+// runLog records output and metadata for a single CLI run.
+// Shared by all execContexts of the run; safe for concurrent use.
+// Without a file, it only records metadata (used for TAP output). With
+// events set, it also writes each task's start and finish as JSON events.
+type runLog struct {
+	mu       sync.Mutex
+	file     *os.File // nil for an in-memory log
+	start    time.Time
+	tasks    []runLogEntry
+	commands []runLogEntry
+	failure  *reproCommand // first failed command, see writeRepro
+	events   io.Writer     // event stream of -output=json, nil when disabled
+	lanes    int           // timeline lanes in use or free, see acquireLane
+	free     []int         // free timeline lanes
+	audit    io.Writer     // audit log, see Config.Audit; nil when disabled
+
+	// stdout and stderr strip ANSI from output written to file (see wrap).
+	stdout, stderr *ansiStripWriter
+}
+
+// runLogEntry is a task or command recorded in the run log.
+type runLogEntry struct {
+	name     string
+	path     string
+	hidden   bool
+	duration time.Duration
+	end      time.Time // when the task or command finished
+	lane     int       // timeline lane, see acquireLane
+	exitCode int
+	err      error
+	skipped  bool // skipped with -skip or -skip-tags, or by OnlyIf
+	cached   bool // skipped because its inputs are unchanged, see WithCache
+}
+
+// FromLogsDir returns a path relative to the .pocket/logs directory.
+func FromLogsDir(elem ...string) string {
+	return FromPocketDir(append([]string{LogsDirName}, elem...)...)
+}
+
+// openRunLog creates a new log file in .pocket/logs and writes the header.
+func openRunLog(now time.Time, args []string, cwd string) (*runLog, error) {
+	dir := FromLogsDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create logs dir: %w", err)
+	}
+	f, err := os.Create(filepath.Join(dir, now.Format(runLogTimeFormat)+".log"))
+	if err != nil {
+		return nil, fmt.Errorf("create run log: %w", err)
+	}
+	fmt.Fprintf(f, "# pocket run %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(f, "# args: %s\n", strings.Join(args, " "))
+	fmt.Fprintf(f, "# cwd: %s\n\n", cwd)
+	return &runLog{file: f, start: now}, nil
+}
+
+// wrap returns an Output that writes to both out and the log file.
+func (l *runLog) wrap(out *Output) *Output {
+	// Strip ANSI so the log stays readable; one stripper per stream so
+	// split escape sequences from stdout and stderr don't interleave.
+	l.stdout = newANSIStripWriter(l.file)
+	l.stderr = newANSIStripWriter(l.file)
+	stdout := &lockedWriter{mu: &l.mu, w: l.stdout}
+	stderr := &lockedWriter{mu: &l.mu, w: l.stderr}
+	return &Output{
+		Stdout: io.MultiWriter(out.Stdout, stdout),
+		Stderr: io.MultiWriter(out.Stderr, stderr),
+	}
+}
+
+// recordTask records a finished task.
+func (l *runLog) recordTask(name, path string, hidden bool, lane int, d time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := runLogEntry{
+		name:     name,
+		path:     path,
+		hidden:   hidden,
+		lane:     lane,
+		duration: d,
+		end:      time.Now(),
+		exitCode: exitCode(err),
+		err:      err,
+	}
+	l.tasks = append(l.tasks, t)
+	l.writeEvent(finishEvent(t), hidden)
+}
+
+// recordSkip records a task skipped with -skip or -skip-tags, or by OnlyIf.
+func (l *runLog) recordSkip(name, path string, hidden bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := runLogEntry{
+		name:    name,
+		path:    path,
+		hidden:  hidden,
+		skipped: true,
+	}
+	l.tasks = append(l.tasks, t)
+	l.writeEvent(finishEvent(t), hidden)
+}
+
+// recordCached records a task skipped because of a cache hit.
+func (l *runLog) recordCached(name, path string, hidden bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := runLogEntry{
+		name:   name,
+		path:   path,
+		hidden: hidden,
+		cached: true,
+	}
+	l.tasks = append(l.tasks, t)
+	l.writeEvent(finishEvent(t), hidden)
+}
+
+// visibleTasks returns the recorded tasks that are not hidden, in completion order.
+func (l *runLog) visibleTasks() []runLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var tasks []runLogEntry
+	for _, t := range l.tasks {
+		if !t.hidden {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// skippedTasks returns the visible skipped tasks (see recordSkip), with
+// their paths, e.g. "go-test [services/api]". It is safe to call on a nil
+// log.
+func (l *runLog) skippedTasks() []string {
+	if l == nil {
+		return nil
+	}
+	var names []string
+	for _, t := range l.visibleTasks() {
+		if !t.skipped {
+			continue
+		}
+		name := t.name
+		if t.path != "" && t.path != "." {
+			name += " [" + t.path + "]"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// recordCommand records a finished external command.
+func (l *runLog) recordCommand(cmd *exec.Cmd, lane int, d time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.commands = append(l.commands, runLogEntry{
+		name:     strings.Join(cmd.Args, " "),
+		path:     cmd.Dir,
+		lane:     lane,
+		duration: d,
+		end:      time.Now(),
+		exitCode: exitCode(err),
+		err:      err,
+	})
+	// Later failures are often commands cancelled because of the first one.
+	if err != nil && l.failure == nil {
+		l.failure = newReproCommand(cmd)
+	}
+}
+
+// close writes the metadata summary and closes the log file.
+func (l *runLog) close(code int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+
+	for _, w := range []*ansiStripWriter{l.stdout, l.stderr} {
+		if w != nil {
+			_ = w.Close()
+		}
+	}
+	fmt.Fprintf(l.file, "\n# --- summary ---\n")
+	for _, t := range l.tasks {
+		if t.skipped {
+			fmt.Fprintf(l.file, "# task %s [%s] skipped-by-flag\n", t.name, t.path)
+			continue
+		}
+		fmt.Fprintf(l.file, "# task %s [%s] exit=%d %s\n", t.name, t.path, t.exitCode, t.duration.Round(time.Millisecond))
+	}
+	for _, c := range l.commands {
+		fmt.Fprintf(l.file, "# exec %s (%s) exit=%d %s\n", c.name, c.path, c.exitCode, c.duration.Round(time.Millisecond))
+	}
+	fmt.Fprintf(l.file, "# exit: %d (%s)\n", code, time.Since(l.start).Round(time.Millisecond))
+	return l.file.Close()
+}
+
+// exitCode returns the process exit code for err (0 for nil, 1 if unknown).
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	var toolErr *ToolError
+	if errors.As(err, &toolErr) {
+		return toolErr.ExitCode()
+	}
+	return 1
+}
+
+// pruneRunLogs removes logs beyond the retention settings.
+func pruneRunLogs(dir string, cfg RunLogConfig, now time.Time) error {
+	keep := cfg.Keep
+	if keep <= 0 {
+		keep = 20
+	}
+	logs, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return err
+	}
+	// Names are timestamps, so lexical order is chronological; newest first.
+	slices.Sort(logs)
+	slices.Reverse(logs)
+
+	var errs []error
+	for i, p := range logs {
+		expired := false
+		if cfg.MaxAge > 0 {
+			ts, err := time.ParseInLocation(runLogTimeFormat, strings.TrimSuffix(filepath.Base(p), ".log"), now.Location())
+			expired = err == nil && now.Sub(ts) > cfg.MaxAge
+		}
+		if i >= keep || expired {
+			if err := os.Remove(p); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
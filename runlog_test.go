@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunLog_RecordsOutputAndTasks(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := &runLog{file: f, start: time.Now()}
+
+	ok := Task("ok-task", "ok", func(ctx context.Context) error {
+		Printf(ctx, "hello from task\n")
+		return nil
+	})
+	fail := Task("fail-task", "fail", func(_ context.Context) error {
+		return errors.New("boom")
+	})
+
+	out := rl.wrap(discardOutput())
+	err = runWithContext(context.Background(), Serial(ok, fail), out, ".", false, nil, rl)
+	if err == nil {
+		t.Fatal("expected error from fail-task")
+	}
+	if err := rl.close(1); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"hello from task",
+		"# task ok-task [.] exit=0",
+		"# task fail-task [.] exit=1",
+		"# exit: 1",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected log to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestPruneRunLogs(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	for _, age := range []time.Duration{0, time.Hour, 48 * time.Hour, 72 * time.Hour} {
+		name := now.Add(-age).Format(runLogTimeFormat) + ".log"
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Keep the 3 newest, then drop anything older than a day.
+	if err := pruneRunLogs(dir, RunLogConfig{Keep: 3, MaxAge: 24 * time.Hour}, now); err != nil {
+		t.Fatalf("pruneRunLogs() failed: %v", err)
+	}
+	logs, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(logs) != 2 {
+		t.Errorf("expected 2 logs to remain, got %d: %v", len(logs), logs)
+	}
+}
+
+func TestRunLog_RecordsRunCommand(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := &runLog{file: f, start: time.Now()}
+
+	task := Task("cmd-task", "cmd", func(ctx context.Context) error {
+		// Command wires os.Stdout; RunCommand redirects it to the task output.
+		return RunCommand(ctx, Command(ctx, "go", "env", "GOOS"))
+	})
+	if err := runWithContext(context.Background(), task, rl.wrap(discardOutput()), ".", false, nil, rl); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if err := rl.close(0); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	for _, want := range []string{runtime.GOOS + "\n", "# exec go env GOOS"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected log to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestRunLog_RecordsSkippedTasks(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := &runLog{file: f, start: time.Now()}
+
+	var ran []string
+	record := func(name string) *TaskDef {
+		return Task(name, name, Do(func(_ context.Context) error {
+			ran = append(ran, name)
+			return nil
+		}))
+	}
+	root := &skipRunnable{names: []string{"go-vulncheck"}, inner: Serial(record("go-lint"), record("go-vulncheck"))}
+
+	var stdout strings.Builder
+	out := rl.wrap(&Output{Stdout: &stdout, Stderr: io.Discard})
+	if err := runWithContext(context.Background(), root, out, ".", false, nil, rl); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 1 || ran[0] != "go-lint" {
+		t.Errorf("ran %v, want [go-lint]", ran)
+	}
+	if got := rl.skippedTasks(); len(got) != 1 || got[0] != "go-vulncheck" {
+		t.Errorf("skippedTasks() = %v, want [go-vulncheck]", got)
+	}
+	if !strings.Contains(stdout.String(), ":: go-vulncheck (skipped by -skip)") {
+		t.Errorf("expected skipped header, got:\n%s", stdout.String())
+	}
+	if err := rl.close(0); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# task go-vulncheck [.] skipped-by-flag") {
+		t.Errorf("expected skipped task in summary, got:\n%s", data)
+	}
+}
@@ -1,10 +1,14 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 )
@@ -33,8 +37,13 @@ type ConfigPlan struct {
 	AutoRunNames map[string]bool
 	// PathMappings maps task names to their PathFilter for visibility
 	PathMappings map[string]*PathFilter
+	// TaskDirs maps task names to the directory they run in (see InDir)
+	TaskDirs map[string]string
 	// AllTask is the hidden task that runs the full AutoRun tree
 	AllTask *TaskDef
+	// DefaultTask is the task ./pok runs without arguments (see
+	// Config.DefaultTask). Nil runs AllTask.
+	DefaultTask *TaskDef
 	// BuiltinTasks are always-available tasks (plan, clean, generate, etc.)
 	BuiltinTasks []*TaskDef
 	// ModuleDirectories are all directories where shims should be generated
@@ -50,6 +59,7 @@ func BuildConfigPlan(cfg Config) *ConfigPlan {
 		Tasks:        make([]*TaskDef, 0),
 		AutoRunNames: make(map[string]bool),
 		PathMappings: make(map[string]*PathFilter),
+		TaskDirs:     make(map[string]string),
 		Config:       &cfg,
 	}
 
@@ -63,6 +73,7 @@ func BuildConfigPlan(cfg Config) *ConfigPlan {
 		if execPlan, err := engine.Plan(context.Background()); err == nil {
 			plan.Tasks = execPlan.TaskDefs()
 			plan.PathMappings = execPlan.PathMappings()
+			maps.Copy(plan.TaskDirs, execPlan.TaskDirs())
 			for _, dir := range execPlan.ModuleDirectories() {
 				moduleDirSet[dir] = true
 			}
@@ -85,13 +96,16 @@ func BuildConfigPlan(cfg Config) *ConfigPlan {
 				if _, err := generateAllFn(configPlan); err != nil {
 					return fmt.Errorf("generate: %w", err)
 				}
+				if err := runGenerators(ctx, &cfg); err != nil {
+					return fmt.Errorf("generate: %w", err)
+				}
 			}
 			if err := cfg.AutoRun.run(ctx); err != nil {
 				return err
 			}
 			if !cfg.SkipGitDiff {
-				if err := Exec(ctx, "git", "diff", "--exit-code"); err != nil {
-					return fmt.Errorf("uncommitted changes detected; please commit or stage your changes")
+				if err := gitDiffCheck(ctx, cfg.GitDiff); err != nil {
+					return err
 				}
 			}
 			return nil
@@ -106,6 +120,7 @@ func BuildConfigPlan(cfg Config) *ConfigPlan {
 			for name, pf := range execPlan.PathMappings() {
 				plan.PathMappings[name] = pf
 			}
+			maps.Copy(plan.TaskDirs, execPlan.TaskDirs())
 			for _, dir := range execPlan.ModuleDirectories() {
 				moduleDirSet[dir] = true
 			}
@@ -122,9 +137,29 @@ func BuildConfigPlan(cfg Config) *ConfigPlan {
 	// Phase 4: Add built-in tasks
 	plan.BuiltinTasks = builtinTasks(&cfg)
 
+	// Phase 5: Resolve the default task for ./pok without arguments
+	plan.DefaultTask = resolveDefaultTask(plan, cfg.DefaultTask)
+
 	return plan
 }
 
+// VisibleTasks returns the tasks listed by ./pok -h in dir (relative to the
+// git root, "." for the root): the visible AutoRun and ManualRun tasks that
+// run in dir, followed by the visible built-in tasks. Each group is sorted
+// by name. Generators of editor and build-tool integrations use it.
+func (p *ConfigPlan) VisibleTasks(dir string) []*TaskDef {
+	var tasks []*TaskDef
+	for _, f := range filterFuncsByCwd(p.Tasks, dir, p.PathMappings) {
+		if !f.hidden {
+			tasks = append(tasks, f)
+		}
+	}
+	slices.SortFunc(tasks, func(a, b *TaskDef) int { return strings.Compare(a.name, b.name) })
+	builtins := slices.DeleteFunc(slices.Clone(p.BuiltinTasks), (*TaskDef).IsHidden)
+	slices.SortFunc(builtins, func(a, b *TaskDef) int { return strings.Compare(a.name, b.name) })
+	return append(tasks, builtins...)
+}
+
 // Validate checks the ConfigPlan for errors (e.g., duplicate task names).
 func (p *ConfigPlan) Validate() error {
 	seen := make(map[string]bool)
@@ -143,12 +178,77 @@ func (p *ConfigPlan) Validate() error {
 		}
 	}
 
+	// A Runnable as DefaultTask is run by a task named "default".
+	if p.Config != nil && seen["default"] {
+		if _, isTask := p.Config.DefaultTask.(*TaskDef); !isTask {
+			if _, ok := p.Config.DefaultTask.(Runnable); ok {
+				duplicates = append(duplicates, "default (conflicts with DefaultTask)")
+			}
+		}
+	}
+
 	if len(duplicates) > 0 {
 		return fmt.Errorf("duplicate function names: %s", strings.Join(duplicates, ", "))
 	}
+
+	if p.Config != nil {
+		switch p.Config.ParallelOutput {
+		case "", ParallelOutputBuffered, ParallelOutputPrefixed:
+		default:
+			return fmt.Errorf("invalid ParallelOutput %q (want %s or %s)",
+				p.Config.ParallelOutput, ParallelOutputBuffered, ParallelOutputPrefixed)
+		}
+	}
+
+	if p.Config != nil {
+		for _, name := range slices.Sorted(maps.Keys(p.Config.DefaultConfigs)) {
+			if err := p.Config.DefaultConfigs[name].validate(); err != nil {
+				return fmt.Errorf("invalid DefaultConfigs[%q]: %w", name, err)
+			}
+		}
+	}
+
+	if p.Config != nil && p.Config.DefaultTask != nil && p.DefaultTask == nil {
+		if name, ok := p.Config.DefaultTask.(string); ok {
+			return fmt.Errorf("default task %q not found", name)
+		}
+		return fmt.Errorf("default task must be a task name or a Runnable, got %T", p.Config.DefaultTask)
+	}
 	return nil
 }
 
+// resolveDefaultTask returns the task for Config.DefaultTask: the task with
+// the given name, or a task named "default" running the given Runnable.
+// It returns nil if def is nil or can't be resolved.
+func resolveDefaultTask(plan *ConfigPlan, def any) *TaskDef {
+	switch d := def.(type) {
+	case string:
+		if d == "all" {
+			return plan.AllTask
+		}
+		for _, tasks := range [][]*TaskDef{plan.Tasks, plan.BuiltinTasks} {
+			if i := slices.IndexFunc(tasks, func(f *TaskDef) bool { return f.name == d }); i >= 0 {
+				return tasks[i]
+			}
+		}
+	case *TaskDef:
+		return d
+	case Runnable:
+		return Task("default", "run the default tasks", d)
+	}
+	return nil
+}
+
+// isCustomDefault reports whether the default task is not one of the
+// config's tasks or the built-in tasks, e.g. a Runnable set as DefaultTask.
+func (p *ConfigPlan) isCustomDefault() bool {
+	if p.DefaultTask == nil || p.DefaultTask == p.AllTask {
+		return false
+	}
+	named := func(f *TaskDef) bool { return f.name == p.DefaultTask.name }
+	return !slices.ContainsFunc(p.Tasks, named) && !slices.ContainsFunc(p.BuiltinTasks, named)
+}
+
 // RunConfig is the main entry point for running a pocket configuration.
 // It parses CLI flags, discovers functions, and runs the appropriate ones.
 //
@@ -166,13 +266,28 @@ func RunConfig(cfg Config) {
 	// Phase 2: Validate
 	if err := plan.Validate(); err != nil {
 		fmt.Fprintf(os.Stderr, "configuration error: %v\n", err)
-		os.Exit(1)
+		os.Exit(ExitConfig)
 	}
 
 	// Phase 3: Run CLI
 	cliMain(plan)
 }
 
+// runGenerators runs the Config.Generate runnables in order.
+// Generators are deduplicated like any other task in the execution.
+func runGenerators(ctx context.Context, cfg *Config) error {
+	ec := getExecContext(ctx)
+	for _, r := range cfg.Generate {
+		if !shouldRun(ec, r) {
+			continue
+		}
+		if err := r.run(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // planOptions configures the plan command.
 type planOptions struct {
 	Hidden  bool   `arg:"hidden"  usage:"show hidden functions (e.g., install tasks)"`
@@ -182,7 +297,7 @@ type planOptions struct {
 }
 
 // builtinTasks returns the built-in tasks that are always available.
-// These include: clean, generate, git-diff, plan, update.
+// These include: affected, clean, generate, git-diff, plan, update.
 func builtinTasks(cfg *Config) []*TaskDef {
 	return []*TaskDef{
 		// plan: show the execution tree
@@ -195,6 +310,7 @@ func builtinTasks(cfg *Config) []*TaskDef {
 				if err != nil {
 					return fmt.Errorf("plan: %w", err)
 				}
+				stripPlanANSI(&plan)
 				data, err := json.MarshalIndent(plan, "", "  ")
 				if err != nil {
 					return fmt.Errorf("plan: marshal: %w", err)
@@ -251,14 +367,24 @@ func builtinTasks(cfg *Config) []*TaskDef {
 			return nil
 		}, Opts(planOptions{}), AsSilent()),
 
-		// clean: remove .pocket/tools, .pocket/bin, and .pocket/venvs directories
+		// affected: list the tasks that read the given files
+		Task("affected", "list tasks affected by changed files", affected,
+			Opts(affectedOptions{}), acceptArgs("<files...>"), AsSilent(),
+			WithExample("./pok affected $(git diff --name-only origin/main)", "tasks to run for a branch"),
+			WithExample("./pok affected -owner=@org/team $(git diff --name-only origin/main)", "tasks to run for a team's changes"),
+		),
+
+		// clean: remove .pocket/tools, .pocket/bin, .pocket/venvs and .pocket/cache directories
 		Task(
 			"clean",
-			"remove .pocket/tools, .pocket/bin, and .pocket/venvs directories",
+			"remove .pocket/tools, .pocket/bin, .pocket/venvs and .pocket/cache directories",
 			func(ctx context.Context) error {
-				for _, dir := range []string{FromToolsDir(), FromBinDir(), FromPocketDir("venvs")} {
+				// The shims run pocket from .pocket/bin, and a running
+				// executable cannot be removed on Windows.
+				self, _ := os.Executable()
+				for _, dir := range []string{FromToolsDir(), FromBinDir(), FromPocketDir("venvs"), FromCacheDir()} {
 					if _, err := os.Stat(dir); err == nil {
-						if err := os.RemoveAll(dir); err != nil {
+						if err := removeAllExcept(dir, self); err != nil {
 							return fmt.Errorf("remove %s: %w", dir, err)
 						}
 						Printf(ctx, "Removed %s\n", dir)
@@ -268,8 +394,45 @@ func builtinTasks(cfg *Config) []*TaskDef {
 			},
 		),
 
-		// generate: regenerate all generated files (main.go, shim)
-		Task("generate", "regenerate all generated files (main.go, shim)", func(ctx context.Context) error {
+		// uninstall: remove pocket from the repository
+		Task("uninstall", "remove .pocket, shims, generated files and git hooks", uninstallPocket,
+			Opts(uninstallOptions{}),
+			WithExample("./pok uninstall -dry-run", "list what would be removed"),
+		),
+
+		// doctor: diagnose tool installation and PATH problems
+		Task("doctor", "check that required tools are installed and runnable", doctor),
+
+		// env: show the environment variables pocket reads and sets
+		Task("env", "show environment variables pocket reads and sets", env),
+
+		// internal: run a hidden task exposed with Config.Internal
+		Task("internal", "run a hidden maintenance task, e.g. a tool installer", runInternal,
+			Opts(internalOptions{}), acceptArgs("<task>"),
+			WithExample("./pok internal", "list the tasks exposed with Config.Internal"),
+			WithExample("./pok internal -reinstall install:golangci-lint", "install a tool again"),
+		),
+
+		// tools: list or reinstall managed tools
+		Task("tools", "list managed tools, or reinstall them", runTools,
+			acceptArgs("[reinstall [<tool>...]]"),
+			WithExample("./pok tools", "list the tools with their versions and install tasks"),
+			WithExample("./pok tools reinstall golangci-lint", "download and link golangci-lint again"),
+			WithExample("./pok tools reinstall", "reinstall all tools"),
+		),
+
+		// audit: report the commands recorded in the audit log
+		Task("audit", "report the external commands recorded in the audit log", audit,
+			Opts(auditOptions{}), AsSilent(),
+			WithExample("./pok audit -since=24h -tool=golangci-lint", "golangci-lint runs of the last day"),
+			WithExample("./pok audit -failed -json", "failed commands as JSON lines"),
+		),
+
+		// cache-info: show cache locations and sizes
+		Task("cache-info", "show the cache directories of pocket, Go, uv and bun with their sizes", cacheInfo),
+
+		// generate: regenerate all generated files (main.go, shim, Config.Generate)
+		Task("generate", "regenerate all generated files (main.go, shim, generators)", func(ctx context.Context) error {
 			if generateAllFn == nil {
 				return fmt.Errorf("scaffold not registered; import github.com/fredrikaverpil/pocket/internal/scaffold")
 			}
@@ -278,6 +441,9 @@ func builtinTasks(cfg *Config) []*TaskDef {
 			if err != nil {
 				return err
 			}
+			if err := runGenerators(ctx, cfg); err != nil {
+				return err
+			}
 			if Verbose(ctx) {
 				Printf(ctx, "Generated .pocket/main.go and shims:\n  %s\n", strings.Join(shimPaths, "\n  "))
 			} else {
@@ -288,11 +454,8 @@ func builtinTasks(cfg *Config) []*TaskDef {
 
 		// git-diff: fail if there are uncommitted changes
 		Task("git-diff", "fail if there are uncommitted changes", func(ctx context.Context) error {
-			if err := Exec(ctx, "git", "diff", "--exit-code"); err != nil {
-				return fmt.Errorf("uncommitted changes detected; please commit or stage your changes")
-			}
-			return nil
-		}),
+			return gitDiffCheck(ctx, Options[GitDiffOptions](ctx))
+		}, Opts(cfg.GitDiff)),
 
 		// update: update pocket dependency and regenerate files
 		Task("update", "update pocket dependency and regenerate files", func(ctx context.Context) error {
@@ -306,10 +469,7 @@ func builtinTasks(cfg *Config) []*TaskDef {
 			cmd := Command(ctx, "go", "get", "-u", "github.com/fredrikaverpil/pocket@latest")
 			cmd.Dir = pocketDir
 			cmd.Env = append(cmd.Env, "GOPROXY=direct")
-			out := GetOutput(ctx)
-			cmd.Stdout = out.Stdout
-			cmd.Stderr = out.Stderr
-			if err := cmd.Run(); err != nil {
+			if err := RunCommand(ctx, cmd); err != nil {
 				return fmt.Errorf("go get -u: %w", err)
 			}
 
@@ -321,6 +481,11 @@ func builtinTasks(cfg *Config) []*TaskDef {
 				return fmt.Errorf("go mod tidy: %w", err)
 			}
 
+			// Fetch the default configs of tools again
+			if err := refreshDefaultConfigs(cfg); err != nil {
+				return err
+			}
+
 			// Regenerate all files
 			if verbose {
 				Println(ctx, "Regenerating files")
@@ -340,3 +505,25 @@ func builtinTasks(cfg *Config) []*TaskDef {
 		}),
 	}
 }
+
+// removeAllExcept removes dir like os.RemoveAll, but keeps the file keep
+// (and dir itself) if keep is a direct child of dir.
+func removeAllExcept(dir, keep string) error {
+	if keep == "" || filepath.Dir(keep) != filepath.Clean(dir) {
+		return os.RemoveAll(dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		if p == keep {
+			continue
+		}
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -76,3 +80,113 @@ func TestConfigPlanValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate_ParallelOutput(t *testing.T) {
+	for _, mode := range []ParallelOutput{"", ParallelOutputBuffered, ParallelOutputPrefixed} {
+		if err := BuildConfigPlan(Config{ParallelOutput: mode}).Validate(); err != nil {
+			t.Errorf("ParallelOutput %q: Validate() = %v", mode, err)
+		}
+	}
+	err := BuildConfigPlan(Config{ParallelOutput: "interleaved"}).Validate()
+	if err == nil || !strings.Contains(err.Error(), `invalid ParallelOutput "interleaved"`) {
+		t.Errorf("Validate() = %v, want invalid ParallelOutput", err)
+	}
+}
+
+func TestBuildConfigPlan_DefaultTask(t *testing.T) {
+	noop := func(_ context.Context) error { return nil }
+	lint := Task("lint", "lint code", noop)
+	quick := Task("quick", "run quick checks", noop)
+
+	tests := []struct {
+		name        string
+		defaultTask any
+		want        string
+		errMsg      string
+	}{
+		{name: "unset"},
+		{name: "all", defaultTask: "all", want: "all"},
+		{name: "name", defaultTask: "quick", want: "quick"},
+		{name: "builtin", defaultTask: "plan", want: "plan"},
+		{name: "task", defaultTask: quick, want: "quick"},
+		{name: "runnable", defaultTask: Serial(lint, quick), want: "default"},
+		{name: "unknown name", defaultTask: "deploy", errMsg: `default task "deploy" not found`},
+		{name: "invalid type", defaultTask: 42, errMsg: "default task must be a task name or a Runnable, got int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := BuildConfigPlan(Config{
+				AutoRun:     lint,
+				ManualRun:   []Runnable{quick},
+				DefaultTask: tt.defaultTask,
+			})
+			err := plan.Validate()
+			if tt.errMsg != "" {
+				if err == nil || err.Error() != tt.errMsg {
+					t.Errorf("Validate() = %v, want %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() = %v", err)
+			}
+			got := ""
+			if plan.DefaultTask != nil {
+				got = plan.DefaultTask.Name()
+			}
+			if got != tt.want {
+				t.Errorf("DefaultTask = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunGenerators(t *testing.T) {
+	var calls []string
+	gen := func(name string) *TaskDef {
+		return Task(name, "generate "+name, func(_ context.Context) error {
+			calls = append(calls, name)
+			return nil
+		}, AsHidden())
+	}
+	a, b := gen("a"), gen("b")
+
+	cfg := &Config{Generate: []Runnable{a, b, a}}
+	ctx := TestContext(discardOutput())
+	if err := runGenerators(ctx, cfg); err != nil {
+		t.Fatalf("runGenerators() failed: %v", err)
+	}
+
+	// a is deduplicated.
+	if len(calls) != 2 || calls[0] != "a" || calls[1] != "b" {
+		t.Errorf("expected calls [a b], got %v", calls)
+	}
+}
+
+func TestConfigPlanVisibleTasks(t *testing.T) {
+	noop := func(_ context.Context) error { return nil }
+	plan := BuildConfigPlan(Config{
+		AutoRun: Serial(
+			Task("lint", "lint", noop),
+			RunIn(Task("test", "test", noop), Include("services/api")),
+			Task("install:x", "install x", noop, AsHidden()),
+		),
+		ManualRun: []Runnable{Task("deploy", "deploy", noop)},
+	})
+
+	names := func(tasks []*TaskDef) []string {
+		var result []string
+		for _, task := range tasks {
+			result = append(result, task.Name())
+		}
+		return result
+	}
+	builtins := []string{"affected", "audit", "cache-info", "clean", "doctor", "env", "generate", "git-diff", "internal", "plan", "tools", "uninstall", "update"}
+
+	if got, want := names(plan.VisibleTasks(".")), append([]string{"deploy", "lint"}, builtins...); !slices.Equal(got, want) {
+		t.Errorf("VisibleTasks(.) = %v, want %v", got, want)
+	}
+	if got, want := names(plan.VisibleTasks("services/api")), append([]string{"test"}, builtins...); !slices.Equal(got, want) {
+		t.Errorf("VisibleTasks(services/api) = %v, want %v", got, want)
+	}
+}
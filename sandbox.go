@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Sandbox restricts the commands a task runs, for running third-party
+// formatters and linters over the tree without trusting them with the rest
+// of the machine. Sandboxed commands can read the file system, but only
+// write to the git root, the temp directory and Writable, and have no
+// network access unless Network is set. Within the git root, .git and the
+// installed tools in .pocket/bin and .pocket/tools stay read-only, so a
+// sandboxed command can't plant git hooks or replace the tools other tasks
+// run outside the sandbox.
+//
+// On Linux, commands run under bubblewrap (bwrap), which must be installed.
+// On macOS, commands run under sandbox-exec. Sandboxed tasks fail on other
+// platforms rather than run unrestricted.
+type Sandbox struct {
+	// Network allows network access.
+	Network bool `json:"network,omitempty"`
+
+	// Writable lists additional writable directories, absolute or relative
+	// to the git root, e.g. a build cache.
+	Writable []string `json:"writable,omitempty"`
+}
+
+// String formats the sandbox for plan output, e.g. "sandbox, network".
+func (s Sandbox) String() string {
+	parts := []string{"sandbox"}
+	if s.Network {
+		parts = append(parts, "network")
+	}
+	for _, dir := range s.Writable {
+		parts = append(parts, "writable "+dir)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WithSandbox runs each command of the task in a sandbox, including
+// commands of nested tasks. Tool installers (tasks marked with AsTool) run
+// outside the sandbox, as they need the network and write to caches.
+// The sandbox is shown by ./pok plan.
+//
+// Example:
+//
+//	pocket.Clone(markdown.Format, pocket.WithSandbox(pocket.Sandbox{}))
+func WithSandbox(sandbox Sandbox) TaskOpt {
+	return func(td *TaskDef) {
+		td.sandbox = &sandbox
+	}
+}
+
+// withSandbox sets the sandbox for commands run with ctx. A nil sandbox
+// runs commands unrestricted.
+func withSandbox(ctx context.Context, sandbox *Sandbox) context.Context {
+	ec := getExecContext(ctx)
+	newEC := *ec
+	newEC.sandbox = sandbox
+	return withExecContext(ctx, &newEC)
+}
+
+// runRestricted runs cmd in the sandbox, if not nil, and with the limits.
+func runRestricted(cmd *exec.Cmd, sandbox *Sandbox, limits Limits) error {
+	// A command that was not found is left to Run to report.
+	if sandbox != nil && cmd.Err == nil {
+		prefix, err := sandboxPrefix(sandbox.writableDirs(), sandbox.readOnlyDirs(), sandbox.Network)
+		if err != nil {
+			return err
+		}
+		wrapper, err := exec.LookPath(prefix[0])
+		if err != nil {
+			// Not wrapped with %w, as it is not the command that is missing.
+			return fmt.Errorf("sandbox: %s is not installed", prefix[0])
+		}
+		// Restore the command afterwards, so that errors and the run log
+		// show it rather than the wrapper.
+		path, args := cmd.Path, cmd.Args
+		defer func() { cmd.Path, cmd.Args = path, args }()
+		cmd.Args = append(append(prefix, path), args[1:]...)
+		cmd.Path = wrapper
+	}
+	if limits.IsZero() {
+		return cmd.Run()
+	}
+	return runWithLimits(cmd, limits)
+}
+
+// writableDirs returns the absolute directories sandboxed commands may
+// write to, with symlinks resolved. Directories that don't exist are left
+// out.
+func (s Sandbox) writableDirs() []string {
+	dirs := append([]string{GitRoot(), os.TempDir()}, s.Writable...)
+	var result []string
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			dir = FromGitRoot(dir)
+		}
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+		result = append(result, resolved)
+	}
+	return result
+}
+
+// readOnlyDirs returns the absolute paths within the writable directories
+// that sandboxed commands may not write to, with symlinks resolved: .git and
+// the installed tools. Paths that don't exist or are listed in Writable are
+// left out.
+func (s Sandbox) readOnlyDirs() []string {
+	var result []string
+	for _, dir := range []string{FromGitRoot(".git"), FromBinDir(), FromToolsDir()} {
+		if slices.ContainsFunc(s.Writable, func(w string) bool {
+			if !filepath.IsAbs(w) {
+				w = FromGitRoot(w)
+			}
+			return filepath.Clean(w) == dir
+		}) {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+		result = append(result, resolved)
+	}
+	return result
+}
+
+// bwrapArgs returns the bubblewrap command line that runs a command with a
+// read-only view of the file system, except for the writable directories.
+// The read-only paths are mounted on top of the writable ones.
+func bwrapArgs(writable, readOnly []string, network bool) []string {
+	args := []string{"bwrap", "--die-with-parent", "--ro-bind", "/", "/", "--dev-bind", "/dev", "/dev"}
+	for _, dir := range writable {
+		args = append(args, "--bind", dir, dir)
+	}
+	for _, dir := range readOnly {
+		args = append(args, "--ro-bind", dir, dir)
+	}
+	if !network {
+		args = append(args, "--unshare-net")
+	}
+	return append(args, "--")
+}
+
+// sandboxExecArgs returns the sandbox-exec command line that denies writes
+// outside the writable directories and to the read-only paths. The last
+// matching rule of a profile wins, so the read-only paths are denied last.
+func sandboxExecArgs(writable, readOnly []string, network bool) []string {
+	var profile strings.Builder
+	profile.WriteString("(version 1)\n(allow default)\n(deny file-write*)\n")
+	profile.WriteString(`(allow file-write* (literal "/dev/null") (literal "/dev/tty") (regex #"^/dev/fd/")`)
+	for _, dir := range writable {
+		fmt.Fprintf(&profile, " (subpath %s)", strconv.Quote(dir))
+	}
+	profile.WriteString(")\n")
+	if len(readOnly) > 0 {
+		profile.WriteString("(deny file-write*")
+		for _, dir := range readOnly {
+			fmt.Fprintf(&profile, " (subpath %s)", strconv.Quote(dir))
+		}
+		profile.WriteString(")\n")
+	}
+	if !network {
+		profile.WriteString("(deny network*)\n")
+	}
+	return []string{"sandbox-exec", "-p", profile.String()}
+}
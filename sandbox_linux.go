@@ -0,0 +1,8 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+// sandboxPrefix returns the command line to run a sandboxed command with.
+func sandboxPrefix(writable, readOnly []string, network bool) ([]string, error) {
+	return bwrapArgs(writable, readOnly, network), nil
+}
@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: MIT
+
+//go:build !linux && !darwin
+
+package pocket
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// sandboxPrefix returns the command line to run a sandboxed command with.
+func sandboxPrefix(_, _ []string, _ bool) ([]string, error) {
+	return nil, fmt.Errorf("sandbox: not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestBwrapArgs(t *testing.T) {
+	got := bwrapArgs([]string{"/repo", "/tmp"}, []string{"/repo/.git", "/repo/.pocket/tools"}, false)
+	want := []string{
+		"bwrap", "--die-with-parent", "--ro-bind", "/", "/", "--dev-bind", "/dev", "/dev",
+		"--bind", "/repo", "/repo", "--bind", "/tmp", "/tmp",
+		"--ro-bind", "/repo/.git", "/repo/.git", "--ro-bind", "/repo/.pocket/tools", "/repo/.pocket/tools",
+		"--unshare-net", "--",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("bwrapArgs() = %q, want %q", got, want)
+	}
+	if got := bwrapArgs(nil, nil, true); slices.Contains(got, "--unshare-net") {
+		t.Errorf("bwrapArgs() with network = %q, must not unshare the network", got)
+	}
+}
+
+func TestSandboxExecArgs(t *testing.T) {
+	got := sandboxExecArgs([]string{"/Users/me/repo"}, []string{"/Users/me/repo/.git"}, false)
+	if len(got) != 3 || got[0] != "sandbox-exec" || got[1] != "-p" {
+		t.Fatalf("sandboxExecArgs() = %q", got)
+	}
+	for _, want := range []string{
+		"(deny file-write*)",
+		`(subpath "/Users/me/repo")`,
+		`(deny file-write* (subpath "/Users/me/repo/.git"))`,
+		"(deny network*)",
+	} {
+		if !strings.Contains(got[2], want) {
+			t.Errorf("profile does not contain %q:\n%s", want, got[2])
+		}
+	}
+	if got := sandboxExecArgs(nil, nil, true); strings.Contains(got[2], "network") {
+		t.Errorf("profile with network must not deny it:\n%s", got[2])
+	}
+}
+
+func TestSandboxReadOnlyDirs(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore := gitroot.Override(root)
+	defer restore()
+	for _, dir := range []string{".git", ".pocket/tools"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// .pocket/bin doesn't exist and is left out.
+	want := []string{filepath.Join(root, ".git"), filepath.Join(root, ".pocket", "tools")}
+	if got := (Sandbox{}).readOnlyDirs(); !slices.Equal(got, want) {
+		t.Errorf("readOnlyDirs() = %q, want %q", got, want)
+	}
+	// Listing a path as writable opts out of its protection.
+	if got := (Sandbox{Writable: []string{".pocket/tools"}}).readOnlyDirs(); !slices.Equal(got, want[:1]) {
+		t.Errorf("readOnlyDirs() with writable tools = %q, want %q", got, want[:1])
+	}
+}
+
+func TestWithSandbox(t *testing.T) {
+	var taskSandbox, toolSandbox *Sandbox
+	install := Task("install:tool", "install tool", Do(func(ctx context.Context) error {
+		toolSandbox = getExecContext(ctx).sandbox
+		return nil
+	}), AsHidden(), AsTool("tool", "v1.0.0"))
+	task := Task("format", "format files", Serial(install, Do(func(ctx context.Context) error {
+		taskSandbox = getExecContext(ctx).sandbox
+		return nil
+	})), WithSandbox(Sandbox{Network: true}))
+
+	plan, err := NewEngine(task).Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() failed: %v", err)
+	}
+	var stdout strings.Builder
+	ctx := TestContext(&Output{Stdout: &stdout, Stderr: io.Discard})
+	printPlanSteps(ctx, plan.Steps(), "", false, false)
+	if want := "format (sandbox, network) - format files"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("plan output %q does not contain %q", stdout.String(), want)
+	}
+
+	if err := task.Run(TestContext(&Output{Stdout: io.Discard, Stderr: io.Discard})); err != nil {
+		t.Fatal(err)
+	}
+	if taskSandbox == nil || !taskSandbox.Network {
+		t.Errorf("task commands must run in the sandbox, got %v", taskSandbox)
+	}
+	if toolSandbox != nil {
+		t.Errorf("tool installers must run outside the sandbox, got %v", toolSandbox)
+	}
+}
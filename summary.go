@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Formats for the -summary flag.
+const (
+	summaryAuto = "auto" // text, if more than one task ran
+	summaryText = "text"
+	summaryJSON = "json"
+	summaryNone = "none"
+)
+
+// timingSummary is the summary of a run printed with -summary.
+type timingSummary struct {
+	Task       string       `json:"task"` // invoked task
+	DurationMS int64        `json:"duration_ms"`
+	Status     string       `json:"status"` // ok or failed
+	ExitCode   int          `json:"exit_code"`
+	Tasks      []taskTiming `json:"tasks"` // in completion order
+	duration   time.Duration
+}
+
+// taskTiming is a task run in a timingSummary.
+type taskTiming struct {
+	Task       string `json:"task"`
+	Path       string `json:"path"`
+	DurationMS int64  `json:"duration_ms"`
+	Status     string `json:"status"` // ok, failed, cached or skipped
+	duration   time.Duration
+}
+
+// newTimingSummary builds the summary of a run of the task name from the
+// tasks recorded in the run log (see tapEntries).
+func newTimingSummary(tasks []runLogEntry, name string, d time.Duration, err error) timingSummary {
+	s := timingSummary{
+		Task:       name,
+		DurationMS: d.Milliseconds(),
+		Status:     "ok",
+		Tasks:      []taskTiming{},
+		duration:   d,
+	}
+	if err != nil {
+		s.Status, s.ExitCode = "failed", cliExitCode(err)
+	}
+	for _, t := range tapEntries(tasks, name, d, err) {
+		status := "ok"
+		switch {
+		case t.skipped:
+			status = "skipped"
+		case t.cached:
+			status = "cached"
+		case t.err != nil:
+			status = "failed"
+		}
+		s.Tasks = append(s.Tasks, taskTiming{
+			Task:       t.name,
+			Path:       t.path,
+			DurationMS: t.duration.Milliseconds(),
+			Status:     status,
+			duration:   t.duration,
+		})
+	}
+	return s
+}
+
+// writeText writes the summary as a table, e.g.
+//
+//	Summary:
+//	  go-lint  .             4.2s   ok
+//	  go-test  services/api  12.5s  failed
+//	  total                  12.9s  failed (1 of 2 tasks)
+func (s timingSummary) writeText(w io.Writer) error {
+	fmt.Fprintln(w, "\nSummary:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	failed := 0
+	for _, t := range s.Tasks {
+		if t.Status == "failed" {
+			failed++
+		}
+		duration := "-"
+		if t.Status != "skipped" && t.Status != "cached" {
+			duration = formatDuration(t.duration)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", t.Task, t.Path, duration, t.Status)
+	}
+	status := s.Status
+	if failed > 0 {
+		status += fmt.Sprintf(" (%d of %d tasks)", failed, len(s.Tasks))
+	}
+	fmt.Fprintf(tw, "  total\t\t%s\t%s\n", formatDuration(s.duration), status)
+	return tw.Flush()
+}
+
+// writeJSON writes the summary as JSON.
+func (s timingSummary) writeJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// formatDuration formats d for the summary, e.g. "850ms" or "12.5s".
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
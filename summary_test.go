@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimingSummary(t *testing.T) {
+	tasks := []runLogEntry{
+		{name: "go-lint", path: ".", duration: 4200 * time.Millisecond},
+		{name: "go-test", path: "services/api", duration: 12500 * time.Millisecond, err: errors.New("exit status 1"), exitCode: 1},
+		{name: "go-vulncheck", path: ".", cached: true},
+		{name: "md-format", path: ".", skipped: true},
+	}
+	s := newTimingSummary(tasks, "all", 12900*time.Millisecond, errors.New("go-test failed"))
+
+	var text strings.Builder
+	if err := s.writeText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\nSummary:\n",
+		"  go-lint       .             4.2s   ok\n",
+		"  go-test       services/api  12.5s  failed\n",
+		"  go-vulncheck  .             -      cached\n",
+		"  md-format     .             -      skipped\n",
+		"  total                       12.9s  failed (1 of 4 tasks)\n",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text summary missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var data strings.Builder
+	if err := s.writeJSON(&data); err != nil {
+		t.Fatal(err)
+	}
+	var got timingSummary
+	if err := json.Unmarshal([]byte(data.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Task != "all" || got.Status != "failed" || got.ExitCode != ExitFailure || got.DurationMS != 12900 || len(got.Tasks) != 4 {
+		t.Errorf("JSON summary = %+v", got)
+	}
+	if tt := got.Tasks[1]; tt.Task != "go-test" || tt.Path != "services/api" || tt.DurationMS != 12500 || tt.Status != "failed" {
+		t.Errorf("JSON summary task = %+v", tt)
+	}
+
+	// A run that failed outside any task is reported as a failed task.
+	s = newTimingSummary(tasks[:1], "all", time.Second, errors.New("uncommitted changes"))
+	if len(s.Tasks) != 2 || s.Tasks[1].Task != "all" || s.Tasks[1].Status != "failed" {
+		t.Errorf("summary tasks = %+v, want a failed all task", s.Tasks)
+	}
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SyncFiles creates a Runnable that copies source to each of targets, to
+// keep shared files such as lint configs, license files or CI snippets
+// identical across a monorepo. Paths are relative to the git root.
+//
+// The source may be a file or a directory, which is copied recursively.
+// A target that ends with "/" or is an existing directory receives the
+// source by its base name; a target with glob characters is expanded to the
+// matching directories. Files are only written when their content or mode
+// differs, and files in a target that are not in the source are left alone.
+//
+// Add it to Config.Generate, so the git-diff check catches drift:
+//
+//	Generate: []pocket.Runnable{
+//	    pocket.SyncFiles(".golangci.yml", "services/*/"),
+//	}
+func SyncFiles(source string, targets ...string) Runnable {
+	return Do(func(ctx context.Context) error {
+		return syncFiles(ctx, source, targets)
+	})
+}
+
+// syncFiles is the internal implementation of SyncFiles.
+func syncFiles(ctx context.Context, source string, targets []string) error {
+	src := FromGitRoot(filepath.FromSlash(source))
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("sync %s: %w", source, err)
+	}
+	dests, err := syncDests(src, targets)
+	if err != nil {
+		return fmt.Errorf("sync %s: %w", source, err)
+	}
+	for _, dest := range dests {
+		if filepath.Clean(dest) == filepath.Clean(src) {
+			continue
+		}
+		if !info.IsDir() {
+			if err := syncFile(ctx, src, dest); err != nil {
+				return err
+			}
+			continue
+		}
+		err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(src, path)
+			if err != nil {
+				return err
+			}
+			return syncFile(ctx, path, filepath.Join(dest, rel))
+		})
+		if err != nil {
+			return fmt.Errorf("sync %s: %w", source, err)
+		}
+	}
+	return nil
+}
+
+// syncDests resolves targets to absolute destination paths for src.
+func syncDests(src string, targets []string) ([]string, error) {
+	var dests []string
+	for _, target := range targets {
+		path := FromGitRoot(filepath.FromSlash(target))
+		if strings.ContainsAny(target, "*?[") {
+			matches, err := filepath.Glob(path)
+			if err != nil {
+				return nil, fmt.Errorf("target %q: %w", target, err)
+			}
+			for _, m := range matches {
+				if fi, err := os.Stat(m); err == nil && fi.IsDir() {
+					dests = append(dests, filepath.Join(m, filepath.Base(src)))
+				}
+			}
+			continue
+		}
+		if fi, err := os.Stat(path); strings.HasSuffix(target, "/") || (err == nil && fi.IsDir()) {
+			path = filepath.Join(path, filepath.Base(src))
+		}
+		dests = append(dests, path)
+	}
+	return dests, nil
+}
+
+// syncFile copies src to dest unless dest already has the same content and
+// mode.
+func syncFile(ctx context.Context, src, dest string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if existing, err := os.ReadFile(dest); err == nil && bytes.Equal(existing, data) {
+		if fi, err := os.Stat(dest); err == nil && fi.Mode().Perm() == info.Mode().Perm() {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	if err := os.WriteFile(dest, data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("write %s: %w", dest, err)
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(dest, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if Verbose(ctx) {
+		rel, _ := filepath.Rel(GitRoot(), dest)
+		Printf(ctx, "  Synced %s\n", filepath.ToSlash(rel))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestSyncFiles(t *testing.T) {
+	// Not parallel due to shared git root.
+	tmpDir := t.TempDir()
+	defer gitroot.Override(tmpDir)()
+
+	files := map[string]string{
+		"shared/.golangci.yml":       "linters: {}\n",
+		"shared/ci/lint.yml":         "lint\n",
+		"shared/ci/sub/test.yml":     "test\n",
+		"services/api/go.mod":        "module api\n",
+		"services/web/.golangci.yml": "stale\n",
+		"services/web/ci/keep.yml":   "keep\n",
+	}
+	for path, content := range files {
+		full := filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := TestContext(discardOutput())
+	if err := syncFiles(ctx, "shared/.golangci.yml", []string{"services/*/", "tools/lint.yml"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncFiles(ctx, "shared/ci", []string{"services/web/"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"services/api/.golangci.yml":   "linters: {}\n",
+		"services/web/.golangci.yml":   "linters: {}\n",
+		"tools/lint.yml":               "linters: {}\n",
+		"services/web/ci/lint.yml":     "lint\n",
+		"services/web/ci/sub/test.yml": "test\n",
+		"services/web/ci/keep.yml":     "keep\n",
+	}
+	for path, content := range want {
+		got, err := os.ReadFile(filepath.Join(tmpDir, path))
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", path, got, content)
+		}
+	}
+
+	if err := syncFiles(ctx, "shared/missing.yml", []string{"services/"}); err == nil {
+		t.Error("syncFiles() with missing source: expected error")
+	}
+}
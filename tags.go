@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// WithTags returns a copy of the task with the given tags added. Tags select
+// subsets of the tree for a run with ./pok -tags and -skip-tags, e.g. to run
+// only the slow tests, or everything but the integration tests. The tags are
+// shown in task help and by ./pok plan.
+//
+// Example:
+//
+//	AutoRun: pocket.Parallel(
+//	    golang.Lint,
+//	    pocket.Clone(golang.Test, pocket.Named("integration-test")).WithTags("slow", "integration"),
+//	)
+func (f *TaskDef) WithTags(tags ...string) *TaskDef {
+	return Clone(f, func(td *TaskDef) {
+		td.tags = append(slices.Clip(td.tags), tags...)
+	})
+}
+
+// Tags returns the tags of the task, see WithTags.
+func (f *TaskDef) Tags() []string {
+	return f.tags
+}
+
+// hasTag reports whether the task has any of the tags.
+func (f *TaskDef) hasTag(tags []string) bool {
+	return slices.ContainsFunc(f.tags, func(tag string) bool {
+		return slices.Contains(tags, tag)
+	})
+}
+
+// tagFilter selects the tasks of a run by tag. See the -tags and
+// -skip-tags flags.
+type tagFilter struct {
+	only     []string // run only tasks with one of these tags (empty = all)
+	skip     []string // skip tasks with one of these tags
+	selected bool     // inside a task selected by only, so all tasks run
+}
+
+// allows reports whether the task runs with the filter, and whether it is
+// selected by only, so that all tasks inside it run. A task without the
+// tags of only still runs if tasks inside it have them, so that a composite
+// task can run its tagged tasks. Hidden tasks, such as tool installers, are
+// only filtered by skip.
+func (t *tagFilter) allows(f *TaskDef) (run, selected bool) {
+	if f.hasTag(t.skip) {
+		return false, false
+	}
+	if len(t.only) == 0 || t.selected || f.hidden {
+		return true, false
+	}
+	if f.hasTag(t.only) {
+		return true, true
+	}
+	tasks, _ := CollectTasks(f.body)
+	return slices.ContainsFunc(tasks, func(info TaskInfo) bool {
+		return slices.ContainsFunc(info.Tags, func(tag string) bool {
+			return slices.Contains(t.only, tag)
+		})
+	}), false
+}
+
+// tagRunnable runs a Runnable with tasks selected by tag.
+type tagRunnable struct {
+	filter tagFilter
+	inner  Runnable
+}
+
+func (t *tagRunnable) run(ctx context.Context) error {
+	ec := getExecContext(ctx)
+	newEC := *ec
+	filter := t.filter
+	newEC.tags = &filter
+	return t.inner.run(withExecContext(ctx, &newEC))
+}
+
+// withTagSelected returns a context in which the tags of -tags no longer
+// filter tasks, for the tasks inside a selected task.
+func withTagSelected(ctx context.Context) context.Context {
+	ec := getExecContext(ctx)
+	newEC := *ec
+	filter := *ec.tags
+	filter.selected = true
+	newEC.tags = &filter
+	return withExecContext(ctx, &newEC)
+}
+
+// validateTags checks that each tag given to the flag is used by a task.
+func validateTags(plan *ConfigPlan, flag string, tags []string) error {
+	for _, tag := range tags {
+		if !slices.ContainsFunc(plan.Tasks, func(f *TaskDef) bool { return slices.Contains(f.tags, tag) }) {
+			return fmt.Errorf("%s: no task is tagged %s", flag, tag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// tagTree returns a tree with tagged tasks and the list the tasks append
+// their names to when they run.
+func tagTree() (Runnable, *[]string) {
+	var ran []string
+	record := func(name string, tags ...string) *TaskDef {
+		return Task(name, name, Do(func(_ context.Context) error {
+			ran = append(ran, name)
+			return nil
+		})).WithTags(tags...)
+	}
+	install := Task("install:tool", "install tool", Do(func(_ context.Context) error {
+		ran = append(ran, "install:tool")
+		return nil
+	}), AsHidden())
+	tree := Serial(
+		record("lint"),
+		record("unit-test", "test"),
+		record("integration-test", "test", "slow"),
+		Task("go", "go tasks", Serial(record("go-fix"), record("go-bench", "slow"))),
+		Task("e2e", "end-to-end tests", Serial(install, record("e2e-setup"))).WithTags("slow"),
+	)
+	return tree, &ran
+}
+
+func TestTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter tagFilter
+		want   []string
+	}{
+		{
+			name:   "only",
+			filter: tagFilter{only: []string{"test"}},
+			want:   []string{"unit-test", "integration-test"},
+		},
+		{
+			name:   "only runs tagged tasks inside composite tasks",
+			filter: tagFilter{only: []string{"slow"}},
+			want:   []string{"integration-test", "go-bench", "install:tool", "e2e-setup"},
+		},
+		{
+			name:   "skip",
+			filter: tagFilter{skip: []string{"slow"}},
+			want:   []string{"lint", "unit-test", "go-fix"},
+		},
+		{
+			name:   "only and skip",
+			filter: tagFilter{only: []string{"test"}, skip: []string{"slow"}},
+			want:   []string{"unit-test"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, ran := tagTree()
+			root := &tagRunnable{filter: tt.filter, inner: tree}
+			if err := runWithContext(context.Background(), root, discardOutput(), ".", false, nil, nil); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(*ran, tt.want) {
+				t.Errorf("ran %v, want %v", *ran, tt.want)
+			}
+		})
+	}
+}
+
+func TestTags_SkipHeader(t *testing.T) {
+	tree, _ := tagTree()
+	root := &tagRunnable{filter: tagFilter{skip: []string{"slow"}}, inner: tree}
+	var stdout strings.Builder
+	out := &Output{Stdout: &stdout, Stderr: discardOutput().Stderr}
+	if err := runWithContext(context.Background(), root, out, ".", false, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), ":: e2e (skipped by -skip-tags)") {
+		t.Errorf("expected skipped header, got:\n%s", stdout.String())
+	}
+}
+
+func TestTaskDef_WithTags(t *testing.T) {
+	base := Task("test", "run tests", func(_ context.Context) error { return nil })
+	tagged := base.WithTags("slow").WithTags("integration")
+
+	if len(base.Tags()) != 0 {
+		t.Error("WithTags modified the original task")
+	}
+	if want := []string{"slow", "integration"}; !slices.Equal(tagged.Tags(), want) {
+		t.Errorf("Tags() = %v, want %v", tagged.Tags(), want)
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	tree, _ := tagTree()
+	plan := BuildConfigPlan(Config{AutoRun: tree})
+
+	if err := validateTags(plan, "-tags", []string{"slow", "test"}); err != nil {
+		t.Errorf("validateTags() = %v, want nil", err)
+	}
+	err := validateTags(plan, "-skip-tags", []string{"slow", "flaky"})
+	if err == nil || err.Error() != "-skip-tags: no task is tagged flaky" {
+		t.Errorf("validateTags() = %v, want unknown tag error", err)
+	}
+}
+
+func TestWithTags_Plan(t *testing.T) {
+	task := Task("test", "run tests", func(_ context.Context) error { return nil }).WithTags("slow", "integration")
+
+	plan, err := NewEngine(task).Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() failed: %v", err)
+	}
+	if tasks := plan.Tasks(); len(tasks) != 1 || !slices.Equal(tasks[0].Tags, []string{"slow", "integration"}) {
+		t.Errorf("expected task info with tags, got %+v", tasks)
+	}
+
+	var stdout strings.Builder
+	ctx := TestContext(&Output{Stdout: &stdout, Stderr: discardOutput().Stderr})
+	printPlanSteps(ctx, plan.Steps(), "", false, false)
+	if want := "test (tags: slow, integration) - run tests"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("plan output %q does not contain %q", stdout.String(), want)
+	}
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Output formats for the -output flag.
+const (
+	outputText = "text"
+	outputTAP  = "tap"
+	outputJSON = "json" // see taskEvent
+)
+
+// tapEntries returns the test points for a run. If the run failed but no
+// task did (e.g. the git-diff check in "all", or an error outside any task),
+// a failing point named after the invoked function is added so the TAP
+// stream never reports success for a failed run.
+func tapEntries(tasks []runLogEntry, name string, d time.Duration, err error) []runLogEntry {
+	if err == nil || slices.ContainsFunc(tasks, func(t runLogEntry) bool { return t.err != nil }) {
+		return tasks
+	}
+	return append(tasks, runLogEntry{
+		name:     name,
+		path:     ".",
+		duration: d,
+		exitCode: cliExitCode(err),
+		err:      err,
+	})
+}
+
+// writeTAP writes task results as a TAP version 13 stream.
+// Each task run is a test point; failures carry a YAML diagnostic block.
+func writeTAP(w io.Writer, tasks []runLogEntry) {
+	fmt.Fprintln(w, "TAP version 13")
+	fmt.Fprintf(w, "1..%d\n", len(tasks))
+	for i, t := range tasks {
+		status := "ok"
+		if t.err != nil {
+			status = "not ok"
+		}
+		desc := t.name
+		if t.path != "" && t.path != "." {
+			desc += " [" + t.path + "]"
+		}
+		if t.skipped {
+			fmt.Fprintf(w, "ok %d - %s # SKIP skipped-by-flag\n", i+1, desc)
+			continue
+		}
+		fmt.Fprintf(w, "%s %d - %s\n", status, i+1, desc)
+		fmt.Fprintln(w, "  ---")
+		fmt.Fprintf(w, "  duration_ms: %d\n", t.duration.Round(time.Millisecond).Milliseconds())
+		if t.err != nil {
+			fmt.Fprintf(w, "  exit_code: %d\n", t.exitCode)
+			fmt.Fprintf(w, "  message: %q\n", strings.TrimSpace(t.err.Error()))
+		}
+		fmt.Fprintln(w, "  ...")
+	}
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTAP(t *testing.T) {
+	var buf strings.Builder
+	writeTAP(&buf, []runLogEntry{
+		{name: "go-lint", path: ".", duration: 1500 * time.Millisecond},
+		{name: "go-test", path: "services/api", duration: time.Second, exitCode: 1, err: errors.New("exit status 1")},
+	})
+
+	want := `TAP version 13
+1..2
+ok 1 - go-lint
+  ---
+  duration_ms: 1500
+  ...
+not ok 2 - go-test [services/api]
+  ---
+  duration_ms: 1000
+  exit_code: 1
+  message: "exit status 1"
+  ...
+`
+	if got := buf.String(); got != want {
+		t.Errorf("writeTAP() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteTAP_Skipped(t *testing.T) {
+	var buf strings.Builder
+	writeTAP(&buf, []runLogEntry{{name: "md-format", path: "docs", skipped: true}})
+	want := "TAP version 13\n1..1\nok 1 - md-format [docs] # SKIP skipped-by-flag\n"
+	if got := buf.String(); got != want {
+		t.Errorf("writeTAP() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTapEntries(t *testing.T) {
+	passed := []runLogEntry{{name: "go-lint", path: "."}}
+	failed := []runLogEntry{{name: "go-test", path: ".", err: errors.New("exit status 1")}}
+	runErr := errors.New("uncommitted changes detected in 1 file(s)")
+
+	if got := tapEntries(passed, "all", time.Second, nil); len(got) != 1 {
+		t.Errorf("expected successful run to keep %d entries, got %d", 1, len(got))
+	}
+	if got := tapEntries(failed, "all", time.Second, runErr); len(got) != 1 {
+		t.Errorf("expected failed task to be reported alone, got %d entries", len(got))
+	}
+
+	got := tapEntries(passed, "all", time.Second, runErr)
+	if len(got) != 2 {
+		t.Fatalf("expected a failing point for the run, got %d entries", len(got))
+	}
+	var buf strings.Builder
+	writeTAP(&buf, got)
+	if !strings.Contains(buf.String(), "not ok 2 - all\n") {
+		t.Errorf("expected not ok point for all, got:\n%s", buf.String())
+	}
+}
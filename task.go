@@ -1,7 +1,17 @@
+// SPDX-License-Identifier: MIT
+
 package pocket
 
 import (
 	"context"
+	"errors"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
 // TaskDef represents a named function that can be executed.
@@ -9,6 +19,7 @@ import (
 //
 // The body can be:
 //   - pocket.Run(name, args...) - static command
+//   - pocket.RunInteractive(name, args...) - command attached to the terminal
 //   - pocket.Do(fn) - dynamic commands or arbitrary Go code
 //   - pocket.Serial(...) or pocket.Parallel(...) - compositions
 //
@@ -42,12 +53,33 @@ import (
 //	    pocket.InstallGo("github.com/org/linter", "v1.0.0"),
 //	).Hidden()
 type TaskDef struct {
-	name   string
-	usage  string
-	body   Runnable
-	opts   any
-	hidden bool
-	silent bool // suppress task header output (for machine-readable output)
+	name      string
+	usage     string
+	longUsage string    // detailed help shown by ./pok <task> -h
+	examples  []Example // example invocations shown by ./pok <task> -h
+	tool      *ToolInfo // managed tool installed by this task
+	body      Runnable
+	opts      any
+	hidden    bool
+	silent    bool          // suppress task header output (for machine-readable output)
+	dir       string        // fixed directory set by InDir (relative to git root)
+	limits    Limits        // resource limits set by WithLimits
+	sandbox   *Sandbox      // sandbox set by WithSandbox
+	timeout   time.Duration // run time limit set by WithTimeout
+	tags      []string      // tags set by WithTags
+	inputs    []string      // globs of files read, set by WithInputs
+	outputs   []string      // globs of files written, set by WithOutputs
+	cache     bool          // skip runs with unchanged inputs, set by WithCache
+	cacheEnv  []string      // environment variables in the cache key, set by WithCacheEnv
+	upToDate  bool          // skip runs with outputs newer than inputs, set by WithUpToDate
+	group     string        // help group, see WithGroup
+	args      string        // positional arguments accepted, e.g. "<files...>"
+}
+
+// Example is an example invocation of a task, shown in task help.
+type Example struct {
+	Command     string `json:"command"`               // e.g. "./pok deploy -env=prod"
+	Description string `json:"description,omitempty"` // what the example does
 }
 
 // TaskOpt configures a task created with Task().
@@ -83,6 +115,7 @@ func Task(name, usage string, body any, opts ...TaskOpt) *TaskDef {
 		name:  name,
 		usage: usage,
 		body:  toRunnable(body),
+		group: callerGroup(),
 	}
 	for _, opt := range opts {
 		opt(td)
@@ -108,6 +141,56 @@ func Opts(opts any) TaskOpt {
 	}
 }
 
+// WithGroup lists the task under group in ./pok -h, instead of the group
+// derived from the package that created it.
+//
+// Example:
+//
+//	pocket.Clone(myLint, pocket.WithGroup("lint"))
+func WithGroup(group string) TaskOpt {
+	return func(td *TaskDef) {
+		td.group = group
+	}
+}
+
+// callerGroup returns the help group of a task created by the caller of
+// Task: the name of the calling package, e.g. "golang" for tasks/golang.
+// Tasks created by package main (the config) or by pocket itself have no
+// group and are listed under "custom".
+func callerGroup() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	pkg := funcPackage(frame.Function)
+	if pkg == "" || pkg == "main" || pkg == pocketPackage {
+		return ""
+	}
+	elems := strings.Split(pkg, "/")
+	name := elems[len(elems)-1]
+	// Major version suffixes are not package names, e.g. ".../v2".
+	if len(elems) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = elems[len(elems)-2]
+	}
+	return name
+}
+
+// pocketPackage is the import path of this package.
+var pocketPackage = funcPackage(runtime.FuncForPC(reflect.ValueOf(funcPackage).Pointer()).Name())
+
+// funcPackage returns the import path of the package of a function name as
+// reported by the runtime, e.g. "example.com/m/pkg" for
+// "example.com/m/pkg.(*T).Method.func1".
+func funcPackage(fn string) string {
+	slash := strings.LastIndex(fn, "/")
+	dot := strings.Index(fn[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return fn[:slash+1+dot]
+}
+
 // AsHidden marks a task as hidden from CLI help.
 // Hidden tasks can still be executed but don't appear in ./pok -h.
 // Use this for internal tasks like tool installers.
@@ -124,6 +207,75 @@ func AsHidden() TaskOpt {
 	}
 }
 
+// ToolInfo identifies a managed tool and its pinned version.
+type ToolInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// Datasource and DepName identify where new versions are published,
+	// using Renovate's names (see ToolSource). Empty if unknown.
+	Datasource string `json:"datasource,omitempty"`
+	DepName    string `json:"depName,omitempty"`
+}
+
+// Tool datasources understood by ToolSource, named as in Renovate.
+const (
+	// DatasourceGo looks up versions of a Go module (DepName) on the Go
+	// module proxy.
+	DatasourceGo = "go"
+	// DatasourceGitHubReleases looks up the latest release of a GitHub
+	// repository (DepName "owner/repo").
+	DatasourceGitHubReleases = "github-releases"
+)
+
+// AsTool marks the task as the installer of a managed tool pinned to version.
+// Pinned versions are included in introspection, e.g. to invalidate the tool
+// cache of generated CI workflows when a version changes.
+//
+// Example:
+//
+//	var Install = pocket.Task("install:tool", "install tool",
+//	    pocket.InstallGo("github.com/org/tool", Version),
+//	    pocket.AsHidden(),
+//	    pocket.AsTool("tool", Version),
+//	)
+func AsTool(name, version string) TaskOpt {
+	return func(td *TaskDef) {
+		tool := ToolInfo{}
+		if td.tool != nil {
+			tool = *td.tool
+		}
+		tool.Name, tool.Version = name, version
+		td.tool = &tool
+	}
+}
+
+// ToolSource records where new versions of the task's tool are published,
+// for version reports such as the tools-versions task. Use the same values
+// as the tool's Renovate annotation.
+//
+// Example:
+//
+//	// renovate: datasource=go depName=github.com/org/tool
+//	const Version = "v1.0.0"
+//
+//	var Install = pocket.Task("install:tool", "install tool",
+//	    pocket.InstallGo("github.com/org/tool/cmd/tool", Version),
+//	    pocket.AsHidden(),
+//	    pocket.AsTool("tool", Version),
+//	    pocket.ToolSource(pocket.DatasourceGo, "github.com/org/tool"),
+//	)
+func ToolSource(datasource, depName string) TaskOpt {
+	return func(td *TaskDef) {
+		tool := ToolInfo{}
+		if td.tool != nil {
+			tool = *td.tool
+		}
+		tool.Datasource, tool.DepName = datasource, depName
+		td.tool = &tool
+	}
+}
+
 // AsSilent suppresses the task header output (e.g., ":: task-name").
 // Use this for tasks that produce machine-readable output (JSON, etc.).
 //
@@ -172,6 +324,38 @@ func Usage(usage string) TaskOpt {
 	}
 }
 
+// WithLongUsage sets detailed help text for the task, shown below the
+// one-line usage by ./pok <task> -h. Use it to explain options and behavior
+// that don't fit in the usage string.
+//
+// Example:
+//
+//	pocket.Task("deploy", "deploy to environment", deployImpl,
+//	    pocket.Opts(DeployOptions{}),
+//	    pocket.WithLongUsage(`Deploys the service to the given environment.
+//
+//	Production deploys require a clean working tree.`),
+//	)
+func WithLongUsage(text string) TaskOpt {
+	return func(td *TaskDef) {
+		td.longUsage = text
+	}
+}
+
+// WithExample adds an example invocation, shown by ./pok <task> -h.
+// Multiple calls accumulate examples.
+//
+// Example:
+//
+//	pocket.Task("deploy", "deploy to environment", deployImpl,
+//	    pocket.WithExample("./pok deploy -env=prod -dry-run", "preview a production deploy"),
+//	)
+func WithExample(command, description string) TaskOpt {
+	return func(td *TaskDef) {
+		td.examples = append(slices.Clip(td.examples), Example{Command: command, Description: description})
+	}
+}
+
 // Name returns the function's CLI name.
 func (f *TaskDef) Name() string {
 	return f.name
@@ -182,6 +366,32 @@ func (f *TaskDef) Usage() string {
 	return f.usage
 }
 
+// Group returns the group the task is listed under in help, or "" for the
+// config's own tasks. See WithGroup.
+func (f *TaskDef) Group() string {
+	return f.group
+}
+
+// LongUsage returns the function's detailed help text, if any.
+func (f *TaskDef) LongUsage() string {
+	return f.longUsage
+}
+
+// Examples returns the function's example invocations.
+func (f *TaskDef) Examples() []Example {
+	return f.examples
+}
+
+// Inputs returns the globs of files the task reads, see WithInputs.
+func (f *TaskDef) Inputs() []string {
+	return f.inputs
+}
+
+// Outputs returns the globs of files the task writes, see WithOutputs.
+func (f *TaskDef) Outputs() []string {
+	return f.outputs
+}
+
 // IsHidden returns whether the function is hidden from CLI help.
 func (f *TaskDef) IsHidden() bool {
 	return f.hidden
@@ -208,12 +418,27 @@ func (f *TaskDef) GetOpts() any {
 //	taskWithOpts := pocket.WithOpts(task, parsedOpts)
 func WithOpts(task *TaskDef, opts any) *TaskDef {
 	return &TaskDef{
-		name:   task.name,
-		usage:  task.usage,
-		body:   task.body,
-		opts:   opts,
-		hidden: task.hidden,
-		silent: task.silent,
+		name:      task.name,
+		usage:     task.usage,
+		longUsage: task.longUsage,
+		examples:  task.examples,
+		tool:      task.tool,
+		body:      task.body,
+		opts:      opts,
+		hidden:    task.hidden,
+		silent:    task.silent,
+		dir:       task.dir,
+		limits:    task.limits,
+		sandbox:   task.sandbox,
+		timeout:   task.timeout,
+		tags:      task.tags,
+		inputs:    task.inputs,
+		outputs:   task.outputs,
+		cache:     task.cache,
+		cacheEnv:  task.cacheEnv,
+		upToDate:  task.upToDate,
+		group:     task.group,
+		args:      task.args,
 	}
 }
 
@@ -230,12 +455,27 @@ func WithOpts(task *TaskDef, opts any) *TaskDef {
 //	pocket.Clone(myTask, pocket.Named("new-name"), pocket.AsHidden())
 func Clone(task *TaskDef, opts ...TaskOpt) *TaskDef {
 	td := &TaskDef{
-		name:   task.name,
-		usage:  task.usage,
-		body:   task.body,
-		opts:   task.opts,
-		hidden: task.hidden,
-		silent: task.silent,
+		name:      task.name,
+		usage:     task.usage,
+		longUsage: task.longUsage,
+		examples:  task.examples,
+		tool:      task.tool,
+		body:      task.body,
+		opts:      task.opts,
+		hidden:    task.hidden,
+		silent:    task.silent,
+		dir:       task.dir,
+		limits:    task.limits,
+		sandbox:   task.sandbox,
+		timeout:   task.timeout,
+		tags:      task.tags,
+		inputs:    task.inputs,
+		outputs:   task.outputs,
+		cache:     task.cache,
+		cacheEnv:  task.cacheEnv,
+		upToDate:  task.upToDate,
+		group:     task.group,
+		args:      task.args,
 	}
 	for _, opt := range opts {
 		opt(td)
@@ -243,6 +483,25 @@ func Clone(task *TaskDef, opts ...TaskOpt) *TaskDef {
 	return td
 }
 
+// InDir returns a copy of the task that runs in dir, relative to the git
+// root, instead of the current path. Use it for directories that are not
+// detected modules, such as a docs folder. No shim is generated in dir, so
+// the task is run from the root shim (or a shim in a parent of dir).
+//
+// Example:
+//
+//	AutoRun: pocket.Serial(
+//	    golang.Tasks(),
+//	    markdown.Format.InDir("docs"),
+//	)
+//
+// See also the InDir function, which wraps any Runnable.
+func (f *TaskDef) InDir(dir string) *TaskDef {
+	return Clone(f, func(td *TaskDef) {
+		td.dir = cleanDir(dir)
+	})
+}
+
 // Run executes this function with the given context.
 // This is useful for testing or programmatic execution.
 func (f *TaskDef) Run(ctx context.Context) error {
@@ -256,8 +515,19 @@ func (f *TaskDef) run(ctx context.Context) error {
 
 	// In collect mode, register function and collect nested deps from static tree
 	if ec.mode == modeCollect {
+		// Tasks not selected by -tags or -skip-tags are left out of the plan
+		if ec.tags != nil {
+			run, selected := ec.tags.allows(f)
+			if !run {
+				return nil
+			}
+			if selected {
+				ctx = withTagSelected(ctx)
+			}
+		}
+
 		// Check if this would be deduplicated
-		deduped := !ec.dedup.shouldRun(runnableKey(f))
+		deduped := !ec.dedup.shouldRun(dedupKey{ptr: runnableKey(f)})
 		ec.plan.addFunc(f, deduped)
 		defer ec.plan.popFunc()
 
@@ -270,14 +540,49 @@ func (f *TaskDef) run(ctx context.Context) error {
 		return nil
 	}
 
+	// Tasks with a fixed directory run there, if it is within the
+	// directory pocket was invoked from.
+	if f.dir != "" {
+		if !dirWithin(f.dir, ec.cwd) {
+			return nil
+		}
+		ctx = withPath(ctx, f.dir)
+		ec = getExecContext(ctx)
+	}
+
 	// Check skip rules before executing
 	if ec.shouldSkipTask(f.name) {
 		return nil
 	}
 
-	// Execute mode - print task header (skip for hidden or silent tasks)
-	if !f.hidden && !f.silent {
-		printTaskHeader(ctx, f.name)
+	// Tasks skipped with -skip are reported as such
+	if ec.skipFlag[f.name] {
+		if !f.hidden && !f.silent {
+			printTaskHeader(ctx, f.name+" (skipped by -skip)")
+		}
+		if ec.runLog != nil {
+			ec.runLog.recordSkip(f.name, Path(ctx), f.hidden)
+		}
+		return nil
+	}
+
+	// Tasks are selected by tag with -tags and -skip-tags
+	if ec.tags != nil {
+		run, selected := ec.tags.allows(f)
+		if !run {
+			if f.hasTag(ec.tags.skip) {
+				if !f.hidden && !f.silent {
+					printTaskHeader(ctx, f.name+" (skipped by -skip-tags)")
+				}
+				if ec.runLog != nil {
+					ec.runLog.recordSkip(f.name, Path(ctx), f.hidden)
+				}
+			}
+			return nil
+		}
+		if selected {
+			ctx = withTagSelected(ctx)
+		}
 	}
 
 	// Inject options into context if present
@@ -285,19 +590,138 @@ func (f *TaskDef) run(ctx context.Context) error {
 		ctx = withOptions(ctx, f.opts)
 	}
 
+	// Skip tasks whose outputs are newer than their inputs
+	if f.upToDateEnabled() {
+		switch ok, err := f.checkUpToDate(ctx); {
+		case err != nil:
+			Printf(ctx, "warning: %s: %v\n", f.name, err)
+		case ok:
+			if !f.hidden && !f.silent {
+				printTaskHeader(ctx, f.name+" (up to date)")
+			}
+			if ec.runLog != nil {
+				ec.runLog.recordCached(f.name, Path(ctx), f.hidden)
+			}
+			return nil
+		}
+	}
+
+	// Skip cached tasks whose inputs are unchanged since their last success
+	var cacheKey string
+	if f.cacheEnabled() {
+		key, err := f.cacheKey(ctx)
+		switch {
+		case err != nil:
+			Printf(ctx, "warning: %s: cache disabled: %v\n", f.name, err)
+		case cacheHit(f.name, Path(ctx), key), remoteCacheHit(ctx, f.name, key):
+			if err := storeCache(f.name, Path(ctx), key); err != nil {
+				Printf(ctx, "warning: %s: store cache: %v\n", f.name, err)
+			}
+			if !f.hidden && !f.silent {
+				printTaskHeader(ctx, f.name+" (cached)")
+			}
+			if ec.runLog != nil {
+				ec.runLog.recordCached(f.name, Path(ctx), f.hidden)
+			}
+			return nil
+		default:
+			cacheKey = key
+		}
+	}
+
+	// Execute mode - print task header (skip for hidden or silent tasks)
+	if !f.hidden && !f.silent {
+		printTaskHeader(ctx, f.name)
+	}
+	ctx = withTask(ctx, f.name)
+	if ec.runLog != nil {
+		ec.runLog.recordStart(f.name, Path(ctx), f.hidden)
+	}
+
+	// In Parallel groups with prefixed output, label the lines of this task
+	if ec.prefixer != nil && !f.hidden {
+		var flush func()
+		ctx, flush = withLinePrefix(ctx, f.name)
+		defer flush()
+	}
+
+	// Apply resource limits to the commands of this task
+	if !f.limits.IsZero() {
+		ctx = withLimits(ctx, f.limits)
+	}
+
+	// Sandbox the commands of this task; tool installers run unrestricted
+	switch {
+	case f.sandbox != nil:
+		ctx = withSandbox(ctx, f.sandbox)
+	case f.tool != nil && ec.sandbox != nil:
+		ctx = withSandbox(ctx, nil)
+	}
+
+	// With -keep-going, tasks inside this task that fail mark it as failed
+	var scope *failScope
+	if ec.keepGoing != nil {
+		ctx, scope = withFailScope(ctx)
+	}
+
 	// Execute the Runnable body
+	start := time.Now()
+	var err error
+	if f.tool != nil {
+		var ran bool
+		if ran, err = runInstaller(ctx, f); !ran {
+			// Another task installed the tool and recorded the run.
+			return err
+		}
+	} else {
+		err = f.runBody(ctx)
+	}
+	if err == nil && scope != nil && scope.failed.Load() {
+		err = errFailedInside
+	}
+	if ec.runLog != nil {
+		ec.runLog.recordTask(f.name, Path(ctx), f.hidden, ec.lane, time.Since(start), err)
+	}
+	if err == nil && cacheKey != "" {
+		if cacheErr := storeCache(f.name, Path(ctx), cacheKey); cacheErr != nil {
+			Printf(ctx, "warning: %s: store cache: %v\n", f.name, cacheErr)
+		}
+		storeRemoteCache(ctx, f.name, cacheKey)
+	}
+
+	// With -keep-going, a failed task is reported at the end of the run
+	// and the tasks after it still run. Interrupts stop the run.
+	if err != nil && scope != nil && !f.hidden && ctx.Err() == nil {
+		if !errors.Is(err, errFailedInside) {
+			ec.keepGoing.add(f.name, Path(ctx), err)
+		}
+		scope.parent.markFailed()
+		return nil
+	}
+	return err
+}
+
+// runBody runs the task body, within the task's timeout if set.
+func (f *TaskDef) runBody(ctx context.Context) error {
+	if f.timeout > 0 {
+		return runWithTimeout(ctx, f.timeout, f.name, f.body.run)
+	}
 	return f.body.run(ctx)
 }
 
 // Runnable is the interface for anything that can be executed.
 // It uses unexported methods to prevent external implementation,
-// ensuring only pocket types (TaskDef, serial, parallel, PathFilter) can satisfy it.
+// ensuring only pocket types (TaskDef, serial, parallel, PathFilter, ...) can satisfy it.
 //
 // Users create Runnables via:
 //   - pocket.Task() for individual functions
 //   - pocket.Serial() for sequential execution
 //   - pocket.Parallel() for concurrent execution
 //   - pocket.RunIn() for path filtering
+//   - pocket.InDir() for a fixed directory
+//   - pocket.Timeout() for a deadline
+//   - pocket.OnlyIf() for a run-time condition
+//   - pocket.Env() for extra environment variables
 type Runnable interface {
 	run(ctx context.Context) error
 }
@@ -329,15 +753,23 @@ type funcRunnable struct {
 }
 
 func (f *funcRunnable) run(ctx context.Context) error {
+	ec := getExecContext(ctx)
+	if ec.mode == modeCollect {
+		return nil
+	}
 	return f.fn(ctx)
 }
 
 // commandRunnable executes an external command with static arguments.
 type commandRunnable struct {
-	name string
-	args []string
+	name        string
+	args        []string
+	interactive bool // attach to the terminal, see RunInteractive
 }
 
+// interactiveMu serializes interactive commands, which share the terminal.
+var interactiveMu sync.Mutex
+
 func (c *commandRunnable) run(ctx context.Context) error {
 	ec := getExecContext(ctx)
 	if ec.mode == modeCollect {
@@ -351,7 +783,14 @@ func (c *commandRunnable) run(ctx context.Context) error {
 	} else {
 		cmd.Dir = GitRoot()
 	}
-	return cmd.Run()
+	if c.interactive {
+		interactiveMu.Lock()
+		defer interactiveMu.Unlock()
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+	return runCommand(ec, cmd)
 }
 
 // Run creates a Runnable that executes an external command.
@@ -365,6 +804,22 @@ func Run(name string, args ...string) Runnable {
 	return &commandRunnable{name: name, args: args}
 }
 
+// RunInteractive creates a Runnable that executes an interactive external
+// command, such as a REPL, a debugger or "docker login". Unlike Run, the
+// command reads pocket's stdin and writes straight to pocket's stdout and
+// stderr, so it gets the terminal when pocket runs in one. Its output is
+// not buffered by Parallel, and interactive commands run one at a time.
+//
+// Example:
+//
+//	var Shell = pocket.Task("py-shell", "start a Python shell", pocket.Serial(
+//	    uv.Install,
+//	    pocket.RunInteractive("uv", "run", "python"),
+//	))
+func RunInteractive(name string, args ...string) Runnable {
+	return &commandRunnable{name: name, args: args, interactive: true}
+}
+
 // doRunnable wraps arbitrary Go code as a Runnable.
 type doRunnable struct {
 	fn func(context.Context) error
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+
+// Package bazel provides tasks for repositories that keep Bazel builds
+// alongside Go modules.
+// This is a "task" package - it orchestrates tools to do work.
+package bazel
+
+import (
+	"context"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/gazelle"
+)
+
+// GazelleOptions configures the gazelle task.
+type GazelleOptions struct {
+	Check bool `arg:"check" usage:"fail with a diff if BUILD files are out of date, without writing"`
+}
+
+// Gazelle regenerates the Bazel BUILD files of the workspace in the path
+// with the standalone gazelle binary.
+//
+// Register it in Config.Generate, so that ./pok generate updates the BUILD
+// files and the git-diff check catches drift:
+//
+//	Generate: []pocket.Runnable{
+//	    pocket.RunIn(bazel.Gazelle, pocket.Detect(bazel.Detect())),
+//	},
+//
+// With -check, gazelle runs in diff mode and fails if BUILD files would
+// change, for pipelines that skip generate.
+var Gazelle = pocket.Task("gazelle", "regenerate Bazel BUILD files with gazelle",
+	pocket.Serial(gazelle.Install, gazelleCmd()),
+	pocket.Opts(GazelleOptions{}),
+	pocket.WithExample("./pok gazelle -check", "fail if BUILD files are out of date"),
+)
+
+func gazelleCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[GazelleOptions](ctx)
+		opts.Check = !pocket.Fix(ctx, !opts.Check)
+		return pocket.Exec(ctx, gazelle.Name, gazelleArgs(opts)...)
+	})
+}
+
+func gazelleArgs(opts GazelleOptions) []string {
+	args := []string{"update"}
+	if opts.Check {
+		// Diff mode prints the changes and exits non-zero if there are any.
+		args = append(args, "-mode=diff")
+	}
+	return args
+}
+
+// Detect returns a detection function for Bazel workspaces.
+// It finds directories containing a MODULE.bazel or WORKSPACE file.
+func Detect() func() []string {
+	return func() []string {
+		return pocket.DetectByFile("MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel")
+	}
+}
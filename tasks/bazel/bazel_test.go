@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: MIT
+
+package bazel_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/pockettest"
+	"github.com/fredrikaverpil/pocket/tasks/bazel"
+	"github.com/fredrikaverpil/pocket/tools/gazelle"
+)
+
+func TestGazelle(t *testing.T) {
+	tests := []struct {
+		name string
+		opts bazel.GazelleOptions
+		want string
+	}{
+		{"update", bazel.GazelleOptions{}, "gazelle update"},
+		{"check", bazel.GazelleOptions{Check: true}, "gazelle update -mode=diff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := pockettest.New(t)
+			if err := h.Run(pocket.WithOpts(bazel.Gazelle, tt.opts)); err != nil {
+				t.Fatal(err)
+			}
+			want := []string{"github.com/bazelbuild/bazel-gazelle/cmd/gazelle@" + gazelle.Version}
+			if got := h.Runner.Installs(); !slices.Equal(got, want) {
+				t.Errorf("Installs() = %q, want %q", got, want)
+			}
+			if got := h.Runner.Commands(); !slices.Equal(got, []string{tt.want}) {
+				t.Errorf("Commands() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: MIT
+
+// Package compose brings Docker Compose stacks up around other work, such as
+// integration tests.
+// This is a "task" package - it orchestrates tools to do work.
+//
+// Docker and the compose plugin are not installed by pocket; they must be
+// on PATH. Stacks managed by testcontainers handle their own lifecycle and
+// need nothing from this package.
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// DefaultWaitTimeout is how long Run waits for services to become healthy.
+const DefaultWaitTimeout = 2 * time.Minute
+
+// Stack is a Docker Compose stack.
+type Stack struct {
+	// Files are the compose files, relative to the task path.
+	// Default: compose looks up compose.yaml or docker-compose.yml.
+	Files []string
+
+	// Project is the compose project name.
+	// Default: compose derives it from the directory name.
+	Project string
+
+	// WaitTimeout bounds how long to wait for services with health checks
+	// to become healthy. Default: DefaultWaitTimeout.
+	WaitTimeout time.Duration
+}
+
+// ParseFiles splits a comma-separated list of compose files, as accepted by
+// the -compose option of the test tasks. It returns nil for "".
+func ParseFiles(s string) []string {
+	var files []string
+	for f := range strings.SplitSeq(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// Run brings the stack up, waits until its services are running and
+// healthy, and calls fn. If fn fails, the service logs are printed to help
+// diagnose the failure. The stack is always torn down afterwards, including
+// its volumes, even if ctx was canceled.
+//
+// Example:
+//
+//	stack := compose.Stack{Files: []string{"compose.test.yaml"}}
+//	return stack.Run(ctx, func(ctx context.Context) error {
+//	    return pocket.Exec(ctx, "go", "test", "-tags=integration", "./...")
+//	})
+func (s Stack) Run(ctx context.Context, fn func(context.Context) error) (err error) {
+	// Tear down without ctx's cancellation, so an interrupted run cleans up.
+	cleanupCtx := context.WithoutCancel(ctx)
+	defer func() {
+		if downErr := s.exec(cleanupCtx, "down", "--volumes", "--remove-orphans"); downErr != nil {
+			err = errors.Join(err, fmt.Errorf("compose down: %w", downErr))
+		}
+	}()
+
+	timeout := s.WaitTimeout
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+	if err := s.exec(ctx, "up", "--detach", "--wait", fmt.Sprintf("--wait-timeout=%d", int(timeout.Seconds()))); err != nil {
+		s.printLogs(cleanupCtx)
+		return fmt.Errorf("compose up: %w", err)
+	}
+	if err := fn(ctx); err != nil {
+		s.printLogs(cleanupCtx)
+		return err
+	}
+	return nil
+}
+
+// printLogs prints the service logs. Failing to fetch them must not hide
+// the error that made them interesting, so errors are only reported.
+func (s Stack) printLogs(ctx context.Context) {
+	pocket.Println(ctx, "Compose service logs:")
+	if err := s.exec(ctx, "logs", "--no-color", "--timestamps"); err != nil {
+		pocket.Printf(ctx, "Warning: compose logs: %v\n", err)
+	}
+}
+
+func (s Stack) exec(ctx context.Context, args ...string) error {
+	return pocket.Exec(ctx, "docker", append(s.args(), args...)...)
+}
+
+// args returns the global compose arguments for the stack.
+func (s Stack) args() []string {
+	args := []string{"compose"}
+	for _, f := range s.Files {
+		args = append(args, "--file", f)
+	}
+	if s.Project != "" {
+		args = append(args, "--project-name", s.Project)
+	}
+	return args
+}
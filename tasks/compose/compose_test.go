@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: MIT
+
+package compose_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/pockettest"
+	"github.com/fredrikaverpil/pocket/tasks/compose"
+)
+
+func TestStack_Run(t *testing.T) {
+	const prefix = "docker compose --file compose.test.yaml --project-name it "
+	stack := compose.Stack{Files: []string{"compose.test.yaml"}, Project: "it", WaitTimeout: 30 * time.Second}
+	up := prefix + "up --detach --wait --wait-timeout=30"
+	down := prefix + "down --volumes --remove-orphans"
+	logs := prefix + "logs --no-color --timestamps"
+	errTests := errors.New("tests failed")
+
+	tests := []struct {
+		name    string
+		upFails bool
+		fnErr   error
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "success",
+			want: []string{up, "go test ./...", down},
+		},
+		{
+			name:    "tests fail",
+			fnErr:   errTests,
+			want:    []string{up, "go test ./...", logs, down},
+			wantErr: true,
+		},
+		{
+			name:    "up fails",
+			upFails: true,
+			want:    []string{up, logs, down},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := pockettest.New(t)
+			if tt.upFails {
+				h.Runner.On("docker", "compose", "--file", "compose.test.yaml", "--project-name", "it", "up").
+					Return(pockettest.Response{ExitCode: 1})
+			}
+			err := stack.Run(h.Context(), func(ctx context.Context) error {
+				if err := pocket.Exec(ctx, "go", "test", "./..."); err != nil {
+					return err
+				}
+				return tt.fnErr
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.fnErr != nil && !errors.Is(err, tt.fnErr) {
+				t.Errorf("Run() error = %v, want %v", err, tt.fnErr)
+			}
+			if got := h.Runner.Commands(); !slices.Equal(got, tt.want) {
+				t.Errorf("Commands() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestStack_RunCanceled(t *testing.T) {
+	h := pockettest.New(t)
+	ctx, cancel := context.WithCancel(h.Context())
+	err := compose.Stack{}.Run(ctx, func(context.Context) error {
+		cancel()
+		return context.Canceled
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	want := "docker compose down --volumes --remove-orphans"
+	if got := h.Runner.Commands(); !slices.Contains(got, want) {
+		t.Errorf("Commands() = %q, want %q after cancel", got, want)
+	}
+}
+
+func TestParseFiles(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"compose.yaml", []string{"compose.yaml"}},
+		{"compose.yaml, compose.ci.yaml,", []string{"compose.yaml", "compose.ci.yaml"}},
+	}
+	for _, tt := range tests {
+		if got := compose.ParseFiles(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseFiles(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: MIT
+
+// Package container provides container image tasks.
+// This is a "task" package - it orchestrates tools to do work.
+package container
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tasks/sarif"
+	"github.com/fredrikaverpil/pocket/tools/grype"
+	"github.com/fredrikaverpil/pocket/tools/trivy"
+)
+
+// ScanOptions configures the image-scan task.
+type ScanOptions struct {
+	Image      string `arg:"image"       usage:"image reference to scan, e.g. ghcr.io/owner/app:latest"`
+	Tarball    string `arg:"tarball"     usage:"image tarball to scan (e.g. from go-image -tarball)"`
+	Scanner    string `arg:"scanner"     usage:"scanner backend: trivy or grype (default: trivy)"`
+	Severity   string `arg:"severity"    usage:"lowest severity that fails the scan (default: high)"`
+	IgnoreFile string `arg:"ignore-file" usage:"ignore file (.trivyignore) or grype config with ignore rules"`
+}
+
+// severities in ascending order, as used by trivy.
+var severities = []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+// Scan scans a container image for vulnerabilities with trivy or grype.
+// It fails when a finding at or above the severity threshold is found.
+// When POK_SARIF_DIR is set, findings are also written as SARIF.
+var Scan = pocket.Task("image-scan", "scan container image for vulnerabilities",
+	scanCmd(),
+	pocket.Opts(ScanOptions{}),
+)
+
+func scanCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[ScanOptions](ctx)
+		if (opts.Image == "") == (opts.Tarball == "") {
+			return fmt.Errorf("image-scan: exactly one of -image or -tarball is required")
+		}
+		severity := strings.ToUpper(opts.Severity)
+		if severity == "" {
+			severity = "HIGH"
+		}
+		idx := -1
+		for i, s := range severities {
+			if s == severity {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("image-scan: unknown severity %q", opts.Severity)
+		}
+		sarifFile, err := sarif.OutputFile(ctx, "image-scan")
+		if err != nil {
+			return err
+		}
+
+		// The backend is chosen at runtime, so install it on demand.
+		switch opts.Scanner {
+		case "", "trivy":
+			if err := trivy.Install.Run(ctx); err != nil {
+				return err
+			}
+			if sarifFile != "" {
+				// trivy has a single output format per run; the second run reuses its cache.
+				if err := pocket.Exec(ctx, trivy.Name, trivySARIFArgs(opts, sarifFile)...); err != nil {
+					return err
+				}
+			}
+			return pocket.Exec(ctx, trivy.Name, trivyArgs(opts, severities[idx:])...)
+		case "grype":
+			if err := grype.Install.Run(ctx); err != nil {
+				return err
+			}
+			return pocket.Exec(ctx, grype.Name, grypeArgs(opts, grypeFailOn(severity), sarifFile)...)
+		default:
+			return fmt.Errorf("image-scan: unknown scanner %q", opts.Scanner)
+		}
+	})
+}
+
+// grypeFailOn maps a trivy severity to a grype --fail-on value.
+// grype has no "unknown" threshold; its lowest level is "negligible".
+func grypeFailOn(severity string) string {
+	if severity == "UNKNOWN" {
+		return "negligible"
+	}
+	return strings.ToLower(severity)
+}
+
+// trivyBaseArgs returns the trivy arguments shared by the SARIF and
+// threshold runs, without the target.
+func trivyBaseArgs(opts ScanOptions) []string {
+	args := []string{"image"}
+	if opts.Tarball != "" {
+		args = append(args, "--input", opts.Tarball)
+	}
+	if opts.IgnoreFile != "" {
+		args = append(args, "--ignorefile", opts.IgnoreFile)
+	}
+	return args
+}
+
+// trivySARIFArgs returns the trivy arguments that write all findings to sarifFile.
+func trivySARIFArgs(opts ScanOptions, sarifFile string) []string {
+	args := append(trivyBaseArgs(opts), "--format", "sarif", "--output", sarifFile)
+	return appendTarget(args, opts.Image)
+}
+
+// trivyArgs returns the trivy arguments that fail on the given severities.
+func trivyArgs(opts ScanOptions, failOn []string) []string {
+	args := append(trivyBaseArgs(opts), "--severity", strings.Join(failOn, ","), "--exit-code", "1")
+	return appendTarget(args, opts.Image)
+}
+
+// grypeArgs returns the grype arguments. grype writes the table and the
+// SARIF report from a single run.
+func grypeArgs(opts ScanOptions, failOn, sarifFile string) []string {
+	target := opts.Image
+	if opts.Tarball != "" {
+		target = "docker-archive:" + opts.Tarball
+	}
+	args := []string{target, "--fail-on", failOn, "-o", "table"}
+	if sarifFile != "" {
+		args = append(args, "-o", "sarif="+sarifFile)
+	}
+	if opts.IgnoreFile != "" {
+		args = append(args, "--config", opts.IgnoreFile)
+	}
+	return args
+}
+
+func appendTarget(args []string, target string) []string {
+	if target == "" {
+		return args
+	}
+	return append(args, target)
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGrypeFailOn(t *testing.T) {
+	tests := map[string]string{
+		"UNKNOWN":  "negligible",
+		"LOW":      "low",
+		"MEDIUM":   "medium",
+		"HIGH":     "high",
+		"CRITICAL": "critical",
+	}
+	for severity, want := range tests {
+		if got := grypeFailOn(severity); got != want {
+			t.Errorf("grypeFailOn(%q) = %q, want %q", severity, got, want)
+		}
+	}
+}
+
+func TestTrivyArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      ScanOptions
+		failOn    []string
+		want      []string
+		wantSARIF []string
+	}{
+		{
+			name:      "image",
+			opts:      ScanOptions{Image: "ghcr.io/owner/app:latest"},
+			failOn:    []string{"HIGH", "CRITICAL"},
+			want:      []string{"image", "--severity", "HIGH,CRITICAL", "--exit-code", "1", "ghcr.io/owner/app:latest"},
+			wantSARIF: []string{"image", "--format", "sarif", "--output", "out.sarif", "ghcr.io/owner/app:latest"},
+		},
+		{
+			name:   "tarball with ignore file",
+			opts:   ScanOptions{Tarball: "image.tar", IgnoreFile: ".trivyignore"},
+			failOn: []string{"CRITICAL"},
+			want: []string{
+				"image", "--input", "image.tar", "--ignorefile", ".trivyignore",
+				"--severity", "CRITICAL", "--exit-code", "1",
+			},
+			wantSARIF: []string{
+				"image", "--input", "image.tar", "--ignorefile", ".trivyignore",
+				"--format", "sarif", "--output", "out.sarif",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trivyArgs(tt.opts, tt.failOn); !slices.Equal(got, tt.want) {
+				t.Errorf("trivyArgs() = %v, want %v", got, tt.want)
+			}
+			if got := trivySARIFArgs(tt.opts, "out.sarif"); !slices.Equal(got, tt.wantSARIF) {
+				t.Errorf("trivySARIFArgs() = %v, want %v", got, tt.wantSARIF)
+			}
+		})
+	}
+}
+
+func TestGrypeArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  ScanOptions
+		sarif string
+		want  []string
+	}{
+		{
+			name: "image",
+			opts: ScanOptions{Image: "ghcr.io/owner/app:latest"},
+			want: []string{"ghcr.io/owner/app:latest", "--fail-on", "high", "-o", "table"},
+		},
+		{
+			name:  "tarball with sarif and config",
+			opts:  ScanOptions{Tarball: "image.tar", IgnoreFile: ".grype.yaml"},
+			sarif: "out.sarif",
+			want: []string{
+				"docker-archive:image.tar", "--fail-on", "high", "-o", "table",
+				"-o", "sarif=out.sarif", "--config", ".grype.yaml",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grypeArgs(tt.opts, "high", tt.sarif); !slices.Equal(got, tt.want) {
+				t.Errorf("grypeArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
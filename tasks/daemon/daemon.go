@@ -0,0 +1,326 @@
+// SPDX-License-Identifier: MIT
+
+// Package daemon runs pocket tasks locally in the background, on intervals
+// or after git events, without cron or git hooks.
+// This is a "task" package - it orchestrates tools to do work.
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Git events a job can run on. They are named after the git hooks git runs
+// for the same operations.
+const (
+	PostCheckout = "post-checkout" // branch switched with git checkout or git switch
+	PostMerge    = "post-merge"    // git merge or git pull
+	PostCommit   = "post-commit"   // git commit
+	PostRewrite  = "post-rewrite"  // git commit --amend or a finished rebase
+)
+
+// events lists the supported git events.
+var events = []string{PostCheckout, PostMerge, PostCommit, PostRewrite}
+
+// pollInterval is how often the git reflog is checked for new events.
+var pollInterval = 2 * time.Second
+
+// Job is a task invocation run by the daemon.
+type Job struct {
+	// Run is a task name with optional flags, run through the shim from the
+	// git root, e.g. "go-test -short".
+	Run string
+
+	// Every runs the job on this interval, e.g. 7 * 24 * time.Hour. The time
+	// of the last run is kept in .pocket/logs/daemon, so the interval
+	// carries over daemon restarts. Zero disables the interval.
+	Every time.Duration
+
+	// On runs the job after these git events, e.g. []string{daemon.PostMerge}.
+	On []string
+}
+
+// Config configures the daemon task.
+type Config struct {
+	Jobs []Job
+}
+
+// Task creates the daemon task, which runs the jobs of cfg until
+// interrupted. Job output is appended to .pocket/logs/daemon/daemon.log;
+// the daemon prints one line per run.
+//
+// Example usage in .pocket/config.go:
+//
+//	var Config = pocket.Config{
+//	    ManualRun: []pocket.Runnable{
+//	        daemon.Task(daemon.Config{
+//	            Jobs: []daemon.Job{
+//	                {Run: "tools-versions", Every: 7 * 24 * time.Hour},
+//	                {Run: "go-test -short", On: []string{daemon.PostMerge, daemon.PostCheckout}},
+//	            },
+//	        }),
+//	    },
+//	}
+func Task(cfg Config) *pocket.TaskDef {
+	return pocket.Task("daemon", "run tasks on intervals and after git events until interrupted",
+		daemonCmd(cfg),
+	)
+}
+
+func daemonCmd(cfg Config) pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		if err := validate(cfg); err != nil {
+			return fmt.Errorf("daemon: %w", err)
+		}
+		dir := pocket.FromLogsDir("daemon")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("daemon: create logs dir: %w", err)
+		}
+		logFile, err := os.OpenFile(filepath.Join(dir, "daemon.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("daemon: %w", err)
+		}
+		defer logFile.Close()
+
+		d := &daemon{
+			cfg:       cfg,
+			shim:      shimPath(ctx),
+			log:       logFile,
+			statePath: filepath.Join(dir, "state.json"),
+			lastRun:   loadState(filepath.Join(dir, "state.json")),
+		}
+		if d.reflog, err = reflogPath(ctx); err != nil {
+			return fmt.Errorf("daemon: %w", err)
+		}
+		d.offset = fileSize(d.reflog)
+
+		pocket.Printf(ctx, "Running %d job(s), logging to %s (press Ctrl-C to stop)\n", len(cfg.Jobs), logFile.Name())
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			if err := d.poll(ctx, time.Now()); err != nil {
+				return fmt.Errorf("daemon: %w", err)
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// validate checks that every job has a trigger and only known events.
+func validate(cfg Config) error {
+	if len(cfg.Jobs) == 0 {
+		return fmt.Errorf("no jobs configured")
+	}
+	for _, job := range cfg.Jobs {
+		if strings.TrimSpace(job.Run) == "" {
+			return fmt.Errorf("job without a task")
+		}
+		if job.Every <= 0 && len(job.On) == 0 {
+			return fmt.Errorf("job %q: set Every or On", job.Run)
+		}
+		for _, event := range job.On {
+			if !slices.Contains(events, event) {
+				return fmt.Errorf("job %q: unknown event %q (want one of %s)", job.Run, event, strings.Join(events, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// daemon holds the state of a running daemon task.
+type daemon struct {
+	cfg       Config
+	shim      string
+	log       io.Writer
+	statePath string
+	lastRun   map[string]time.Time // job Run -> time of its last run
+	reflog    string
+	offset    int64 // bytes of the reflog already read
+}
+
+// poll runs the jobs triggered by git events since the last poll and the
+// jobs whose interval has passed.
+func (d *daemon) poll(ctx context.Context, now time.Time) error {
+	happened := d.readEvents()
+	for _, job := range d.cfg.Jobs {
+		trigger := ""
+		for _, event := range job.On {
+			if happened[event] {
+				trigger = event
+				break
+			}
+		}
+		if trigger == "" && due(job, d.lastRun[job.Run], now) {
+			trigger = "every " + job.Every.String()
+		}
+		if trigger == "" {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		d.run(ctx, job, trigger)
+		d.lastRun[job.Run] = time.Now()
+		if err := saveState(d.statePath, d.lastRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run runs job through the shim, appending its output to the log.
+func (d *daemon) run(ctx context.Context, job Job, trigger string) {
+	start := time.Now()
+	fmt.Fprintf(d.log, "# %s %s (%s)\n", start.Format(time.RFC3339), job.Run, trigger)
+	cmd := pocket.Command(ctx, d.shim, strings.Fields(job.Run)...)
+	cmd.Dir = pocket.GitRoot()
+	cmd.Stdout = d.log
+	cmd.Stderr = d.log
+	err := pocket.RunCommand(ctx, cmd)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	status := "ok"
+	if err != nil {
+		status = "failed: " + err.Error()
+	}
+	fmt.Fprintf(d.log, "# %s (%s)\n\n", status, elapsed)
+	pocket.Printf(ctx, "  [%s] %s (%s): %s (%s)\n", start.Format(time.TimeOnly), job.Run, trigger, status, elapsed)
+}
+
+// readEvents returns the git events recorded in the reflog since the last
+// call.
+func (d *daemon) readEvents() map[string]bool {
+	size := fileSize(d.reflog)
+	if size < d.offset {
+		// The reflog was expired or rewritten; start over from its end.
+		d.offset = size
+		return nil
+	}
+	if size == d.offset {
+		return nil
+	}
+	f, err := os.Open(d.reflog)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	data := make([]byte, size-d.offset)
+	n, _ := f.ReadAt(data, d.offset)
+	// Only consume complete lines; git may still be writing the last one.
+	data = data[:bytes.LastIndexByte(data[:n], '\n')+1]
+	d.offset += int64(len(data))
+	return reflogEvents(string(data))
+}
+
+// reflogEvents returns the git events of the given HEAD reflog lines.
+func reflogEvents(lines string) map[string]bool {
+	happened := make(map[string]bool)
+	for _, line := range strings.Split(lines, "\n") {
+		_, msg, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if event := reflogEvent(msg); event != "" {
+			happened[event] = true
+		}
+	}
+	return happened
+}
+
+// reflogEvent maps a HEAD reflog message, e.g. "checkout: moving from main
+// to fix", to the git event of the operation, or "" if it has none.
+func reflogEvent(msg string) string {
+	action, _, _ := strings.Cut(msg, ":")
+	switch {
+	case strings.HasPrefix(action, "checkout"):
+		return PostCheckout
+	case strings.Contains(action, "rebase"):
+		// A rebase writes an entry per step; only the last one counts.
+		if strings.Contains(action, "(finish)") {
+			return PostRewrite
+		}
+		return ""
+	case strings.HasPrefix(action, "pull"), strings.HasPrefix(action, "merge"):
+		return PostMerge
+	case action == "commit (amend)":
+		return PostRewrite
+	case strings.HasPrefix(action, "commit"):
+		return PostCommit
+	}
+	return ""
+}
+
+// due reports whether job runs on an interval that has passed since last.
+func due(job Job, last, now time.Time) bool {
+	return job.Every > 0 && now.Sub(last) >= job.Every
+}
+
+// loadState reads the last run times of the jobs, or returns an empty map.
+func loadState(path string) map[string]time.Time {
+	state := make(map[string]time.Time)
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// saveState writes the last run times of the jobs.
+func saveState(path string, state map[string]time.Time) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// reflogPath returns the path of the HEAD reflog of the repository.
+func reflogPath(ctx context.Context) (string, error) {
+	var out bytes.Buffer
+	cmd := pocket.Command(ctx, "git", "rev-parse", "--git-path", "logs/HEAD")
+	cmd.Dir = pocket.GitRoot()
+	cmd.Stdout = &out
+	if err := pocket.RunCommand(ctx, cmd); err != nil {
+		return "", fmt.Errorf("find git reflog: %w", err)
+	}
+	path := strings.TrimSpace(out.String())
+	if !filepath.IsAbs(path) {
+		path = pocket.FromGitRoot(path)
+	}
+	return path, nil
+}
+
+// shimPath returns the path of the shim that runs the jobs.
+func shimPath(ctx context.Context) string {
+	name := "pok"
+	if plan := pocket.GetConfigPlan(ctx); plan != nil && plan.Config != nil {
+		name = plan.Config.WithDefaults().Shim.Name
+	}
+	if runtime.GOOS == pocket.Windows {
+		name += ".cmd"
+	}
+	return pocket.FromGitRoot(name)
+}
+
+// fileSize returns the size of the file at path, or 0 if it doesn't exist.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
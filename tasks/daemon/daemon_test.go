@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: MIT
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/pocket/pockettest"
+)
+
+func TestReflogEvent(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"checkout: moving from main to fix", PostCheckout},
+		{"pull: Fast-forward", PostMerge},
+		{"pull origin main: Merge made by the 'ort' strategy.", PostMerge},
+		{"merge fix: Fast-forward", PostMerge},
+		{"commit: add daemon", PostCommit},
+		{"commit (initial): first", PostCommit},
+		{"commit (amend): add daemon", PostRewrite},
+		{"rebase (start): checkout main", ""},
+		{"rebase (pick): add daemon", ""},
+		{"rebase (finish): returning to refs/heads/fix", PostRewrite},
+		{"pull --rebase (finish): returning to refs/heads/main", PostRewrite},
+		{"reset: moving to HEAD~1", ""},
+	}
+	for _, tt := range tests {
+		if got := reflogEvent(tt.msg); got != tt.want {
+			t.Errorf("reflogEvent(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{name: "valid", cfg: Config{Jobs: []Job{{Run: "go-test", On: []string{PostMerge}}, {Run: "tools-update", Every: time.Hour}}}},
+		{name: "no jobs", want: "no jobs"},
+		{name: "no task", cfg: Config{Jobs: []Job{{Every: time.Hour}}}, want: "without a task"},
+		{name: "no trigger", cfg: Config{Jobs: []Job{{Run: "go-test"}}}, want: "set Every or On"},
+		{name: "unknown event", cfg: Config{Jobs: []Job{{Run: "go-test", On: []string{"pre-push"}}}}, want: `unknown event "pre-push"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(tt.cfg)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validate() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoll(t *testing.T) {
+	dir := t.TempDir()
+	reflog := filepath.Join(dir, "HEAD")
+	if err := os.WriteFile(reflog, []byte("a b Me <me@example.com> 1 +0000\tcommit: old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	d := &daemon{
+		cfg: Config{Jobs: []Job{
+			{Run: "go-mod-tidy -diff", On: []string{PostMerge, PostCheckout}},
+			{Run: "tools-update", Every: 24 * time.Hour},
+		}},
+		shim:      "./pok",
+		log:       &strings.Builder{},
+		statePath: filepath.Join(dir, "state.json"),
+		lastRun:   map[string]time.Time{"tools-update": now.Add(-time.Hour)},
+		reflog:    reflog,
+		offset:    fileSize(reflog),
+	}
+
+	h := pockettest.New(t)
+	if err := d.poll(h.Context(), now); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Runner.Commands(); len(got) != 0 {
+		t.Errorf("expected no jobs without events or due intervals, got %q", got)
+	}
+
+	f, err := os.OpenFile(reflog, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A pull and a checkout run the job once; the partial line is kept for later.
+	_, _ = f.WriteString("b c Me <me@example.com> 2 +0000\tpull: Fast-forward\n" +
+		"c d Me <me@example.com> 3 +0000\tcheckout: moving from main to fix\n" +
+		"d e Me <me@example.com> 4 +0000\tcommit")
+	_ = f.Close()
+	if err := d.poll(h.Context(), now.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"pok go-mod-tidy -diff", "pok tools-update"}
+	if got := h.Runner.Commands(); !slices.Equal(got, want) {
+		t.Errorf("Commands() = %q, want %q", got, want)
+	}
+	if d.offset != fileSize(reflog)-int64(len("d e Me <me@example.com> 4 +0000\tcommit")) {
+		t.Errorf("expected the partial reflog line to be left unread")
+	}
+	if state := loadState(d.statePath); state["tools-update"].IsZero() || state["go-mod-tidy -diff"].IsZero() {
+		t.Errorf("expected the last runs to be saved, got %v", state)
+	}
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: MIT
+
+// Package filesync keeps files synchronized across a monorepo from a single
+// source of truth.
+// This is a "task" package - it orchestrates tools to do work.
+package filesync
+
+import (
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Sync copies Source to each of Targets, see pocket.SyncFiles.
+type Sync struct {
+	// Source is a file or directory, relative to the git root.
+	Source string
+
+	// Targets are files or directories, relative to the git root. Globs
+	// expand to the matching directories.
+	Targets []string
+}
+
+// Config configures the sync-files task.
+type Config struct {
+	// Files are synced in order.
+	Files []Sync
+}
+
+// Task creates the sync-files task, which copies each source to its
+// targets. Add it to Config.Generate, so ./pok generate restores the copies
+// and the git-diff check catches copies edited in place:
+//
+//	var Config = pocket.Config{
+//	    Generate: []pocket.Runnable{
+//	        filesync.Task(filesync.Config{
+//	            Files: []filesync.Sync{
+//	                {Source: ".golangci.yml", Targets: []string{"services/*/"}},
+//	                {Source: "LICENSE", Targets: []string{"sdk/go/", "sdk/python/"}},
+//	            },
+//	        }),
+//	    },
+//	}
+func Task(cfg Config) *pocket.TaskDef {
+	items := make([]any, len(cfg.Files))
+	for i, f := range cfg.Files {
+		items[i] = pocket.SyncFiles(f.Source, f.Targets...)
+	}
+	return pocket.Task("sync-files", "sync shared files across the repository",
+		pocket.Serial(items...),
+	)
+}
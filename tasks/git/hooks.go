@@ -0,0 +1,269 @@
+// SPDX-License-Identifier: MIT
+
+// Package git provides git-related tasks.
+// This is a "task" package - it orchestrates tools to do work.
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/uninstall"
+)
+
+// HooksDir is where hooks are written, relative to the git root.
+// core.hooksPath is pointed at this directory.
+const HooksDir = uninstall.HooksPath
+
+// backupKey is the git config key that holds a core.hooksPath set before
+// hooks-install, so that -uninstall can restore it.
+const backupKey = uninstall.HooksPathBackupKey
+
+// HooksConfig maps git hook names to the pocket task invocations they run.
+// Each invocation is a task name with optional flags, run through the shim
+// from the git root.
+//
+// Example:
+//
+//	git.HooksConfig{
+//	    "pre-commit": {"go-format", "md-format"},
+//	    "pre-push":   {"go-test -short"},
+//	}
+type HooksConfig map[string][]string
+
+// DefaultHooksConfig returns a pre-commit hook that formats Go and Markdown,
+// and a pre-push hook that runs the short Go tests.
+func DefaultHooksConfig() HooksConfig {
+	return HooksConfig{
+		"pre-commit": {"go-format", "md-format"},
+		"pre-push":   {"go-test -short"},
+	}
+}
+
+// HooksOptions configures the hooks-install task.
+type HooksOptions struct {
+	Uninstall bool `arg:"uninstall" usage:"remove hooks and restore the previous core.hooksPath"`
+}
+
+// HooksTask creates the hooks-install task.
+// It writes one script per hook into .pocket/hooks (which is gitignored)
+// and sets core.hooksPath to that directory. A core.hooksPath that was
+// already set is kept in git config and restored by -uninstall.
+//
+// Hooks in the previous hooks directory (core.hooksPath or .git/hooks) keep
+// running: a pocket hook of the same name runs the previous hook first, and
+// previous hooks that pocket doesn't configure are reported, since git no
+// longer runs them.
+//
+// Example usage in .pocket/config.go:
+//
+//	var Config = pocket.Config{
+//	    ManualRun: []pocket.Runnable{
+//	        git.HooksTask(git.DefaultHooksConfig()),
+//	    },
+//	}
+func HooksTask(cfg HooksConfig) *pocket.TaskDef {
+	return pocket.Task("hooks-install", "install git hooks that run pocket tasks",
+		hooksCmd(cfg),
+		pocket.Opts(HooksOptions{}),
+	)
+}
+
+func hooksCmd(cfg HooksConfig) pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[HooksOptions](ctx)
+		hooksDir := pocket.FromGitRoot(HooksDir)
+
+		// git config --get fails when the key is unset.
+		current, _ := gitConfig(ctx, "--get", "core.hooksPath")
+
+		if opts.Uninstall {
+			// Only touch core.hooksPath if it points at our directory.
+			if current == HooksDir {
+				if err := restoreHooksPath(ctx); err != nil {
+					return err
+				}
+			}
+			if err := os.RemoveAll(hooksDir); err != nil {
+				return fmt.Errorf("remove %s: %w", hooksDir, err)
+			}
+			pocket.Printf(ctx, "  Removed git hooks from %s\n", HooksDir)
+			return nil
+		}
+
+		shimName := "pok"
+		if plan := pocket.GetConfigPlan(ctx); plan != nil && plan.Config.Shim != nil && plan.Config.Shim.Name != "" {
+			shimName = plan.Config.Shim.Name
+		}
+
+		previous, err := previousHooks(ctx, current)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+			return fmt.Errorf("create hooks dir: %w", err)
+		}
+		if err := ensureIgnored(pocket.FromPocketDir(".gitignore"), "hooks/"); err != nil {
+			return err
+		}
+		hooks := make([]string, 0, len(cfg))
+		for hook := range cfg {
+			hooks = append(hooks, hook)
+		}
+		slices.Sort(hooks)
+		for _, hook := range hooks {
+			path := filepath.Join(hooksDir, hook)
+			if err := os.WriteFile(path, HookScript(shimName, cfg[hook], previous[hook]), 0o755); err != nil {
+				return fmt.Errorf("write hook %s: %w", hook, err)
+			}
+			if previous[hook] != "" {
+				pocket.Printf(ctx, "  Chained %s to %s\n", hook, previous[hook])
+			}
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  Wrote %s\n", path)
+			}
+		}
+		for _, hook := range slices.Sorted(maps.Keys(previous)) {
+			if _, ok := cfg[hook]; !ok {
+				pocket.Printf(ctx, "  Warning: %s is no longer run by git (core.hooksPath=%s)\n", previous[hook], HooksDir)
+			}
+		}
+
+		if current != "" && current != HooksDir {
+			if _, err := gitConfig(ctx, backupKey, current); err != nil {
+				return err
+			}
+			pocket.Printf(ctx, "  Replaced core.hooksPath=%s (restored by -uninstall)\n", current)
+		}
+		if _, err := gitConfig(ctx, "core.hooksPath", HooksDir); err != nil {
+			return err
+		}
+		pocket.Printf(ctx, "  Installed %s (core.hooksPath=%s)\n", strings.Join(hooks, ", "), HooksDir)
+		return nil
+	})
+}
+
+// HookScript renders a POSIX shell hook that runs the given task invocations
+// through the shim from the git root, stopping at the first failure.
+// If previous is set, the hook at that path (relative to the git root, or
+// absolute) runs first, with the hook's arguments.
+func HookScript(shimName string, invocations []string, previous string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("#!/bin/sh\n")
+	buf.WriteString("# Code generated by pocket. DO NOT EDIT.\n")
+	buf.WriteString("# Run `./" + shimName + " hooks-install` to regenerate.\n")
+	buf.WriteString("set -e\n")
+	buf.WriteString("cd \"$(git rev-parse --show-toplevel)\"\n")
+	if previous != "" {
+		quoted := "'" + strings.ReplaceAll(previous, "'", `'\''`) + "'"
+		fmt.Fprintf(&buf, "if [ -x %s ]; then %s \"$@\"; fi\n", quoted, quoted)
+	}
+	for _, inv := range invocations {
+		fmt.Fprintf(&buf, "./%s %s\n", shimName, inv)
+	}
+	return buf.Bytes()
+}
+
+// previousHooks returns the hooks git ran before hooks-install, by name.
+// They are read from current (the core.hooksPath being replaced) or, if it is
+// unset, from the repository's hooks directory. Sample hooks are skipped.
+// Paths are relative to the git root where possible.
+func previousHooks(ctx context.Context, current string) (map[string]string, error) {
+	if current == HooksDir {
+		return nil, nil
+	}
+	dir := current
+	if dir == "" {
+		out, err := gitOutput(ctx, "rev-parse", "--git-path", "hooks")
+		if err != nil {
+			return nil, err
+		}
+		dir = out
+	}
+	root := pocket.GitRoot()
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read hooks dir: %w", err)
+	}
+	hooks := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".sample") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		hooks[entry.Name()] = filepath.ToSlash(path)
+	}
+	return hooks, nil
+}
+
+// restoreHooksPath sets core.hooksPath back to the value saved by install,
+// or unsets it if there was none.
+func restoreHooksPath(ctx context.Context) error {
+	previous, _ := gitConfig(ctx, "--get", backupKey)
+	if previous == "" {
+		_, err := gitConfig(ctx, "--unset", "core.hooksPath")
+		return err
+	}
+	if _, err := gitConfig(ctx, "core.hooksPath", previous); err != nil {
+		return err
+	}
+	_, err := gitConfig(ctx, "--unset", backupKey)
+	return err
+}
+
+// ensureIgnored appends line to the .gitignore at path unless it is
+// already listed. Repositories scaffolded before hooks-install existed
+// don't ignore the hooks directory.
+func ensureIgnored(path, line string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if slices.ContainsFunc(strings.Split(string(data), "\n"), func(l string) bool {
+		return strings.TrimSpace(l) == line
+	}) {
+		return nil
+	}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	data = append(data, line+"\n"...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("update %s: %w", path, err)
+	}
+	return nil
+}
+
+// gitConfig runs git config with the given arguments and returns its output.
+func gitConfig(ctx context.Context, args ...string) (string, error) {
+	return gitOutput(ctx, append([]string{"config", "--local"}, args...)...)
+}
+
+// gitOutput runs git from the git root and returns its trimmed output.
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := pocket.Command(ctx, "git", args...)
+	cmd.Dir = pocket.GitRoot()
+	cmd.Stdout = &out
+	if err := pocket.RunCommand(ctx, cmd); err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestHookScript(t *testing.T) {
+	got := string(HookScript("pok", []string{"go-format", "go-test -short"}, ""))
+	want := "#!/bin/sh\n" +
+		"# Code generated by pocket. DO NOT EDIT.\n" +
+		"# Run `./pok hooks-install` to regenerate.\n" +
+		"set -e\n" +
+		"cd \"$(git rev-parse --show-toplevel)\"\n" +
+		"./pok go-format\n" +
+		"./pok go-test -short\n"
+	if got != want {
+		t.Errorf("HookScript() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHookScript_CustomShim(t *testing.T) {
+	got := string(HookScript("x", []string{"lint"}, ""))
+	want := "./x lint\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("expected script to end with %q, got:\n%s", want, got)
+	}
+}
+
+func TestHookScript_Chained(t *testing.T) {
+	got := string(HookScript("pok", []string{"lint"}, ".git/hooks/pre-commit"))
+	want := "cd \"$(git rev-parse --show-toplevel)\"\n" +
+		"if [ -x '.git/hooks/pre-commit' ]; then '.git/hooks/pre-commit' \"$@\"; fi\n" +
+		"./pok lint\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("expected script to end with:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestHooksTask_Group(t *testing.T) {
+	if got := HooksTask(DefaultHooksConfig()).Group(); got != "git" {
+		t.Errorf("Group() = %q, want git", got)
+	}
+}
+
+func TestEnsureIgnored(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{"missing file", "", "hooks/\n"},
+		{"appends", "bin/\n", "bin/\nhooks/\n"},
+		{"adds newline", "bin/", "bin/\nhooks/\n"},
+		{"already listed", "bin/\nhooks/\nlogs/\n", "bin/\nhooks/\nlogs/\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".gitignore")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := ensureIgnored(path, "hooks/"); err != nil {
+				t.Fatalf("ensureIgnored() failed: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHooksTask_ChainsPreviousHooks(t *testing.T) {
+	root := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Skipf("git init: %v: %s", err, out)
+	}
+	restore := gitroot.Override(root)
+	defer restore()
+	hooks := filepath.Join(root, ".git", "hooks")
+	if err := os.MkdirAll(hooks, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"pre-commit", "commit-msg", "pre-push.sample"} {
+		if err := os.WriteFile(filepath.Join(hooks, name), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out strings.Builder
+	ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{
+		Output: &pocket.Output{Stdout: &out, Stderr: &out},
+	})
+	if err := HooksTask(HooksConfig{"pre-commit": {"lint"}}).Run(ctx); err != nil {
+		t.Fatalf("hooks-install failed: %v\n%s", err, out.String())
+	}
+
+	script, err := os.ReadFile(filepath.Join(root, HooksDir, "pre-commit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(script), "'.git/hooks/pre-commit' \"$@\"") {
+		t.Errorf("expected pre-commit to chain the previous hook, got:\n%s", script)
+	}
+	if !strings.Contains(out.String(), "Warning: .git/hooks/commit-msg is no longer run") {
+		t.Errorf("expected a warning for commit-msg, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "pre-push") {
+		t.Errorf("expected sample hooks to be ignored, got:\n%s", out.String())
+	}
+}
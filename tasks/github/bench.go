@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"text/template"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// BenchWorkflowConfig configures the generated benchmark workflow.
+type BenchWorkflowConfig struct {
+	// Name is the workflow name and file name. Default: "bench".
+	Name string
+
+	// Cron is the schedule of the nightly run. Default: "0 3 * * *".
+	Cron string
+
+	// RunsOn is the runner label. Default: "ubuntu-latest".
+	RunsOn string
+
+	// GoVersionFile is passed to actions/setup-go. Default: ".pocket/go.mod".
+	GoVersionFile string
+}
+
+// benchWorkflowData holds the template data for the benchmark workflow.
+type benchWorkflowData struct {
+	BenchWorkflowConfig
+	Shim string
+}
+
+// withDefaults returns a copy of the config with default values applied.
+func (c BenchWorkflowConfig) withDefaults() BenchWorkflowConfig {
+	if c.Name == "" {
+		c.Name = "bench"
+	}
+	if c.Cron == "" {
+		c.Cron = "0 3 * * *"
+	}
+	if c.RunsOn == "" {
+		c.RunsOn = "ubuntu-latest"
+	}
+	if c.GoVersionFile == "" {
+		c.GoVersionFile = ".pocket/go.mod"
+	}
+	return c
+}
+
+// GenerateBenchWorkflow renders the benchmark workflow. It runs go-bench
+// nightly and on pull requests, keeps the results of base branch runs in
+// the Actions cache, and runs go-bench-compare on pull requests, which
+// comments on regressions.
+func GenerateBenchWorkflow(shimName string, cfg BenchWorkflowConfig) ([]byte, error) {
+	const tmplFile = "bench.yml.tmpl"
+	cfg = cfg.withDefaults()
+
+	// NOTE: Use path.Join (not filepath.Join) because embed.FS always uses forward slashes.
+	tmplContent, err := workflowTemplates.ReadFile(path.Join("workflows", tmplFile))
+	if err != nil {
+		return nil, fmt.Errorf("read template %s: %w", tmplFile, err)
+	}
+	tmpl, err := template.New(tmplFile).Parse(string(tmplContent))
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", tmplFile, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, benchWorkflowData{BenchWorkflowConfig: cfg, Shim: shimName}); err != nil {
+		return nil, fmt.Errorf("execute template %s: %w", tmplFile, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// BenchWorkflowTask creates the gha-bench-workflow task, which writes
+// .github/workflows/<Name>.yml (bench.yml by default).
+// Register the golang.Bench and golang.BenchCompare tasks for the modules
+// to benchmark, and add this task to Config.Generate:
+//
+//	var Config = pocket.Config{
+//	    ManualRun: []pocket.Runnable{
+//	        pocket.RunIn(pocket.Serial(golang.Bench, golang.BenchCompare), pocket.Include("services/api")),
+//	    },
+//	    Generate: []pocket.Runnable{
+//	        github.BenchWorkflowTask(github.BenchWorkflowConfig{}),
+//	    },
+//	}
+func BenchWorkflowTask(cfg BenchWorkflowConfig) *pocket.TaskDef {
+	return pocket.Task("gha-bench-workflow", "generate GitHub Actions benchmark workflow",
+		pocket.Do(func(ctx context.Context) error {
+			shimName := "pok"
+			if plan := pocket.GetConfigPlan(ctx); plan != nil && plan.Config != nil {
+				shimName = plan.Config.WithDefaults().Shim.Name
+			}
+			content, err := GenerateBenchWorkflow(shimName, cfg)
+			if err != nil {
+				return err
+			}
+
+			workflowDir := pocket.FromGitRoot(".github", "workflows")
+			if err := os.MkdirAll(workflowDir, 0o755); err != nil {
+				return fmt.Errorf("create workflows dir: %w", err)
+			}
+			destPath := filepath.Join(workflowDir, cfg.withDefaults().Name+".yml")
+			if err := os.WriteFile(destPath, content, 0o644); err != nil {
+				return fmt.Errorf("write %s: %w", destPath, err)
+			}
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  Generated %s\n", destPath)
+			}
+			return nil
+		}),
+	)
+}
@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateBenchWorkflow(t *testing.T) {
+	data, err := GenerateBenchWorkflow("pok", BenchWorkflowConfig{Cron: "30 1 * * 1-5"})
+	if err != nil {
+		t.Fatalf("GenerateBenchWorkflow() failed: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"name: bench",
+		`- cron: "30 1 * * 1-5"`,
+		"pull-requests: write",
+		"runs-on: ubuntu-latest",
+		"go-version-file: .pocket/go.mod",
+		"key: pocket-bench-${{ github.sha }}",
+		"run: ./pok go-bench -v",
+		"run: ./pok go-bench-compare -v",
+		"uses: actions/cache/save@v4",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// DefaultTypes are the conventional commit types allowed in PR titles by
+// branch-lint, the same as in the PR title job of the generated workflow.
+var DefaultTypes = []string{
+	"build", "chore", "ci", "docs", "feat", "fix", "merge",
+	"perf", "refactor", "revert", "style", "test", "wip",
+}
+
+// BranchLintOptions configures the branch-lint task.
+type BranchLintOptions struct {
+	BranchPattern string `arg:"branch-pattern" usage:"regexp branch names must match (default: not checked)"`
+	TitlePattern  string `arg:"title-pattern"  usage:"regexp PR titles must match, instead of conventional commit types"`
+	Types         string `arg:"types"          usage:"comma-separated conventional commit types (default: build, chore, ci, docs, feat, fix, ...)"`
+	Branch        string `arg:"branch"         usage:"branch name to check (default: the PR branch, or the current branch outside CI)"`
+	Title         string `arg:"title"          usage:"PR title to check (default: from the pull request event)"`
+}
+
+// BranchLint validates the branch name and pull request title. Titles must
+// be conventional commits, e.g. "feat(api): add endpoint", with one of
+// DefaultTypes (or -types) and a subject that doesn't start with an
+// uppercase letter; set -title-pattern to use a regexp instead. Branch names
+// are only checked with -branch-pattern.
+//
+// In GitHub Actions, the branch and title are read from GITHUB_HEAD_REF and
+// the pull request event, so the task is meant for the title job of the
+// generated workflow, see MatrixConfig.BranchLint. Outside of a pull
+// request, the title is not checked unless -title is set.
+//
+// Example usage in .pocket/config.go:
+//
+//	ManualRun: []pocket.Runnable{
+//	    pocket.WithOpts(github.BranchLint, github.BranchLintOptions{
+//	        BranchPattern: `^(feat|fix|chore|docs)/[a-z0-9-]+$`,
+//	    }),
+//	},
+var BranchLint = pocket.Task("branch-lint", "validate branch name and PR title",
+	branchLintCmd(),
+	pocket.Opts(BranchLintOptions{}),
+	pocket.WithExample(`./pok branch-lint -title "feat: add branch-lint"`, "check a title locally"),
+)
+
+func branchLintCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[BranchLintOptions](ctx)
+		branch := opts.Branch
+		if branch == "" && opts.BranchPattern != "" {
+			branch = currentBranch(ctx, os.Getenv)
+		}
+		title := opts.Title
+		if title == "" {
+			title = pullRequestTitle(os.Getenv)
+		}
+
+		violations, err := lintBranch(opts, branch, title)
+		if err != nil {
+			return err
+		}
+		if len(violations) > 0 {
+			for _, v := range violations {
+				pocket.Printf(ctx, "  %s\n", v)
+			}
+			return fmt.Errorf("%d branch-lint violation(s)", len(violations))
+		}
+		if pocket.Verbose(ctx) {
+			if title == "" {
+				pocket.Println(ctx, "  No pull request title to check")
+			}
+			if branch != "" {
+				pocket.Printf(ctx, "  Branch %q is valid\n", branch)
+			}
+		}
+		return nil
+	})
+}
+
+// lintBranch checks branch and title against the rules of opts. Empty
+// values are not checked.
+func lintBranch(opts BranchLintOptions, branch, title string) ([]string, error) {
+	var violations []string
+	if opts.BranchPattern != "" && branch != "" {
+		re, err := regexp.Compile(opts.BranchPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -branch-pattern: %w", err)
+		}
+		if !re.MatchString(branch) {
+			violations = append(violations, fmt.Sprintf("branch %q does not match %s", branch, opts.BranchPattern))
+		}
+	}
+	if title == "" {
+		return violations, nil
+	}
+	if opts.TitlePattern != "" {
+		re, err := regexp.Compile(opts.TitlePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -title-pattern: %w", err)
+		}
+		if !re.MatchString(title) {
+			violations = append(violations, fmt.Sprintf("PR title %q does not match %s", title, opts.TitlePattern))
+		}
+		return violations, nil
+	}
+	types := DefaultTypes
+	if opts.Types != "" {
+		types = nil
+		for t := range strings.SplitSeq(opts.Types, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+	if v := checkConventionalTitle(title, types); v != "" {
+		violations = append(violations, v)
+	}
+	return violations, nil
+}
+
+// conventionalRe matches a conventional commit header: type, optional
+// scope, optional "!" for breaking changes, and the subject.
+var conventionalRe = regexp.MustCompile(`^([a-z]+)(\([^()]+\))?!?: (\S.*)$`)
+
+// checkConventionalTitle returns a violation if title is not a conventional
+// commit with one of types, or "" if it is.
+func checkConventionalTitle(title string, types []string) string {
+	m := conventionalRe.FindStringSubmatch(title)
+	if m == nil {
+		return fmt.Sprintf("PR title %q is not a conventional commit, e.g. \"feat(api): add endpoint\"", title)
+	}
+	if !slices.Contains(types, m[1]) {
+		return fmt.Sprintf("PR title %q has unknown type %q, want one of: %s", title, m[1], strings.Join(types, ", "))
+	}
+	if r, _ := utf8.DecodeRuneInString(m[3]); unicode.IsUpper(r) {
+		return fmt.Sprintf("PR title %q: subject must not start with an uppercase letter", title)
+	}
+	return ""
+}
+
+// currentBranch returns the pull request branch in GitHub Actions, or the
+// checked out branch outside of CI. It returns "" on push events and
+// detached checkouts.
+func currentBranch(ctx context.Context, getenv func(string) string) string {
+	if getenv("GITHUB_ACTIONS") == "true" {
+		return getenv("GITHUB_HEAD_REF")
+	}
+	var out bytes.Buffer
+	cmd := pocket.Command(ctx, "git", "branch", "--show-current")
+	cmd.Dir = pocket.GitRoot()
+	cmd.Stdout = &out
+	if err := pocket.RunCommand(ctx, cmd); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// pullRequestTitle returns the title of the pull request that triggered the
+// GitHub Actions run, or "" for other events.
+func pullRequestTitle(getenv func(string) string) string {
+	path := getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var event struct {
+		PullRequest struct {
+			Title string `json:"title"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return ""
+	}
+	return event.PullRequest.Title
+}
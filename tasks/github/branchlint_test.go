@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintBranch(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   BranchLintOptions
+		branch string
+		title  string
+		want   string // substring of the violation, "" for none
+	}{
+		{name: "conventional title", title: "feat(api): add endpoint"},
+		{name: "breaking change", title: "refactor!: drop v1 API"},
+		{name: "no title", branch: "anything"},
+		{name: "not conventional", title: "Add endpoint", want: "is not a conventional commit"},
+		{name: "missing space", title: "fix:typo", want: "is not a conventional commit"},
+		{name: "unknown type", title: "feature: add endpoint", want: `unknown type "feature"`},
+		{name: "uppercase subject", title: "fix: Typo", want: "must not start with an uppercase letter"},
+		{name: "custom types", opts: BranchLintOptions{Types: "feat, deps"}, title: "deps: bump x"},
+		{name: "custom types exclude defaults", opts: BranchLintOptions{Types: "feat,deps"}, title: "fix: x", want: "want one of: feat, deps"},
+		{name: "title pattern", opts: BranchLintOptions{TitlePattern: `^\[[A-Z]+-\d+\] `}, title: "[ABC-12] Add x"},
+		{name: "title pattern mismatch", opts: BranchLintOptions{TitlePattern: `^\[[A-Z]+-\d+\] `}, title: "feat: x", want: "does not match"},
+		{name: "branch pattern", opts: BranchLintOptions{BranchPattern: `^(feat|fix)/[a-z0-9-]+$`}, branch: "feat/add-x"},
+		{name: "branch mismatch", opts: BranchLintOptions{BranchPattern: `^(feat|fix)/[a-z0-9-]+$`}, branch: "My_Branch", want: `branch "My_Branch" does not match`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := lintBranch(tt.opts, tt.branch, tt.title)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Join(violations, "\n")
+			if tt.want == "" && got != "" {
+				t.Errorf("unexpected violation: %s", got)
+			}
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("violations %q do not contain %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := lintBranch(BranchLintOptions{BranchPattern: "("}, "x", ""); err == nil {
+		t.Error("expected invalid -branch-pattern to fail")
+	}
+}
+
+func TestPullRequestTitle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(path, []byte(`{"pull_request": {"number": 1, "title": "feat: x"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"GITHUB_EVENT_PATH": path}
+	if got := pullRequestTitle(func(k string) string { return env[k] }); got != "feat: x" {
+		t.Errorf("pullRequestTitle() = %q, want %q", got, "feat: x")
+	}
+	if got := pullRequestTitle(func(string) string { return "" }); got != "" {
+		t.Errorf("pullRequestTitle() without event = %q, want empty", got)
+	}
+}
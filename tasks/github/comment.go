@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"context"
+	"os"
+
+	"github.com/fredrikaverpil/pocket/internal/prcomment"
+)
+
+// UpsertPRComment posts body as a comment on the pull request of the
+// current GitHub Actions run, or updates the comment posted earlier with the
+// same key, so that repeated runs keep a single comment up to date. It
+// requires GITHUB_TOKEN with pull-requests: write permission, and reports
+// false without posting when not running for a pull request.
+func UpsertPRComment(ctx context.Context, key, body string) (bool, error) {
+	pr, ok := prcomment.Current(os.Getenv)
+	if !ok {
+		return false, nil
+	}
+	return true, pr.Upsert(ctx, key, body)
+}
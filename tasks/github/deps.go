@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"text/template"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+//go:embed deps/*.tmpl
+var depsTemplates embed.FS
+
+// toolVersionRegex matches the "// renovate:" comments above tool version
+// constants in tools/*, e.g.
+//
+//	// renovate: datasource=go depName=golang.org/x/vuln
+//	const Version = "v1.1.4"
+const toolVersionRegex = `//\s*renovate:\s*datasource=(?<datasource>[^\s]+)\s+depName=(?<depName>[^\s]+)` +
+	`(?:\s+extractVersion=(?<extractVersion>[^\s]+))?\n\s*(?:const\s+)?(?:version|Version)\s*(?:=|:=)\s*` +
+	`["']?(?<currentValue>[^"'\s]+)["']?`
+
+// DepsConfig configures the deps-config task.
+type DepsConfig struct {
+	// Renovate writes renovate.json instead of .github/dependabot.yml.
+	// Only Renovate can update tool versions pinned in Go constants
+	// (via a regex manager on "// renovate:" comments).
+	Renovate bool
+
+	// Schedule is the Dependabot update interval (daily, weekly, monthly).
+	// Default: "weekly"
+	Schedule string
+
+	// SkipPocket excludes the .pocket module from Go updates.
+	SkipPocket bool
+
+	// SkipGitHubActions excludes GitHub Actions updates.
+	SkipGitHubActions bool
+}
+
+// dependabotData holds the template data for dependabot.yml.
+type dependabotData struct {
+	GoDirectories []string
+	GitHubActions bool
+	Schedule      string
+}
+
+// renovateConfig is the generated renovate.json.
+type renovateConfig struct {
+	Schema         string                  `json:"$schema"`
+	Extends        []string                `json:"extends"`
+	IgnorePaths    []string                `json:"ignorePaths,omitempty"`
+	PostUpdate     []string                `json:"postUpdateOptions,omitempty"`
+	PackageRules   []renovatePackageRule   `json:"packageRules,omitempty"`
+	CustomManagers []renovateCustomManager `json:"customManagers"`
+}
+
+type renovatePackageRule struct {
+	MatchManagers []string `json:"matchManagers"`
+	GroupName     string   `json:"groupName,omitempty"`
+	Enabled       *bool    `json:"enabled,omitempty"`
+}
+
+type renovateCustomManager struct {
+	CustomType          string   `json:"customType"`
+	ManagerFilePatterns []string `json:"managerFilePatterns"`
+	MatchStrings        []string `json:"matchStrings"`
+}
+
+// GenerateDependabot renders .github/dependabot.yml for the given Go module
+// directories (relative to the git root, "." for the root module).
+func GenerateDependabot(goDirs []string, cfg DepsConfig) ([]byte, error) {
+	const tmplFile = "dependabot.yml.tmpl"
+
+	tmplContent, err := depsTemplates.ReadFile(path.Join("deps", tmplFile))
+	if err != nil {
+		return nil, fmt.Errorf("read template %s: %w", tmplFile, err)
+	}
+	funcs := template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+	tmpl, err := template.New(tmplFile).Funcs(funcs).Parse(string(tmplContent))
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", tmplFile, err)
+	}
+
+	data := dependabotData{
+		GitHubActions: !cfg.SkipGitHubActions,
+		Schedule:      cfg.Schedule,
+	}
+	if data.Schedule == "" {
+		data.Schedule = "weekly"
+	}
+	for _, dir := range goDirs {
+		if dir == "." {
+			dir = ""
+		}
+		data.GoDirectories = append(data.GoDirectories, "/"+filepath.ToSlash(dir))
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("execute template %s: %w", tmplFile, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateRenovate renders renovate.json with a regex manager for tool
+// versions pinned in Go constants. Go modules (including .pocket) are
+// detected by Renovate itself.
+func GenerateRenovate(cfg DepsConfig) ([]byte, error) {
+	rc := renovateConfig{
+		Schema:     "https://docs.renovatebot.com/renovate-schema.json",
+		Extends:    []string{"config:recommended"},
+		PostUpdate: []string{"gomodTidy"},
+		CustomManagers: []renovateCustomManager{{
+			CustomType:          "regex",
+			ManagerFilePatterns: []string{`/\.go$/`},
+			MatchStrings:        []string{toolVersionRegex},
+		}},
+	}
+	if cfg.SkipPocket {
+		rc.IgnorePaths = []string{pocket.DirName + "/**"}
+	}
+	actionsRule := renovatePackageRule{MatchManagers: []string{"github-actions"}, GroupName: "github-actions"}
+	if cfg.SkipGitHubActions {
+		disabled := false
+		actionsRule = renovatePackageRule{MatchManagers: []string{"github-actions"}, Enabled: &disabled}
+	}
+	rc.PackageRules = append(rc.PackageRules, actionsRule)
+	// Disable HTML escaping so the regex named groups stay readable.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DepsConfigTask creates the deps-config task.
+// It writes .github/dependabot.yml (or renovate.json with DepsConfig.Renovate)
+// covering the root module, nested Go modules, the .pocket module and GitHub
+// Actions. Add it to Config.Generate to keep it in sync with the repository.
+//
+// Example usage in .pocket/config.go:
+//
+//	var Config = pocket.Config{
+//	    Generate: []pocket.Runnable{
+//	        github.DepsConfigTask(github.DepsConfig{Renovate: true}),
+//	    },
+//	}
+func DepsConfigTask(cfg DepsConfig) *pocket.TaskDef {
+	return pocket.Task("deps-config", "generate dependabot/renovate configuration",
+		depsConfigCmd(cfg),
+	)
+}
+
+func depsConfigCmd(cfg DepsConfig) pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		var (
+			content  []byte
+			destPath string
+			err      error
+		)
+		if cfg.Renovate {
+			destPath = pocket.FromGitRoot("renovate.json")
+			content, err = GenerateRenovate(cfg)
+		} else {
+			goDirs := pocket.DetectByFile("go.mod")
+			if !cfg.SkipPocket {
+				goDirs = append(goDirs, pocket.DirName)
+				slices.Sort(goDirs)
+			}
+			destPath = pocket.FromGitRoot(".github", "dependabot.yml")
+			content, err = GenerateDependabot(goDirs, cfg)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+			return fmt.Errorf("create dir: %w", err)
+		}
+		if err := os.WriteFile(destPath, content, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", destPath, err)
+		}
+
+		if pocket.Verbose(ctx) {
+			pocket.Printf(ctx, "  Generated %s\n", destPath)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerateDependabot(t *testing.T) {
+	data, err := GenerateDependabot([]string{".", ".pocket", "services/api"}, DepsConfig{})
+	if err != nil {
+		t.Fatalf("GenerateDependabot() failed: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"package-ecosystem: gomod",
+		`- "/"`,
+		`- "/.pocket"`,
+		`- "/services/api"`,
+		"package-ecosystem: github-actions",
+		"interval: weekly",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected dependabot.yml to contain %q, got:\n%s", want, content)
+		}
+	}
+
+	data, err = GenerateDependabot(nil, DepsConfig{Schedule: "daily", SkipGitHubActions: true})
+	if err != nil {
+		t.Fatalf("GenerateDependabot() failed: %v", err)
+	}
+	content = string(data)
+	if strings.Contains(content, "gomod") || strings.Contains(content, "github-actions") {
+		t.Errorf("expected no update entries, got:\n%s", content)
+	}
+}
+
+func TestGenerateRenovate(t *testing.T) {
+	data, err := GenerateRenovate(DepsConfig{Renovate: true})
+	if err != nil {
+		t.Fatalf("GenerateRenovate() failed: %v", err)
+	}
+
+	var rc renovateConfig
+	if err := json.Unmarshal(data, &rc); err != nil {
+		t.Fatalf("invalid renovate.json: %v", err)
+	}
+	if len(rc.CustomManagers) != 1 {
+		t.Fatalf("expected 1 custom manager, got %d", len(rc.CustomManagers))
+	}
+
+	// The regex manager must match the version constants in tools/*.
+	// Go's regexp uses (?P<name>) for named groups.
+	re := regexp.MustCompile(strings.ReplaceAll(rc.CustomManagers[0].MatchStrings[0], "(?<", "(?P<"))
+	src := "// renovate: datasource=github-releases depName=oven-sh/bun extractVersion=^bun-v(?<version>.*)$\n" +
+		"const Version = \"1.2.3\"\n"
+	m := re.FindStringSubmatch(src)
+	if m == nil {
+		t.Fatalf("expected regex to match tool version constant")
+	}
+	if got := m[re.SubexpIndex("currentValue")]; got != "1.2.3" {
+		t.Errorf("expected currentValue 1.2.3, got %q", got)
+	}
+}
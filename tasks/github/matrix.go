@@ -1,9 +1,14 @@
+// SPDX-License-Identifier: MIT
+
 package github
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/fredrikaverpil/pocket"
@@ -17,6 +22,8 @@ type MatrixConfig struct {
 	// TaskOverrides provides per-task platform configuration.
 	// Keys are treated as regular expressions and matched against task names.
 	// Example: "py-test:.*" matches "py-test:3.9", "py-test:3.10", etc.
+	// If several keys match, an exact task name wins, then the first
+	// matching key in sorted order.
 	TaskOverrides map[string]TaskOverride
 
 	// ExcludeTasks removes tasks from the matrix entirely.
@@ -32,6 +39,104 @@ type MatrixConfig struct {
 	// Default: "ps1"
 	// Ignored when WindowsShell is "bash" (always uses ./pok).
 	WindowsShim string
+
+	// WorkflowName is the name of the workflow generated by WorkflowTask.
+	// It is also used as the file name (.github/workflows/<name>.yml).
+	// Default: "pr"
+	WorkflowName string
+
+	// SkipPRTitle removes the job that validates pull request titles against
+	// conventional commit types from the generated workflow.
+	SkipPRTitle bool
+
+	// BranchLint makes the PR title job run "./pok branch-lint" instead of
+	// action-semantic-pull-request, so that titles and branch names are
+	// checked against the rules configured for BranchLint. Register the
+	// task in Config.ManualRun. Ignored with SkipPRTitle.
+	BranchLint bool
+
+	// Branches lists the branches that trigger the generated workflow on push.
+	// Pull requests always trigger the workflow.
+	// Default: ["main", "master"]
+	Branches []string
+
+	// Permissions sets the GITHUB_TOKEN permissions of the generated workflow.
+	// Default: {"contents": "read"}
+	Permissions map[string]string
+
+	// GoVersionFile is passed to actions/setup-go in the generated workflow.
+	// Default: ".pocket/go.mod"
+	GoVersionFile string
+
+	// SkipToolCache disables the actions/cache step for .pocket/tools and
+	// .pocket/bin in the generated workflow. By default, downloaded tools
+	// (golangci-lint, uv, stylua, the Go SDK, ...) are cached between runs.
+	// The Go caches are handled separately, see SkipBuildCache.
+	SkipToolCache bool
+
+	// ToolCacheKeyFiles are the files hashed into the tool cache key, in
+	// addition to the pinned versions of all tools installed by the task tree
+	// (see pocket.AsTool). The versions are hashed when the workflow is
+	// generated, so bumping a tool version changes the key even when
+	// .pocket/go.sum does not (e.g. with a local replace directive).
+	// Default: [".pocket/go.mod", ".pocket/go.sum"]
+	ToolCacheKeyFiles []string
+
+	// SkipBuildCache disables caching the Go build and module caches (GOCACHE
+	// and GOMODCACHE) in the generated workflow, and leaves them to
+	// actions/setup-go instead. By default, they are cached per task and
+	// path, keyed by the hash of all go.sum files, so jobs don't overwrite
+	// each other's build cache. When a task installs uv or bun, their
+	// package caches are cached too, keyed by uv.lock and bun.lock(b).
+	SkipBuildCache bool
+
+	// Container runs every Linux job in this container image by default.
+	// GitHub only supports job containers on Linux runners, so macOS and
+	// Windows jobs run directly on the runner.
+	// TaskOverride.Container takes precedence.
+	Container string
+
+	// TimeoutMinutes sets timeout-minutes for every job by default.
+	// Zero means the GitHub default (360). TaskOverride.TimeoutMinutes takes precedence.
+	TimeoutMinutes int
+
+	// Concurrency is a job-level concurrency group template applied to every
+	// entry by default. The placeholders {task}, {os} and {path} are expanded
+	// per entry, e.g. "deploy-{task}". Jobs without a group are not limited.
+	// TaskOverride.Concurrency takes precedence.
+	Concurrency string
+
+	// UploadSARIF makes the generated workflow collect SARIF reports from
+	// lint and security tasks and upload them to GitHub code scanning.
+	// Each job sets POK_SARIF_DIR, runs "sarif-report" to merge the reports,
+	// and uploads the result. Register sarif.Report in Config.ManualRun.
+	// The "security-events: write" permission is added automatically.
+	UploadSARIF bool
+
+	// PRComment passes GITHUB_TOKEN to the task step, so that pocket can post
+	// the run summary configured by pocket.Config.PRComment on pull requests.
+	// The "pull-requests: write" permission is added automatically.
+	PRComment bool
+
+	// Schedules generates one scheduled workflow per entry alongside the
+	// pull request workflow, e.g. a nightly run of go-fuzz and image-scan.
+	// Scheduled tasks may come from AutoRun or ManualRun and are not removed
+	// from the pull request workflow; use ExcludeTasks for that.
+	Schedules []Schedule
+}
+
+// Schedule configures a scheduled workflow generated by WorkflowTask.
+type Schedule struct {
+	// Name is the workflow name. It is also used as the file name
+	// (.github/workflows/<name>.yml), e.g. "nightly".
+	Name string
+
+	// Cron is the schedule in cron syntax, e.g. "0 3 * * *".
+	Cron string
+
+	// Tasks are the names of the tasks to run on the schedule.
+	// TaskOverrides apply to them as in the pull request workflow.
+	Tasks []string
 }
 
 // TaskOverride configures a single task in the matrix.
@@ -43,6 +148,27 @@ type TaskOverride struct {
 	// SkipGitDiff disables the git-diff check after this task.
 	// Useful for tasks that intentionally modify files (e.g., code generators).
 	SkipGitDiff bool
+
+	// PerPath fans the task out into one job per resolved path
+	// (e.g. go-test in services/api and services/worker as separate jobs).
+	// Each job runs the shim from that directory. Tasks that only run
+	// in the root are unaffected.
+	PerPath bool
+
+	// RunsOn schedules the task's jobs on these runner labels instead of the
+	// platform name, e.g. ["self-hosted", "linux", "x64"]. The platform still
+	// selects the shell and shim.
+	RunsOn []string
+
+	// Container overrides MatrixConfig.Container for this task.
+	// Like MatrixConfig.Container, it only applies to Linux jobs.
+	Container string
+
+	// TimeoutMinutes overrides MatrixConfig.TimeoutMinutes for this task.
+	TimeoutMinutes int
+
+	// Concurrency overrides MatrixConfig.Concurrency for this task.
+	Concurrency string
 }
 
 // DefaultMatrixConfig returns sensible defaults.
@@ -60,7 +186,13 @@ type matrixEntry struct {
 	OS      string `json:"os"`
 	Shell   string `json:"shell"`
 	Shim    string `json:"shim"`
-	GitDiff bool   `json:"gitDiff"` // whether to run git-diff after this task
+	GitDiff bool   `json:"gitDiff"`        // whether to run git-diff after this task
+	Path    string `json:"path,omitempty"` // working directory when fanned out per path
+
+	RunsOn         []string `json:"runsOn,omitempty"`      // runner labels, overrides os for runs-on
+	Container      string   `json:"container,omitempty"`   // container image for the job
+	Concurrency    string   `json:"concurrency,omitempty"` // job-level concurrency group
+	TimeoutMinutes int      `json:"timeout,omitempty"`     // job timeout-minutes
 }
 
 // matrixOutput is the JSON structure for fromJson().
@@ -70,6 +202,11 @@ type matrixOutput struct {
 
 // GenerateMatrix creates the GitHub Actions matrix JSON from tasks.
 func GenerateMatrix(tasks []pocket.TaskInfo, cfg MatrixConfig) ([]byte, error) {
+	return json.Marshal(matrixOutput{Include: matrixEntries(tasks, cfg)})
+}
+
+// matrixEntries creates one matrix entry per task and platform.
+func matrixEntries(tasks []pocket.TaskInfo, cfg MatrixConfig) []matrixEntry {
 	if cfg.DefaultPlatforms == nil {
 		cfg.DefaultPlatforms = []string{"ubuntu-latest"}
 	}
@@ -101,37 +238,83 @@ func GenerateMatrix(tasks []pocket.TaskInfo, cfg MatrixConfig) ([]byte, error) {
 		// Determine if git-diff should run (default: true, unless overridden)
 		gitDiff := !override.SkipGitDiff
 
-		// Create entry for each platform
+		// Fan out per path if requested; "" means the task runs from the root.
+		paths := []string{""}
+		if override.PerPath && !slices.Equal(task.Paths, []string{"."}) && len(task.Paths) > 0 {
+			paths = task.Paths
+		}
+
+		container := cmp.Or(override.Container, cfg.Container)
+		timeout := cmp.Or(override.TimeoutMinutes, cfg.TimeoutMinutes)
+		concurrency := cmp.Or(override.Concurrency, cfg.Concurrency)
+
+		// Create entry for each platform (and path)
 		for _, platform := range platforms {
-			entries = append(entries, matrixEntry{
-				Task:    task.Name,
-				OS:      platform,
-				Shell:   shellForPlatform(platform, cfg.WindowsShell),
-				Shim:    shimForPlatform(platform, cfg.WindowsShell, cfg.WindowsShim),
-				GitDiff: gitDiff,
-			})
+			jobContainer := ""
+			if isLinux(platform, override.RunsOn) {
+				jobContainer = container
+			}
+			for _, p := range paths {
+				entries = append(entries, matrixEntry{
+					Task:           task.Name,
+					OS:             platform,
+					Shell:          shellForPlatform(platform, cfg.WindowsShell),
+					Shim:           shimForPlatform(platform, cfg.WindowsShell, cfg.WindowsShim),
+					GitDiff:        gitDiff,
+					Path:           p,
+					RunsOn:         override.RunsOn,
+					Container:      jobContainer,
+					Concurrency:    expandGroup(concurrency, task.Name, platform, p),
+					TimeoutMinutes: timeout,
+				})
+			}
 		}
 	}
 
-	return json.Marshal(matrixOutput{Include: entries})
+	return entries
+}
+
+// expandGroup expands the {task}, {os} and {path} placeholders in a
+// concurrency group template.
+func expandGroup(tmpl, task, platform, path string) string {
+	if tmpl == "" {
+		return ""
+	}
+	return strings.NewReplacer("{task}", task, "{os}", platform, "{path}", path).Replace(tmpl)
 }
 
 // getTaskOverride finds the TaskOverride for a task name by matching against
 // the patterns in TaskOverrides. Patterns are regular expressions.
+// Patterns are tried in sorted order so the result does not depend on map
+// iteration order; an exact task name match takes precedence.
 func getTaskOverride(taskName string, overrides map[string]TaskOverride) TaskOverride {
-	for pattern, override := range overrides {
+	if override, ok := overrides[taskName]; ok {
+		return override
+	}
+	for _, pattern := range slices.Sorted(maps.Keys(overrides)) {
 		re, err := regexp.Compile("^" + pattern + "$")
 		if err != nil {
 			// Invalid pattern, skip
 			continue
 		}
 		if re.MatchString(taskName) {
-			return override
+			return overrides[pattern]
 		}
 	}
 	return TaskOverride{}
 }
 
+// isLinux reports whether a job runs on a Linux runner. Runner labels take
+// precedence over the platform name, e.g. ["self-hosted", "linux"].
+func isLinux(platform string, runsOn []string) bool {
+	if len(runsOn) > 0 {
+		return slices.ContainsFunc(runsOn, func(label string) bool {
+			return strings.EqualFold(label, "linux")
+		})
+	}
+	return strings.Contains(platform, "ubuntu") || strings.Contains(platform, "linux")
+}
+
 // shellForPlatform returns the appropriate shell for the platform.
 func shellForPlatform(platform, windowsShell string) string {
 	if strings.Contains(platform, "windows") {
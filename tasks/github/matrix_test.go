@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package github
 
 import (
@@ -331,6 +333,40 @@ func TestGenerateMatrix_SkipGitDiff(t *testing.T) {
 	}
 }
 
+func TestGenerateMatrix_PerPath(t *testing.T) {
+	tasks := []pocket.TaskInfo{
+		{Name: "go-test", Usage: "test", Paths: []string{"services/api", "services/worker"}},
+		{Name: "go-lint", Usage: "lint", Paths: []string{"services/api", "services/worker"}},
+		{Name: "md-format", Usage: "format", Paths: []string{"."}},
+	}
+
+	cfg := MatrixConfig{
+		DefaultPlatforms: []string{"ubuntu-latest"},
+		TaskOverrides: map[string]TaskOverride{
+			"go-test":   {PerPath: true},
+			"md-format": {PerPath: true},
+		},
+	}
+	data, err := GenerateMatrix(tasks, cfg)
+	if err != nil {
+		t.Fatalf("GenerateMatrix() failed: %v", err)
+	}
+
+	var output matrixOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	var got []string
+	for _, entry := range output.Include {
+		got = append(got, entry.Task+"@"+entry.Path)
+	}
+	want := []string{"go-test@services/api", "go-test@services/worker", "go-lint@", "md-format@"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected entries %v, got %v", want, got)
+	}
+}
+
 func TestGenerateMatrix_TaskOverridesRegexp(t *testing.T) {
 	tasks := []pocket.TaskInfo{
 		{Name: "py-test:3.9", Usage: "test python 3.9"},
@@ -434,7 +470,29 @@ func TestGetTaskOverride_InvalidRegexp(t *testing.T) {
 		t.Errorf("expected valid pattern to match, got %+v", override)
 	}
 
-	// Invalid pattern should be skipped
+	// An invalid pattern still matches its exact task name.
 	override = getTaskOverride("[invalid", overrides)
-	// This might or might not match depending on iteration order, but shouldn't panic
+	if !override.SkipGitDiff {
+		t.Errorf("expected exact key to match, got %+v", override)
+	}
+}
+
+func TestGetTaskOverride_Deterministic(t *testing.T) {
+	overrides := map[string]TaskOverride{
+		"go-.*":   {Platforms: []string{"a"}},
+		"go-t.*":  {Platforms: []string{"b"}},
+		".*":      {Platforms: []string{"c"}},
+		"go-test": {Platforms: []string{"exact"}},
+	}
+
+	// Run repeatedly since map iteration order is randomized.
+	for range 50 {
+		if got := getTaskOverride("go-test", overrides).Platforms[0]; got != "exact" {
+			t.Fatalf("go-test: got %q, want exact match", got)
+		}
+		// ".*" sorts before "go-.*".
+		if got := getTaskOverride("go-lint", overrides).Platforms[0]; got != "c" {
+			t.Fatalf("go-lint: got %q, want first sorted pattern", got)
+		}
+	}
 }
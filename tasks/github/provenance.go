@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// ProvenanceOptions configures the provenance-subjects task.
+type ProvenanceOptions struct {
+	Artifacts string `arg:"artifacts" usage:"comma-separated artifact globs, relative to git root (e.g. dist/*.tar.gz)"`
+	Output    string `arg:"output"    usage:"checksums file to write (default: .pocket/provenance/subjects.sha256)"`
+}
+
+// Provenance collects the release artifacts matching the given globs and
+// writes their SHA-256 digests in sha256sum format. It does not sign or
+// attest anything: the file is the input for a signing step, passed as
+// subject-checksums to actions/attest-build-provenance (GitHub attestation API),
+// and its base64 encoding is what slsa-github-generator expects as base64-subjects.
+//
+// When running in GitHub Actions, the outputs "subject-checksums",
+// "base64-subjects" and "artifacts" (the artifact paths, space-separated) are
+// written to $GITHUB_OUTPUT for use in later steps:
+//
+//	steps:
+//	  - run: ./pok provenance-subjects -artifacts "dist/*.tar.gz,dist/*.zip"
+//	    id: provenance
+//	  - uses: actions/attest-build-provenance@v2
+//	    with:
+//	      subject-checksums: ${{ steps.provenance.outputs.subject-checksums }}
+//
+// The release workflow of github-workflows -provenance runs these steps for
+// each release and uploads the attested artifacts to it.
+var Provenance = pocket.Task("provenance-subjects", "write provenance subjects (artifact digests) for attestation",
+	provenanceCmd(),
+	pocket.Opts(ProvenanceOptions{}),
+)
+
+func provenanceCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[ProvenanceOptions](ctx)
+		if opts.Artifacts == "" {
+			return fmt.Errorf("provenance-subjects: -artifacts is required")
+		}
+
+		subjects, err := ProvenanceSubjects(pocket.GitRoot(), strings.Split(opts.Artifacts, ","))
+		if err != nil {
+			return err
+		}
+		if len(subjects) == 0 {
+			return fmt.Errorf("provenance-subjects: no artifacts match %q", opts.Artifacts)
+		}
+
+		output := opts.Output
+		if output == "" {
+			output = filepath.Join(pocket.DirName, "provenance", "subjects.sha256")
+		}
+		if !filepath.IsAbs(output) {
+			output = pocket.FromGitRoot(output)
+		}
+		if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+			return fmt.Errorf("create provenance dir: %w", err)
+		}
+		checksums := formatChecksums(subjects)
+		if err := os.WriteFile(output, checksums, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", output, err)
+		}
+		pocket.Printf(ctx, "  Wrote %d subject(s) to %s\n", len(subjects), output)
+
+		if ghOutput := os.Getenv("GITHUB_OUTPUT"); ghOutput != "" {
+			f, err := os.OpenFile(ghOutput, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+			if err != nil {
+				return fmt.Errorf("open GITHUB_OUTPUT: %w", err)
+			}
+			defer f.Close()
+			fmt.Fprintf(f, "subject-checksums=%s\n", output)
+			fmt.Fprintf(f, "base64-subjects=%s\n", base64.StdEncoding.EncodeToString(checksums))
+			paths := make([]string, 0, len(subjects))
+			for _, subject := range subjects {
+				paths = append(paths, subject.Path)
+			}
+			fmt.Fprintf(f, "artifacts=%s\n", strings.Join(paths, " "))
+		}
+
+		return nil
+	})
+}
+
+// ProvenanceSubject is a release artifact and its SHA-256 digest.
+type ProvenanceSubject struct {
+	Name   string // artifact file name, as published
+	Path   string // artifact path, slash-separated and relative to the root
+	SHA256 string // hex-encoded digest
+}
+
+// ProvenanceSubjects resolves the globs relative to root and hashes the matching
+// files. Subjects are named after the artifact file name (not its build
+// directory), sorted by name and deduplicated. Two different files with the
+// same name are an error.
+func ProvenanceSubjects(root string, globs []string) ([]ProvenanceSubject, error) {
+	var names []string
+	for _, glob := range globs {
+		glob = strings.TrimSpace(glob)
+		if glob == "" {
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(root, glob))
+		if err != nil {
+			return nil, fmt.Errorf("invalid artifact glob %q: %w", glob, err)
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && !info.IsDir() {
+				names = append(names, m)
+			}
+		}
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	subjects := make([]ProvenanceSubject, 0, len(names))
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		base := filepath.Base(name)
+		if other, ok := seen[base]; ok {
+			return nil, fmt.Errorf("artifacts %s and %s have the same name", other, name)
+		}
+		seen[base] = name
+		digest, err := pocket.FileSHA256(name)
+		if err != nil {
+			return nil, fmt.Errorf("hash %s: %w", name, err)
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, ProvenanceSubject{Name: base, Path: filepath.ToSlash(rel), SHA256: digest})
+	}
+	slices.SortFunc(subjects, func(a, b ProvenanceSubject) int { return strings.Compare(a.Name, b.Name) })
+	return subjects, nil
+}
+
+// formatChecksums renders subjects in sha256sum format.
+func formatChecksums(subjects []ProvenanceSubject) []byte {
+	var buf bytes.Buffer
+	for _, s := range subjects {
+		fmt.Fprintf(&buf, "%s  %s\n", s.SHA256, s.Name)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProvenanceSubjects(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "dist"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"dist/app.tar.gz": "hello",
+		"dist/app.zip":    "world",
+		"dist/notes.txt":  "ignored",
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	subjects, err := ProvenanceSubjects(root, []string{"dist/*.tar.gz", "dist/*.zip", "dist/app.zip"})
+	if err != nil {
+		t.Fatalf("ProvenanceSubjects() failed: %v", err)
+	}
+	if len(subjects) != 2 {
+		t.Fatalf("expected 2 subjects, got %d: %v", len(subjects), subjects)
+	}
+	if subjects[0].Name != "app.tar.gz" || subjects[0].Path != "dist/app.tar.gz" {
+		t.Errorf("expected first subject app.tar.gz, got %q (%s)", subjects[0].Name, subjects[0].Path)
+	}
+	// sha256("hello")
+	want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if subjects[0].SHA256 != want {
+		t.Errorf("expected digest %s, got %s", want, subjects[0].SHA256)
+	}
+
+	checksums := string(formatChecksums(subjects[:1]))
+	if checksums != want+"  app.tar.gz\n" {
+		t.Errorf("unexpected checksums format: %q", checksums)
+	}
+}
+
+func TestProvenanceSubjects_DuplicateName(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"linux/app.tar.gz", "darwin/app.tar.gz"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ProvenanceSubjects(root, []string{"*/app.tar.gz"}); err == nil {
+		t.Error("expected error for artifacts with the same name")
+	}
+}
@@ -0,0 +1,324 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tasks/sarif"
+)
+
+// workflowPermission is a single GITHUB_TOKEN permission in the workflow.
+type workflowPermission struct {
+	Scope  string
+	Access string
+}
+
+// workflowData holds the template data for the generated matrix workflow.
+type workflowData struct {
+	Name          string
+	Cron          string // scheduled workflow when set, instead of push and pull_request
+	Branches      []string
+	Permissions   []workflowPermission
+	GoVersionFile string
+	Entries       []matrixEntry
+	PRTitle       bool
+	BranchLint    bool // run branch-lint in the PR title job
+
+	// Job-level keys are only rendered when at least one entry sets them.
+	HasRunsOn      bool
+	HasContainer   bool
+	HasConcurrency bool
+	HasTimeout     bool
+
+	ToolCache     bool
+	ToolCacheHash string // comma-separated, quoted arguments for hashFiles()
+	ToolVersions  string // hash of the pinned tool versions, empty without tools
+	BuildCache    bool   // cache GOCACHE and GOMODCACHE per task
+	UVCache       bool   // cache UV_CACHE_DIR, when a task installs uv
+	BunCache      bool   // cache BUN_INSTALL_CACHE_DIR, when a task installs bun
+	SARIF         bool
+	SARIFDir      string
+	SARIFOutput   string
+	PRComment     bool
+}
+
+// workflowName returns the configured workflow name or its default.
+func workflowName(cfg MatrixConfig) string {
+	if cfg.WorkflowName == "" {
+		return "pr"
+	}
+	return cfg.WorkflowName
+}
+
+// newWorkflowData applies workflow defaults and builds the template data.
+func newWorkflowData(tasks []pocket.TaskInfo, cfg MatrixConfig) workflowData {
+	data := workflowData{
+		Name:          workflowName(cfg),
+		Branches:      cfg.Branches,
+		GoVersionFile: cfg.GoVersionFile,
+		Entries:       matrixEntries(tasks, cfg),
+		PRTitle:       !cfg.SkipPRTitle,
+		BranchLint:    cfg.BranchLint && !cfg.SkipPRTitle,
+	}
+	if len(data.Branches) == 0 {
+		data.Branches = []string{"main", "master"}
+	}
+	if data.GoVersionFile == "" {
+		data.GoVersionFile = ".pocket/go.mod"
+	}
+	for _, e := range data.Entries {
+		data.HasRunsOn = data.HasRunsOn || len(e.RunsOn) > 0
+		data.HasContainer = data.HasContainer || e.Container != ""
+		data.HasConcurrency = data.HasConcurrency || e.Concurrency != ""
+		data.HasTimeout = data.HasTimeout || e.TimeoutMinutes > 0
+	}
+
+	if !cfg.SkipToolCache {
+		keyFiles := cfg.ToolCacheKeyFiles
+		if len(keyFiles) == 0 {
+			keyFiles = []string{".pocket/go.mod", ".pocket/go.sum"}
+		}
+		quoted := make([]string, 0, len(keyFiles))
+		for _, f := range keyFiles {
+			quoted = append(quoted, "'"+f+"'")
+		}
+		data.ToolCache = true
+		data.ToolCacheHash = strings.Join(quoted, ", ")
+		data.ToolVersions = toolVersionsHash(tasks)
+	}
+
+	if !cfg.SkipBuildCache {
+		data.BuildCache = true
+		data.UVCache = usesTool(tasks, "uv")
+		data.BunCache = usesTool(tasks, "bun")
+	}
+
+	permissions := maps.Clone(cfg.Permissions)
+	if len(permissions) == 0 {
+		permissions = map[string]string{"contents": "read"}
+	}
+	if cfg.PRComment {
+		data.PRComment = true
+		permissions["pull-requests"] = "write"
+	}
+	if data.PRTitle {
+		if _, ok := permissions["pull-requests"]; !ok {
+			permissions["pull-requests"] = "read"
+		}
+	}
+	if cfg.UploadSARIF {
+		data.SARIF = true
+		data.SARIFDir = sarif.DefaultDir
+		data.SARIFOutput = sarif.DefaultOutput
+		if _, ok := permissions["security-events"]; !ok {
+			permissions["security-events"] = "write"
+		}
+	}
+	// Sort scopes so the generated file is stable between runs.
+	scopes := make([]string, 0, len(permissions))
+	for scope := range permissions {
+		scopes = append(scopes, scope)
+	}
+	slices.Sort(scopes)
+	for _, scope := range scopes {
+		data.Permissions = append(data.Permissions, workflowPermission{Scope: scope, Access: permissions[scope]})
+	}
+
+	return data
+}
+
+// toolVersionsHash returns a short hash of the pinned versions of the tools
+// installed by tasks (see pocket.AsTool), or "" if there are none.
+// Embedding it in the cache key invalidates the tool cache whenever a tool
+// version changes, even if .pocket/go.sum does not (e.g. with a local replace).
+func toolVersionsHash(tasks []pocket.TaskInfo) string {
+	var versions []string
+	for _, t := range tasks {
+		if t.Tool != nil {
+			versions = append(versions, t.Tool.Name+"@"+t.Tool.Version)
+		}
+	}
+	if len(versions) == 0 {
+		return ""
+	}
+	slices.Sort(versions)
+	versions = slices.Compact(versions)
+	sum := sha256.Sum256([]byte(strings.Join(versions, "\n")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// usesTool reports whether one of tasks installs the named tool.
+func usesTool(tasks []pocket.TaskInfo, name string) bool {
+	return slices.ContainsFunc(tasks, func(t pocket.TaskInfo) bool {
+		return t.Tool != nil && t.Tool.Name == name
+	})
+}
+
+// GenerateWorkflow renders the complete pull request workflow for the given
+// tasks: triggers, permissions, PR title validation, Go setup, tool caching,
+// the task matrix and the git-diff check. The matrix from GenerateMatrix is
+// inlined, so the file changes whenever the task tree or MatrixConfig changes.
+func GenerateWorkflow(tasks []pocket.TaskInfo, cfg MatrixConfig) ([]byte, error) {
+	return renderWorkflow(newWorkflowData(tasks, cfg))
+}
+
+// GenerateScheduleWorkflow renders the scheduled workflow for s. It runs the
+// tasks of s on the cron schedule and on manual dispatch, with the same job
+// settings as the pull request workflow, but without PR title validation
+// and PR comments.
+func GenerateScheduleWorkflow(tasks []pocket.TaskInfo, cfg MatrixConfig, s Schedule) ([]byte, error) {
+	if s.Name == "" {
+		return nil, fmt.Errorf("schedule %q: name is required", s.Cron)
+	}
+	if s.Cron == "" {
+		return nil, fmt.Errorf("schedule %s: cron is required", s.Name)
+	}
+	if len(s.Tasks) == 0 {
+		return nil, fmt.Errorf("schedule %s: no tasks", s.Name)
+	}
+
+	// Keep tool install tasks so the tool cache key matches the tasks run.
+	selected := make([]pocket.TaskInfo, 0, len(s.Tasks))
+	for _, name := range s.Tasks {
+		i := slices.IndexFunc(tasks, func(t pocket.TaskInfo) bool { return t.Name == name && !t.Hidden })
+		if i < 0 {
+			return nil, fmt.Errorf("schedule %s: unknown task %q", s.Name, name)
+		}
+		selected = append(selected, tasks[i])
+	}
+	for _, t := range tasks {
+		if t.Tool != nil {
+			selected = append(selected, t)
+		}
+	}
+
+	cfg.WorkflowName = s.Name
+	cfg.SkipPRTitle = true
+	cfg.PRComment = false
+	cfg.ExcludeTasks = nil
+	data := newWorkflowData(selected, cfg)
+	data.Cron = s.Cron
+	return renderWorkflow(data)
+}
+
+// renderWorkflow executes the workflow template with data.
+func renderWorkflow(data workflowData) ([]byte, error) {
+	const tmplFile = "pocket-workflow.yml.tmpl"
+
+	// NOTE: Use path.Join (not filepath.Join) because embed.FS always uses forward slashes.
+	tmplContent, err := workflowTemplates.ReadFile(path.Join("workflows", tmplFile))
+	if err != nil {
+		return nil, fmt.Errorf("read template %s: %w", tmplFile, err)
+	}
+
+	funcs := template.FuncMap{
+		"join": strings.Join,
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+	tmpl, err := template.New(tmplFile).Funcs(funcs).Parse(string(tmplContent))
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", tmplFile, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("execute template %s: %w", tmplFile, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WorkflowTask creates the gha-workflow task.
+// It writes .github/workflows/<WorkflowName>.yml (pr.yml by default) with the
+// task matrix inlined, and .github/workflows/<Name>.yml for each of
+// MatrixConfig.Schedules.
+// Add it to Config.Generate so the workflow is regenerated by ./pok generate
+// and any drift from the matrix config is caught by the git-diff check.
+//
+// Example usage in .pocket/config.go:
+//
+//	var Config = pocket.Config{
+//	    AutoRun: autoRun,
+//	    Generate: []pocket.Runnable{
+//	        github.WorkflowTask(autoRun, github.MatrixConfig{
+//	            DefaultPlatforms: []string{"ubuntu-latest", "macos-latest"},
+//	            Schedules: []github.Schedule{
+//	                {Name: "nightly", Cron: "0 3 * * *", Tasks: []string{"go-fuzz", "image-scan"}},
+//	            },
+//	        }),
+//	    },
+//	}
+func WorkflowTask(autoRun pocket.Runnable, cfg MatrixConfig) *pocket.TaskDef {
+	return pocket.Task("gha-workflow", "generate GitHub Actions workflow from the task matrix",
+		workflowCmd(autoRun, cfg),
+	)
+}
+
+func workflowCmd(autoRun pocket.Runnable, cfg MatrixConfig) pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		tasks, err := pocket.CollectTasks(autoRun)
+		if err != nil {
+			return err
+		}
+		content, err := GenerateWorkflow(tasks, cfg)
+		if err != nil {
+			return err
+		}
+		files := map[string][]byte{workflowName(cfg): content}
+
+		if len(cfg.Schedules) > 0 {
+			// Scheduled tasks are often too slow for pull requests and only
+			// registered in ManualRun.
+			if plan := pocket.GetConfigPlan(ctx); plan != nil && plan.Config != nil {
+				for _, r := range plan.Config.ManualRun {
+					manual, err := pocket.CollectTasks(r)
+					if err != nil {
+						return err
+					}
+					tasks = append(tasks, manual...)
+				}
+			}
+		}
+		for _, s := range cfg.Schedules {
+			if _, ok := files[s.Name]; ok {
+				return fmt.Errorf("schedule %s: workflow name is already used", s.Name)
+			}
+			content, err := GenerateScheduleWorkflow(tasks, cfg, s)
+			if err != nil {
+				return err
+			}
+			files[s.Name] = content
+		}
+
+		workflowDir := pocket.FromGitRoot(".github", "workflows")
+		if err := os.MkdirAll(workflowDir, 0o755); err != nil {
+			return fmt.Errorf("create workflows dir: %w", err)
+		}
+		for _, name := range slices.Sorted(maps.Keys(files)) {
+			destPath := filepath.Join(workflowDir, name+".yml")
+			if err := os.WriteFile(destPath, files[name], 0o644); err != nil {
+				return fmt.Errorf("write %s: %w", destPath, err)
+			}
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  Generated %s\n", destPath)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,451 @@
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+func TestGenerateWorkflow_Default(t *testing.T) {
+	tasks := []pocket.TaskInfo{
+		{Name: "go-lint", Usage: "lint"},
+		{Name: "go-test", Usage: "test"},
+	}
+
+	cfg := MatrixConfig{
+		DefaultPlatforms: []string{"ubuntu-latest", "windows-latest"},
+		TaskOverrides: map[string]TaskOverride{
+			"go-lint": {Platforms: []string{"ubuntu-latest"}},
+		},
+	}
+	data, err := GenerateWorkflow(tasks, cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	content := string(data)
+
+	wantContains := []string{
+		"name: pr",
+		"branches: [main, master]",
+		"  contents: read\n  pull-requests: read\n",
+		"types: [opened, edited, synchronize, reopened]",
+		"uses: amannn/action-semantic-pull-request@v6",
+		"fail-fast: true",
+		"go-version-file: .pocket/go.mod",
+		`- task: "go-lint"`,
+		`os: "windows-latest"`,
+		`shim: ".\\pok.ps1"`,
+		"run: ${{ matrix.shim }} git-diff",
+		"shell: ${{ matrix.shell }}",
+		"${{ matrix.shim }} ${{ matrix.task }} -v",
+	}
+	for _, want := range wantContains {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, content)
+		}
+	}
+
+	// go-lint on 1 platform, go-test on 2 platforms.
+	if got := strings.Count(content, "- task: "); got != 3 {
+		t.Errorf("expected 3 matrix entries, got %d", got)
+	}
+	if strings.Contains(content, "shell: pwsh") {
+		t.Errorf("expected steps to use the matrix shell, got:\n%s", content)
+	}
+}
+
+func TestGenerateWorkflow_WindowsBash(t *testing.T) {
+	tasks := []pocket.TaskInfo{{Name: "go-test", Usage: "test"}}
+	cfg := MatrixConfig{DefaultPlatforms: []string{"windows-latest"}, WindowsShell: "bash"}
+
+	data, err := GenerateWorkflow(tasks, cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{`shell: "bash"`, `shim: "./pok"`, "shell: ${{ matrix.shell }}"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestGenerateWorkflow_SkipPRTitle(t *testing.T) {
+	tasks := []pocket.TaskInfo{{Name: "go-test", Usage: "test"}}
+
+	data, err := GenerateWorkflow(tasks, MatrixConfig{SkipPRTitle: true})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	content := string(data)
+	for _, unwanted := range []string{"action-semantic-pull-request", "pull-requests: read", "edited"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("expected workflow not to contain %q, got:\n%s", unwanted, content)
+		}
+	}
+}
+
+func TestGenerateWorkflow_BranchLint(t *testing.T) {
+	tasks := []pocket.TaskInfo{{Name: "go-test", Usage: "test"}}
+
+	data, err := GenerateWorkflow(tasks, MatrixConfig{BranchLint: true})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	content := string(data)
+	want := "    steps:\n      - uses: actions/checkout@v4\n"
+	if !strings.Contains(content, "  title:\n") || !strings.Contains(content, "        run: ./pok branch-lint -v\n") ||
+		!strings.Contains(content, want) {
+		t.Errorf("expected title job to run branch-lint, got:\n%s", content)
+	}
+	if strings.Contains(content, "action-semantic-pull-request") {
+		t.Errorf("expected workflow not to use action-semantic-pull-request, got:\n%s", content)
+	}
+
+	data, err = GenerateWorkflow(tasks, MatrixConfig{BranchLint: true, SkipPRTitle: true})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	if strings.Contains(string(data), "branch-lint") {
+		t.Errorf("expected no branch-lint with SkipPRTitle, got:\n%s", data)
+	}
+}
+
+func TestGenerateWorkflow_Custom(t *testing.T) {
+	tasks := []pocket.TaskInfo{{Name: "test", Usage: "test"}}
+
+	cfg := MatrixConfig{
+		WorkflowName:  "ci",
+		Branches:      []string{"trunk"},
+		Permissions:   map[string]string{"pull-requests": "write", "contents": "read"},
+		GoVersionFile: "go.mod",
+		SkipPRTitle:   true,
+	}
+	data, err := GenerateWorkflow(tasks, cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	content := string(data)
+
+	for _, want := range []string{
+		"name: ci",
+		"branches: [trunk]",
+		"permissions:\n  contents: read\n  pull-requests: write\n",
+		"go-version-file: go.mod",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestGenerateWorkflow_EmptyTasks(t *testing.T) {
+	data, err := GenerateWorkflow(nil, DefaultMatrixConfig())
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	content := string(data)
+
+	if !strings.Contains(content, "include: []") {
+		t.Errorf("expected empty include list, got:\n%s", content)
+	}
+	if !strings.Contains(content, "if: false") {
+		t.Errorf("expected job to be disabled, got:\n%s", content)
+	}
+}
+
+func TestGenerateWorkflow_ToolCache(t *testing.T) {
+	tasks := []pocket.TaskInfo{{Name: "test", Usage: "test"}}
+
+	data, err := GenerateWorkflow(tasks, MatrixConfig{})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	want := "key: pocket-tools-${{ runner.os }}-${{ runner.arch }}-${{ hashFiles('.pocket/go.mod', '.pocket/go.sum') }}"
+	if !strings.Contains(string(data), want) {
+		t.Errorf("expected workflow to contain %q, got:\n%s", want, data)
+	}
+
+	// Pinned tool versions are part of the key.
+	withTool := func(version string) string {
+		t.Helper()
+		tasks := []pocket.TaskInfo{
+			{Name: "test", Usage: "test"},
+			{Name: "install:lint", Hidden: true, Tool: &pocket.ToolInfo{Name: "lint", Version: version}},
+		}
+		data, err := GenerateWorkflow(tasks, MatrixConfig{})
+		if err != nil {
+			t.Fatalf("GenerateWorkflow() failed: %v", err)
+		}
+		for line := range strings.Lines(string(data)) {
+			if strings.Contains(line, "key: pocket-tools-") {
+				return line
+			}
+		}
+		t.Fatalf("no cache key in:\n%s", data)
+		return ""
+	}
+	v1, v2 := withTool("v1.0.0"), withTool("v2.0.0")
+	if v1 == v2 {
+		t.Errorf("expected cache key to change with tool version, got %q for both", v1)
+	}
+	if !strings.Contains(v1, "-"+toolVersionsHash([]pocket.TaskInfo{{Tool: &pocket.ToolInfo{Name: "lint", Version: "v1.0.0"}}})+"-") {
+		t.Errorf("expected cache key to contain tool versions hash, got %q", v1)
+	}
+
+	data, err = GenerateWorkflow(tasks, MatrixConfig{ToolCacheKeyFiles: []string{"tools.lock"}})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	if !strings.Contains(string(data), "hashFiles('tools.lock')") {
+		t.Errorf("expected custom cache key files, got:\n%s", data)
+	}
+
+	data, err = GenerateWorkflow(tasks, MatrixConfig{SkipToolCache: true})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	if strings.Contains(string(data), "Cache pocket tools") {
+		t.Errorf("expected no tool cache step with SkipToolCache, got:\n%s", data)
+	}
+}
+
+func TestGenerateWorkflow_BuildCache(t *testing.T) {
+	tasks := []pocket.TaskInfo{{Name: "test", Usage: "test"}}
+
+	data, err := GenerateWorkflow(tasks, MatrixConfig{})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"cache: false",
+		`echo "go-build=$(go env GOCACHE)" >> "$GITHUB_OUTPUT"`,
+		"${{ steps.build-cache.outputs.go-mod }}",
+		"key: go-${{ runner.os }}-${{ runner.arch }}-${{ matrix.task }}-${{ matrix.path }}-${{ hashFiles('**/go.sum') }}",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, content)
+		}
+	}
+	for _, notWant := range []string{"cache-dependency-path", "UV_CACHE_DIR", "BUN_INSTALL_CACHE_DIR"} {
+		if strings.Contains(content, notWant) {
+			t.Errorf("expected workflow not to contain %q, got:\n%s", notWant, content)
+		}
+	}
+
+	// uv and bun caches are added for the tasks that install them.
+	tasks = append(tasks,
+		pocket.TaskInfo{Name: "install:uv", Hidden: true, Tool: &pocket.ToolInfo{Name: "uv", Version: "0.9.0"}},
+		pocket.TaskInfo{Name: "install:bun", Hidden: true, Tool: &pocket.ToolInfo{Name: "bun", Version: "1.3.0"}},
+	)
+	data, err = GenerateWorkflow(tasks, MatrixConfig{})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	for _, want := range []string{
+		`echo "UV_CACHE_DIR=$RUNNER_TEMP/uv-cache" >> "$GITHUB_ENV"`,
+		"key: uv-${{ runner.os }}-${{ runner.arch }}-${{ hashFiles('**/uv.lock') }}",
+		`echo "BUN_INSTALL_CACHE_DIR=$RUNNER_TEMP/bun-cache" >> "$GITHUB_ENV"`,
+		"key: bun-${{ runner.os }}-${{ runner.arch }}-${{ hashFiles('**/bun.lock', '**/bun.lockb') }}",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, data)
+		}
+	}
+
+	data, err = GenerateWorkflow(tasks, MatrixConfig{SkipBuildCache: true})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	if !strings.Contains(string(data), `cache-dependency-path: "**/go.sum"`) || strings.Contains(string(data), "build-cache") {
+		t.Errorf("expected actions/setup-go caching with SkipBuildCache, got:\n%s", data)
+	}
+}
+
+func TestGenerateWorkflow_UploadSARIF(t *testing.T) {
+	tasks := []pocket.TaskInfo{{Name: "go-lint", Usage: "lint"}}
+
+	data, err := GenerateWorkflow(tasks, MatrixConfig{UploadSARIF: true})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"security-events: write",
+		"POK_SARIF_DIR: .pocket/sarif",
+		"${{ matrix.shim }} sarif-report",
+		"uses: github/codeql-action/upload-sarif@v3",
+		"sarif_file: .pocket/pocket.sarif",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, content)
+		}
+	}
+
+	data, err = GenerateWorkflow(tasks, MatrixConfig{})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	if strings.Contains(string(data), "upload-sarif") {
+		t.Errorf("expected no SARIF upload by default, got:\n%s", data)
+	}
+}
+
+func TestGenerateWorkflow_PRComment(t *testing.T) {
+	tasks := []pocket.TaskInfo{{Name: "go-test", Usage: "test"}}
+
+	data, err := GenerateWorkflow(tasks, MatrixConfig{PRComment: true})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"  contents: read\n  pull-requests: write\n",
+		"        env:\n          GITHUB_TOKEN: ${{ github.token }}\n        run: ${{ matrix.shim }} ${{ matrix.task }} -v",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestGenerateWorkflow_JobSettings(t *testing.T) {
+	tasks := []pocket.TaskInfo{
+		{Name: "go-test", Usage: "test"},
+		{Name: "deploy", Usage: "deploy"},
+	}
+	cfg := MatrixConfig{
+		TimeoutMinutes: 30,
+		TaskOverrides: map[string]TaskOverride{
+			"deploy": {
+				RunsOn:         []string{"self-hosted", "linux"},
+				Container:      "golang:1.24",
+				Concurrency:    "deploy-{task}-{os}",
+				TimeoutMinutes: 10,
+			},
+		},
+	}
+
+	data, err := GenerateWorkflow(tasks, cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"runs-on: ${{ matrix.runsOn || matrix.os }}",
+		"container: ${{ matrix.container }}",
+		"timeout-minutes: ${{ matrix.timeout || 360 }}",
+		`runsOn: ["self-hosted","linux"]`,
+		`container: "golang:1.24"`,
+		`concurrency: "deploy-deploy-ubuntu-latest"`,
+		"timeout: 10",
+		"timeout: 30",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, content)
+		}
+	}
+
+	data, err = GenerateWorkflow(tasks, MatrixConfig{})
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	for _, unwanted := range []string{"container:", "timeout-minutes:", "matrix.runsOn", "matrix.concurrency"} {
+		if strings.Contains(string(data), unwanted) {
+			t.Errorf("expected workflow not to contain %q by default", unwanted)
+		}
+	}
+}
+
+func TestGenerateWorkflow_ContainerOnlyOnLinux(t *testing.T) {
+	tasks := []pocket.TaskInfo{{Name: "go-test", Usage: "test"}}
+	cfg := MatrixConfig{
+		DefaultPlatforms: []string{"ubuntu-latest", "macos-latest", "windows-latest"},
+		Container:        "golang:1.24",
+	}
+
+	entries := matrixEntries(tasks, cfg)
+	for _, e := range entries {
+		want := ""
+		if e.OS == "ubuntu-latest" {
+			want = "golang:1.24"
+		}
+		if e.Container != want {
+			t.Errorf("%s: container = %q, want %q", e.OS, e.Container, want)
+		}
+	}
+
+	data, err := GenerateWorkflow(tasks, cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkflow() failed: %v", err)
+	}
+	if n := strings.Count(string(data), `container: "golang:1.24"`); n != 1 {
+		t.Errorf("expected container on 1 entry, got %d:\n%s", n, data)
+	}
+}
+
+func TestGenerateScheduleWorkflow(t *testing.T) {
+	tasks := []pocket.TaskInfo{
+		{Name: "go-test", Usage: "test"},
+		{Name: "go-fuzz", Usage: "fuzz"},
+		{Name: "image-scan", Usage: "scan"},
+		{Name: "install:trivy", Hidden: true, Tool: &pocket.ToolInfo{Name: "trivy", Version: "v0.50.0"}},
+	}
+	cfg := MatrixConfig{
+		PRComment:    true,
+		ExcludeTasks: []string{"go-fuzz"},
+	}
+	s := Schedule{Name: "nightly", Cron: "0 3 * * *", Tasks: []string{"image-scan", "go-fuzz"}}
+	data, err := GenerateScheduleWorkflow(tasks, cfg, s)
+	if err != nil {
+		t.Fatalf("GenerateScheduleWorkflow() failed: %v", err)
+	}
+	content := string(data)
+
+	for _, want := range []string{
+		"name: nightly",
+		"  schedule:\n    - cron: \"0 3 * * *\"\n  workflow_dispatch:\n",
+		`- task: "image-scan"`,
+		`- task: "go-fuzz"`,
+		"pocket-tools-${{ runner.os }}-${{ runner.arch }}-" + toolVersionsHash(tasks),
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected workflow to contain %q, got:\n%s", want, content)
+		}
+	}
+	for _, unwanted := range []string{"pull_request", "push:", "go-test", "action-semantic-pull-request", "GITHUB_TOKEN"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("expected workflow not to contain %q, got:\n%s", unwanted, content)
+		}
+	}
+	if strings.Index(content, "image-scan") > strings.Index(content, "go-fuzz") {
+		t.Errorf("expected tasks in schedule order, got:\n%s", content)
+	}
+}
+
+func TestGenerateScheduleWorkflow_Errors(t *testing.T) {
+	tasks := []pocket.TaskInfo{{Name: "go-test"}, {Name: "install:tool", Hidden: true}}
+	tests := []struct {
+		name     string
+		schedule Schedule
+		want     string
+	}{
+		{name: "no name", schedule: Schedule{Cron: "0 3 * * *", Tasks: []string{"go-test"}}, want: "name is required"},
+		{name: "no cron", schedule: Schedule{Name: "nightly", Tasks: []string{"go-test"}}, want: "cron is required"},
+		{name: "no tasks", schedule: Schedule{Name: "nightly", Cron: "0 3 * * *"}, want: "no tasks"},
+		{name: "unknown task", schedule: Schedule{Name: "nightly", Cron: "0 3 * * *", Tasks: []string{"go-fuzz"}}, want: `unknown task "go-fuzz"`},
+		{name: "hidden task", schedule: Schedule{Name: "nightly", Cron: "0 3 * * *", Tasks: []string{"install:tool"}}, want: "unknown task"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GenerateScheduleWorkflow(tasks, MatrixConfig{}, tt.schedule)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("GenerateScheduleWorkflow() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package github provides GitHub-related tasks.
 package github
 
@@ -20,19 +22,18 @@ var workflowTemplates embed.FS
 // WorkflowsOptions configures which workflows to bootstrap.
 type WorkflowsOptions struct {
 	SkipPocket  bool `arg:"skip-pocket"  usage:"exclude pocket workflow"`
-	SkipPR      bool `arg:"skip-pr"      usage:"exclude PR workflow"`
 	SkipRelease bool `arg:"skip-release" usage:"exclude release-please workflow"`
 	SkipStale   bool `arg:"skip-stale"   usage:"exclude stale workflow"`
 	SkipSync    bool `arg:"skip-sync"    usage:"exclude sync workflow"`
 
-	// IncludePocketMatrix enables the pocket-matrix workflow (disabled by default).
-	// The matrix workflow is more complex and intended for projects that need
-	// fine-grained control over which tasks run on which platforms.
-	IncludePocketMatrix bool `arg:"include-pocket-matrix" usage:"include pocket-matrix workflow (excluded by default)"`
-
 	// Platforms overrides the default platforms for pocket.yml.
 	// Comma-separated list, e.g. "ubuntu-latest" or "ubuntu-latest,macos-latest".
 	Platforms string `arg:"platforms" usage:"platforms for pocket.yml (comma-separated)"`
+
+	// Provenance makes release.yml attest the release artifacts matching these
+	// globs with actions/attest-build-provenance and upload them to the release.
+	Provenance   string `arg:"provenance"    usage:"release artifact globs to attest in release.yml (comma-separated)"`
+	ReleaseBuild string `arg:"release-build" usage:"task that builds the release artifacts (default: all)"`
 }
 
 // PocketConfig holds configuration for the pocket workflow template.
@@ -47,6 +48,12 @@ func DefaultPocketConfig() PocketConfig {
 	}
 }
 
+// ReleaseConfig holds configuration for the release workflow template.
+type ReleaseConfig struct {
+	Artifacts string // comma-separated artifact globs to attest; empty disables attestation
+	Build     string // task that builds the artifacts
+}
+
 // StaleConfig holds configuration for the stale workflow template.
 type StaleConfig struct {
 	DaysBeforeStale int
@@ -65,6 +72,7 @@ func DefaultStaleConfig() StaleConfig {
 
 // Workflows bootstraps GitHub workflow files into .github/workflows/.
 // By default, all workflows are copied. Use flags to select specific ones.
+// The pull request workflow is generated from the task matrix by WorkflowTask.
 var Workflows = pocket.Task("github-workflows", "bootstrap GitHub workflow files",
 	workflowsCmd(),
 	pocket.Opts(WorkflowsOptions{}),
@@ -102,16 +110,15 @@ func runWorkflows(ctx context.Context) error {
 	if opts.Platforms != "" {
 		pocketConfig.Platforms = opts.Platforms
 	}
+	releaseConfig := ReleaseConfig{Artifacts: opts.Provenance, Build: opts.ReleaseBuild}
+	if releaseConfig.Build == "" {
+		releaseConfig.Build = "all"
+	}
 	staleConfig := DefaultStaleConfig()
 
-	// Include pocket-matrix only if explicitly requested via IncludePocketMatrix.
-	includePocketMatrix := opts.IncludePocketMatrix
-
 	workflowDefs := []workflowDef{
 		{"pocket.yml.tmpl", "pocket.yml", pocketConfig, !opts.SkipPocket},
-		{"pocket-matrix.yml.tmpl", "pocket-matrix.yml", nil, includePocketMatrix},
-		{"pr.yml.tmpl", "pr.yml", nil, !opts.SkipPR},
-		{"release.yml.tmpl", "release.yml", nil, !opts.SkipRelease},
+		{"release.yml.tmpl", "release.yml", releaseConfig, !opts.SkipRelease},
 		{"stale.yml.tmpl", "stale.yml", staleConfig, !opts.SkipStale},
 		{"sync.yml.tmpl", "sync.yml", nil, !opts.SkipSync},
 	}
@@ -124,29 +131,9 @@ func runWorkflows(ctx context.Context) error {
 
 		destPath := filepath.Join(workflowDir, wf.outFile)
 
-		// Read and parse template
-		// NOTE: Use path.Join (not filepath.Join) because embed.FS always uses forward slashes.
-		tmplContent, err := workflowTemplates.ReadFile(path.Join("workflows", wf.tmplFile))
+		content, err := renderWorkflowTemplate(wf.tmplFile, wf.data)
 		if err != nil {
-			return fmt.Errorf("read template %s: %w", wf.tmplFile, err)
-		}
-
-		var content []byte
-		if wf.data != nil {
-			// Render template with data
-			tmpl, err := template.New(wf.tmplFile).Parse(string(tmplContent))
-			if err != nil {
-				return fmt.Errorf("parse template %s: %w", wf.tmplFile, err)
-			}
-
-			var buf bytes.Buffer
-			if err := tmpl.Execute(&buf, wf.data); err != nil {
-				return fmt.Errorf("execute template %s: %w", wf.tmplFile, err)
-			}
-			content = buf.Bytes()
-		} else {
-			// No templating needed, use as-is
-			content = tmplContent
+			return err
 		}
 
 		if err := os.WriteFile(destPath, content, 0o644); err != nil {
@@ -163,3 +150,26 @@ func runWorkflows(ctx context.Context) error {
 
 	return nil
 }
+
+// renderWorkflowTemplate renders the embedded workflow template with data. Templates
+// without data are returned as-is.
+func renderWorkflowTemplate(tmplFile string, data any) ([]byte, error) {
+	// NOTE: Use path.Join (not filepath.Join) because embed.FS always uses forward slashes.
+	tmplContent, err := workflowTemplates.ReadFile(path.Join("workflows", tmplFile))
+	if err != nil {
+		return nil, fmt.Errorf("read template %s: %w", tmplFile, err)
+	}
+	if data == nil {
+		return tmplContent, nil
+	}
+
+	tmpl, err := template.New(tmplFile).Parse(string(tmplContent))
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", tmplFile, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("execute template %s: %w", tmplFile, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -1,7 +1,10 @@
+// SPDX-License-Identifier: MIT
+
 package github
 
 import (
 	"path"
+	"strings"
 	"testing"
 )
 
@@ -12,8 +15,7 @@ import (
 func TestWorkflowTemplates_EmbedReadFile(t *testing.T) {
 	templates := []string{
 		"pocket.yml.tmpl",
-		"pocket-matrix.yml.tmpl",
-		"pr.yml.tmpl",
+		"pocket-workflow.yml.tmpl",
 		"release.yml.tmpl",
 		"stale.yml.tmpl",
 		"sync.yml.tmpl",
@@ -54,3 +56,33 @@ func TestDefaultStaleConfig(t *testing.T) {
 		t.Error("expected non-empty ExemptLabels")
 	}
 }
+
+func TestReleaseWorkflow_Provenance(t *testing.T) {
+	plain, err := renderWorkflowTemplate("release.yml.tmpl", ReleaseConfig{Build: "all"})
+	if err != nil {
+		t.Fatalf("renderWorkflowTemplate() failed: %v", err)
+	}
+	if strings.Contains(string(plain), "attest-build-provenance") {
+		t.Error("expected no attestation job without artifacts")
+	}
+	if !strings.Contains(string(plain), "token: ${{ github.token }}") {
+		t.Errorf("expected GitHub expressions to be kept, got:\n%s", plain)
+	}
+
+	attested, err := renderWorkflowTemplate("release.yml.tmpl", ReleaseConfig{Artifacts: "dist/*.tar.gz", Build: "build"})
+	if err != nil {
+		t.Fatalf("renderWorkflowTemplate() failed: %v", err)
+	}
+	for _, want := range []string{
+		"id-token: write",
+		"attestations: write",
+		"run: ./pok build",
+		`./pok provenance-subjects -artifacts "dist/*.tar.gz"`,
+		"uses: actions/attest-build-provenance@v2",
+		"subject-checksums: ${{ steps.provenance.outputs.subject-checksums }}",
+	} {
+		if !strings.Contains(string(attested), want) {
+			t.Errorf("expected %q in release workflow, got:\n%s", want, attested)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tasks/github"
+)
+
+// BenchDir is where go-bench stores results, relative to the git root.
+// Results are keyed by commit: <BenchDir>/<path>/<commit>.txt.
+const BenchDir = ".pocket/bench"
+
+// BenchOptions configures the go-bench task.
+type BenchOptions struct {
+	Bench string `arg:"bench" usage:"benchmarks to run (default: .)"`
+	Count int    `arg:"count" usage:"number of runs per benchmark (default: 6)"`
+}
+
+// Bench runs the benchmarks of the module in the path and stores the
+// output for the current commit in BenchDir, for go-bench-compare.
+var Bench = pocket.Task("go-bench", "run Go benchmarks and store results",
+	benchCmd(),
+	pocket.Opts(BenchOptions{}),
+)
+
+func benchCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[BenchOptions](ctx)
+		bench, count := opts.Bench, opts.Count
+		if bench == "" {
+			bench = "."
+		}
+		if count <= 0 {
+			count = 6
+		}
+		commit, err := gitOutput(ctx, "rev-parse", "HEAD")
+		if err != nil {
+			return err
+		}
+
+		var out bytes.Buffer
+		cmd := pocket.Command(ctx, "go", "test", "-run=^$", "-bench="+bench, "-benchmem",
+			"-count="+strconv.Itoa(count), "./...")
+		cmd.Dir = pocket.FromGitRoot(pocket.Path(ctx))
+		cmd.Stdout = io.MultiWriter(pocket.GetOutput(ctx).Stdout, &out)
+		if err := pocket.RunCommand(ctx, cmd); err != nil {
+			return err
+		}
+
+		dest := benchFile(pocket.Path(ctx), commit)
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return fmt.Errorf("create bench dir: %w", err)
+		}
+		if err := os.WriteFile(dest, out.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", dest, err)
+		}
+		if pocket.Verbose(ctx) {
+			pocket.Printf(ctx, "  Stored %s\n", dest)
+		}
+		return nil
+	})
+}
+
+// benchFile returns the results file for the module in dir at commit.
+func benchFile(dir, commit string) string {
+	return pocket.FromGitRoot(filepath.FromSlash(BenchDir), filepath.FromSlash(dir), commit+".txt")
+}
+
+// BenchCompareOptions configures the go-bench-compare task.
+type BenchCompareOptions struct {
+	Base      string `arg:"base"      usage:"git ref to compare against (default: origin's default branch)"`
+	Threshold int    `arg:"threshold" usage:"slowdown in percent that counts as a regression (default: 10)"`
+}
+
+// BenchCompare compares the go-bench results of the current commit with
+// the latest stored results on the base branch, at or before the merge
+// base, and fails on regressions in time per operation. In a GitHub Actions
+// pull request run with GITHUB_TOKEN, the comparison is posted as a pull
+// request comment, which later runs update.
+//
+// The comparison is skipped with a note when there are no base results,
+// e.g. before the first nightly run of the benchmark workflow (see
+// github.BenchWorkflowTask).
+var BenchCompare = pocket.Task("go-bench-compare", "compare Go benchmarks against the base branch",
+	benchCompareCmd(),
+	pocket.Opts(BenchCompareOptions{}),
+)
+
+func benchCompareCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[BenchCompareOptions](ctx)
+		threshold := opts.Threshold
+		if threshold <= 0 {
+			threshold = 10
+		}
+		dir := pocket.Path(ctx)
+
+		head, err := gitOutput(ctx, "rev-parse", "HEAD")
+		if err != nil {
+			return err
+		}
+		newData, err := os.ReadFile(benchFile(dir, head))
+		if err != nil {
+			return fmt.Errorf("go-bench-compare: no results for HEAD, run go-bench first: %w", err)
+		}
+
+		base := opts.Base
+		if base == "" {
+			ref, err := gitOutput(ctx, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+			if err != nil {
+				pocket.Println(ctx, "Note: go-bench-compare skipped, origin's default branch is unknown (set -base)")
+				return nil
+			}
+			base = ref
+		}
+		mergeBase, err := gitOutput(ctx, "merge-base", "HEAD", base)
+		if err != nil {
+			pocket.Printf(ctx, "Note: go-bench-compare skipped, %s is not available (fetch it first)\n", base)
+			return nil
+		}
+		revs, err := gitOutput(ctx, "rev-list", "--first-parent", "--max-count=100", mergeBase)
+		if err != nil {
+			return err
+		}
+		var oldData []byte
+		var oldRev string
+		for rev := range strings.FieldsSeq(revs) {
+			if rev == head {
+				continue
+			}
+			if data, err := os.ReadFile(benchFile(dir, rev)); err == nil {
+				oldData, oldRev = data, rev
+				break
+			}
+		}
+		if oldData == nil {
+			pocket.Printf(ctx, "Note: go-bench-compare skipped, no stored results on %s\n", base)
+			return nil
+		}
+
+		deltas := compareBench(parseBench(oldData), parseBench(newData), threshold)
+		regressions := 0
+		for _, d := range deltas {
+			if d.Regression {
+				regressions++
+			}
+			if d.Regression || pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  %s: %s -> %s (%+.1f%%)\n", d.Name, formatNs(d.Old), formatNs(d.New), d.Percent)
+			}
+		}
+		posted, err := github.UpsertPRComment(ctx, "go-bench:"+dir, benchMarkdown(dir, oldRev, head, deltas, threshold))
+		if err != nil {
+			pocket.Printf(ctx, "Warning: could not post benchmark comment: %v\n", err)
+		} else if posted && pocket.Verbose(ctx) {
+			pocket.Println(ctx, "  Posted benchmark comparison to the pull request")
+		}
+		if regressions > 0 {
+			return fmt.Errorf("go-bench-compare: %d benchmark(s) more than %d%% slower than %s", regressions, threshold, oldRev[:12])
+		}
+		return nil
+	})
+}
+
+// benchDelta is the change of one benchmark's time per operation.
+type benchDelta struct {
+	Name       string
+	Old, New   float64 // ns/op
+	Percent    float64
+	Regression bool
+}
+
+// parseBench returns the mean ns/op per benchmark in "go test -bench"
+// output, keyed by "pkg.BenchmarkName".
+func parseBench(data []byte) map[string]float64 {
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	var pkg string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(p)
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			if fields[i+1] != "ns/op" {
+				continue
+			}
+			if v, err := strconv.ParseFloat(fields[i], 64); err == nil {
+				name := fields[0]
+				if pkg != "" {
+					name = pkg + "." + name
+				}
+				sums[name] += v
+				counts[name]++
+			}
+		}
+	}
+	means := make(map[string]float64, len(sums))
+	for name, sum := range sums {
+		means[name] = sum / float64(counts[name])
+	}
+	return means
+}
+
+// compareBench returns the deltas of the benchmarks in both oldRes and
+// newRes, sorted by name. Slowdowns above threshold percent are regressions.
+func compareBench(oldRes, newRes map[string]float64, threshold int) []benchDelta {
+	var deltas []benchDelta
+	for name, newNs := range newRes {
+		oldNs, ok := oldRes[name]
+		if !ok || oldNs == 0 {
+			continue
+		}
+		percent := (newNs - oldNs) / oldNs * 100
+		deltas = append(deltas, benchDelta{
+			Name:       name,
+			Old:        oldNs,
+			New:        newNs,
+			Percent:    percent,
+			Regression: percent > float64(threshold),
+		})
+	}
+	slices.SortFunc(deltas, func(a, b benchDelta) int { return strings.Compare(a.Name, b.Name) })
+	return deltas
+}
+
+// benchMarkdown formats deltas as a pull request comment.
+func benchMarkdown(dir, oldRev, newRev string, deltas []benchDelta, threshold int) string {
+	var b strings.Builder
+	regressions := 0
+	for _, d := range deltas {
+		if d.Regression {
+			regressions++
+		}
+	}
+	title := "Benchmarks"
+	if dir != "." {
+		title += " (" + dir + ")"
+	}
+	fmt.Fprintf(&b, "### %s\n\n", title)
+	if regressions > 0 {
+		fmt.Fprintf(&b, ":warning: %d benchmark(s) more than %d%% slower", regressions, threshold)
+	} else {
+		fmt.Fprintf(&b, "No regressions above %d%%", threshold)
+	}
+	fmt.Fprintf(&b, " compared to %s (head %s).\n\n", oldRev[:min(12, len(oldRev))], newRev[:min(12, len(newRev))])
+	if len(deltas) == 0 {
+		return b.String()
+	}
+	b.WriteString("| Benchmark | Base | Head | Delta |\n|---|---:|---:|---:|\n")
+	for _, d := range deltas {
+		mark := ""
+		if d.Regression {
+			mark = " :warning:"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %+.1f%%%s |\n", d.Name, formatNs(d.Old), formatNs(d.New), d.Percent, mark)
+	}
+	return b.String()
+}
+
+// formatNs formats a duration in nanoseconds with a readable unit.
+func formatNs(ns float64) string {
+	switch {
+	case ns >= 1e9:
+		return fmt.Sprintf("%.2fs", ns/1e9)
+	case ns >= 1e6:
+		return fmt.Sprintf("%.2fms", ns/1e6)
+	case ns >= 1e3:
+		return fmt.Sprintf("%.2fµs", ns/1e3)
+	default:
+		return fmt.Sprintf("%.2fns", ns)
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"strings"
+	"testing"
+)
+
+const benchOld = `goos: linux
+goarch: amd64
+pkg: example.com/app/parse
+cpu: AMD EPYC
+BenchmarkParse-8     	  100000	      1000 ns/op	     128 B/op	       2 allocs/op
+BenchmarkParse-8     	  100000	      1200 ns/op	     128 B/op	       2 allocs/op
+BenchmarkFormat-8    	  500000	       300 ns/op
+BenchmarkRemoved-8   	  500000	       300 ns/op
+PASS
+ok  	example.com/app/parse	3.2s
+`
+
+const benchNew = `pkg: example.com/app/parse
+BenchmarkParse-8     	  100000	      1400 ns/op	     256 B/op	       4 allocs/op
+BenchmarkParse-8     	  100000	      1240 ns/op	     256 B/op	       4 allocs/op
+BenchmarkFormat-8    	  500000	       310 ns/op
+BenchmarkAdded-8     	  500000	       300 ns/op
+`
+
+func TestParseBench(t *testing.T) {
+	got := parseBench([]byte(benchOld))
+	want := map[string]float64{
+		"example.com/app/parse.BenchmarkParse-8":   1100,
+		"example.com/app/parse.BenchmarkFormat-8":  300,
+		"example.com/app/parse.BenchmarkRemoved-8": 300,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseBench() = %v, want %v", got, want)
+	}
+	for name, ns := range want {
+		if got[name] != ns {
+			t.Errorf("parseBench()[%s] = %v, want %v", name, got[name], ns)
+		}
+	}
+}
+
+func TestCompareBench(t *testing.T) {
+	deltas := compareBench(parseBench([]byte(benchOld)), parseBench([]byte(benchNew)), 10)
+	if len(deltas) != 2 {
+		t.Fatalf("compareBench() = %+v, want 2 deltas", deltas)
+	}
+	format, parse := deltas[0], deltas[1]
+	if format.Name != "example.com/app/parse.BenchmarkFormat-8" || format.Regression {
+		t.Errorf("format delta = %+v, want no regression", format)
+	}
+	if parse.Percent != 20 || !parse.Regression {
+		t.Errorf("parse delta = %+v, want +20%% regression", parse)
+	}
+
+	md := benchMarkdown("services/api", "aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb", deltas, 10)
+	for _, want := range []string{
+		"### Benchmarks (services/api)",
+		":warning: 1 benchmark(s) more than 10% slower compared to aaaaaaaaaaaa (head bbbbbbbbbbbb).",
+		"| `example.com/app/parse.BenchmarkParse-8` | 1.10µs | 1.32µs | +20.0% :warning: |",
+		"| `example.com/app/parse.BenchmarkFormat-8` | 300.00ns | 310.00ns | +3.3% |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("benchMarkdown() missing %q:\n%s", want, md)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// DepsPolicy checks the module graph of the Go module in the path against
+// pocket.Config.GoDeps: denied modules, major version limits and replace
+// directives. Violations are reported per module with the modules that
+// require it.
+//
+// Example usage in .pocket/config.go:
+//
+//	var Config = pocket.Config{
+//	    AutoRun: pocket.RunIn(golang.DepsPolicy, pocket.Detect(golang.Detect())),
+//	    GoDeps: pocket.GoDepsPolicy{
+//	        Deny: []string{"github.com/pkg/errors"},
+//	    },
+//	}
+var DepsPolicy = pocket.Task("go-deps-policy", "check Go module graph against dependency policy",
+	depsPolicyCmd(),
+)
+
+func depsPolicyCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		var policy pocket.GoDepsPolicy
+		if plan := pocket.GetConfigPlan(ctx); plan != nil && plan.Config != nil {
+			policy = plan.Config.GoDeps
+		}
+		graph, err := goOutput(ctx, "mod", "graph")
+		if err != nil {
+			return err
+		}
+		var replaces []modReplace
+		if policy.DenyReplace {
+			data, err := goOutput(ctx, "mod", "edit", "-json")
+			if err != nil {
+				return err
+			}
+			var mod struct{ Replace []modReplace }
+			if err := json.Unmarshal([]byte(data), &mod); err != nil {
+				return fmt.Errorf("parse go.mod: %w", err)
+			}
+			replaces = mod.Replace
+		}
+
+		main, violations := checkDepsPolicy(policy, graph, replaces)
+		if len(violations) == 0 {
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  %s: no violations\n", main)
+			}
+			return nil
+		}
+		pocket.Printf(ctx, "%s:\n", main)
+		for _, v := range violations {
+			pocket.Printf(ctx, "  %s\n", v)
+		}
+		return fmt.Errorf("go-deps-policy: %d violation(s) in %s", len(violations), main)
+	})
+}
+
+// modReplace is a replace directive as printed by "go mod edit -json".
+type modReplace struct {
+	Old, New struct{ Path, Version string }
+}
+
+// checkDepsPolicy checks the "go mod graph" output graph and the replace
+// directives of its main module against policy. It returns the main module
+// path and the violations, sorted by module.
+func checkDepsPolicy(policy pocket.GoDepsPolicy, graph string, replaces []modReplace) (string, []string) {
+	var main string
+	requiredBy := map[string][]string{}
+	for line := range strings.Lines(graph) {
+		from, to, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		if main == "" && !strings.Contains(from, "@") {
+			main = from
+		}
+		if !slices.Contains(requiredBy[to], from) {
+			requiredBy[to] = append(requiredBy[to], from)
+		}
+	}
+
+	var violations []string
+	for mod, from := range requiredBy {
+		modPath, version, _ := strings.Cut(mod, "@")
+		if matchAny(policy.Allow, modPath) {
+			continue
+		}
+		var reasons []string
+		if matchAny(policy.Deny, modPath) {
+			reasons = append(reasons, "denied module")
+		}
+		for pattern, limit := range policy.MaxMajor {
+			if major, ok := majorVersion(version); ok && major > limit && matchModule(pattern, modPath) {
+				reasons = append(reasons, fmt.Sprintf("major version above v%d", limit))
+				break
+			}
+		}
+		if len(reasons) > 0 {
+			slices.Sort(from)
+			violations = append(violations, fmt.Sprintf("%s: %s (required by %s)",
+				mod, strings.Join(reasons, ", "), strings.Join(from, ", ")))
+		}
+	}
+	for _, r := range replaces {
+		if !policy.DenyReplace || matchAny(policy.Allow, r.Old.Path) {
+			continue
+		}
+		old, repl := r.Old.Path, r.New.Path
+		if r.Old.Version != "" {
+			old += "@" + r.Old.Version
+		}
+		if r.New.Version != "" {
+			repl += "@" + r.New.Version
+		}
+		violations = append(violations, fmt.Sprintf("%s: replace directive (replaced by %s)", old, repl))
+	}
+	slices.Sort(violations)
+	return main, violations
+}
+
+// matchAny reports whether modPath matches any of patterns.
+func matchAny(patterns []string, modPath string) bool {
+	return slices.ContainsFunc(patterns, func(p string) bool { return matchModule(p, modPath) })
+}
+
+// matchModule reports whether modPath matches pattern: a module path, a
+// path.Match glob, or a path ending in "/..." that matches the path and
+// everything below it.
+func matchModule(pattern, modPath string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return modPath == prefix || strings.HasPrefix(modPath, prefix+"/")
+	}
+	ok, _ := path.Match(pattern, modPath)
+	return ok
+}
+
+// majorVersion returns the major version of a module version such as
+// "v2.1.0" or "v3.0.0+incompatible".
+func majorVersion(version string) (int, bool) {
+	v, ok := strings.CutPrefix(version, "v")
+	if !ok {
+		return 0, false
+	}
+	v, _, _ = strings.Cut(v, ".")
+	n, err := strconv.Atoi(v)
+	return n, err == nil
+}
+
+// goOutput runs a go command in the path and returns its stdout.
+func goOutput(ctx context.Context, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := pocket.Command(ctx, "go", args...)
+	cmd.Dir = pocket.FromGitRoot(pocket.Path(ctx))
+	cmd.Stdout = &out
+	if err := pocket.RunCommand(ctx, cmd); err != nil {
+		return "", fmt.Errorf("go %s: %w", strings.Join(args, " "), err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+const testGraph = `example.com/app github.com/pkg/errors@v0.9.1
+example.com/app github.com/jackc/pgx/v5@v5.7.1
+example.com/app github.com/golang/protobuf@v1.5.4
+example.com/app golang.org/x/sync@v0.10.0
+github.com/golang/protobuf@v1.5.4 google.golang.org/protobuf@v1.33.0
+github.com/jackc/pgx/v5@v5.7.1 github.com/pkg/errors@v0.9.1
+github.com/jackc/pgx/v5@v5.7.1 github.com/docker/docker@v26.1.0+incompatible
+`
+
+func TestCheckDepsPolicy(t *testing.T) {
+	var replace modReplace
+	replace.Old.Path = "golang.org/x/sync"
+	replace.New.Path = "../sync"
+
+	tests := []struct {
+		name     string
+		policy   pocket.GoDepsPolicy
+		replaces []modReplace
+		want     []string
+	}{
+		{
+			name: "no rules",
+		},
+		{
+			name: "deny",
+			policy: pocket.GoDepsPolicy{
+				Deny:  []string{"github.com/pkg/errors", "github.com/golang/...", "google.golang.org/*"},
+				Allow: []string{"google.golang.org/protobuf"},
+			},
+			want: []string{
+				"github.com/golang/protobuf@v1.5.4: denied module (required by example.com/app)",
+				"github.com/pkg/errors@v0.9.1: denied module (required by example.com/app, github.com/jackc/pgx/v5@v5.7.1)",
+			},
+		},
+		{
+			name: "max major",
+			policy: pocket.GoDepsPolicy{
+				MaxMajor: map[string]int{"github.com/jackc/pgx/...": 4, "github.com/docker/docker": 25},
+			},
+			want: []string{
+				"github.com/docker/docker@v26.1.0+incompatible: major version above v25 (required by github.com/jackc/pgx/v5@v5.7.1)",
+				"github.com/jackc/pgx/v5@v5.7.1: major version above v4 (required by example.com/app)",
+			},
+		},
+		{
+			name:     "deny replace",
+			policy:   pocket.GoDepsPolicy{DenyReplace: true},
+			replaces: []modReplace{replace},
+			want: []string{
+				"golang.org/x/sync: replace directive (replaced by ../sync)",
+			},
+		},
+		{
+			name:     "allowed replace",
+			policy:   pocket.GoDepsPolicy{DenyReplace: true, Allow: []string{"golang.org/x/..."}},
+			replaces: []modReplace{replace},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			main, got := checkDepsPolicy(tt.policy, testGraph, tt.replaces)
+			if main != "example.com/app" {
+				t.Errorf("main module = %q, want example.com/app", main)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("checkDepsPolicy() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
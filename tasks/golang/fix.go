@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package golang
 
 import (
@@ -7,7 +9,9 @@ import (
 )
 
 // Fix runs go fix to update code for newer Go versions.
-var Fix = pocket.Task("go-fix", "update code for newer Go versions", fixCmd())
+var Fix = pocket.Task("go-fix", "update code for newer Go versions", fixCmd(),
+	pocket.WithInputs(Inputs...),
+)
 
 func fixCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
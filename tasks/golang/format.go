@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package golang
 
 import (
@@ -9,13 +11,16 @@ import (
 
 // FormatOptions configures the go-format task.
 type FormatOptions struct {
-	Config string `arg:"config" usage:"path to golangci-lint config file"`
+	Config     string `arg:"config"      usage:"path to golangci-lint config file"`
+	RootConfig bool   `arg:"root-config" usage:"use the repo-root golangci-lint config, ignoring module configs"`
 }
 
-// Format formats Go code using golangci-lint fmt.
+// Format formats Go code using golangci-lint fmt, with the golangci-lint
+// config of the module (see golangcilint.ConfigPath).
 var Format = pocket.Task("go-format", "format Go code",
 	pocket.Serial(golangcilint.Install, formatCmd()),
 	pocket.Opts(FormatOptions{}),
+	pocket.WithInputs(Inputs...),
 )
 
 func formatCmd() pocket.Runnable {
@@ -23,12 +28,16 @@ func formatCmd() pocket.Runnable {
 		opts := pocket.Options[FormatOptions](ctx)
 
 		args := []string{"fmt"}
-		if opts.Config != "" {
-			args = append(args, "-c", opts.Config)
-		} else if configPath, err := pocket.ConfigPath(ctx, "golangci-lint", golangcilint.Config); err == nil {
-			if configPath != "" {
-				args = append(args, "-c", configPath)
-			}
+		if !pocket.Fix(ctx, true) {
+			// Print the changes and fail if there are any, without writing.
+			args = append(args, "--diff")
+		}
+		configPath, err := lintConfig(ctx, opts.Config, opts.RootConfig)
+		if err != nil {
+			return err
+		}
+		if configPath != "" {
+			args = append(args, "-c", configPath)
 		}
 		args = append(args, "./...")
 
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/ko"
+)
+
+// ImageOptions configures the go-image task.
+//
+// Repo and Tags support the placeholders {sha} (short commit hash) and
+// {version} (git describe --tags --always).
+type ImageOptions struct {
+	Packages  string `arg:"packages"  usage:"comma-separated packages to build (default: ./cmd/...)"`
+	Repo      string `arg:"repo"      usage:"image repository, e.g. ghcr.io/owner/name (default: $KO_DOCKER_REPO)"`
+	Tags      string `arg:"tags"      usage:"comma-separated image tags (default: latest)"`
+	Platforms string `arg:"platforms" usage:"comma-separated platforms, e.g. linux/amd64,linux/arm64"`
+	Push      bool   `arg:"push"      usage:"push images to the repository"`
+	Tarball   string `arg:"tarball"   usage:"write images to a tarball instead of pushing"`
+}
+
+// Image builds OCI images for Go main packages with ko (no Docker needed).
+// Images are only pushed with -push; otherwise they are built and, if
+// -tarball is set, written to a local tarball.
+var Image = pocket.Task("go-image", "build container images with ko",
+	pocket.Serial(ko.Install, imageCmd()),
+	pocket.Opts(ImageOptions{}),
+)
+
+func imageCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[ImageOptions](ctx)
+
+		expand, err := imagePlaceholders(ctx, opts.Repo, opts.Tags)
+		if err != nil {
+			return err
+		}
+
+		repo := os.Getenv("KO_DOCKER_REPO")
+		if opts.Repo != "" {
+			repo = expand.Replace(opts.Repo)
+		}
+		if repo == "" {
+			if opts.Push {
+				return fmt.Errorf("go-image: -repo or KO_DOCKER_REPO is required with -push")
+			}
+			// ko requires a repository even when not pushing.
+			repo = "ko.local"
+		}
+
+		cmd := pocket.Command(ctx, ko.Name, imageArgs(opts, expand)...)
+		cmd.Dir = pocket.FromGitRoot(pocket.Path(ctx))
+		cmd.Env = append(cmd.Env, "KO_DOCKER_REPO="+repo)
+		return pocket.RunCommand(ctx, cmd)
+	})
+}
+
+// imageArgs returns the ko arguments for opts, with placeholders in the
+// tags expanded.
+func imageArgs(opts ImageOptions, expand *strings.Replacer) []string {
+	args := []string{"build", "--base-import-paths"}
+	if opts.Push {
+		args = append(args, "--push=true")
+	} else {
+		args = append(args, "--push=false")
+		if opts.Tarball != "" {
+			args = append(args, "--tarball="+opts.Tarball)
+		}
+	}
+	if opts.Platforms != "" {
+		args = append(args, "--platform="+opts.Platforms)
+	}
+	if opts.Tags != "" {
+		args = append(args, "--tags="+expand.Replace(opts.Tags))
+	}
+	packages := opts.Packages
+	if packages == "" {
+		packages = "./cmd/..."
+	}
+	return append(args, strings.Split(packages, ",")...)
+}
+
+// imagePlaceholders returns a replacer for the {sha} and {version}
+// placeholders. git is only run for placeholders that appear in values,
+// so the task works outside a repository with tags or commits.
+func imagePlaceholders(ctx context.Context, values ...string) (*strings.Replacer, error) {
+	joined := strings.Join(values, " ")
+	var oldnew []string
+	if strings.Contains(joined, "{sha}") {
+		sha, err := gitOutput(ctx, "rev-parse", "--short", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		oldnew = append(oldnew, "{sha}", sha)
+	}
+	if strings.Contains(joined, "{version}") {
+		version, err := gitOutput(ctx, "describe", "--tags", "--always")
+		if err != nil {
+			return nil, err
+		}
+		oldnew = append(oldnew, "{version}", version)
+	}
+	return strings.NewReplacer(oldnew...), nil
+}
+
+// gitOutput runs a git command and returns its trimmed stdout.
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := pocket.Command(ctx, "git", args...)
+	cmd.Dir = pocket.GitRoot()
+	cmd.Stdout = &out
+	if err := pocket.RunCommand(ctx, cmd); err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+func TestImageArgs(t *testing.T) {
+	expand := strings.NewReplacer("{sha}", "abc1234")
+	tests := []struct {
+		name string
+		opts ImageOptions
+		want []string
+	}{
+		{
+			name: "defaults",
+			opts: ImageOptions{},
+			want: []string{"build", "--base-import-paths", "--push=false", "./cmd/..."},
+		},
+		{
+			name: "push with tags and platforms",
+			opts: ImageOptions{
+				Packages:  "./cmd/api,./cmd/worker",
+				Tags:      "latest,{sha}",
+				Platforms: "linux/amd64,linux/arm64",
+				Push:      true,
+				Tarball:   "ignored.tar",
+			},
+			want: []string{
+				"build", "--base-import-paths", "--push=true",
+				"--platform=linux/amd64,linux/arm64", "--tags=latest,abc1234",
+				"./cmd/api", "./cmd/worker",
+			},
+		},
+		{
+			name: "tarball",
+			opts: ImageOptions{Tarball: "image.tar"},
+			want: []string{"build", "--base-import-paths", "--push=false", "--tarball=image.tar", "./cmd/..."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageArgs(tt.opts, expand); !slices.Equal(got, tt.want) {
+				t.Errorf("imageArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImagePlaceholders(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		wantGit  []string
+		input    string
+		expanded string
+	}{
+		{
+			name:     "no placeholders runs no git",
+			values:   []string{"ghcr.io/owner/app", "latest"},
+			input:    "latest",
+			expanded: "latest",
+		},
+		{
+			name:     "sha only",
+			values:   []string{"ghcr.io/owner/app", "{sha}"},
+			wantGit:  []string{"rev-parse --short HEAD"},
+			input:    "{sha}",
+			expanded: "out-of-rev-parse",
+		},
+		{
+			name:     "sha and version",
+			values:   []string{"ghcr.io/owner/app:{version}", "{sha}"},
+			wantGit:  []string{"rev-parse --short HEAD", "describe --tags --always"},
+			input:    "{version}-{sha}",
+			expanded: "out-of-describe-out-of-rev-parse",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+			ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{
+				Runner: func(cmd *exec.Cmd) error {
+					args := strings.Join(cmd.Args[1:], " ")
+					ran = append(ran, args)
+					fmt.Fprintf(cmd.Stdout, "out-of-%s\n", cmd.Args[1])
+					return nil
+				},
+			})
+
+			expand, err := imagePlaceholders(ctx, tt.values...)
+			if err != nil {
+				t.Fatalf("imagePlaceholders() failed: %v", err)
+			}
+			if !slices.Equal(ran, tt.wantGit) {
+				t.Errorf("ran git %q, want %q", ran, tt.wantGit)
+			}
+			if got := expand.Replace(tt.input); got != tt.expanded {
+				t.Errorf("Replace(%q) = %q, want %q", tt.input, got, tt.expanded)
+			}
+		})
+	}
+}
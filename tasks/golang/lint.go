@@ -1,22 +1,30 @@
+// SPDX-License-Identifier: MIT
+
 package golang
 
 import (
 	"context"
 
 	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tasks/sarif"
 	"github.com/fredrikaverpil/pocket/tools/golangcilint"
 )
 
 // LintOptions configures the go-lint task.
 type LintOptions struct {
-	Config  string `arg:"config"   usage:"path to golangci-lint config file"`
-	SkipFix bool   `arg:"skip-fix" usage:"don't auto-fix issues"`
+	Config     string `arg:"config"      usage:"path to golangci-lint config file"`
+	RootConfig bool   `arg:"root-config" usage:"use the repo-root golangci-lint config, ignoring module configs"`
+	SkipFix    bool   `arg:"skip-fix"    usage:"don't auto-fix issues"`
+	Vendor     bool   `arg:"vendor"      usage:"use vendored dependencies (-mod=vendor)"`
 }
 
-// Lint runs golangci-lint with auto-fix enabled by default.
+// Lint runs golangci-lint with auto-fix enabled by default, with the
+// golangci-lint config of the module (see golangcilint.ConfigPath).
+// When POK_SARIF_DIR is set, findings are also written as SARIF.
 var Lint = pocket.Task("go-lint", "run golangci-lint",
 	pocket.Serial(golangcilint.Install, lintCmd()),
 	pocket.Opts(LintOptions{}),
+	pocket.WithInputs(Inputs...),
 )
 
 func lintCmd() pocket.Runnable {
@@ -27,16 +35,27 @@ func lintCmd() pocket.Runnable {
 		if pocket.Verbose(ctx) {
 			args = append(args, "-v")
 		}
-		if opts.Config != "" {
-			args = append(args, "-c", opts.Config)
-		} else if configPath, err := pocket.ConfigPath(ctx, "golangci-lint", golangcilint.Config); err == nil {
-			if configPath != "" {
-				args = append(args, "-c", configPath)
-			}
+		configPath, err := lintConfig(ctx, opts.Config, opts.RootConfig)
+		if err != nil {
+			return err
+		}
+		if configPath != "" {
+			args = append(args, "-c", configPath)
 		}
-		if !opts.SkipFix {
+		if pocket.Fix(ctx, !opts.SkipFix) {
 			args = append(args, "--fix")
 		}
+		if opts.Vendor {
+			args = append(args, "--modules-download-mode=vendor")
+		}
+		sarifFile, err := sarif.OutputFile(ctx, "go-lint")
+		if err != nil {
+			return err
+		}
+		if sarifFile != "" {
+			// Setting any output replaces the default, so keep text on stdout.
+			args = append(args, "--output.text.path=stdout", "--output.sarif.path="+sarifFile)
+		}
 		args = append(args, "./...")
 
 		return pocket.Exec(ctx, golangcilint.Name, args...)
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestLintConfig(t *testing.T) {
+	root := t.TempDir()
+	restore := gitroot.Override(root)
+	defer restore()
+	write := func(name string) string {
+		t.Helper()
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("version: \"2\"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{Path: "services/api"})
+	config := func(path string, rootOnly bool) string {
+		t.Helper()
+		got, err := lintConfig(ctx, path, rootOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if got := config("", false); got != "" {
+		t.Errorf("lintConfig() without configs = %q, want empty", got)
+	}
+	rootConfig := write(".golangci.yml")
+	if got := config("", false); got != rootConfig {
+		t.Errorf("lintConfig() = %q, want repo root %q", got, rootConfig)
+	}
+	moduleConfig := write("services/api/.golangci.yaml")
+	if got := config("", false); got != moduleConfig {
+		t.Errorf("lintConfig() = %q, want module %q", got, moduleConfig)
+	}
+	if got := config("", true); got != rootConfig {
+		t.Errorf("lintConfig(rootOnly) = %q, want repo root %q", got, rootConfig)
+	}
+	if got := config("custom.yml", false); got != "custom.yml" {
+		t.Errorf("lintConfig(custom.yml) = %q, want the task option", got)
+	}
+}
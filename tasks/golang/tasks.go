@@ -1,17 +1,50 @@
+// SPDX-License-Identifier: MIT
+
 // Package golang provides Go development tasks.
 // This is a "task" package - it orchestrates tools to do work.
 package golang
 
 import (
+	"context"
+
 	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/golangcilint"
 )
 
+// Inputs are the files the Go tasks read, relative to the module directory.
+// See pocket.WithInputs.
+var Inputs = []string{"**/*.go", "go.mod", "go.sum"}
+
+// lintConfig returns the golangci-lint config of go-format and go-lint:
+// path if set, else the repo-root config if rootOnly, else the module's
+// config (see golangcilint.ConfigPath). Empty means golangci-lint defaults.
+func lintConfig(ctx context.Context, path string, rootOnly bool) (string, error) {
+	switch {
+	case path != "":
+		return path, nil
+	case rootOnly:
+		if path := golangcilint.FindConfig(pocket.GitRoot()); path != "" {
+			return path, nil
+		}
+		return pocket.ConfigPath(ctx, golangcilint.Name, pocket.ToolConfig{})
+	}
+	return golangcilint.ConfigPath(ctx)
+}
+
 // Option configures the golang task group.
 type Option func(*config)
 
 type config struct {
-	lint LintOptions
-	test TestOptions
+	format FormatOptions
+	lint   LintOptions
+	test   TestOptions
+	vendor bool
+	env    map[string]string
+}
+
+// WithFormat sets options for the go-format task.
+func WithFormat(opts FormatOptions) Option {
+	return func(c *config) { c.format = opts }
 }
 
 // WithLint sets options for the go-lint task.
@@ -24,6 +57,20 @@ func WithTest(opts TestOptions) Option {
 	return func(c *config) { c.test = opts }
 }
 
+// WithVendor builds with vendored dependencies (-mod=vendor) in go-lint and
+// go-test, and adds go-vendor-check, for modules that must vendor their
+// dependencies. Set it explicitly rather than relying on go's default
+// vendor mode, which GOFLAGS=-mod=mod (common in CI) turns off.
+func WithVendor() Option {
+	return func(c *config) { c.vendor = true }
+}
+
+// WithEnv sets extra environment variables for the commands of the Go
+// tasks, e.g. GOFLAGS or CGO_ENABLED=0. See pocket.Env.
+func WithEnv(env map[string]string) Option {
+	return func(c *config) { c.env = env }
+}
+
 // Tasks returns all Go tasks composed as a Runnable.
 // Use this with pocket.RunIn() and pocket.Detect() for auto-detection.
 //
@@ -42,8 +89,17 @@ func Tasks(opts ...Option) pocket.Runnable {
 	for _, opt := range opts {
 		opt(&cfg)
 	}
+	if cfg.vendor {
+		cfg.lint.Vendor = true
+		cfg.test.Vendor = true
+	}
 
 	// Apply options to tasks
+	formatTask := Format
+	if cfg.format != (FormatOptions{}) {
+		formatTask = pocket.WithOpts(Format, cfg.format)
+	}
+
 	lintTask := Lint
 	if cfg.lint != (LintOptions{}) {
 		lintTask = pocket.WithOpts(Lint, cfg.lint)
@@ -54,12 +110,16 @@ func Tasks(opts ...Option) pocket.Runnable {
 		testTask = pocket.WithOpts(Test, cfg.test)
 	}
 
-	return pocket.Serial(
+	tasks := []any{
 		Fix,
-		Format,
+		formatTask,
 		lintTask,
 		pocket.Parallel(testTask, Vulncheck),
-	)
+	}
+	if cfg.vendor {
+		tasks = append([]any{VendorCheck}, tasks...)
+	}
+	return pocket.Env(cfg.env, pocket.Serial(tasks...))
 }
 
 // Detect returns a detection function for Go modules.
@@ -1,32 +1,39 @@
+// SPDX-License-Identifier: MIT
+
 package golang
 
 import (
 	"context"
 
 	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tasks/compose"
 )
 
 // TestOptions configures the go-test task.
 type TestOptions struct {
-	SkipRace     bool `arg:"skip-race"     usage:"disable race detection"`
-	SkipCoverage bool `arg:"skip-coverage" usage:"disable coverage generation"`
-	Short        bool `arg:"short"         usage:"run short tests only"`
+	SkipRace     bool   `arg:"skip-race"     usage:"disable race detection"`
+	SkipCoverage bool   `arg:"skip-coverage" usage:"disable coverage generation"`
+	Short        bool   `arg:"short"         usage:"run short tests only"`
+	Compose      string `arg:"compose"       usage:"comma-separated compose files to bring up around the tests"`
+	Vendor       bool   `arg:"vendor"        usage:"use vendored dependencies (-mod=vendor)"`
 }
 
 // Test runs tests with race detection and coverage by default.
+// With -compose, a Docker Compose stack is brought up before the tests and
+// torn down after them, see compose.Stack.
+// When tests fail, the failed tests and the file:line of their failure
+// messages are listed at the end of the output.
 var Test = pocket.Task("go-test", "run Go tests",
 	testCmd(),
 	pocket.Opts(TestOptions{}),
+	pocket.WithInputs(Inputs...),
 )
 
 func testCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
 		opts := pocket.Options[TestOptions](ctx)
 
-		args := []string{"test"}
-		if pocket.Verbose(ctx) {
-			args = append(args, "-v")
-		}
+		args := []string{"test", "-json"}
 		if !opts.SkipRace {
 			args = append(args, "-race")
 		}
@@ -37,8 +44,34 @@ func testCmd() pocket.Runnable {
 		if opts.Short {
 			args = append(args, "-short")
 		}
+		if opts.Vendor {
+			args = append(args, "-mod=vendor")
+		}
 		args = append(args, "./...")
 
-		return pocket.Exec(ctx, "go", args...)
+		if opts.Compose == "" {
+			return goTest(ctx, args)
+		}
+		stack := compose.Stack{Files: compose.ParseFiles(opts.Compose)}
+		return stack.Run(ctx, func(ctx context.Context) error {
+			return goTest(ctx, args)
+		})
 	})
 }
+
+// goTest runs go test -json with args in the current path, writing its
+// output like go test without -json, followed by a list of the failed tests.
+func goTest(ctx context.Context, args []string) error {
+	out := pocket.GetOutput(ctx)
+	report := newTestReport(out.Stdout, pocket.Verbose(ctx))
+	cmd := pocket.Command(ctx, "go", args...)
+	cmd.Dir = pocket.FromGitRoot(pocket.Path(ctx))
+	cmd.Stdout = report
+	cmd.Stderr = out.Stderr
+	err := pocket.RunCommand(ctx, cmd)
+	report.Flush()
+	if err != nil {
+		report.WriteSummary(out.Stdout)
+	}
+	return err
+}
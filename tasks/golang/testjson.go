@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// testEvent is an event of go test -json, see go doc test2json.
+type testEvent struct {
+	Action      string
+	Package     string
+	Test        string
+	Output      string
+	FailedBuild string // set on package failures caused by a build error
+}
+
+// testFailure is a failed test, or a package that failed without a failed
+// test (e.g. a build failure) if Test is empty.
+type testFailure struct {
+	Package   string
+	Test      string
+	Build     bool     // the package failed to build
+	Locations []string // file:line of the failure messages
+}
+
+// testLocation matches the file:line prefix that t.Error and t.Fatal add
+// to failure messages, e.g. "    parse_test.go:12: got 1, want 2".
+var testLocation = regexp.MustCompile(`^\s+([\w.\-/]+\.go:\d+): `)
+
+// testReport writes the output of go test -json to out like go test
+// without -json, and collects the failed tests. Without verbose, only the
+// output of failed tests is written, like go test without -v.
+type testReport struct {
+	out      io.Writer
+	verbose  bool
+	partial  []byte
+	pending  map[string][]string // test key -> output of a running test
+	failures []testFailure
+}
+
+func newTestReport(out io.Writer, verbose bool) *testReport {
+	return &testReport{out: out, verbose: verbose, pending: make(map[string][]string)}
+}
+
+// Write implements io.Writer for the stdout of go test -json.
+func (r *testReport) Write(p []byte) (int, error) {
+	r.partial = append(r.partial, p...)
+	for {
+		i := bytes.IndexByte(r.partial, '\n')
+		if i < 0 {
+			break
+		}
+		r.line(r.partial[:i+1])
+		r.partial = r.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush handles the last line if it isn't terminated by a newline.
+func (r *testReport) Flush() {
+	if len(r.partial) > 0 {
+		r.line(append(r.partial, '\n'))
+		r.partial = nil
+	}
+}
+
+// line handles one line of go test -json output. Lines that aren't events,
+// e.g. from a test binary writing to its stdout directly, are written as is.
+func (r *testReport) line(line []byte) {
+	var e testEvent
+	if !bytes.HasPrefix(line, []byte("{")) || json.Unmarshal(line, &e) != nil {
+		_, _ = r.out.Write(line)
+		return
+	}
+	key := e.Package + " " + e.Test
+	switch e.Action {
+	case "output":
+		switch {
+		case r.verbose:
+			fmt.Fprint(r.out, e.Output)
+		case e.Test != "":
+			r.pending[key] = append(r.pending[key], e.Output)
+		case e.Output != "PASS\n":
+			fmt.Fprint(r.out, e.Output)
+		}
+	case "fail":
+		r.fail(e, r.pending[key])
+		if !r.verbose {
+			for _, out := range r.pending[key] {
+				if !strings.HasPrefix(out, "=== ") {
+					fmt.Fprint(r.out, out)
+				}
+			}
+		}
+		delete(r.pending, key)
+	case "pass", "skip":
+		delete(r.pending, key)
+	case "build-output":
+		fmt.Fprint(r.out, e.Output)
+	}
+}
+
+// fail records a failed test or package. Parents of failed subtests and
+// packages with failed tests are left out, as the failures are listed.
+func (r *testReport) fail(e testEvent, output []string) {
+	for _, f := range r.failures {
+		if f.Package != e.Package {
+			continue
+		}
+		if e.Test == "" || strings.HasPrefix(f.Test, e.Test+"/") {
+			return
+		}
+	}
+	f := testFailure{Package: e.Package, Test: e.Test, Build: e.FailedBuild != ""}
+	for _, out := range output {
+		if m := testLocation.FindStringSubmatch(out); m != nil && !slices.Contains(f.Locations, m[1]) {
+			f.Locations = append(f.Locations, m[1])
+		}
+	}
+	r.failures = append(r.failures, f)
+}
+
+// WriteSummary writes the failed tests with their locations, if any.
+func (r *testReport) WriteSummary(w io.Writer) {
+	if len(r.failures) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%d failed:\n", len(r.failures))
+	for _, f := range r.failures {
+		switch {
+		case f.Build:
+			fmt.Fprintf(w, "  %s (build failed)\n", f.Package)
+		case f.Test == "":
+			fmt.Fprintf(w, "  %s (package failed)\n", f.Package)
+		case len(f.Locations) > 0:
+			fmt.Fprintf(w, "  %s %s (%s)\n", f.Package, f.Test, strings.Join(f.Locations, ", "))
+		default:
+			fmt.Fprintf(w, "  %s %s\n", f.Package, f.Test)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"strings"
+	"testing"
+)
+
+const testJSON = `{"Action":"start","Package":"example.com/app"}
+{"Action":"run","Package":"example.com/app","Test":"TestOK"}
+{"Action":"output","Package":"example.com/app","Test":"TestOK","Output":"=== RUN   TestOK\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestOK","Output":"    app_test.go:5: fine\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestOK","Output":"--- PASS: TestOK (0.00s)\n"}
+{"Action":"pass","Package":"example.com/app","Test":"TestOK"}
+{"Action":"run","Package":"example.com/app","Test":"TestParse"}
+{"Action":"output","Package":"example.com/app","Test":"TestParse","Output":"=== RUN   TestParse\n"}
+{"Action":"run","Package":"example.com/app","Test":"TestParse/empty"}
+{"Action":"output","Package":"example.com/app","Test":"TestParse/empty","Output":"=== RUN   TestParse/empty\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestParse/empty","Output":"    parse_test.go:12: got 1, want 2\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestParse/empty","Output":"--- FAIL: TestParse/empty (0.00s)\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestParse/empty"}
+{"Action":"output","Package":"example.com/app","Test":"TestParse","Output":"--- FAIL: TestParse (0.00s)\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestParse"}
+{"Action":"output","Package":"example.com/app","Output":"FAIL\n"}
+{"Action":"output","Package":"example.com/app","Output":"FAIL\texample.com/app\t0.002s\n"}
+{"Action":"fail","Package":"example.com/app"}
+{"ImportPath":"example.com/app/cmd [example.com/app/cmd.test]","Action":"build-output","Output":"cmd/main_test.go:5:33: undefined: run\n"}
+{"ImportPath":"example.com/app/cmd [example.com/app/cmd.test]","Action":"build-fail"}
+{"Action":"output","Package":"example.com/app/cmd","Output":"FAIL\texample.com/app/cmd [build failed]\n"}
+{"Action":"fail","Package":"example.com/app/cmd","FailedBuild":"example.com/app/cmd [example.com/app/cmd.test]"}
+{"Action":"output","Package":"example.com/app/util","Output":"PASS\n"}
+{"Action":"output","Package":"example.com/app/util","Output":"ok  \texample.com/app/util\t0.001s\n"}
+{"Action":"pass","Package":"example.com/app/util"}
+`
+
+func TestTestReport(t *testing.T) {
+	var out strings.Builder
+	r := newTestReport(&out, false)
+	// Write in chunks that split lines, like a pipe.
+	for data := testJSON; data != ""; {
+		n := min(len(data), 100)
+		_, _ = r.Write([]byte(data[:n]))
+		data = data[n:]
+	}
+	r.Flush()
+
+	got := out.String()
+	for _, want := range []string{
+		"    parse_test.go:12: got 1, want 2\n--- FAIL: TestParse/empty",
+		"FAIL\texample.com/app\t0.002s\n",
+		"cmd/main_test.go:5:33: undefined: run\n",
+		"ok  \texample.com/app/util\t0.001s\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	for _, notWant := range []string{"=== RUN", "app_test.go:5", "PASS\n"} {
+		if strings.Contains(got, notWant) {
+			t.Errorf("output contains %q without verbose:\n%s", notWant, got)
+		}
+	}
+
+	var summary strings.Builder
+	r.WriteSummary(&summary)
+	want := "\n2 failed:\n" +
+		"  example.com/app TestParse/empty (parse_test.go:12)\n" +
+		"  example.com/app/cmd (build failed)\n"
+	if summary.String() != want {
+		t.Errorf("WriteSummary() = %q, want %q", summary.String(), want)
+	}
+}
+
+func TestTestReport_Verbose(t *testing.T) {
+	var out strings.Builder
+	r := newTestReport(&out, true)
+	_, _ = r.Write([]byte(testJSON))
+	if got := out.String(); !strings.Contains(got, "=== RUN   TestOK\n    app_test.go:5: fine\n") {
+		t.Errorf("expected the output of all tests with verbose:\n%s", got)
+	}
+}
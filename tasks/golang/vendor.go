@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// VendorCheck fails when the vendor directory of the Go module in the path
+// differs from what "go mod vendor" writes, e.g. after go.mod was updated
+// without re-vendoring. The vendor directory is left untouched: go mod
+// vendor writes to a temporary directory that is compared with it.
+//
+// Example usage in .pocket/config.go:
+//
+//	pocket.RunIn(golang.Tasks(golang.WithVendor()), pocket.Detect(golang.Detect()))
+var VendorCheck = pocket.Task("go-vendor-check", "check that vendor/ matches go mod vendor",
+	vendorCheckCmd(),
+	pocket.WithInputs("go.mod", "go.sum", "vendor/**"),
+)
+
+func vendorCheckCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		dir := pocket.FromGitRoot(pocket.Path(ctx))
+		vendorDir := filepath.Join(dir, "vendor")
+		if _, err := os.Stat(vendorDir); err != nil {
+			return fmt.Errorf("go-vendor-check: no vendor directory in %s, run go mod vendor", pocket.Path(ctx))
+		}
+
+		tmpDir, err := os.MkdirTemp("", "pocket-vendor-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		want := filepath.Join(tmpDir, "vendor")
+		if _, err := goOutput(ctx, "mod", "vendor", "-o", want); err != nil {
+			return err
+		}
+
+		diffs, err := diffDirs(want, vendorDir)
+		if err != nil {
+			return fmt.Errorf("go-vendor-check: %w", err)
+		}
+		if len(diffs) == 0 {
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  vendor/ is up to date\n")
+			}
+			return nil
+		}
+		for _, d := range diffs {
+			pocket.Printf(ctx, "  vendor/%s\n", d)
+		}
+		return fmt.Errorf("go-vendor-check: %d file(s) in vendor/ differ from go mod vendor, run go mod vendor", len(diffs))
+	})
+}
+
+// diffDirs compares the regular files below want and got and returns the
+// differences, sorted by path, e.g. "modules.txt (changed)".
+func diffDirs(want, got string) ([]string, error) {
+	wantFiles, err := dirFiles(want)
+	if err != nil {
+		return nil, err
+	}
+	gotFiles, err := dirFiles(got)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []string
+	for rel := range wantFiles {
+		if !gotFiles[rel] {
+			diffs = append(diffs, rel+" (missing)")
+			continue
+		}
+		a, err := os.ReadFile(filepath.Join(want, rel))
+		if err != nil {
+			return nil, err
+		}
+		b, err := os.ReadFile(filepath.Join(got, rel))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(a, b) {
+			diffs = append(diffs, rel+" (changed)")
+		}
+	}
+	for rel := range gotFiles {
+		if !wantFiles[rel] {
+			diffs = append(diffs, rel+" (extra)")
+		}
+	}
+	slices.Sort(diffs)
+	return diffs, nil
+}
+
+// dirFiles returns the slash-separated paths of the regular files below
+// dir, relative to dir. A missing dir has no files.
+func dirFiles(dir string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return files, nil
+	}
+	return files, err
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: MIT
+
+package golang
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+func TestDiffDirs(t *testing.T) {
+	want, got := t.TempDir(), t.TempDir()
+	write := func(dir, name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(want, "modules.txt", "# golang.org/x/sync v0.10.0\n")
+	write(got, "modules.txt", "# golang.org/x/sync v0.9.0\n")
+	write(want, "golang.org/x/sync/errgroup/errgroup.go", "package errgroup")
+	write(got, "golang.org/x/sync/errgroup/errgroup.go", "package errgroup")
+	write(want, "golang.org/x/sync/LICENSE", "license")
+	write(got, "github.com/pkg/errors/errors.go", "package errors")
+
+	diffs, err := diffDirs(want, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDiffs := []string{
+		"github.com/pkg/errors/errors.go (extra)",
+		"golang.org/x/sync/LICENSE (missing)",
+		"modules.txt (changed)",
+	}
+	if !slices.Equal(diffs, wantDiffs) {
+		t.Errorf("diffDirs() = %v, want %v", diffs, wantDiffs)
+	}
+
+	if diffs, err := diffDirs(want, want); err != nil || len(diffs) != 0 {
+		t.Errorf("diffDirs() of the same dir = %v, %v; want no differences", diffs, err)
+	}
+}
+
+func TestTasks_WithVendor(t *testing.T) {
+	names := func(r pocket.Runnable) []string {
+		t.Helper()
+		tasks, err := pocket.CollectTasks(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result []string
+		for _, task := range tasks {
+			result = append(result, task.Name)
+		}
+		return result
+	}
+	if slices.Contains(names(Tasks()), "go-vendor-check") {
+		t.Error("expected go-vendor-check only with WithVendor")
+	}
+	if got := names(Tasks(WithVendor())); len(got) == 0 || got[0] != "go-vendor-check" {
+		t.Errorf("expected go-vendor-check to run first with WithVendor, got %v", got)
+	}
+}
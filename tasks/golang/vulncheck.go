@@ -1,19 +1,34 @@
+// SPDX-License-Identifier: MIT
+
 package golang
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tasks/sarif"
 	"github.com/fredrikaverpil/pocket/tools/govulncheck"
 )
 
 // Vulncheck runs govulncheck for vulnerability scanning.
+// When POK_SARIF_DIR is set, findings are written as SARIF instead of text.
 var Vulncheck = pocket.Task("go-vulncheck", "run govulncheck",
 	pocket.Serial(govulncheck.Install, vulncheckCmd()),
+	pocket.WithInputs(Inputs...),
 )
 
 func vulncheckCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
+		sarifFile, err := sarif.OutputFile(ctx, "go-vulncheck")
+		if err != nil {
+			return err
+		}
+		if sarifFile != "" {
+			return vulncheckSARIF(ctx, sarifFile)
+		}
+
 		args := []string{}
 		if pocket.Verbose(ctx) {
 			args = append(args, "-show", "verbose")
@@ -22,3 +37,47 @@ func vulncheckCmd() pocket.Runnable {
 		return pocket.Exec(ctx, govulncheck.Name, args...)
 	})
 }
+
+// vulncheckSARIF runs govulncheck once in SARIF mode and writes the report
+// to sarifFile. govulncheck exits 0 in SARIF mode, so the task result is
+// taken from the report: results at level "error" are vulnerable symbols
+// that the code calls, which is what fails a text-mode run.
+func vulncheckSARIF(ctx context.Context, sarifFile string) error {
+	f, err := os.Create(sarifFile)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", sarifFile, err)
+	}
+	cmd := pocket.Command(ctx, govulncheck.Name, "-format", "sarif", "./...")
+	cmd.Dir = pocket.FromGitRoot(pocket.Path(ctx))
+	cmd.Stdout = f
+	runErr := pocket.RunCommand(ctx, cmd)
+	if err := f.Close(); err != nil && runErr == nil {
+		runErr = err
+	}
+	if runErr != nil {
+		return runErr
+	}
+
+	data, err := os.ReadFile(sarifFile)
+	if err != nil {
+		return err
+	}
+	findings, err := sarif.Findings(data)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", sarifFile, err)
+	}
+	called := 0
+	for _, finding := range findings {
+		if finding.Level != "error" && !pocket.Verbose(ctx) {
+			continue
+		}
+		if finding.Level == "error" {
+			called++
+		}
+		pocket.Printf(ctx, "  %s (%s): %s\n", finding.RuleID, finding.Level, finding.Message)
+	}
+	if called > 0 {
+		return fmt.Errorf("govulncheck: %d called vulnerabilities, see %s", called, sarifFile)
+	}
+	return nil
+}
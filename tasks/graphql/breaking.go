@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: MIT
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// BreakingOptions configures the graphql-breaking task.
+type BreakingOptions struct {
+	Base string `arg:"base" usage:"git ref to compare against (default: origin's default branch)"`
+}
+
+// Breaking fails if the GraphQL schema below the path has breaking changes
+// compared to the base ref: removed types, fields, arguments, enum values
+// or union members, incompatible type changes, and new required arguments
+// or input fields. The schema is compared against the merge base, so
+// changes on the base branch since branching off are not reported.
+//
+// The check is skipped with a note when the base ref is not available,
+// e.g. in a shallow CI checkout; fetch it first to enable the check.
+var Breaking = pocket.Task("graphql-breaking", "check GraphQL schema for breaking changes",
+	breakingCmd(),
+	pocket.Opts(BreakingOptions{}),
+	pocket.WithExample("./pok graphql-breaking -base=origin/release", "compare against a release branch"),
+)
+
+func breakingCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[BreakingOptions](ctx)
+		base := opts.Base
+		if base == "" {
+			ref, err := gitOutput(ctx, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+			if err != nil {
+				pocket.Println(ctx, "Note: graphql-breaking skipped, origin's default branch is unknown (set -base)")
+				return nil
+			}
+			base = ref
+		}
+		rev, err := gitOutput(ctx, "merge-base", "HEAD", base)
+		if err != nil {
+			pocket.Printf(ctx, "Note: graphql-breaking skipped, %s is not available (fetch it first)\n", base)
+			return nil
+		}
+
+		newSources, err := readSchemaSources(pocket.GitRoot(), pocket.FromGitRoot(pocket.Path(ctx)))
+		if err != nil {
+			return err
+		}
+		oldSources, err := readSchemaSourcesAt(ctx, rev, pocket.Path(ctx))
+		if err != nil {
+			return err
+		}
+		if len(oldSources) == 0 {
+			return nil
+		}
+		oldSchema, err := loadSchema(oldSources)
+		if err != nil {
+			return fmt.Errorf("graphql-breaking: schema at %s: %w", base, err)
+		}
+		newSchema, err := loadSchema(newSources)
+		if err != nil {
+			return fmt.Errorf("graphql-breaking: %w", err)
+		}
+
+		changes := breakingChanges(oldSchema, newSchema)
+		for _, change := range changes {
+			pocket.Println(ctx, change)
+		}
+		if len(changes) > 0 {
+			return fmt.Errorf("graphql-breaking: %d breaking change(s) compared to %s", len(changes), base)
+		}
+		return nil
+	})
+}
+
+// readSchemaSourcesAt reads the schema files below dir at the git revision
+// rev. Source names are relative to the git root.
+func readSchemaSourcesAt(ctx context.Context, rev, dir string) ([]*ast.Source, error) {
+	list, err := gitOutput(ctx, "ls-tree", "-r", "--name-only", rev, "--", dir)
+	if err != nil {
+		return nil, err
+	}
+	var sources []*ast.Source
+	for name := range strings.Lines(list) {
+		name = strings.TrimSpace(name)
+		if !isSchemaFile(name) || hiddenOrVendored(name) {
+			continue
+		}
+		input, err := gitOutput(ctx, "show", rev+":"+name)
+		if err != nil {
+			return nil, err
+		}
+		if isSchema(input) {
+			sources = append(sources, &ast.Source{Name: name, Input: input})
+		}
+	}
+	return sources, nil
+}
+
+// hiddenOrVendored reports whether a slash-separated path has a directory
+// that readSchemaSources skips.
+func hiddenOrVendored(name string) bool {
+	dirs := strings.Split(name, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if strings.HasPrefix(dir, ".") || dir == "vendor" || dir == "node_modules" {
+			return true
+		}
+	}
+	return false
+}
+
+// gitOutput runs a git command from the git root and returns its trimmed
+// stdout.
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := pocket.Command(ctx, "git", args...)
+	cmd.Dir = pocket.GitRoot()
+	cmd.Stdout = &out
+	if err := pocket.RunCommand(ctx, cmd); err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// breakingChanges returns the changes from oldSchema to newSchema that can
+// break existing clients, as "file:line:col: message" lines. Positions of
+// removed definitions refer to the old schema.
+func breakingChanges(oldSchema, newSchema *ast.Schema) []string {
+	var issues []issue
+	report := func(pos *ast.Position, format string, args ...any) {
+		issues = append(issues, issue{pos, fmt.Sprintf(format, args...)})
+	}
+	for name, oldDef := range oldSchema.Types {
+		if oldDef.BuiltIn {
+			continue
+		}
+		newDef := newSchema.Types[name]
+		if newDef == nil {
+			report(oldDef.Position, "type %s was removed", name)
+			continue
+		}
+		if newDef.Kind != oldDef.Kind {
+			report(newDef.Position, "type %s changed from %s to %s", name, kindName(oldDef.Kind), kindName(newDef.Kind))
+			continue
+		}
+		switch oldDef.Kind {
+		case ast.Object, ast.Interface:
+			for _, iface := range oldDef.Interfaces {
+				if !slices.Contains(newDef.Interfaces, iface) {
+					report(newDef.Position, "type %s no longer implements %s", name, iface)
+				}
+			}
+			compareFields(report, oldDef, newDef)
+		case ast.InputObject:
+			compareInputFields(report, oldDef, newDef)
+		case ast.Enum:
+			for _, value := range oldDef.EnumValues {
+				if newDef.EnumValues.ForName(value.Name) == nil {
+					report(value.Position, "enum value %s.%s was removed", name, value.Name)
+				}
+			}
+		case ast.Union:
+			for _, member := range oldDef.Types {
+				if !slices.Contains(newDef.Types, member) {
+					report(newDef.Position, "type %s was removed from union %s", member, name)
+				}
+			}
+		}
+	}
+	for name, oldDir := range oldSchema.Directives {
+		if oldDir.Position != nil && oldDir.Position.Src != nil && oldDir.Position.Src.BuiltIn {
+			continue
+		}
+		if newSchema.Directives[name] == nil {
+			report(oldDir.Position, "directive @%s was removed", name)
+		}
+	}
+	return formatIssues(issues)
+}
+
+// compareFields reports breaking changes to the fields of an object or
+// interface type.
+func compareFields(report func(*ast.Position, string, ...any), oldDef, newDef *ast.Definition) {
+	for _, oldField := range oldDef.Fields {
+		if strings.HasPrefix(oldField.Name, "__") {
+			continue
+		}
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			report(oldField.Position, "field %s.%s was removed", oldDef.Name, oldField.Name)
+			continue
+		}
+		if !safeOutputChange(oldField.Type, newField.Type) {
+			report(newField.Position, "field %s.%s changed type from %s to %s",
+				oldDef.Name, oldField.Name, oldField.Type, newField.Type)
+		}
+		for _, oldArg := range oldField.Arguments {
+			newArg := newField.Arguments.ForName(oldArg.Name)
+			if newArg == nil {
+				report(oldArg.Position, "argument %s.%s(%s) was removed", oldDef.Name, oldField.Name, oldArg.Name)
+				continue
+			}
+			if !safeInputChange(oldArg.Type, newArg.Type) {
+				report(newArg.Position, "argument %s.%s(%s) changed type from %s to %s",
+					oldDef.Name, oldField.Name, oldArg.Name, oldArg.Type, newArg.Type)
+			}
+		}
+		for _, newArg := range newField.Arguments {
+			if oldField.Arguments.ForName(newArg.Name) == nil && newArg.Type.NonNull && newArg.DefaultValue == nil {
+				report(newArg.Position, "required argument %s.%s(%s) was added", oldDef.Name, oldField.Name, newArg.Name)
+			}
+		}
+	}
+}
+
+// compareInputFields reports breaking changes to the fields of an input
+// object type.
+func compareInputFields(report func(*ast.Position, string, ...any), oldDef, newDef *ast.Definition) {
+	for _, oldField := range oldDef.Fields {
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			report(oldField.Position, "input field %s.%s was removed", oldDef.Name, oldField.Name)
+			continue
+		}
+		if !safeInputChange(oldField.Type, newField.Type) {
+			report(newField.Position, "input field %s.%s changed type from %s to %s",
+				oldDef.Name, oldField.Name, oldField.Type, newField.Type)
+		}
+	}
+	for _, newField := range newDef.Fields {
+		if oldDef.Fields.ForName(newField.Name) == nil && newField.Type.NonNull && newField.DefaultValue == nil {
+			report(newField.Position, "required input field %s.%s was added", oldDef.Name, newField.Name)
+		}
+	}
+}
+
+// safeOutputChange reports whether a field may change type from oldType to
+// newType without breaking clients: it may only become non-null.
+func safeOutputChange(oldType, newType *ast.Type) bool {
+	switch {
+	case oldType.NonNull:
+		return newType.NonNull && safeOutputChange(nullable(oldType), nullable(newType))
+	case newType.NonNull:
+		return safeOutputChange(oldType, nullable(newType))
+	case oldType.Elem != nil:
+		return newType.Elem != nil && safeOutputChange(oldType.Elem, newType.Elem)
+	default:
+		return newType.Elem == nil && oldType.NamedType == newType.NamedType
+	}
+}
+
+// safeInputChange reports whether an argument or input field may change
+// type from oldType to newType without breaking clients: it may only
+// become nullable.
+func safeInputChange(oldType, newType *ast.Type) bool {
+	switch {
+	case oldType.NonNull:
+		return safeInputChange(nullable(oldType), nullable(newType))
+	case newType.NonNull:
+		return false
+	case oldType.Elem != nil:
+		return newType.Elem != nil && safeInputChange(oldType.Elem, newType.Elem)
+	default:
+		return newType.Elem == nil && oldType.NamedType == newType.NamedType
+	}
+}
+
+func nullable(t *ast.Type) *ast.Type {
+	c := *t
+	c.NonNull = false
+	return &c
+}
+
+func kindName(kind ast.DefinitionKind) string {
+	return strings.ToLower(strings.ReplaceAll(string(kind), "_", " "))
+}
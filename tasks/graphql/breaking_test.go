@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: MIT
+
+package graphql
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const oldSchema = `type Query {
+  user(id: ID!, expand: Boolean): User
+  users(first: Int): [User!]!
+  search(term: String!): [Result]
+}
+
+interface Node {
+  id: ID!
+}
+
+type User implements Node {
+  id: ID!
+  name: String
+  email: String!
+  role: Role
+}
+
+type Team {
+  id: ID!
+}
+
+union Result = User | Team
+
+enum Role {
+  ADMIN
+  MEMBER
+  GUEST
+}
+
+input UserFilter {
+  role: Role
+  active: Boolean!
+}
+
+type Legacy {
+  id: ID
+}
+`
+
+func TestBreakingChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   []string
+	}{
+		{
+			name:   "unchanged",
+			schema: oldSchema,
+		},
+		{
+			name: "safe changes",
+			schema: strings.NewReplacer(
+				"name: String", "name: String!\n  nickname: String",
+				"users(first: Int)", "users(first: Int, after: String, limit: Int! = 10)",
+				"active: Boolean!", "active: Boolean\n  team: ID",
+				"GUEST", "GUEST\n  OWNER",
+			).Replace(oldSchema),
+		},
+		{
+			name: "breaking changes",
+			schema: strings.NewReplacer(
+				"type Legacy {\n  id: ID\n}\n", "",
+				"email: String!", "email: String",
+				"expand: Boolean", "expand: Boolean!",
+				"search(term: String!)", "search(term: String!, locale: String!)",
+				"union Result = User | Team", "union Result = User",
+				"  GUEST\n", "",
+				"active: Boolean!", "active: Boolean!\n  team: ID!",
+				"type User implements Node", "type User",
+			).Replace(oldSchema),
+			want: []string{
+				"schema.graphqls:2:17: argument Query.user(expand) changed type from Boolean to Boolean!",
+				"schema.graphqls:4:25: required argument Query.search(locale) was added",
+				"schema.graphqls:11:6: type User no longer implements Node",
+				"schema.graphqls:14:3: field User.email changed type from String! to String",
+				"schema.graphqls:22:7: type Team was removed from union Result",
+				"schema.graphqls:27:3: enum value Role.GUEST was removed",
+				"schema.graphqls:32:3: required input field UserFilter.team was added",
+				"schema.graphqls:35:6: type Legacy was removed",
+			},
+		},
+		{
+			name:   "kind change",
+			schema: strings.Replace(oldSchema, "type Legacy", "input Legacy", 1),
+			want: []string{
+				"schema.graphqls:35:7: type Legacy changed from object to input object",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldS := mustLoad(t, map[string]string{"schema.graphqls": oldSchema})
+			newS := mustLoad(t, map[string]string{"schema.graphqls": tt.schema})
+			got := breakingChanges(oldS, newS)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("breakingChanges() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
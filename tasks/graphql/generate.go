@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: MIT
+
+package graphql
+
+import (
+	"context"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// GenerateOptions configures the graphql-generate task.
+type GenerateOptions struct {
+	Config string `arg:"config" usage:"gqlgen config file (default: gqlgen.yml)"`
+}
+
+// Generate runs gqlgen code generation in the path. gqlgen runs with
+// "go run" at the version in the module's go.mod, so the generated code
+// matches the gqlgen runtime the module builds against.
+//
+// Register it in Config.Generate, so that ./pok generate regenerates the
+// code and the git-diff check catches a stale schema:
+//
+//	Generate: []pocket.Runnable{
+//	    pocket.RunIn(graphql.Generate, pocket.Detect(graphql.DetectGqlgen())),
+//	},
+var Generate = pocket.Task("graphql-generate", "generate Go code from GraphQL schema with gqlgen",
+	generateCmd(),
+	pocket.Opts(GenerateOptions{}),
+)
+
+func generateCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[GenerateOptions](ctx)
+		args := []string{"run", "github.com/99designs/gqlgen", "generate"}
+		if opts.Config != "" {
+			args = append(args, "--config", opts.Config)
+		}
+		if pocket.Verbose(ctx) {
+			args = append(args, "--verbose")
+		}
+		return pocket.Exec(ctx, "go", args...)
+	})
+}
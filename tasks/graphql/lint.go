@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: MIT
+
+package graphql
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// LintOptions configures the graphql-lint task.
+type LintOptions struct {
+	SkipNaming bool `arg:"skip-naming" usage:"only validate the schema, without naming conventions"`
+}
+
+// Lint validates the GraphQL schema files below the path as one schema and
+// checks naming conventions: PascalCase types, camelCase fields and
+// arguments, and UPPER_CASE enum values.
+var Lint = pocket.Task("graphql-lint", "lint GraphQL schema",
+	lintCmd(),
+	pocket.Opts(LintOptions{}),
+)
+
+func lintCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[LintOptions](ctx)
+		sources, err := readSchemaSources(pocket.GitRoot(), pocket.FromGitRoot(pocket.Path(ctx)))
+		if err != nil {
+			return err
+		}
+		if len(sources) == 0 {
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "No GraphQL schema files in %s\n", pocket.Path(ctx))
+			}
+			return nil
+		}
+		schema, err := loadSchema(sources)
+		if err != nil {
+			return fmt.Errorf("graphql-lint: %w", err)
+		}
+		if opts.SkipNaming {
+			return nil
+		}
+		issues := lintNaming(schema)
+		for _, issue := range issues {
+			pocket.Println(ctx, issue)
+		}
+		if len(issues) > 0 {
+			return fmt.Errorf("graphql-lint: %d issue(s)", len(issues))
+		}
+		return nil
+	})
+}
+
+var (
+	pascalCaseRe = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	camelCaseRe  = regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`)
+	upperCaseRe  = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// issue is a problem found at a position in a schema file.
+type issue struct {
+	pos *ast.Position
+	msg string
+}
+
+// lintNaming returns the naming convention violations in schema as
+// "file:line:col: message" lines, sorted by position.
+func lintNaming(schema *ast.Schema) []string {
+	var issues []issue
+	report := func(pos *ast.Position, format string, args ...any) {
+		issues = append(issues, issue{pos, fmt.Sprintf(format, args...)})
+	}
+	for _, def := range schema.Types {
+		if def.BuiltIn || def.Position == nil || def.Position.Src == nil || def.Position.Src.BuiltIn {
+			continue
+		}
+		if !pascalCaseRe.MatchString(def.Name) {
+			report(def.Position, "type %q should be PascalCase", def.Name)
+		}
+		for _, field := range def.Fields {
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			if !camelCaseRe.MatchString(field.Name) {
+				report(field.Position, "field %s.%s should be camelCase", def.Name, field.Name)
+			}
+			for _, arg := range field.Arguments {
+				if !camelCaseRe.MatchString(arg.Name) {
+					report(arg.Position, "argument %s.%s(%s) should be camelCase", def.Name, field.Name, arg.Name)
+				}
+			}
+		}
+		for _, value := range def.EnumValues {
+			if !upperCaseRe.MatchString(value.Name) {
+				report(value.Position, "enum value %s.%s should be UPPER_CASE", def.Name, value.Name)
+			}
+		}
+	}
+	return formatIssues(issues)
+}
+
+// formatIssues sorts issues by position and formats them as
+// "file:line:col: message" lines.
+func formatIssues(issues []issue) []string {
+	slices.SortFunc(issues, func(a, b issue) int {
+		return cmp.Or(
+			strings.Compare(sourceName(a.pos), sourceName(b.pos)),
+			cmp.Compare(line(a.pos), line(b.pos)),
+			strings.Compare(a.msg, b.msg),
+		)
+	})
+	lines := make([]string, len(issues))
+	for i, is := range issues {
+		lines[i] = position(is.pos) + ": " + is.msg
+	}
+	return lines
+}
+
+// position formats pos as "file:line:col".
+func position(pos *ast.Position) string {
+	if pos == nil {
+		return sourceName(pos)
+	}
+	return fmt.Sprintf("%s:%d:%d", sourceName(pos), pos.Line, pos.Column)
+}
+
+func sourceName(pos *ast.Position) string {
+	if pos == nil || pos.Src == nil {
+		return "<schema>"
+	}
+	return pos.Src.Name
+}
+
+func line(pos *ast.Position) int {
+	if pos == nil {
+		return 0
+	}
+	return pos.Line
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: MIT
+
+package graphql
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func mustLoad(t *testing.T, files map[string]string) *ast.Schema {
+	t.Helper()
+	var sources []*ast.Source
+	for name, input := range files {
+		sources = append(sources, &ast.Source{Name: name, Input: input})
+	}
+	schema, err := loadSchema(sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestLintNaming(t *testing.T) {
+	schema := mustLoad(t, map[string]string{
+		"api/schema.graphqls": `type Query {
+  user(user_id: ID!): user
+  allUsers: [user!]!
+}
+
+type user {
+  id: ID!
+  Full_Name: String
+  role: Role
+}
+
+enum Role {
+  ADMIN
+  member
+}
+`,
+	})
+
+	want := []string{
+		`api/schema.graphqls:2:8: argument Query.user(user_id) should be camelCase`,
+		`api/schema.graphqls:6:6: type "user" should be PascalCase`,
+		`api/schema.graphqls:8:3: field user.Full_Name should be camelCase`,
+		`api/schema.graphqls:14:3: enum value Role.member should be UPPER_CASE`,
+	}
+	if got := lintNaming(schema); !slices.Equal(got, want) {
+		t.Errorf("lintNaming() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestIsSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"types", "type Query { hello: String }", true},
+		{"extension", "extend type Query { bye: String }", true},
+		{"empty", "", true},
+		{"query", "query Hello { hello }", false},
+		{"anonymous query", "{ hello }", false},
+		{"fragment", "fragment F on Query { hello }", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSchema(tt.input); got != tt.want {
+				t.Errorf("isSchema(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutermost(t *testing.T) {
+	got := outermost([]string{"services/api/graph", "services/api", "web", "services/api", "services/apis"})
+	want := []string{"services/api", "services/apis", "web"}
+	if !slices.Equal(got, want) {
+		t.Errorf("outermost() = %v, want %v", got, want)
+	}
+	if got := outermost([]string{"api", "."}); !slices.Equal(got, []string{"."}) {
+		t.Errorf("outermost() = %v, want [.]", got)
+	}
+}
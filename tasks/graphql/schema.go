@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: MIT
+
+package graphql
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// schemaExtensions are the file extensions of GraphQL files. Files holding
+// operations rather than type definitions are skipped, see isSchema.
+var schemaExtensions = []string{".graphqls", ".graphql"}
+
+// gqlgenConfigs are the config file names gqlgen looks up.
+var gqlgenConfigs = []string{"gqlgen.yml", "gqlgen.yaml"}
+
+// isSchemaFile reports whether name has a GraphQL file extension.
+func isSchemaFile(name string) bool {
+	return slices.ContainsFunc(schemaExtensions, func(ext string) bool {
+		return strings.HasSuffix(name, ext)
+	})
+}
+
+// isSchema reports whether input holds type definitions. Client operation
+// documents (queries, mutations, fragments) in the same tree are not part
+// of the schema.
+func isSchema(input string) bool {
+	doc, err := parser.ParseQuery(&ast.Source{Input: input})
+	return err != nil || len(doc.Operations)+len(doc.Fragments) == 0
+}
+
+// readSchemaSources reads the schema files below dir, skipping hidden and
+// vendor directories. Source names are relative to root, with forward
+// slashes, so that messages point at the files from the git root.
+func readSchemaSources(root, dir string) ([]*ast.Source, error) {
+	var sources []*ast.Source
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isSchemaFile(d.Name()) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !isSchema(string(data)) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, &ast.Source{Name: filepath.ToSlash(rel), Input: string(data)})
+		return nil
+	})
+	return sources, err
+}
+
+// loadSchema parses and validates sources as one schema.
+func loadSchema(sources []*ast.Source) (*ast.Schema, error) {
+	return gqlparser.LoadSchema(sources...)
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: MIT
+
+// Package graphql provides GraphQL schema tasks.
+// This is a "task" package - it orchestrates tools to do work.
+package graphql
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Option configures the graphql task group.
+type Option func(*config)
+
+type config struct {
+	lint     LintOptions
+	breaking BreakingOptions
+}
+
+// WithLint sets options for the graphql-lint task.
+func WithLint(opts LintOptions) Option {
+	return func(c *config) { c.lint = opts }
+}
+
+// WithBreaking sets options for the graphql-breaking task.
+func WithBreaking(opts BreakingOptions) Option {
+	return func(c *config) { c.breaking = opts }
+}
+
+// Tasks returns all GraphQL schema checks composed as a Runnable.
+// Use this with pocket.RunIn() and pocket.Detect() for auto-detection.
+// Code generation belongs in Config.Generate, see Generate.
+//
+// Example:
+//
+//	pocket.RunIn(graphql.Tasks(), pocket.Detect(graphql.Detect()))
+func Tasks(opts ...Option) pocket.Runnable {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	lintTask := Lint
+	if cfg.lint != (LintOptions{}) {
+		lintTask = pocket.WithOpts(Lint, cfg.lint)
+	}
+
+	breakingTask := Breaking
+	if cfg.breaking != (BreakingOptions{}) {
+		breakingTask = pocket.WithOpts(Breaking, cfg.breaking)
+	}
+
+	return pocket.Serial(lintTask, breakingTask)
+}
+
+// Detect returns a detection function for GraphQL projects.
+// It finds directories containing gqlgen config or schema files, keeping
+// only the outermost directory of nested matches, since the checks read
+// all schema files below their path.
+func Detect() func() []string {
+	return func() []string {
+		dirs := append(pocket.DetectByFile(gqlgenConfigs...), pocket.DetectByExtension(schemaExtensions...)...)
+		return outermost(dirs)
+	}
+}
+
+// DetectGqlgen returns a detection function for gqlgen projects.
+// It finds directories containing a gqlgen config file.
+func DetectGqlgen() func() []string {
+	return func() []string {
+		return pocket.DetectByFile(gqlgenConfigs...)
+	}
+}
+
+// outermost returns the sorted, unique dirs that are not below another dir.
+func outermost(dirs []string) []string {
+	slices.Sort(dirs)
+	dirs = slices.Compact(dirs)
+	var result []string
+	for _, dir := range dirs {
+		nested := slices.ContainsFunc(result, func(parent string) bool {
+			return parent == "." || strings.HasPrefix(dir, parent+"/")
+		})
+		if !nested {
+			result = append(result, path.Clean(dir))
+		}
+	}
+	return result
+}
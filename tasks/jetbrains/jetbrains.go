@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: MIT
+
+// Package jetbrains generates JetBrains IDE (GoLand, PyCharm, IntelliJ) run
+// configurations that run pocket tasks, keeping IDE users on the same
+// toolchain as CI.
+// This is a "task" package - it orchestrates tools to do work.
+package jetbrains
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// runConfigDir is where JetBrains IDEs pick up shared run configurations.
+const runConfigDir = ".idea/runConfigurations"
+
+// filePrefix marks the run configuration files written by pocket. Stale
+// files with the prefix are removed; other files are left alone.
+const filePrefix = "pocket_"
+
+// Config configures the jetbrains task.
+type Config struct {
+	// Folder groups the run configurations in the IDE.
+	// Default: "pocket".
+	Folder string
+}
+
+// File is a rendered run configuration.
+type File struct {
+	Name    string // file name in .idea/runConfigurations
+	Content []byte
+}
+
+type component struct {
+	XMLName       xml.Name      `xml:"component"`
+	Name          string        `xml:"name,attr"`
+	Configuration configuration `xml:"configuration"`
+}
+
+type configuration struct {
+	Default    bool     `xml:"default,attr"`
+	Name       string   `xml:"name,attr"`
+	Type       string   `xml:"type,attr"`
+	FolderName string   `xml:"folderName,attr,omitempty"`
+	Options    []option `xml:"option"`
+	Envs       struct{} `xml:"envs"`
+	Method     method   `xml:"method"`
+}
+
+type option struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type method struct {
+	V string `xml:"v,attr"`
+}
+
+// Task creates the jetbrains task. It writes a Shell Script run
+// configuration per task listed by ./pok -h, plus one running ./pok, to
+// .idea/runConfigurations. Configurations of removed tasks are deleted.
+// The configurations run the posix shim, so on Windows they need a shell
+// such as Git Bash.
+//
+// Example usage in .pocket/config.go:
+//
+//	var Config = pocket.Config{
+//	    Generate: []pocket.Runnable{
+//	        jetbrains.Task(jetbrains.Config{}),
+//	    },
+//	}
+func Task(cfg Config) *pocket.TaskDef {
+	return pocket.Task("jetbrains", "generate JetBrains run configurations",
+		pocket.Do(func(ctx context.Context) error {
+			plan := pocket.GetConfigPlan(ctx)
+			if plan == nil || plan.Config == nil {
+				return fmt.Errorf("jetbrains: config not available")
+			}
+			files, err := Generate(plan.Config.WithDefaults().Shim.Name, plan.VisibleTasks("."), cfg)
+			if err != nil {
+				return err
+			}
+			dir := pocket.FromGitRoot(filepath.FromSlash(runConfigDir))
+			if err := writeFiles(dir, files); err != nil {
+				return err
+			}
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  Generated %d run configurations in %s\n", len(files), runConfigDir)
+			}
+			return nil
+		}),
+	)
+}
+
+// Generate renders the run configurations for tasks, run through the shim
+// shimName.
+func Generate(shimName string, tasks []*pocket.TaskDef, cfg Config) ([]File, error) {
+	folder := cfg.Folder
+	if folder == "" {
+		folder = "pocket"
+	}
+	files := make([]File, 0, len(tasks)+1)
+	add := func(name, args string) error {
+		content, err := render(shimName, name, args, folder)
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Name: fileName(name), Content: content})
+		return nil
+	}
+	if err := add(shimName, ""); err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		if err := add(shimName+" "+t.Name(), t.Name()); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// fileName returns the file name for the run configuration name, e.g.
+// "pocket_pok_go_test.xml" for "pok go-test".
+func fileName(name string) string {
+	return filePrefix + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name) + ".xml"
+}
+
+func render(shimName, name, args, folder string) ([]byte, error) {
+	c := component{
+		Name: "ProjectRunConfigurationManager",
+		Configuration: configuration{
+			Name:       name,
+			Type:       "ShConfigurationType",
+			FolderName: folder,
+			Options: []option{
+				{"SCRIPT_TEXT", ""},
+				{"INDEPENDENT_SCRIPT_PATH", "true"},
+				{"SCRIPT_PATH", "$PROJECT_DIR$/" + shimName},
+				{"SCRIPT_OPTIONS", args},
+				{"INDEPENDENT_SCRIPT_WORKING_DIRECTORY", "true"},
+				{"SCRIPT_WORKING_DIRECTORY", "$PROJECT_DIR$"},
+				{"INDEPENDENT_INTERPRETER_PATH", "true"},
+				{"INTERPRETER_PATH", ""},
+				{"INTERPRETER_OPTIONS", ""},
+				{"EXECUTE_IN_TERMINAL", "true"},
+				{"EXECUTE_SCRIPT_FILE", "true"},
+			},
+			Method: method{V: "2"},
+		},
+	}
+	out, err := xml.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// writeFiles writes files to dir and removes stale pocket files from it.
+func writeFiles(dir string, files []File) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	keep := make(map[string]bool, len(files))
+	for _, f := range files {
+		keep[f.Name] = true
+		if err := os.WriteFile(filepath.Join(dir, f.Name), f.Content, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", f.Name, err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), filePrefix) && !keep[e.Name()] {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: MIT
+
+package jetbrains
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+func TestGenerate(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	files, err := Generate("pok", []*pocket.TaskDef{pocket.Task("go-test", "run Go tests", noop)}, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	if want := []string{"pocket_pok.xml", "pocket_pok_go_test.xml"}; !slices.Equal(names, want) {
+		t.Fatalf("files = %q, want %q", names, want)
+	}
+
+	want := `<component name="ProjectRunConfigurationManager">
+  <configuration default="false" name="pok go-test" type="ShConfigurationType" folderName="pocket">
+    <option name="SCRIPT_TEXT" value=""></option>
+    <option name="INDEPENDENT_SCRIPT_PATH" value="true"></option>
+    <option name="SCRIPT_PATH" value="$PROJECT_DIR$/pok"></option>
+    <option name="SCRIPT_OPTIONS" value="go-test"></option>
+    <option name="INDEPENDENT_SCRIPT_WORKING_DIRECTORY" value="true"></option>
+    <option name="SCRIPT_WORKING_DIRECTORY" value="$PROJECT_DIR$"></option>
+    <option name="INDEPENDENT_INTERPRETER_PATH" value="true"></option>
+    <option name="INTERPRETER_PATH" value=""></option>
+    <option name="INTERPRETER_OPTIONS" value=""></option>
+    <option name="EXECUTE_IN_TERMINAL" value="true"></option>
+    <option name="EXECUTE_SCRIPT_FILE" value="true"></option>
+    <envs></envs>
+    <method v="2"></method>
+  </configuration>
+</component>
+`
+	if got := string(files[1].Content); got != want {
+		t.Errorf("content =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"pocket_pok_removed.xml", "custom.xml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writeFiles(dir, []File{{Name: "pocket_pok.xml", Content: []byte("<component/>\n")}}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"custom.xml", "pocket_pok.xml"}; !slices.Equal(names, want) {
+		t.Errorf("files = %q, want %q", names, want)
+	}
+}
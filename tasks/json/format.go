@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: MIT
+
+package json
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// FormatOptions configures the json-format task.
+type FormatOptions struct {
+	Check   bool   `arg:"check"   usage:"check only, don't write"`
+	Sort    bool   `arg:"sort"    usage:"sort object keys"`
+	Indent  int    `arg:"indent"  usage:"spaces per indentation level (default: 2)"`
+	Exclude string `arg:"exclude" usage:"comma-separated globs of files to skip, relative to the path (lockfiles are always skipped)"`
+}
+
+// Format formats JSON and JSONC files such as package.json, tsconfig.json
+// and renovate.json: one member or element per line, comments kept, and
+// object keys optionally sorted with -sort. Lockfiles are skipped, see
+// DefaultExclude.
+var Format = pocket.Task("json-format", "format JSON files",
+	formatCmd(),
+	pocket.Opts(FormatOptions{}),
+	pocket.WithInputs(Inputs...),
+)
+
+func formatCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[FormatOptions](ctx)
+		opts.Check = !pocket.Fix(ctx, !opts.Check)
+		if opts.Indent < 0 {
+			return fmt.Errorf("invalid -indent %d", opts.Indent)
+		}
+		s := style{indent: strings.Repeat(" ", cmp.Or(opts.Indent, 2)), sortKeys: opts.Sort}
+
+		exclude := slices.Clone(DefaultExclude)
+		for glob := range strings.SplitSeq(opts.Exclude, ",") {
+			if glob = strings.TrimSpace(glob); glob != "" {
+				exclude = append(exclude, glob)
+			}
+		}
+
+		root := pocket.FromGitRoot(pocket.Path(ctx))
+		files, err := jsonFiles(root, exclude)
+		if err != nil {
+			return err
+		}
+
+		var unformatted, invalid []string
+		for _, file := range files {
+			path := filepath.Join(root, filepath.FromSlash(file))
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			formatted, err := format(data, s)
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s:%v", file, err))
+				continue
+			}
+			if string(formatted) == string(data) {
+				continue
+			}
+			if opts.Check {
+				unformatted = append(unformatted, file)
+				continue
+			}
+			if err := os.WriteFile(path, formatted, 0o644); err != nil {
+				return fmt.Errorf("write %s: %w", file, err)
+			}
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  Formatted %s\n", file)
+			}
+		}
+
+		for _, msg := range invalid {
+			pocket.Printf(ctx, "  invalid JSON: %s\n", msg)
+		}
+		for _, file := range unformatted {
+			pocket.Printf(ctx, "  not formatted: %s\n", file)
+		}
+		switch {
+		case len(invalid) > 0:
+			return fmt.Errorf("%d invalid JSON file(s)", len(invalid))
+		case len(unformatted) > 0:
+			return fmt.Errorf("%d JSON file(s) not formatted (run without -check to format)", len(unformatted))
+		}
+		return nil
+	})
+}
+
+// jsonFiles returns the .json and .jsonc files below root that match none
+// of the exclude globs, as slash-separated paths relative to root. The .git,
+// .pocket, node_modules and vendor directories are skipped.
+func jsonFiles(root string, exclude []string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", ".pocket", "node_modules", "vendor":
+				if path != root {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".json" && ext != ".jsonc" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, glob := range exclude {
+			if pocket.MatchGlob(glob, rel) {
+				return nil
+			}
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find JSON files: %w", err)
+	}
+	return files, nil
+}
@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: MIT
+
+package json
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		sort bool
+		want string
+	}{
+		{
+			name: "indents and keeps scalars as written",
+			in:   `{"name":"app","version":1.0e3,"private":true,"files":["a","b"],"empty":{},"none":[]}`,
+			want: `{
+  "name": "app",
+  "version": 1.0e3,
+  "private": true,
+  "files": [
+    "a",
+    "b"
+  ],
+  "empty": {},
+  "none": []
+}
+`,
+		},
+		{
+			name: "keeps comments and removes trailing commas",
+			in: `// tsconfig
+{
+  // compiler
+  "compilerOptions": {"strict": true, /* why */ "target": "es2022",}, // options
+  "include": ["src"],
+  // end
+}
+`,
+			want: `// tsconfig
+{
+  // compiler
+  "compilerOptions": {
+    "strict": true, /* why */
+    "target": "es2022"
+  }, // options
+  "include": [
+    "src"
+  ]
+  // end
+}
+`,
+		},
+		{
+			name: "sorts keys with their comments",
+			in: `{
+  "version": "1.0.0",
+  // the name
+  "name": "app",
+  "scripts": {"test": "x", "build": "y"},
+  "list": [3, 1]
+}`,
+			sort: true,
+			want: `{
+  "list": [
+    3,
+    1
+  ],
+  // the name
+  "name": "app",
+  "scripts": {
+    "build": "y",
+    "test": "x"
+  },
+  "version": "1.0.0"
+}
+`,
+		},
+		{
+			name: "top-level scalar",
+			in:   "\uFEFF \"x\"",
+			want: "\"x\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := format([]byte(tt.in), style{indent: "  ", sortKeys: tt.sort})
+			if err != nil {
+				t.Fatalf("format() failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("format() =\n%s\nwant:\n%s", got, tt.want)
+			}
+			again, err := format(got, style{indent: "  ", sortKeys: tt.sort})
+			if err != nil || string(again) != string(got) {
+				t.Errorf("format() is not idempotent: %v\n%s", err, again)
+			}
+		})
+	}
+}
+
+func TestFormat_Errors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{"a": 1`, `1:8: unexpected end of file, want ',' or '}'`},
+		{"{\n  \"a\" 1}", `2:7: unexpected "1", want ':'`},
+		{`{"a": tru}`, `1:7: invalid value "tru"`},
+		{`{a: 1}`, `1:2: invalid value "a"`},
+		{`["a`, `1:2: unterminated string`},
+		{`[1] 2`, `1:5: unexpected "2" after top-level value`},
+		{`{"a": 1 /* open`, `1:9: unterminated comment`},
+		{``, `1:1: unexpected end of file, want a value`},
+	}
+	for _, tt := range tests {
+		_, err := format([]byte(tt.in), style{indent: "  "})
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("format(%q) error = %v, want %q", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestJSONFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{
+		"package.json",
+		"package-lock.json",
+		"tsconfig.jsonc",
+		".github/renovate.json",
+		"web/package-lock.json",
+		"web/testdata/bad.json",
+		"node_modules/x/package.json",
+		".pocket/state.json",
+		"README.md",
+	} {
+		writeFile(t, filepath.Join(root, f), "{}")
+	}
+
+	got, err := jsonFiles(root, append(slices.Clone(DefaultExclude), "**/testdata/**"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".github/renovate.json", "package.json", "tsconfig.jsonc"}
+	if !slices.Equal(got, want) {
+		t.Errorf("jsonFiles() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatTask(t *testing.T) {
+	root := t.TempDir()
+	restore := gitroot.Override(root)
+	defer restore()
+	writeFile(t, filepath.Join(root, "a.json"), `{"b":1,"a":2}`)
+	writeFile(t, filepath.Join(root, "ok.json"), "{\n  \"a\": 1\n}\n")
+	writeFile(t, filepath.Join(root, "package-lock.json"), `{"x":1}`)
+
+	run := func(opts FormatOptions) (string, error) {
+		var out strings.Builder
+		ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{
+			Output: &pocket.Output{Stdout: &out, Stderr: &out},
+		})
+		err := pocket.WithOpts(Format, opts).Run(ctx)
+		return out.String(), err
+	}
+
+	out, err := run(FormatOptions{Check: true})
+	if err == nil || !strings.Contains(out, "not formatted: a.json") || strings.Contains(out, "ok.json") {
+		t.Fatalf("check: err = %v, output:\n%s", err, out)
+	}
+
+	// ./pok -fix=false only checks.
+	t.Setenv("POK_FIX", "false")
+	if out, err := run(FormatOptions{}); err == nil || !strings.Contains(out, "not formatted: a.json") {
+		t.Fatalf("-fix=false: err = %v, output:\n%s", err, out)
+	}
+	t.Setenv("POK_FIX", "")
+
+	if _, err := run(FormatOptions{Sort: true}); err != nil {
+		t.Fatalf("format: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "a.json"))
+	if want := "{\n  \"a\": 2,\n  \"b\": 1\n}\n"; string(data) != want {
+		t.Errorf("a.json = %q, want %q", data, want)
+	}
+	data, _ = os.ReadFile(filepath.Join(root, "package-lock.json"))
+	if string(data) != `{"x":1}` {
+		t.Errorf("lockfile was formatted: %q", data)
+	}
+
+	if _, err := run(FormatOptions{Check: true, Sort: true}); err != nil {
+		t.Errorf("check after format: %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,377 @@
+// SPDX-License-Identifier: MIT
+
+package json
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// style configures how JSON is formatted.
+type style struct {
+	indent   string // one level of indentation
+	sortKeys bool   // sort object members by key
+}
+
+// format formats JSON or JSONC (JSON with comments and trailing commas).
+// Each object member and array element is put on its own line, the way
+// JSON.stringify indents, and comments are kept next to the member they
+// precede or follow. Strings and numbers are kept as written. Trailing
+// commas are removed.
+func format(data []byte, s style) ([]byte, error) {
+	src := strings.TrimPrefix(string(data), "\uFEFF") // byte order mark
+	p := &parser{lex: lexer{src: src, line: 1, col: 1}}
+	doc, err := p.document()
+	if err != nil {
+		return nil, err
+	}
+	if s.sortKeys {
+		sortMembers(doc.value)
+	}
+	var b strings.Builder
+	for _, c := range doc.before {
+		b.WriteString(c.text + "\n")
+	}
+	writeValue(&b, doc.value, "", s.indent)
+	for _, c := range doc.after {
+		if c.newlineBefore {
+			b.WriteString("\n" + c.text)
+		} else {
+			b.WriteString(" " + c.text)
+		}
+	}
+	b.WriteString("\n")
+	return []byte(b.String()), nil
+}
+
+// node is a JSON value: an object, an array or a scalar.
+type node struct {
+	kind    byte      // '{', '[' or 0 for scalars
+	raw     string    // text of a scalar, as written
+	members []*member // object members or array elements
+	end     []comment // comments before the closing bracket
+}
+
+// member is an object member or an array element (without key).
+type member struct {
+	before []comment // comments on the lines before the member
+	key    string    // quoted key, "" for array elements
+	value  *node
+	after  []comment // comments on the same line after the member
+}
+
+// comment is a // or /* */ comment.
+type comment struct {
+	text          string
+	newlineBefore bool // the comment starts a line
+}
+
+// document is a formatted file: a value with the comments around it.
+type document struct {
+	before []comment
+	value  *node
+	after  []comment
+}
+
+// writeValue writes n, with nested lines indented by indent+unit.
+func writeValue(b *strings.Builder, n *node, indent, unit string) {
+	if n.kind == 0 {
+		b.WriteString(n.raw)
+		return
+	}
+	open, closing := "{", "}"
+	if n.kind == '[' {
+		open, closing = "[", "]"
+	}
+	if len(n.members) == 0 && len(n.end) == 0 {
+		b.WriteString(open + closing)
+		return
+	}
+	inner := indent + unit
+	b.WriteString(open + "\n")
+	for i, m := range n.members {
+		for _, c := range m.before {
+			b.WriteString(inner + c.text + "\n")
+		}
+		b.WriteString(inner)
+		if m.key != "" {
+			b.WriteString(m.key + ": ")
+		}
+		writeValue(b, m.value, inner, unit)
+		if i < len(n.members)-1 {
+			b.WriteString(",")
+		}
+		for _, c := range m.after {
+			b.WriteString(" " + c.text)
+		}
+		b.WriteString("\n")
+	}
+	for _, c := range n.end {
+		b.WriteString(inner + c.text + "\n")
+	}
+	b.WriteString(indent + closing)
+}
+
+// sortMembers sorts the members of all objects in n by key. Comments move
+// with their member.
+func sortMembers(n *node) {
+	if n.kind == '{' {
+		slices.SortStableFunc(n.members, func(a, b *member) int {
+			return strings.Compare(unquote(a.key), unquote(b.key))
+		})
+	}
+	for _, m := range n.members {
+		sortMembers(m.value)
+	}
+}
+
+// unquote returns the value of a quoted string, or s if it is invalid.
+func unquote(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return s
+}
+
+// parser parses JSONC into nodes.
+type parser struct {
+	lex     lexer
+	tok     token
+	pending []comment // comments read before tok
+}
+
+// next reads the next token that is not a comment into p.tok, collecting
+// the comments before it in p.pending.
+func (p *parser) next() error {
+	for {
+		tok, err := p.lex.next()
+		if err != nil {
+			return err
+		}
+		if tok.kind != tokComment {
+			p.tok = tok
+			return nil
+		}
+		p.pending = append(p.pending, comment{text: tok.text, newlineBefore: tok.newlineBefore})
+	}
+}
+
+// takePending returns and clears the pending comments.
+func (p *parser) takePending() []comment {
+	c := p.pending
+	p.pending = nil
+	return c
+}
+
+// takeTrailing returns and removes the pending comments on the line of the
+// previous token.
+func (p *parser) takeTrailing() []comment {
+	i := 0
+	for i < len(p.pending) && !p.pending[i].newlineBefore {
+		i++
+	}
+	trailing := p.pending[:i:i]
+	p.pending = p.pending[i:]
+	return trailing
+}
+
+func (p *parser) document() (*document, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	doc := &document{before: p.takePending()}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	doc.value = value
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s after top-level value", p.tok)
+	}
+	doc.after = p.takePending()
+	return doc, nil
+}
+
+// value parses the value starting at p.tok and reads the token after it.
+func (p *parser) value() (*node, error) {
+	switch p.tok.kind {
+	case tokString, tokLiteral:
+		n := &node{raw: p.tok.text}
+		return n, p.next()
+	case tokPunct:
+		if p.tok.text == "{" || p.tok.text == "[" {
+			return p.container(p.tok.text[0])
+		}
+	}
+	return nil, p.errorf("unexpected %s, want a value", p.tok)
+}
+
+// container parses an object or array, with p.tok at its opening bracket.
+func (p *parser) container(kind byte) (*node, error) {
+	closing := "}"
+	if kind == '[' {
+		closing = "]"
+	}
+	n := &node{kind: kind}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	for {
+		if p.tok.kind == tokPunct && p.tok.text == closing {
+			n.end = p.takePending()
+			return n, p.next()
+		}
+		m := &member{before: p.takePending()}
+		if kind == '{' {
+			if p.tok.kind != tokString {
+				return nil, p.errorf("unexpected %s, want an object key", p.tok)
+			}
+			m.key = p.tok.text
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.tok.kind != tokPunct || p.tok.text != ":" {
+				return nil, p.errorf("unexpected %s, want ':'", p.tok)
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			// Comments between the key and the value follow the member.
+			m.after = p.takePending()
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		m.value = value
+		m.after = append(m.after, p.takeTrailing()...)
+		switch {
+		case p.tok.kind == tokPunct && p.tok.text == ",":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			m.after = append(m.after, p.takeTrailing()...)
+		case p.tok.kind == tokPunct && p.tok.text == closing:
+		default:
+			return nil, p.errorf("unexpected %s, want ',' or '%s'", p.tok, closing)
+		}
+		n.members = append(n.members, m)
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%d:%d: %s", p.tok.line, p.tok.col, fmt.Sprintf(format, args...))
+}
+
+// Token kinds.
+const (
+	tokEOF = iota
+	tokPunct
+	tokString
+	tokLiteral
+	tokComment
+)
+
+// token is a lexical token of JSONC.
+type token struct {
+	kind          int
+	text          string
+	line, col     int
+	newlineBefore bool // a newline precedes the token
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of file"
+	}
+	return strconv.Quote(t.text)
+}
+
+// literalRe matches the JSON literals and numbers.
+var literalRe = regexp.MustCompile(`^(true|false|null|-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?)$`)
+
+// lexer splits JSONC into tokens.
+type lexer struct {
+	src       string
+	pos       int
+	line, col int
+}
+
+// advance moves past n bytes.
+func (l *lexer) advance(n int) {
+	for _, r := range l.src[l.pos : l.pos+n] {
+		if r == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+	}
+	l.pos += n
+}
+
+func (l *lexer) errorf(format string, args ...any) error {
+	return fmt.Errorf("%d:%d: %s", l.line, l.col, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) next() (token, error) {
+	newline := false
+	for l.pos < len(l.src) && strings.IndexByte(" \t\r\n", l.src[l.pos]) >= 0 {
+		if l.src[l.pos] == '\n' {
+			newline = true
+		}
+		l.advance(1)
+	}
+	tok := token{line: l.line, col: l.col, newlineBefore: newline}
+	if l.pos >= len(l.src) {
+		tok.kind = tokEOF
+		return tok, nil
+	}
+	rest := l.src[l.pos:]
+	n := 0
+	switch c := rest[0]; {
+	case strings.IndexByte("{}[]:,", c) >= 0:
+		tok.kind, n = tokPunct, 1
+	case c == '"':
+		tok.kind = tokString
+		for n = 1; ; n++ {
+			if n >= len(rest) || rest[n] == '\n' {
+				return tok, l.errorf("unterminated string")
+			}
+			if rest[n] == '\\' {
+				n++
+			} else if rest[n] == '"' {
+				n++
+				break
+			}
+		}
+	case strings.HasPrefix(rest, "//"):
+		tok.kind = tokComment
+		n = strings.IndexByte(rest, '\n')
+		if n < 0 {
+			n = len(rest)
+		}
+		n = len(strings.TrimRight(rest[:n], " \t\r"))
+	case strings.HasPrefix(rest, "/*"):
+		tok.kind = tokComment
+		end := strings.Index(rest[2:], "*/")
+		if end < 0 {
+			return tok, l.errorf("unterminated comment")
+		}
+		n = end + 4
+	default:
+		tok.kind = tokLiteral
+		for n < len(rest) && strings.IndexByte("{}[]:,\"/ \t\r\n", rest[n]) < 0 {
+			n++
+		}
+		if !literalRe.MatchString(rest[:n]) {
+			return tok, l.errorf("invalid value %q", rest[:max(n, 1)])
+		}
+	}
+	tok.text = rest[:n]
+	l.advance(n)
+	return tok, nil
+}
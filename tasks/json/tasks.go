@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+
+// Package json provides JSON and JSONC formatting tasks.
+// This is a "task" package - it orchestrates tools to do work. The
+// formatter is built in, so no tool is installed.
+package json
+
+import (
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Inputs are the files the JSON tasks read, relative to the project
+// directory. See pocket.WithInputs.
+var Inputs = []string{"**/*.json", "**/*.jsonc"}
+
+// DefaultExclude are the files json-format skips in addition to its
+// -exclude option: lockfiles generated by package managers.
+var DefaultExclude = []string{"**/package-lock.json", "**/npm-shrinkwrap.json"}
+
+// Option configures the json task group.
+type Option func(*config)
+
+type config struct {
+	format FormatOptions
+}
+
+// WithFormat sets options for the json-format task.
+func WithFormat(opts FormatOptions) Option {
+	return func(c *config) { c.format = opts }
+}
+
+// Tasks returns a Runnable that executes all JSON tasks.
+// Runs from repository root since JSON files are typically scattered.
+// Use pocket.RunIn(json.Tasks(), pocket.Detect(json.Detect())) to enable path filtering.
+//
+// Example with options:
+//
+//	pocket.RunIn(json.Tasks(
+//	    json.WithFormat(json.FormatOptions{Sort: true, Exclude: "testdata/**"}),
+//	), pocket.Detect(json.Detect()))
+func Tasks(opts ...Option) pocket.Runnable {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	formatTask := Format
+	if cfg.format != (FormatOptions{}) {
+		formatTask = pocket.WithOpts(Format, cfg.format)
+	}
+
+	return pocket.Serial(formatTask)
+}
+
+// Detect returns a detection function for JSON files.
+// Returns repository root since JSON files are typically scattered.
+func Detect() func() []string {
+	return func() []string {
+		return []string{"."}
+	}
+}
@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: MIT
+
+// Package license provides license header tasks.
+// This is a "task" package - it orchestrates tools to do work.
+package license
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// HeaderOptions configures the license-header task.
+type HeaderOptions struct {
+	// Header is the header text without comment markers. Lines are separated
+	// by "\n". {year} matches any year or year range (e.g. 2024-2026) when
+	// checking, and is replaced with the current year when inserting.
+	Header     string `arg:"header"     usage:"header text, {year} matches any year (default: SPDX-License-Identifier: MIT)"`
+	Extensions string `arg:"extensions" usage:"comma-separated file extensions to check (default: .go)"`
+	Exclude    string `arg:"exclude"    usage:"comma-separated regexp patterns of paths to exclude"`
+}
+
+// commentPrefixes maps file extensions to their line comment prefix.
+var commentPrefixes = map[string]string{
+	".go":   "// ",
+	".js":   "// ",
+	".ts":   "// ",
+	".tsx":  "// ",
+	".rs":   "// ",
+	".c":    "// ",
+	".h":    "// ",
+	".java": "// ",
+	".py":   "# ",
+	".sh":   "# ",
+	".rb":   "# ",
+	".yml":  "# ",
+	".yaml": "# ",
+	".toml": "# ",
+	".lua":  "-- ",
+	".sql":  "-- ",
+}
+
+// generatedRe matches the conventional marker for generated files.
+var generatedRe = regexp.MustCompile(`(?m)^(//|#|--) Code generated .* DO NOT EDIT\.$`)
+
+// Header verifies that source files start with a license header.
+// With -fix (see pocket.Fix), the header is inserted into files that lack it
+// (after any shebang line). Generated files are skipped.
+var Header = pocket.Task("license-header", "check or insert license headers",
+	headerCmd(),
+	pocket.Opts(HeaderOptions{}),
+)
+
+func headerCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[HeaderOptions](ctx)
+		fix := pocket.Fix(ctx, false)
+
+		header := opts.Header
+		if header == "" {
+			header = "SPDX-License-Identifier: MIT"
+		}
+		header = strings.ReplaceAll(header, `\n`, "\n")
+
+		extensions := []string{".go"}
+		if opts.Extensions != "" {
+			extensions = strings.Split(opts.Extensions, ",")
+		}
+		var excludes []*regexp.Regexp
+		if opts.Exclude != "" {
+			for _, pattern := range strings.Split(opts.Exclude, ",") {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+				}
+				excludes = append(excludes, re)
+			}
+		}
+
+		root := pocket.FromGitRoot(pocket.Path(ctx))
+		files, err := sourceFiles(root, extensions, excludes)
+		if err != nil {
+			return err
+		}
+
+		var missing []string
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			prefix := commentPrefixes[filepath.Ext(file)]
+			if prefix == "" {
+				prefix = "// "
+			}
+			if generatedRe.Match(data) || HasHeader(data, header, prefix) {
+				continue
+			}
+			rel, _ := filepath.Rel(pocket.GitRoot(), file)
+			if !fix {
+				missing = append(missing, rel)
+				continue
+			}
+			if err := os.WriteFile(file, InsertHeader(data, header, prefix), 0o644); err != nil {
+				return fmt.Errorf("write %s: %w", rel, err)
+			}
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  Added header to %s\n", rel)
+			}
+		}
+
+		if len(missing) > 0 {
+			for _, rel := range missing {
+				pocket.Printf(ctx, "  missing license header: %s\n", rel)
+			}
+			return fmt.Errorf("%d file(s) missing license header (run with -fix to insert)", len(missing))
+		}
+		return nil
+	})
+}
+
+// sourceFiles walks root and returns files with the given extensions.
+// Hidden and vendor directories are skipped.
+func sourceFiles(root string, extensions []string, excludes []*regexp.Regexp) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(pocket.GitRoot(), path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		for _, re := range excludes {
+			if re.MatchString(rel) {
+				return nil
+			}
+		}
+		for _, ext := range extensions {
+			if strings.HasSuffix(d.Name(), strings.TrimSpace(ext)) {
+				files = append(files, path)
+				break
+			}
+		}
+		return nil
+	})
+	return files, err
+}
+
+// commentHeader renders the header with a comment prefix on each line.
+func commentHeader(header, prefix string) []byte {
+	var buf bytes.Buffer
+	for _, line := range strings.Split(header, "\n") {
+		buf.WriteString(strings.TrimRight(prefix+line, " "))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// yearPattern matches a year or a year range such as 2024-2026.
+const yearPattern = `\d{4}(?:-\d{4})?`
+
+// HasHeader reports whether data starts with the commented header,
+// ignoring a leading shebang line. A {year} placeholder in header matches
+// any year or year range, so existing headers don't go stale.
+func HasHeader(data []byte, header, prefix string) bool {
+	_, rest := splitShebang(data)
+	want := commentHeader(header, prefix)
+	if !strings.Contains(header, "{year}") {
+		return bytes.HasPrefix(rest, want)
+	}
+	pattern := strings.ReplaceAll(regexp.QuoteMeta(string(want)), regexp.QuoteMeta("{year}"), yearPattern)
+	return regexp.MustCompile(`\A` + pattern).Match(rest)
+}
+
+// InsertHeader returns data with the commented header inserted at the top,
+// after a shebang line if present. A {year} placeholder in header is
+// replaced with the current year.
+func InsertHeader(data []byte, header, prefix string) []byte {
+	header = strings.ReplaceAll(header, "{year}", strconv.Itoa(time.Now().Year()))
+	shebang, rest := splitShebang(data)
+	var buf bytes.Buffer
+	buf.Write(shebang)
+	buf.Write(commentHeader(header, prefix))
+	buf.WriteByte('\n')
+	buf.Write(rest)
+	return buf.Bytes()
+}
+
+func splitShebang(data []byte) (shebang, rest []byte) {
+	if !bytes.HasPrefix(data, []byte("#!")) {
+		return nil, data
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return data[:i+1], data[i+1:]
+	}
+	return data, nil
+}
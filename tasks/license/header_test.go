@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: MIT
+
+package license
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestInsertHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		prefix string
+		want   string
+	}{
+		{
+			name:   "go file",
+			input:  "package main\n",
+			prefix: "// ",
+			want:   "// SPDX-License-Identifier: MIT\n\npackage main\n",
+		},
+		{
+			name:   "shebang is kept first",
+			input:  "#!/bin/sh\necho hi\n",
+			prefix: "# ",
+			want:   "#!/bin/sh\n# SPDX-License-Identifier: MIT\n\necho hi\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := InsertHeader([]byte(tt.input), "SPDX-License-Identifier: MIT", tt.prefix)
+			if string(got) != tt.want {
+				t.Errorf("InsertHeader() = %q, want %q", got, tt.want)
+			}
+			if !HasHeader(got, "SPDX-License-Identifier: MIT", tt.prefix) {
+				t.Error("expected HasHeader() to be true after insertion")
+			}
+		})
+	}
+}
+
+func TestHasHeader_MultiLine(t *testing.T) {
+	header := "Copyright 2025 Example\n\nSPDX-License-Identifier: MIT"
+	data := []byte("// Copyright 2025 Example\n//\n// SPDX-License-Identifier: MIT\n\npackage main\n")
+	if !HasHeader(data, header, "// ") {
+		t.Error("expected multi-line header to be detected")
+	}
+	if HasHeader([]byte("package main\n"), header, "// ") {
+		t.Error("expected missing header not to be detected")
+	}
+}
+
+func TestHasHeader_Year(t *testing.T) {
+	header := "Copyright {year} Example"
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{"// Copyright 2019 Example\n\npackage main\n", true},
+		{"// Copyright 2019-2026 Example\n\npackage main\n", true},
+		{"// Copyright {year} Example\n\npackage main\n", false},
+		{"// Copyright 19 Example\n\npackage main\n", false},
+		{"// Copyright 2019 Other\n\npackage main\n", false},
+	}
+	for _, tt := range tests {
+		if got := HasHeader([]byte(tt.data), header, "// "); got != tt.want {
+			t.Errorf("HasHeader(%q) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestInsertHeader_Year(t *testing.T) {
+	got := InsertHeader([]byte("package main\n"), "Copyright {year} Example", "// ")
+	want := fmt.Sprintf("// Copyright %d Example\n\npackage main\n", time.Now().Year())
+	if string(got) != want {
+		t.Errorf("InsertHeader() = %q, want %q", got, want)
+	}
+	if !HasHeader(got, "Copyright {year} Example", "// ") {
+		t.Error("expected HasHeader() to match the inserted year")
+	}
+}
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package lua
 
 import (
@@ -16,6 +18,7 @@ type FormatOptions struct {
 var Format = pocket.Task("lua-format", "format Lua files",
 	pocket.Serial(stylua.Install, formatCmd()),
 	pocket.Opts(FormatOptions{}),
+	pocket.WithInputs("**/*.lua"),
 )
 
 func formatCmd() pocket.Runnable {
@@ -33,6 +36,9 @@ func formatCmd() pocket.Runnable {
 		absDir := pocket.FromGitRoot(pocket.Path(ctx))
 
 		args := []string{}
+		if !pocket.Fix(ctx, true) {
+			args = append(args, "--check")
+		}
 		if pocket.Verbose(ctx) {
 			args = append(args, "--verbose")
 		}
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package lua provides Lua-related build tasks.
 package lua
 
@@ -10,6 +12,7 @@ type Option func(*config)
 
 type config struct {
 	format FormatOptions
+	env    map[string]string
 }
 
 // WithFormat sets options for the lua-format task.
@@ -17,6 +20,12 @@ func WithFormat(opts FormatOptions) Option {
 	return func(c *config) { c.format = opts }
 }
 
+// WithEnv sets extra environment variables for the commands of the
+// lua-format task. See pocket.Env.
+func WithEnv(env map[string]string) Option {
+	return func(c *config) { c.env = env }
+}
+
 // Tasks returns a Runnable that executes all Lua tasks.
 // Runs from repository root since Lua files are typically scattered.
 // Use pocket.RunIn(lua.Tasks(), pocket.Detect(lua.Detect())) to enable path filtering.
@@ -39,7 +48,7 @@ func Tasks(opts ...Option) pocket.Runnable {
 		formatTask = pocket.WithOpts(Format, cfg.format)
 	}
 
-	return pocket.Serial(formatTask)
+	return pocket.Env(cfg.env, pocket.Serial(formatTask))
 }
 
 // Detect returns a detection function that finds Lua projects.
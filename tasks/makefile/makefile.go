@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: MIT
+
+// Package makefile generates a thin Makefile that delegates to the pocket
+// shim, for developers and IDE integrations that expect "make test".
+// This is a "task" package - it orchestrates tools to do work.
+package makefile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// header starts every generated Makefile. An existing Makefile without it is
+// never overwritten.
+const header = "# Code generated by pocket. DO NOT EDIT.\n"
+
+// Config configures the makefile task.
+type Config struct {
+	// Aliases adds targets that run a task under another name,
+	// e.g. {"test": "go-test"}. Aliases never replace task targets.
+	Aliases map[string]string
+}
+
+// Task creates the makefile task. It writes a Makefile at the git root with
+// a target per task listed by ./pok -h, plus "all" (the default goal, runs
+// ./pok) and "help". Extra arguments are passed with ARGS:
+//
+//	make go-test ARGS="-skip-race"
+//
+// Add it to Config.Generate so the Makefile never drifts from the config.
+// A Makefile that was not generated by pocket is left alone and the task fails.
+//
+// Example usage in .pocket/config.go:
+//
+//	var Config = pocket.Config{
+//	    Generate: []pocket.Runnable{
+//	        makefile.Task(makefile.Config{Aliases: map[string]string{"test": "go-test"}}),
+//	    },
+//	}
+func Task(cfg Config) *pocket.TaskDef {
+	return pocket.Task("makefile", "generate Makefile delegating to pocket tasks",
+		pocket.Do(func(ctx context.Context) error {
+			plan := pocket.GetConfigPlan(ctx)
+			if plan == nil || plan.Config == nil {
+				return fmt.Errorf("makefile: config not available")
+			}
+			shimName := plan.Config.WithDefaults().Shim.Name
+			content := Generate(shimName, plan.VisibleTasks("."), cfg)
+			dest := pocket.FromGitRoot("Makefile")
+			if err := write(dest, content); err != nil {
+				return err
+			}
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  Generated %s\n", dest)
+			}
+			return nil
+		}),
+	)
+}
+
+// write writes content to dest, unless dest exists and was not generated
+// by pocket.
+func write(dest string, content []byte) error {
+	existing, err := os.ReadFile(dest)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read %s: %w", dest, err)
+	}
+	if err == nil && !bytes.HasPrefix(existing, []byte(header)) {
+		return fmt.Errorf("makefile: %s was not generated by pocket; remove it to generate one", dest)
+	}
+	if err := os.WriteFile(dest, content, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", dest, err)
+	}
+	return nil
+}
+
+// Generate renders the Makefile for tasks, run through the shim shimName.
+// Colons and spaces in task names are escaped in target names. Names that
+// make can't express as a target (containing "#", "$", "%" or "=") are
+// skipped.
+func Generate(shimName string, tasks []*pocket.TaskDef, cfg Config) []byte {
+	shim := "./" + shimName
+	type target struct{ name, usage, command string }
+	targets := []target{
+		{"all", "run all auto-run tasks", shim},
+		{"help", "list pocket tasks", shim + " -h"},
+	}
+	seen := map[string]bool{"all": true, "help": true}
+	for _, t := range tasks {
+		if !seen[t.Name()] {
+			seen[t.Name()] = true
+			targets = append(targets, target{t.Name(), t.Usage(), shim + " " + shellQuote(t.Name()) + " $(ARGS)"})
+		}
+	}
+	aliases := make([]string, 0, len(cfg.Aliases))
+	for alias := range cfg.Aliases {
+		aliases = append(aliases, alias)
+	}
+	slices.Sort(aliases)
+	for _, alias := range aliases {
+		if !seen[alias] {
+			seen[alias] = true
+			task := cfg.Aliases[alias]
+			targets = append(targets, target{alias, "alias for " + task, shim + " " + shellQuote(task) + " $(ARGS)"})
+		}
+	}
+
+	targets = slices.DeleteFunc(targets, func(t target) bool {
+		return strings.ContainsAny(t.name, "#$%=")
+	})
+
+	var b strings.Builder
+	b.WriteString(header)
+	fmt.Fprintf(&b, "# Targets delegate to %s; run %s generate to regenerate.\n\n", shim, shim)
+	b.WriteString(".DEFAULT_GOAL := all\n")
+	b.WriteString(".PHONY:")
+	for _, t := range targets {
+		b.WriteString(" " + targetName(t.name))
+	}
+	b.WriteString("\n")
+	for _, t := range targets {
+		usage := strings.Join(strings.Fields(t.usage), " ")
+		fmt.Fprintf(&b, "\n# %s\n%s:\n\t@%s\n", usage, targetName(t.name), t.command)
+	}
+	return []byte(b.String())
+}
+
+// targetName escapes the colons and whitespace in a task name for use as a
+// make target, e.g. "tool-test:buf" becomes "tool-test\:buf".
+func targetName(name string) string {
+	return strings.NewReplacer(":", `\:`, " ", `\ `).Replace(name)
+}
+
+// shellQuote quotes a task name for the recipe's shell if it contains spaces.
+func shellQuote(name string) string {
+	if !strings.ContainsAny(name, " \t") {
+		return name
+	}
+	return "'" + strings.ReplaceAll(name, "'", `'\''`) + "'"
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: MIT
+
+package makefile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+func TestGenerate(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	tasks := []*pocket.TaskDef{
+		pocket.Task("go-test", "run Go tests", noop),
+		pocket.Task("help", "shadowed by the help target", noop),
+	}
+	cfg := Config{Aliases: map[string]string{"test": "go-test", "go-test": "ignored", "lint": "go-lint"}}
+
+	want := `# Code generated by pocket. DO NOT EDIT.
+# Targets delegate to ./pok; run ./pok generate to regenerate.
+
+.DEFAULT_GOAL := all
+.PHONY: all help go-test lint test
+
+# run all auto-run tasks
+all:
+	@./pok
+
+# list pocket tasks
+help:
+	@./pok -h
+
+# run Go tests
+go-test:
+	@./pok go-test $(ARGS)
+
+# alias for go-lint
+lint:
+	@./pok go-lint $(ARGS)
+
+# alias for go-test
+test:
+	@./pok go-test $(ARGS)
+`
+	if got := string(Generate("pok", tasks, cfg)); got != want {
+		t.Errorf("Generate() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGenerate_EscapesTargetNames(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	tasks := []*pocket.TaskDef{
+		pocket.Task("tool-test:buf", "test the buf tool\n\nruns buf --version", noop),
+		pocket.Task("a=b", "not a target", noop),
+	}
+	got := string(Generate("pok", tasks, Config{}))
+
+	for _, want := range []string{
+		".PHONY: all help tool-test\\:buf\n",
+		"\n# test the buf tool runs buf --version\ntool-test\\:buf:\n\t@./pok tool-test:buf $(ARGS)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in Makefile, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "a=b") {
+		t.Errorf("expected a=b to be skipped, got:\n%s", got)
+	}
+}
+
+func TestWrite_KeepsHandwrittenMakefile(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "Makefile")
+	if err := os.WriteFile(dest, []byte("build:\n\tgo build ./...\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := write(dest, []byte(header)); err == nil || !strings.Contains(err.Error(), "not generated by pocket") {
+		t.Fatalf("expected an error for a handwritten Makefile, got %v", err)
+	}
+	data, _ := os.ReadFile(dest)
+	if string(data) != "build:\n\tgo build ./...\n" {
+		t.Errorf("handwritten Makefile was overwritten: %q", data)
+	}
+
+	if err := os.WriteFile(dest, []byte(header+"old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := write(dest, []byte(header+"new\n")); err != nil {
+		t.Fatalf("write() failed for a generated Makefile: %v", err)
+	}
+}
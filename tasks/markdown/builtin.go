@@ -0,0 +1,449 @@
+// SPDX-License-Identifier: MIT
+
+package markdown
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// format normalizes a Markdown document without changing how it renders:
+//
+//   - trailing whitespace is removed, except for hard line breaks
+//   - runs of blank lines are collapsed, and the file ends with one newline
+//   - headings are ATX headings ("## Title"), separated by blank lines
+//   - bullet list markers are "-", and thematic breaks are "---"
+//   - top-level code fences are separated by blank lines
+//   - pipe tables are aligned
+//
+// Code blocks, HTML comments and front matter are kept as written. Unlike
+// prettier, prose is not rewrapped and inline markup is not changed.
+func format(data []byte) []byte {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	f := &formatter{}
+
+	i := 0
+	if trimRight(lines[0]) == "---" {
+		for j := 1; j < len(lines); j++ {
+			if t := trimRight(lines[j]); t == "---" || t == "..." {
+				f.out = append(f.out, lines[:j+1]...)
+				f.blank = true
+				i = j + 1
+				break
+			}
+		}
+	}
+
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		text := trimRight(line)
+		indent := indentWidth(line)
+		next := ""
+		if i+1 < len(lines) {
+			next = lines[i+1]
+		}
+
+		if f.fence != "" {
+			f.out = append(f.out, line)
+			if isClosingFence(text, f.fence) {
+				f.fence = ""
+				f.blank = f.blank || f.topFence
+			}
+			continue
+		}
+		if f.comment {
+			f.out = append(f.out, line)
+			f.comment = !strings.Contains(line, "-->")
+			continue
+		}
+
+		if text == "" {
+			f.blank = true
+			f.para = false
+			if f.code {
+				// Keep blank lines inside an indented code block.
+				j := i + 1
+				for j < len(lines) && trimRight(lines[j]) == "" {
+					j++
+				}
+				if j < len(lines) && indentWidth(lines[j]) >= 4 {
+					for range j - i {
+						f.out = append(f.out, "")
+					}
+					f.blank = false
+					i = j - 1
+				}
+			}
+			continue
+		}
+		if f.code {
+			if indent >= 4 {
+				f.out = append(f.out, line)
+				continue
+			}
+			f.code = false
+		}
+		if indent == 0 && f.blank && !isListItem(text) {
+			f.list = false
+		}
+
+		switch {
+		case indent >= 4 && !f.list && !f.para:
+			f.emit(line)
+			f.code = true
+
+		case (indent < 4 || f.list) && fenceMarker(text) != "":
+			f.fence = fenceMarker(text)
+			f.topFence = indent == 0
+			if f.topFence {
+				f.list = false
+				f.blank = true
+			}
+			f.emit(text)
+			f.para = false
+
+		case strings.HasPrefix(text, "<!--") && !strings.Contains(text, "-->"):
+			f.emit(line)
+			f.comment = true
+			f.para = false
+
+		case f.para && setextRe.MatchString(text):
+			// The underline of a heading spanning several lines.
+			f.out = append(f.out, text)
+			f.para = false
+			f.blank = true
+
+		case indent == 0 && atxRe.MatchString(text):
+			f.heading(atxHeading(text))
+
+		case indent == 0 && !f.para && !f.list && isParagraphStart(text) && setextRe.MatchString(trimRight(next)):
+			level := "#"
+			if strings.TrimSpace(next)[0] == '-' {
+				level = "##"
+			}
+			f.heading(level + " " + strings.TrimSpace(text))
+			i++
+
+		case indent == 0 && isThematicBreak(text):
+			f.list = false
+			f.blank = true
+			f.emit("---")
+			f.para = false
+			f.blank = true
+
+		case indent == 0 && !f.list && strings.HasPrefix(text, "|") && isDelimiterRow(trimRight(next)):
+			j := i
+			for j < len(lines) && strings.HasPrefix(trimRight(lines[j]), "|") {
+				j++
+			}
+			for _, row := range alignTable(lines[i:j]) {
+				f.emit(row)
+			}
+			f.para = true
+			i = j - 1
+
+		default:
+			if m := bulletRe.FindStringSubmatch(text); m != nil && !isThematicBreak(text) {
+				text = m[1] + "-" + m[3]
+				f.list = true
+			} else if orderedRe.MatchString(text) {
+				f.list = true
+			}
+			if strings.HasSuffix(line, "  ") && trimRight(next) != "" {
+				text += "  " // hard line break
+			}
+			f.emit(text)
+			f.para = true
+		}
+	}
+
+	if len(f.out) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(f.out, "\n") + "\n")
+}
+
+// formatter is the state of format between lines.
+type formatter struct {
+	out      []string
+	blank    bool   // a blank line goes before the next line
+	para     bool   // the last line was part of a paragraph
+	list     bool   // inside a list
+	code     bool   // inside an indented code block
+	comment  bool   // inside an HTML comment
+	fence    string // the opening marker inside a fenced code block
+	topFence bool   // the fenced code block is not indented
+}
+
+// emit appends a line, after a blank line if one is pending.
+func (f *formatter) emit(line string) {
+	if f.blank && len(f.out) > 0 {
+		f.out = append(f.out, "")
+	}
+	f.blank = false
+	f.out = append(f.out, line)
+}
+
+// heading appends a heading between blank lines.
+func (f *formatter) heading(line string) {
+	f.list = false
+	f.blank = true
+	f.emit(line)
+	f.para = false
+	f.blank = true
+}
+
+var (
+	atxRe     = regexp.MustCompile(`^#{1,6}([ \t].*)?$`)
+	setextRe  = regexp.MustCompile(`^ {0,3}(=+|-+)$`)
+	bulletRe  = regexp.MustCompile(`^(\s*)([-*+])([ \t].*)?$`)
+	orderedRe = regexp.MustCompile(`^\s*\d{1,9}[.)]([ \t]|$)`)
+	cellRe    = regexp.MustCompile(`^:?-+:?$`)
+)
+
+// atxHeading normalizes the spacing of an ATX heading and removes its
+// closing sequence, e.g. "##  Title ##" becomes "## Title".
+func atxHeading(text string) string {
+	level := len(text) - len(strings.TrimLeft(text, "#"))
+	content := strings.TrimSpace(text[level:])
+	closed := strings.TrimRight(content, "#")
+	switch {
+	case closed == "":
+		content = ""
+	case closed != content && (strings.HasSuffix(closed, " ") || strings.HasSuffix(closed, "\t")):
+		content = strings.TrimSpace(closed)
+	}
+	if content == "" {
+		return text[:level]
+	}
+	return text[:level] + " " + content
+}
+
+// isParagraphStart reports whether text, which is not indented, starts a
+// paragraph rather than another block.
+func isParagraphStart(text string) bool {
+	switch text[0] {
+	case '>', '<', '|', '#', '`', '~', '=':
+		return false
+	}
+	return !isListItem(text) && !isThematicBreak(text)
+}
+
+func isListItem(text string) bool {
+	return bulletRe.MatchString(text) || orderedRe.MatchString(text)
+}
+
+// isThematicBreak reports whether text is a line of three or more "-", "*"
+// or "_", optionally separated by spaces.
+func isThematicBreak(text string) bool {
+	s := strings.Join(strings.Fields(text), "")
+	if len(s) < 3 || strings.Trim(s, s[:1]) != "" {
+		return false
+	}
+	return s[0] == '-' || s[0] == '*' || s[0] == '_'
+}
+
+// fenceMarker returns the opening marker of a fenced code block, such as
+// "```" or "~~~~", or "" if text doesn't open one.
+func fenceMarker(text string) string {
+	text = strings.TrimLeft(text, " \t")
+	if !strings.HasPrefix(text, "```") && !strings.HasPrefix(text, "~~~") {
+		return ""
+	}
+	marker := text[:len(text)-len(strings.TrimLeft(text, text[:1]))]
+	if marker[0] == '`' && strings.Contains(text[len(marker):], "`") {
+		return "" // backticks in the info string make it inline code
+	}
+	return marker
+}
+
+// isClosingFence reports whether text closes a fenced code block opened
+// with marker.
+func isClosingFence(text, marker string) bool {
+	s := strings.TrimSpace(text)
+	return len(s) >= len(marker) && strings.Trim(s, marker[:1]) == ""
+}
+
+// isDelimiterRow reports whether text is the row below a table header,
+// such as "| --- | :-: |".
+func isDelimiterRow(text string) bool {
+	if !strings.Contains(text, "-") {
+		return false
+	}
+	for _, cell := range splitRow(text) {
+		if !cellRe.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitRow returns the trimmed cells of a table row. Escaped pipes don't
+// separate cells.
+func splitRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+	var cells []string
+	start := 0
+	for i := 0; i < len(row); i++ {
+		switch row[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, strings.TrimSpace(row[start:i]))
+			start = i + 1
+		}
+	}
+	return append(cells, strings.TrimSpace(row[start:]))
+}
+
+// alignTable pads the cells of a table so that its columns line up. rows[1]
+// is the delimiter row. Tables with a header and delimiter row of different
+// widths are not tables, and are returned as is.
+func alignTable(rows []string) []string {
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = splitRow(row)
+	}
+	if len(cells[0]) != len(cells[1]) {
+		out := make([]string, len(rows))
+		for i, row := range rows {
+			out[i] = trimRight(row)
+		}
+		return out
+	}
+
+	align := make([]byte, len(cells[1]))
+	for i, cell := range cells[1] {
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			align[i] = 'c'
+		case right:
+			align[i] = 'r'
+		case left:
+			align[i] = 'l'
+		}
+	}
+	var widths []int
+	for i, row := range cells {
+		for j, cell := range row {
+			if j == len(widths) {
+				widths = append(widths, 3)
+			}
+			if i != 1 {
+				widths[j] = max(widths[j], utf8.RuneCountInString(cell))
+			}
+		}
+	}
+
+	out := make([]string, len(rows))
+	for i, row := range cells {
+		for len(row) < len(align) {
+			row = append(row, "")
+		}
+		padded := make([]string, len(row))
+		for j, cell := range row {
+			a := byte(0)
+			if j < len(align) {
+				a = align[j]
+			}
+			if i == 1 {
+				padded[j] = delimiter(a, widths[j])
+			} else {
+				padded[j] = pad(cell, a, widths[j])
+			}
+		}
+		out[i] = "| " + strings.Join(padded, " | ") + " |"
+	}
+	return out
+}
+
+// delimiter returns a delimiter row cell of width characters for the
+// alignment: 'l', 'r', 'c' or none.
+func delimiter(align byte, width int) string {
+	switch align {
+	case 'l':
+		return ":" + strings.Repeat("-", width-1)
+	case 'r':
+		return strings.Repeat("-", width-1) + ":"
+	case 'c':
+		return ":" + strings.Repeat("-", width-2) + ":"
+	}
+	return strings.Repeat("-", width)
+}
+
+// pad pads a cell to width characters for the alignment of its column.
+func pad(cell string, align byte, width int) string {
+	n := width - utf8.RuneCountInString(cell)
+	switch align {
+	case 'r':
+		return strings.Repeat(" ", n) + cell
+	case 'c':
+		return strings.Repeat(" ", n/2) + cell + strings.Repeat(" ", n-n/2)
+	}
+	return cell + strings.Repeat(" ", n)
+}
+
+// indentWidth returns the indentation of line in columns, with tab stops
+// every four columns.
+func indentWidth(line string) int {
+	n := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += 4 - n%4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func trimRight(s string) string {
+	return strings.TrimRight(s, " \t")
+}
+
+// markdownFiles returns the .md files below root as slash-separated paths
+// relative to root. Like the default .prettierignore, hidden files and
+// directories are skipped, and so are node_modules and vendor.
+func markdownFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") || filepath.Ext(name) != ".md" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find Markdown files: %w", err)
+	}
+	return files, nil
+}
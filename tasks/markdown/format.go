@@ -1,48 +1,120 @@
+// SPDX-License-Identifier: MIT
+
 package markdown
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/fredrikaverpil/pocket"
 	"github.com/fredrikaverpil/pocket/tools/prettier"
 )
 
+// Formatting engines for the md-format task, see FormatOptions.Engine.
+const (
+	EnginePrettier = "prettier"
+	EngineBuiltin  = "builtin"
+)
+
 // FormatOptions configures markdown formatting.
 type FormatOptions struct {
-	Check bool `arg:"check" usage:"check only, don't write"`
+	Check  bool   `arg:"check"  usage:"check only, don't write"`
+	Engine string `arg:"engine" usage:"formatter to use: prettier or builtin (default: prettier)"`
 }
 
-// Format formats Markdown files using prettier.
+// Format formats Markdown files using prettier, or with the built-in
+// formatter when Engine is EngineBuiltin, which needs no Node.js runtime.
 var Format = pocket.Task("md-format", "format Markdown files",
-	pocket.Serial(prettier.Install, formatCmd()),
+	pocket.Serial(pocket.OnlyIf(usePrettier, prettier.Install), formatCmd()),
 	pocket.Opts(FormatOptions{}),
+	pocket.WithInputs("**/*.md"),
+	pocket.WithExample("./pok md-format -engine=builtin", "format without installing prettier"),
 )
 
+// usePrettier reports whether md-format runs prettier, so that it is only
+// installed when needed.
+func usePrettier(ctx context.Context) bool {
+	engine := pocket.Options[FormatOptions](ctx).Engine
+	return engine == "" || engine == EnginePrettier
+}
+
 func formatCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
 		opts := pocket.Options[FormatOptions](ctx)
+		opts.Check = !pocket.Fix(ctx, !opts.Check)
+		switch opts.Engine {
+		case "", EnginePrettier:
+			return prettierFormat(ctx, opts)
+		case EngineBuiltin:
+			return builtinFormat(ctx, opts)
+		default:
+			return fmt.Errorf("invalid -engine %q, want %s or %s", opts.Engine, EnginePrettier, EngineBuiltin)
+		}
+	})
+}
+
+func prettierFormat(ctx context.Context, opts FormatOptions) error {
+	args := []string{}
+	if opts.Check {
+		args = append(args, "--check")
+	} else {
+		args = append(args, "--write")
+	}
+
+	// Add config if available (use absolute path)
+	if configPath, err := pocket.ConfigPath(ctx, "prettier", prettier.Config); err == nil && configPath != "" {
+		args = append(args, "--config", configPath)
+	}
+
+	// Add ignore file if available (use absolute path)
+	if ignorePath, err := prettier.EnsureIgnoreFile(); err == nil {
+		args = append(args, "--ignore-path", ignorePath)
+	}
 
-		args := []string{}
+	// Use absolute path pattern since prettier runs from install directory
+	pattern := pocket.FromGitRoot("**/*.md")
+	args = append(args, pattern)
+
+	return prettier.Exec(ctx, args...)
+}
+
+func builtinFormat(ctx context.Context, opts FormatOptions) error {
+	root := pocket.FromGitRoot(pocket.Path(ctx))
+	files, err := markdownFiles(root)
+	if err != nil {
+		return err
+	}
+
+	var unformatted []string
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		formatted := format(data)
+		if string(formatted) == string(data) {
+			continue
+		}
 		if opts.Check {
-			args = append(args, "--check")
-		} else {
-			args = append(args, "--write")
+			unformatted = append(unformatted, file)
+			continue
 		}
-
-		// Add config if available (use absolute path)
-		if configPath, err := pocket.ConfigPath(ctx, "prettier", prettier.Config); err == nil && configPath != "" {
-			args = append(args, "--config", configPath)
+		if err := os.WriteFile(path, formatted, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", file, err)
 		}
-
-		// Add ignore file if available (use absolute path)
-		if ignorePath, err := prettier.EnsureIgnoreFile(); err == nil {
-			args = append(args, "--ignore-path", ignorePath)
+		if pocket.Verbose(ctx) {
+			pocket.Printf(ctx, "  Formatted %s\n", file)
 		}
+	}
 
-		// Use absolute path pattern since prettier runs from install directory
-		pattern := pocket.FromGitRoot("**/*.md")
-		args = append(args, pattern)
-
-		return prettier.Exec(ctx, args...)
-	})
+	for _, file := range unformatted {
+		pocket.Printf(ctx, "  not formatted: %s\n", file)
+	}
+	if len(unformatted) > 0 {
+		return fmt.Errorf("%d Markdown file(s) not formatted (run without -check to format)", len(unformatted))
+	}
+	return nil
 }
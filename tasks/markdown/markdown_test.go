@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: MIT
+
+package markdown
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "headings and blank lines",
+			in:   "\n\nTitle\n=====\nIntro   \n\n\n\nSection\n---\n##  Usage ##\ntext  \nbroken line\n\n\n",
+			want: "# Title\n\nIntro\n\n## Section\n\n## Usage\n\ntext  \nbroken line\n",
+		},
+		{
+			name: "lists and thematic breaks",
+			in:   "* one\n  + nested\n* two\n\n***\n\n1. first\n2. second\n- - -\n",
+			want: "- one\n  - nested\n- two\n\n---\n\n1. first\n2. second\n\n---\n",
+		},
+		{
+			name: "code is kept as written",
+			in:   "Run:\n```sh\n* not a list   \n\n\n# not a heading\n```\nDone.\n\n    * indented code\n\n\n    ## still code\n",
+			want: "Run:\n\n```sh\n* not a list   \n\n\n# not a heading\n```\n\nDone.\n\n    * indented code\n\n\n    ## still code\n",
+		},
+		{
+			name: "front matter, comments and multi-line headings",
+			in:   "---\ntitle:  x  \n---\n<!--\n* keep\n-->\nFirst line\nsecond line\n---\n",
+			want: "---\ntitle:  x  \n---\n\n<!--\n* keep\n-->\nFirst line\nsecond line\n---\n",
+		},
+		{
+			name: "tables are aligned",
+			in:   "| Name | Default |Note|\n|:-|-:|:-:|\n| `-engine` | prettier | a \\| b |\n| x |\n",
+			want: "| Name      |  Default |  Note  |\n| :-------- | -------: | :----: |\n| `-engine` | prettier | a \\| b |\n| x         |          |        |\n",
+		},
+		{
+			name: "fences in lists",
+			in:   "* step:\n\n    ```sh\n    * keep\n    ```\n* next\n",
+			want: "- step:\n\n    ```sh\n    * keep\n    ```\n- next\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(format([]byte(tt.in)))
+			if got != tt.want {
+				t.Errorf("format() =\n%s\nwant:\n%s", got, tt.want)
+			}
+			if again := string(format([]byte(got))); again != got {
+				t.Errorf("format() is not idempotent:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestFormatTask_Builtin(t *testing.T) {
+	root := t.TempDir()
+	restore := gitroot.Override(root)
+	defer restore()
+	writeFile(t, filepath.Join(root, "README.md"), "Title\n=====\n* item\n")
+	writeFile(t, filepath.Join(root, "docs/guide.md"), "# Guide\n")
+	writeFile(t, filepath.Join(root, ".github/PULL_REQUEST_TEMPLATE.md"), "* hidden\n")
+	writeFile(t, filepath.Join(root, "node_modules/pkg/README.md"), "* dependency\n")
+
+	run := func(opts FormatOptions) (string, error) {
+		var out strings.Builder
+		ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{
+			Output: &pocket.Output{Stdout: &out, Stderr: &out},
+		})
+		err := pocket.WithOpts(Format, opts).Run(ctx)
+		return out.String(), err
+	}
+
+	out, err := run(FormatOptions{Engine: EngineBuiltin, Check: true})
+	if err == nil || !strings.Contains(out, "not formatted: README.md") || strings.Contains(out, "guide.md") {
+		t.Fatalf("check: err = %v, output:\n%s", err, out)
+	}
+	if out, err := run(FormatOptions{Engine: EngineBuiltin}); err != nil {
+		t.Fatalf("format: %v\n%s", err, out)
+	}
+	if got := readFile(t, filepath.Join(root, "README.md")); got != "# Title\n\n- item\n" {
+		t.Errorf("README.md = %q", got)
+	}
+	for _, skipped := range []string{".github/PULL_REQUEST_TEMPLATE.md", "node_modules/pkg/README.md"} {
+		if got := readFile(t, filepath.Join(root, skipped)); !strings.HasPrefix(got, "* ") {
+			t.Errorf("%s was formatted: %q", skipped, got)
+		}
+	}
+
+	if _, err := run(FormatOptions{Engine: "mdformat"}); err == nil || !strings.Contains(err.Error(), "invalid -engine") {
+		t.Errorf("unknown engine: err = %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package markdown provides Markdown formatting tasks.
 // This is a "task" package - it orchestrates tools to do work.
 package markdown
@@ -6,13 +8,39 @@ import (
 	"github.com/fredrikaverpil/pocket"
 )
 
+// Option configures the markdown task group.
+type Option func(*config)
+
+type config struct {
+	format FormatOptions
+}
+
+// WithFormat sets options for the md-format task.
+func WithFormat(opts FormatOptions) Option {
+	return func(c *config) { c.format = opts }
+}
+
 // Tasks returns all markdown tasks composed as a Runnable.
 // Use this with pocket.RunIn() and pocket.Detect() for auto-detection.
 //
 // Example:
 //
 //	pocket.RunIn(markdown.Tasks(), pocket.Detect(markdown.Detect()))
-func Tasks() pocket.Runnable {
+//
+// Example with the built-in formatter, which needs no Node.js runtime:
+//
+//	pocket.RunIn(markdown.Tasks(
+//	    markdown.WithFormat(markdown.FormatOptions{Engine: markdown.EngineBuiltin}),
+//	), pocket.Detect(markdown.Detect()))
+func Tasks(opts ...Option) pocket.Runnable {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.format != (FormatOptions{}) {
+		return pocket.WithOpts(Format, cfg.format)
+	}
 	return Format
 }
 
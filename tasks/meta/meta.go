@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: MIT
+
+// Package meta drives tasks across sibling git repositories from one pocket
+// config, for platform teams coordinating changes across many services.
+// This is a "task" package - it orchestrates tools to do work.
+package meta
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Repo is a git repository driven by the meta config.
+type Repo struct {
+	// Name identifies the repository in output. Default: the base name of
+	// Path.
+	Name string
+
+	// Path is the checkout directory, relative to the git root, e.g.
+	// "../api". Default: a sibling of the git root named after URL.
+	Path string
+
+	// URL is the clone URL. Repositories without a URL must already be
+	// checked out at Path.
+	URL string
+
+	// Ref is the branch or tag to check out. Default: the default branch
+	// for new clones, and the current branch for existing checkouts.
+	Ref string
+
+	// Tasks run in the repository, with pocket.Path set to Path, e.g.
+	// golang.Tasks() to run this config's Go tasks or Pok("all") to run the
+	// repository's own pocket config. Nil only syncs the repository.
+	Tasks pocket.Runnable
+}
+
+// Config configures the meta tasks.
+type Config struct {
+	// Repos are synced and run in order.
+	Repos []Repo
+
+	// Parallel runs the tasks of the repositories concurrently.
+	Parallel bool
+}
+
+// SyncOptions configures the meta-sync task.
+type SyncOptions struct {
+	NoUpdate bool `arg:"no-update" usage:"only clone missing repositories, don't fetch existing ones"`
+}
+
+// Tasks returns the meta-sync task followed by the tasks of each repository:
+//
+//	var Config = pocket.Config{
+//	    ManualRun: []pocket.Runnable{
+//	        meta.Tasks(meta.Config{
+//	            Repos: []meta.Repo{
+//	                {URL: "git@github.com:org/api.git", Tasks: golang.Tasks()},
+//	                {URL: "git@github.com:org/web.git", Tasks: meta.Pok("all")},
+//	                {Path: "../infra", Tasks: meta.Pok("lint")},
+//	            },
+//	        }),
+//	    },
+//	}
+func Tasks(cfg Config) pocket.Runnable {
+	var repos []any
+	for _, repo := range cfg.Repos {
+		if repo.Tasks != nil {
+			repos = append(repos, pocket.InDir(repo.dir(), repo.Tasks))
+		}
+	}
+	if cfg.Parallel {
+		return pocket.Serial(Sync(cfg), pocket.Parallel(repos...))
+	}
+	return pocket.Serial(append([]any{Sync(cfg)}, repos...)...)
+}
+
+// Sync creates the meta-sync task, which clones the repositories that are
+// missing and fetches and fast-forwards the others.
+func Sync(cfg Config) *pocket.TaskDef {
+	return pocket.Task("meta-sync", "clone and update the repositories of the meta config",
+		syncCmd(cfg.Repos),
+		pocket.Opts(SyncOptions{}),
+	)
+}
+
+func syncCmd(repos []Repo) pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[SyncOptions](ctx)
+		for _, repo := range repos {
+			if err := syncRepo(ctx, repo, opts); err != nil {
+				return fmt.Errorf("meta-sync %s: %w", repo.name(), err)
+			}
+		}
+		return nil
+	})
+}
+
+// syncRepo clones repo if it is missing, or updates it.
+func syncRepo(ctx context.Context, repo Repo, opts SyncOptions) error {
+	dir := pocket.FromGitRoot(repo.dir())
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if repo.URL == "" {
+			return fmt.Errorf("%s does not exist and no URL is set", dir)
+		}
+		pocket.Printf(ctx, "  Cloning %s\n", repo.URL)
+		args := []string{"clone"}
+		if repo.Ref != "" {
+			args = append(args, "--branch", repo.Ref)
+		}
+		return pocket.Exec(ctx, "git", append(args, repo.URL, dir)...)
+	}
+	if opts.NoUpdate {
+		return nil
+	}
+	if pocket.Verbose(ctx) {
+		pocket.Printf(ctx, "  Updating %s\n", repo.name())
+	}
+	if err := pocket.ExecIn(ctx, dir, "git", "fetch", "--prune", "--quiet"); err != nil {
+		return err
+	}
+	if repo.Ref != "" {
+		if err := pocket.ExecIn(ctx, dir, "git", "checkout", "--quiet", repo.Ref); err != nil {
+			return err
+		}
+	}
+	// A detached HEAD, e.g. a tag, has nothing to fast-forward.
+	cmd := pocket.Command(ctx, "git", "symbolic-ref", "--quiet", "HEAD")
+	cmd.Dir = dir
+	cmd.Stdout = io.Discard
+	if pocket.RunCommand(ctx, cmd) != nil {
+		return nil
+	}
+	return pocket.ExecIn(ctx, dir, "git", "merge", "--ff-only", "--quiet", "@{upstream}")
+}
+
+// Pok runs the repository's own pok shim with args, for repositories with
+// their own pocket config.
+func Pok(args ...string) pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		shim := "./pok"
+		if runtime.GOOS == "windows" {
+			shim = `.\pok.cmd`
+		}
+		return pocket.Exec(ctx, shim, args...)
+	})
+}
+
+// name returns the name of the repository for output.
+func (r Repo) name() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return path.Base(r.dir())
+}
+
+// dir returns the checkout directory relative to the git root.
+func (r Repo) dir() string {
+	if r.Path != "" {
+		return filepath.ToSlash(r.Path)
+	}
+	base := r.URL[strings.LastIndexAny(r.URL, "/:")+1:]
+	return "../" + strings.TrimSuffix(base, ".git")
+}
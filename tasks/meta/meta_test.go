@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: MIT
+
+package meta_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/pockettest"
+	"github.com/fredrikaverpil/pocket/tasks/meta"
+)
+
+func TestSync(t *testing.T) {
+	cfg := meta.Config{Repos: []meta.Repo{
+		{URL: "git@github.com:org/api.git", Ref: "main"},
+		{Path: "tasks"}, // an existing checkout
+	}}
+	h := pockettest.New(t)
+	h.Runner.On("git", "symbolic-ref").Return(pockettest.Response{ExitCode: 1})
+	if err := h.Run(meta.Sync(cfg)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"git clone --branch main git@github.com:org/api.git " + pocket.FromGitRoot("../api"),
+		"git fetch --prune --quiet",
+		"git symbolic-ref --quiet HEAD",
+	}
+	if got := h.Runner.Commands(); !slices.Equal(got, want) {
+		t.Errorf("Commands() =\n%q\nwant\n%q", got, want)
+	}
+
+	h = pockettest.New(t)
+	if err := h.Run(pocket.WithOpts(meta.Sync(cfg), meta.SyncOptions{NoUpdate: true})); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Runner.Commands(); len(got) != 1 {
+		t.Errorf("with -no-update, expected only the clone, got %q", got)
+	}
+
+	err := pockettest.New(t).Run(meta.Sync(meta.Config{Repos: []meta.Repo{{Path: "../missing"}}}))
+	if err == nil {
+		t.Error("expected error for a missing repository without URL")
+	}
+}
+
+func TestTasks(t *testing.T) {
+	var ran []string
+	task := pocket.Task("check", "check", pocket.Do(func(ctx context.Context) error {
+		ran = append(ran, pocket.Path(ctx))
+		return nil
+	}))
+	cfg := meta.Config{Repos: []meta.Repo{
+		{Path: "tasks", Tasks: task},
+		{Path: "tools", Tasks: meta.Pok("lint")},
+		{Path: "internal"},
+	}}
+	h := pockettest.New(t)
+	if err := h.Run(pocket.Task("meta", "run meta tasks", meta.Tasks(cfg))); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ran, []string{"tasks"}) {
+		t.Errorf("task ran in %v, want [tasks]", ran)
+	}
+	calls := h.Runner.Calls()
+	last := calls[len(calls)-1]
+	if last.String() != "pok lint" || last.Dir != pocket.FromGitRoot("tools") {
+		t.Errorf("last call = %q in %s, want pok lint in tools", last, last.Dir)
+	}
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: MIT
+
+// Package policy provides repository hygiene tasks.
+// This is a "task" package - it orchestrates tools to do work.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Rule declares a file that must exist in the repository.
+// Rules are declared in pocket.Config.Policy.
+type Rule = pocket.PolicyRule
+
+// ruleName returns a display name for the rule.
+func ruleName(r Rule) string {
+	return strings.Join(r.Paths, " | ")
+}
+
+// DefaultRules returns rules for LICENSE, CODEOWNERS, SECURITY.md and .editorconfig.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Paths:    []string{"LICENSE", "LICENSE.md", "LICENSE.txt"},
+			Contains: []string{`(?i)(license|copyright)`},
+		},
+		{
+			Paths:    []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"},
+			Validate: ValidateCodeowners,
+		},
+		{
+			Paths:    []string{"SECURITY.md", ".github/SECURITY.md", "docs/SECURITY.md"},
+			Contains: []string{`(?i)(report|vulnerabilit)`},
+		},
+		{
+			Paths:    []string{".editorconfig"},
+			Contains: []string{`(?m)^\s*\[`},
+		},
+	}
+}
+
+// Task is the repo-policy task, which fails if any rule in
+// pocket.Config.Policy is violated. Without rules in the config,
+// DefaultRules are checked.
+//
+// Example usage in .pocket/config.go:
+//
+//	var Config = pocket.Config{
+//	    AutoRun: pocket.Serial(
+//	        policy.Task,
+//	    ),
+//	    Policy: sharedconfig.PolicyRules(),
+//	}
+var Task = pocket.Task("repo-policy", "check required repository files",
+	policyCmd(),
+)
+
+// configuredRules returns the rules declared in the config, or DefaultRules.
+func configuredRules(ctx context.Context) []Rule {
+	if plan := pocket.GetConfigPlan(ctx); plan != nil && plan.Config != nil && plan.Config.Policy != nil {
+		return plan.Config.Policy
+	}
+	return DefaultRules()
+}
+
+func policyCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		rules := configuredRules(ctx)
+		var violations []string
+		for _, rule := range rules {
+			if err := Check(rule); err != nil {
+				violations = append(violations, err.Error())
+			}
+		}
+		if len(violations) > 0 {
+			for _, v := range violations {
+				pocket.Printf(ctx, "  %s\n", v)
+			}
+			return fmt.Errorf("%d repository policy violation(s)", len(violations))
+		}
+		if pocket.Verbose(ctx) {
+			pocket.Printf(ctx, "  %d rule(s) passed\n", len(rules))
+		}
+		return nil
+	})
+}
+
+// Check verifies a single rule against the repository.
+func Check(rule Rule) error {
+	for _, p := range rule.Paths {
+		data, err := os.ReadFile(pocket.FromGitRoot(p))
+		if err != nil {
+			continue
+		}
+		return checkContent(p, data, rule)
+	}
+	return fmt.Errorf("missing required file: %s", ruleName(rule))
+}
+
+func checkContent(path string, data []byte, rule Rule) error {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return fmt.Errorf("%s: file is empty", path)
+	}
+	for _, pattern := range rule.Contains {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern %q: %w", path, pattern, err)
+		}
+		if !re.Match(data) {
+			return fmt.Errorf("%s: content does not match %q", path, pattern)
+		}
+	}
+	if rule.Validate != nil {
+		if err := rule.Validate(data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// ValidateCodeowners checks that every CODEOWNERS owner is an @user, an
+// @org/team or an email address, and that there is at least one entry.
+// A pattern without owners is valid: it removes ownership of those paths.
+func ValidateCodeowners(data []byte) error {
+	entries := 0
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			if !strings.HasPrefix(owner, "@") && !strings.Contains(owner, "@") {
+				return fmt.Errorf("line %d: invalid owner %q", i+1, owner)
+			}
+		}
+		entries++
+	}
+	if entries == 0 {
+		return fmt.Errorf("no ownership entries")
+	}
+	return nil
+}
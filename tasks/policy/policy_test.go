@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+func TestValidateCodeowners(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"user and team", "* @owner @org/team\n", false},
+		{"email owner", "docs/ docs@example.com\n", false},
+		{"comments and blank lines", "# owners\n\n*.go @gopher # inline\n", false},
+		{"owner-less line unsets ownership", "* @owner\n/vendor/\n", false},
+		{"invalid owner", "* owner\n", true},
+		{"no entries", "# only comments\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCodeowners([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCodeowners() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		rule    Rule
+		wantErr bool
+	}{
+		{"matches", "MIT License\n", Rule{Contains: []string{`(?i)license`}}, false},
+		{"empty file", "  \n", Rule{}, true},
+		{"no match", "hello\n", Rule{Contains: []string{`(?i)license`}}, true},
+		{"all patterns must match", "license\n", Rule{Contains: []string{`license`, `copyright`}}, true},
+		{"invalid pattern", "x\n", Rule{Contains: []string{`(`}}, true},
+		{"validate fails", "x\n", Rule{Validate: func([]byte) error { return errors.New("bad") }}, true},
+		{"validate passes", "x\n", Rule{Validate: func([]byte) error { return nil }}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkContent("FILE", []byte(tt.data), tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkContent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTask_RulesFromConfig(t *testing.T) {
+	run := func(rules []Rule) error {
+		ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{
+			Output:     &pocket.Output{Stdout: io.Discard, Stderr: io.Discard},
+			ConfigPlan: &pocket.ConfigPlan{Config: &pocket.Config{Policy: rules}},
+		})
+		return Task.Run(ctx)
+	}
+
+	if err := run([]Rule{{Paths: []string{"LICENSE"}, Contains: []string{`MIT`}}}); err != nil {
+		t.Errorf("expected config rule to pass, got %v", err)
+	}
+	if err := run([]Rule{{Paths: []string{"does-not-exist.md"}}}); err == nil {
+		t.Error("expected missing file from config rule to fail")
+	}
+}
@@ -0,0 +1,277 @@
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// DefaultMaxSize is the largest file allowed outside Git LFS by
+// repo-size-check.
+const DefaultMaxSize = 1 << 20 // 1 MiB
+
+// SizeOptions configures the repo-size-check task.
+type SizeOptions struct {
+	MaxSize   string `arg:"max-size"   usage:"largest file allowed outside Git LFS, e.g. 500KB or 5MiB (default: 1MiB)"`
+	Base      string `arg:"base"       usage:"only check files added or changed since this git ref (default: all tracked files)"`
+	NoObjects bool   `arg:"no-objects" usage:"don't check that LFS objects are in the local LFS store"`
+}
+
+// SizeCheck fails when a tracked file larger than -max-size is not stored
+// in Git LFS, so that large binaries don't land in the history by
+// accident. Files tracked by LFS (filter=lfs in .gitattributes) must be
+// committed as LFS pointers, and the objects they point to must be in the
+// local LFS store; a CI checkout without LFS objects fails unless
+// -no-objects is set.
+//
+// Example usage in .pocket/config.go:
+//
+//	AutoRun: pocket.Serial(
+//	    pocket.WithOpts(policy.SizeCheck, policy.SizeOptions{MaxSize: "5MiB"}),
+//	)
+var SizeCheck = pocket.Task("repo-size-check", "check for large files outside Git LFS",
+	sizeCheckCmd(),
+	pocket.Opts(SizeOptions{}),
+	pocket.WithExample("./pok repo-size-check -base=origin/main", "check only the files changed on a branch"),
+)
+
+func sizeCheckCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[SizeOptions](ctx)
+		maxSize := int64(DefaultMaxSize)
+		if opts.MaxSize != "" {
+			n, err := parseSize(opts.MaxSize)
+			if err != nil {
+				return fmt.Errorf("invalid -max-size: %w", err)
+			}
+			maxSize = n
+		}
+
+		files, err := checkedFiles(ctx, opts.Base)
+		if err != nil {
+			return err
+		}
+		lfs, err := lfsFiles(ctx, files)
+		if err != nil {
+			return err
+		}
+		store := ""
+		if !opts.NoObjects && len(lfs) > 0 {
+			out, err := gitOutput(ctx, nil, "rev-parse", "--git-common-dir")
+			if err != nil {
+				return err
+			}
+			dir := strings.TrimSpace(string(out))
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(pocket.GitRoot(), dir)
+			}
+			store = filepath.Join(dir, "lfs", "objects")
+		}
+
+		var violations []string
+		for _, file := range files {
+			if lfs[file] {
+				blob, err := gitOutput(ctx, nil, "cat-file", "blob", ":"+file)
+				if err != nil {
+					return err
+				}
+				if v := checkPointer(file, blob, store); v != "" {
+					violations = append(violations, v)
+				}
+				continue
+			}
+			info, err := os.Stat(pocket.FromGitRoot(file))
+			if err != nil {
+				continue // deleted in the working tree
+			}
+			if info.Size() > maxSize {
+				violations = append(violations, fmt.Sprintf("%s: %s exceeds %s; track it with Git LFS",
+					file, formatSize(info.Size()), formatSize(maxSize)))
+			}
+		}
+		if len(violations) > 0 {
+			for _, v := range violations {
+				pocket.Printf(ctx, "  %s\n", v)
+			}
+			return fmt.Errorf("%d large file violation(s)", len(violations))
+		}
+		if pocket.Verbose(ctx) {
+			pocket.Printf(ctx, "  %d file(s) checked, %d in Git LFS\n", len(files), len(lfs))
+		}
+		return nil
+	})
+}
+
+// checkedFiles returns the tracked files in the path, relative to the git
+// root, or the files added or changed since base.
+func checkedFiles(ctx context.Context, base string) ([]string, error) {
+	args := []string{"ls-files", "-z"}
+	if base != "" {
+		rev, err := gitOutput(ctx, nil, "merge-base", "HEAD", base)
+		if err != nil {
+			return nil, err
+		}
+		args = []string{"diff", "--name-only", "-z", "--diff-filter=AM", strings.TrimSpace(string(rev))}
+	}
+	if path := pocket.Path(ctx); path != "." {
+		args = append(args, "--", path)
+	}
+	out, err := gitOutput(ctx, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// lfsFiles returns the files with the filter=lfs attribute.
+func lfsFiles(ctx context.Context, files []string) (map[string]bool, error) {
+	lfs := make(map[string]bool)
+	if len(files) == 0 {
+		return lfs, nil
+	}
+	stdin := []byte(strings.Join(files, "\x00") + "\x00")
+	out, err := gitOutput(ctx, stdin, "check-attr", "-z", "--stdin", "filter")
+	if err != nil {
+		return nil, err
+	}
+	// The output is path, attribute and value, each NUL-terminated.
+	fields := splitNUL(out)
+	for i := 0; i+2 < len(fields); i += 3 {
+		if fields[i+2] == "lfs" {
+			lfs[fields[i]] = true
+		}
+	}
+	return lfs, nil
+}
+
+// lfsPointer is the content of a Git LFS pointer file.
+type lfsPointer struct {
+	oid  string // SHA-256 of the object
+	size int64
+}
+
+var lfsOIDRe = regexp.MustCompile(`^sha256:([0-9a-f]{64})$`)
+
+// parsePointer parses a Git LFS pointer file. ok is false if data is not a
+// pointer, e.g. a file committed without LFS.
+func parsePointer(data []byte) (p lfsPointer, ok bool) {
+	if len(data) > 1024 || !bytes.HasPrefix(data, []byte("version https://git-lfs.github.com/spec/")) {
+		return p, false
+	}
+	size := int64(-1)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		switch key {
+		case "oid":
+			if m := lfsOIDRe.FindStringSubmatch(value); m != nil {
+				p.oid = m[1]
+			}
+		case "size":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil && n >= 0 {
+				size = n
+			}
+		}
+	}
+	p.size = size
+	return p, p.oid != "" && size >= 0
+}
+
+// checkPointer returns a violation if the committed blob of an LFS file is
+// not a valid pointer, or if store is set and the object is not in it.
+func checkPointer(file string, blob []byte, store string) string {
+	p, ok := parsePointer(blob)
+	if !ok {
+		return fmt.Sprintf("%s: tracked by Git LFS but committed as a regular file; run git add --renormalize", file)
+	}
+	if store == "" {
+		return ""
+	}
+	info, err := os.Stat(filepath.Join(store, p.oid[:2], p.oid[2:4], p.oid))
+	if err != nil {
+		return fmt.Sprintf("%s: LFS object %s is missing; run git lfs fetch", file, p.oid[:12])
+	}
+	if info.Size() != p.size {
+		return fmt.Sprintf("%s: LFS object %s has size %d, want %d", file, p.oid[:12], info.Size(), p.size)
+	}
+	return ""
+}
+
+// sizeRe matches a size such as "1048576", "500KB" or "5 MiB".
+var sizeRe = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([KMG]i?B?|B)?$`)
+
+// parseSize parses a size in bytes with an optional decimal (KB, MB, GB)
+// or binary (KiB, MiB, GiB) unit. K, M and G are binary.
+func parseSize(s string) (int64, error) {
+	m := sizeRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("%q is not a size, e.g. 500KB or 5MiB", s)
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	unit := m[2]
+	if unit == "" || unit == "B" {
+		return int64(n), nil
+	}
+	base := 1024.0
+	if strings.HasSuffix(unit, "B") && !strings.HasSuffix(unit, "iB") {
+		base = 1000
+	}
+	exp := strings.Index("KMG", unit[:1]) + 1
+	for range exp {
+		n *= base
+	}
+	return int64(n), nil
+}
+
+// formatSize formats n with a binary unit, e.g. 1536 KiB as "1.5MiB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 2; m /= unit {
+		div *= unit
+		exp++
+	}
+	s := fmt.Sprintf("%.1f", float64(n)/float64(div))
+	return strings.TrimSuffix(s, ".0") + string("KMG"[exp]) + "iB"
+}
+
+// gitOutput runs a git command in the git root with stdin, if set, and
+// returns its stdout.
+func gitOutput(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	var out bytes.Buffer
+	cmd := pocket.Command(ctx, "git", args...)
+	cmd.Dir = pocket.GitRoot()
+	cmd.Stdout = &out
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	if err := pocket.RunCommand(ctx, cmd); err != nil {
+		return nil, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return out.Bytes(), nil
+}
+
+// splitNUL splits NUL-terminated fields.
+func splitNUL(data []byte) []string {
+	s := strings.TrimSuffix(string(data), "\x00")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\x00")
+}
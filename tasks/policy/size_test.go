@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1048576", 1 << 20},
+		{"512B", 512},
+		{"500KB", 500_000},
+		{"5MiB", 5 << 20},
+		{"1.5 GiB", 3 << 29},
+		{"2M", 2 << 20},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "MB", "5TB", "-1"} {
+		if _, err := parseSize(in); err == nil {
+			t.Errorf("parseSize(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{100: "100B", 1536: "1.5KiB", 5 << 20: "5MiB", 3 << 30: "3GiB"} {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	oid := strings.Repeat("ab", 32)
+	valid := "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345\n"
+	p, ok := parsePointer([]byte(valid))
+	if !ok || p.oid != oid || p.size != 12345 {
+		t.Errorf("parsePointer() = %+v, %v", p, ok)
+	}
+	for _, data := range []string{
+		"binary content",
+		"version https://git-lfs.github.com/spec/v1\nsize 1\n",
+		"version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize 1\n",
+	} {
+		if _, ok := parsePointer([]byte(data)); ok {
+			t.Errorf("parsePointer(%q) ok, want not a pointer", data)
+		}
+	}
+}
+
+func TestSizeCheck(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	root := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(root, ".git", "test-gitconfig"))
+	restore := gitroot.Override(root)
+	defer restore()
+	mustGit(t, root, "init", "-q")
+
+	// An LFS object in the local store, and its pointer.
+	object := strings.Repeat("x", 4096)
+	sum := sha256.Sum256([]byte(object))
+	oid := hex.EncodeToString(sum[:])
+	pointer := fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, len(object))
+	writeFile(t, filepath.Join(root, ".git", "lfs", "objects", oid[:2], oid[2:4], oid), object)
+
+	writeFile(t, filepath.Join(root, ".gitattributes"), "*.bin filter=lfs diff=lfs merge=lfs -text\n")
+	writeFile(t, filepath.Join(root, "small.txt"), "hello\n")
+	writeFile(t, filepath.Join(root, "assets/image.bin"), pointer)
+	mustGit(t, root, "add", ".")
+	mustGit(t, root, "commit", "-q", "-m", "initial")
+
+	run := func(opts SizeOptions) (string, error) {
+		var out strings.Builder
+		ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{
+			Output: &pocket.Output{Stdout: &out, Stderr: &out},
+		})
+		err := pocket.WithOpts(SizeCheck, opts).Run(ctx)
+		return out.String(), err
+	}
+
+	if out, err := run(SizeOptions{MaxSize: "1KiB"}); err != nil {
+		t.Fatalf("clean repo: %v\n%s", err, out)
+	}
+
+	// Large files outside LFS, and LFS files that don't resolve.
+	writeFile(t, filepath.Join(root, "data.csv"), strings.Repeat("1,2\n", 1024))
+	writeFile(t, filepath.Join(root, "raw.bin"), "not a pointer")
+	writeFile(t, filepath.Join(root, "missing.bin"), strings.Replace(pointer, oid, strings.Repeat("0", 64), 1))
+	mustGit(t, root, "add", ".")
+	mustGit(t, root, "commit", "-q", "-m", "large files")
+
+	out, err := run(SizeOptions{MaxSize: "1KiB"})
+	if err == nil {
+		t.Fatalf("expected violations, output:\n%s", out)
+	}
+	for _, want := range []string{
+		"data.csv: 4KiB exceeds 1KiB; track it with Git LFS",
+		"raw.bin: tracked by Git LFS but committed as a regular file",
+		"missing.bin: LFS object 000000000000 is missing",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "image.bin") || strings.Contains(out, "small.txt") {
+		t.Errorf("unexpected violation:\n%s", out)
+	}
+
+	// -no-objects skips the LFS store, and -max-size raises the limit.
+	out, err = run(SizeOptions{MaxSize: "1MB", NoObjects: true})
+	if err == nil || strings.Contains(out, "missing.bin") || strings.Contains(out, "data.csv") {
+		t.Errorf("with -no-objects and -max-size: err = %v, output:\n%s", err, out)
+	}
+
+	// -base only checks the files changed since the ref.
+	mustGit(t, root, "rm", "-q", "raw.bin", "missing.bin")
+	mustGit(t, root, "commit", "-q", "-m", "remove")
+	if out, err := run(SizeOptions{MaxSize: "1KiB", Base: "HEAD"}); err != nil {
+		t.Errorf("with -base: %v\n%s", err, out)
+	}
+}
+
+func mustGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: MIT
+
+// Package prebuilt builds the prebuilt task runners that shims download when
+// pocket.ShimConfig.Prebuilt is set.
+// This is a "task" package - it orchestrates tools to do work.
+package prebuilt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/shim"
+)
+
+// DefaultPlatforms are the platforms runners are built for by default.
+var DefaultPlatforms = []string{
+	"darwin/amd64", "darwin/arm64",
+	"linux/amd64", "linux/arm64",
+	"windows/amd64", "windows/arm64",
+}
+
+// DefaultOut is the directory the runners are written to by default.
+const DefaultOut = ".pocket/dist"
+
+// BuildOptions configures the runner-build task.
+type BuildOptions struct {
+	Platforms string `arg:"platforms" usage:"comma-separated os/arch platforms to build for (default: darwin, linux and windows on amd64 and arm64)"`
+	Out       string `arg:"out"       usage:"output directory, relative to the git root (default: .pocket/dist)"`
+}
+
+// Build cross-compiles the task runner in .pocket for each platform and
+// writes a SHA256SUMS file next to the runners. Upload the files as release
+// assets and point pocket.ShimConfig.Prebuilt at them:
+//
+//	./pok runner-build
+//	gh release create pocket-runner-v1 .pocket/dist/*
+var Build = pocket.Task("runner-build", "build prebuilt task runners for the shims",
+	buildCmd(),
+	pocket.Opts(BuildOptions{}),
+)
+
+func buildCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[BuildOptions](ctx)
+
+		platforms := DefaultPlatforms
+		if opts.Platforms != "" {
+			platforms = strings.Split(opts.Platforms, ",")
+		}
+		out := pocket.FromGitRoot(DefaultOut)
+		if opts.Out != "" {
+			out = opts.Out
+			if !filepath.IsAbs(out) {
+				out = pocket.FromGitRoot(out)
+			}
+		}
+		if err := os.MkdirAll(out, 0o755); err != nil {
+			return fmt.Errorf("create %s: %w", out, err)
+		}
+
+		var assets []string
+		for _, platform := range platforms {
+			goos, goarch, ok := strings.Cut(strings.TrimSpace(platform), "/")
+			if !ok {
+				return fmt.Errorf("runner-build: invalid platform %q, want os/arch", platform)
+			}
+			asset := shim.AssetName(goos, goarch)
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  Building %s\n", asset)
+			}
+			cmd := pocket.Command(ctx, "go", "build", "-trimpath", "-ldflags=-s -w", "-o", filepath.Join(out, asset), ".")
+			cmd.Dir = pocket.FromPocketDir()
+			cmd.Env = append(cmd.Env, "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0")
+			if err := pocket.RunCommand(ctx, cmd); err != nil {
+				return fmt.Errorf("build %s: %w", asset, err)
+			}
+			assets = append(assets, asset)
+		}
+		return writeChecksums(out, assets)
+	})
+}
+
+// writeChecksums writes the SHA256SUMS file for the assets in dir, in the
+// format of sha256sum.
+func writeChecksums(dir string, assets []string) error {
+	slices.Sort(assets)
+	var sums strings.Builder
+	for _, asset := range assets {
+		sum, err := fileSHA256(filepath.Join(dir, asset))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&sums, "%s  %s\n", sum, asset)
+	}
+	return os.WriteFile(filepath.Join(dir, shim.ChecksumsFile), []byte(sums.String()), 0o644)
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: MIT
+
+package prebuilt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteChecksums(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"pocket-runner-linux-amd64", "pocket-runner-darwin-arm64"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("runner"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writeChecksums(dir, []string{"pocket-runner-linux-amd64", "pocket-runner-darwin-arm64"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "SHA256SUMS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const sum = "527aa9f431539da8e151d5434d1d5e611d973f601d8e970790882624554146b0" // sha256("runner")
+	want := sum + "  pocket-runner-darwin-arm64\n" + sum + "  pocket-runner-linux-amd64\n"
+	if string(got) != want {
+		t.Errorf("SHA256SUMS =\n%s\nwant\n%s", got, want)
+	}
+}
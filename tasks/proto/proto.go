@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: MIT
+
+// Package proto provides Protocol Buffers code generation tasks.
+// This is a "task" package - it orchestrates tools to do work.
+package proto
+
+import (
+	"context"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/buf"
+	"github.com/fredrikaverpil/pocket/tools/protocgenconnectgo"
+	"github.com/fredrikaverpil/pocket/tools/protocgengo"
+	"github.com/fredrikaverpil/pocket/tools/protocgengogrpc"
+)
+
+// GenerateOptions configures the proto-generate task.
+type GenerateOptions struct {
+	Template string `arg:"template" usage:"buf generate template (default: buf.gen.yaml)"`
+}
+
+// Plugins installs the protoc plugins for Go, gRPC and Connect at pinned
+// versions into .pocket/bin, where buf finds local plugins such as
+//
+//	plugins:
+//	  - local: protoc-gen-go
+//	    out: gen
+var Plugins = pocket.Parallel(
+	protocgengo.Install,
+	protocgengogrpc.Install,
+	protocgenconnectgo.Install,
+)
+
+// Generate runs buf generate in the path, with buf and the protoc plugins
+// installed by pocket, so contributors need neither installed.
+//
+// Register it in Config.Generate, so that ./pok generate regenerates the
+// code and the git-diff check catches stale generated code:
+//
+//	Generate: []pocket.Runnable{
+//	    pocket.RunIn(proto.Generate, pocket.Detect(proto.Detect())),
+//	},
+var Generate = pocket.Task("proto-generate", "generate code from Protocol Buffers with buf",
+	pocket.Serial(buf.Install, Plugins, generateCmd()),
+	pocket.Opts(GenerateOptions{}),
+)
+
+func generateCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[GenerateOptions](ctx)
+		args := []string{"generate"}
+		if opts.Template != "" {
+			args = append(args, "--template", opts.Template)
+		}
+		if pocket.Verbose(ctx) {
+			args = append(args, "--debug")
+		}
+		return pocket.Exec(ctx, buf.Name, args...)
+	})
+}
+
+// Detect returns a detection function for buf projects.
+// It finds directories containing a buf.gen.yaml file.
+func Detect() func() []string {
+	return func() []string {
+		return pocket.DetectByFile("buf.gen.yaml")
+	}
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: MIT
+
+package proto_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/pockettest"
+	"github.com/fredrikaverpil/pocket/tasks/proto"
+	"github.com/fredrikaverpil/pocket/tools/buf"
+	"github.com/fredrikaverpil/pocket/tools/protocgenconnectgo"
+	"github.com/fredrikaverpil/pocket/tools/protocgengo"
+	"github.com/fredrikaverpil/pocket/tools/protocgengogrpc"
+)
+
+func TestGenerate(t *testing.T) {
+	h := pockettest.New(t, pockettest.WithPath("api"))
+	task := pocket.WithOpts(proto.Generate, proto.GenerateOptions{Template: "buf.gen.go.yaml"})
+	if err := h.Run(task); err != nil {
+		t.Fatal(err)
+	}
+
+	installs := h.Runner.Installs()
+	slices.Sort(installs)
+	want := []string{
+		"connectrpc.com/connect/cmd/protoc-gen-connect-go@" + protocgenconnectgo.Version,
+		"github.com/bufbuild/buf/cmd/buf@" + buf.Version,
+		"google.golang.org/grpc/cmd/protoc-gen-go-grpc@" + protocgengogrpc.Version,
+		"google.golang.org/protobuf/cmd/protoc-gen-go@" + protocgengo.Version,
+	}
+	if !slices.Equal(installs, want) {
+		t.Errorf("Installs() = %q, want %q", installs, want)
+	}
+	if got := h.Runner.Commands(); !slices.Equal(got, []string{"buf generate --template buf.gen.go.yaml"}) {
+		t.Errorf("Commands() = %q", got)
+	}
+}
@@ -1,27 +1,34 @@
+// SPDX-License-Identifier: MIT
+
 package python
 
 import (
 	"context"
 
 	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/ruff"
 	"github.com/fredrikaverpil/pocket/tools/uv"
 )
 
 // FormatOptions configures the py-format task.
 type FormatOptions struct {
-	PythonVersion string `arg:"python" usage:"Python version (for target-version inference)"`
+	PythonVersion string `arg:"python"      usage:"Python version (for target-version inference)"`
+	RuffConfig    string `arg:"ruff-config" usage:"path to ruff config file (default: nearest ruff config)"`
 }
 
 // Format formats Python files using ruff format.
-// Requires ruff as a project dependency in pyproject.toml.
+// Requires ruff as a project dependency in pyproject.toml. The ruff config is
+// -ruff-config, else the nearest one found by ruff.ConfigPath.
 var Format = pocket.Task("py-format", "format Python files",
 	pocket.Serial(uv.Install, formatSyncCmd(), formatCmd()),
 	pocket.Opts(FormatOptions{}),
+	pocket.WithInputs(Inputs...),
 )
 
 func formatSyncCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
 		opts := pocket.Options[FormatOptions](ctx)
+		opts.PythonVersion = pythonVersion(ctx, opts.PythonVersion)
 		return uv.Sync(ctx, opts.PythonVersion, true)
 	})
 }
@@ -29,11 +36,20 @@ func formatSyncCmd() pocket.Runnable {
 func formatCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
 		opts := pocket.Options[FormatOptions](ctx)
+		opts.PythonVersion = pythonVersion(ctx, opts.PythonVersion)
 
 		args := []string{
 			"format",
 			"--exclude", ".pocket", // Exclude pocket-managed directories
 		}
+		if !pocket.Fix(ctx, true) {
+			args = append(args, "--check")
+		}
+		configPath, err := ruffConfig(ctx, opts.RuffConfig)
+		if err != nil {
+			return err
+		}
+		args = append(args, "--config", configPath)
 		if pocket.Verbose(ctx) {
 			args = append(args, "--verbose")
 		}
@@ -42,6 +58,6 @@ func formatCmd() pocket.Runnable {
 		}
 		args = append(args, pocket.Path(ctx))
 
-		return uv.Run(ctx, opts.PythonVersion, "ruff", args...)
+		return uv.Run(ctx, opts.PythonVersion, ruff.Name, args...)
 	})
 }
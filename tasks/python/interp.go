@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: MIT
+
+package python
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/uv"
+)
+
+// InterpOptions configures the py-interp task.
+type InterpOptions struct {
+	PythonVersion string `arg:"python" usage:"Python version to install and pin (default: Config.PythonVersion)"`
+	NoPin         bool   `arg:"no-pin" usage:"install only, don't write .python-version"`
+}
+
+// Interp installs the Python interpreter with "uv python install" and pins
+// it in .python-version in the project directory, so that uv, pyenv and
+// editors use the same version as the Python tasks. The version is -python,
+// else Config.PythonVersion, else uv.DefaultPythonVersion.
+//
+// Example usage in .pocket/config.go:
+//
+//	PythonVersion: "3.12",
+//	ManualRun: []pocket.Runnable{
+//	    pocket.RunIn(python.Interp, pocket.Detect(python.Detect())),
+//	},
+var Interp = pocket.Task("py-interp", "install and pin the Python interpreter",
+	pocket.Serial(uv.Install, interpCmd()),
+	pocket.Opts(InterpOptions{}),
+	pocket.WithExample("./pok py-interp -python=3.12", "install Python 3.12 and pin it"),
+)
+
+func interpCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[InterpOptions](ctx)
+		version := uv.PythonVersion(ctx, opts.PythonVersion)
+		if err := pocket.Exec(ctx, uv.Name, "python", "install", version); err != nil {
+			return err
+		}
+		if opts.NoPin {
+			return nil
+		}
+		return pinPythonVersion(ctx, version)
+	})
+}
+
+// pinPythonVersion writes version to .python-version in the project
+// directory, unless it is pinned already.
+func pinPythonVersion(ctx context.Context, version string) error {
+	file := filepath.Join(pocket.Path(ctx), ".python-version")
+	path := pocket.FromGitRoot(file)
+	content := version + "\n"
+	if data, err := os.ReadFile(path); err == nil && string(data) == content {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("pin Python version: %w", err)
+	}
+	if pocket.Verbose(ctx) {
+		pocket.Printf(ctx, "  Pinned Python %s in %s\n", version, filepath.ToSlash(file))
+	}
+	return nil
+}
@@ -1,28 +1,35 @@
+// SPDX-License-Identifier: MIT
+
 package python
 
 import (
 	"context"
 
 	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/ruff"
 	"github.com/fredrikaverpil/pocket/tools/uv"
 )
 
 // LintOptions configures the py-lint task.
 type LintOptions struct {
-	PythonVersion string `arg:"python"   usage:"Python version (for target-version inference)"`
-	SkipFix       bool   `arg:"skip-fix" usage:"don't auto-fix issues"`
+	PythonVersion string `arg:"python"      usage:"Python version (for target-version inference)"`
+	RuffConfig    string `arg:"ruff-config" usage:"path to ruff config file (default: nearest ruff config)"`
+	SkipFix       bool   `arg:"skip-fix"    usage:"don't auto-fix issues"`
 }
 
 // Lint lints Python files using ruff check with auto-fix enabled by default.
-// Requires ruff as a project dependency in pyproject.toml.
+// Requires ruff as a project dependency in pyproject.toml. The ruff config is
+// -ruff-config, else the nearest one found by ruff.ConfigPath.
 var Lint = pocket.Task("py-lint", "lint Python files",
 	pocket.Serial(uv.Install, lintSyncCmd(), lintCmd()),
 	pocket.Opts(LintOptions{}),
+	pocket.WithInputs(Inputs...),
 )
 
 func lintSyncCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
 		opts := pocket.Options[LintOptions](ctx)
+		opts.PythonVersion = pythonVersion(ctx, opts.PythonVersion)
 		return uv.Sync(ctx, opts.PythonVersion, true)
 	})
 }
@@ -30,15 +37,21 @@ func lintSyncCmd() pocket.Runnable {
 func lintCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
 		opts := pocket.Options[LintOptions](ctx)
+		opts.PythonVersion = pythonVersion(ctx, opts.PythonVersion)
 
 		args := []string{
 			"check",
 			"--exclude", ".pocket", // Exclude pocket-managed directories
 		}
+		configPath, err := ruffConfig(ctx, opts.RuffConfig)
+		if err != nil {
+			return err
+		}
+		args = append(args, "--config", configPath)
 		if pocket.Verbose(ctx) {
 			args = append(args, "--verbose")
 		}
-		if !opts.SkipFix {
+		if pocket.Fix(ctx, !opts.SkipFix) {
 			args = append(args, "--fix")
 		}
 		if opts.PythonVersion != "" {
@@ -46,6 +59,6 @@ func lintCmd() pocket.Runnable {
 		}
 		args = append(args, pocket.Path(ctx))
 
-		return uv.Run(ctx, opts.PythonVersion, "ruff", args...)
+		return uv.Run(ctx, opts.PythonVersion, ruff.Name, args...)
 	})
 }
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: MIT
+
+package python
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+	"github.com/fredrikaverpil/pocket/tools/uv"
+)
+
+func TestPythonVersion(t *testing.T) {
+	ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{})
+	if got := pythonVersion(ctx, ""); got != "" {
+		t.Errorf("pythonVersion() without config = %q, want empty", got)
+	}
+	if got := uv.PythonVersion(ctx, ""); got != uv.DefaultPythonVersion {
+		t.Errorf("uv.PythonVersion() without config = %q, want %q", got, uv.DefaultPythonVersion)
+	}
+
+	plan := pocket.BuildConfigPlan(pocket.Config{PythonVersion: "3.12"})
+	ctx = pocket.NewRunContext(context.Background(), pocket.RunContextOptions{ConfigPlan: plan})
+	if got := pythonVersion(ctx, ""); got != "3.12" {
+		t.Errorf("pythonVersion() = %q, want Config.PythonVersion", got)
+	}
+	if got := uv.PythonVersion(ctx, ""); got != "3.12" {
+		t.Errorf("uv.PythonVersion() = %q, want Config.PythonVersion", got)
+	}
+	if got := pythonVersion(ctx, "3.9"); got != "3.9" {
+		t.Errorf("pythonVersion(3.9) = %q, want the task option", got)
+	}
+}
+
+func TestPinPythonVersion(t *testing.T) {
+	root := t.TempDir()
+	restore := gitroot.Override(root)
+	defer restore()
+
+	ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{})
+	for _, version := range []string{"3.12", "3.13.1"} {
+		if err := pinPythonVersion(ctx, version); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(root, ".python-version"))
+		if err != nil || string(data) != version+"\n" {
+			t.Errorf(".python-version = %q, %v, want %s", data, err, version)
+		}
+	}
+}
+
+func TestRuffConfig(t *testing.T) {
+	root := t.TempDir()
+	restore := gitroot.Override(root)
+	defer restore()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{Path: "libs/core"})
+	configPath := func() string {
+		t.Helper()
+		path, err := ruffConfig(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Without a ruff config, the bundled default is used.
+	write("libs/core/pyproject.toml", "[project]\nname = \"core\"\n")
+	if got, want := configPath(), pocket.FromToolsDir("ruff", "ruff.toml"); got != want {
+		t.Errorf("ruffConfig() = %q, want bundled default %q", got, want)
+	}
+
+	// A repo-root config applies to all modules.
+	rootConfig := write("ruff.toml", "line-length = 100\n")
+	if got := configPath(); got != rootConfig {
+		t.Errorf("ruffConfig() = %q, want repo root %q", got, rootConfig)
+	}
+
+	// A module config takes precedence, also from pyproject.toml.
+	moduleConfig := write("libs/core/pyproject.toml", "[project]\nname = \"core\"\n\n[tool.ruff.lint]\nselect = [\"ALL\"]\n")
+	if got := configPath(); got != moduleConfig {
+		t.Errorf("ruffConfig() = %q, want module %q", got, moduleConfig)
+	}
+	libsConfig := write("libs/.ruff.toml", "line-length = 80\n")
+	if got := configPath(); got != moduleConfig {
+		t.Errorf("ruffConfig() = %q, want module %q over %q", got, moduleConfig, libsConfig)
+	}
+
+	if got, _ := ruffConfig(ctx, "custom.toml"); got != "custom.toml" {
+		t.Errorf("ruffConfig(custom.toml) = %q, want the task option", got)
+	}
+}
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package python provides Python-related build tasks using ruff and mypy.
 //
 // # Python Version
@@ -11,13 +13,17 @@
 //  2. Wire it to the appropriate tool flag (e.g., --python, --target-version)
 //  3. Update Tasks() to pass pythonVersion to the new task's options
 //
-// See [WithPythonVersion] for setting the version across all tasks.
+// See [WithPythonVersion] for setting the version across all tasks, or
+// Config.PythonVersion for setting it for the whole repository. The [Interp]
+// task installs the version with uv and pins it in .python-version.
 package python
 
 import (
+	"context"
 	"strings"
 
 	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/ruff"
 )
 
 // pythonVersionToRuff converts a Python version (e.g., "3.9") to ruff's format (e.g., "py39").
@@ -30,6 +36,32 @@ func pythonVersionToRuff(version string) string {
 	return "py" + strings.ReplaceAll(version, ".", "")
 }
 
+// pythonVersion returns the Python version of a task: version if set, else
+// Config.PythonVersion. Empty means uv.DefaultPythonVersion for uv commands,
+// and the tools' own defaults for their target version.
+func pythonVersion(ctx context.Context, version string) string {
+	if version != "" {
+		return version
+	}
+	if plan := pocket.GetConfigPlan(ctx); plan != nil && plan.Config != nil {
+		return plan.Config.PythonVersion
+	}
+	return ""
+}
+
+// ruffConfig returns the ruff config of a task: path if set, else the
+// nearest ruff config of the task's directory (see ruff.ConfigPath).
+func ruffConfig(ctx context.Context, path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	return ruff.ConfigPath(ctx)
+}
+
+// Inputs are the files the Python tasks read, relative to the project
+// directory. See pocket.WithInputs.
+var Inputs = []string{"**/*.py", "pyproject.toml", "uv.lock", "ruff.toml", ".ruff.toml"}
+
 // Option configures the python task group.
 type Option func(*config)
 
@@ -39,9 +71,11 @@ type config struct {
 	lint          LintOptions
 	typecheck     TypecheckOptions
 	test          TestOptions
+	env           map[string]string
 }
 
-// WithPythonVersion sets the Python version for uv commands.
+// WithPythonVersion sets the Python version for uv commands, overriding
+// Config.PythonVersion.
 func WithPythonVersion(version string) Option {
 	return func(c *config) { c.pythonVersion = version }
 }
@@ -66,6 +100,12 @@ func WithTest(opts TestOptions) Option {
 	return func(c *config) { c.test = opts }
 }
 
+// WithEnv sets extra environment variables for the commands of the Python
+// tasks, e.g. UV_INDEX_URL. See pocket.Env.
+func WithEnv(env map[string]string) Option {
+	return func(c *config) { c.env = env }
+}
+
 // Tasks returns a Runnable that executes all Python tasks.
 // Use pocket.RunIn(python.Tasks(), pocket.Detect(python.Detect())) to enable path filtering.
 //
@@ -106,14 +146,14 @@ func Tasks(opts ...Option) pocket.Runnable {
 
 	// Run format, lint, typecheck, test (serial since format/lint modify files)
 	// Each task handles its own uv.Sync internally
-	return pocket.Serial(
+	return pocket.Env(cfg.env, pocket.Serial(
 		pocket.Parallel(
 			pocket.WithOpts(Typecheck, typecheckOpts),
 			pocket.WithOpts(Test, testOpts),
 		),
 		pocket.WithOpts(Format, formatOpts),
 		pocket.WithOpts(Lint, lintOpts),
-	)
+	))
 }
 
 // Detect returns a detection function that finds Python projects.
@@ -1,9 +1,12 @@
+// SPDX-License-Identifier: MIT
+
 package python
 
 import (
 	"context"
 
 	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tasks/compose"
 	"github.com/fredrikaverpil/pocket/tools/uv"
 )
 
@@ -11,18 +14,23 @@ import (
 type TestOptions struct {
 	PythonVersion string `arg:"python"        usage:"Python version to use (e.g., 3.9)"`
 	SkipCoverage  bool   `arg:"skip-coverage" usage:"disable coverage generation"`
+	Compose       string `arg:"compose"       usage:"comma-separated compose files to bring up around the tests"`
 }
 
 // Test runs Python tests using pytest with coverage by default.
 // Requires pytest and coverage as project dependencies in pyproject.toml.
+// With -compose, a Docker Compose stack is brought up before the tests and
+// torn down after them, see compose.Stack.
 var Test = pocket.Task("py-test", "run Python tests",
 	pocket.Serial(uv.Install, testSyncCmd(), testCmd()),
 	pocket.Opts(TestOptions{}),
+	pocket.WithInputs(Inputs...),
 )
 
 func testSyncCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
 		opts := pocket.Options[TestOptions](ctx)
+		opts.PythonVersion = pythonVersion(ctx, opts.PythonVersion)
 		return uv.Sync(ctx, opts.PythonVersion, true)
 	})
 }
@@ -30,39 +38,49 @@ func testSyncCmd() pocket.Runnable {
 func testCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
 		opts := pocket.Options[TestOptions](ctx)
-
-		if opts.SkipCoverage {
-			// Run pytest directly without coverage
-			args := []string{}
-			if pocket.Verbose(ctx) {
-				args = append(args, "-vv")
-			}
-			return uv.Run(ctx, opts.PythonVersion, "pytest", args...)
+		opts.PythonVersion = pythonVersion(ctx, opts.PythonVersion)
+		if opts.Compose == "" {
+			return runTests(ctx, opts)
 		}
+		stack := compose.Stack{Files: compose.ParseFiles(opts.Compose)}
+		return stack.Run(ctx, func(ctx context.Context) error {
+			return runTests(ctx, opts)
+		})
+	})
+}
 
-		// Run with coverage: coverage run --parallel-mode -m pytest
-		// --parallel-mode creates .coverage.<hostname>.<pid> files to avoid conflicts
-		// when running multiple test processes in parallel (e.g., TestMatrix)
-		args := []string{"run", "--parallel-mode", "-m", "pytest"}
+func runTests(ctx context.Context, opts TestOptions) error {
+	if opts.SkipCoverage {
+		// Run pytest directly without coverage
+		args := []string{}
 		if pocket.Verbose(ctx) {
 			args = append(args, "-vv")
 		}
-		if err := uv.Run(ctx, opts.PythonVersion, "coverage", args...); err != nil {
-			return err
-		}
+		return uv.Run(ctx, opts.PythonVersion, "pytest", args...)
+	}
 
-		// Combine parallel coverage files before reporting
-		if err := uv.Run(ctx, opts.PythonVersion, "coverage", "combine"); err != nil {
-			// Ignore error if no parallel files to combine (single run)
-			pocket.Printf(ctx, "Note: coverage combine skipped (may be single run)\n")
-		}
+	// Run with coverage: coverage run --parallel-mode -m pytest
+	// --parallel-mode creates .coverage.<hostname>.<pid> files to avoid conflicts
+	// when running multiple test processes in parallel (e.g., TestMatrix)
+	args := []string{"run", "--parallel-mode", "-m", "pytest"}
+	if pocket.Verbose(ctx) {
+		args = append(args, "-vv")
+	}
+	if err := uv.Run(ctx, opts.PythonVersion, "coverage", args...); err != nil {
+		return err
+	}
 
-		// Show coverage report
-		if err := uv.Run(ctx, opts.PythonVersion, "coverage", "report"); err != nil {
-			return err
-		}
+	// Combine parallel coverage files before reporting
+	if err := uv.Run(ctx, opts.PythonVersion, "coverage", "combine"); err != nil {
+		// Ignore error if no parallel files to combine (single run)
+		pocket.Printf(ctx, "Note: coverage combine skipped (may be single run)\n")
+	}
 
-		// Generate HTML report
-		return uv.Run(ctx, opts.PythonVersion, "coverage", "html")
-	})
+	// Show coverage report
+	if err := uv.Run(ctx, opts.PythonVersion, "coverage", "report"); err != nil {
+		return err
+	}
+
+	// Generate HTML report
+	return uv.Run(ctx, opts.PythonVersion, "coverage", "html")
 }
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package python
 
 import (
@@ -17,11 +19,13 @@ type TypecheckOptions struct {
 var Typecheck = pocket.Task("py-typecheck", "type-check Python files",
 	pocket.Serial(uv.Install, typecheckSyncCmd(), typecheckCmd()),
 	pocket.Opts(TypecheckOptions{}),
+	pocket.WithInputs(Inputs...),
 )
 
 func typecheckSyncCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
 		opts := pocket.Options[TypecheckOptions](ctx)
+		opts.PythonVersion = pythonVersion(ctx, opts.PythonVersion)
 		return uv.Sync(ctx, opts.PythonVersion, true)
 	})
 }
@@ -29,6 +33,7 @@ func typecheckSyncCmd() pocket.Runnable {
 func typecheckCmd() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
 		opts := pocket.Options[TypecheckOptions](ctx)
+		opts.PythonVersion = pythonVersion(ctx, opts.PythonVersion)
 
 		args := []string{
 			"--exclude", `\.pocket/`, // Exclude pocket-managed directories
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: MIT
+
+// Package render renders Go templates into generated files, such as version
+// files or Kubernetes manifests.
+// This is a "task" package - it orchestrates tools to do work.
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// File is a template to render into a target file.
+type File struct {
+	// Source is the template file, relative to the git root.
+	Source string
+
+	// Text is the template itself, used when Source is empty.
+	Text string
+
+	// Target is the file to write, relative to the git root.
+	Target string
+
+	// Values are merged over Config.Values for this file.
+	Values map[string]any
+}
+
+// Config configures the render task.
+type Config struct {
+	// Files are rendered in order.
+	Files []File
+
+	// Values are available to all templates as .Values.
+	Values map[string]any
+}
+
+// Data is the data passed to templates.
+type Data struct {
+	// Values are Config.Values merged with File.Values.
+	Values map[string]any
+
+	// Target is the path of the file being rendered, relative to the git root.
+	Target string
+}
+
+// funcs are the template functions in addition to the text/template builtins.
+var funcs = template.FuncMap{
+	// env returns the value of an environment variable; the render fails if
+	// it is unset, so that a file is never silently rendered empty.
+	"env": func(name string) (string, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	},
+	// envOr returns the value of an environment variable, or def if unset.
+	"envOr": func(name, def string) string {
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		return def
+	},
+}
+
+// Task creates the render task. It renders each File with text/template
+// and writes the result to its target. A reference to a missing value fails
+// the render. Templates can read the environment with {{env "NAME"}} and
+// {{envOr "NAME" "default"}}.
+//
+// Add it to Config.Generate, so the targets are re-rendered by ./pok
+// generate and the git-diff check catches edits to generated files:
+//
+//	var Config = pocket.Config{
+//	    Generate: []pocket.Runnable{
+//	        render.Task(render.Config{
+//	            Values: map[string]any{"Version": "1.4.0"},
+//	            Files: []render.File{
+//	                {Source: "deploy/app.yaml.tmpl", Target: "deploy/app.yaml"},
+//	                {Text: "package version\n\nconst Version = {{printf \"%q\" .Values.Version}}\n", Target: "internal/version/version.go"},
+//	            },
+//	        }),
+//	    },
+//	}
+func Task(cfg Config) *pocket.TaskDef {
+	return pocket.Task("render", "render templates into generated files",
+		pocket.Do(func(ctx context.Context) error {
+			for _, f := range cfg.Files {
+				content, err := Render(f, cfg.Values)
+				if err != nil {
+					return err
+				}
+				dest := pocket.FromGitRoot(filepath.FromSlash(f.Target))
+				if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+					return fmt.Errorf("create dir: %w", err)
+				}
+				if err := os.WriteFile(dest, content, 0o644); err != nil {
+					return fmt.Errorf("write %s: %w", f.Target, err)
+				}
+				if pocket.Verbose(ctx) {
+					pocket.Printf(ctx, "  Rendered %s\n", f.Target)
+				}
+			}
+			return nil
+		}),
+	)
+}
+
+// Render renders f with values merged under f.Values.
+func Render(f File, values map[string]any) ([]byte, error) {
+	if f.Target == "" {
+		return nil, errors.New("render: file without target")
+	}
+	text, name := f.Text, f.Target
+	if f.Source != "" {
+		data, err := os.ReadFile(pocket.FromGitRoot(filepath.FromSlash(f.Source)))
+		if err != nil {
+			return nil, fmt.Errorf("render %s: %w", f.Target, err)
+		}
+		text, name = string(data), f.Source
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", f.Target, err)
+	}
+
+	merged := make(map[string]any, len(values)+len(f.Values))
+	for k, v := range values {
+		merged[k] = v
+	}
+	for k, v := range f.Values {
+		merged[k] = v
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, Data{Values: merged, Target: f.Target}); err != nil {
+		return nil, fmt.Errorf("render %s: %w", f.Target, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: MIT
+
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	t.Setenv("RENDER_TEST_IMAGE", "ghcr.io/acme/app")
+	values := map[string]any{"Version": "1.4.0", "Replicas": 2}
+
+	tests := []struct {
+		name    string
+		file    File
+		want    string
+		wantErr string
+	}{
+		{
+			name: "values",
+			file: File{
+				Text:   "version: {{.Values.Version}}\nreplicas: {{.Values.Replicas}}\n",
+				Target: "deploy/app.yaml",
+				Values: map[string]any{"Replicas": 3},
+			},
+			want: "version: 1.4.0\nreplicas: 3\n",
+		},
+		{
+			name: "env",
+			file: File{
+				Text:   `{{env "RENDER_TEST_IMAGE"}}:{{.Values.Version}} {{envOr "RENDER_TEST_UNSET" "latest"}} {{.Target}}`,
+				Target: "image.txt",
+			},
+			want: "ghcr.io/acme/app:1.4.0 latest image.txt",
+		},
+		{
+			name:    "missing value",
+			file:    File{Text: "{{.Values.Verison}}", Target: "version.txt"},
+			wantErr: `map has no entry for key "Verison"`,
+		},
+		{
+			name:    "missing env",
+			file:    File{Text: `{{env "RENDER_TEST_UNSET"}}`, Target: "version.txt"},
+			wantErr: "environment variable RENDER_TEST_UNSET is not set",
+		},
+		{
+			name:    "no target",
+			file:    File{Text: "x"},
+			wantErr: "file without target",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.file, values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
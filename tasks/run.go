@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package tasks provides the entry point for running pocket tasks.
 // Import this package in .pocket/main.go to use pocket.
 package tasks
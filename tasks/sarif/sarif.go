@@ -0,0 +1,371 @@
+// SPDX-License-Identifier: MIT
+
+// Package sarif provides SARIF report collection and merging.
+// Lint and security tasks write SARIF files when POK_SARIF_DIR is set,
+// and the sarif-report task merges them into a single file for upload
+// to GitHub code scanning.
+package sarif
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// DirEnv is the environment variable that enables SARIF output.
+// When set, SARIF-capable tasks write one file per task and path into
+// this directory. Relative paths are resolved from the git root.
+const DirEnv = "POK_SARIF_DIR"
+
+// DefaultDir is the SARIF directory used by the generated workflow.
+const DefaultDir = ".pocket/sarif"
+
+// DefaultOutput is the merged SARIF file written by the sarif-report task.
+const DefaultOutput = ".pocket/pocket.sarif"
+
+// Dir returns the SARIF output directory, or "" when SARIF output is disabled.
+func Dir() string {
+	dir := os.Getenv(DirEnv)
+	if dir == "" {
+		return ""
+	}
+	if !filepath.IsAbs(dir) {
+		dir = pocket.FromGitRoot(dir)
+	}
+	return dir
+}
+
+// OutputFile returns the SARIF file a task should write for the current path,
+// e.g. "<dir>/services/api/go-lint.sarif". The subdirectory mirrors the path
+// so that sarif-report can rebase artifact URIs onto the git root.
+// It returns "" when SARIF output is disabled. The directory is created if
+// it does not exist.
+func OutputFile(ctx context.Context, name string) (string, error) {
+	dir := Dir()
+	if dir == "" {
+		return "", nil
+	}
+	dir = filepath.Join(dir, filepath.FromSlash(pocket.Path(ctx)))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create sarif dir: %w", err)
+	}
+	return filepath.Join(dir, name+".sarif"), nil
+}
+
+// ReportOptions configures the sarif-report task.
+type ReportOptions struct {
+	Dir    string `arg:"dir"    usage:"directory with SARIF files (default: $POK_SARIF_DIR or .pocket/sarif)"`
+	Output string `arg:"output" usage:"merged SARIF file to write (default: .pocket/pocket.sarif)"`
+}
+
+// Report merges all SARIF files in the SARIF directory into a single file.
+// Runs from the same tool are combined so that code scanning accepts the upload.
+var Report = pocket.Task("sarif-report", "merge SARIF reports into a single file",
+	reportCmd(),
+	pocket.Opts(ReportOptions{}),
+)
+
+func reportCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[ReportOptions](ctx)
+
+		dir := opts.Dir
+		switch {
+		case dir != "" && !filepath.IsAbs(dir):
+			dir = pocket.FromGitRoot(dir)
+		case dir == "":
+			dir = Dir()
+		}
+		if dir == "" {
+			dir = pocket.FromGitRoot(DefaultDir)
+		}
+		output := opts.Output
+		if output == "" {
+			output = DefaultOutput
+		}
+		if !filepath.IsAbs(output) {
+			output = pocket.FromGitRoot(output)
+		}
+
+		var files []string
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if p == dir && errors.Is(err, fs.ErrNotExist) {
+					return fs.SkipDir
+				}
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(p) == ".sarif" && p != output {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			pocket.Printf(ctx, "  No SARIF files in %s\n", dir)
+			return nil
+		}
+
+		logs := make([][]byte, 0, len(files))
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return fmt.Errorf("read %s: %w", f, err)
+			}
+			// Tools report URIs relative to the path they ran in.
+			rel, err := filepath.Rel(dir, filepath.Dir(f))
+			if err != nil {
+				return err
+			}
+			if data, err = Rebase(data, filepath.ToSlash(rel)); err != nil {
+				return fmt.Errorf("rebase %s: %w", f, err)
+			}
+			logs = append(logs, data)
+		}
+		merged, err := Merge(logs...)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+			return fmt.Errorf("create output dir: %w", err)
+		}
+		if err := os.WriteFile(output, merged, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", output, err)
+		}
+
+		if pocket.Verbose(ctx) {
+			pocket.Printf(ctx, "  Merged %d SARIF files into %s\n", len(files), output)
+		}
+		return nil
+	})
+}
+
+// sarifLog is the top-level SARIF document. Runs are kept as generic maps
+// so that tool-specific properties survive the merge.
+type sarifLog struct {
+	Schema  string           `json:"$schema"`
+	Version string           `json:"version"`
+	Runs    []map[string]any `json:"runs"`
+}
+
+// Merge combines SARIF 2.1.0 documents into one.
+// Runs are grouped by tool driver name: results and rules of later runs are
+// appended to the first run of the same tool, with rule and artifact indexes
+// rewritten to match.
+func Merge(logs ...[]byte) ([]byte, error) {
+	out := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []map[string]any{},
+	}
+	byTool := make(map[string]map[string]any)
+
+	for i, data := range logs {
+		var log sarifLog
+		if err := json.Unmarshal(data, &log); err != nil {
+			return nil, fmt.Errorf("parse sarif log %d: %w", i, err)
+		}
+		for _, run := range log.Runs {
+			tool := driverName(run)
+			base, ok := byTool[tool]
+			if !ok {
+				byTool[tool] = run
+				out.Runs = append(out.Runs, run)
+				continue
+			}
+			mergeRun(base, run)
+		}
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// Rebase prefixes relative artifact URIs in a SARIF document with dir,
+// so that results from a tool run in a subdirectory resolve from the git
+// root. Absolute paths and URIs with a scheme are left untouched.
+func Rebase(data []byte, dir string) ([]byte, error) {
+	dir = strings.Trim(dir, "/")
+	if dir == "" || dir == "." {
+		return data, nil
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	rebaseArtifactLocations(doc, dir)
+	return json.Marshal(doc)
+}
+
+// rebaseArtifactLocations walks v and prefixes every relative
+// artifactLocation.uri with dir.
+func rebaseArtifactLocations(v any, dir string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if k == "artifactLocation" || k == "location" {
+				if loc, ok := child.(map[string]any); ok {
+					if uri, ok := loc["uri"].(string); ok && isRelativeURI(uri) {
+						loc["uri"] = path.Join(dir, uri)
+					}
+				}
+			}
+			rebaseArtifactLocations(child, dir)
+		}
+	case []any:
+		for _, child := range v {
+			rebaseArtifactLocations(child, dir)
+		}
+	}
+}
+
+func isRelativeURI(uri string) bool {
+	if uri == "" || strings.HasPrefix(uri, "/") || filepath.IsAbs(uri) {
+		return false
+	}
+	u, err := url.Parse(uri)
+	return err == nil && u.Scheme == ""
+}
+
+// Finding is a single result in a SARIF document.
+type Finding struct {
+	RuleID  string
+	Level   string
+	Message string
+}
+
+// Findings returns the results of all runs in a SARIF document.
+// Results without an explicit level get the SARIF default, "warning".
+func Findings(data []byte) ([]Finding, error) {
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, run := range log.Runs {
+		results, _ := run["results"].([]any)
+		for _, res := range results {
+			f := Finding{
+				RuleID: stringField(res, "ruleId"),
+				Level:  stringField(res, "level"),
+			}
+			if res, ok := res.(map[string]any); ok {
+				f.Message = stringField(res["message"], "text")
+			}
+			if f.Level == "" {
+				f.Level = "warning"
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// driver returns run.tool.driver, or nil if missing.
+func driver(run map[string]any) map[string]any {
+	tool, _ := run["tool"].(map[string]any)
+	d, _ := tool["driver"].(map[string]any)
+	return d
+}
+
+func driverName(run map[string]any) string {
+	name, _ := driver(run)["name"].(string)
+	return name
+}
+
+// mergeRun appends the results of src to dst.
+func mergeRun(dst, src map[string]any) {
+	// Rules: dedupe by id and remember the new index of each.
+	dstDriver, srcDriver := driver(dst), driver(src)
+	dstRules, _ := dstDriver["rules"].([]any)
+	srcRules, _ := srcDriver["rules"].([]any)
+	ruleIndex := make(map[string]int)
+	for i, r := range dstRules {
+		if id := stringField(r, "id"); id != "" {
+			ruleIndex[id] = i
+		}
+	}
+	for _, r := range srcRules {
+		id := stringField(r, "id")
+		if _, ok := ruleIndex[id]; ok || id == "" {
+			continue
+		}
+		ruleIndex[id] = len(dstRules)
+		dstRules = append(dstRules, r)
+	}
+	if dstDriver != nil && len(dstRules) > 0 {
+		dstDriver["rules"] = dstRules
+	}
+
+	// Artifacts: append and shift artifact indexes in src results.
+	dstArtifacts, _ := dst["artifacts"].([]any)
+	srcArtifacts, _ := src["artifacts"].([]any)
+	offset := len(dstArtifacts)
+	if len(srcArtifacts) > 0 {
+		dst["artifacts"] = append(dstArtifacts, srcArtifacts...)
+	}
+
+	dstResults, _ := dst["results"].([]any)
+	srcResults, _ := src["results"].([]any)
+	for _, res := range srcResults {
+		result, ok := res.(map[string]any)
+		if !ok {
+			continue
+		}
+		id, _ := result["ruleId"].(string)
+		if id == "" {
+			if idx, ok := result["ruleIndex"].(float64); ok && int(idx) < len(srcRules) {
+				id = stringField(srcRules[int(idx)], "id")
+				result["ruleId"] = id
+			}
+		}
+		if idx, ok := ruleIndex[id]; ok {
+			result["ruleIndex"] = idx
+		} else {
+			delete(result, "ruleIndex")
+		}
+		if offset > 0 {
+			shiftArtifactIndexes(result, offset)
+		}
+		dstResults = append(dstResults, result)
+	}
+	dst["results"] = dstResults
+}
+
+// shiftArtifactIndexes adds offset to every artifactLocation.index in a result.
+func shiftArtifactIndexes(v any, offset int) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if k == "artifactLocation" {
+				if loc, ok := child.(map[string]any); ok {
+					if idx, ok := loc["index"].(float64); ok {
+						loc["index"] = int(idx) + offset
+					}
+				}
+				continue
+			}
+			shiftArtifactIndexes(child, offset)
+		}
+	case []any:
+		for _, child := range v {
+			shiftArtifactIndexes(child, offset)
+		}
+	}
+}
+
+func stringField(v any, key string) string {
+	m, _ := v.(map[string]any)
+	s, _ := m[key].(string)
+	return s
+}
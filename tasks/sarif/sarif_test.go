@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: MIT
+
+package sarif
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMerge_CombinesRunsOfSameTool(t *testing.T) {
+	a := `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"golangci-lint","rules":[{"id":"errcheck"}]}},
+		"results":[{"ruleId":"errcheck","ruleIndex":0}]}]}`
+	b := `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"golangci-lint","rules":[{"id":"govet"},{"id":"errcheck"}]}},
+		"artifacts":[{"location":{"uri":"b.go"}}],
+		"results":[{"ruleIndex":0,"locations":[{"physicalLocation":{"artifactLocation":{"uri":"b.go","index":0}}}]},
+		{"ruleId":"errcheck","ruleIndex":1}]}]}`
+	c := `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"govulncheck"}},"results":[]}]}`
+
+	data, err := Merge([]byte(a), []byte(b), []byte(c))
+	if err != nil {
+		t.Fatalf("Merge() failed: %v", err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("unmarshal merged log: %v", err)
+	}
+
+	if log.Version != "2.1.0" {
+		t.Errorf("expected version 2.1.0, got %q", log.Version)
+	}
+	if len(log.Runs) != 2 {
+		t.Fatalf("expected 2 runs (one per tool), got %d", len(log.Runs))
+	}
+	lint := log.Runs[0]
+	if lint.Tool.Driver.Name != "golangci-lint" {
+		t.Errorf("expected first run from golangci-lint, got %q", lint.Tool.Driver.Name)
+	}
+	if len(lint.Tool.Driver.Rules) != 2 {
+		t.Errorf("expected 2 deduplicated rules, got %d", len(lint.Tool.Driver.Rules))
+	}
+	want := []struct {
+		id  string
+		idx int
+	}{{"errcheck", 0}, {"govet", 1}, {"errcheck", 0}}
+	if len(lint.Results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(lint.Results))
+	}
+	for i, w := range want {
+		if lint.Results[i].RuleID != w.id || lint.Results[i].RuleIndex != w.idx {
+			t.Errorf("result %d: expected %s/%d, got %s/%d",
+				i, w.id, w.idx, lint.Results[i].RuleID, lint.Results[i].RuleIndex)
+		}
+	}
+}
+
+func TestMerge_InvalidJSON(t *testing.T) {
+	if _, err := Merge([]byte("not json")); err == nil {
+		t.Error("expected error for invalid SARIF")
+	}
+}
+
+func TestRebase(t *testing.T) {
+	in := `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"golangci-lint"}},
+		"artifacts":[{"location":{"uri":"main.go"}}],
+		"results":[{"locations":[
+			{"physicalLocation":{"artifactLocation":{"uri":"internal/a.go"}}},
+			{"physicalLocation":{"artifactLocation":{"uri":"/usr/lib/x.so"}}},
+			{"physicalLocation":{"artifactLocation":{"uri":"file:///tmp/b.go"}}}
+		]}]}]}`
+
+	data, err := Rebase([]byte(in), "services/api")
+	if err != nil {
+		t.Fatalf("Rebase() failed: %v", err)
+	}
+
+	var log struct {
+		Runs []struct {
+			Artifacts []struct {
+				Location struct {
+					URI string `json:"uri"`
+				} `json:"location"`
+			} `json:"artifacts"`
+			Results []struct {
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("unmarshal rebased log: %v", err)
+	}
+
+	run := log.Runs[0]
+	if got := run.Artifacts[0].Location.URI; got != "services/api/main.go" {
+		t.Errorf("artifact uri = %q, want services/api/main.go", got)
+	}
+	want := []string{"services/api/internal/a.go", "/usr/lib/x.so", "file:///tmp/b.go"}
+	for i, w := range want {
+		if got := run.Results[0].Locations[i].PhysicalLocation.ArtifactLocation.URI; got != w {
+			t.Errorf("location %d uri = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestRebase_RootIsUnchanged(t *testing.T) {
+	in := []byte(`{"runs":[]}`)
+	data, err := Rebase(in, ".")
+	if err != nil {
+		t.Fatalf("Rebase() failed: %v", err)
+	}
+	if string(data) != string(in) {
+		t.Errorf("expected root rebase to be a no-op, got %s", data)
+	}
+}
+
+func TestFindings(t *testing.T) {
+	in := `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"govulncheck"}},"results":[
+		{"ruleId":"GO-2024-0001","level":"error","message":{"text":"called"}},
+		{"ruleId":"GO-2024-0002","message":{"text":"imported"}}
+	]}]}`
+
+	findings, err := Findings([]byte(in))
+	if err != nil {
+		t.Fatalf("Findings() failed: %v", err)
+	}
+	want := []Finding{
+		{RuleID: "GO-2024-0001", Level: "error", Message: "called"},
+		{RuleID: "GO-2024-0002", Level: "warning", Message: "imported"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d", len(want), len(findings))
+	}
+	for i, w := range want {
+		if findings[i] != w {
+			t.Errorf("finding %d = %+v, want %+v", i, findings[i], w)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: MIT
+
+// Package schema provides schema validation of YAML and JSON files.
+// This is a "task" package - it orchestrates tools to do work.
+package schema
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/cue"
+)
+
+// Rule declares files that must validate against a schema.
+// Rules are declared in pocket.Config.Schemas.
+type Rule = pocket.SchemaRule
+
+// Validate is the schema-validate task, which validates the files matched
+// by the rules in pocket.Config.Schemas with cue vet. Failures are reported
+// per file and line, and annotated in GitHub Actions.
+//
+// Example usage in .pocket/config.go:
+//
+//	var Config = pocket.Config{
+//	    AutoRun: pocket.Serial(
+//	        schema.Validate,
+//	    ),
+//	    Schemas: []pocket.SchemaRule{
+//	        {Files: []string{"deploy/**/*.yaml"}, Schema: "schemas/k8s.cue", Definition: "#Manifest"},
+//	    },
+//	}
+var Validate = pocket.Task("schema-validate", "validate YAML/JSON files against schemas",
+	pocket.Serial(cue.Install, validateCmd()),
+)
+
+// configuredRules returns the rules declared in the config.
+func configuredRules(ctx context.Context) []Rule {
+	if plan := pocket.GetConfigPlan(ctx); plan != nil && plan.Config != nil {
+		return plan.Config.Schemas
+	}
+	return nil
+}
+
+func validateCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		rules := configuredRules(ctx)
+		if len(rules) == 0 {
+			if pocket.Verbose(ctx) {
+				pocket.Printf(ctx, "  no schema rules in pocket.Config.Schemas\n")
+			}
+			return nil
+		}
+
+		checked, failed := 0, 0
+		for _, rule := range rules {
+			files, err := MatchFiles(pocket.GitRoot(), rule.Files)
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				return fmt.Errorf("%s: no files match %s", rule.Schema, strings.Join(rule.Files, ", "))
+			}
+			for _, file := range files {
+				checked++
+				ok, err := vet(ctx, rule, file)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					failed++
+				}
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d file(s) failed schema validation", failed, checked)
+		}
+		if pocket.Verbose(ctx) {
+			pocket.Printf(ctx, "  %d file(s) valid\n", checked)
+		}
+		return nil
+	})
+}
+
+// vet validates file against the rule's schema and reports the failures.
+// It returns an error only if cue could not be run.
+func vet(ctx context.Context, rule Rule, file string) (bool, error) {
+	var out bytes.Buffer
+	cmd := pocket.Command(ctx, cue.Name, vetArgs(rule, file)...)
+	cmd.Dir = pocket.GitRoot()
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := pocket.RunCommand(ctx, cmd)
+	if err == nil {
+		if pocket.Verbose(ctx) {
+			pocket.Printf(ctx, "  ok: %s\n", file)
+		}
+		return true, nil
+	}
+	var toolErr *pocket.ToolError
+	if errors.As(err, &toolErr) || ctx.Err() != nil {
+		return false, err
+	}
+
+	problems := parseVetOutput(out.String(), file)
+	if len(problems) == 0 {
+		msg := strings.TrimSpace(out.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		problems = []problem{{msg: msg}}
+	}
+	for _, p := range problems {
+		if p.line > 0 {
+			pocket.Printf(ctx, "  %s:%d: %s\n", file, p.line, p.msg)
+		} else {
+			pocket.Printf(ctx, "  %s: %s\n", file, p.msg)
+		}
+		pocket.AnnotateError(ctx, file, p.line, "Schema validation", p.msg)
+	}
+	return false, nil
+}
+
+// vetArgs returns the cue vet arguments validating file against the rule's
+// schema. Qualifiers tell cue how to interpret each file.
+func vetArgs(rule Rule, file string) []string {
+	args := []string{"vet", "-c"}
+	if path.Ext(rule.Schema) == ".cue" {
+		if rule.Definition != "" {
+			args = append(args, "-d", rule.Definition)
+		}
+		args = append(args, rule.Schema)
+	} else {
+		args = append(args, "jsonschema:", rule.Schema)
+	}
+	if path.Ext(file) == ".json" {
+		return append(args, "json:", file)
+	}
+	return append(args, "yaml:", file)
+}
+
+// problem is a validation failure reported by cue vet.
+type problem struct {
+	msg  string
+	line int // line in the validated file, 0 if unknown
+}
+
+// positionRe matches a position line of a cue error, e.g. "    ./a.yaml:6:13".
+var positionRe = regexp.MustCompile(`^\s+(\S+):(\d+):\d+$`)
+
+// parseVetOutput parses the errors in cue vet output. Each error is a
+// message line followed by indented position lines; the first position in
+// file is the error's line.
+func parseVetOutput(out, file string) []problem {
+	var problems []problem
+	for line := range strings.Lines(out) {
+		line = strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if m := positionRe.FindStringSubmatch(line); m != nil {
+			if len(problems) == 0 {
+				continue
+			}
+			p := &problems[len(problems)-1]
+			if p.line == 0 && path.Clean(m[1]) == file {
+				p.line, _ = strconv.Atoi(m[2])
+			}
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		problems = append(problems, problem{msg: strings.TrimSuffix(line, ":")})
+	}
+	return problems
+}
+
+// MatchFiles returns the files below root matching any of the globs, as
+// slash-separated paths relative to root, in lexical order. The .git and
+// node_modules directories are skipped.
+func MatchFiles(root string, globs []string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); p != root && (name == ".git" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, glob := range globs {
+			if pocket.MatchGlob(glob, rel) {
+				files = append(files, rel)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find schema files: %w", err)
+	}
+	return files, nil
+}
@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: MIT
+
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestVetArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		file string
+		want []string
+	}{
+		{
+			name: "cue definition",
+			rule: Rule{Schema: "schemas/k8s.cue", Definition: "#Manifest"},
+			file: "deploy/app.yaml",
+			want: []string{"vet", "-c", "-d", "#Manifest", "schemas/k8s.cue", "yaml:", "deploy/app.yaml"},
+		},
+		{
+			name: "json schema",
+			rule: Rule{Schema: "schemas/config.schema.json", Definition: "#Ignored"},
+			file: "config/app.json",
+			want: []string{"vet", "-c", "jsonschema:", "schemas/config.schema.json", "json:", "config/app.json"},
+		},
+		{
+			name: "yaml json schema",
+			rule: Rule{Schema: "schema.yaml"},
+			file: "app.yml",
+			want: []string{"vet", "-c", "jsonschema:", "schema.yaml", "yaml:", "app.yml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vetArgs(tt.rule, tt.file); !slices.Equal(got, tt.want) {
+				t.Errorf("vetArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVetOutput(t *testing.T) {
+	out := `spec.replicas: conflicting values 3 and string (mismatched types int and string):
+    ./schemas/k8s.cue:4:13
+    ./deploy/app.yaml:6:13
+metadata.labels: field not allowed:
+    ./deploy/app.yaml:3:5
+    ./schemas/k8s.cue:9:2
+kind: incomplete value string
+`
+	got := parseVetOutput(out, "deploy/app.yaml")
+	want := []problem{
+		{msg: "spec.replicas: conflicting values 3 and string (mismatched types int and string)", line: 6},
+		{msg: "metadata.labels: field not allowed", line: 3},
+		{msg: "kind: incomplete value string"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseVetOutput() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMatchFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{
+		"deploy/app.yaml",
+		"deploy/base/db.yaml",
+		"deploy/README.md",
+		"config.json",
+		".git/config.yaml",
+		"node_modules/pkg/x.yaml",
+	} {
+		writeFile(t, filepath.Join(root, f), "x")
+	}
+
+	got, err := MatchFiles(root, []string{"deploy/**/*.yaml", "*.json", "**/x.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"config.json", "deploy/app.yaml", "deploy/base/db.yaml"}
+	if !slices.Equal(got, want) {
+		t.Errorf("MatchFiles() = %q, want %q", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	root := t.TempDir()
+	restore := gitroot.Override(root)
+	defer restore()
+	writeFile(t, filepath.Join(root, "deploy/good.yaml"), "replicas: 1\n")
+	writeFile(t, filepath.Join(root, "deploy/bad.yaml"), "replicas: x\n")
+	t.Setenv("GITHUB_ACTIONS", "true")
+
+	var stdout strings.Builder
+	var vetted []string
+	ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{
+		Output: &pocket.Output{Stdout: &stdout, Stderr: &stdout},
+		ConfigPlan: pocket.BuildConfigPlan(pocket.Config{
+			Schemas: []pocket.SchemaRule{{Files: []string{"deploy/*.yaml"}, Schema: "s.cue", Definition: "#D"}},
+		}),
+		Runner: func(cmd *exec.Cmd) error {
+			file := cmd.Args[len(cmd.Args)-1]
+			vetted = append(vetted, file)
+			if file == "deploy/bad.yaml" {
+				fmt.Fprintf(cmd.Stderr, "#D.replicas: conflicting values \"x\" and int:\n    ./s.cue:1:13\n    ./%s:1:11\n", file)
+				return errors.New("exit status 1")
+			}
+			return nil
+		},
+	})
+
+	err := pocket.Task("validate", "validate", validateCmd()).Run(ctx)
+	if err == nil || err.Error() != "1 of 2 file(s) failed schema validation" {
+		t.Fatalf("validate = %v, want one failure", err)
+	}
+	if want := []string{"deploy/bad.yaml", "deploy/good.yaml"}; !slices.Equal(vetted, want) {
+		t.Errorf("vetted %q, want %q", vetted, want)
+	}
+	for _, want := range []string{
+		`  deploy/bad.yaml:1: #D.replicas: conflicting values "x" and int`,
+		`::error file=deploy/bad.yaml,line=1,title=Schema validation::#D.replicas: conflicting values "x" and int`,
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output %q does not contain %q", stdout.String(), want)
+		}
+	}
+}
+
+func TestValidate_NoMatches(t *testing.T) {
+	root := t.TempDir()
+	restore := gitroot.Override(root)
+	defer restore()
+
+	ctx := pocket.NewRunContext(context.Background(), pocket.RunContextOptions{
+		Output: &pocket.Output{Stdout: &strings.Builder{}, Stderr: &strings.Builder{}},
+		ConfigPlan: pocket.BuildConfigPlan(pocket.Config{
+			Schemas: []pocket.SchemaRule{{Files: []string{"deploy/*.yaml"}, Schema: "s.cue"}},
+		}),
+		Runner: func(*exec.Cmd) error { return nil },
+	})
+	err := pocket.Task("validate", "validate", validateCmd()).Run(ctx)
+	if err == nil || !strings.Contains(err.Error(), "no files match deploy/*.yaml") {
+		t.Errorf("validate = %v, want error for a rule without files", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
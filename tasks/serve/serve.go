@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: MIT
+
+// Package serve provides a local static file server for reviewing build
+// output such as coverage reports and documentation.
+// This is a "task" package - it orchestrates tools to do work.
+package serve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// DefaultAddr is the address the serve task listens on.
+const DefaultAddr = "localhost:8000"
+
+// reloadPath is the server-sent events endpoint that live-reload listens on.
+const reloadPath = "/__pocket/reload"
+
+// pollInterval is how often the served directory is checked for changes.
+var pollInterval = 500 * time.Millisecond
+
+// reloadScript is injected into HTML pages to reload them on changes.
+const reloadScript = `<script>new EventSource("` + reloadPath + `").onmessage = () => location.reload();</script>`
+
+// Options configures the serve task.
+type Options struct {
+	Dir        string `arg:"dir"         usage:"directory to serve, relative to the git root (default: task path)"`
+	Addr       string `arg:"addr"        usage:"address to listen on (default: localhost:8000)"`
+	SkipReload bool   `arg:"skip-reload" usage:"disable live-reload of HTML pages"`
+}
+
+// Task serves a directory over HTTP until interrupted. HTML pages reload
+// in the browser when a file in the directory changes.
+//
+// Configure the directory in .pocket/config.go:
+//
+//	ManualRun: []pocket.Runnable{
+//	    pocket.WithOpts(serve.Task, serve.Options{Dir: "docs/_build/html"}),
+//	},
+var Task = pocket.Task("serve", "serve a directory on localhost with live-reload",
+	serveCmd(),
+	pocket.Opts(Options{}),
+	pocket.WithExample("./pok serve -dir=docs/_build/html", "serve built documentation"),
+)
+
+func serveCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[Options](ctx)
+		dir := opts.Dir
+		if dir == "" {
+			dir = pocket.Path(ctx)
+		}
+		root := pocket.FromGitRoot(filepath.FromSlash(dir))
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("serve: %s is not a directory", dir)
+		}
+		addr := opts.Addr
+		if addr == "" {
+			addr = DefaultAddr
+		}
+
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("serve: %w", err)
+		}
+		h := newHandler(ctx, root, !opts.SkipReload)
+		if !opts.SkipReload {
+			go h.watch(ctx, fingerprint(root))
+		}
+		srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdownCtx)
+		}()
+
+		pocket.Printf(ctx, "Serving %s at http://%s (press Ctrl-C to stop)\n", dir, ln.Addr())
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("serve: %w", err)
+		}
+		return nil
+	})
+}
+
+// handler serves files from root and, with reload enabled, injects the
+// live-reload script into HTML pages.
+type handler struct {
+	ctx    context.Context
+	root   string
+	reload bool
+	files  http.Handler
+
+	mu      sync.Mutex
+	changed chan struct{} // closed and replaced on every change
+}
+
+func newHandler(ctx context.Context, root string, reload bool) *handler {
+	return &handler{
+		ctx:     ctx,
+		root:    root,
+		reload:  reload,
+		files:   http.FileServer(http.Dir(root)),
+		changed: make(chan struct{}),
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.reload {
+		h.files.ServeHTTP(w, r)
+		return
+	}
+	if r.URL.Path == reloadPath {
+		h.serveEvents(w, r)
+		return
+	}
+	name := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+	if !strings.HasSuffix(name, ".html") {
+		h.files.ServeHTTP(w, r)
+		return
+	}
+	file := filepath.Join(h.root, filepath.FromSlash(name))
+	info, err := os.Stat(file)
+	if err != nil || info.IsDir() {
+		h.files.ServeHTTP(w, r)
+		return
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(injectReload(data)))
+}
+
+// injectReload adds the live-reload script before </body>, or at the end
+// of a page without one.
+func injectReload(page []byte) []byte {
+	i := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if i < 0 {
+		return append(page, reloadScript...)
+	}
+	out := make([]byte, 0, len(page)+len(reloadScript))
+	out = append(out, page[:i]...)
+	out = append(out, reloadScript...)
+	return append(out, page[i:]...)
+}
+
+// serveEvents sends a server-sent event when the served files change.
+func (h *handler) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	h.mu.Lock()
+	changed := h.changed
+	h.mu.Unlock()
+	select {
+	case <-changed:
+		fmt.Fprint(w, "data: reload\n\n")
+		flusher.Flush()
+	case <-r.Context().Done():
+	case <-h.ctx.Done():
+	}
+}
+
+// notify wakes up all clients waiting for a change.
+func (h *handler) notify() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
+
+// watch polls root and notifies clients when its fingerprint changes from
+// last, until ctx is done.
+func (h *handler) watch(ctx context.Context, last string) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if fp := fingerprint(h.root); fp != last {
+				last = fp
+				h.notify()
+			}
+		}
+	}
+}
+
+// fingerprint summarizes the files below root by count, total size and
+// latest modification time.
+func fingerprint(root string) string {
+	var count, size int64
+	var latest time.Time
+	_ = filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		count++
+		size += info.Size()
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return fmt.Sprintf("%d/%d/%d", count, size, latest.UnixNano())
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: MIT
+
+package serve
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInjectReload(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"before body end", "<html><BODY>hi</BODY></html>", "<html><BODY>hi" + reloadScript + "</BODY></html>"},
+		{"no body", "<p>hi</p>", "<p>hi</p>" + reloadScript},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(injectReload([]byte(tt.page))); got != tt.want {
+				t.Errorf("injectReload() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"index.html":   "<body>index</body>",
+		"style.css":    "body {}",
+		"sub/old.html": "<body>old</body>",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := newHandler(ctx, root, true)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	get := func(path string) string {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+	if got, want := get("/"), "<body>index"+reloadScript+"</body>"; got != want {
+		t.Errorf("GET / = %q, want %q", got, want)
+	}
+	if got, want := get("/sub/old.html"), "<body>old"+reloadScript+"</body>"; got != want {
+		t.Errorf("GET /sub/old.html = %q, want %q", got, want)
+	}
+	if got, want := get("/style.css"), "body {}"; got != want {
+		t.Errorf("GET /style.css = %q, want %q", got, want)
+	}
+
+	defer func(d time.Duration) { pollInterval = d }(pollInterval)
+	pollInterval = 10 * time.Millisecond
+	go h.watch(ctx, fingerprint(root))
+	resp, err := http.Get(srv.URL + reloadPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := os.WriteFile(filepath.Join(root, "new.html"), []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(line, "data: reload") {
+		t.Errorf("reload event = %q, want data: reload", line)
+	}
+}
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: MIT
+
+// Package toolversions reports the pinned versions of managed tools.
+// This is a "task" package - it orchestrates tools to do work.
+package toolversions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Task prints every managed tool in the config with its pinned version and
+// the latest upstream version. Tools declare their upstream with
+// pocket.ToolSource.
+//
+// With -fix (see pocket.Fix), version constants annotated for Renovate are rewritten to the
+// latest version:
+//
+//	// renovate: datasource=go depName=github.com/org/tool
+//	const Version = "v1.0.0"
+//
+// This is a lightweight alternative to Renovate for the tool packages in the
+// repository, e.g. in pocket itself or in a shared tools package.
+//
+// Example usage in .pocket/config.go:
+//
+//	ManualRun: []pocket.Runnable{
+//	    toolversions.Task,
+//	},
+var Task = pocket.Task("tools-versions", "show pinned and latest versions of managed tools",
+	pocket.Do(run),
+	pocket.WithExample("./pok tools-versions -fix", "update annotated version constants"),
+)
+
+func run(ctx context.Context) error {
+	tools, err := configTools(pocket.GetConfigPlan(ctx))
+	if err != nil {
+		return err
+	}
+	if len(tools) == 0 {
+		pocket.Println(ctx, "No managed tools in the config.")
+		return nil
+	}
+
+	latest := make(map[string]string, len(tools))
+	var errs []error
+	w := tabwriter.NewWriter(pocket.GetOutput(ctx).Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOOL\tPINNED\tLATEST\tSOURCE")
+	for _, tool := range tools {
+		source, version := "-", "-"
+		if tool.DepName != "" {
+			source = tool.Datasource + ":" + tool.DepName
+			v, err := latestVersion(ctx, tool)
+			switch {
+			case err != nil:
+				version = "error"
+				errs = append(errs, fmt.Errorf("%s: %w", tool.Name, err))
+			case v == tool.Version:
+				version = v
+			default:
+				version = v + " (update available)"
+				latest[tool.Datasource+" "+tool.DepName] = v
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", tool.Name, tool.Version, version, source)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, err := range errs {
+		pocket.Printf(ctx, "Warning: %v\n", err)
+	}
+
+	if !pocket.Fix(ctx, false) || len(latest) == 0 {
+		return nil
+	}
+	files, err := fixVersions(pocket.FromGitRoot(), latest)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		pocket.Printf(ctx, "Updated %s\n", f)
+	}
+	if len(files) == 0 {
+		pocket.Println(ctx, "No Renovate-annotated version constants to update in this repository.")
+	}
+	return nil
+}
+
+// configTools returns the managed tools of the config's AutoRun and
+// ManualRun trees, sorted by name.
+func configTools(plan *pocket.ConfigPlan) ([]pocket.ToolInfo, error) {
+	if plan == nil || plan.Config == nil {
+		return nil, errors.New("tools-versions: config not available")
+	}
+	roots := slices.Clone(plan.Config.ManualRun)
+	if plan.Config.AutoRun != nil {
+		roots = append(roots, plan.Config.AutoRun)
+	}
+	seen := make(map[string]bool)
+	var tools []pocket.ToolInfo
+	for _, r := range roots {
+		tasks, err := pocket.CollectTasks(r)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			if t.Tool == nil || seen[t.Tool.Name+"@"+t.Tool.Version] {
+				continue
+			}
+			seen[t.Tool.Name+"@"+t.Tool.Version] = true
+			tools = append(tools, *t.Tool)
+		}
+	}
+	slices.SortFunc(tools, func(a, b pocket.ToolInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return tools, nil
+}
+
+// Upstream endpoints, replaced in tests.
+var (
+	goProxyURL   = "https://proxy.golang.org"
+	githubAPIURL = "https://api.github.com"
+)
+
+// latestVersion looks up the latest upstream version of tool, formatted
+// like its pinned version.
+func latestVersion(ctx context.Context, tool pocket.ToolInfo) (string, error) {
+	switch tool.Datasource {
+	case pocket.DatasourceGo:
+		var info struct{ Version string }
+		if err := getJSON(ctx, goProxyURL+"/"+escapeModulePath(tool.DepName)+"/@latest", &info); err != nil {
+			return "", err
+		}
+		return info.Version, nil
+	case pocket.DatasourceGitHubReleases:
+		var release struct {
+			TagName string `json:"tag_name"`
+		}
+		if err := getJSON(ctx, githubAPIURL+"/repos/"+tool.DepName+"/releases/latest", &release); err != nil {
+			return "", err
+		}
+		return matchVersionFormat(release.TagName, tool.Version), nil
+	default:
+		return "", fmt.Errorf("unsupported datasource %q", tool.Datasource)
+	}
+}
+
+func getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && strings.HasPrefix(url, githubAPIURL) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// escapeModulePath escapes a module path for the Go module proxy, which
+// encodes upper-case letters as "!" followed by the lower-case letter.
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// matchVersionFormat strips release tag prefixes such as "bun-v" or "v"
+// from tag, keeping a "v" prefix only if pinned has one.
+func matchVersionFormat(tag, pinned string) string {
+	i := strings.IndexFunc(tag, unicode.IsDigit)
+	if i < 0 {
+		return tag
+	}
+	if strings.HasPrefix(pinned, "v") {
+		return "v" + tag[i:]
+	}
+	return tag[i:]
+}
+
+// renovateConstRe matches a Renovate-annotated version constant.
+var renovateConstRe = regexp.MustCompile(
+	`(// renovate: datasource=(\S+) depName=(\S+)[^\n]*\n\s*const \w+\s*=\s*")([^"]+)(")`,
+)
+
+// fixVersions rewrites Renovate-annotated version constants in the Go files
+// below root to the versions in latest, keyed by "<datasource> <depName>".
+// Returns the updated files relative to root.
+func fixVersions(root string, latest map[string]string) ([]string, error) {
+	var updated []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") && d.Name() != pocket.DirName ||
+				d.Name() == "vendor" || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			if path == filepath.Join(root, pocket.DirName, pocket.ToolsDirName) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		changed := false
+		out := renovateConstRe.ReplaceAllFunc(data, func(m []byte) []byte {
+			sub := renovateConstRe.FindSubmatch(m)
+			v, ok := latest[string(sub[2])+" "+string(sub[3])]
+			if !ok || v == string(sub[4]) {
+				return m
+			}
+			changed = true
+			return slices.Concat(sub[1], []byte(v), sub[5])
+		})
+		if !changed {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		updated = append(updated, filepath.ToSlash(rel))
+		return nil
+	})
+	return updated, err
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: MIT
+
+package toolversions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+func TestLatestVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/github.com/!burnt!sushi/toml/@latest":
+			w.Write([]byte(`{"Version":"v1.5.0"}`))
+		case "/repos/oven-sh/bun/releases/latest":
+			w.Write([]byte(`{"tag_name":"bun-v1.3.7"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	oldProxy, oldAPI := goProxyURL, githubAPIURL
+	goProxyURL, githubAPIURL = srv.URL, srv.URL
+	t.Cleanup(func() { goProxyURL, githubAPIURL = oldProxy, oldAPI })
+
+	tests := []struct {
+		tool    pocket.ToolInfo
+		want    string
+		wantErr bool
+	}{
+		{
+			tool: pocket.ToolInfo{Version: "v1.4.0", Datasource: pocket.DatasourceGo, DepName: "github.com/BurntSushi/toml"},
+			want: "v1.5.0",
+		},
+		{
+			tool: pocket.ToolInfo{Version: "1.3.6", Datasource: pocket.DatasourceGitHubReleases, DepName: "oven-sh/bun"},
+			want: "1.3.7",
+		},
+		{
+			tool:    pocket.ToolInfo{Version: "1.0.0", Datasource: pocket.DatasourceGitHubReleases, DepName: "org/missing"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool.DepName, func(t *testing.T) {
+			got, err := latestVersion(context.Background(), tt.tool)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("latestVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("latestVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchVersionFormat(t *testing.T) {
+	tests := []struct{ tag, pinned, want string }{
+		{"v0.59.0", "0.58.1", "0.59.0"},
+		{"bun-v1.3.7", "1.3.6", "1.3.7"},
+		{"2.0.0", "v1.0.0", "v2.0.0"},
+		{"nightly", "1.0.0", "nightly"},
+	}
+	for _, tt := range tests {
+		if got := matchVersionFormat(tt.tag, tt.pinned); got != tt.want {
+			t.Errorf("matchVersionFormat(%q, %q) = %q, want %q", tt.tag, tt.pinned, got, tt.want)
+		}
+	}
+}
+
+func TestFixVersions(t *testing.T) {
+	root := t.TempDir()
+	src := `package tool
+
+// renovate: datasource=github-releases depName=oven-sh/bun extractVersion=^bun-v(?<version>.*)$
+const Version = "1.3.6"
+
+// renovate: datasource=go depName=golang.org/x/vuln
+const VulnVersion = "v1.1.4"
+`
+	want := `package tool
+
+// renovate: datasource=github-releases depName=oven-sh/bun extractVersion=^bun-v(?<version>.*)$
+const Version = "1.3.7"
+
+// renovate: datasource=go depName=golang.org/x/vuln
+const VulnVersion = "v1.1.4"
+`
+	path := filepath.Join(root, "tools", "tool.go")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := fixVersions(root, map[string]string{"github-releases oven-sh/bun": "1.3.7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(files, []string{"tools/tool.go"}) {
+		t.Errorf("updated files = %v", files)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConfigTools(t *testing.T) {
+	install := pocket.Task("install:tool", "install tool", func(context.Context) error { return nil },
+		pocket.AsHidden(),
+		pocket.AsTool("tool", "v1.0.0"),
+		pocket.ToolSource(pocket.DatasourceGo, "example.com/tool"),
+	)
+	lint := pocket.Task("lint", "lint", pocket.Serial(install, func(context.Context) error { return nil }))
+	cfg := pocket.Config{AutoRun: lint, ManualRun: []pocket.Runnable{install}}
+
+	tools, err := configTools(pocket.BuildConfigPlan(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []pocket.ToolInfo{{Name: "tool", Version: "v1.0.0", Datasource: "go", DepName: "example.com/tool"}}
+	if !slices.Equal(tools, want) {
+		t.Errorf("configTools() = %+v, want %+v", tools, want)
+	}
+}
@@ -0,0 +1,264 @@
+// SPDX-License-Identifier: MIT
+
+// Package vscode generates Visual Studio Code task and launch configuration
+// that run pocket tasks, so "Run Build Task" (Cmd-Shift-B) uses the same
+// toolchain as CI.
+// This is a "task" package - it orchestrates tools to do work.
+package vscode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// header marks the files as generated. VS Code reads both files as JSON
+// with comments.
+const header = "// Code generated by pocket. DO NOT EDIT.\n// Run ./pok generate to regenerate.\n"
+
+// Config configures the vscode task.
+type Config struct {
+	// DefaultBuild is the task run by "Run Build Task" (Cmd-Shift-B).
+	// Default: "" (./pok, running all auto-run tasks).
+	DefaultBuild string
+
+	// DefaultTest is the task run by "Run Test Task".
+	// Default: none.
+	DefaultTest string
+
+	// SkipLaunch skips .vscode/launch.json, which debugs a pocket task.
+	SkipLaunch bool
+}
+
+// problemMatchers maps task names to the VS Code problem matcher for their
+// output, so findings show up in the Problems panel.
+var problemMatchers = map[string]any{
+	// golangci-lint: "path/file.go:12:3: message (linter)"
+	"go-lint": problemMatcher{
+		Owner:        "golangci-lint",
+		FileLocation: []string{"autoDetect", "${workspaceFolder}"},
+		Pattern: problemPattern{
+			Regexp: `^(.+?\.go):(\d+):(\d+): (.+?) \((\w+)\)$`,
+			File:   1, Line: 2, Column: 3, Message: 4, Code: 5,
+		},
+	},
+	// ruff: "path/file.py:12:3: E501 [*] message"
+	"py-lint": problemMatcher{
+		Owner:        "ruff",
+		FileLocation: []string{"autoDetect", "${workspaceFolder}"},
+		Pattern: problemPattern{
+			Regexp: `^(.+?\.py):(\d+):(\d+): ([A-Z]+\d+) (?:\[\*\] )?(.+)$`,
+			File:   1, Line: 2, Column: 3, Code: 4, Message: 5,
+		},
+	},
+}
+
+type problemMatcher struct {
+	Owner        string         `json:"owner"`
+	FileLocation []string       `json:"fileLocation"`
+	Pattern      problemPattern `json:"pattern"`
+}
+
+type problemPattern struct {
+	Regexp  string `json:"regexp"`
+	File    int    `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message int    `json:"message"`
+	Code    int    `json:"code,omitempty"`
+}
+
+type tasksFile struct {
+	Version string `json:"version"`
+	Tasks   []task `json:"tasks"`
+}
+
+type task struct {
+	Label          string   `json:"label"`
+	Detail         string   `json:"detail,omitempty"`
+	Type           string   `json:"type"`
+	Command        string   `json:"command"`
+	Args           []string `json:"args,omitempty"`
+	Windows        *command `json:"windows,omitempty"`
+	Group          *group   `json:"group,omitempty"`
+	ProblemMatcher any      `json:"problemMatcher"`
+}
+
+type command struct {
+	Command string `json:"command"`
+}
+
+type group struct {
+	Kind      string `json:"kind"`
+	IsDefault bool   `json:"isDefault,omitempty"`
+}
+
+type launchFile struct {
+	Version        string         `json:"version"`
+	Configurations []launchConfig `json:"configurations"`
+	Inputs         []input        `json:"inputs"`
+}
+
+type launchConfig struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Request string   `json:"request"`
+	Mode    string   `json:"mode"`
+	Program string   `json:"program"`
+	Cwd     string   `json:"cwd"`
+	Args    []string `json:"args"`
+}
+
+type input struct {
+	ID          string   `json:"id"`
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Options     []string `json:"options"`
+}
+
+// Task creates the vscode task. It writes .vscode/tasks.json with a task per
+// pocket task listed by ./pok -h, and .vscode/launch.json with a
+// configuration that debugs a pocket task picked from the same list (needs
+// the Go extension). Both files are owned by pocket and overwritten.
+//
+// Example usage in .pocket/config.go:
+//
+//	var Config = pocket.Config{
+//	    Generate: []pocket.Runnable{
+//	        vscode.Task(vscode.Config{DefaultTest: "go-test"}),
+//	    },
+//	}
+func Task(cfg Config) *pocket.TaskDef {
+	return pocket.Task("vscode", "generate VS Code tasks.json and launch.json",
+		pocket.Do(func(ctx context.Context) error {
+			plan := pocket.GetConfigPlan(ctx)
+			if plan == nil || plan.Config == nil {
+				return fmt.Errorf("vscode: config not available")
+			}
+			shim := plan.Config.WithDefaults().Shim
+			tasks := plan.VisibleTasks(".")
+			files := map[string]func() ([]byte, error){
+				"tasks.json": func() ([]byte, error) { return GenerateTasks(shim, tasks, cfg) },
+			}
+			if !cfg.SkipLaunch {
+				files["launch.json"] = func() ([]byte, error) { return GenerateLaunch(tasks) }
+			}
+			for name, generate := range files {
+				content, err := generate()
+				if err != nil {
+					return err
+				}
+				if err := writeFile(pocket.FromGitRoot(".vscode", name), content); err != nil {
+					return err
+				}
+				if pocket.Verbose(ctx) {
+					pocket.Printf(ctx, "  Generated .vscode/%s\n", name)
+				}
+			}
+			return nil
+		}),
+	)
+}
+
+// GenerateTasks renders .vscode/tasks.json for tasks, run through the
+// shims configured by shim.
+func GenerateTasks(shim *pocket.ShimConfig, tasks []*pocket.TaskDef, cfg Config) ([]byte, error) {
+	posix := "./" + shim.Name
+	var windows *command
+	switch {
+	case shim.Windows:
+		windows = &command{Command: `.\` + shim.Name + ".cmd"}
+	case shim.PowerShell:
+		windows = &command{Command: `.\` + shim.Name + ".ps1"}
+	}
+
+	all := task{
+		Label:          shim.Name,
+		Detail:         "run all auto-run tasks",
+		Type:           "shell",
+		Command:        posix,
+		Windows:        windows,
+		ProblemMatcher: []string{},
+	}
+	if cfg.DefaultBuild == "" {
+		all.Group = &group{Kind: "build", IsDefault: true}
+	}
+	file := tasksFile{Version: "2.0.0", Tasks: []task{all}}
+	for _, t := range tasks {
+		vt := task{
+			Label:          shim.Name + ": " + t.Name(),
+			Detail:         t.Usage(),
+			Type:           "shell",
+			Command:        posix,
+			Args:           []string{t.Name()},
+			Windows:        windows,
+			ProblemMatcher: []string{},
+		}
+		if m, ok := problemMatchers[t.Name()]; ok {
+			vt.ProblemMatcher = m
+		}
+		switch t.Name() {
+		case cfg.DefaultBuild:
+			vt.Group = &group{Kind: "build", IsDefault: true}
+		case cfg.DefaultTest:
+			vt.Group = &group{Kind: "test", IsDefault: true}
+		}
+		file.Tasks = append(file.Tasks, vt)
+	}
+	return marshal(file)
+}
+
+// GenerateLaunch renders .vscode/launch.json, which debugs the pocket task
+// runner in .pocket with a task picked from tasks.
+func GenerateLaunch(tasks []*pocket.TaskDef) ([]byte, error) {
+	names := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		names = append(names, t.Name())
+	}
+	file := launchFile{
+		Version: "0.2.0",
+		Configurations: []launchConfig{{
+			Name:    "pocket: debug task",
+			Type:    "go",
+			Request: "launch",
+			Mode:    "auto",
+			Program: "${workspaceFolder}/" + pocket.DirName,
+			Cwd:     "${workspaceFolder}",
+			Args:    []string{"${input:pocketTask}"},
+		}},
+		Inputs: []input{{
+			ID:          "pocketTask",
+			Type:        "pickString",
+			Description: "pocket task to debug",
+			Options:     names,
+		}},
+	}
+	return marshal(file)
+}
+
+func marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: MIT
+
+package vscode
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// decode strips the header comment and decodes a generated file.
+func decode(t *testing.T, data []byte, v any) {
+	t.Helper()
+	body, ok := strings.CutPrefix(string(data), header)
+	if !ok {
+		t.Fatalf("missing header:\n%s", data)
+	}
+	if err := json.Unmarshal([]byte(body), v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateTasks(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	tasks := []*pocket.TaskDef{
+		pocket.Task("go-lint", "run golangci-lint", noop),
+		pocket.Task("go-test", "run Go tests", noop),
+	}
+	shim := &pocket.ShimConfig{Name: "pok", Posix: true, Windows: true}
+
+	data, err := GenerateTasks(shim, tasks, Config{DefaultTest: "go-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Tasks []struct {
+			Label          string
+			Command        string
+			Args           []string
+			Windows        *command
+			Group          *group
+			ProblemMatcher json.RawMessage
+		}
+	}
+	decode(t, data, &file)
+
+	var labels []string
+	for _, task := range file.Tasks {
+		labels = append(labels, task.Label)
+		if task.Command != "./pok" || task.Windows == nil || task.Windows.Command != `.\pok.cmd` {
+			t.Errorf("%s: command = %q, windows = %+v", task.Label, task.Command, task.Windows)
+		}
+	}
+	if want := []string{"pok", "pok: go-lint", "pok: go-test"}; !slices.Equal(labels, want) {
+		t.Fatalf("labels = %q, want %q", labels, want)
+	}
+	if g := file.Tasks[0].Group; g == nil || g.Kind != "build" || !g.IsDefault {
+		t.Errorf("pok group = %+v, want default build", g)
+	}
+	if g := file.Tasks[2].Group; g == nil || g.Kind != "test" || !g.IsDefault {
+		t.Errorf("go-test group = %+v, want default test", g)
+	}
+	if !slices.Equal(file.Tasks[1].Args, []string{"go-lint"}) {
+		t.Errorf("go-lint args = %q", file.Tasks[1].Args)
+	}
+	if !strings.Contains(string(file.Tasks[1].ProblemMatcher), `"owner": "golangci-lint"`) {
+		t.Errorf("go-lint problem matcher = %s", file.Tasks[1].ProblemMatcher)
+	}
+	if string(file.Tasks[2].ProblemMatcher) != "[]" {
+		t.Errorf("go-test problem matcher = %s, want []", file.Tasks[2].ProblemMatcher)
+	}
+}
+
+func TestProblemMatchers(t *testing.T) {
+	tests := []struct {
+		task string
+		line string
+		want []string // file, line, column, message, code
+	}{
+		{
+			task: "go-lint",
+			line: "internal/app/app.go:12:3: Error return value is not checked (errcheck)",
+			want: []string{"internal/app/app.go", "12", "3", "Error return value is not checked", "errcheck"},
+		},
+		{
+			task: "py-lint",
+			line: "src/app/main.py:4:1: F401 [*] `os` imported but unused",
+			want: []string{"src/app/main.py", "4", "1", "`os` imported but unused", "F401"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.task, func(t *testing.T) {
+			p := problemMatchers[tt.task].(problemMatcher).Pattern
+			m := regexp.MustCompile(p.Regexp).FindStringSubmatch(tt.line)
+			if m == nil {
+				t.Fatalf("%q does not match %q", p.Regexp, tt.line)
+			}
+			got := []string{m[p.File], m[p.Line], m[p.Column], m[p.Message], m[p.Code]}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("groups = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateLaunch(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	data, err := GenerateLaunch([]*pocket.TaskDef{pocket.Task("go-test", "run Go tests", noop)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file launchFile
+	decode(t, data, &file)
+	if len(file.Configurations) != 1 || file.Configurations[0].Program != "${workspaceFolder}/.pocket" {
+		t.Errorf("configurations = %+v", file.Configurations)
+	}
+	if len(file.Inputs) != 1 || !slices.Equal(file.Inputs[0].Options, []string{"go-test"}) {
+		t.Errorf("inputs = %+v", file.Inputs)
+	}
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/prettier"
+)
+
+// FormatOptions configures the web-format task.
+type FormatOptions struct {
+	Patterns string `arg:"patterns" usage:"comma-separated globs relative to the path (default: HTML, CSS, SCSS, Less, Vue and Svelte files)"`
+	Check    bool   `arg:"check"    usage:"check only, don't write"`
+}
+
+// sveltePlugin is the prettier plugin needed to format Svelte files.
+const sveltePlugin = "prettier-plugin-svelte"
+
+// Format formats HTML, CSS, SCSS, Less, Vue and Svelte files using
+// prettier. Svelte files are formatted only if prettier-plugin-svelte is
+// installed in the project's node_modules; otherwise they are skipped.
+var Format = pocket.Task("web-format", "format HTML, CSS and component files",
+	pocket.Serial(prettier.Install, formatCmd()),
+	pocket.Opts(FormatOptions{}),
+	pocket.WithInputs(Inputs...),
+)
+
+func formatCmd() pocket.Runnable {
+	return pocket.Do(func(ctx context.Context) error {
+		opts := pocket.Options[FormatOptions](ctx)
+		dir := pocket.FromGitRoot(pocket.Path(ctx))
+
+		args := []string{}
+		if !pocket.Fix(ctx, !opts.Check) {
+			args = append(args, "--check")
+		} else {
+			args = append(args, "--write")
+		}
+		// Don't fail on patterns without files, or on files without a parser
+		// (e.g. Svelte files without the plugin).
+		args = append(args, "--no-error-on-unmatched-pattern", "--ignore-unknown")
+
+		// Add config if available (use absolute path)
+		if configPath, err := pocket.ConfigPath(ctx, "prettier", prettier.Config); err == nil && configPath != "" {
+			args = append(args, "--config", configPath)
+		}
+
+		// Add ignore file if available (use absolute path)
+		if ignorePath, err := prettier.EnsureIgnoreFile(); err == nil {
+			args = append(args, "--ignore-path", ignorePath)
+		}
+
+		if hasNodeModule(dir, sveltePlugin) {
+			args = append(args, "--plugin", sveltePlugin)
+		} else if pocket.Verbose(ctx) {
+			pocket.Printf(ctx, "  %s not installed, skipping Svelte files\n", sveltePlugin)
+		}
+
+		// Use absolute path patterns since prettier runs from install directory
+		args = append(args, absPatterns(dir, opts.Patterns)...)
+
+		return prettier.Exec(ctx, args...)
+	})
+}
+
+// absPatterns returns the comma-separated patterns, or DefaultPatterns, as
+// absolute globs below dir.
+func absPatterns(dir, patterns string) []string {
+	globs := DefaultPatterns()
+	if patterns != "" {
+		globs = nil
+		for p := range strings.SplitSeq(patterns, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				globs = append(globs, p)
+			}
+		}
+	}
+	base := filepath.ToSlash(dir)
+	abs := make([]string, 0, len(globs))
+	for _, glob := range globs {
+		abs = append(abs, path.Join(base, glob))
+	}
+	return abs
+}
+
+// hasNodeModule reports whether the node module is installed in a
+// node_modules directory in dir or one of its parents within the git root,
+// where Node module resolution from dir finds it.
+func hasNodeModule(dir, module string) bool {
+	root := pocket.GitRoot()
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "node_modules", module)); err == nil {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
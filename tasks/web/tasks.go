@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: MIT
+
+// Package web provides formatting tasks for HTML, CSS and component files
+// using prettier.
+// This is a "task" package - it orchestrates tools to do work.
+package web
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Extensions are the file extensions of the files formatted by web-format.
+var Extensions = []string{".html", ".css", ".scss", ".less", ".vue", ".svelte"}
+
+// Inputs are the files the web tasks read, relative to the project
+// directory. See pocket.WithInputs.
+var Inputs = DefaultPatterns()
+
+// DefaultPatterns returns the globs formatted by web-format when no
+// patterns are given, one per extension in Extensions.
+func DefaultPatterns() []string {
+	patterns := make([]string, 0, len(Extensions))
+	for _, ext := range Extensions {
+		patterns = append(patterns, "**/*"+ext)
+	}
+	return patterns
+}
+
+// Option configures the web task group.
+type Option func(*config)
+
+type config struct {
+	format FormatOptions
+	env    map[string]string
+}
+
+// WithFormat sets options for the web-format task.
+func WithFormat(opts FormatOptions) Option {
+	return func(c *config) { c.format = opts }
+}
+
+// WithEnv sets extra environment variables for the commands of the
+// web-format task, e.g. NODE_OPTIONS. See pocket.Env.
+func WithEnv(env map[string]string) Option {
+	return func(c *config) { c.env = env }
+}
+
+// Tasks returns a Runnable that executes all web tasks.
+// Use pocket.RunIn(web.Tasks(), pocket.Detect(web.Detect())) to enable path filtering.
+//
+// Example with options:
+//
+//	pocket.RunIn(web.Tasks(
+//	    web.WithFormat(web.FormatOptions{Patterns: "src/**/*.vue,public/*.html"}),
+//	), pocket.Detect(web.Detect()))
+func Tasks(opts ...Option) pocket.Runnable {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	formatTask := Format
+	if cfg.format != (FormatOptions{}) {
+		formatTask = pocket.WithOpts(Format, cfg.format)
+	}
+
+	return pocket.Env(cfg.env, pocket.Serial(formatTask))
+}
+
+// Detect returns a detection function that finds web projects: the
+// topmost directories containing HTML, CSS, SCSS, Less, Vue or Svelte
+// files. Directories below a detected directory are not returned, since
+// web-format formats a directory recursively.
+//
+// Usage:
+//
+//	pocket.RunIn(web.Tasks(), pocket.Detect(web.Detect()))
+func Detect() func() []string {
+	return func() []string {
+		return topmostDirs(pocket.DetectByExtension(Extensions...))
+	}
+}
+
+// topmostDirs returns the sorted dirs that are not below another dir.
+func topmostDirs(dirs []string) []string {
+	var result []string
+	for _, dir := range dirs {
+		nested := slices.ContainsFunc(result, func(parent string) bool {
+			return parent == "." || strings.HasPrefix(dir, parent+"/")
+		})
+		if !nested {
+			result = append(result, dir)
+		}
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestTopmostDirs(t *testing.T) {
+	tests := []struct {
+		dirs []string
+		want []string
+	}{
+		{[]string{".", "site", "site/css"}, []string{"."}},
+		{[]string{"app", "app/src", "site", "siteb"}, []string{"app", "site", "siteb"}},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := topmostDirs(tt.dirs); !slices.Equal(got, tt.want) {
+			t.Errorf("topmostDirs(%q) = %q, want %q", tt.dirs, got, tt.want)
+		}
+	}
+}
+
+func TestAbsPatterns(t *testing.T) {
+	got := absPatterns("/repo/site", "src/**/*.vue, public/*.html,")
+	want := []string{"/repo/site/src/**/*.vue", "/repo/site/public/*.html"}
+	if !slices.Equal(got, want) {
+		t.Errorf("absPatterns() = %q, want %q", got, want)
+	}
+
+	got = absPatterns("/repo", "")
+	if len(got) != len(Extensions) || got[0] != "/repo/**/*.html" {
+		t.Errorf("absPatterns() default = %q", got)
+	}
+}
+
+func TestHasNodeModule(t *testing.T) {
+	root := t.TempDir()
+	restore := gitroot.Override(root)
+	defer restore()
+	app := filepath.Join(root, "apps", "site")
+	if err := os.MkdirAll(filepath.Join(root, "apps", "node_modules", sveltePlugin), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(app, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if !hasNodeModule(app, sveltePlugin) {
+		t.Error("expected plugin in a parent node_modules to be found")
+	}
+	if hasNodeModule(root, sveltePlugin) {
+		t.Error("expected plugin below root not to be found from root")
+	}
+	if hasNodeModule(app, "other-plugin") {
+		t.Error("expected missing module not to be found")
+	}
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithTimeout returns a copy of the task that is cancelled if it runs
+// longer than d, so that a hung test or linter can't stall a whole run.
+// Commands of the task are interrupted and, after WaitDelay, killed.
+// The timeout is shown by ./pok plan.
+//
+// Example:
+//
+//	AutoRun: pocket.Serial(
+//	    golang.Test.WithTimeout(10*time.Minute),
+//	    golang.Lint,
+//	)
+//
+// See also the Timeout function, which wraps any Runnable.
+func (f *TaskDef) WithTimeout(d time.Duration) *TaskDef {
+	return Clone(f, func(td *TaskDef) {
+		td.timeout = d
+	})
+}
+
+// Timeout wraps a Runnable so that it is cancelled if it runs longer than d.
+// The deadline covers all tasks inside, including their own timeouts.
+//
+// Example:
+//
+//	AutoRun: pocket.Timeout(30*time.Minute, pocket.Parallel(
+//	    golang.Tasks(),
+//	    markdown.Tasks(),
+//	))
+func Timeout(d time.Duration, r Runnable) Runnable {
+	return &timeoutRunnable{timeout: d, inner: r}
+}
+
+// timeoutRunnable runs a Runnable with a deadline.
+type timeoutRunnable struct {
+	timeout time.Duration
+	inner   Runnable
+}
+
+func (t *timeoutRunnable) run(ctx context.Context) error {
+	if getExecContext(ctx).mode == modeCollect {
+		return t.inner.run(ctx)
+	}
+	return runWithTimeout(ctx, t.timeout, "", t.inner.run)
+}
+
+// ErrTimeout is returned when a task or Runnable exceeds its timeout.
+var ErrTimeout = errors.New("timeout exceeded")
+
+// runWithTimeout runs fn with a context that is cancelled after d. If fn
+// fails because the deadline passed, an error wrapping ErrTimeout is
+// returned, naming the task if name is set.
+func runWithTimeout(ctx context.Context, d time.Duration, name string, fn func(context.Context) error) error {
+	tctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	err := fn(tctx)
+	if err == nil || ctx.Err() != nil || !errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("timed out after %s: %w", d, ErrTimeout)
+	}
+	return fmt.Errorf("%s timed out after %s: %w", name, d, ErrTimeout)
+}
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskDef_WithTimeout(t *testing.T) {
+	hang := Task("hang", "wait for cancellation", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	task := hang.WithTimeout(50 * time.Millisecond)
+
+	if hang.timeout != 0 {
+		t.Error("WithTimeout modified the original task")
+	}
+	err := task.Run(TestContext(discardOutput()))
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Run() = %v, want ErrTimeout", err)
+	}
+	if want := "hang timed out after 50ms"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not contain %q", err, want)
+	}
+}
+
+func TestTaskDef_WithTimeout_KillsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	task := Task("sleep", "sleep", Run("sleep", "30")).WithTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	err := task.Run(TestContext(discardOutput()))
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Run() = %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > WaitDelay+5*time.Second {
+		t.Errorf("command ran for %s after the timeout", elapsed)
+	}
+}
+
+func TestTaskDef_WithTimeout_FinishesInTime(t *testing.T) {
+	task := Task("quick", "return", func(_ context.Context) error { return nil }).WithTimeout(time.Minute)
+	if err := task.Run(TestContext(discardOutput())); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	hang := Task("hang", "wait for cancellation", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := Timeout(50*time.Millisecond, Serial(hang)).run(TestContext(discardOutput()))
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("run() = %v, want ErrTimeout", err)
+	}
+	if got, want := err.Error(), "timed out after 50ms: timeout exceeded"; got != want {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+}
+
+func TestTimeout_NestedTaskTimeout(t *testing.T) {
+	hang := Task("hang", "wait for cancellation", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}).WithTimeout(50 * time.Millisecond)
+
+	// The task's own timeout is reported, not the enclosing one.
+	err := Timeout(time.Minute, hang).run(TestContext(discardOutput()))
+	if err == nil || !strings.HasPrefix(err.Error(), "hang timed out after 50ms") {
+		t.Errorf("run() = %v, want hang to time out", err)
+	}
+}
+
+func TestTimeout_ParentCancelled(t *testing.T) {
+	hang := Task("hang", "wait for cancellation", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}).WithTimeout(time.Minute)
+
+	ctx, cancel := context.WithCancel(TestContext(discardOutput()))
+	cancel()
+	if err := hang.Run(ctx); !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+}
+
+func TestWithTimeout_Plan(t *testing.T) {
+	task := Task("test", "run tests", func(_ context.Context) error { return nil }).WithTimeout(10 * time.Minute)
+
+	plan, err := NewEngine(Timeout(time.Hour, task)).Plan(context.Background())
+	if err != nil {
+		t.Fatalf("Plan() failed: %v", err)
+	}
+	steps := plan.Steps()
+	if len(steps) != 1 || steps[0].Timeout != "10m0s" {
+		t.Fatalf("expected step with timeout 10m0s, got %+v", steps)
+	}
+	if tasks := plan.Tasks(); len(tasks) != 1 || tasks[0].Timeout != "10m0s" {
+		t.Errorf("expected task info with timeout, got %+v", tasks)
+	}
+
+	var stdout strings.Builder
+	ctx := TestContext(&Output{Stdout: &stdout, Stderr: discardOutput().Stderr})
+	printPlanSteps(ctx, steps, "", false, false)
+	if want := "test (timeout: 10m0s) - run tests"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("plan output %q does not contain %q", stdout.String(), want)
+	}
+}
@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: MIT
+
+// Package buf provides buf (Protocol Buffers toolchain) integration.
+package buf
+
+import "github.com/fredrikaverpil/pocket"
+
+// Name is the binary name for buf.
+const Name = "buf"
+
+// renovate: datasource=go depName=github.com/bufbuild/buf
+const Version = "v1.50.0"
+
+// Install ensures buf is available.
+var Install = pocket.Task("install:buf", "install buf",
+	pocket.InstallGo("github.com/bufbuild/buf/cmd/buf", Version),
+	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGo, "github.com/bufbuild/buf"),
+)
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package bun provides bun runtime integration.
 // Bun is a JavaScript runtime used by other tools (e.g., prettier).
 package bun
@@ -21,16 +23,34 @@ const Version = "1.3.6"
 var Install = pocket.Task("install:bun", "ensure bun is available",
 	installBun(),
 	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGitHubReleases, "oven-sh/bun"),
 )
 
+// platforms maps host platforms to bun release names. The glibc builds
+// need a recent glibc; Alpine gets the musl builds.
+var platforms = map[string]string{
+	"darwin/amd64":     "darwin-x64",
+	"darwin/arm64":     "darwin-aarch64",
+	"linux/amd64-gnu":  "linux-x64",
+	"linux/amd64-musl": "linux-x64-musl",
+	"linux/arm64-gnu":  "linux-aarch64",
+	"linux/arm64-musl": "linux-aarch64-musl",
+	"windows/amd64":    "windows-x64",
+}
+
 func installBun() pocket.Runnable {
 	binDir := pocket.FromToolsDir(Name, Version, "bin")
 	binaryName := pocket.BinaryName(Name)
 	binaryPath := filepath.Join(binDir, binaryName)
 
+	platform, err := pocket.PlatformAsset(Name, platforms)
+	if err != nil {
+		return pocket.Do(func(context.Context) error { return err })
+	}
 	url := fmt.Sprintf(
 		"https://github.com/oven-sh/bun/releases/download/bun-v%s/bun-%s.zip",
-		Version, platformArch(),
+		Version, platform,
 	)
 
 	return pocket.Download(url,
@@ -42,28 +62,6 @@ func installBun() pocket.Runnable {
 	)
 }
 
-func platformArch() string {
-	hostOS := pocket.HostOS()
-	hostArch := pocket.HostArch()
-
-	switch hostOS {
-	case pocket.Darwin:
-		if hostArch == pocket.ARM64 {
-			return "darwin-aarch64"
-		}
-		return "darwin-x64"
-	case pocket.Linux:
-		if hostArch == pocket.ARM64 {
-			return "linux-aarch64"
-		}
-		return "linux-x64"
-	case pocket.Windows:
-		return "windows-x64"
-	default:
-		return fmt.Sprintf("%s-%s", hostOS, hostArch)
-	}
-}
-
 // BinaryPath returns the path to a binary installed by bun in the given directory.
 // On Windows, it appends .exe to the binary name.
 func BinaryPath(installDir, binaryName string) string {
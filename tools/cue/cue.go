@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: MIT
+
+// Package cue provides CUE integration.
+package cue
+
+import "github.com/fredrikaverpil/pocket"
+
+// Name is the binary name for cue.
+const Name = "cue"
+
+// renovate: datasource=go depName=cuelang.org/go
+const Version = "v0.14.1"
+
+// Install ensures cue is available.
+var Install = pocket.Task("install:cue", "install cue",
+	pocket.InstallGo("cuelang.org/go/cmd/cue", Version),
+	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGo, "cuelang.org/go"),
+)
@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: MIT
+
+// Package gazelle provides Gazelle (Bazel BUILD file generator) integration.
+package gazelle
+
+import "github.com/fredrikaverpil/pocket"
+
+// Name is the binary name for gazelle.
+const Name = "gazelle"
+
+// renovate: datasource=go depName=github.com/bazelbuild/bazel-gazelle
+const Version = "v0.42.0"
+
+// Install ensures gazelle is available.
+var Install = pocket.Task("install:gazelle", "install gazelle",
+	pocket.InstallGo("github.com/bazelbuild/bazel-gazelle/cmd/gazelle", Version),
+	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGo, "github.com/bazelbuild/bazel-gazelle"),
+)
@@ -1,7 +1,15 @@
+// SPDX-License-Identifier: MIT
+
 // Package golangcilint provides golangci-lint integration.
 package golangcilint
 
-import "github.com/fredrikaverpil/pocket"
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/fredrikaverpil/pocket"
+)
 
 // Name is the binary name for golangci-lint.
 const Name = "golangci-lint"
@@ -13,9 +21,11 @@ const Version = "v2.0.2"
 var Install = pocket.Task("install:golangci-lint", "install golangci-lint",
 	pocket.InstallGo("github.com/golangci/golangci-lint/v2/cmd/golangci-lint", Version),
 	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGo, "github.com/golangci/golangci-lint/v2"),
 )
 
-// Config for golangci-lint configuration file lookup.
+// Config for golangci-lint configuration file lookup. See ConfigPath.
 var Config = pocket.ToolConfig{
 	UserFiles: []string{
 		".golangci.yml",
@@ -25,3 +35,35 @@ var Config = pocket.ToolConfig{
 	},
 	DefaultFile: "", // No default - use golangci-lint defaults
 }
+
+// ConfigPath returns the golangci-lint config for the task's directory: the
+// nearest of Config.UserFiles, looking in the module directory first and
+// then in each parent up to the git root. If there is none, returns the
+// default config from Config.DefaultConfigs, or empty string to leave
+// golangci-lint to its defaults.
+//
+// This lets each Go module in a monorepo carry its own config, with a
+// repo-root config for the modules that don't.
+func ConfigPath(ctx context.Context) (string, error) {
+	if path := FindConfig(pocket.FromGitRoot(pocket.Path(ctx))); path != "" {
+		return path, nil
+	}
+	return pocket.ConfigPath(ctx, Name, pocket.ToolConfig{})
+}
+
+// FindConfig returns the nearest of Config.UserFiles in dir or its parents
+// up to the git root, or empty string if there is none.
+func FindConfig(dir string) string {
+	root := pocket.GitRoot()
+	for ; ; dir = filepath.Dir(dir) {
+		for _, name := range Config.UserFiles {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+		if dir == root || dir == filepath.Dir(dir) {
+			return ""
+		}
+	}
+}
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package govulncheck provides govulncheck integration.
 package govulncheck
 
@@ -13,4 +15,6 @@ const Version = "v1.1.4"
 var Install = pocket.Task("install:govulncheck", "install govulncheck",
 	pocket.InstallGo("golang.org/x/vuln/cmd/govulncheck", Version),
 	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGo, "golang.org/x/vuln"),
 )
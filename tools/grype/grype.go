@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: MIT
+
+// Package grype provides grype vulnerability scanner integration.
+package grype
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Name is the binary name for grype.
+const Name = "grype"
+
+// renovate: datasource=github-releases depName=anchore/grype
+const Version = "0.86.1"
+
+// Install ensures grype is available.
+var Install = pocket.Task("install:grype", "install grype",
+	installGrype(),
+	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGitHubReleases, "anchore/grype"),
+)
+
+// platforms maps host platforms to grype release names. The Linux builds
+// are statically linked, so they also run on musl.
+var platforms = map[string]string{
+	"darwin/amd64":  "darwin_amd64",
+	"darwin/arm64":  "darwin_arm64",
+	"linux/amd64":   "linux_amd64",
+	"linux/arm64":   "linux_arm64",
+	"windows/amd64": "windows_amd64",
+}
+
+func installGrype() pocket.Runnable {
+	binDir := pocket.FromToolsDir("grype", Version, "bin")
+	binaryName := pocket.BinaryName("grype")
+	binaryPath := filepath.Join(binDir, binaryName)
+
+	platform, err := pocket.PlatformAsset(Name, platforms)
+	if err != nil {
+		return pocket.Do(func(context.Context) error { return err })
+	}
+	format := pocket.DefaultArchiveFormat()
+	url := fmt.Sprintf(
+		"https://github.com/anchore/grype/releases/download/v%s/grype_%s_%s.%s",
+		Version, Version, platform, format,
+	)
+
+	return pocket.Download(url,
+		pocket.WithDestDir(binDir),
+		pocket.WithFormat(format),
+		pocket.WithExtract(pocket.WithExtractFile(binaryName)),
+		pocket.WithSymlink(),
+		pocket.WithSkipIfExists(binaryPath),
+	)
+}
@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: MIT
+
+// Package ko provides ko integration for building Go container images.
+package ko
+
+import "github.com/fredrikaverpil/pocket"
+
+// Name is the binary name for ko.
+const Name = "ko"
+
+// renovate: datasource=go depName=github.com/google/ko
+const Version = "v0.17.1"
+
+// Install ensures ko is available.
+var Install = pocket.Task("install:ko", "install ko",
+	pocket.InstallGo("github.com/google/ko", Version),
+	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGo, "github.com/google/ko"),
+)
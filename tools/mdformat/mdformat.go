@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package mdformat provides mdformat (Markdown formatter) tool integration.
 // mdformat is installed via uv into a virtual environment with plugins.
 package mdformat
@@ -36,7 +38,7 @@ func Version() string {
 var Install = pocket.Task("install:mdformat", "install mdformat", pocket.Serial(
 	uv.Install,
 	installMdformat(),
-), pocket.AsHidden())
+), pocket.AsHidden(), pocket.AsTool(Name, Version()))
 
 func installMdformat() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
@@ -46,9 +48,14 @@ func installMdformat() pocket.Runnable {
 
 		// Skip if already installed.
 		if _, err := os.Stat(binary); err == nil {
-			// Ensure symlink/copy exists.
-			_, err := pocket.CreateSymlink(binary)
-			return err
+			if !pocket.Reinstall(ctx) {
+				// Ensure symlink/copy exists.
+				_, err := pocket.CreateSymlink(binary)
+				return err
+			}
+			if err := os.RemoveAll(venvDir); err != nil {
+				return err
+			}
 		}
 
 		// Create virtual environment with Python 3.13+ for --exclude support.
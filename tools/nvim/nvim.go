@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package nvim provides Neovim tool integration.
 // Used for running plenary tests and other Neovim-based operations.
 package nvim
@@ -5,7 +7,6 @@ package nvim
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 	"runtime"
 
 	"github.com/fredrikaverpil/pocket"
@@ -21,61 +22,69 @@ const Version = "0.11.5"
 var Install = pocket.Task("install:nvim", "install neovim",
 	installNvim(),
 	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGitHubReleases, "neovim/neovim"),
 )
 
-func installNvim() pocket.Runnable {
-	binDir := pocket.FromToolsDir("nvim", Version)
-	binaryName := pocket.BinaryName("nvim")
+// platforms maps host platforms to Neovim release names. The Linux builds
+// are linked against glibc.
+var platforms = map[string]string{
+	"darwin/amd64":    "macos-x86_64",
+	"darwin/arm64":    "macos-arm64",
+	"linux/amd64-gnu": "linux-x86_64",
+	"linux/arm64-gnu": "linux-arm64",
+	"windows/amd64":   "win64",
+	"windows/arm64":   "win-arm64",
+}
 
-	platform := platformArch()
-	ext := "tar.gz"
-	if runtime.GOOS == pocket.Windows {
-		ext = "zip"
+func installNvim() pocket.Runnable {
+	platform, err := pocket.PlatformAsset(Name, platforms)
+	if err != nil {
+		return pocket.Do(func(context.Context) error { return err })
 	}
-
+	format := pocket.DefaultArchiveFormat()
 	url := fmt.Sprintf(
 		"https://github.com/neovim/neovim/releases/download/v%s/nvim-%s.%s",
-		Version, platform, ext,
+		Version, platform, format,
 	)
 
-	// Neovim archives extract to nvim-<platform>/bin/nvim
-	archiveDir := fmt.Sprintf("nvim-%s", platform)
-	binaryInArchive := filepath.Join(archiveDir, "bin", binaryName)
-	extractedBinaryPath := filepath.Join(binDir, archiveDir, "bin", binaryName)
-
+	// The whole archive is extracted: nvim finds its runtime files (and on
+	// Windows, its DLLs) relative to the binary.
 	return pocket.Serial(
 		pocket.Download(url,
-			pocket.WithDestDir(binDir),
-			pocket.WithFormat(ext),
-			pocket.WithExtract(pocket.WithExtractFile(binaryInArchive)),
-			pocket.WithSkipIfExists(extractedBinaryPath),
+			pocket.WithDestDir(pocket.FromToolsDir("nvim", Version)),
+			pocket.WithFormat(format),
+			pocket.WithSkipIfExists(binaryPath(platform)),
 		),
 		// Create symlink to the binary inside the extracted directory.
+		// On Windows, where it would be a copy without the DLLs and runtime
+		// files next to it, Exec runs the extracted binary instead.
 		pocket.Do(func(_ context.Context) error {
-			_, err := pocket.CreateSymlink(extractedBinaryPath)
+			if runtime.GOOS == pocket.Windows {
+				return nil
+			}
+			_, err := pocket.CreateSymlink(binaryPath(platform))
 			return err
 		}),
 	)
 }
 
-func platformArch() string {
-	switch runtime.GOOS {
-	case pocket.Darwin:
-		if runtime.GOARCH == pocket.ARM64 {
-			return "macos-arm64"
-		}
-		return "macos-x86_64"
-	case pocket.Linux:
-		if runtime.GOARCH == pocket.ARM64 {
-			return "linux-arm64"
-		}
-		return "linux-x86_64"
-	case pocket.Windows:
-		if runtime.GOARCH == pocket.ARM64 {
-			return "win-arm64"
+// binaryPath returns the path of the binary in the extracted archive,
+// nvim-<platform>/bin/nvim.
+func binaryPath(platform string) string {
+	return pocket.FromToolsDir("nvim", Version, "nvim-"+platform, "bin", pocket.BinaryName(Name))
+}
+
+// Exec runs nvim with the given arguments.
+// On Windows, runs the extracted binary, which is not copied to .pocket/bin.
+// NOTE: Callers must ensure Install has been composed as a dependency.
+func Exec(ctx context.Context, args ...string) error {
+	if runtime.GOOS == pocket.Windows {
+		platform, err := pocket.PlatformAsset(Name, platforms)
+		if err != nil {
+			return err
 		}
-		return "win64"
-	default:
-		return fmt.Sprintf("%s-%s", runtime.GOOS, runtime.GOARCH)
+		return pocket.Exec(ctx, binaryPath(platform), args...)
 	}
+	return pocket.Exec(ctx, Name, args...)
 }
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package prettier provides prettier (code formatter) integration.
 // prettier is installed via bun into a local directory with locked dependencies.
 package prettier
@@ -57,7 +59,7 @@ func Version() string {
 var Install = pocket.Task("install:prettier", "install prettier", pocket.Serial(
 	bun.Install,
 	installPrettier(),
-), pocket.AsHidden())
+), pocket.AsHidden(), pocket.AsTool(Name, Version()))
 
 func installPrettier() pocket.Runnable {
 	return pocket.Do(func(ctx context.Context) error {
@@ -65,7 +67,7 @@ func installPrettier() pocket.Runnable {
 		binary := bun.BinaryPath(installDir, Name)
 
 		// Skip if already installed.
-		if _, err := os.Stat(binary); err == nil {
+		if _, err := os.Stat(binary); err == nil && !pocket.Reinstall(ctx) {
 			return nil
 		}
 
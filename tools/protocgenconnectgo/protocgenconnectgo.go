@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: MIT
+
+// Package protocgenconnectgo provides protoc-gen-connect-go (Connect RPC
+// code generator) integration.
+package protocgenconnectgo
+
+import "github.com/fredrikaverpil/pocket"
+
+// Name is the binary name for protoc-gen-connect-go.
+const Name = "protoc-gen-connect-go"
+
+// renovate: datasource=go depName=connectrpc.com/connect
+const Version = "v1.18.1"
+
+// Install ensures protoc-gen-connect-go is available.
+var Install = pocket.Task("install:protoc-gen-connect-go", "install protoc-gen-connect-go",
+	pocket.InstallGo("connectrpc.com/connect/cmd/protoc-gen-connect-go", Version),
+	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGo, "connectrpc.com/connect"),
+)
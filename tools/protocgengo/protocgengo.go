@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: MIT
+
+// Package protocgengo provides protoc-gen-go (Go protobuf code generator)
+// integration.
+package protocgengo
+
+import "github.com/fredrikaverpil/pocket"
+
+// Name is the binary name for protoc-gen-go.
+const Name = "protoc-gen-go"
+
+// renovate: datasource=go depName=google.golang.org/protobuf
+const Version = "v1.36.12"
+
+// Install ensures protoc-gen-go is available.
+var Install = pocket.Task("install:protoc-gen-go", "install protoc-gen-go",
+	pocket.InstallGo("google.golang.org/protobuf/cmd/protoc-gen-go", Version),
+	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGo, "google.golang.org/protobuf"),
+)
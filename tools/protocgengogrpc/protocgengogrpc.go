@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: MIT
+
+// Package protocgengogrpc provides protoc-gen-go-grpc (Go gRPC code
+// generator) integration.
+package protocgengogrpc
+
+import "github.com/fredrikaverpil/pocket"
+
+// Name is the binary name for protoc-gen-go-grpc.
+const Name = "protoc-gen-go-grpc"
+
+// renovate: datasource=go depName=google.golang.org/grpc/cmd/protoc-gen-go-grpc
+const Version = "v1.5.1"
+
+// Install ensures protoc-gen-go-grpc is available.
+var Install = pocket.Task("install:protoc-gen-go-grpc", "install protoc-gen-go-grpc",
+	pocket.InstallGo("google.golang.org/grpc/cmd/protoc-gen-go-grpc", Version),
+	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGo, "google.golang.org/grpc/cmd/protoc-gen-go-grpc"),
+)
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+
+// Package ruff provides ruff configuration lookup. Ruff itself is a project
+// dependency, run with uv.Run from the project's virtual environment.
+package ruff
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Name is the binary name for ruff.
+const Name = "ruff"
+
+//go:embed ruff.toml
+var defaultConfig []byte
+
+// Config describes ruff's configuration files. UserFiles are looked up by
+// ConfigPath in the module directory and each of its parents up to the git
+// root.
+var Config = pocket.ToolConfig{
+	UserFiles:   []string{"ruff.toml", ".ruff.toml", "pyproject.toml"},
+	DefaultFile: "ruff.toml",
+	DefaultData: defaultConfig,
+}
+
+// ConfigPath returns the ruff config for the task's directory: the nearest
+// ruff.toml, .ruff.toml or pyproject.toml with a [tool.ruff] table, looking
+// in the module directory first and then in each parent up to the git root.
+// If there is none, the bundled default is written to .pocket/tools/ruff/
+// and returned.
+//
+// This lets a module carry stricter (or looser) rules than the repository,
+// e.g. libraries/ruff.toml next to a repo-root ruff.toml for scripts.
+func ConfigPath(ctx context.Context) (string, error) {
+	root := pocket.GitRoot()
+	for dir := pocket.FromGitRoot(pocket.Path(ctx)); ; dir = filepath.Dir(dir) {
+		for _, name := range Config.UserFiles {
+			path := filepath.Join(dir, name)
+			if isConfig(path) {
+				return path, nil
+			}
+		}
+		if dir == root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	return pocket.ConfigPath(ctx, Name, pocket.ToolConfig{
+		DefaultFile: Config.DefaultFile,
+		DefaultData: Config.DefaultData,
+	})
+}
+
+// isConfig reports whether path is a ruff config. A pyproject.toml only
+// counts if it configures ruff.
+func isConfig(path string) bool {
+	if filepath.Base(path) != "pyproject.toml" {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	for line := range bytes.Lines(data) {
+		line = bytes.TrimSpace(line)
+		if bytes.Equal(line, []byte("[tool.ruff]")) || bytes.HasPrefix(line, []byte("[tool.ruff.")) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,10 @@
+// SPDX-License-Identifier: MIT
+
 // Package stylua provides stylua tool integration.
 package stylua
 
 import (
+	"context"
 	_ "embed"
 	"fmt"
 	"path/filepath"
@@ -29,25 +32,33 @@ var Config = pocket.ToolConfig{
 var Install = pocket.Task("install:stylua", "install stylua",
 	installStylua(),
 	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGitHubReleases, "JohnnyMorganz/StyLua"),
 )
 
+// platforms maps host platforms to StyLua release names.
+var platforms = map[string]string{
+	"darwin/amd64":     "macos-x86_64",
+	"darwin/arm64":     "macos-aarch64",
+	"linux/amd64-gnu":  "linux-x86_64",
+	"linux/amd64-musl": "linux-x86_64-musl",
+	"linux/arm64-gnu":  "linux-aarch64",
+	"linux/arm64-musl": "linux-aarch64-musl",
+	"windows/amd64":    "windows-x86_64",
+}
+
 func installStylua() pocket.Runnable {
 	binDir := pocket.FromToolsDir("stylua", Version, "bin")
 	binaryName := pocket.BinaryName("stylua")
 	binaryPath := filepath.Join(binDir, binaryName)
 
-	hostOS := pocket.HostOS()
-	hostArch := pocket.HostArch()
-
-	// StyLua uses different naming: darwin->macos, amd64->x86_64, arm64->aarch64
-	if hostOS == pocket.Darwin {
-		hostOS = "macos"
+	platform, err := pocket.PlatformAsset(Name, platforms)
+	if err != nil {
+		return pocket.Do(func(context.Context) error { return err })
 	}
-	hostArch = pocket.ArchToX8664(hostArch)
-
 	url := fmt.Sprintf(
-		"https://github.com/JohnnyMorganz/StyLua/releases/download/v%s/stylua-%s-%s.zip",
-		Version, hostOS, hostArch,
+		"https://github.com/JohnnyMorganz/StyLua/releases/download/v%s/stylua-%s.zip",
+		Version, platform,
 	)
 
 	return pocket.Download(url,
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 package tools_test
 
 import (
@@ -6,12 +8,23 @@ import (
 	"testing"
 
 	"github.com/fredrikaverpil/pocket"
+	"github.com/fredrikaverpil/pocket/tools/buf"
 	"github.com/fredrikaverpil/pocket/tools/bun"
+	"github.com/fredrikaverpil/pocket/tools/cue"
+	"github.com/fredrikaverpil/pocket/tools/gazelle"
 	"github.com/fredrikaverpil/pocket/tools/golangcilint"
 	"github.com/fredrikaverpil/pocket/tools/govulncheck"
+	"github.com/fredrikaverpil/pocket/tools/grype"
+	"github.com/fredrikaverpil/pocket/tools/ko"
 	"github.com/fredrikaverpil/pocket/tools/mdformat"
+	"github.com/fredrikaverpil/pocket/tools/nvim"
 	"github.com/fredrikaverpil/pocket/tools/prettier"
+	"github.com/fredrikaverpil/pocket/tools/protocgenconnectgo"
+	"github.com/fredrikaverpil/pocket/tools/protocgengo"
+	"github.com/fredrikaverpil/pocket/tools/protocgengogrpc"
 	"github.com/fredrikaverpil/pocket/tools/stylua"
+	"github.com/fredrikaverpil/pocket/tools/trivy"
+	"github.com/fredrikaverpil/pocket/tools/tsqueryls"
 	"github.com/fredrikaverpil/pocket/tools/uv"
 )
 
@@ -21,21 +34,40 @@ type toolTest struct {
 	install     *pocket.TaskDef
 	binary      string
 	versionArgs []string
-	// customExec is used for tools that need special execution (e.g., prettier via bun).
+	// customExec is used for tools that need special execution (e.g., prettier via bun,
+	// or nvim, which runs from its extracted archive on Windows).
 	customExec func(ctx context.Context, args ...string) error
 }
 
 var tools = []toolTest{
 	{"golangci-lint", golangcilint.Install, golangcilint.Name, []string{"version"}, nil},
 	{"govulncheck", govulncheck.Install, govulncheck.Name, []string{"-version"}, nil},
+	{"ko", ko.Install, ko.Name, []string{"version"}, nil},
+	{"trivy", trivy.Install, trivy.Name, []string{"--version"}, nil},
+	{"grype", grype.Install, grype.Name, []string{"version"}, nil},
 	{"uv", uv.Install, uv.Name, []string{"--version"}, nil},
 	{"mdformat", mdformat.Install, mdformat.Name, []string{"--version"}, nil},
 	{"stylua", stylua.Install, stylua.Name, []string{"--version"}, nil},
 	{"bun", bun.Install, bun.Name, []string{"--version"}, nil},
 	{"prettier", prettier.Install, prettier.Name, []string{"--version"}, prettier.Exec},
+	{"buf", buf.Install, buf.Name, []string{"--version"}, nil},
+	{"protoc-gen-go", protocgengo.Install, protocgengo.Name, []string{"--version"}, nil},
+	{"protoc-gen-go-grpc", protocgengogrpc.Install, protocgengogrpc.Name, []string{"--version"}, nil},
+	{"protoc-gen-connect-go", protocgenconnectgo.Install, protocgenconnectgo.Name, []string{"--version"}, nil},
+	{"cue", cue.Install, cue.Name, []string{"version"}, nil},
+	{"gazelle", gazelle.Install, gazelle.Name, []string{"help"}, nil},
+	{"nvim", nvim.Install, nvim.Name, []string{"--version"}, nvim.Exec},
+	{"ts_query_ls", tsqueryls.Install, tsqueryls.Name, []string{"--version"}, nil},
 }
 
+// TestTools installs each tool from the network and runs it. It is skipped
+// with -short; CI runs it per tool and platform, e.g.
+// go test ./tools -run '^TestTools$/^uv$'.
 func TestTools(t *testing.T) {
+	if testing.Short() {
+		t.Skip("installs tools from the network")
+	}
+
 	// Create execution context for testing.
 	out := pocket.StdOutput()
 	out.Stdout = os.Stdout
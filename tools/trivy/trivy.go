@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+
+// Package trivy provides trivy vulnerability scanner integration.
+package trivy
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fredrikaverpil/pocket"
+)
+
+// Name is the binary name for trivy.
+const Name = "trivy"
+
+// renovate: datasource=github-releases depName=aquasecurity/trivy
+const Version = "0.58.1"
+
+// Install ensures trivy is available.
+var Install = pocket.Task("install:trivy", "install trivy",
+	installTrivy(),
+	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGitHubReleases, "aquasecurity/trivy"),
+)
+
+// platforms maps host platforms to trivy release names. The Linux builds
+// are statically linked, so they also run on musl.
+var platforms = map[string]string{
+	"darwin/amd64":  "macOS-64bit",
+	"darwin/arm64":  "macOS-ARM64",
+	"linux/amd64":   "Linux-64bit",
+	"linux/arm64":   "Linux-ARM64",
+	"linux/arm":     "Linux-ARM",
+	"windows/amd64": "windows-64bit",
+}
+
+func installTrivy() pocket.Runnable {
+	binDir := pocket.FromToolsDir("trivy", Version, "bin")
+	binaryName := pocket.BinaryName("trivy")
+	binaryPath := filepath.Join(binDir, binaryName)
+
+	platform, err := pocket.PlatformAsset(Name, platforms)
+	if err != nil {
+		return pocket.Do(func(context.Context) error { return err })
+	}
+	format := pocket.DefaultArchiveFormat()
+	url := fmt.Sprintf(
+		"https://github.com/aquasecurity/trivy/releases/download/v%s/trivy_%s_%s.%s",
+		Version, Version, platform, format,
+	)
+
+	return pocket.Download(url,
+		pocket.WithDestDir(binDir),
+		pocket.WithFormat(format),
+		pocket.WithExtract(pocket.WithExtractFile(binaryName)),
+		pocket.WithSymlink(),
+		pocket.WithSkipIfExists(binaryPath),
+	)
+}
@@ -1,11 +1,13 @@
+// SPDX-License-Identifier: MIT
+
 // Package tsqueryls provides ts_query_ls tool integration.
 // ts_query_ls is a tree-sitter query file formatter and linter.
 package tsqueryls
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
-	"runtime"
 
 	"github.com/fredrikaverpil/pocket"
 )
@@ -20,48 +22,41 @@ const Version = "3.15.1"
 var Install = pocket.Task("install:ts_query_ls", "install ts_query_ls",
 	installTSQueryLs(),
 	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGitHubReleases, "ribru17/ts_query_ls"),
 )
 
+// platforms maps host platforms to ts_query_ls release targets. Windows on
+// ARM runs the x86_64 build under emulation.
+var platforms = map[string]string{
+	"darwin/amd64":    "x86_64-apple-darwin",
+	"darwin/arm64":    "aarch64-apple-darwin",
+	"linux/amd64-gnu": "x86_64-unknown-linux-gnu",
+	"linux/arm64-gnu": "aarch64-unknown-linux-gnu",
+	"windows/amd64":   "x86_64-pc-windows-msvc",
+	"windows/arm64":   "x86_64-pc-windows-msvc",
+}
+
 func installTSQueryLs() pocket.Runnable {
 	binDir := pocket.FromToolsDir("ts_query_ls", Version, "bin")
 	binaryName := pocket.BinaryName("ts_query_ls")
 	binaryPath := filepath.Join(binDir, binaryName)
 
-	platform := platformArch()
-	ext := "tar.gz"
-	if runtime.GOOS == pocket.Windows {
-		ext = "zip"
+	target, err := pocket.PlatformAsset(Name, platforms)
+	if err != nil {
+		return pocket.Do(func(context.Context) error { return err })
 	}
-
+	format := pocket.DefaultArchiveFormat()
 	url := fmt.Sprintf(
 		"https://github.com/ribru17/ts_query_ls/releases/download/v%s/ts_query_ls-%s.%s",
-		Version, platform, ext,
+		Version, target, format,
 	)
 
 	return pocket.Download(url,
 		pocket.WithDestDir(binDir),
-		pocket.WithFormat(ext),
+		pocket.WithFormat(format),
 		pocket.WithExtract(pocket.WithExtractFile(binaryName)),
 		pocket.WithSymlink(),
 		pocket.WithSkipIfExists(binaryPath),
 	)
 }
-
-func platformArch() string {
-	switch runtime.GOOS {
-	case pocket.Darwin:
-		if runtime.GOARCH == pocket.ARM64 {
-			return "aarch64-apple-darwin"
-		}
-		return "x86_64-apple-darwin"
-	case pocket.Linux:
-		if runtime.GOARCH == pocket.ARM64 {
-			return "aarch64-unknown-linux-gnu"
-		}
-		return "x86_64-unknown-linux-gnu"
-	case pocket.Windows:
-		return "x86_64-pc-windows-msvc"
-	default:
-		return fmt.Sprintf("%s-%s", runtime.GOARCH, runtime.GOOS)
-	}
-}
@@ -1,3 +1,5 @@
+// SPDX-License-Identifier: MIT
+
 // Package uv provides uv (Python package manager) tool integration.
 //
 // # Usage Modes
@@ -8,7 +10,7 @@
 //
 // For tools managed by pocket (installed once, shared across runs):
 //
-//	uv.CreateVenv(ctx, ".pocket/tools/ruff/0.14.0", "")  // Uses DefaultPythonVersion
+//	uv.CreateVenv(ctx, ".pocket/tools/ruff/0.14.0", "")  // Uses Config.PythonVersion or DefaultPythonVersion
 //	uv.PipInstall(ctx, venvDir, "ruff==0.14.0")
 //	// Then run via pocket.Exec(ctx, "ruff", args...)
 //
@@ -49,21 +51,53 @@ const Version = "0.7.13"
 // renovate: datasource=github-releases depName=python/cpython
 const DefaultPythonVersion = "3.14.2"
 
+// PythonVersion returns the Python version for uv commands: version if set,
+// else Config.PythonVersion, else DefaultPythonVersion.
+func PythonVersion(ctx context.Context, version string) string {
+	if version != "" {
+		return version
+	}
+	if plan := pocket.GetConfigPlan(ctx); plan != nil && plan.Config != nil && plan.Config.PythonVersion != "" {
+		return plan.Config.PythonVersion
+	}
+	return DefaultPythonVersion
+}
+
 // Install ensures uv is available.
 var Install = pocket.Task("install:uv", "install uv",
 	installUV(),
 	pocket.AsHidden(),
+	pocket.AsTool(Name, Version),
+	pocket.ToolSource(pocket.DatasourceGitHubReleases, "astral-sh/uv"),
 )
 
+// platforms maps host platforms to uv release targets.
+var platforms = map[string]string{
+	"darwin/amd64":     "x86_64-apple-darwin",
+	"darwin/arm64":     "aarch64-apple-darwin",
+	"linux/amd64-gnu":  "x86_64-unknown-linux-gnu",
+	"linux/amd64-musl": "x86_64-unknown-linux-musl",
+	"linux/arm64-gnu":  "aarch64-unknown-linux-gnu",
+	"linux/arm64-musl": "aarch64-unknown-linux-musl",
+	"linux/arm-gnu":    "armv7-unknown-linux-gnueabihf",
+	"linux/arm-musl":   "armv7-unknown-linux-musleabihf",
+	"windows/amd64":    "x86_64-pc-windows-msvc",
+	"windows/arm64":    "aarch64-pc-windows-msvc",
+}
+
 func installUV() pocket.Runnable {
 	binDir := pocket.FromToolsDir("uv", Version, "bin")
 	binaryName := pocket.BinaryName("uv")
 	binaryPath := filepath.Join(binDir, binaryName)
 
+	target, err := pocket.PlatformAsset(Name, platforms)
+	if err != nil {
+		return pocket.Do(func(context.Context) error { return err })
+	}
 	url := fmt.Sprintf(
 		"https://github.com/astral-sh/uv/releases/download/%s/uv-%s.%s",
 		Version,
-		platformArch(),
+		target,
 		pocket.DefaultArchiveFormat(),
 	)
 
@@ -77,12 +111,11 @@ func installUV() pocket.Runnable {
 }
 
 // CreateVenv creates a Python virtual environment at the specified path.
-// If pythonVersion is empty, DefaultPythonVersion is used.
+// If pythonVersion is empty, Config.PythonVersion or DefaultPythonVersion is
+// used, see PythonVersion.
 // NOTE: Callers must ensure uv.Install has been composed as a dependency.
 func CreateVenv(ctx context.Context, venvPath, pythonVersion string) error {
-	if pythonVersion == "" {
-		pythonVersion = DefaultPythonVersion
-	}
+	pythonVersion = PythonVersion(ctx, pythonVersion)
 	args := []string{"venv", "--python", pythonVersion, venvPath}
 	return pocket.Exec(ctx, Name, args...)
 }
@@ -131,11 +164,11 @@ func ProjectVenvPath(pythonVersion string) string {
 
 // Sync runs uv sync to install project dependencies into .pocket/venvs/<version>/.
 // If allGroups is true, --all-groups is passed to install dev dependencies.
+// If pythonVersion is empty, Config.PythonVersion or DefaultPythonVersion is
+// used, see PythonVersion.
 // NOTE: Callers must ensure uv.Install has been composed as a dependency.
 func Sync(ctx context.Context, pythonVersion string, allGroups bool) error {
-	if pythonVersion == "" {
-		pythonVersion = DefaultPythonVersion
-	}
+	pythonVersion = PythonVersion(ctx, pythonVersion)
 
 	venvPath := ProjectVenvPath(pythonVersion)
 	if pocket.Verbose(ctx) {
@@ -151,22 +184,16 @@ func Sync(ctx context.Context, pythonVersion string, allGroups bool) error {
 	cmd := pocket.Command(ctx, Name, args...)
 	cmd.Env = append(cmd.Env, "UV_PROJECT_ENVIRONMENT="+venvPath)
 
-	// Use context output for proper parallel buffering
-	out := pocket.GetOutput(ctx)
-	cmd.Stdout = out.Stdout
-	cmd.Stderr = out.Stderr
-
-	return cmd.Run()
+	return pocket.RunCommand(ctx, cmd)
 }
 
 // Run executes a command using uv run from .pocket/venvs/<version>/.
-// If pythonVersion is empty, DefaultPythonVersion is used.
+// If pythonVersion is empty, Config.PythonVersion or DefaultPythonVersion is
+// used, see PythonVersion.
 // NOTE: Callers must ensure uv.Install has been composed as a dependency,
 // and that Sync has been run to install project dependencies.
 func Run(ctx context.Context, pythonVersion, cmd string, args ...string) error {
-	if pythonVersion == "" {
-		pythonVersion = DefaultPythonVersion
-	}
+	pythonVersion = PythonVersion(ctx, pythonVersion)
 
 	venvPath := ProjectVenvPath(pythonVersion)
 	if pocket.Verbose(ctx) {
@@ -180,29 +207,5 @@ func Run(ctx context.Context, pythonVersion, cmd string, args ...string) error {
 	command := pocket.Command(ctx, Name, uvArgs...)
 	command.Env = append(command.Env, "UV_PROJECT_ENVIRONMENT="+venvPath)
 
-	// Use context output for proper parallel buffering
-	out := pocket.GetOutput(ctx)
-	command.Stdout = out.Stdout
-	command.Stderr = out.Stderr
-
-	return command.Run()
-}
-
-func platformArch() string {
-	switch runtime.GOOS {
-	case pocket.Darwin:
-		if runtime.GOARCH == pocket.ARM64 {
-			return "aarch64-apple-darwin"
-		}
-		return "x86_64-apple-darwin"
-	case pocket.Linux:
-		if runtime.GOARCH == pocket.ARM64 {
-			return "aarch64-unknown-linux-gnu"
-		}
-		return "x86_64-unknown-linux-gnu"
-	case pocket.Windows:
-		return "x86_64-pc-windows-msvc"
-	default:
-		return fmt.Sprintf("%s-%s", runtime.GOARCH, runtime.GOOS)
-	}
+	return pocket.RunCommand(ctx, command)
 }
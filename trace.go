@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// traceEnv enables command tracing when set to a non-empty value. The -trace
+// flag sets it, so nested pocket runs trace their commands too.
+const traceEnv = "POK_TRACE"
+
+// traceEnabled reports whether commands are traced.
+func traceEnabled() bool {
+	return os.Getenv(traceEnv) != ""
+}
+
+// traceCommand writes cmd to w before it runs, as a shell line: the
+// directory, the environment variables pocket changed (except PATH, see
+// ./pok env) and the resolved binary with its arguments.
+func traceCommand(w io.Writer, cmd *exec.Cmd) {
+	c := newReproCommand(cmd)
+	parts := []string{"+ cd", shellQuote(c.dir), "&&"}
+	for _, e := range c.env {
+		key, value, _ := strings.Cut(e, "=")
+		parts = append(parts, key+"="+shellQuote(value))
+	}
+	for _, arg := range c.args {
+		parts = append(parts, shellQuote(arg))
+	}
+	fmt.Fprintln(w, strings.Join(parts, " "))
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestTraceCommand(t *testing.T) {
+	cmd := exec.Command("/usr/bin/go", "test", "-run", "Test Foo", "./...")
+	cmd.Dir = "/repo/services/api"
+	cmd.Env = append(PrependPath(os.Environ(), "/repo/.pocket/bin"), "POCKET_TRACE_TEST=1")
+
+	var out strings.Builder
+	traceCommand(&out, cmd)
+	want := "+ cd /repo/services/api && POCKET_TRACE_TEST=1 /usr/bin/go test -run 'Test Foo' ./...\n"
+	if got := out.String(); got != want {
+		t.Errorf("traceCommand() = %q, want %q", got, want)
+	}
+}
+
+func TestExec_Trace(t *testing.T) {
+	var stdout, stderr strings.Builder
+	ctx := NewRunContext(context.Background(), RunContextOptions{
+		Output: &Output{Stdout: &stdout, Stderr: &stderr},
+		Runner: func(*exec.Cmd) error { return nil },
+	})
+	if err := Exec(ctx, "golangci-lint", "run"); err != nil {
+		t.Fatal(err)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("expected no trace without %s, got %q", traceEnv, stderr.String())
+	}
+
+	t.Setenv(traceEnv, "1")
+	if err := Exec(ctx, "golangci-lint", "run"); err != nil {
+		t.Fatal(err)
+	}
+	if got := stderr.String(); !strings.HasPrefix(got, "+ cd ") || !strings.HasSuffix(got, "golangci-lint run\n") {
+		t.Errorf("expected the command to be traced to stderr, got %q", got)
+	}
+}
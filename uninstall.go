@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/fredrikaverpil/pocket/internal/uninstall"
+)
+
+// uninstallOptions configures the uninstall builtin task.
+type uninstallOptions struct {
+	DryRun bool `arg:"dry-run" usage:"only list what would be removed"`
+}
+
+// uninstallPocket removes .pocket, the shims, generated files and the git
+// hooks set up by hooks-install.
+func uninstallPocket(ctx context.Context) error {
+	opts := Options[uninstallOptions](ctx)
+	root := GitRoot()
+	plan, err := uninstall.Find(root)
+	if err != nil {
+		return fmt.Errorf("uninstall: %w", err)
+	}
+	if plan.Empty() {
+		Println(ctx, "Nothing to remove.")
+		return nil
+	}
+	if opts.DryRun {
+		Println(ctx, "Would remove:")
+		plan.Print(getExecContext(ctx).out.Stdout)
+		return nil
+	}
+	// The shims run pocket from .pocket/bin, and a running executable
+	// cannot be removed on Windows.
+	if runtime.GOOS == Windows {
+		return fmt.Errorf("uninstall: pocket cannot remove itself on Windows; run 'go run github.com/fredrikaverpil/pocket/cmd/pocket@latest uninstall' instead")
+	}
+	Println(ctx, "Removing:")
+	plan.Print(getExecContext(ctx).out.Stdout)
+	if err := plan.Apply(root); err != nil {
+		return fmt.Errorf("uninstall: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WithUpToDate skips the task when its outputs (see WithOutputs) are newer
+// than its inputs (see WithInputs): every output glob matches a file and no
+// input was modified after the oldest output. No state is kept, so it suits
+// generators whose outputs are kept in the tree. Deleted inputs and changed
+// options are not noticed; -no-cache runs the task anyway. Tasks without
+// both inputs and outputs are never skipped.
+//
+// Example:
+//
+//	pocket.Clone(protoGenerate,
+//		pocket.WithInputs("**/*.proto"),
+//		pocket.WithOutputs("gen/**"),
+//		pocket.WithUpToDate(),
+//	)
+func WithUpToDate() TaskOpt {
+	return func(td *TaskDef) {
+		td.upToDate = true
+	}
+}
+
+// upToDateEnabled reports whether the task is skipped when its outputs are
+// newer than its inputs. That takes WithUpToDate, WithInputs and WithOutputs.
+func (f *TaskDef) upToDateEnabled() bool {
+	return f.upToDate && len(f.inputs) > 0 && len(f.outputs) > 0 && os.Getenv(noCacheEnv) == ""
+}
+
+// checkUpToDate reports whether the outputs of the task run in ctx are newer
+// than its inputs: every output glob matches a file and no input was modified
+// after the oldest output. Files matching both count as outputs.
+func (f *TaskDef) checkUpToDate(ctx context.Context) (bool, error) {
+	var newestInput, oldestOutput time.Time
+	matched := make([]bool, len(f.outputs))
+	root := FromGitRoot(Path(ctx))
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		output := false
+		for i, glob := range f.outputs {
+			if MatchGlob(glob, rel) {
+				matched[i] = true
+				output = true
+			}
+		}
+		if !output && !matchesAnyGlob(rel, f.inputs) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch mtime := info.ModTime(); {
+		case output:
+			if oldestOutput.IsZero() || mtime.Before(oldestOutput) {
+				oldestOutput = mtime
+			}
+		case mtime.After(newestInput):
+			newestInput = mtime
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("check outputs: %w", err)
+	}
+	for _, ok := range matched {
+		if !ok {
+			return false, nil
+		}
+	}
+	return !newestInput.After(oldestOutput), nil
+}
@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// watchInterval is how often -watch polls the watched paths for changes.
+// Polling keeps pocket free of a file notification dependency and works
+// the same on every platform and file system.
+var watchInterval = 500 * time.Millisecond
+
+// watchDebounce is how long the watched files must be unchanged before a
+// change triggers a run, so that saving several files runs the task once.
+var watchDebounce = 300 * time.Millisecond
+
+// fileStamp identifies a version of a watched file.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// watcher finds changes to the files a task reads.
+type watcher struct {
+	paths   []string // directories to watch, relative to the git root
+	inputs  []string // globs of files that trigger a run (empty = all files)
+	outputs []string // globs of files written by the task, which are ignored
+}
+
+// snapshot returns the watched files with their stamps. Hidden directories
+// such as .git and .pocket are not watched.
+func (w *watcher) snapshot() (map[string]fileStamp, error) {
+	files := make(map[string]fileStamp)
+	for _, dir := range w.paths {
+		root := FromGitRoot(dir)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Files can disappear while walking, e.g. editor swap files.
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel, _ := filepath.Rel(root, path)
+			rel = filepath.ToSlash(rel)
+			if len(w.inputs) > 0 && !matchesAnyGlob(rel, w.inputs) || matchesAnyGlob(rel, w.outputs) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			files[path] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// settle waits until the watched files stop changing, e.g. while an editor
+// saves several files or a formatter rewrites them, and returns them.
+func (w *watcher) settle(ctx context.Context, files map[string]fileStamp) (map[string]fileStamp, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return files, nil
+		case <-time.After(watchDebounce):
+		}
+		next, err := w.snapshot()
+		if err != nil || maps.Equal(next, files) {
+			return next, err
+		}
+		files = next
+	}
+}
+
+// watchRun runs r, and runs it again whenever the files watched by w
+// change, until ctx is canceled. A change during a run cancels the run
+// before starting the next one. Each run starts with fresh deduplication,
+// like a new invocation.
+func watchRun(ctx context.Context, r Runnable, name string, w *watcher, out *Output, cwd string, verbose bool, plan *ConfigPlan) error {
+	last, err := w.snapshot()
+	if err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	var cancel context.CancelFunc
+	start := func() {
+		var runCtx context.Context
+		runCtx, cancel = context.WithCancel(ctx)
+		go func() { done <- runWithContext(runCtx, r, out, cwd, verbose, plan, nil) }()
+	}
+	start()
+
+	running := true
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			if running {
+				<-done
+			}
+			return ctx.Err()
+
+		case err := <-done:
+			running = false
+			cancel()
+			if err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
+				out.Printf("watch: %s failed: %v\n", name, err)
+			}
+			out.Printf("watch: waiting for changes in %s (ctrl-c to stop)\n", strings.Join(w.paths, ", "))
+
+		case <-ticker.C:
+			current, err := w.snapshot()
+			if err != nil {
+				return err
+			}
+			if maps.Equal(current, last) {
+				continue
+			}
+			if current, err = w.settle(ctx, current); err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				continue
+			}
+			last = current
+			if running {
+				cancel()
+				<-done
+				out.Printf("watch: files changed, restarting %s\n", name)
+			} else {
+				out.Printf("watch: files changed, running %s\n", name)
+			}
+			start()
+			running = true
+		}
+	}
+}
+
+// watchPaths returns the directories to watch for the task name: the paths
+// given with path=<dir>, else the paths the task runs in, else cwd.
+func watchPaths(plan *ConfigPlan, name, cwd string, paths []string) []string {
+	if len(paths) > 0 {
+		return paths
+	}
+	if resolved := taskPaths(plan, name, cwd); len(resolved) > 0 {
+		return resolved
+	}
+	if cwd == "" {
+		return []string{"."}
+	}
+	return []string{cwd}
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: MIT
+
+package pocket
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fredrikaverpil/pocket/internal/gitroot"
+)
+
+func TestWatchRun(t *testing.T) {
+	// Not parallel due to shared git root and watch intervals.
+	tmpDir := t.TempDir()
+	defer gitroot.Override(tmpDir)()
+	defer func(interval, debounce time.Duration) {
+		watchInterval, watchDebounce = interval, debounce
+	}(watchInterval, watchDebounce)
+	watchInterval, watchDebounce = 10*time.Millisecond, 20*time.Millisecond
+
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main\n")
+	write("gen.txt", "generated\n")
+
+	started := make(chan int, 10)
+	canceled := make(chan int, 10)
+	runs := 0
+	task := Task("build", "build", func(ctx context.Context) error {
+		runs++
+		run := runs
+		started <- run
+		if run == 2 {
+			// Block until a change restarts the run.
+			<-ctx.Done()
+			canceled <- run
+			return ctx.Err()
+		}
+		return nil
+	})
+	w := &watcher{paths: []string{"."}, inputs: []string{"*.go", "*.txt"}, outputs: []string{"gen.txt"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- watchRun(ctx, task, "build", w, discardOutput(), ".", false, nil) }()
+
+	wait := func(ch chan int, want int) {
+		t.Helper()
+		select {
+		case got := <-ch:
+			if got != want {
+				t.Fatalf("got run %d, want %d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for run %d", want)
+		}
+	}
+	wait(started, 1)
+
+	// Outputs don't trigger a run; inputs do.
+	write("gen.txt", "regenerated\n")
+	time.Sleep(100 * time.Millisecond)
+	write("main.go", "package main\n\nfunc main() {}\n")
+	wait(started, 2)
+
+	// A change during a run cancels it and starts the next one.
+	write("main.go", "package main\n")
+	wait(canceled, 2)
+	wait(started, 3)
+
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("watchRun() = %v, want context.Canceled", err)
+	}
+}
+
+func TestWatchPaths(t *testing.T) {
+	plan := BuildConfigPlan(Config{
+		AutoRun: RunIn(Task("test", "test", func(context.Context) error { return nil }), Include("api", "web")),
+	})
+	tests := []struct {
+		name  string
+		cwd   string
+		paths []string
+		want  []string
+	}{
+		{"task paths", ".", nil, []string{"api", "web"}},
+		{"task paths from shim", "web", nil, []string{"web"}},
+		{"path arguments", ".", []string{"api"}, []string{"api"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := watchPaths(plan, "test", tt.cwd, tt.paths)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("watchPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := watchPaths(plan, "all", "", nil); !slices.Equal(got, []string{"."}) {
+		t.Errorf("watchPaths(all) = %v, want [.]", got)
+	}
+}